@@ -0,0 +1,421 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+const (
+	// CardinalityOther is the label value used for every variable label of the series
+	// into which label value combinations over a cardinality limit are collapsed.
+	CardinalityOther = "other"
+
+	// DroppedSeriesCountName is the name, within SelfNamespace, of the counter of
+	// label value combinations that exceeded a cardinality limit.  Its MetricLabel
+	// holds the fully qualified name of the limited metric.
+	DroppedSeriesCountName = "dropped_series_count"
+)
+
+// ErrInvalidCardinalityLimit indicates that a cardinality limit was not positive.
+var ErrInvalidCardinalityLimit = errors.New("A cardinality limit must be positive")
+
+// cardinalityLimiter bounds the number of distinct label value combinations of a
+// single vector.  The first combinations, up to the limit, are admitted.  Each distinct
+// combination after that is counted once, and only a hash of it is retained.
+//
+// To bound memory, at most limit hashes of rejected combinations are retained.  Once
+// that many have been retained, each rejection of a combination that was not retained
+// is counted, so the count becomes an upper bound on the distinct combinations.
+type cardinalityLimiter struct {
+	limit   int
+	dropped prometheus.Counter
+
+	lock     sync.RWMutex
+	admitted map[string]struct{}
+	rejected map[uint64]struct{}
+	drops    int
+}
+
+func newCardinalityLimiter(limit int, dropped prometheus.Counter) *cardinalityLimiter {
+	return &cardinalityLimiter{
+		limit:    limit,
+		dropped:  dropped,
+		admitted: make(map[string]struct{}, limit),
+		rejected: make(map[uint64]struct{}),
+	}
+}
+
+// admit tests whether the given label values are within the limit, admitting them
+// if there is room.
+func (cl *cardinalityLimiter) admit(values []string) bool {
+	key := strings.Join(values, labelValuesSeparator)
+
+	cl.lock.RLock()
+	_, admitted := cl.admitted[key]
+	cl.lock.RUnlock()
+	if admitted {
+		return true
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	hash := h.Sum64()
+
+	cl.lock.Lock()
+	if _, admitted = cl.admitted[key]; !admitted && len(cl.admitted) < cl.limit {
+		cl.admitted[key] = struct{}{}
+		admitted = true
+	}
+
+	var counted bool
+	if _, seen := cl.rejected[hash]; !admitted && !seen {
+		counted = true
+		cl.drops++
+		if len(cl.rejected) < cl.limit {
+			cl.rejected[hash] = struct{}{}
+		}
+	}
+
+	cl.lock.Unlock()
+	if counted && cl.dropped != nil {
+		cl.dropped.Inc()
+	}
+
+	return admitted
+}
+
+// droppedCount returns the number of combinations that were not admitted.
+func (cl *cardinalityLimiter) droppedCount() int {
+	cl.lock.RLock()
+	defer cl.lock.RUnlock()
+	return cl.drops
+}
+
+// limitedMetric rewrites the variable labels of a collected metric as CardinalityOther
+// if its combination of values is not admitted.  The variable map holds the names of
+// the variable labels.
+func (cl *cardinalityLimiter) limitedMetric(variable map[string]bool, m *dto.Metric) {
+	values := make([]string, 0, len(variable))
+	for _, lp := range m.Label {
+		if variable[lp.GetName()] {
+			values = append(values, lp.GetValue())
+		}
+	}
+
+	if len(values) == 0 || cl.admit(values) {
+		return
+	}
+
+	labels := make([]*dto.LabelPair, len(m.Label))
+	for i, lp := range m.Label {
+		labels[i] = lp
+		if variable[lp.GetName()] {
+			other := CardinalityOther
+			labels[i] = &dto.LabelPair{Name: lp.Name, Value: &other}
+		}
+	}
+
+	m.Label = labels
+}
+
+// WithCardinalityLimit bounds the number of distinct label value combinations of each
+// vector a Factory creates.  Once a vector has exposed limit combinations, any new
+// combination is collapsed into a single series whose variable labels are all
+// CardinalityOther, and is counted once by the DroppedSeriesCountName counter.
+//
+// Like LabelTransforms, the limit is applied when metrics are collected.  This protects
+// the scraper from label explosions, but the vectors still retain every child.  Use
+// the limited vectors, e.g. NewLimitedCounterVec, to also bound memory.
+//
+// A limit that is not positive disables limiting.  This option overrides
+// Config.CardinalityLimit.
+func WithCardinalityLimit(limit int) FactoryOption {
+	return func(f *Factory) {
+		f.cardinalityLimit = limit
+	}
+}
+
+// droppedSeries returns the counter of combinations dropped from the named metric
+// by a cardinality limit.  A failure to register the counter is logged, and drops
+// are still limited.
+func (f *Factory) droppedSeries(metric string) prometheus.Counter {
+	count := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: SelfNamespace,
+			Name:      DroppedSeriesCountName,
+			Help:      "the total number of distinct label value combinations that exceeded a cardinality limit",
+		},
+		[]string{MetricLabel},
+	)
+
+	if err := ExistingCollector(&count, f.registerer.Register(count)); err != nil && f.logger != nil {
+		f.logger.Warn("Unable to register dropped series counter", zap.Error(err))
+	}
+
+	return count.WithLabelValues(metric)
+}
+
+// cardinalityFor returns the collection time cardinality limiter for the named metric.
+// If no limit applies, including when the collector already limits itself, this
+// method returns nil.
+func (f *Factory) cardinalityFor(c prometheus.Collector, name string, labelNames []string) *cardinalityLimiter {
+	if f.cardinalityLimit <= 0 || len(labelNames) == 0 {
+		return nil
+	}
+
+	if _, limited := c.(interface{ cardinalityLimited() }); limited {
+		return nil
+	}
+
+	return newCardinalityLimiter(f.cardinalityLimit, f.droppedSeries(name))
+}
+
+// limiterVec is the subset of behavior of prometheus vectors that a limited
+// vector requires.
+type limiterVec[M any] interface {
+	prometheus.Collector
+	With(prometheus.Labels) M
+	WithLabelValues(...string) M
+}
+
+// limitedVec is the generic implementation of the exported limited vectors.
+type limitedVec[M any] struct {
+	vec        limiterVec[M]
+	labelNames []string
+	other      []string
+	limiter    *cardinalityLimiter
+}
+
+func newLimitedVec[M any](vec limiterVec[M], labelNames []string, limit int, dropped prometheus.Counter) limitedVec[M] {
+	other := make([]string, len(labelNames))
+	for i := range other {
+		other[i] = CardinalityOther
+	}
+
+	return limitedVec[M]{
+		vec:        vec,
+		labelNames: append([]string{}, labelNames...),
+		other:      other,
+		limiter:    newCardinalityLimiter(limit, dropped),
+	}
+}
+
+// WithLabelValues returns the child metric for the given label values.  If the values
+// would exceed the cardinality limit, the child whose values are all CardinalityOther is
+// returned instead.  Like the prometheus vectors, this method panics if the number of
+// values does not match the number of variable labels.
+func (lv *limitedVec[M]) WithLabelValues(values ...string) M {
+	if len(values) == len(lv.labelNames) && !lv.limiter.admit(values) {
+		values = lv.other
+	}
+
+	return lv.vec.WithLabelValues(values...)
+}
+
+// With is like WithLabelValues, but accepts a map of variable label names to values.
+func (lv *limitedVec[M]) With(labels prometheus.Labels) M {
+	if len(labels) != len(lv.labelNames) {
+		return lv.vec.With(labels)
+	}
+
+	values := make([]string, len(lv.labelNames))
+	for i, name := range lv.labelNames {
+		v, ok := labels[name]
+		if !ok {
+			return lv.vec.With(labels)
+		}
+
+		values[i] = v
+	}
+
+	return lv.WithLabelValues(values...)
+}
+
+// Dropped returns the number of distinct label value combinations that have exceeded
+// the cardinality limit.  Only as many rejected combinations as the limit are remembered,
+// so once that many have been dropped, each further drop of a combination that was not
+// remembered is counted again.
+func (lv *limitedVec[M]) Dropped() int {
+	return lv.limiter.droppedCount()
+}
+
+// Describe implements prometheus.Collector.
+func (lv *limitedVec[M]) Describe(ch chan<- *prometheus.Desc) {
+	lv.vec.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (lv *limitedVec[M]) Collect(ch chan<- prometheus.Metric) {
+	lv.vec.Collect(ch)
+}
+
+// Unwrap returns the decorated vector.  CollectorAs uses this method.
+func (lv *limitedVec[M]) Unwrap() prometheus.Collector {
+	return lv.vec
+}
+
+// cardinalityLimited marks a collector that already limits its own cardinality, so
+// that a Factory does not apply its collection time limit on top of it.
+func (lv *limitedVec[M]) cardinalityLimited() {}
+
+// LimitedCounterVec is a counter vector that bounds the number of distinct label value
+// combinations it exposes.  Once the limit is reached, each new combination is collapsed
+// into the single child whose variable labels are all CardinalityOther.  That child does
+// not count towards the limit.
+//
+// Unlike WithCardinalityLimit, the limit is applied as children are obtained, so the
+// vector never allocates a child for a combination over the limit.  Children must be
+// obtained through With or WithLabelValues.  When created by a Factory that also has a
+// cardinality limit, only this vector's limit applies.
+type LimitedCounterVec struct {
+	limitedVec[prometheus.Counter]
+}
+
+// LimitedGaugeVec is the gauge analog of LimitedCounterVec.
+type LimitedGaugeVec struct {
+	limitedVec[prometheus.Gauge]
+}
+
+// LimitedObserverVec is the histogram and summary analog of LimitedCounterVec.
+type LimitedObserverVec struct {
+	limitedVec[prometheus.Observer]
+}
+
+// LimitCounterVec decorates an existing counter vector, which must have the given
+// variable labels, so that it exposes at most limit combinations of label values.
+// Combinations over the limit are reported by Dropped.
+//
+// The returned vector is not registered.  Register it instead of the decorated vector.
+func LimitCounterVec(vec *prometheus.CounterVec, limit int, labelNames ...string) (*LimitedCounterVec, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidCardinalityLimit
+	}
+
+	return &LimitedCounterVec{
+		limitedVec: newLimitedVec[prometheus.Counter](vec, labelNames, limit, nil),
+	}, nil
+}
+
+// LimitGaugeVec is the gauge analog of LimitCounterVec.
+func LimitGaugeVec(vec *prometheus.GaugeVec, limit int, labelNames ...string) (*LimitedGaugeVec, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidCardinalityLimit
+	}
+
+	return &LimitedGaugeVec{
+		limitedVec: newLimitedVec[prometheus.Gauge](vec, labelNames, limit, nil),
+	}, nil
+}
+
+// LimitObserverVec is the histogram and summary analog of LimitCounterVec.
+func LimitObserverVec(vec prometheus.ObserverVec, limit int, labelNames ...string) (*LimitedObserverVec, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidCardinalityLimit
+	}
+
+	return &LimitedObserverVec{
+		limitedVec: newLimitedVec[prometheus.Observer](vec, labelNames, limit, nil),
+	}, nil
+}
+
+// NewLimitedCounterVec creates and registers a counter vector that exposes at most limit
+// combinations of label values.  Combinations over the limit are also counted by the
+// DroppedSeriesCountName counter.
+//
+// This method returns an error if the options do not specify a name or if limit is not
+// positive.  Both namespace and subsystem are defaulted appropriately if not set in
+// the options.
+func (f *Factory) NewLimitedCounterVec(o prometheus.CounterOpts, limit int, labelNames ...string) (m *LimitedCounterVec, err error) {
+	err = f.checkLimited(o.Name, limit)
+	if err == nil {
+		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
+		f.warnOnNoHelp(o.Name, o.Help)
+
+		m = &LimitedCounterVec{
+			limitedVec: newLimitedVec[prometheus.Counter](
+				prometheus.NewCounterVec(o, labelNames), labelNames, limit,
+				f.droppedSeries(prometheus.BuildFQName(o.Namespace, o.Subsystem, o.Name)),
+			),
+		}
+
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_COUNTER, Opts: prometheus.Opts(o), LabelNames: labelNames})
+	}
+
+	if err != nil {
+		m = nil
+	}
+
+	return
+}
+
+// NewLimitedGaugeVec creates and registers a gauge vector that exposes at most limit
+// combinations of label values.  See NewLimitedCounterVec.
+func (f *Factory) NewLimitedGaugeVec(o prometheus.GaugeOpts, limit int, labelNames ...string) (m *LimitedGaugeVec, err error) {
+	err = f.checkLimited(o.Name, limit)
+	if err == nil {
+		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
+		f.warnOnNoHelp(o.Name, o.Help)
+
+		m = &LimitedGaugeVec{
+			limitedVec: newLimitedVec[prometheus.Gauge](
+				prometheus.NewGaugeVec(o, labelNames), labelNames, limit,
+				f.droppedSeries(prometheus.BuildFQName(o.Namespace, o.Subsystem, o.Name)),
+			),
+		}
+
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_GAUGE, Opts: prometheus.Opts(o), LabelNames: labelNames})
+	}
+
+	if err != nil {
+		m = nil
+	}
+
+	return
+}
+
+// NewLimitedHistogramVec creates and registers a histogram vector that exposes at most
+// limit combinations of label values.  See NewLimitedCounterVec.
+func (f *Factory) NewLimitedHistogramVec(o prometheus.HistogramOpts, limit int, labelNames ...string) (m *LimitedObserverVec, err error) {
+	err = f.checkLimited(o.Name, limit)
+	if err == nil {
+		ApplyDefaults(&o, f.defaults)
+		f.native.apply(&o)
+		o.Name = f.metricName(o.Name)
+		f.warnOnNoHelp(o.Name, o.Help)
+
+		m = &LimitedObserverVec{
+			limitedVec: newLimitedVec[prometheus.Observer](
+				prometheus.NewHistogramVec(o, labelNames), labelNames, limit,
+				f.droppedSeries(prometheus.BuildFQName(o.Namespace, o.Subsystem, o.Name)),
+			),
+		}
+
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_HISTOGRAM, Opts: histogramOpts(o), LabelNames: labelNames})
+	}
+
+	if err != nil {
+		m = nil
+	}
+
+	return
+}
+
+// checkLimited validates the name and limit of a limited vector.
+func (f *Factory) checkLimited(name string, limit int) error {
+	if limit <= 0 {
+		return ErrInvalidCardinalityLimit
+	}
+
+	return f.checkName(name)
+}
@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type CardinalityTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *CardinalityTestSuite) newFactory(opts ...FactoryOption) *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r, opts...)
+}
+
+func (suite *CardinalityTestSuite) other(labelNames ...string) prometheus.Labels {
+	l := make(prometheus.Labels, len(labelNames))
+	for _, ln := range labelNames {
+		l[ln] = CardinalityOther
+	}
+
+	return l
+}
+
+func (suite *CardinalityTestSuite) TestInvalid() {
+	f := suite.newFactory()
+
+	cv, err := f.NewLimitedCounterVec(prometheus.CounterOpts{Name: "counter"}, 0, "device")
+	suite.ErrorIs(err, ErrInvalidCardinalityLimit)
+	suite.Nil(cv)
+
+	gv, err := f.NewLimitedGaugeVec(prometheus.GaugeOpts{}, 10, "device")
+	suite.ErrorIs(err, ErrNoMetricName)
+	suite.Nil(gv)
+
+	hv, err := f.NewLimitedHistogramVec(prometheus.HistogramOpts{Name: "histogram"}, -1, "device")
+	suite.ErrorIs(err, ErrInvalidCardinalityLimit)
+	suite.Nil(hv)
+
+	_, err = LimitCounterVec(prometheus.NewCounterVec(prometheus.CounterOpts{Name: "counter"}, []string{"device"}), 0, "device")
+	suite.ErrorIs(err, ErrInvalidCardinalityLimit)
+
+	_, err = LimitGaugeVec(prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gauge"}, []string{"device"}), 0, "device")
+	suite.ErrorIs(err, ErrInvalidCardinalityLimit)
+
+	_, err = LimitObserverVec(prometheus.NewSummaryVec(prometheus.SummaryOpts{Name: "summary"}, []string{"device"}), 0, "device")
+	suite.ErrorIs(err, ErrInvalidCardinalityLimit)
+}
+
+func (suite *CardinalityTestSuite) TestCounterVec() {
+	f := suite.newFactory()
+	cv, err := f.NewLimitedCounterVec(prometheus.CounterOpts{Name: "counter", Help: "test"}, 2, "device", "code")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(cv)
+
+	cv.WithLabelValues("a", "200").Inc()
+	cv.With(prometheus.Labels{"code": "200", "device": "b"}).Inc()
+	cv.WithLabelValues("a", "200").Inc()
+	suite.Zero(cv.Dropped())
+	suite.Equal(2, testutil.CollectAndCount(cv))
+
+	cv.WithLabelValues("c", "200").Inc()
+	cv.With(prometheus.Labels{"device": "d", "code": "500"}).Inc()
+	cv.WithLabelValues("c", "200").Inc()
+	suite.Equal(2, cv.Dropped())
+	suite.Equal(3, testutil.CollectAndCount(cv))
+
+	v, err := Value(cv, prometheus.Labels{"device": "a", "code": "200"})
+	suite.NoError(err)
+	suite.Equal(2.0, v)
+
+	v, err = Value(cv, suite.other("device", "code"))
+	suite.NoError(err)
+	suite.Equal(3.0, v)
+
+	// each distinct combination is counted once
+	suite.Equal(2.0, testutil.ToFloat64(f.droppedSeries("test_counter")))
+
+	suite.Panics(func() {
+		cv.With(prometheus.Labels{"device": "a", "nosuch": "200"})
+	})
+
+	suite.Panics(func() {
+		cv.WithLabelValues("a")
+	})
+}
+
+func (suite *CardinalityTestSuite) TestGaugeVec() {
+	f := suite.newFactory()
+	gv, err := f.NewLimitedGaugeVec(prometheus.GaugeOpts{Name: "gauge", Help: "test"}, 1, "device")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(gv)
+
+	gv.WithLabelValues("a").Set(1.0)
+	gv.WithLabelValues("b").Set(2.0)
+	gv.With(prometheus.Labels{"device": "c"}).Add(3.0)
+	suite.Equal(2, gv.Dropped())
+	suite.Equal(2, testutil.CollectAndCount(gv))
+
+	v, err := Value(gv, suite.other("device"))
+	suite.NoError(err)
+	suite.Equal(5.0, v)
+	suite.Equal(2.0, testutil.ToFloat64(f.droppedSeries("test_gauge")))
+}
+
+func (suite *CardinalityTestSuite) TestHistogramVec() {
+	f := suite.newFactory()
+	hv, err := f.NewLimitedHistogramVec(prometheus.HistogramOpts{Name: "histogram", Help: "test"}, 1, "device")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(hv)
+
+	hv.WithLabelValues("a").Observe(1.0)
+	hv.WithLabelValues("b").Observe(2.0)
+	hv.With(prometheus.Labels{"device": "c"}).Observe(3.0)
+	suite.Equal(2, hv.Dropped())
+	suite.Equal(2, testutil.CollectAndCount(hv))
+}
+
+func (suite *CardinalityTestSuite) TestLimitVec() {
+	var (
+		r  = prometheus.NewPedanticRegistry()
+		cv = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "counter", Help: "test"}, []string{"device"})
+	)
+
+	limited, err := LimitCounterVec(cv, 1, "device")
+	suite.Require().NoError(err)
+	suite.Require().NoError(r.Register(limited))
+	suite.Same(cv, limited.Unwrap())
+
+	limited.WithLabelValues("a").Inc()
+	limited.WithLabelValues("b").Inc()
+	limited.WithLabelValues("c").Inc()
+	suite.Equal(2, limited.Dropped())
+	suite.Equal(2, testutil.CollectAndCount(r))
+
+	var existing *prometheus.CounterVec
+	suite.NoError(ExistingCollector(&existing, r.Register(cv)))
+	suite.Same(cv, existing)
+}
+
+func (suite *CardinalityTestSuite) testFactoryLimit(f *Factory) {
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Name: "counter", Help: "test"}, "device")
+	suite.Require().NoError(err)
+
+	c, err := f.NewCounter(prometheus.CounterOpts{Name: "unlabeled", Help: "test"})
+	suite.Require().NoError(err)
+	c.Inc()
+
+	for _, device := range []string{"a", "b", "c", "d"} {
+		cv.WithLabelValues(device).Inc()
+	}
+
+	g := prometheus.Gatherers{f.registerer.(prometheus.Gatherer)}
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+
+	values := make(map[string]float64)
+	for _, mf := range mfs {
+		if mf.GetName() != "test_counter" {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			values[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+		}
+	}
+
+	// which combinations are admitted depends on the order of collection
+	suite.Len(values, 3)
+	suite.Equal(2.0, values[CardinalityOther])
+	suite.Equal(2.0, testutil.ToFloat64(f.droppedSeries("test_counter")))
+
+	// each collection produces the same series
+	_, err = g.Gather()
+	suite.Require().NoError(err)
+	suite.Equal(2.0, testutil.ToFloat64(f.droppedSeries("test_counter")))
+	suite.Equal(1.0, testutil.ToFloat64(c))
+}
+
+func (suite *CardinalityTestSuite) TestFactoryLimit() {
+	newRegistry := func(Config) prometheus.Registerer {
+		return prometheus.NewPedanticRegistry()
+	}
+
+	suite.Run("Config", func() {
+		cfg := Config{DefaultNamespace: "test", CardinalityLimit: 2}
+		suite.testFactoryLimit(NewFactory(cfg, suite.logger, newRegistry(cfg)))
+	})
+
+	suite.Run("Option", func() {
+		cfg := Config{DefaultNamespace: "test", CardinalityLimit: 100}
+		suite.testFactoryLimit(NewFactory(cfg, suite.logger, newRegistry(cfg), WithCardinalityLimit(2)))
+	})
+
+	suite.Run("WithTransforms", func() {
+		cfg := Config{DefaultNamespace: "test"}
+		f := NewFactory(cfg, suite.logger, newRegistry(cfg),
+			WithCardinalityLimit(2),
+			WithLabelTransforms(LabelTransforms{
+				Global: map[string]LabelTransformer{
+					"device": strings.ToUpper,
+				},
+			}),
+		)
+
+		suite.testFactoryLimit(f)
+	})
+}
+
+func (suite *CardinalityTestSuite) TestBoundedRejections() {
+	dropped := prometheus.NewCounter(prometheus.CounterOpts{Name: "dropped"})
+	cl := newCardinalityLimiter(1, dropped)
+
+	suite.True(cl.admit([]string{"a"}))
+	suite.False(cl.admit([]string{"b"}))
+	suite.False(cl.admit([]string{"b"}))
+	suite.Equal(1, cl.droppedCount())
+
+	// only limit rejections are remembered, so later ones are counted each time
+	suite.False(cl.admit([]string{"c"}))
+	suite.False(cl.admit([]string{"c"}))
+	suite.Len(cl.rejected, 1)
+	suite.Equal(3, cl.droppedCount())
+	suite.Equal(3.0, testutil.ToFloat64(dropped))
+}
+
+func (suite *CardinalityTestSuite) TestLimitedVecWithFactoryLimit() {
+	cfg := Config{DefaultNamespace: "test", CardinalityLimit: 1}
+	r := prometheus.NewPedanticRegistry()
+	f := NewFactory(cfg, suite.logger, r)
+
+	cv, err := f.NewLimitedCounterVec(prometheus.CounterOpts{Name: "counter", Help: "test"}, 3, "device")
+	suite.Require().NoError(err)
+	for _, device := range []string{"a", "b", "c"} {
+		cv.WithLabelValues(device).Inc()
+	}
+
+	// only the vector's own limit applies
+	suite.Equal(3, testutil.CollectAndCount(r, "test_counter"))
+	suite.Zero(testutil.ToFloat64(f.droppedSeries("test_counter")))
+}
+
+func TestCardinality(t *testing.T) {
+	suite.Run(t, new(CardinalityTestSuite))
+}
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	// ErrNoChildName indicates that a child process was added without a name.
+	ErrNoChildName = errors.New("A child process name is required")
+
+	// ErrChildExists indicates that a child process was added with the same name
+	// as a child process that is still being collected.
+	ErrChildExists = errors.New("A child process with that name already exists")
+)
+
+// PidFn returns a function that always returns the given PID.  This is useful with
+// ChildProcesses.Add when the PID of a child is known, e.g. from os.Process.Pid.
+// To read the PID of a process from a file instead, use prometheus.NewPidFileFn.
+func PidFn(pid int) func() (int, error) {
+	return func() (int, error) {
+		return pid, nil
+	}
+}
+
+// ChildProcesses manages process collectors for child processes, such as supervised
+// helpers, whose resource usage is not reported by the collector for the process
+// itself.  Children are added as they start and removed as they stop.
+//
+// The metrics of each child are namespaced by its name, which is appended to the
+// Factory's default namespace, if any.  For example, a child named "helper" produces
+// helper_process_cpu_seconds_total.
+//
+// The collectors are registered through the Factory, so Factory.Close and
+// UnregisterOnStop unregister them as well.
+//
+// ChildProcesses is safe for concurrent use.
+type ChildProcesses struct {
+	factory *Factory
+
+	lock     sync.Mutex
+	children map[string]prometheus.Collector
+}
+
+// NewChildProcesses creates a ChildProcesses that registers collectors with this Factory.
+func (f *Factory) NewChildProcesses() *ChildProcesses {
+	return &ChildProcesses{
+		factory:  f,
+		children: make(map[string]prometheus.Collector),
+	}
+}
+
+// namespace returns the metrics namespace for the given child.
+func (cp *ChildProcesses) namespace(name string) string {
+	return prometheus.BuildFQName(cp.factory.DefaultNamespace(), "", name)
+}
+
+// Add registers a process collector for the named child, using pidFn to find
+// the child's PID at each collection.  Once a child has exited, its collector
+// produces no metrics, but it remains registered until it is removed.
+//
+// ErrChildExists is returned if a child with the same name has been added and
+// not removed.
+func (cp *ChildProcesses) Add(name string, pidFn func() (int, error)) error {
+	if len(name) == 0 {
+		return ErrNoChildName
+	}
+
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	if _, exists := cp.children[name]; exists {
+		return ErrChildExists
+	}
+
+	ns := cp.namespace(name)
+	c := collectors.NewProcessCollector(collectors.ProcessCollectorOpts{
+		PidFn:     pidFn,
+		Namespace: ns,
+	})
+
+	err := cp.factory.register(c, RegistrationEvent{
+		Type: dto.MetricType_UNTYPED,
+		Opts: prometheus.Opts{Namespace: ns},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	cp.children[name] = c
+	return nil
+}
+
+// AddPid is like Add, but for a child whose PID is known.
+func (cp *ChildProcesses) AddPid(name string, pid int) error {
+	return cp.Add(name, PidFn(pid))
+}
+
+// Remove unregisters the collector for the named child.  This method returns
+// false if no such child has been added.
+func (cp *ChildProcesses) Remove(name string) bool {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	c, exists := cp.children[name]
+	if exists {
+		delete(cp.children, name)
+		cp.factory.unregisterCollector(c)
+	}
+
+	return exists
+}
+
+// RemoveAll unregisters the collectors for all children, e.g. when the
+// supervisor shuts down.
+func (cp *ChildProcesses) RemoveAll() {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	for name, c := range cp.children {
+		delete(cp.children, name)
+		cp.factory.unregisterCollector(c)
+	}
+}
+
+// Names returns the sorted names of the children currently being collected.
+func (cp *ChildProcesses) Names() []string {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	names := make([]string, 0, len(cp.children))
+	for name := range cp.children {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
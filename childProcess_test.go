@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/stretchr/testify/suite"
+)
+
+type ChildProcessesTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *ChildProcessesTestSuite) newFactory(namespace string) (*Factory, prometheus.Gatherer) {
+	cfg := Config{
+		DefaultNamespace:          namespace,
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := New(cfg)
+	suite.Require().NoError(err)
+	return NewFactory(cfg, suite.logger, r), g
+}
+
+// families returns the names of the gathered metric families with the given prefix.
+func (suite *ChildProcessesTestSuite) families(g prometheus.Gatherer, prefix string) (names []string) {
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+	for _, mf := range mfs {
+		if strings.HasPrefix(mf.GetName(), prefix) {
+			names = append(names, mf.GetName())
+		}
+	}
+
+	return
+}
+
+func (suite *ChildProcessesTestSuite) TestPidFn() {
+	pid, err := PidFn(123)()
+	suite.NoError(err)
+	suite.Equal(123, pid)
+}
+
+func (suite *ChildProcessesTestSuite) TestAddRemove() {
+	f, g := suite.newFactory("")
+	cp := f.NewChildProcesses()
+	suite.Empty(cp.Names())
+
+	suite.Require().NoError(cp.AddPid("helper", os.Getpid()))
+	suite.Require().NoError(cp.Add("worker", PidFn(os.Getpid())))
+	suite.Equal([]string{"helper", "worker"}, cp.Names())
+	if runtime.GOOS == "linux" {
+		suite.Contains(suite.families(g, "helper_"), "helper_process_cpu_seconds_total")
+		suite.Contains(suite.families(g, "worker_"), "worker_process_cpu_seconds_total")
+	}
+
+	suite.ErrorIs(cp.AddPid("helper", os.Getpid()), ErrChildExists)
+
+	suite.True(cp.Remove("helper"))
+	suite.False(cp.Remove("helper"))
+	suite.Equal([]string{"worker"}, cp.Names())
+	suite.Empty(suite.families(g, "helper_"))
+
+	// a removed child can be added again, e.g. when it is restarted
+	suite.NoError(cp.AddPid("helper", os.Getpid()))
+	suite.Equal([]string{"helper", "worker"}, cp.Names())
+
+	cp.RemoveAll()
+	suite.Empty(cp.Names())
+	suite.Empty(suite.families(g, "helper_"))
+	suite.Empty(suite.families(g, "worker_"))
+	suite.Empty(f.registered.entries)
+}
+
+func (suite *ChildProcessesTestSuite) TestClose() {
+	f, _ := suite.newFactory("")
+	cp := f.NewChildProcesses()
+	suite.Require().NoError(cp.AddPid("helper", os.Getpid()))
+	suite.Require().NoError(f.Close())
+
+	// closing the Factory unregisters the child's collector
+	suite.NoError(f.registerer.Register(
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{Namespace: "helper"}),
+	))
+}
+
+func (suite *ChildProcessesTestSuite) TestDefaultNamespace() {
+	if runtime.GOOS != "linux" {
+		suite.T().Skip("process metrics are only collected on linux")
+	}
+
+	f, g := suite.newFactory("test")
+	cp := f.NewChildProcesses()
+	suite.Require().NoError(cp.AddPid("helper", os.Getpid()))
+	suite.Contains(suite.families(g, "test_helper_"), "test_helper_process_cpu_seconds_total")
+}
+
+func (suite *ChildProcessesTestSuite) TestExited() {
+	f, g := suite.newFactory("")
+	cp := f.NewChildProcesses()
+	suite.Require().NoError(cp.Add("helper", func() (int, error) {
+		return 0, errors.New("the child has exited")
+	}))
+
+	suite.Empty(suite.families(g, "helper_"))
+	suite.Equal([]string{"helper"}, cp.Names())
+}
+
+func (suite *ChildProcessesTestSuite) TestNoName() {
+	f, _ := suite.newFactory("")
+	suite.ErrorIs(f.NewChildProcesses().AddPid("", os.Getpid()), ErrNoChildName)
+}
+
+func (suite *ChildProcessesTestSuite) TestRegisterError() {
+	f, _ := suite.newFactory("")
+	suite.Require().NoError(f.registerer.Register(
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{Namespace: "helper"}),
+	))
+
+	cp := f.NewChildProcesses()
+	suite.NotNil(AsAlreadyRegisteredError(cp.AddPid("helper", os.Getpid())))
+	suite.Empty(cp.Names())
+}
+
+func TestChildProcesses(t *testing.T) {
+	suite.Run(t, new(ChildProcessesTestSuite))
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import "time"
+
+// Clock is the source of the current time for duration metrics and other
+// time-based helpers.  Provide emits a SystemClock as this component.  Tests
+// can replace it so that a single fake clock drives every duration:
+//
+//	fx.Decorate(
+//	  func(touchstone.Clock) touchstone.Clock {
+//	    return touchstone.ClockFunc(fakeNow)
+//	  },
+//	)
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// SystemClock is the Clock that reports the actual system time.
+type SystemClock struct{}
+
+// Now returns time.Now().
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ClockFunc is a closure type that implements Clock.
+type ClockFunc func() time.Time
+
+// Now invokes this closure.
+func (cf ClockFunc) Now() time.Time {
+	return cf()
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+type ClockTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *ClockTestSuite) TestSystemClock() {
+	before := time.Now()
+	now := SystemClock{}.Now()
+	suite.False(now.Before(before))
+}
+
+func (suite *ClockTestSuite) TestClockFunc() {
+	expected := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	suite.Equal(expected, ClockFunc(func() time.Time { return expected }).Now())
+}
+
+func (suite *ClockTestSuite) TestFactoryClock() {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+
+	suite.Run("Default", func() {
+		f := NewFactory(Config{}, suite.logger, r)
+		suite.Equal(SystemClock{}, f.Clock())
+	})
+
+	suite.Run("Custom", func() {
+		expected := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+		f := NewFactory(Config{}, suite.logger, r, WithClock(
+			ClockFunc(func() time.Time { return expected }),
+		))
+
+		suite.Equal(expected, f.Clock().Now())
+	})
+}
+
+func (suite *ClockTestSuite) TestProvide() {
+	suite.Run("Default", func() {
+		var (
+			c Clock
+			f *Factory
+		)
+
+		app := suite.newTestApp(
+			Provide(),
+			fx.Populate(&c, &f),
+		)
+
+		app.RequireStart()
+		suite.Equal(SystemClock{}, c)
+		suite.Equal(SystemClock{}, f.Clock())
+		app.RequireStop()
+	})
+
+	suite.Run("Decorated", func() {
+		var (
+			current = time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+			fake    = ClockFunc(func() time.Time { return current })
+			f       *Factory
+		)
+
+		app := suite.newTestApp(
+			Provide(),
+			fx.Decorate(func(Clock) Clock { return fake }),
+			fx.Populate(&f),
+		)
+
+		app.RequireStart()
+		suite.Equal(current, f.Clock().Now())
+
+		// time-based helpers created by the factory use the decorated clock
+		rc, err := f.NewRollingCounter(prometheus.GaugeOpts{Name: "recent_requests"}, time.Minute, 6)
+		suite.Require().NoError(err)
+		rc.Add(5.0)
+		suite.Equal(5.0, rc.Sum())
+
+		current = current.Add(2 * time.Minute)
+		suite.Zero(rc.Sum())
+		app.RequireStop()
+	})
+}
+
+func TestClock(t *testing.T) {
+	suite.Run(t, new(ClockTestSuite))
+}
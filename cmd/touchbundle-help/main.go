@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Command touchbundle-help generates touchbundle.HelpProvider implementations from
+// the doc comments of bundle struct fields, so that the comments become the default
+// help text of the metrics.  It is intended to be run with go generate:
+//
+//	//go:generate go run github.com/xmidt-org/touchstone/cmd/touchbundle-help -type MyBundle
+//
+// Usage:
+//
+//	touchbundle-help -type T1[,T2...] [-output file] [dir]
+//
+// The package in dir, which defaults to the current directory, is parsed without being
+// compiled.  Each named type must be a struct.  Exported fields with a doc comment, or
+// failing that a line comment, are included.  Test files are ignored.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultOutput is the output file name used when no -output flag is given.
+const defaultOutput = "bundle_help.go"
+
+type options struct {
+	dir    string
+	types  []string
+	output string
+}
+
+func parseOptions(args []string, output io.Writer) (o options, err error) {
+	var types string
+	fs := flag.NewFlagSet("touchbundle-help", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&types, "type", "", "the comma-delimited names of the bundle types (required)")
+	fs.StringVar(&o.output, "output", defaultOutput, "the name of the generated file, relative to the package directory")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: touchbundle-help -type T1[,T2...] [flags] [dir]")
+		fs.PrintDefaults()
+	}
+
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	for _, t := range strings.Split(types, ",") {
+		if t = strings.TrimSpace(t); len(t) > 0 {
+			o.types = append(o.types, t)
+		}
+	}
+
+	switch {
+	case len(o.types) == 0:
+		fs.Usage()
+		err = errors.New("at least one type is required")
+
+	case fs.NArg() > 1:
+		fs.Usage()
+		err = errors.New("at most one directory is allowed")
+
+	case fs.NArg() == 1:
+		o.dir = fs.Arg(0)
+
+	default:
+		o.dir = "."
+	}
+
+	return
+}
+
+// bundleHelp is the help text extracted for a single bundle type.
+type bundleHelp struct {
+	typeName string
+	fields   [][2]string
+}
+
+// parsePackage parses the non-test Go files in a directory, returning the package name
+// and the struct types declared in the package.
+func parsePackage(dir, output string) (pkg string, structs map[string]*ast.StructType, err error) {
+	var paths []string
+	paths, err = filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return
+	}
+
+	sort.Strings(paths)
+	fset := token.NewFileSet()
+	structs = make(map[string]*ast.StructType)
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == output {
+			continue
+		}
+
+		var file *ast.File
+		file, err = parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return
+		}
+
+		pkg = file.Name.Name
+		ast.Inspect(file, func(n ast.Node) bool {
+			if ts, ok := n.(*ast.TypeSpec); ok {
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					structs[ts.Name.Name] = st
+				}
+			}
+
+			return true
+		})
+	}
+
+	if len(pkg) == 0 {
+		err = fmt.Errorf("no Go files in %s", dir)
+	}
+
+	return
+}
+
+// commentText collapses a comment into a single line of help text.
+func commentText(cg *ast.CommentGroup) string {
+	return strings.Join(strings.Fields(cg.Text()), " ")
+}
+
+// extract gathers the help text for the fields of a struct type.
+func extract(typeName string, st *ast.StructType) (bh bundleHelp) {
+	bh.typeName = typeName
+	for _, field := range st.Fields.List {
+		cg := field.Doc
+		if cg == nil {
+			cg = field.Comment
+		}
+
+		if cg == nil {
+			continue
+		}
+
+		help := commentText(cg)
+		for _, name := range field.Names {
+			if name.IsExported() && len(help) > 0 {
+				bh.fields = append(bh.fields, [2]string{name.Name, help})
+			}
+		}
+	}
+
+	return
+}
+
+// generate writes the formatted source for the HelpProvider implementations.
+func generate(pkg string, bundles []bundleHelp) ([]byte, error) {
+	var src bytes.Buffer
+	fmt.Fprintln(&src, "// Code generated by touchbundle-help; DO NOT EDIT.")
+	fmt.Fprintln(&src)
+	fmt.Fprintf(&src, "package %s\n", pkg)
+	for _, bh := range bundles {
+		fmt.Fprintln(&src)
+		fmt.Fprintf(&src, "// BundleHelp returns the default help text for the metric fields of %s.\n", bh.typeName)
+		fmt.Fprintf(&src, "// It implements touchbundle.HelpProvider.\n")
+		fmt.Fprintf(&src, "func (%s) BundleHelp() map[string]string {\n", bh.typeName)
+		fmt.Fprintln(&src, "return map[string]string{")
+		for _, f := range bh.fields {
+			fmt.Fprintf(&src, "%s: %s,\n", strconv.Quote(f[0]), strconv.Quote(f[1]))
+		}
+
+		fmt.Fprintln(&src, "}")
+		fmt.Fprintln(&src, "}")
+	}
+
+	return format.Source(src.Bytes())
+}
+
+func run(args []string, stderr io.Writer) error {
+	o, err := parseOptions(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	pkg, structs, err := parsePackage(o.dir, o.output)
+	if err != nil {
+		return err
+	}
+
+	bundles := make([]bundleHelp, 0, len(o.types))
+	for _, t := range o.types {
+		st, ok := structs[t]
+		if !ok {
+			return fmt.Errorf("no struct type named %s in %s", t, o.dir)
+		}
+
+		bundles = append(bundles, extract(t, st))
+	}
+
+	src, err := generate(pkg, bundles)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(o.dir, o.output), src, 0644) //nolint:gosec
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const testSource = `package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Bundle is not part of the help.
+type Bundle struct {
+	// Requests is the total number
+	// of requests.
+	Requests prometheus.Counter
+
+	InFlight prometheus.Gauge // the "current" requests
+
+	// unexported is ignored
+	unexported prometheus.Gauge
+
+	NoComment prometheus.Counter
+}
+
+type Other struct {
+	// A, B share a comment
+	A, B prometheus.Counter
+}
+`
+
+type RunSuite struct {
+	suite.Suite
+}
+
+func (suite *RunSuite) writePackage() string {
+	dir := suite.T().TempDir()
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "bundle.go"), []byte(testSource), 0600))
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "bundle_test.go"), []byte("this does not parse"), 0600))
+	return dir
+}
+
+func (suite *RunSuite) run(args ...string) error {
+	var stderr bytes.Buffer
+	return run(args, &stderr)
+}
+
+func (suite *RunSuite) TestInvalidArguments() {
+	suite.Error(suite.run())
+	suite.Error(suite.run("-type", " , "))
+	suite.Error(suite.run("-type", "Bundle", "a", "b"))
+	suite.Error(suite.run("-nosuch"))
+}
+
+func (suite *RunSuite) TestNoSuchType() {
+	suite.Error(suite.run("-type", "NoSuch", suite.writePackage()))
+}
+
+func (suite *RunSuite) TestNoPackage() {
+	suite.Error(suite.run("-type", "Bundle", suite.T().TempDir()))
+}
+
+func (suite *RunSuite) TestGenerate() {
+	dir := suite.writePackage()
+	suite.Require().NoError(suite.run("-type", "Bundle,Other", "-output", "help.go", dir))
+
+	generated, err := os.ReadFile(filepath.Join(dir, "help.go"))
+	suite.Require().NoError(err)
+
+	expected := `// Code generated by touchbundle-help; DO NOT EDIT.
+
+package metrics
+
+// BundleHelp returns the default help text for the metric fields of Bundle.
+// It implements touchbundle.HelpProvider.
+func (Bundle) BundleHelp() map[string]string {
+	return map[string]string{
+		"Requests": "Requests is the total number of requests.",
+		"InFlight": "the \"current\" requests",
+	}
+}
+
+// BundleHelp returns the default help text for the metric fields of Other.
+// It implements touchbundle.HelpProvider.
+func (Other) BundleHelp() map[string]string {
+	return map[string]string{
+		"A": "A, B share a comment",
+		"B": "A, B share a comment",
+	}
+}
+`
+
+	suite.Equal(expected, string(generated))
+
+	// regenerating ignores the previous output
+	suite.Require().NoError(suite.run("-type", "Bundle,Other", "-output", "help.go", dir))
+}
+
+func TestRun(t *testing.T) {
+	suite.Run(t, new(RunSuite))
+}
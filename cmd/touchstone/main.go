@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Command touchstone inspects a live prometheus registry, either by fetching
+// a metrics endpoint or by reading a text exposition dump.  It reports series
+// counts per metric, the top cardinality offenders, and lint violations.
+//
+// Usage:
+//
+//	touchstone [-top N] [-lint] [-all] <url|file|->
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/xmidt-org/touchstone/touchinspect"
+)
+
+// acceptHeader requests the text exposition format from metrics endpoints.
+const acceptHeader = `text/plain;version=0.0.4;q=1,*/*;q=0.1`
+
+// errLintProblems is returned when linting was requested and problems were found.
+var errLintProblems = errors.New("lint problems found")
+
+type options struct {
+	source  string
+	top     int
+	lint    bool
+	all     bool
+	timeout time.Duration
+}
+
+func parseOptions(args []string, output io.Writer) (o options, err error) {
+	fs := flag.NewFlagSet("touchstone", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.IntVar(&o.top, "top", 10, "the number of top cardinality offenders to report")
+	fs.BoolVar(&o.lint, "lint", false, "report lint violations and exit nonzero if any are found")
+	fs.BoolVar(&o.all, "all", false, "report series counts for every metric")
+	fs.DurationVar(&o.timeout, "timeout", 10*time.Second, "the timeout for fetching a metrics endpoint")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: touchstone [flags] <url|file|->")
+		fs.PrintDefaults()
+	}
+
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		err = errors.New("exactly one source is required")
+		return
+	}
+
+	o.source = fs.Arg(0)
+	return
+}
+
+// load produces a Snapshot from the source, which can be an http(s) URL,
+// a file containing a text exposition, or "-" for stdin.
+func load(o options, stdin io.Reader) (touchinspect.Snapshot, error) {
+	switch {
+	case o.source == "-":
+		return touchinspect.DecodeText(stdin)
+
+	case strings.HasPrefix(o.source, "http://") || strings.HasPrefix(o.source, "https://"):
+		return fetch(o)
+
+	default:
+		f, err := os.Open(o.source)
+		if err != nil {
+			return touchinspect.Snapshot{}, err
+		}
+
+		defer f.Close()
+		return touchinspect.DecodeText(f)
+	}
+}
+
+func fetch(o options) (touchinspect.Snapshot, error) {
+	request, err := http.NewRequest(http.MethodGet, o.source, nil)
+	if err != nil {
+		return touchinspect.Snapshot{}, err
+	}
+
+	request.Header.Set("Accept", acceptHeader)
+	client := &http.Client{Timeout: o.timeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return touchinspect.Snapshot{}, err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return touchinspect.Snapshot{}, fmt.Errorf("unexpected status from %s: %s", o.source, response.Status)
+	}
+
+	return touchinspect.Decode(response.Body, expfmt.ResponseFormat(response.Header))
+}
+
+func writeCounts(w io.Writer, title string, counts []touchinspect.FamilyCount) {
+	fmt.Fprintf(w, "%s\n", title)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METRIC\tTYPE\tSERIES\tSAMPLES")
+	for _, c := range counts {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", c.Name, strings.ToLower(c.Type.String()), c.Series, c.Samples)
+	}
+
+	tw.Flush()
+	fmt.Fprintln(w)
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	o, err := parseOptions(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	s, err := load(o, stdin)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "%d metrics, %d series\n\n", len(s.Families), s.TotalSeries())
+	if o.all {
+		writeCounts(stdout, "Series counts:", s.SeriesCounts())
+	}
+
+	if o.top > 0 {
+		writeCounts(stdout, "Top cardinality offenders:", s.TopCardinality(o.top))
+	}
+
+	if o.lint {
+		problems, err := s.Lint()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "%d lint problems\n", len(problems))
+		for _, p := range problems {
+			fmt.Fprintf(stdout, "  %s: %s\n", p.Metric, p.Text)
+		}
+
+		if len(problems) > 0 {
+			return errLintProblems
+		}
+	}
+
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+
+		os.Exit(1)
+	}
+}
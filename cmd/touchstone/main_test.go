@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/suite"
+)
+
+const testExposition = `
+# HELP requests_total the number of requests
+# TYPE requests_total counter
+requests_total{code="200"} 10
+requests_total{code="500"} 1
+# TYPE badGauge gauge
+badGauge 1
+`
+
+type RunSuite struct {
+	suite.Suite
+}
+
+func (suite *RunSuite) run(stdin string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	err := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func (suite *RunSuite) TestNoSource() {
+	_, err := suite.run("")
+	suite.Error(err)
+}
+
+func (suite *RunSuite) TestStdin() {
+	output, err := suite.run(testExposition, "-all", "-")
+	suite.Require().NoError(err)
+	suite.Contains(output, "2 metrics, 3 series")
+	suite.Contains(output, "requests_total")
+	suite.Contains(output, "badGauge")
+}
+
+func (suite *RunSuite) TestLint() {
+	output, err := suite.run(testExposition, "-lint", "-top", "0", "-")
+	suite.ErrorIs(err, errLintProblems)
+	suite.Contains(output, "badGauge")
+}
+
+func (suite *RunSuite) TestFile() {
+	path := filepath.Join(suite.T().TempDir(), "metrics.txt")
+	suite.Require().NoError(os.WriteFile(path, []byte(testExposition), 0600))
+
+	output, err := suite.run("", path)
+	suite.Require().NoError(err)
+	suite.Contains(output, "Top cardinality offenders")
+
+	_, err = suite.run("", filepath.Join(suite.T().TempDir(), "nosuch.txt"))
+	suite.Error(err)
+}
+
+func (suite *RunSuite) TestURL() {
+	r := prometheus.NewPedanticRegistry()
+	cv := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total", Help: "test"}, []string{"label"})
+	suite.Require().NoError(r.Register(cv))
+	cv.WithLabelValues("a").Inc()
+
+	server := httptest.NewServer(promhttp.HandlerFor(r, promhttp.HandlerOpts{}))
+	defer server.Close()
+
+	output, err := suite.run("", "-lint", server.URL)
+	suite.Require().NoError(err)
+	suite.Contains(output, "1 metrics, 1 series")
+	suite.Contains(output, "0 lint problems")
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	_, err = suite.run("", notFound.URL)
+	suite.Error(err)
+}
+
+func TestRun(t *testing.T) {
+	suite.Run(t, new(RunSuite))
+}
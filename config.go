@@ -4,10 +4,42 @@
 package touchstone
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/common/model"
+	"go.uber.org/multierr"
 )
 
+// ConfigError describes a configuration field with an invalid value.  Validate methods
+// return one ConfigError for each problem, combined with go.uber.org/multierr.
+type ConfigError struct {
+	// Field is the name of the invalid field, e.g. DefaultNamespace or Routes[1].Namespace.
+	Field string
+
+	// Message describes the problem and how to correct it.
+	Message string
+
+	// Cause is the underlying error, if any.
+	Cause error
+}
+
+// Unwrap returns the underlying error, if any.
+func (ce *ConfigError) Unwrap() error {
+	return ce.Cause
+}
+
+// Error satisfies the error interface.
+func (ce *ConfigError) Error() string {
+	if ce.Cause != nil {
+		return fmt.Sprintf("Invalid %s: %s: %s", ce.Field, ce.Message, ce.Cause)
+	}
+
+	return fmt.Sprintf("Invalid %s: %s", ce.Field, ce.Message)
+}
+
 // Config defines the configuration options for bootstrapping a prometheus-based metrics environment.
 type Config struct {
 	// DefaultNamespace is the prometheus namespace to apply when a metric has no namespace.
@@ -38,11 +70,210 @@ type Config struct {
 	//
 	// See: https://pkg.go.dev/github.com/prometheus/client_golang/prometheus/collectors#NewBuildInfoCollector
 	DisableBuildInfoCollector bool `json:"disableBuildInfoCollector" yaml:"disableBuildInfoCollector"`
+
+	// SuppressMetrics is a list of metric names that are filtered out of the go, process,
+	// and build info collectors.  Each entry may be an exact name or a glob pattern
+	// as understood by path.Match, e.g. "go_gc_*".  This allows individual metrics of the
+	// default collectors to be dropped without disabling an entire collector.
+	//
+	// This list does not apply to metrics created by the application.
+	SuppressMetrics []string `json:"suppressMetrics" yaml:"suppressMetrics"`
+
+	// VerifyOnStart controls whether metrics are gathered once when the enclosing
+	// fx.App starts.  Any problems that only show up at gather time, such as inconsistent
+	// help or label names between collectors, then fail application startup instead
+	// of the first scrape.
+	//
+	// This field is only used by Provide.  See Verify.
+	VerifyOnStart bool `json:"verifyOnStart" yaml:"verifyOnStart"`
+
+	// DisableCreatedTimestamps strips created timestamps from the metrics created by
+	// the Factory.  By default, counters, histograms, and summaries carry the time
+	// they were created, which allows Prometheus to detect counter resets precisely.
+	//
+	// See WithCreatedTimestamps.
+	DisableCreatedTimestamps bool `json:"disableCreatedTimestamps" yaml:"disableCreatedTimestamps"`
+
+	// Routes send metrics in selected namespaces to alternate registerers, e.g. for
+	// split scrape endpoints, without changing metric definitions.  Each route refers
+	// to a NamedRegisterer supplied to the enclosing fx.App.  See SupplyRegisterer.
+	//
+	// This field is only used by Provide.  See WithRouter.
+	Routes []Route `json:"routes" yaml:"routes"`
+
+	// Resource describes OpenTelemetry resource attributes, e.g. service.name, that are
+	// applied as constant labels to every metric, including those of the go, process,
+	// and build info collectors.  By default, no such labels are applied.
+	Resource Resource `json:"resource" yaml:"resource"`
+
+	// ConstLabels are constant labels, e.g. region or role, applied to every metric
+	// alongside any Resource labels.  A name cannot also be produced by the Resource.
+	// By default, no such labels are applied.
+	ConstLabels map[string]string `json:"constLabels" yaml:"constLabels"`
+
+	// LabelValueLimit bounds the length of the label values of metrics created by the
+	// Factory, truncating or hashing longer values.  By default, label values are not limited.
+	LabelValueLimit LabelValueLimit `json:"labelValueLimit" yaml:"labelValueLimit"`
+
+	// NativeHistograms holds the default native histogram parameters for histograms
+	// created by the Factory.  Setting NativeHistograms.BucketFactor opts every such
+	// histogram in to native histograms.  By default, only classic buckets are used.
+	NativeHistograms NativeHistograms `json:"nativeHistograms" yaml:"nativeHistograms"`
+
+	// CardinalityLimit bounds the number of distinct label value combinations each vector
+	// created by the Factory exposes.  Combinations over the limit are collapsed into a
+	// single series.  See WithCardinalityLimit.  By default, vectors are not limited.
+	CardinalityLimit int `json:"cardinalityLimit" yaml:"cardinalityLimit"`
+}
+
+// Validate checks this Config for values that cannot work, returning a ConfigError
+// for each problem.  The namespace and subsystem must be usable in metric names, each
+// SuppressMetrics entry and route namespace must be a well formed pattern, each route
+// must name a registerer, the Resource must produce valid labels, each ConstLabels name
+// must be valid and distinct from the Resource labels, the LabelValueLimit and
+// NativeHistograms must be usable, and the CardinalityLimit cannot be negative.
+//
+// New and Provide call this method, so that problems are reported when the metrics
+// environment is created rather than when metrics are first registered.
+func (cfg Config) Validate() error {
+	return multierr.Combine(
+		cfg.validateNames(),
+		cfg.validatePatterns(),
+		cfg.validateLabels(),
+		cfg.validateLimits(),
+	)
+}
+
+// validateNames checks the DefaultNamespace and DefaultSubsystem.
+func (cfg Config) validateNames() (err error) {
+	if len(cfg.DefaultNamespace) > 0 && !model.IsValidLegacyMetricName(cfg.DefaultNamespace) {
+		err = multierr.Append(err, &ConfigError{
+			Field:   "DefaultNamespace",
+			Message: fmt.Sprintf("%q must contain only letters, digits, underscores, and colons and must not start with a digit", cfg.DefaultNamespace),
+		})
+	}
+
+	if len(cfg.DefaultSubsystem) > 0 && !model.IsValidLegacyMetricName(cfg.DefaultSubsystem) {
+		err = multierr.Append(err, &ConfigError{
+			Field:   "DefaultSubsystem",
+			Message: fmt.Sprintf("%q must contain only letters, digits, underscores, and colons and must not start with a digit", cfg.DefaultSubsystem),
+		})
+	}
+
+	return
+}
+
+// validatePatterns checks the SuppressMetrics patterns and the Routes.
+func (cfg Config) validatePatterns() (err error) {
+	for i, p := range cfg.SuppressMetrics {
+		if patternErr := checkPatterns([]string{p}); patternErr != nil {
+			err = multierr.Append(err, &ConfigError{
+				Field:   fmt.Sprintf("SuppressMetrics[%d]", i),
+				Message: "must be a metric name or a glob pattern as understood by path.Match",
+				Cause:   patternErr,
+			})
+		}
+	}
+
+	for i, route := range cfg.Routes {
+		if patternErr := checkPatterns([]string{route.Namespace}); patternErr != nil {
+			err = multierr.Append(err, &ConfigError{
+				Field:   fmt.Sprintf("Routes[%d].Namespace", i),
+				Message: "must be a namespace or a glob pattern as understood by path.Match",
+				Cause:   patternErr,
+			})
+		}
+
+		if len(route.Registerer) == 0 {
+			err = multierr.Append(err, &ConfigError{
+				Field:   fmt.Sprintf("Routes[%d].Registerer", i),
+				Message: "must name a registerer supplied to the application, e.g. with SupplyRegisterer",
+			})
+		}
+	}
+
+	return
+}
+
+// validateLabels checks the Resource and the ConstLabels, which must not repeat
+// any of the Resource labels.
+func (cfg Config) validateLabels() (err error) {
+	resourceLabels, resourceErr := cfg.Resource.Labels()
+	if resourceErr != nil {
+		err = multierr.Append(err, &ConfigError{
+			Field:   "Resource",
+			Message: "must describe well formed resource attributes",
+			Cause:   resourceErr,
+		})
+	}
+
+	names := make([]string, 0, len(cfg.ConstLabels))
+	for name := range cfg.ConstLabels {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		switch _, exists := resourceLabels[name]; {
+		case !model.LabelName(name).IsValidLegacy():
+			err = multierr.Append(err, &ConfigError{
+				Field:   fmt.Sprintf("ConstLabels[%s]", name),
+				Message: fmt.Sprintf("%q must contain only letters, digits, and underscores and must not start with a digit", name),
+			})
+
+		case exists:
+			err = multierr.Append(err, &ConfigError{
+				Field:   fmt.Sprintf("ConstLabels[%s]", name),
+				Message: "is already applied by the Resource",
+			})
+		}
+	}
+
+	return
+}
+
+// validateLimits checks the LabelValueLimit, NativeHistograms, and CardinalityLimit.
+func (cfg Config) validateLimits() (err error) {
+	if limitErr := cfg.LabelValueLimit.Validate(); limitErr != nil {
+		err = multierr.Append(err, &ConfigError{
+			Field:   "LabelValueLimit",
+			Message: "must have a nonnegative size and a policy of truncate or hash",
+			Cause:   limitErr,
+		})
+	}
+
+	if nativeErr := cfg.NativeHistograms.Validate(); nativeErr != nil {
+		err = multierr.Append(err, &ConfigError{
+			Field:   "NativeHistograms",
+			Message: "must have a BucketFactor greater than 1, if set, and no negative values",
+			Cause:   nativeErr,
+		})
+	}
+
+	if cfg.CardinalityLimit < 0 {
+		err = multierr.Append(err, &ConfigError{
+			Field:   "CardinalityLimit",
+			Message: "cannot be negative",
+		})
+	}
+
+	return
 }
 
 // New bootstraps a prometheus registry given a Config instance.  Note that the
 // returned Registerer may be decorated to arbitrary depth.
+//
+// The Config is checked with Validate first, and any problems are returned.
 func New(cfg Config) (g prometheus.Gatherer, r prometheus.Registerer, err error) {
+	if err = cfg.Validate(); err != nil {
+		return
+	}
+
+	resourceLabels, err := cfg.Resource.Labels()
+	if err != nil {
+		return
+	}
+
 	var pr *prometheus.Registry
 	if cfg.Pedantic {
 		pr = prometheus.NewPedanticRegistry()
@@ -50,12 +281,32 @@ func New(cfg Config) (g prometheus.Gatherer, r prometheus.Registerer, err error)
 		pr = prometheus.NewRegistry()
 	}
 
+	for name, value := range cfg.ConstLabels {
+		resourceLabels[name] = value
+	}
+
+	var wrapped prometheus.Registerer = pr
+	if len(resourceLabels) > 0 {
+		wrapped = prometheus.WrapRegistererWith(resourceLabels, pr)
+	}
+
+	register := func(c prometheus.Collector) error {
+		if len(cfg.SuppressMetrics) > 0 {
+			c = suppressingCollector{
+				collector: c,
+				patterns:  cfg.SuppressMetrics,
+			}
+		}
+
+		return wrapped.Register(c)
+	}
+
 	if !cfg.DisableGoCollector {
-		err = pr.Register(collectors.NewGoCollector())
+		err = register(collectors.NewGoCollector())
 	}
 
 	if err == nil && !cfg.DisableProcessCollector {
-		err = pr.Register(
+		err = register(
 			collectors.NewProcessCollector(
 				collectors.ProcessCollectorOpts{
 					Namespace: cfg.DefaultNamespace,
@@ -65,12 +316,12 @@ func New(cfg Config) (g prometheus.Gatherer, r prometheus.Registerer, err error)
 	}
 
 	if err == nil && !cfg.DisableBuildInfoCollector {
-		err = pr.Register(collectors.NewBuildInfoCollector())
+		err = register(collectors.NewBuildInfoCollector())
 	}
 
 	if err == nil {
 		g = pr
-		r = pr
+		r = wrapped
 	}
 
 	return
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/multierr"
+)
+
+const (
+	// ConfigValueLabel is the label that holds the configured value of a gauge
+	// created with NewConfigEnum.
+	ConfigValueLabel = "value"
+)
+
+// configGaugeOpts produces the options for a gauge that exposes a configuration value.
+// The constLabels are copied, as they may be modified.
+func configGaugeOpts(name string, constLabels prometheus.Labels) prometheus.GaugeOpts {
+	o := prometheus.GaugeOpts{
+		Name: name,
+		Help: fmt.Sprintf("the configured value of %s", name),
+	}
+
+	if len(constLabels) > 0 {
+		o.ConstLabels = make(prometheus.Labels, len(constLabels)+1)
+		for k, v := range constLabels {
+			o.ConstLabels[k] = v
+		}
+	}
+
+	return o
+}
+
+// NewConfigGauge creates and registers a gauge that exposes a configuration value,
+// such as a timeout or a limit.  Exposing configuration allows alerting on
+// misconfiguration.  The gauge is set to the value before it is returned, and may be
+// updated later, e.g. when configuration is reloaded.
+//
+// The constLabels are optional, and the gauge's help is generated from its name.
+// Both namespace and subsystem are defaulted as with NewGauge.
+func (f *Factory) NewConfigGauge(name string, value float64, constLabels prometheus.Labels) (prometheus.Gauge, error) {
+	g, err := f.NewGauge(configGaugeOpts(name, constLabels))
+	if err != nil {
+		return nil, err
+	}
+
+	g.Set(value)
+	return g, nil
+}
+
+// NewConfigFlag creates and registers a gauge that exposes a feature flag or other
+// boolean configuration value.  The gauge has the value 1 if enabled is true, and 0
+// otherwise.  See NewConfigGauge.
+func (f *Factory) NewConfigFlag(name string, enabled bool, constLabels prometheus.Labels) (prometheus.Gauge, error) {
+	var value float64
+	if enabled {
+		value = 1.0
+	}
+
+	return f.NewConfigGauge(name, value, constLabels)
+}
+
+// NewConfigEnum creates and registers a gauge that exposes a configuration value
+// chosen from a set of strings, e.g. a mode or a strategy.  The value is held in the
+// ConfigValueLabel, which overrides any constLabel of the same name, and the gauge has
+// the value 1.  See NewConfigGauge.
+//
+// Since the value is a constant label, a changed value requires a new gauge.
+func (f *Factory) NewConfigEnum(name, value string, constLabels prometheus.Labels) (prometheus.Gauge, error) {
+	o := configGaugeOpts(name, constLabels)
+	if o.ConstLabels == nil {
+		o.ConstLabels = make(prometheus.Labels, 1)
+	}
+
+	o.ConstLabels[ConfigValueLabel] = value
+	g, err := f.NewGauge(o)
+	if err != nil {
+		return nil, err
+	}
+
+	g.Set(1.0)
+	return g, nil
+}
+
+// NewConfigGauges creates and registers a gauge for each configuration value in the
+// given map, keyed by name.  Each gauge has the same constLabels.  See NewConfigGauge.
+//
+// Gauges are created in name order, and a failure to create one gauge does not
+// prevent the creation of the others.  The returned map holds the gauges that were
+// created, and the returned error aggregates all failures.
+func (f *Factory) NewConfigGauges(values map[string]float64, constLabels prometheus.Labels) (map[string]prometheus.Gauge, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var (
+		gauges = make(map[string]prometheus.Gauge, len(values))
+		err    error
+	)
+
+	for _, name := range names {
+		g, gErr := f.NewConfigGauge(name, values[name], constLabels)
+		if gErr == nil {
+			gauges[name] = g
+		} else {
+			multierr.AppendInto(&err, gErr)
+		}
+	}
+
+	return gauges, err
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/multierr"
+)
+
+type ConfigGaugeTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *ConfigGaugeTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r)
+}
+
+func (suite *ConfigGaugeTestSuite) TestNewConfigGauge() {
+	f := suite.newFactory()
+	constLabels := prometheus.Labels{"server": "main"}
+	g, err := f.NewConfigGauge("request_timeout_seconds", 30.0, constLabels)
+	suite.Require().NoError(err)
+	suite.Equal(30.0, testutil.ToFloat64(g))
+
+	desc := g.Desc().String()
+	suite.Contains(desc, `"test_request_timeout_seconds"`)
+	suite.Contains(desc, `"the configured value of request_timeout_seconds"`)
+	suite.Contains(desc, `server="main"`)
+
+	g.Set(60.0)
+	suite.Equal(60.0, testutil.ToFloat64(g))
+
+	_, err = f.NewConfigGauge("request_timeout_seconds", 30.0, constLabels)
+	suite.Error(err)
+
+	_, err = f.NewConfigGauge("", 30.0, nil)
+	suite.Error(err)
+}
+
+func (suite *ConfigGaugeTestSuite) TestNewConfigFlag() {
+	f := suite.newFactory()
+	enabled, err := f.NewConfigFlag("feature_enabled", true, nil)
+	suite.Require().NoError(err)
+	suite.Equal(1.0, testutil.ToFloat64(enabled))
+
+	disabled, err := f.NewConfigFlag("other_feature_enabled", false, nil)
+	suite.Require().NoError(err)
+	suite.Zero(testutil.ToFloat64(disabled))
+}
+
+func (suite *ConfigGaugeTestSuite) TestNewConfigEnum() {
+	var (
+		f           = suite.newFactory()
+		constLabels = prometheus.Labels{ConfigValueLabel: "overridden"}
+	)
+
+	g, err := f.NewConfigEnum("balancer_strategy", "round_robin", constLabels)
+	suite.Require().NoError(err)
+	suite.Equal(1.0, testutil.ToFloat64(g))
+	suite.Contains(g.Desc().String(), `value="round_robin"`)
+
+	// the caller's labels are not modified
+	suite.Equal(prometheus.Labels{ConfigValueLabel: "overridden"}, constLabels)
+
+	_, err = f.NewConfigEnum("", "round_robin", nil)
+	suite.Error(err)
+}
+
+func (suite *ConfigGaugeTestSuite) TestNewConfigGauges() {
+	f := suite.newFactory()
+	gauges, err := f.NewConfigGauges(
+		map[string]float64{
+			"max_connections": 100.0,
+			"queue_size":      1000.0,
+		},
+		nil,
+	)
+
+	suite.Require().NoError(err)
+	suite.Require().Len(gauges, 2)
+	suite.Equal(100.0, testutil.ToFloat64(gauges["max_connections"]))
+	suite.Equal(1000.0, testutil.ToFloat64(gauges["queue_size"]))
+
+	gauges, err = f.NewConfigGauges(
+		map[string]float64{
+			"max_connections": 100.0, // duplicate
+			"retries":         3.0,
+			"":                1.0, // invalid
+		},
+		nil,
+	)
+
+	suite.Len(multierr.Errors(err), 2)
+	suite.Require().Len(gauges, 1)
+	suite.Equal(3.0, testutil.ToFloat64(gauges["retries"]))
+}
+
+func TestConfigGauge(t *testing.T) {
+	suite.Run(t, new(ConfigGaugeTestSuite))
+}
@@ -4,10 +4,13 @@
 package touchstone
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/multierr"
 )
 
 type NewTestSuite struct {
@@ -74,6 +77,207 @@ func (suite *NewTestSuite) TestDisableStandardCollectors() {
 	)
 }
 
+func (suite *NewTestSuite) TestSuppressMetrics() {
+	g, _, err := New(Config{
+		Pedantic:        true,
+		SuppressMetrics: []string{"go_gc_*", "go_goroutines", "go_build_info"},
+	})
+
+	suite.Require().NoError(err)
+	families, err := g.Gather()
+	suite.Require().NoError(err)
+
+	var sawGo bool
+	for _, mf := range families {
+		name := mf.GetName()
+		suite.False(strings.HasPrefix(name, "go_gc_"), "metric %s should have been suppressed", name)
+		suite.NotEqual("go_goroutines", name)
+		suite.NotEqual("go_build_info", name)
+		sawGo = sawGo || strings.HasPrefix(name, "go_")
+	}
+
+	suite.True(sawGo, "unsuppressed go metrics should still be gathered")
+}
+
+func (suite *NewTestSuite) TestSuppressMetricsInvalidPattern() {
+	_, _, err := New(Config{SuppressMetrics: []string{"go_["}})
+	suite.Error(err)
+}
+
+func (suite *NewTestSuite) TestResource() {
+	g, r, err := New(Config{
+		Resource: Resource{
+			Attributes: map[string]string{
+				AttributeServiceName:       "api",
+				AttributeServiceInstanceID: "i-1",
+			},
+		},
+	})
+
+	suite.Require().NoError(err)
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_counter", Help: "test"})
+	suite.Require().NoError(r.Register(c))
+
+	families, err := g.Gather()
+	suite.Require().NoError(err)
+	suite.NotEmpty(families)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string)
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+
+			suite.Equal("api", labels["service_name"], mf.GetName())
+			suite.Equal("i-1", labels["service_instance_id"], mf.GetName())
+		}
+	}
+
+	suite.Run("Invalid", func() {
+		suite.T().Setenv(EnvResourceAttributes, "service.name")
+		_, _, err := New(Config{Resource: Resource{FromEnvironment: true}})
+		suite.ErrorIs(err, ErrInvalidResourceAttributes)
+	})
+}
+
+func (suite *NewTestSuite) TestConstLabels() {
+	g, r, err := New(Config{
+		Resource: Resource{
+			Attributes: map[string]string{
+				AttributeServiceName: "api",
+			},
+		},
+		ConstLabels: map[string]string{
+			"region": "east",
+			"role":   "edge",
+		},
+	})
+
+	suite.Require().NoError(err)
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_counter", Help: "test"})
+	suite.Require().NoError(r.Register(c))
+
+	families, err := g.Gather()
+	suite.Require().NoError(err)
+	suite.NotEmpty(families)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string)
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+
+			suite.Equal("api", labels["service_name"], mf.GetName())
+			suite.Equal("east", labels["region"], mf.GetName())
+			suite.Equal("edge", labels["role"], mf.GetName())
+		}
+	}
+}
+
 func TestNew(t *testing.T) {
 	suite.Run(t, new(NewTestSuite))
 }
+
+type ConfigValidateTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ConfigValidateTestSuite) TestValid() {
+	suite.NoError(Config{}.Validate())
+	suite.NoError(Config{
+		DefaultNamespace: "acme",
+		DefaultSubsystem: "api",
+		SuppressMetrics:  []string{"go_gc_*"},
+		Routes:           []Route{{Namespace: "debug_*", Registerer: "debug"}},
+	}.Validate())
+}
+
+func (suite *ConfigValidateTestSuite) TestInvalid() {
+	testCases := []struct {
+		name   string
+		cfg    Config
+		fields []string
+	}{
+		{
+			name:   "Namespace",
+			cfg:    Config{DefaultNamespace: "acme-corp"},
+			fields: []string{"DefaultNamespace"},
+		},
+		{
+			name:   "Subsystem",
+			cfg:    Config{DefaultSubsystem: "1api"},
+			fields: []string{"DefaultSubsystem"},
+		},
+		{
+			name:   "SuppressMetrics",
+			cfg:    Config{SuppressMetrics: []string{"go_gc_*", "go_["}},
+			fields: []string{"SuppressMetrics[1]"},
+		},
+		{
+			name:   "Routes",
+			cfg:    Config{Routes: []Route{{Namespace: "debug_["}, {Namespace: "debug", Registerer: "debug"}}},
+			fields: []string{"Routes[0].Namespace", "Routes[0].Registerer"},
+		},
+		{
+			name:   "LabelValueLimit",
+			cfg:    Config{LabelValueLimit: LabelValueLimit{MaxBytes: 64, Policy: "drop"}},
+			fields: []string{"LabelValueLimit"},
+		},
+		{
+			name:   "NativeHistograms",
+			cfg:    Config{NativeHistograms: NativeHistograms{BucketFactor: 0.5}},
+			fields: []string{"NativeHistograms"},
+		},
+		{
+			name: "ConstLabels",
+			cfg: Config{
+				Resource:    Resource{Attributes: map[string]string{AttributeServiceName: "api"}},
+				ConstLabels: map[string]string{"region": "east", "service_name": "api", "1role": "edge"},
+			},
+			fields: []string{"ConstLabels[1role]", "ConstLabels[service_name]"},
+		},
+		{
+			name:   "CardinalityLimit",
+			cfg:    Config{CardinalityLimit: -1},
+			fields: []string{"CardinalityLimit"},
+		},
+		{
+			name:   "Several",
+			cfg:    Config{DefaultNamespace: "a b", DefaultSubsystem: "c d"},
+			fields: []string{"DefaultNamespace", "DefaultSubsystem"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.name, func() {
+			err := testCase.cfg.Validate()
+			suite.Require().Error(err)
+			errs := multierr.Errors(err)
+			suite.Require().Len(errs, len(testCase.fields))
+			for i, field := range testCase.fields {
+				var ce *ConfigError
+				suite.Require().ErrorAs(errs[i], &ce)
+				suite.Equal(field, ce.Field)
+				suite.Contains(ce.Error(), field)
+			}
+
+			_, _, err = New(testCase.cfg)
+			suite.Error(err)
+		})
+	}
+}
+
+func (suite *ConfigValidateTestSuite) TestCause() {
+	suite.T().Setenv(EnvResourceAttributes, "service.name")
+	err := Config{Resource: Resource{FromEnvironment: true}}.Validate()
+	suite.ErrorIs(err, ErrInvalidResourceAttributes)
+
+	var ce *ConfigError
+	suite.Require().ErrorAs(err, &ce)
+	suite.Equal("Resource", ce.Field)
+	suite.Contains(ce.Error(), ce.Message)
+}
+
+func TestConfigValidate(t *testing.T) {
+	suite.Run(t, new(ConfigValidateTestSuite))
+}
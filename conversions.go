@@ -19,6 +19,9 @@ var (
 // Collector.  This function returns true if target was set, false to indicate no conversion
 // was possitble.
 //
+// If c cannot be assigned to target and c has an Unwrap() prometheus.Collector
+// method, the unwrapped collector is tried in turn.
+//
 // As it was inspired by errors.As, this function panics in similar situations where
 // errors.As would panic:
 //
@@ -46,6 +49,9 @@ func CollectorAs(c prometheus.Collector, target interface{}) bool {
 	assignable := cvalue.Type().AssignableTo(tElem.Type())
 	if assignable {
 		tElem.Set(cvalue)
+	} else if u, ok := c.(interface{ Unwrap() prometheus.Collector }); ok {
+		// decorators, such as those that transform labels, expose the original collector
+		assignable = CollectorAs(u.Unwrap(), target)
 	}
 
 	return assignable
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WithCreatedTimestamps controls the created timestamps of the metrics a Factory creates.
+// Prometheus uses created timestamps to detect counter resets precisely, rather than
+// inferring them from decreasing values.
+//
+// The prometheus client already stamps counters, histograms, and summaries with the
+// time they were created.  When enabled, which is the default, a Factory also stamps
+// the counters returned by NewCounterFunc with the time, according to the Factory's
+// Clock, that they were created.  When disabled, the Factory strips created timestamps
+// from every metric it registers, e.g. for downstream systems that mishandle them.
+//
+// NewFactory uses Config.DisableCreatedTimestamps as the default for this option.
+func WithCreatedTimestamps(enabled bool) FactoryOption {
+	return func(f *Factory) {
+		f.noCreated = !enabled
+	}
+}
+
+// createdCounterFunc is a prometheus.CounterFunc that reports a created timestamp.
+type createdCounterFunc struct {
+	prometheus.CounterFunc
+	created *timestamppb.Timestamp
+}
+
+func newCreatedCounterFunc(cf prometheus.CounterFunc, created time.Time) prometheus.CounterFunc {
+	return &createdCounterFunc{
+		CounterFunc: cf,
+		created:     timestamppb.New(created),
+	}
+}
+
+func (ccf *createdCounterFunc) Write(out *dto.Metric) error {
+	err := ccf.CounterFunc.Write(out)
+	if err == nil && out.Counter != nil && out.Counter.CreatedTimestamp == nil {
+		out.Counter.CreatedTimestamp = ccf.created
+	}
+
+	return err
+}
+
+// Collect sends this decorated metric rather than the embedded CounterFunc.
+func (ccf *createdCounterFunc) Collect(ch chan<- prometheus.Metric) {
+	ch <- ccf
+}
+
+// noCreatedCollector decorates a collector so that none of its metrics have
+// created timestamps.
+type noCreatedCollector struct {
+	collector prometheus.Collector
+}
+
+// Unwrap returns the decorated collector.
+func (ncc noCreatedCollector) Unwrap() prometheus.Collector {
+	return ncc.collector
+}
+
+func (ncc noCreatedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ncc.collector.Describe(ch)
+}
+
+func (ncc noCreatedCollector) Collect(ch chan<- prometheus.Metric) {
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		ncc.collector.Collect(metrics)
+		close(metrics)
+	}()
+
+	for m := range metrics {
+		ch <- noCreatedMetric{Metric: m}
+	}
+}
+
+// noCreatedMetric is a metric whose created timestamp is removed when written.
+type noCreatedMetric struct {
+	prometheus.Metric
+}
+
+func (ncm noCreatedMetric) Write(out *dto.Metric) error {
+	err := ncm.Metric.Write(out)
+	if out.Counter != nil {
+		out.Counter.CreatedTimestamp = nil
+	}
+
+	if out.Histogram != nil {
+		out.Histogram.CreatedTimestamp = nil
+	}
+
+	if out.Summary != nil {
+		out.Summary.CreatedTimestamp = nil
+	}
+
+	return err
+}
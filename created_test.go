@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type CreatedTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *CreatedTestSuite) newFactory(cfg Config, opts ...FactoryOption) (prometheus.Gatherer, *Factory) {
+	g, r, err := New(Config{
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	})
+
+	suite.Require().NoError(err)
+	return g, NewFactory(cfg, suite.logger, r, opts...)
+}
+
+// created gathers the created timestamp of the first series of each metric family.
+func (suite *CreatedTestSuite) created(g prometheus.Gatherer) map[string]*time.Time {
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+
+	created := make(map[string]*time.Time, len(mfs))
+	for _, mf := range mfs {
+		var (
+			m  = mf.GetMetric()[0]
+			ct *timestamppb.Timestamp
+		)
+
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			ct = m.GetCounter().GetCreatedTimestamp()
+
+		case dto.MetricType_HISTOGRAM:
+			ct = m.GetHistogram().GetCreatedTimestamp()
+
+		case dto.MetricType_SUMMARY:
+			ct = m.GetSummary().GetCreatedTimestamp()
+		}
+
+		created[mf.GetName()] = nil
+		if ct != nil {
+			t := ct.AsTime()
+			created[mf.GetName()] = &t
+		}
+	}
+
+	return created
+}
+
+func (suite *CreatedTestSuite) createMetrics(f *Factory) {
+	_, err := f.NewCounter(prometheus.CounterOpts{Name: "counter", Help: "counter"})
+	suite.Require().NoError(err)
+
+	_, err = f.NewCounterFunc(prometheus.CounterOpts{Name: "counter_func", Help: "counter func"}, func() float64 { return 1.0 })
+	suite.Require().NoError(err)
+
+	h, err := f.NewHistogram(prometheus.HistogramOpts{Name: "histogram", Help: "histogram"})
+	suite.Require().NoError(err)
+	h.Observe(1.0)
+
+	s, err := f.NewSummary(prometheus.SummaryOpts{Name: "summary", Help: "summary"})
+	suite.Require().NoError(err)
+	s.Observe(1.0)
+}
+
+func (suite *CreatedTestSuite) TestEnabled() {
+	var (
+		now  = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+		g, f = suite.newFactory(Config{}, WithClock(ClockFunc(func() time.Time { return now })))
+	)
+
+	suite.createMetrics(f)
+	created := suite.created(g)
+	suite.Len(created, 4)
+	for name, ct := range created {
+		suite.NotNil(ct, name)
+	}
+
+	suite.Require().NotNil(created["counter_func"])
+	suite.Equal(now, *created["counter_func"])
+}
+
+func (suite *CreatedTestSuite) TestDisabled() {
+	suite.Run("Config", func() {
+		g, f := suite.newFactory(Config{DisableCreatedTimestamps: true})
+		suite.createMetrics(f)
+		created := suite.created(g)
+		suite.Len(created, 4)
+		for name, ct := range created {
+			suite.Nil(ct, name)
+		}
+	})
+
+	suite.Run("Option", func() {
+		g, f := suite.newFactory(Config{}, WithCreatedTimestamps(false))
+		suite.createMetrics(f)
+		for name, ct := range suite.created(g) {
+			suite.Nil(ct, name)
+		}
+	})
+
+	suite.Run("OptionOverridesConfig", func() {
+		g, f := suite.newFactory(Config{DisableCreatedTimestamps: true}, WithCreatedTimestamps(true))
+		suite.createMetrics(f)
+		for name, ct := range suite.created(g) {
+			suite.NotNil(ct, name)
+		}
+	})
+}
+
+func (suite *CreatedTestSuite) TestExistingCollector() {
+	_, f := suite.newFactory(Config{}, WithCreatedTimestamps(false))
+	first, err := f.NewCounterVec(prometheus.CounterOpts{Name: "counter", Help: "counter"}, "label")
+	suite.Require().NoError(err)
+
+	second, err := f.NewCounterVec(prometheus.CounterOpts{Name: "counter", Help: "counter"}, "label")
+	suite.Require().NoError(ExistingCollector(&second, err))
+	suite.Same(first, second)
+}
+
+func (suite *CreatedTestSuite) TestLabelTransforms() {
+	g, f := suite.newFactory(Config{}, WithLabelTransforms(LabelTransforms{
+		Global: map[string]LabelTransformer{"device": RedactLabel("")},
+	}))
+
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Name: "counter", Help: "counter"}, "device")
+	suite.Require().NoError(err)
+	cv.WithLabelValues("a").Inc()
+	cv.WithLabelValues("b").Inc()
+
+	// the merged series keeps a created timestamp
+	suite.NotNil(suite.created(g)["counter"])
+}
+
+func TestCreated(t *testing.T) {
+	suite.Run(t, new(CreatedTestSuite))
+}
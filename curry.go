@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// NewCurriedCounterVec creates and registers a counter vector with the full set of
+// label names, then returns the view curried with the given labels.  This is the
+// typical way of sharing a single metric across several instances of a component,
+// where each instance is distinguished by its curried labels, e.g. a server name.
+//
+// If an equivalent vector has already been registered, that vector is curried
+// instead.  This allows each instance to call this function with the same options.
+//
+// This function is equivalent to Factory.NewCurriedCounterVec, but it will work with
+// any MetricFactory.
+func NewCurriedCounterVec(f MetricFactory, o prometheus.CounterOpts, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
+	cv, err := f.NewCounterVec(o, labelNames...)
+	err = ExistingCollector(&cv, err)
+	if err == nil {
+		cv, err = cv.CurryWith(curry)
+	}
+
+	return cv, err
+}
+
+// NewCurriedGaugeVec is the gauge analog of NewCurriedCounterVec.  When the curried
+// labels include every label name, use GetMetricWith(nil) on the returned vector
+// to obtain the single prometheus.Gauge.
+func NewCurriedGaugeVec(f MetricFactory, o prometheus.GaugeOpts, labelNames []string, curry prometheus.Labels) (*prometheus.GaugeVec, error) {
+	gv, err := f.NewGaugeVec(o, labelNames...)
+	err = ExistingCollector(&gv, err)
+	if err == nil {
+		gv, err = gv.CurryWith(curry)
+	}
+
+	return gv, err
+}
+
+// NewCurriedObserverVec is the histogram and summary analog of NewCurriedCounterVec.
+// As with MetricFactory.NewObserverVec, the type of o determines the type of metric
+// created.
+func NewCurriedObserverVec(f MetricFactory, o interface{}, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+	ov, err := f.NewObserverVec(o, labelNames...)
+	err = ExistingCollector(&ov, err)
+	if err == nil {
+		ov, err = ov.CurryWith(curry)
+	}
+
+	return ov, err
+}
+
+// NewCurriedCounterVec creates and registers a counter vector, returning the view curried
+// with the given labels.  See the package-level NewCurriedCounterVec.
+func (f *Factory) NewCurriedCounterVec(o prometheus.CounterOpts, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
+	return NewCurriedCounterVec(f, o, labelNames, curry)
+}
+
+// NewCurriedGaugeVec creates and registers a gauge vector, returning the view curried
+// with the given labels.  See the package-level NewCurriedGaugeVec.
+func (f *Factory) NewCurriedGaugeVec(o prometheus.GaugeOpts, labelNames []string, curry prometheus.Labels) (*prometheus.GaugeVec, error) {
+	return NewCurriedGaugeVec(f, o, labelNames, curry)
+}
+
+// NewCurriedObserverVec creates and registers a histogram or summary vector, returning
+// the view curried with the given labels.  See the package-level NewCurriedObserverVec.
+func (f *Factory) NewCurriedObserverVec(o interface{}, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+	return NewCurriedObserverVec(f, o, labelNames, curry)
+}
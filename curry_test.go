@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type CurryTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *CurryTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{}, suite.logger, r)
+}
+
+func (suite *CurryTestSuite) TestNewCurriedCounterVec() {
+	f := suite.newFactory()
+	o := prometheus.CounterOpts{Name: "test_counter", Help: "test"}
+
+	first, err := f.NewCurriedCounterVec(o, []string{"instance", "code"}, prometheus.Labels{"instance": "first"})
+	suite.Require().NoError(err)
+	second, err := f.NewCurriedCounterVec(o, []string{"instance", "code"}, prometheus.Labels{"instance": "second"})
+	suite.Require().NoError(err)
+
+	first.WithLabelValues("200").Inc()
+	second.WithLabelValues("200").Add(2.0)
+	suite.Equal(1.0, testutil.ToFloat64(first.WithLabelValues("200")))
+	suite.Equal(2.0, testutil.ToFloat64(second.WithLabelValues("200")))
+
+	_, err = f.NewCurriedCounterVec(o, []string{"instance", "code"}, prometheus.Labels{"nosuch": "value"})
+	suite.Error(err)
+
+	_, err = f.NewCurriedCounterVec(prometheus.CounterOpts{}, []string{"instance"}, nil)
+	suite.ErrorIs(err, ErrNoMetricName)
+}
+
+func (suite *CurryTestSuite) TestNewCurriedGaugeVec() {
+	f := suite.newFactory()
+	o := prometheus.GaugeOpts{Name: "test_gauge", Help: "test"}
+
+	gv, err := f.NewCurriedGaugeVec(o, []string{"instance"}, prometheus.Labels{"instance": "first"})
+	suite.Require().NoError(err)
+
+	g, err := gv.GetMetricWith(nil)
+	suite.Require().NoError(err)
+	g.Set(5.0)
+	suite.Equal(5.0, testutil.ToFloat64(g))
+
+	_, err = f.NewCurriedGaugeVec(prometheus.GaugeOpts{}, []string{"instance"}, nil)
+	suite.ErrorIs(err, ErrNoMetricName)
+}
+
+func (suite *CurryTestSuite) TestNewCurriedObserverVec() {
+	f := suite.newFactory()
+	o := prometheus.HistogramOpts{Name: "test_histogram", Help: "test"}
+
+	first, err := f.NewCurriedObserverVec(o, []string{"instance", "code"}, prometheus.Labels{"instance": "first"})
+	suite.Require().NoError(err)
+	second, err := f.NewCurriedObserverVec(o, []string{"instance", "code"}, prometheus.Labels{"instance": "second"})
+	suite.Require().NoError(err)
+
+	first.WithLabelValues("200").Observe(1.0)
+	second.WithLabelValues("200").Observe(1.0)
+	suite.Equal(2, testutil.CollectAndCount(first))
+
+	_, err = f.NewCurriedObserverVec(prometheus.SummaryOpts{}, []string{"instance"}, nil)
+	suite.ErrorIs(err, ErrNoMetricName)
+}
+
+func TestCurry(t *testing.T) {
+	suite.Run(t, new(CurryTestSuite))
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// PartialDeleter is implemented by vectors that can delete children by a subset of
+// their labels.  All prometheus vectors, e.g. *prometheus.CounterVec, implement this
+// interface, as do the expiring vectors created by a Factory.
+//
+// A prometheus.ObserverVec does not expose this method, so it must be converted to its
+// concrete type, e.g. *prometheus.HistogramVec, before use with DeleteWhere.
+type PartialDeleter interface {
+	DeletePartialMatch(prometheus.Labels) int
+}
+
+// DeleteWhere deletes every child of vec whose labels include all of the partial labels,
+// returning the number of children deleted.  This is the typical way to clean up after
+// an entity such as a tenant or device is removed, without having to track every label
+// combination that was used with that entity.
+//
+// An empty partial deletes nothing.  prometheus vectors treat an empty set of labels
+// as matching every child, and that is rarely intended for cleanup.  Use Reset to
+// delete every child of a vector.
+func DeleteWhere(vec PartialDeleter, partial prometheus.Labels) int {
+	if len(partial) == 0 {
+		return 0
+	}
+
+	return vec.DeletePartialMatch(partial)
+}
+
+// DeleteAllWhere applies DeleteWhere to each vector, returning the total number of
+// children deleted.  This allows an entity's series to be removed from all the metrics
+// that use it in one call.
+func DeleteAllWhere(partial prometheus.Labels, vecs ...PartialDeleter) (deleted int) {
+	for _, vec := range vecs {
+		deleted += DeleteWhere(vec, partial)
+	}
+
+	return
+}
+
+// matchesPartial tests if the label values, in the order of labelNames, include
+// every label in partial.  A label in partial that is not in labelNames never matches.
+func matchesPartial(labelNames, values []string, partial prometheus.Labels) bool {
+	for name, value := range partial {
+		found := false
+		for i, n := range labelNames {
+			if n == name {
+				found = i < len(values) && values[i] == value
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type DeleteWhereTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *DeleteWhereTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r)
+}
+
+func (suite *DeleteWhereTestSuite) TestDeleteWhere() {
+	cv := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test"}, []string{"tenant", "device"})
+	cv.WithLabelValues("a", "1").Inc()
+	cv.WithLabelValues("a", "2").Inc()
+	cv.WithLabelValues("b", "1").Inc()
+
+	suite.Zero(DeleteWhere(cv, nil), "an empty partial should delete nothing")
+	suite.Zero(DeleteWhere(cv, prometheus.Labels{"nosuch": "a"}))
+	suite.Zero(DeleteWhere(cv, prometheus.Labels{"tenant": "c"}))
+	suite.Equal(3, testutil.CollectAndCount(cv))
+
+	suite.Equal(2, DeleteWhere(cv, prometheus.Labels{"tenant": "a"}))
+	suite.Equal(1, testutil.CollectAndCount(cv))
+
+	_, err := Value(cv, prometheus.Labels{"tenant": "b", "device": "1"})
+	suite.NoError(err)
+}
+
+func (suite *DeleteWhereTestSuite) TestDeleteAllWhere() {
+	var (
+		cv = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "counter"}, []string{"tenant", "code"})
+		hv = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "histogram"}, []string{"method", "tenant"})
+	)
+
+	cv.WithLabelValues("a", "200").Inc()
+	cv.WithLabelValues("a", "500").Inc()
+	cv.WithLabelValues("b", "200").Inc()
+	hv.WithLabelValues("GET", "a").Observe(1.0)
+	hv.WithLabelValues("GET", "b").Observe(1.0)
+
+	suite.Equal(3, DeleteAllWhere(prometheus.Labels{"tenant": "a"}, cv, hv))
+	suite.Equal(1, testutil.CollectAndCount(cv))
+	suite.Equal(1, testutil.CollectAndCount(hv))
+}
+
+func (suite *DeleteWhereTestSuite) TestExpiring() {
+	f := suite.newFactory()
+	gv, err := f.NewExpiringGaugeVec(prometheus.GaugeOpts{Name: "gauge", Help: "test"}, time.Hour, "tenant", "device")
+	suite.Require().NoError(err)
+
+	gv.WithLabelValues("a", "1").Set(1.0)
+	gv.WithLabelValues("a", "2").Set(1.0)
+	gv.WithLabelValues("b", "1").Set(1.0)
+
+	suite.Equal(2, DeleteWhere(gv, prometheus.Labels{"tenant": "a"}))
+	suite.Equal(1, gv.Len(), "the expiring bookkeeping should be updated")
+
+	suite.Equal(1, DeleteWhere(gv, prometheus.Labels{"tenant": "b", "device": "1"}))
+	suite.Zero(gv.Len())
+	suite.Zero(testutil.CollectAndCount(gv))
+}
+
+func TestDeleteWhere(t *testing.T) {
+	suite.Run(t, new(DeleteWhereTestSuite))
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// DelimitedFormat is the prometheus protobuf exposition format, in which each metric
+// family is a varint length-delimited protobuf message.  This format is compact and
+// lossless, which makes it suitable for relaying metrics over transports other than HTTP.
+var DelimitedFormat = expfmt.NewFormat(expfmt.TypeProtoDelim)
+
+// WriteDelimited gathers metrics and writes them to the given writer in DelimitedFormat.
+// Any families successfully gathered are written even if the gatherer returns an
+// error, in which case that error is returned after writing.
+func WriteDelimited(w io.Writer, g prometheus.Gatherer) error {
+	mfs, gatherErr := g.Gather()
+	enc := expfmt.NewEncoder(w, DelimitedFormat)
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+
+	return gatherErr
+}
+
+// ReadDelimited reads metric families in DelimitedFormat until the end of the given
+// reader, as produced by WriteDelimited.
+func ReadDelimited(r io.Reader) (mfs []*dto.MetricFamily, err error) {
+	dec := expfmt.NewDecoder(r, DelimitedFormat)
+	for {
+		mf := new(dto.MetricFamily)
+		err = dec.Decode(mf)
+		if errors.Is(err, io.EOF) {
+			return mfs, nil
+		} else if err != nil {
+			return
+		}
+
+		mfs = append(mfs, mf)
+	}
+}
+
+// DelimitedGatherer returns a prometheus.Gatherer that reads metric families in
+// DelimitedFormat from the given data each time it is invoked.  This is useful to
+// compare relayed metrics with prometheus.Gatherer-based tooling, such as
+// the testutil package.
+func DelimitedGatherer(data []byte) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return ReadDelimited(bytes.NewReader(data))
+	})
+}
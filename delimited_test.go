@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+)
+
+type DelimitedTestSuite struct {
+	suite.Suite
+}
+
+func (suite *DelimitedTestSuite) newRegistry() *prometheus.Registry {
+	r := prometheus.NewPedanticRegistry()
+
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total", Help: "the requests"}, []string{"code"})
+	c.WithLabelValues("200").Add(2.0)
+	c.WithLabelValues("500").Inc()
+	suite.Require().NoError(r.Register(c))
+
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency", Help: "the latency", Buckets: []float64{1, 2}})
+	h.Observe(1.5)
+	suite.Require().NoError(r.Register(h))
+
+	return r
+}
+
+func (suite *DelimitedTestSuite) TestRoundTrip() {
+	r := suite.newRegistry()
+
+	var output bytes.Buffer
+	suite.Require().NoError(WriteDelimited(&output, r))
+
+	mfs, err := ReadDelimited(bytes.NewReader(output.Bytes()))
+	suite.Require().NoError(err)
+	suite.Len(mfs, 2)
+
+	expected, err := r.Gather()
+	suite.Require().NoError(err)
+	suite.Equal(len(expected), len(mfs))
+	for i := range expected {
+		suite.Equal(expected[i].String(), mfs[i].String())
+	}
+
+	// the relayed metrics compare equal to the originals
+	problems, err := testutil.GatherAndLint(DelimitedGatherer(output.Bytes()))
+	suite.NoError(err)
+	suite.Empty(problems)
+
+	count, err := testutil.GatherAndCount(DelimitedGatherer(output.Bytes()), "requests_total")
+	suite.NoError(err)
+	suite.Equal(2, count)
+}
+
+func (suite *DelimitedTestSuite) TestWriteDelimitedGatherError() {
+	expectedErr := errors.New("expected")
+	g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return nil, expectedErr
+	})
+
+	var output bytes.Buffer
+	suite.ErrorIs(WriteDelimited(&output, g), expectedErr)
+	suite.Zero(output.Len())
+}
+
+func (suite *DelimitedTestSuite) TestReadDelimited() {
+	mfs, err := ReadDelimited(strings.NewReader(""))
+	suite.NoError(err)
+	suite.Empty(mfs)
+
+	_, err = ReadDelimited(strings.NewReader("this is not a valid protobuf"))
+	suite.Error(err)
+}
+
+func TestDelimited(t *testing.T) {
+	suite.Run(t, new(DelimitedTestSuite))
+}
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DurationGauge is a gauge whose value is a time.Duration, e.g. a configured timeout or
+// the age of a cache.  Durations are converted to a fixed unit, which avoids converting
+// them to float seconds by hand at each call site.
+//
+// A DurationGauge is a prometheus.Collector that delegates to the gauge it wraps.
+// It is safe for concurrent use.
+type DurationGauge struct {
+	gauge prometheus.Gauge
+	unit  time.Duration
+}
+
+var _ prometheus.Collector = (*DurationGauge)(nil)
+
+// NewDurationGauge wraps a gauge so that its values are durations in the given unit,
+// e.g. time.Second or time.Millisecond.  If unit is not positive, time.Second is used,
+// as that is the prometheus convention.
+func NewDurationGauge(g prometheus.Gauge, unit time.Duration) *DurationGauge {
+	if unit <= 0 {
+		unit = time.Second
+	}
+
+	return &DurationGauge{
+		gauge: g,
+		unit:  unit,
+	}
+}
+
+// NewDurationGauge creates and registers a gauge whose values are durations in the
+// given unit.  See NewDurationGauge.
+func (f *Factory) NewDurationGauge(o prometheus.GaugeOpts, unit time.Duration) (*DurationGauge, error) {
+	g, err := f.NewGauge(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDurationGauge(g, unit), nil
+}
+
+func (dg *DurationGauge) value(d time.Duration) float64 {
+	return float64(d) / float64(dg.unit)
+}
+
+// Gauge returns the underlying gauge.
+func (dg *DurationGauge) Gauge() prometheus.Gauge {
+	return dg.gauge
+}
+
+// Unit returns the unit of this gauge's values.
+func (dg *DurationGauge) Unit() time.Duration {
+	return dg.unit
+}
+
+// Set sets this gauge to the given duration.
+func (dg *DurationGauge) Set(d time.Duration) {
+	dg.gauge.Set(dg.value(d))
+}
+
+// Add adds the given duration to this gauge.  The duration may be negative.
+func (dg *DurationGauge) Add(d time.Duration) {
+	dg.gauge.Add(dg.value(d))
+}
+
+// Sub subtracts the given duration from this gauge.
+func (dg *DurationGauge) Sub(d time.Duration) {
+	dg.gauge.Sub(dg.value(d))
+}
+
+// Describe implements prometheus.Collector.
+func (dg *DurationGauge) Describe(ch chan<- *prometheus.Desc) {
+	dg.gauge.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (dg *DurationGauge) Collect(ch chan<- prometheus.Metric) {
+	dg.gauge.Collect(ch)
+}
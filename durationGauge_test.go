@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type DurationGaugeTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *DurationGaugeTestSuite) TestNewDurationGauge() {
+	testCases := []struct {
+		name     string
+		unit     time.Duration
+		expected time.Duration
+		value    float64
+	}{
+		{"Default", 0, time.Second, 1.5},
+		{"Negative", -time.Minute, time.Second, 1.5},
+		{"Seconds", time.Second, time.Second, 1.5},
+		{"Milliseconds", time.Millisecond, time.Millisecond, 1500.0},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.name, func() {
+			g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test", Help: "test"})
+			dg := NewDurationGauge(g, testCase.unit)
+			suite.Same(g, dg.Gauge())
+			suite.Equal(testCase.expected, dg.Unit())
+
+			dg.Set(1500 * time.Millisecond)
+			suite.Equal(testCase.value, testutil.ToFloat64(dg))
+
+			dg.Add(1500 * time.Millisecond)
+			suite.Equal(2*testCase.value, testutil.ToFloat64(g))
+
+			dg.Sub(3 * time.Second)
+			suite.Zero(testutil.ToFloat64(g))
+		})
+	}
+}
+
+func (suite *DurationGaugeTestSuite) TestFactory() {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	f := NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r)
+
+	dg, err := f.NewDurationGauge(prometheus.GaugeOpts{Name: "timeout_seconds", Help: "the timeout"}, time.Second)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(dg)
+
+	dg.Set(time.Minute)
+	suite.Equal(60.0, testutil.ToFloat64(dg))
+	suite.Equal(1, testutil.CollectAndCount(dg, "test_timeout_seconds"))
+
+	dg, err = f.NewDurationGauge(prometheus.GaugeOpts{}, time.Second)
+	suite.ErrorIs(err, ErrNoMetricName)
+	suite.Nil(dg)
+}
+
+func TestDurationGauge(t *testing.T) {
+	suite.Run(t, new(DurationGaugeTestSuite))
+}
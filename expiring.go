@@ -0,0 +1,235 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// ErrInvalidTTL indicates that the time-to-live for an expiring vector was not positive.
+var ErrInvalidTTL = errors.New("The TTL of an expiring vector must be positive")
+
+// labelValuesSeparator joins label values into a key.  It cannot appear in valid UTF-8.
+const labelValuesSeparator = "\xff"
+
+// deleterVec is the subset of behavior of prometheus vectors that an expiring
+// vector requires.
+type deleterVec[M any] interface {
+	prometheus.Collector
+	WithLabelValues(...string) M
+	DeleteLabelValues(...string) bool
+	DeletePartialMatch(prometheus.Labels) int
+}
+
+// expiringSeries is the bookkeeping for a single child of a vector.
+type expiringSeries struct {
+	values  []string
+	updated time.Time
+}
+
+// expiringVec is the generic implementation of the exported expiring vectors.
+type expiringVec[M any] struct {
+	vec        deleterVec[M]
+	labelNames []string
+	ttl        time.Duration
+	now        func() time.Time
+
+	lock   sync.Mutex
+	series map[string]expiringSeries
+}
+
+func newExpiringVec[M any](vec deleterVec[M], labelNames []string, ttl time.Duration, now func() time.Time) expiringVec[M] {
+	return expiringVec[M]{
+		vec:        vec,
+		labelNames: append([]string{}, labelNames...),
+		ttl:        ttl,
+		now:        now,
+		series:     make(map[string]expiringSeries),
+	}
+}
+
+// WithLabelValues returns the child metric for the given label values, marking it as
+// updated now.  The returned metric should be used immediately and not retained, since
+// a retained child that has expired is no longer exposed.
+func (ev *expiringVec[M]) WithLabelValues(values ...string) M {
+	key := strings.Join(values, labelValuesSeparator)
+	now := ev.now()
+
+	ev.lock.Lock()
+	defer ev.lock.Unlock()
+
+	s, ok := ev.series[key]
+	if !ok {
+		s.values = append([]string{}, values...)
+	}
+
+	s.updated = now
+	ev.series[key] = s
+	return ev.vec.WithLabelValues(values...)
+}
+
+// Expire deletes every child that has not been updated within the TTL, returning the
+// number of children deleted.  Expire is called each time this vector is collected,
+// but it may also be called at any other time.
+func (ev *expiringVec[M]) Expire() (deleted int) {
+	cutoff := ev.now().Add(-ev.ttl)
+
+	ev.lock.Lock()
+	defer ev.lock.Unlock()
+
+	for key, s := range ev.series {
+		if s.updated.Before(cutoff) {
+			ev.vec.DeleteLabelValues(s.values...)
+			delete(ev.series, key)
+			deleted++
+		}
+	}
+
+	return
+}
+
+// DeletePartialMatch deletes every child whose labels include all of the given labels,
+// returning the number of children deleted.  This method allows an expiring vector to
+// be used with DeleteWhere.
+func (ev *expiringVec[M]) DeletePartialMatch(partial prometheus.Labels) int {
+	ev.lock.Lock()
+	defer ev.lock.Unlock()
+
+	for key, s := range ev.series {
+		if matchesPartial(ev.labelNames, s.values, partial) {
+			delete(ev.series, key)
+		}
+	}
+
+	return ev.vec.DeletePartialMatch(partial)
+}
+
+// Len returns the number of children currently tracked.
+func (ev *expiringVec[M]) Len() int {
+	ev.lock.Lock()
+	defer ev.lock.Unlock()
+	return len(ev.series)
+}
+
+// Describe implements prometheus.Collector.
+func (ev *expiringVec[M]) Describe(ch chan<- *prometheus.Desc) {
+	ev.vec.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.  Expired children are deleted first.
+func (ev *expiringVec[M]) Collect(ch chan<- prometheus.Metric) {
+	ev.Expire()
+	ev.vec.Collect(ch)
+}
+
+// ExpiringCounterVec is a counter vector whose children are deleted once they have not
+// been updated for a time-to-live.  This prevents series scoped to transient entities,
+// e.g. devices that have disconnected permanently, from being exposed until the process
+// restarts.
+//
+// Children must be obtained through WithLabelValues, which records the update time.
+// Tracking update times requires a lock, so expiring vectors are more expensive than
+// plain vectors and should be reserved for high-churn label values.
+type ExpiringCounterVec struct {
+	expiringVec[prometheus.Counter]
+}
+
+// ExpiringGaugeVec is the gauge analog of ExpiringCounterVec.
+type ExpiringGaugeVec struct {
+	expiringVec[prometheus.Gauge]
+}
+
+// ExpiringHistogramVec is the histogram analog of ExpiringCounterVec.
+type ExpiringHistogramVec struct {
+	expiringVec[prometheus.Observer]
+}
+
+// NewExpiringCounterVec creates and registers a counter vector whose children expire
+// after not being updated for the given TTL.  Expiration uses this Factory's Clock.
+//
+// This method returns an error if the options do not specify a name or if ttl is not
+// positive.  Both namespace and subsystem are defaulted appropriately if not set in
+// the options.
+func (f *Factory) NewExpiringCounterVec(o prometheus.CounterOpts, ttl time.Duration, labelNames ...string) (m *ExpiringCounterVec, err error) {
+	err = f.checkExpiring(o.Name, ttl)
+	if err == nil {
+		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
+		f.warnOnNoHelp(o.Name, o.Help)
+
+		m = &ExpiringCounterVec{
+			expiringVec: newExpiringVec[prometheus.Counter](prometheus.NewCounterVec(o, labelNames), labelNames, ttl, f.Clock().Now),
+		}
+
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_COUNTER, Opts: prometheus.Opts(o), LabelNames: labelNames})
+	}
+
+	if err != nil {
+		m = nil
+	}
+
+	return
+}
+
+// NewExpiringGaugeVec creates and registers a gauge vector whose children expire
+// after not being updated for the given TTL.  See NewExpiringCounterVec.
+func (f *Factory) NewExpiringGaugeVec(o prometheus.GaugeOpts, ttl time.Duration, labelNames ...string) (m *ExpiringGaugeVec, err error) {
+	err = f.checkExpiring(o.Name, ttl)
+	if err == nil {
+		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
+		f.warnOnNoHelp(o.Name, o.Help)
+
+		m = &ExpiringGaugeVec{
+			expiringVec: newExpiringVec[prometheus.Gauge](prometheus.NewGaugeVec(o, labelNames), labelNames, ttl, f.Clock().Now),
+		}
+
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_GAUGE, Opts: prometheus.Opts(o), LabelNames: labelNames})
+	}
+
+	if err != nil {
+		m = nil
+	}
+
+	return
+}
+
+// NewExpiringHistogramVec creates and registers a histogram vector whose children expire
+// after not being updated for the given TTL.  See NewExpiringCounterVec.
+func (f *Factory) NewExpiringHistogramVec(o prometheus.HistogramOpts, ttl time.Duration, labelNames ...string) (m *ExpiringHistogramVec, err error) {
+	err = f.checkExpiring(o.Name, ttl)
+	if err == nil {
+		ApplyDefaults(&o, f.defaults)
+		f.native.apply(&o)
+		o.Name = f.metricName(o.Name)
+		f.warnOnNoHelp(o.Name, o.Help)
+
+		m = &ExpiringHistogramVec{
+			expiringVec: newExpiringVec[prometheus.Observer](prometheus.NewHistogramVec(o, labelNames), labelNames, ttl, f.Clock().Now),
+		}
+
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_HISTOGRAM, Opts: histogramOpts(o), LabelNames: labelNames})
+	}
+
+	if err != nil {
+		m = nil
+	}
+
+	return
+}
+
+// checkExpiring validates the name and TTL of an expiring vector.
+func (f *Factory) checkExpiring(name string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+
+	return f.checkName(name)
+}
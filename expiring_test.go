@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExpiringTestSuite struct {
+	FxTestSuite
+
+	current time.Time
+}
+
+func (suite *ExpiringTestSuite) SetupTest() {
+	suite.current = time.Unix(1700000000, 0)
+}
+
+func (suite *ExpiringTestSuite) now() time.Time {
+	return suite.current
+}
+
+func (suite *ExpiringTestSuite) advance(d time.Duration) {
+	suite.current = suite.current.Add(d)
+}
+
+func (suite *ExpiringTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r, WithClock(ClockFunc(suite.now)))
+}
+
+func (suite *ExpiringTestSuite) TestInvalid() {
+	f := suite.newFactory()
+
+	cv, err := f.NewExpiringCounterVec(prometheus.CounterOpts{Name: "counter"}, 0, "device")
+	suite.ErrorIs(err, ErrInvalidTTL)
+	suite.Nil(cv)
+
+	gv, err := f.NewExpiringGaugeVec(prometheus.GaugeOpts{}, time.Minute, "device")
+	suite.Error(err)
+	suite.Nil(gv)
+
+	hv, err := f.NewExpiringHistogramVec(prometheus.HistogramOpts{Name: "histogram"}, -time.Minute, "device")
+	suite.ErrorIs(err, ErrInvalidTTL)
+	suite.Nil(hv)
+}
+
+func (suite *ExpiringTestSuite) TestCounterVec() {
+	f := suite.newFactory()
+	cv, err := f.NewExpiringCounterVec(prometheus.CounterOpts{Name: "counter", Help: "test"}, time.Minute, "device")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(cv)
+
+	cv.WithLabelValues("a").Inc()
+	cv.WithLabelValues("b").Inc()
+	suite.Equal(2, cv.Len())
+	suite.Equal(2, testutil.CollectAndCount(cv))
+
+	suite.advance(45 * time.Second)
+	cv.WithLabelValues("a").Inc()
+	suite.Zero(cv.Expire())
+
+	suite.advance(30 * time.Second)
+	suite.Equal(1, testutil.CollectAndCount(cv), "b should have expired during collection")
+	suite.Equal(1, cv.Len())
+
+	v, err := Value(cv, prometheus.Labels{"device": "a"})
+	suite.NoError(err)
+	suite.Equal(2.0, v)
+
+	suite.advance(time.Hour)
+	suite.Equal(1, cv.Expire())
+	suite.Zero(cv.Len())
+
+	// an expired series starts over
+	cv.WithLabelValues("a").Inc()
+	v, err = Value(cv, prometheus.Labels{"device": "a"})
+	suite.NoError(err)
+	suite.Equal(1.0, v)
+}
+
+func (suite *ExpiringTestSuite) TestGaugeVec() {
+	f := suite.newFactory()
+	gv, err := f.NewExpiringGaugeVec(prometheus.GaugeOpts{Name: "gauge", Help: "test"}, time.Minute, "device", "region")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(gv)
+
+	gv.WithLabelValues("a", "east").Set(5.0)
+	gv.WithLabelValues("a", "west").Set(7.0)
+	suite.advance(2 * time.Minute)
+	gv.WithLabelValues("a", "west").Add(1.0)
+
+	suite.Equal(1, gv.Expire())
+	_, err = Value(gv, prometheus.Labels{"device": "a", "region": "east"})
+	suite.ErrorIs(err, ErrNoSuchSeries)
+
+	v, err := Value(gv, prometheus.Labels{"device": "a", "region": "west"})
+	suite.NoError(err)
+	suite.Equal(8.0, v)
+}
+
+func (suite *ExpiringTestSuite) TestHistogramVec() {
+	f := suite.newFactory()
+	hv, err := f.NewExpiringHistogramVec(prometheus.HistogramOpts{Name: "histogram", Help: "test"}, time.Minute, "device")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(hv)
+
+	hv.WithLabelValues("a").Observe(1.0)
+	hd, err := HistogramValue(hv, prometheus.Labels{"device": "a"})
+	suite.NoError(err)
+	suite.Equal(uint64(1), hd.Count)
+
+	suite.advance(2 * time.Minute)
+	suite.Zero(testutil.CollectAndCount(hv))
+}
+
+func TestExpiring(t *testing.T) {
+	suite.Run(t, new(ExpiringTestSuite))
+}
@@ -6,8 +6,10 @@ package touchstone
 import (
 	"errors"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"go.uber.org/zap"
 )
 
@@ -36,18 +38,85 @@ type Factory struct {
 	defaults   prometheus.Opts
 	logger     *zap.Logger
 	registerer prometheus.Registerer
+	naming     NamingPolicy
+	clock      Clock
+	listeners  registrationListeners
+	transforms LabelTransforms
+	router     *Router
+	noCreated  bool
+	limiter    *labelLimiter
+	native     NativeHistograms
+	summaries  *summaryIndex
+	registered *registeredCollectors
+	panics     *atomic.Pointer[Panics]
+
+	// cardinalityLimit bounds the label value combinations of each vector as it is collected
+	cardinalityLimit int
+
+	// parent is the Factory this one was derived from, if any, whose listeners
+	// also receive registration events
+	parent *Factory
+}
+
+// FactoryOption is a configurable option for a Factory.
+type FactoryOption func(*Factory)
+
+// WithNamingPolicy sets the NamingPolicy applied to the name of every metric
+// a Factory creates.  A nil policy leaves names unchanged.
+func WithNamingPolicy(p NamingPolicy) FactoryOption {
+	return func(f *Factory) {
+		f.naming = p
+	}
+}
+
+// WithClock sets the Clock used by the time-based helpers a Factory creates,
+// e.g. rolling counters.  A nil Clock uses the system time.
+func WithClock(c Clock) FactoryOption {
+	return func(f *Factory) {
+		f.clock = c
+	}
+}
+
+// WithLabelTransforms sets the transformations applied to the label values of the
+// metrics a Factory creates.  See LabelTransforms.
+func WithLabelTransforms(lt LabelTransforms) FactoryOption {
+	return func(f *Factory) {
+		f.transforms = lt
+	}
 }
 
 // NewFactory produces a Factory that uses the supplied registry.
-func NewFactory(cfg Config, l *zap.Logger, r prometheus.Registerer) *Factory {
-	return &Factory{
+func NewFactory(cfg Config, l *zap.Logger, r prometheus.Registerer, opts ...FactoryOption) *Factory {
+	f := &Factory{
 		defaults: prometheus.Opts{
 			Namespace: cfg.DefaultNamespace,
 			Subsystem: cfg.DefaultSubsystem,
 		},
-		logger:     l,
-		registerer: r,
+		logger:           l,
+		registerer:       r,
+		noCreated:        cfg.DisableCreatedTimestamps,
+		limiter:          newLabelLimiter(cfg.LabelValueLimit, l, r),
+		native:           cfg.NativeHistograms,
+		summaries:        new(summaryIndex),
+		registered:       new(registeredCollectors),
+		panics:           new(atomic.Pointer[Panics]),
+		cardinalityLimit: cfg.CardinalityLimit,
+	}
+
+	for _, o := range opts {
+		o(f)
 	}
+
+	return f
+}
+
+// Clock returns the Clock used by this Factory.  This method never returns nil.
+func (f *Factory) Clock() Clock {
+	if f.clock == nil {
+		return SystemClock{}
+	}
+
+	return f.clock
 }
 
 func (f *Factory) checkName(v string) error {
@@ -58,6 +127,16 @@ func (f *Factory) checkName(v string) error {
 	return nil
 }
 
+// metricName applies any naming policy to a metric name, returning the name as is
+// if this Factory has no policy.
+func (f *Factory) metricName(name string) string {
+	if f.naming != nil {
+		return f.naming.MetricName(name)
+	}
+
+	return name
+}
+
 func (f *Factory) warnOnNoHelp(name, help string) {
 	if len(help) == 0 && f.logger != nil {
 		f.logger.Warn("No help set for metric", zap.String("name", name))
@@ -198,10 +277,11 @@ func (f *Factory) NewCounter(o prometheus.CounterOpts) (m prometheus.Counter, er
 	err = f.checkName(o.Name)
 	if err == nil {
 		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
 		f.warnOnNoHelp(o.Name, o.Help)
 
 		m = prometheus.NewCounter(o)
-		err = f.registerer.Register(m)
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_COUNTER, Opts: prometheus.Opts(o)})
 	}
 
 	return
@@ -217,10 +297,15 @@ func (f *Factory) NewCounterFunc(o prometheus.CounterOpts, fn func() float64) (m
 	err = f.checkName(o.Name)
 	if err == nil {
 		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
 		f.warnOnNoHelp(o.Name, o.Help)
 
 		m = prometheus.NewCounterFunc(o, fn)
-		err = f.registerer.Register(m)
+		if !f.noCreated {
+			m = newCreatedCounterFunc(m, f.Clock().Now())
+		}
+
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_COUNTER, Opts: prometheus.Opts(o)})
 	}
 
 	return
@@ -236,10 +321,11 @@ func (f *Factory) NewCounterVec(o prometheus.CounterOpts, labelNames ...string)
 	err = f.checkName(o.Name)
 	if err == nil {
 		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
 		f.warnOnNoHelp(o.Name, o.Help)
 
 		m = prometheus.NewCounterVec(o, labelNames)
-		err = f.registerer.Register(m)
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_COUNTER, Opts: prometheus.Opts(o), LabelNames: labelNames})
 	}
 
 	return
@@ -255,10 +341,11 @@ func (f *Factory) NewGauge(o prometheus.GaugeOpts) (m prometheus.Gauge, err erro
 	err = f.checkName(o.Name)
 	if err == nil {
 		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
 		f.warnOnNoHelp(o.Name, o.Help)
 
 		m = prometheus.NewGauge(o)
-		err = f.registerer.Register(m)
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_GAUGE, Opts: prometheus.Opts(o)})
 	}
 
 	return
@@ -274,10 +361,11 @@ func (f *Factory) NewGaugeFunc(o prometheus.GaugeOpts, fn func() float64) (m pro
 	err = f.checkName(o.Name)
 	if err == nil {
 		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
 		f.warnOnNoHelp(o.Name, o.Help)
 
 		m = prometheus.NewGaugeFunc(o, fn)
-		err = f.registerer.Register(m)
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_GAUGE, Opts: prometheus.Opts(o)})
 	}
 
 	return
@@ -293,10 +381,11 @@ func (f *Factory) NewGaugeVec(o prometheus.GaugeOpts, labelNames ...string) (m *
 	err = f.checkName(o.Name)
 	if err == nil {
 		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
 		f.warnOnNoHelp(o.Name, o.Help)
 
 		m = prometheus.NewGaugeVec(o, labelNames)
-		err = f.registerer.Register(m)
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_GAUGE, Opts: prometheus.Opts(o), LabelNames: labelNames})
 	}
 
 	return
@@ -313,12 +402,13 @@ func (f *Factory) NewUntypedFunc(o prometheus.UntypedOpts, fn interface{}) (m pr
 	err = f.checkName(o.Name)
 	if err == nil {
 		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
 		f.warnOnNoHelp(o.Name, o.Help)
 		m, err = NewUntypedFunc(o, fn)
 	}
 
 	if err == nil {
-		err = f.registerer.Register(m)
+		err = f.register(m, RegistrationEvent{Type: dto.MetricType_UNTYPED, Opts: prometheus.Opts(o)})
 	}
 
 	return
@@ -326,6 +416,7 @@ func (f *Factory) NewUntypedFunc(o prometheus.UntypedOpts, fn interface{}) (m pr
 
 // NewHistogram creates and registers a new observer using the supplied options.
 // The Observer component is backed by a prometheus.Histogram.
+// Any native histogram parameters not set in the options are taken from Config.NativeHistograms.
 //
 // This method returns an error if the options do not specify a name.  Both namespace
 // and subsystem are defaulted appropriately if not set in the options.
@@ -335,11 +426,13 @@ func (f *Factory) NewHistogram(o prometheus.HistogramOpts) (m prometheus.Observe
 	err = f.checkName(o.Name)
 	if err == nil {
 		ApplyDefaults(&o, f.defaults)
+		f.native.apply(&o)
+		o.Name = f.metricName(o.Name)
 		f.warnOnNoHelp(o.Name, o.Help)
 
 		h := prometheus.NewHistogram(o)
 		m = h
-		err = f.registerer.Register(h)
+		err = f.register(h, RegistrationEvent{Type: dto.MetricType_HISTOGRAM, Opts: histogramOpts(o)})
 	}
 
 	return
@@ -347,6 +440,7 @@ func (f *Factory) NewHistogram(o prometheus.HistogramOpts) (m prometheus.Observe
 
 // NewHistogramVec creates and registers a new observer vector using the supplied options.
 // The ObserverVec component is backed by a prometheus.HistogramVec.
+// Any native histogram parameters not set in the options are taken from Config.NativeHistograms.
 //
 // This method returns an error if the options do not specify a name.  Both namespace
 // and subsystem are defaulted appropriately if not set in the options.
@@ -356,11 +450,13 @@ func (f *Factory) NewHistogramVec(o prometheus.HistogramOpts, labelNames ...stri
 	err = f.checkName(o.Name)
 	if err == nil {
 		ApplyDefaults(&o, f.defaults)
+		f.native.apply(&o)
+		o.Name = f.metricName(o.Name)
 		f.warnOnNoHelp(o.Name, o.Help)
 
 		h := prometheus.NewHistogramVec(o, labelNames)
 		m = h
-		err = f.registerer.Register(h)
+		err = f.register(h, RegistrationEvent{Type: dto.MetricType_HISTOGRAM, Opts: histogramOpts(o), LabelNames: labelNames})
 	}
 
 	return
@@ -377,11 +473,12 @@ func (f *Factory) NewSummary(o prometheus.SummaryOpts) (m prometheus.Observer, e
 	err = f.checkName(o.Name)
 	if err == nil {
 		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
 		f.warnOnNoHelp(o.Name, o.Help)
 
 		s := prometheus.NewSummary(o)
 		m = s
-		err = f.registerer.Register(s)
+		err = f.register(s, RegistrationEvent{Type: dto.MetricType_SUMMARY, Opts: summaryOpts(o)})
 	}
 
 	return
@@ -398,11 +495,12 @@ func (f *Factory) NewSummaryVec(o prometheus.SummaryOpts, labelNames ...string)
 	err = f.checkName(o.Name)
 	if err == nil {
 		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
 		f.warnOnNoHelp(o.Name, o.Help)
 
 		s := prometheus.NewSummaryVec(o, labelNames)
 		m = s
-		err = f.registerer.Register(s)
+		err = f.register(s, RegistrationEvent{Type: dto.MetricType_SUMMARY, Opts: summaryOpts(o), LabelNames: labelNames})
 	}
 
 	return
@@ -4,6 +4,11 @@
 package touchstone
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
@@ -15,6 +20,18 @@ const (
 	Module = "touchstone"
 )
 
+var (
+	// ErrVerifyFailed indicates that metrics could not be gathered during verification.
+	// The error returned by Verify wraps this error as well as the errors from gathering.
+	ErrVerifyFailed = errors.New("Metrics verification failed")
+
+	// ErrNotAMetricOption indicates that an fx.Option passed to Tag or Group was
+	// not created by Metric or one of this package's metric functions.
+	ErrNotAMetricOption = errors.New("Only options created by touchstone metric functions can be tagged")
+
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
 // In represents the components used by this package to bootstrap
 // a prometheus environment.  Provide uses these components.
 type In struct {
@@ -27,6 +44,24 @@ type In struct {
 	// Logger is the *zap.Logger to which this package writes messages.
 	// This is optional, and if unset no messages are written.
 	Logger *zap.Logger `optional:"true"`
+
+	// NamingPolicy is the optional policy applied to the names of all metrics
+	// created by the Factory.  A Naming can be supplied as this component:
+	//
+	//	fx.Supply(
+	//	  fx.Annotate(
+	//	    touchstone.Naming{Prefix: "acme"},
+	//	    fx.As(new(touchstone.NamingPolicy)),
+	//	  ),
+	//	)
+	NamingPolicy NamingPolicy `optional:"true"`
+
+	// LabelTransforms are the optional transformations applied to the label values
+	// of all metrics created by the Factory, e.g. to hash device identifiers.
+	LabelTransforms LabelTransforms `optional:"true"`
+
+	// Registerers are the named alternate registerers that Config.Routes may refer to.
+	Registerers []NamedRegisterer `group:"touchstone.registerers"`
 }
 
 // Provide bootstraps a prometheus environment for an uber/fx App.
@@ -37,20 +72,79 @@ type In struct {
 //     NOTE: Do not rely on the Registerer actually being a *prometheus.Registry.
 //     It may be decorated to arbitrary depth.
 //   - *touchstone.Factory
+//   - touchstone.MetricFactory
+//     NOTE: This is the same object as the *touchstone.Factory unless decorated.
+//     The metric functions in this package and the other touchstone packages
+//     use this component, so decorating it affects all of them.
+//   - touchstone.Clock
+//     This is a SystemClock.  Decorate it to drive all duration metrics
+//     from a single fake clock in tests.
+//   - touchstone.GathererCheck
+//     This checks the prometheus.Gatherer at runtime.  See CheckGatherer.
+//
+// If Config.Routes is set, the Factory registers metrics in the routed namespaces
+// with the NamedRegisterer components supplied to the application, e.g. with
+// SupplyRegisterer.  Each route must refer to one of those components.
+//
+// If Config.VerifyOnStart is set, metrics are verified with Verify when the
+// application starts, and any problems fail startup.
 func Provide() fx.Option {
 	return fx.Module(
 		Module,
+		fx.Invoke(
+			func(g prometheus.Gatherer, lc fx.Lifecycle, in In) {
+				if in.Config.VerifyOnStart {
+					lc.Append(fx.Hook{
+						OnStart: func(context.Context) error {
+							return Verify(g)
+						},
+					})
+				}
+			},
+		),
 		fx.Provide(
 			func(in In) (prometheus.Gatherer, prometheus.Registerer, error) {
 				return New(in.Config)
 			},
-			func(r prometheus.Registerer, in In) *Factory {
-				return NewFactory(in.Config, in.Logger, r)
+			func() Clock {
+				return SystemClock{}
 			},
+			func(r prometheus.Registerer, in In, c Clock) (*Factory, error) {
+				router, err := NewRouter(in.Config.Routes, in.Registerers...)
+				if err != nil {
+					return nil, err
+				}
+
+				return NewFactory(
+					in.Config, in.Logger, r,
+					WithNamingPolicy(in.NamingPolicy),
+					WithLabelTransforms(in.LabelTransforms),
+					WithClock(c),
+					WithRouter(router),
+				), nil
+			},
+			func(f *Factory) MetricFactory {
+				return f
+			},
+			NewGathererCheck,
 		),
 	)
 }
 
+// Verify gathers metrics once, returning any error.  This catches problems
+// with collectors that only show up at gather time, e.g. a collector that emits
+// inconsistent help or label names.
+//
+// The returned error wraps ErrVerifyFailed.  Each gathering problem is reported
+// on its own line.
+func Verify(g prometheus.Gatherer) error {
+	if _, err := g.Gather(); err != nil {
+		return fmt.Errorf("%w:\n%w", ErrVerifyFailed, err)
+	}
+
+	return nil
+}
+
 // Metric emits a named component using the specified target.  The target
 // is expected to be a function (constructor) of the same form accepted
 // by fx.Annotated.Target.
@@ -60,15 +154,120 @@ func Provide() fx.Option {
 // See: https://pkg.go.dev/go.uber.org/fx#Annotated
 func Metric(name string, target interface{}) fx.Option {
 	if len(name) == 0 {
-		return fx.Error(ErrNoMetricName)
+		// still a metricOption, so that Tag reports the original error
+		return metricOption{
+			Option: fx.Error(ErrNoMetricName),
+		}
 	}
 
-	return fx.Provide(
-		fx.Annotated{
-			Name:   name,
-			Target: target,
+	return metricOption{
+		Option: fx.Provide(
+			fx.Annotated{
+				Name:   name,
+				Target: target,
+			},
+		),
+		name:   name,
+		target: target,
+	}
+}
+
+// metricOption is the fx.Option emitted by Metric.  It retains enough information
+// to emit the same metric under additional result tags.
+type metricOption struct {
+	fx.Option
+	name   string
+	target interface{}
+}
+
+// tagged emits this option's metric as a prometheus.Collector using the given result tag.
+func (mo metricOption) tagged(tag string) fx.Option {
+	if mo.target == nil {
+		// the metric option is already an error
+		return fx.Options()
+	}
+
+	tt := reflect.TypeOf(mo.target)
+	if tt.Kind() != reflect.Func || tt.NumOut() == 0 {
+		return fx.Error(fmt.Errorf("'%T' is not a valid metric constructor", mo.target))
+	}
+
+	fn := reflect.MakeFunc(
+		reflect.FuncOf(
+			[]reflect.Type{tt.Out(0)},
+			[]reflect.Type{collectorType, errorType},
+			false,
+		),
+		func(in []reflect.Value) []reflect.Value {
+			var (
+				c   = reflect.New(collectorType).Elem()
+				err = reflect.New(errorType).Elem()
+			)
+
+			if collector, ok := in[0].Interface().(prometheus.Collector); ok {
+				c.Set(reflect.ValueOf(collector))
+			} else {
+				err.Set(reflect.ValueOf(
+					fmt.Errorf("metric '%s' of type %T is not a prometheus.Collector", mo.name, in[0].Interface()),
+				))
+			}
+
+			return []reflect.Value{c, err}
 		},
 	)
+
+	return fx.Provide(
+		fx.Annotate(
+			fn.Interface(),
+			fx.ParamTags(fmt.Sprintf(`name:"%s"`, mo.name)),
+			fx.ResultTags(tag),
+		),
+	)
+}
+
+// Tag emits each metric as an additional prometheus.Collector component with the
+// given fx result tag, e.g. `group:"metrics"` or `name:"alias"`.  The metrics are
+// still emitted as named components, exactly as if Tag had not been used.
+//
+// Each metric must be an fx.Option returned by Metric or one of this package's metric
+// functions, such as Counter or GaugeVec.  Otherwise, application startup is
+// short-circuited with ErrNotAMetricOption.
+func Tag(tag string, metrics ...fx.Option) fx.Option {
+	options := make([]fx.Option, 0, 2*len(metrics))
+	for _, m := range metrics {
+		mo, ok := m.(metricOption)
+		if !ok {
+			return fx.Error(fmt.Errorf("%w: %s", ErrNotAMetricOption, m))
+		}
+
+		options = append(options, mo, mo.tagged(tag))
+	}
+
+	return fx.Options(options...)
+}
+
+// Group emits each metric into the given fx value group as a prometheus.Collector,
+// in addition to its named component.  This allows aggregators, e.g. documentation
+// or warm-up code, to consume every metric without naming each one:
+//
+//	app := fx.New(
+//	  touchstone.Provide(),
+//	  touchstone.Group(
+//	    "metrics",
+//	    touchstone.Counter(prometheus.CounterOpts{Name: "requests"}),
+//	    touchstone.GaugeVec(prometheus.GaugeOpts{Name: "connections"}, "region"),
+//	  ),
+//	  fx.Invoke(
+//	    func(in struct {
+//	      fx.In
+//	      Metrics []prometheus.Collector `group:"metrics"`
+//	    }) {
+//	      // in.Metrics holds both the counter and the gauge vector
+//	    },
+//	  ),
+//	)
+func Group(group string, metrics ...fx.Option) fx.Option {
+	return Tag(fmt.Sprintf(`group:"%s"`, group), metrics...)
 }
 
 // Counter uses a Factory instance from the enclosing fx.App to create and register
@@ -78,7 +277,7 @@ func Metric(name string, target interface{}) fx.Option {
 func Counter(o prometheus.CounterOpts) fx.Option {
 	return Metric(
 		o.Name,
-		func(f *Factory) (prometheus.Counter, error) {
+		func(f MetricFactory) (prometheus.Counter, error) {
 			return f.NewCounter(o)
 		},
 	)
@@ -91,7 +290,7 @@ func Counter(o prometheus.CounterOpts) fx.Option {
 func CounterVec(o prometheus.CounterOpts, labelNames ...string) fx.Option {
 	return Metric(
 		o.Name,
-		func(f *Factory) (*prometheus.CounterVec, error) {
+		func(f MetricFactory) (*prometheus.CounterVec, error) {
 			return f.NewCounterVec(o, labelNames...)
 		},
 	)
@@ -104,7 +303,7 @@ func CounterVec(o prometheus.CounterOpts, labelNames ...string) fx.Option {
 func Gauge(o prometheus.GaugeOpts) fx.Option {
 	return Metric(
 		o.Name,
-		func(f *Factory) (prometheus.Gauge, error) {
+		func(f MetricFactory) (prometheus.Gauge, error) {
 			return f.NewGauge(o)
 		},
 	)
@@ -117,7 +316,7 @@ func Gauge(o prometheus.GaugeOpts) fx.Option {
 func GaugeVec(o prometheus.GaugeOpts, labelNames ...string) fx.Option {
 	return Metric(
 		o.Name,
-		func(f *Factory) (*prometheus.GaugeVec, error) {
+		func(f MetricFactory) (*prometheus.GaugeVec, error) {
 			return f.NewGaugeVec(o, labelNames...)
 		},
 	)
@@ -130,7 +329,7 @@ func GaugeVec(o prometheus.GaugeOpts, labelNames ...string) fx.Option {
 func Histogram(o prometheus.HistogramOpts) fx.Option {
 	return Metric(
 		o.Name,
-		func(f *Factory) (prometheus.Observer, error) {
+		func(f MetricFactory) (prometheus.Observer, error) {
 			return f.NewHistogram(o)
 		},
 	)
@@ -143,7 +342,7 @@ func Histogram(o prometheus.HistogramOpts) fx.Option {
 func HistogramVec(o prometheus.HistogramOpts, labelNames ...string) fx.Option {
 	return Metric(
 		o.Name,
-		func(f *Factory) (prometheus.ObserverVec, error) {
+		func(f MetricFactory) (prometheus.ObserverVec, error) {
 			return f.NewHistogramVec(o, labelNames...)
 		},
 	)
@@ -156,7 +355,7 @@ func HistogramVec(o prometheus.HistogramOpts, labelNames ...string) fx.Option {
 func Summary(o prometheus.SummaryOpts) fx.Option {
 	return Metric(
 		o.Name,
-		func(f *Factory) (prometheus.Observer, error) {
+		func(f MetricFactory) (prometheus.Observer, error) {
 			return f.NewSummary(o)
 		},
 	)
@@ -169,7 +368,7 @@ func Summary(o prometheus.SummaryOpts) fx.Option {
 func SummaryVec(o prometheus.SummaryOpts, labelNames ...string) fx.Option {
 	return Metric(
 		o.Name,
-		func(f *Factory) (prometheus.ObserverVec, error) {
+		func(f MetricFactory) (prometheus.ObserverVec, error) {
 			return f.NewSummaryVec(o, labelNames...)
 		},
 	)
@@ -3,6 +3,8 @@
 package touchstone
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -17,9 +19,10 @@ type ProvideTestSuite struct {
 
 func (suite *ProvideTestSuite) TestDefaults() {
 	var (
-		gatherer   prometheus.Gatherer
-		registerer prometheus.Registerer
-		factory    *Factory
+		gatherer      prometheus.Gatherer
+		registerer    prometheus.Registerer
+		factory       *Factory
+		metricFactory MetricFactory
 	)
 
 	app := suite.newTestApp(
@@ -28,6 +31,7 @@ func (suite *ProvideTestSuite) TestDefaults() {
 			&gatherer,
 			&registerer,
 			&factory,
+			&metricFactory,
 		),
 	)
 
@@ -36,6 +40,7 @@ func (suite *ProvideTestSuite) TestDefaults() {
 	suite.NotNil(gatherer)
 	suite.NotNil(registerer)
 	suite.Require().NotNil(factory)
+	suite.Same(factory, metricFactory)
 	suite.Empty(factory.DefaultNamespace())
 	suite.Empty(factory.DefaultSubsystem())
 
@@ -98,6 +103,60 @@ func (suite *ProvideTestSuite) TestCustom() {
 	app.RequireStop()
 }
 
+// invalidCollector always fails at gather time, but not at registration time.
+type invalidCollector struct {
+	desc *prometheus.Desc
+}
+
+func (ic invalidCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ic.desc
+}
+
+func (ic invalidCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.NewInvalidMetric(ic.desc, errors.New("expected"))
+}
+
+func (suite *ProvideTestSuite) TestVerifyOnStart() {
+	register := fx.Invoke(func(r prometheus.Registerer) error {
+		return r.Register(invalidCollector{
+			desc: prometheus.NewDesc("invalid", "invalid", nil, nil),
+		})
+	})
+
+	suite.Run("Enabled", func() {
+		app := suite.newApp(
+			Provide(),
+			fx.Supply(Config{VerifyOnStart: true}),
+			register,
+		)
+
+		suite.Require().NoError(app.Err())
+		err := app.Start(context.Background())
+		suite.ErrorIs(err, ErrVerifyFailed)
+		suite.ErrorContains(err, "expected")
+	})
+
+	suite.Run("Disabled", func() {
+		app := suite.newTestApp(
+			Provide(),
+			register,
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+	})
+
+	suite.Run("Success", func() {
+		app := suite.newTestApp(
+			Provide(),
+			fx.Supply(Config{VerifyOnStart: true}),
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+	})
+}
+
 func TestProvide(t *testing.T) {
 	suite.Run(t, new(ProvideTestSuite))
 }
@@ -321,6 +380,82 @@ func (suite *MetricTestSuite) TestSummaryVec() {
 	})
 }
 
+func (suite *MetricTestSuite) TestGroup() {
+	var in struct {
+		fx.In
+		Metrics []prometheus.Collector `group:"metrics"`
+		Counter prometheus.Counter     `name:"counter"`
+	}
+
+	app := suite.newTestApp(
+		Provide(),
+		Group(
+			"metrics",
+			Counter(prometheus.CounterOpts{Name: "counter"}),
+			GaugeVec(prometheus.GaugeOpts{Name: "gaugeVec"}, "label1"),
+			Histogram(prometheus.HistogramOpts{Name: "histogram"}),
+			SummaryVec(prometheus.SummaryOpts{Name: "summaryVec"}, "label1"),
+		),
+		fx.Populate(&in),
+	)
+
+	app.RequireStart()
+	suite.Len(in.Metrics, 4)
+	suite.Contains(in.Metrics, in.Counter)
+	app.RequireStop()
+}
+
+func (suite *MetricTestSuite) TestTag() {
+	var in struct {
+		fx.In
+		Alias prometheus.Collector `name:"alias"`
+		Gauge prometheus.Gauge     `name:"gauge"`
+	}
+
+	app := suite.newTestApp(
+		Provide(),
+		Tag(`name:"alias"`, Gauge(prometheus.GaugeOpts{Name: "gauge"})),
+		fx.Populate(&in),
+	)
+
+	app.RequireStart()
+	suite.Require().NotNil(in.Alias)
+	suite.Equal(in.Gauge, in.Alias)
+	app.RequireStop()
+
+	suite.Run("NotAMetric", func() {
+		app := suite.newApp(
+			Provide(),
+			Group("metrics", fx.Provide(func() int { return 1 })),
+		)
+
+		suite.ErrorIs(app.Err(), ErrNotAMetricOption)
+	})
+
+	suite.Run("MissingName", func() {
+		app := suite.newApp(
+			Provide(),
+			Group("metrics", Counter(prometheus.CounterOpts{})),
+		)
+
+		suite.ErrorIs(app.Err(), ErrNoMetricName)
+	})
+
+	suite.Run("NotACollector", func() {
+		app := suite.newApp(
+			Provide(),
+			Group("metrics", Metric("observer", func() prometheus.Observer { return prometheus.ObserverFunc(func(float64) {}) })),
+			fx.Invoke(func(in struct {
+				fx.In
+				Metrics []prometheus.Collector `group:"metrics"`
+			}) {
+			}),
+		)
+
+		suite.Error(app.Err())
+	})
+}
+
 func TestMetric(t *testing.T) {
 	suite.Run(t, new(MetricTestSuite))
 }
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultGatherTimeout is the time CheckGatherer allows for gathering when the
+// context has no deadline.
+const DefaultGatherTimeout = 5 * time.Second
+
+// ErrGatherTimeout indicates that CheckGatherer gave up waiting for metrics to be gathered.
+var ErrGatherTimeout = errors.New("Timed out gathering metrics")
+
+// gathering is a single gather of metrics, which may outlive the check that started it.
+type gathering struct {
+	done chan struct{}
+	err  error
+}
+
+// gathererCheck runs at most one gather at a time.  Checks made while a gather is
+// still running wait on that gather rather than starting another.
+type gathererCheck struct {
+	gatherer prometheus.Gatherer
+
+	lock    sync.Mutex
+	pending *gathering
+}
+
+// start returns the gather in progress, starting one if necessary.
+func (gc *gathererCheck) start() *gathering {
+	gc.lock.Lock()
+	defer gc.lock.Unlock()
+	if gc.pending != nil {
+		return gc.pending
+	}
+
+	g := &gathering{done: make(chan struct{})}
+	gc.pending = g
+	go func() {
+		g.err = Verify(gc.gatherer)
+
+		gc.lock.Lock()
+		gc.pending = nil
+		gc.lock.Unlock()
+		close(g.done)
+	}()
+
+	return g
+}
+
+func (gc *gathererCheck) check(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultGatherTimeout)
+		defer cancel()
+	}
+
+	g := gc.start()
+	select {
+	case <-g.done:
+		return g.err
+
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", ErrGatherTimeout, ctx.Err())
+	}
+}
+
+// CheckGatherer gathers metrics once within the time allowed by the context, returning
+// any error.  Unlike Verify, which is meant for startup, this function is meant for
+// health checks at runtime:  it catches collectors that begin to fail, or to hang,
+// after the application has started, e.g. because a file read by a collector has gone away.
+//
+// If the context has no deadline, DefaultGatherTimeout is used.  A timeout results in
+// an error that wraps ErrGatherTimeout, and any other failure results in an error that
+// wraps ErrVerifyFailed.  A gather that times out continues in the background, since a
+// prometheus.Gatherer cannot be canceled.  Checks that repeat should use NewGathererCheck,
+// which never starts a gather while another is still running.
+func CheckGatherer(ctx context.Context, g prometheus.Gatherer) error {
+	return (&gathererCheck{gatherer: g}).check(ctx)
+}
+
+// GathererCheck is a health check of the application's prometheus.Gatherer.  Provide
+// emits a GathererCheck, built with NewGathererCheck, as a component so that health
+// and liveness endpoints can depend on it.
+type GathererCheck func(context.Context) error
+
+// NewGathererCheck creates a GathererCheck that behaves like CheckGatherer with the given
+// Gatherer, except that only one gather runs at a time.  While a gather is hung, each
+// check waits on that same gather, so repeated checks do not accumulate goroutines.
+func NewGathererCheck(g prometheus.Gatherer) GathererCheck {
+	return (&gathererCheck{gatherer: g}).check
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+type HealthTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *HealthTestSuite) TestSuccess() {
+	r := prometheus.NewPedanticRegistry()
+	suite.NoError(CheckGatherer(context.Background(), r))
+}
+
+func (suite *HealthTestSuite) TestFailure() {
+	g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return nil, errors.New("expected")
+	})
+
+	suite.ErrorIs(CheckGatherer(context.Background(), g), ErrVerifyFailed)
+}
+
+func (suite *HealthTestSuite) TestTimeout() {
+	release := make(chan struct{})
+	defer close(release)
+	g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		<-release
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := CheckGatherer(ctx, g)
+	suite.ErrorIs(err, ErrGatherTimeout)
+	suite.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func (suite *HealthTestSuite) TestOneInFlight() {
+	var (
+		calls   int32
+		release = make(chan struct{})
+	)
+
+	check := NewGathererCheck(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-release
+		}
+
+		return nil, nil
+	}))
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		suite.ErrorIs(check(ctx), ErrGatherTimeout)
+		cancel()
+	}
+
+	suite.Equal(int32(1), atomic.LoadInt32(&calls))
+
+	close(release)
+	suite.NoError(check(context.Background()))
+}
+
+func (suite *HealthTestSuite) TestProvide() {
+	var check GathererCheck
+	app := suite.newTestApp(
+		Provide(),
+		fx.Populate(&check),
+	)
+
+	suite.Require().NoError(app.Err())
+	suite.Require().NotNil(check)
+	suite.NoError(check(context.Background()))
+}
+
+func TestHealth(t *testing.T) {
+	suite.Run(t, new(HealthTestSuite))
+}
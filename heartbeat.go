@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+)
+
+// DefaultHeartbeatInterval is the interval used by NewHeartbeat when the
+// given interval is nonpositive.
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// ErrHeartbeatStarted indicates that a Heartbeat was started more than once.
+var ErrHeartbeatStarted = errors.New("The heartbeat has already been started")
+
+// SetToCurrentTime sets a gauge to the current time of the given Clock, in seconds
+// since the Unix epoch.  Unlike prometheus.Gauge.SetToCurrentTime, this function honors
+// a Factory's Clock.  If c is nil, the system time is used.
+func SetToCurrentTime(g prometheus.Gauge, c Clock) {
+	if c == nil {
+		c = SystemClock{}
+	}
+
+	g.Set(float64(c.Now().UnixNano()) / 1e9)
+}
+
+// Heartbeat periodically sets a gauge to the current time, in seconds since the
+// Unix epoch.  This is the standard liveness signal for deadman alerts, e.g.
+// time() - heartbeat_timestamp_seconds > 60, that fire when a process stops
+// reporting even though its last scrape looked healthy.
+//
+// A Heartbeat does nothing until started.  Its Start and Stop methods have the
+// signatures of fx.Hook's OnStart and OnStop.  See RunHeartbeat.
+type Heartbeat struct {
+	gauge    prometheus.Gauge
+	clock    Clock
+	interval time.Duration
+
+	lock    sync.Mutex
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewHeartbeat creates and registers a gauge with the given name using the Factory,
+// and returns a Heartbeat that sets that gauge to the Factory's Clock every interval.
+// If interval is nonpositive, DefaultHeartbeatInterval is used.
+func NewHeartbeat(f *Factory, name string, interval time.Duration) (*Heartbeat, error) {
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+
+	g, err := f.NewGauge(prometheus.GaugeOpts{
+		Name: name,
+		Help: "The last time this process reported that it was alive, in seconds since the Unix epoch",
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Heartbeat{
+		gauge:    g,
+		clock:    f.Clock(),
+		interval: interval,
+	}, nil
+}
+
+// Gauge returns the gauge this Heartbeat sets.
+func (hb *Heartbeat) Gauge() prometheus.Gauge {
+	return hb.gauge
+}
+
+// Beat sets the gauge to the current time immediately.
+func (hb *Heartbeat) Beat() {
+	SetToCurrentTime(hb.gauge, hb.clock)
+}
+
+// run beats every interval until the done channel is closed.
+func (hb *Heartbeat) run(done <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(hb.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			hb.Beat()
+
+		case <-done:
+			return
+		}
+	}
+}
+
+// Start beats once, then starts beating in the background every interval.  The
+// context is unused.  A Heartbeat that is already running returns ErrHeartbeatStarted.
+func (hb *Heartbeat) Start(context.Context) error {
+	hb.lock.Lock()
+	defer hb.lock.Unlock()
+	if hb.done != nil {
+		return ErrHeartbeatStarted
+	}
+
+	hb.Beat()
+	hb.done = make(chan struct{})
+	hb.stopped = make(chan struct{})
+	go hb.run(hb.done, hb.stopped)
+	return nil
+}
+
+// Stop stops the background beating, waiting until it has finished or the context
+// is canceled.  The gauge keeps its last value, so that deadman alerts fire once it
+// grows stale.  This method is idempotent, and a stopped Heartbeat may be started again.
+func (hb *Heartbeat) Stop(ctx context.Context) error {
+	hb.lock.Lock()
+	defer hb.lock.Unlock()
+	if hb.done == nil {
+		return nil
+	}
+
+	close(hb.done)
+	stopped := hb.stopped
+	hb.done, hb.stopped = nil, nil
+
+	select {
+	case <-stopped:
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunHeartbeat uses a Factory instance from the enclosing fx.App to create a Heartbeat
+// with NewHeartbeat.  The Heartbeat is started and stopped with the application.
+//
+// If the gauge cannot be created, application startup is short-circuited with an error.
+func RunHeartbeat(name string, interval time.Duration) fx.Option {
+	return fx.Invoke(
+		func(f *Factory, lc fx.Lifecycle) error {
+			hb, err := NewHeartbeat(f, name, interval)
+			if err == nil {
+				lc.Append(fx.Hook{
+					OnStart: hb.Start,
+					OnStop:  hb.Stop,
+				})
+			}
+
+			return err
+		},
+	)
+}
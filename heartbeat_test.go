@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+type HeartbeatTestSuite struct {
+	FxTestSuite
+
+	// beats is the number of times the test clock has been queried
+	beats atomic.Int64
+}
+
+func (suite *HeartbeatTestSuite) SetupTest() {
+	suite.beats.Store(0)
+}
+
+// clock returns a Clock that reports one more second past the epoch each time it is queried.
+func (suite *HeartbeatTestSuite) clock() Clock {
+	return ClockFunc(func() time.Time {
+		return time.Unix(suite.beats.Add(1), 0)
+	})
+}
+
+func (suite *HeartbeatTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r, WithClock(suite.clock()))
+}
+
+func (suite *HeartbeatTestSuite) TestSetToCurrentTime() {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test"})
+
+	SetToCurrentTime(g, ClockFunc(func() time.Time {
+		return time.Unix(1234, int64(500*time.Millisecond))
+	}))
+
+	suite.Equal(1234.5, testutil.ToFloat64(g))
+
+	before := float64(time.Now().Unix())
+	SetToCurrentTime(g, nil)
+	suite.GreaterOrEqual(testutil.ToFloat64(g), before)
+}
+
+func (suite *HeartbeatTestSuite) TestNewHeartbeat() {
+	suite.Run("Invalid", func() {
+		hb, err := NewHeartbeat(suite.newFactory(), "", time.Second)
+		suite.Error(err)
+		suite.Nil(hb)
+	})
+
+	suite.Run("DefaultInterval", func() {
+		hb, err := NewHeartbeat(suite.newFactory(), "heartbeat", 0)
+		suite.Require().NoError(err)
+		suite.Equal(DefaultHeartbeatInterval, hb.interval)
+	})
+}
+
+func (suite *HeartbeatTestSuite) TestStartStop() {
+	hb, err := NewHeartbeat(suite.newFactory(), "heartbeat_timestamp_seconds", time.Millisecond)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(hb.Gauge())
+	suite.Zero(testutil.ToFloat64(hb.Gauge()))
+
+	suite.NoError(hb.Stop(context.Background()))
+	suite.Require().NoError(hb.Start(context.Background()))
+	suite.ErrorIs(hb.Start(context.Background()), ErrHeartbeatStarted)
+	suite.GreaterOrEqual(testutil.ToFloat64(hb.Gauge()), 1.0)
+
+	suite.Eventually(
+		func() bool {
+			return testutil.ToFloat64(hb.Gauge()) >= 3.0
+		},
+		time.Second,
+		time.Millisecond,
+	)
+
+	suite.NoError(hb.Stop(context.Background()))
+	suite.NoError(hb.Stop(context.Background()))
+
+	// the gauge keeps its last value once stopped
+	last := testutil.ToFloat64(hb.Gauge())
+	time.Sleep(5 * time.Millisecond)
+	suite.Equal(last, testutil.ToFloat64(hb.Gauge()))
+
+	// a stopped heartbeat can be restarted
+	suite.Require().NoError(hb.Start(context.Background()))
+	suite.Greater(testutil.ToFloat64(hb.Gauge()), last)
+	suite.NoError(hb.Stop(context.Background()))
+}
+
+func (suite *HeartbeatTestSuite) TestRunHeartbeat() {
+	suite.Run("Success", func() {
+		var g prometheus.Gatherer
+		app := suite.newTestApp(
+			Provide(),
+			fx.Decorate(func(Clock) Clock { return suite.clock() }),
+			RunHeartbeat("heartbeat_timestamp_seconds", time.Hour),
+			fx.Populate(&g),
+		)
+
+		app.RequireStart()
+		mfs, err := g.Gather()
+		suite.Require().NoError(err)
+
+		var found bool
+		for _, mf := range mfs {
+			if mf.GetName() == "heartbeat_timestamp_seconds" {
+				found = true
+				suite.Positive(mf.GetMetric()[0].GetGauge().GetValue())
+			}
+		}
+
+		suite.True(found)
+		app.RequireStop()
+	})
+
+	suite.Run("Invalid", func() {
+		app := suite.newApp(
+			Provide(),
+			RunHeartbeat("", time.Hour),
+		)
+
+		suite.Error(app.Err())
+	})
+}
+
+func TestHeartbeat(t *testing.T) {
+	suite.Run(t, new(HeartbeatTestSuite))
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// InventoryFamily describes a gathered metric family without its values.  An
+// inventory answers whether a build exposes a metric, and with which labels,
+// without scraping and parsing the exposition formats.
+type InventoryFamily struct {
+	Name string `json:"name"`
+	Help string `json:"help,omitempty"`
+	Type string `json:"type"`
+
+	// LabelNames is the sorted union of the label names of every series,
+	// including any constant labels.
+	LabelNames []string `json:"labelNames,omitempty"`
+
+	// Series is the number of series currently in the family.
+	Series int `json:"series"`
+}
+
+// NewInventory converts gathered metric families into an inventory.  The order of
+// families is preserved, which for a prometheus.Gatherer is sorted.
+//
+// A vector with no series is not gathered, and so does not appear in an inventory.
+// Use Preinitialize for metrics whose presence must be visible at startup.
+func NewInventory(mfs []*dto.MetricFamily) []InventoryFamily {
+	families := make([]InventoryFamily, 0, len(mfs))
+	for _, mf := range mfs {
+		names := make(map[string]bool)
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				names[lp.GetName()] = true
+			}
+		}
+
+		f := InventoryFamily{
+			Name:   mf.GetName(),
+			Help:   mf.GetHelp(),
+			Type:   strings.ToLower(mf.GetType().String()),
+			Series: len(mf.GetMetric()),
+		}
+
+		if len(names) > 0 {
+			f.LabelNames = make([]string, 0, len(names))
+			for name := range names {
+				f.LabelNames = append(f.LabelNames, name)
+			}
+
+			sort.Strings(f.LabelNames)
+		}
+
+		families = append(families, f)
+	}
+
+	return families
+}
+
+// WriteInventory gathers metrics and writes the JSON form of their inventory to the
+// given writer.  Any families successfully gathered are written even if the gatherer
+// returns an error, in which case that error is returned after writing.
+func WriteInventory(w io.Writer, g prometheus.Gatherer) error {
+	mfs, gatherErr := g.Gather()
+	if err := json.NewEncoder(w).Encode(NewInventory(mfs)); err != nil {
+		return err
+	}
+
+	return gatherErr
+}
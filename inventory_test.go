@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+)
+
+type InventoryTestSuite struct {
+	suite.Suite
+}
+
+func (suite *InventoryTestSuite) newRegistry() *prometheus.Registry {
+	r := prometheus.NewPedanticRegistry()
+
+	c := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "requests",
+			Help:        "the requests",
+			ConstLabels: prometheus.Labels{"server": "main"},
+		},
+		[]string{"code", "method"},
+	)
+
+	c.WithLabelValues("200", "GET").Inc()
+	c.WithLabelValues("500", "POST").Inc()
+	suite.Require().NoError(r.Register(c))
+
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "ratio", Help: "a ratio"})
+	suite.Require().NoError(r.Register(g))
+
+	// a vector without series is not gathered
+	empty := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "empty", Help: "empty"}, []string{"code"})
+	suite.Require().NoError(r.Register(empty))
+
+	return r
+}
+
+func (suite *InventoryTestSuite) TestNewInventory() {
+	mfs, err := suite.newRegistry().Gather()
+	suite.Require().NoError(err)
+
+	suite.Equal(
+		[]InventoryFamily{
+			{
+				Name:   "ratio",
+				Help:   "a ratio",
+				Type:   "gauge",
+				Series: 1,
+			},
+			{
+				Name:       "requests",
+				Help:       "the requests",
+				Type:       "counter",
+				LabelNames: []string{"code", "method", "server"},
+				Series:     2,
+			},
+		},
+		NewInventory(mfs),
+	)
+}
+
+func (suite *InventoryTestSuite) TestWriteInventory() {
+	var output bytes.Buffer
+	suite.Require().NoError(WriteInventory(&output, suite.newRegistry()))
+
+	var families []InventoryFamily
+	suite.Require().NoError(json.Unmarshal(output.Bytes(), &families))
+	suite.Len(families, 2)
+
+	suite.Run("GatherError", func() {
+		expectedErr := errors.New("expected")
+		g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return nil, expectedErr
+		})
+
+		var output bytes.Buffer
+		suite.ErrorIs(WriteInventory(&output, g), expectedErr)
+		suite.JSONEq("[]", output.String())
+	})
+}
+
+func TestInventory(t *testing.T) {
+	suite.Run(t, new(InventoryTestSuite))
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// JSONFamily is the stable JSON form of a gathered metric family.  This form is
+// intended for internal tooling and user interfaces that cannot parse the
+// prometheus exposition formats.
+//
+// Floating point values are encoded as strings, as with the prometheus HTTP API,
+// since JSON has no representation for NaN or infinities.
+type JSONFamily struct {
+	Name    string       `json:"name"`
+	Help    string       `json:"help,omitempty"`
+	Type    string       `json:"type"`
+	Metrics []JSONMetric `json:"metrics"`
+}
+
+// JSONMetric is the JSON form of a single series within a metric family.  Exactly
+// one of Value, Histogram, or Summary is set.
+type JSONMetric struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Value       string            `json:"value,omitempty"`
+	Histogram   *JSONHistogram    `json:"histogram,omitempty"`
+	Summary     *JSONSummary      `json:"summary,omitempty"`
+	TimestampMs int64             `json:"timestampMs,omitempty"`
+}
+
+// JSONHistogram is the JSON form of a histogram series.  Buckets are cumulative,
+// ordered by upper bound.
+type JSONHistogram struct {
+	Count   uint64       `json:"count"`
+	Sum     string       `json:"sum"`
+	Buckets []JSONBucket `json:"buckets,omitempty"`
+}
+
+// JSONBucket is a single cumulative histogram bucket.
+type JSONBucket struct {
+	UpperBound string `json:"upperBound"`
+	Count      uint64 `json:"count"`
+}
+
+// JSONSummary is the JSON form of a summary series.
+type JSONSummary struct {
+	Count     uint64         `json:"count"`
+	Sum       string         `json:"sum"`
+	Quantiles []JSONQuantile `json:"quantiles,omitempty"`
+}
+
+// JSONQuantile is a single summary quantile.
+type JSONQuantile struct {
+	Quantile string `json:"quantile"`
+	Value    string `json:"value"`
+}
+
+func formatJSONFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func newJSONMetric(t dto.MetricType, m *dto.Metric) (jm JSONMetric) {
+	if len(m.GetLabel()) > 0 {
+		jm.Labels = make(map[string]string, len(m.GetLabel()))
+		for _, lp := range m.GetLabel() {
+			jm.Labels[lp.GetName()] = lp.GetValue()
+		}
+	}
+
+	jm.TimestampMs = m.GetTimestampMs()
+	switch t {
+	case dto.MetricType_COUNTER:
+		jm.Value = formatJSONFloat(m.GetCounter().GetValue())
+
+	case dto.MetricType_GAUGE:
+		jm.Value = formatJSONFloat(m.GetGauge().GetValue())
+
+	case dto.MetricType_UNTYPED:
+		jm.Value = formatJSONFloat(m.GetUntyped().GetValue())
+
+	case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+		h := m.GetHistogram()
+		jm.Histogram = &JSONHistogram{
+			Count: h.GetSampleCount(),
+			Sum:   formatJSONFloat(h.GetSampleSum()),
+		}
+
+		for _, b := range h.GetBucket() {
+			jm.Histogram.Buckets = append(jm.Histogram.Buckets, JSONBucket{
+				UpperBound: formatJSONFloat(b.GetUpperBound()),
+				Count:      b.GetCumulativeCount(),
+			})
+		}
+
+	case dto.MetricType_SUMMARY:
+		s := m.GetSummary()
+		jm.Summary = &JSONSummary{
+			Count: s.GetSampleCount(),
+			Sum:   formatJSONFloat(s.GetSampleSum()),
+		}
+
+		for _, q := range s.GetQuantile() {
+			jm.Summary.Quantiles = append(jm.Summary.Quantiles, JSONQuantile{
+				Quantile: formatJSONFloat(q.GetQuantile()),
+				Value:    formatJSONFloat(q.GetValue()),
+			})
+		}
+	}
+
+	return
+}
+
+// NewJSONFamilies converts gathered metric families into their JSON form.  The order
+// of families and metrics is preserved, which for a prometheus.Gatherer is sorted.
+func NewJSONFamilies(mfs []*dto.MetricFamily) []JSONFamily {
+	families := make([]JSONFamily, 0, len(mfs))
+	for _, mf := range mfs {
+		jf := JSONFamily{
+			Name:    mf.GetName(),
+			Help:    mf.GetHelp(),
+			Type:    strings.ToLower(mf.GetType().String()),
+			Metrics: make([]JSONMetric, 0, len(mf.GetMetric())),
+		}
+
+		for _, m := range mf.GetMetric() {
+			jf.Metrics = append(jf.Metrics, newJSONMetric(mf.GetType(), m))
+		}
+
+		families = append(families, jf)
+	}
+
+	return families
+}
+
+// WriteJSON gathers metrics and writes their JSON form to the given writer.  Any
+// families successfully gathered are written even if the gatherer returns an
+// error, in which case that error is returned after writing.
+func WriteJSON(w io.Writer, g prometheus.Gatherer) error {
+	mfs, gatherErr := g.Gather()
+	if err := json.NewEncoder(w).Encode(NewJSONFamilies(mfs)); err != nil {
+		return err
+	}
+
+	return gatherErr
+}
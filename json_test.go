@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+)
+
+type JSONTestSuite struct {
+	suite.Suite
+}
+
+func (suite *JSONTestSuite) newRegistry() *prometheus.Registry {
+	r := prometheus.NewPedanticRegistry()
+
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests", Help: "the requests"}, []string{"code"})
+	c.WithLabelValues("200").Add(2.0)
+	suite.Require().NoError(r.Register(c))
+
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "ratio", Help: "a ratio"})
+	g.Set(math.NaN())
+	suite.Require().NoError(r.Register(g))
+
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency", Help: "the latency", Buckets: []float64{1, 2}})
+	h.Observe(1.5)
+	suite.Require().NoError(r.Register(h))
+
+	s := prometheus.NewSummary(prometheus.SummaryOpts{Name: "size", Help: "the size", Objectives: map[float64]float64{0.5: 0.05}})
+	s.Observe(10.0)
+	suite.Require().NoError(r.Register(s))
+
+	u := prometheus.NewUntypedFunc(prometheus.UntypedOpts{Name: "untyped", Help: "untyped"}, func() float64 { return math.Inf(1) })
+	suite.Require().NoError(r.Register(u))
+
+	return r
+}
+
+func (suite *JSONTestSuite) TestNewJSONFamilies() {
+	mfs, err := suite.newRegistry().Gather()
+	suite.Require().NoError(err)
+
+	suite.Equal(
+		[]JSONFamily{
+			{
+				Name: "latency",
+				Help: "the latency",
+				Type: "histogram",
+				Metrics: []JSONMetric{
+					{
+						Histogram: &JSONHistogram{
+							Count: 1,
+							Sum:   "1.5",
+							Buckets: []JSONBucket{
+								{UpperBound: "1", Count: 0},
+								{UpperBound: "2", Count: 1},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:    "ratio",
+				Help:    "a ratio",
+				Type:    "gauge",
+				Metrics: []JSONMetric{{Value: "NaN"}},
+			},
+			{
+				Name:    "requests",
+				Help:    "the requests",
+				Type:    "counter",
+				Metrics: []JSONMetric{{Labels: map[string]string{"code": "200"}, Value: "2"}},
+			},
+			{
+				Name: "size",
+				Help: "the size",
+				Type: "summary",
+				Metrics: []JSONMetric{
+					{
+						Summary: &JSONSummary{
+							Count:     1,
+							Sum:       "10",
+							Quantiles: []JSONQuantile{{Quantile: "0.5", Value: "10"}},
+						},
+					},
+				},
+			},
+			{
+				Name:    "untyped",
+				Help:    "untyped",
+				Type:    "untyped",
+				Metrics: []JSONMetric{{Value: "+Inf"}},
+			},
+		},
+		NewJSONFamilies(mfs),
+	)
+}
+
+func (suite *JSONTestSuite) TestWriteJSON() {
+	var output bytes.Buffer
+	suite.Require().NoError(WriteJSON(&output, suite.newRegistry()))
+
+	var families []JSONFamily
+	suite.Require().NoError(json.Unmarshal(output.Bytes(), &families))
+	suite.Len(families, 5)
+
+	suite.Run("GatherError", func() {
+		expectedErr := errors.New("expected")
+		g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return nil, expectedErr
+		})
+
+		var output bytes.Buffer
+		suite.ErrorIs(WriteJSON(&output, g), expectedErr)
+		suite.JSONEq("[]", output.String())
+	})
+}
+
+func TestJSON(t *testing.T) {
+	suite.Run(t, new(JSONTestSuite))
+}
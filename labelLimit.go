@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	// LabelTruncationCountName is the name, within SelfNamespace, of the counter of
+	// label values that exceeded Config.LabelValueLimit.  Its MetricLabel holds the
+	// fully qualified name of the metric whose label value was limited.
+	LabelTruncationCountName = "label_value_truncation_count"
+
+	// LimitTruncate is the LabelValueLimit policy that cuts long values down to the
+	// limit.  Series whose values share the same prefix are combined.
+	LimitTruncate = "truncate"
+
+	// LimitHash is the LabelValueLimit policy that replaces long values with a short,
+	// hex-encoded digest.  Distinct values remain distinct series.
+	LimitHash = "hash"
+
+	// maxSeenLabelValues bounds the number of limited values a Factory remembers in
+	// order to count each one only once.
+	maxSeenLabelValues = 4096
+)
+
+// ErrInvalidLimitPolicy indicates that a LabelValueLimit had an unrecognized policy.
+var ErrInvalidLimitPolicy = errors.New("Invalid label value limit policy")
+
+// LabelValueLimit bounds the length of the label values of every metric created by a
+// Factory.  This protects against accidentally huge values, such as stack traces or full
+// URLs, inflating the size of each scrape.  Like LabelTransforms, the limit is applied
+// when metrics are collected, so call sites are unaffected.
+//
+// Each distinct value that exceeds the limit is counted once by the LabelTruncationCountName
+// counter.  To bound memory, only the first several thousand such values are remembered,
+// and any value after that is counted each time it is limited.  Since the limit applies to every vector a Factory creates, each such vector is
+// decorated as by NewTransformingCollector when a limit is set.
+type LabelValueLimit struct {
+	// MaxBytes is the maximum length, in bytes, of a label value.  If unset, label values
+	// are not limited.
+	MaxBytes int `json:"maxBytes" yaml:"maxBytes"`
+
+	// Policy determines what happens to values longer than MaxBytes.  It must be either
+	// LimitTruncate, the default, or LimitHash.  The digests produced by LimitHash are
+	// 16 bytes, so MaxBytes must be at least that large for that policy.
+	Policy string `json:"policy" yaml:"policy"`
+}
+
+// Validate checks that this limit can be applied.
+func (lvl LabelValueLimit) Validate() error {
+	switch {
+	case lvl.MaxBytes < 0:
+		return errors.New("MaxBytes cannot be negative")
+
+	case lvl.Policy != "" && lvl.Policy != LimitTruncate && lvl.Policy != LimitHash:
+		return fmt.Errorf("%w: %q", ErrInvalidLimitPolicy, lvl.Policy)
+
+	case lvl.Policy == LimitHash && lvl.MaxBytes > 0 && lvl.MaxBytes < hashLength:
+		return fmt.Errorf("MaxBytes must be at least %d for the %s policy", hashLength, LimitHash)
+
+	default:
+		return nil
+	}
+}
+
+// Limit applies this limit to a single value.  Values within the limit are returned as is,
+// and the returned flag indicates whether the value was changed.  Truncation never splits
+// a UTF-8 encoded character.
+func (lvl LabelValueLimit) Limit(value string) (string, bool) {
+	if lvl.MaxBytes <= 0 || len(value) <= lvl.MaxBytes {
+		return value, false
+	}
+
+	if lvl.Policy == LimitHash {
+		digest := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(digest[:])[:hashLength], true
+	}
+
+	end := lvl.MaxBytes
+	for end > 0 && !utf8.RuneStart(value[end]) {
+		end--
+	}
+
+	return value[:end], true
+}
+
+// labelLimiter applies a LabelValueLimit to the metrics of a Factory, counting each
+// distinct value that was limited.
+type labelLimiter struct {
+	limit LabelValueLimit
+	count *prometheus.CounterVec
+
+	lock    sync.Mutex
+	seen    map[uint64]bool
+	maxSeen int
+}
+
+// newLabelLimiter creates the labelLimiter for a Factory, registering its counter with
+// the given Registerer.  If the limit is not enabled, this function returns nil.  A failure
+// to register the counter is logged, and values are still limited.
+func newLabelLimiter(lvl LabelValueLimit, l *zap.Logger, r prometheus.Registerer) *labelLimiter {
+	if lvl.MaxBytes <= 0 {
+		return nil
+	}
+
+	count := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: SelfNamespace,
+			Name:      LabelTruncationCountName,
+			Help:      "the total number of distinct label values that exceeded the configured limit",
+		},
+		[]string{MetricLabel},
+	)
+
+	if err := ExistingCollector(&count, r.Register(count)); err != nil && l != nil {
+		l.Warn("Unable to register label value truncation counter", zap.Error(err))
+	}
+
+	return &labelLimiter{
+		limit:   lvl,
+		count:   count,
+		seen:    make(map[uint64]bool),
+		maxSeen: maxSeenLabelValues,
+	}
+}
+
+// transformer produces the LabelTransformer for a single label of a metric.  Any existing
+// transformer for that label is applied first.
+func (ll *labelLimiter) transformer(metric, label string, existing LabelTransformer) LabelTransformer {
+	return func(value string) string {
+		if existing != nil {
+			value = existing(value)
+		}
+
+		limited, changed := ll.limit.Limit(value)
+		if changed {
+			ll.record(metric, label, value)
+		}
+
+		return limited
+	}
+}
+
+// record counts a limited value, unless it has already been counted.  Only a hash
+// of each value is retained, and at most maxSeen hashes are retained.
+func (ll *labelLimiter) record(metric, label, value string) {
+	h := fnv.New64a()
+	h.Write([]byte(metric))
+	h.Write([]byte{0xff})
+	h.Write([]byte(label))
+	h.Write([]byte{0xff})
+	h.Write([]byte(value))
+	key := h.Sum64()
+
+	ll.lock.Lock()
+	first := !ll.seen[key]
+	if first && len(ll.seen) < ll.maxSeen {
+		ll.seen[key] = true
+	}
+
+	ll.lock.Unlock()
+
+	if first {
+		ll.count.WithLabelValues(metric).Inc()
+	}
+}
+
+// forMetric adds the limit to the transformers of a metric, which may be nil.
+func (ll *labelLimiter) forMetric(name string, labelNames []string, t map[string]LabelTransformer) map[string]LabelTransformer {
+	if ll == nil || len(labelNames) == 0 {
+		return t
+	}
+
+	limited := make(map[string]LabelTransformer, len(labelNames))
+	for _, ln := range labelNames {
+		limited[ln] = ll.transformer(name, ln, t[ln])
+	}
+
+	return limited
+}
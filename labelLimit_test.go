@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type LabelValueLimitTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *LabelValueLimitTestSuite) TestValidate() {
+	suite.NoError(LabelValueLimit{}.Validate())
+	suite.NoError(LabelValueLimit{MaxBytes: 8}.Validate())
+	suite.NoError(LabelValueLimit{MaxBytes: 16, Policy: LimitHash}.Validate())
+	suite.Error(LabelValueLimit{MaxBytes: -1}.Validate())
+	suite.Error(LabelValueLimit{MaxBytes: 8, Policy: LimitHash}.Validate())
+	suite.ErrorIs(LabelValueLimit{MaxBytes: 8, Policy: "drop"}.Validate(), ErrInvalidLimitPolicy)
+}
+
+func (suite *LabelValueLimitTestSuite) TestLimit() {
+	testCases := []struct {
+		name     string
+		limit    LabelValueLimit
+		value    string
+		expected string
+		changed  bool
+	}{
+		{"Unlimited", LabelValueLimit{}, "abcdefgh", "abcdefgh", false},
+		{"WithinLimit", LabelValueLimit{MaxBytes: 8}, "abcdefgh", "abcdefgh", false},
+		{"Truncate", LabelValueLimit{MaxBytes: 4}, "abcdefgh", "abcd", true},
+		{"TruncateRune", LabelValueLimit{MaxBytes: 4, Policy: LimitTruncate}, "abcéfgh", "abc", true},
+		{"Hash", LabelValueLimit{MaxBytes: 16, Policy: LimitHash}, strings.Repeat("x", 17), "", true},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.name, func() {
+			actual, changed := testCase.limit.Limit(testCase.value)
+			suite.Equal(testCase.changed, changed)
+			if testCase.limit.Policy == LimitHash {
+				suite.Len(actual, hashLength)
+				suite.NotEqual(testCase.value, actual)
+			} else {
+				suite.Equal(testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func (suite *LabelValueLimitTestSuite) TestFactory() {
+	cfg := Config{
+		DefaultNamespace: "test",
+		LabelValueLimit:  LabelValueLimit{MaxBytes: 4},
+	}
+
+	r := prometheus.NewPedanticRegistry()
+	f := NewFactory(cfg, suite.logger, r)
+
+	// a second factory against the same registry shares the counter
+	NewFactory(cfg, suite.logger, r)
+
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Name: "requests", Help: "requests"}, "path")
+	suite.Require().NoError(err)
+	cv.WithLabelValues("/a").Inc()
+	cv.WithLabelValues("/api/v1/devices").Inc()
+	cv.WithLabelValues("/api/v2/devices").Inc()
+
+	expected := `
+# HELP test_requests requests
+# TYPE test_requests counter
+test_requests{path="/a"} 1
+test_requests{path="/api"} 2
+`
+
+	// gathering repeatedly must not count the same values again
+	for i := 0; i < 2; i++ {
+		suite.NoError(testutil.GatherAndCompare(r, strings.NewReader(expected), "test_requests"))
+	}
+
+	suite.Equal(
+		2.0,
+		testutil.ToFloat64(f.limiter.count.WithLabelValues("test_requests")),
+	)
+
+	suite.Equal(1, testutil.CollectAndCount(r, SelfNamespace+"_"+LabelTruncationCountName))
+}
+
+func (suite *LabelValueLimitTestSuite) TestBoundedSeen() {
+	ll := newLabelLimiter(LabelValueLimit{MaxBytes: 1}, suite.logger, prometheus.NewPedanticRegistry())
+	suite.Require().NotNil(ll)
+	ll.maxSeen = 1
+
+	ll.record("metric", "label", "ab")
+	ll.record("metric", "label", "ab")
+	suite.Equal(1.0, testutil.ToFloat64(ll.count.WithLabelValues("metric")))
+
+	// values past the bound are not remembered, so each one is counted every time
+	ll.record("metric", "label", "cd")
+	ll.record("metric", "label", "cd")
+	suite.Len(ll.seen, 1)
+	suite.Equal(3.0, testutil.ToFloat64(ll.count.WithLabelValues("metric")))
+}
+
+func (suite *LabelValueLimitTestSuite) TestDisabled() {
+	f := NewFactory(Config{}, suite.logger, prometheus.NewPedanticRegistry())
+	suite.Nil(f.limiter)
+}
+
+func TestLabelValueLimit(t *testing.T) {
+	suite.Run(t, new(LabelValueLimitTestSuite))
+}
@@ -0,0 +1,305 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// hashLength is the number of hex characters of a digest that HashLabel retains.
+	hashLength = 16
+
+	// DefaultRedaction is the value RedactLabel uses when no replacement is given.
+	DefaultRedaction = "REDACTED"
+)
+
+// LabelTransformer rewrites the value of a label, e.g. to hash or redact a
+// sensitive value such as a MAC address or device id.
+type LabelTransformer func(value string) string
+
+// HashLabel produces a LabelTransformer that replaces each value with a truncated,
+// hex-encoded HMAC-SHA256 digest.  Distinct values remain distinct series, with
+// a negligible chance of collision, but the original values are not exposed.
+//
+// The key should be secret and stable across restarts, so that series remain
+// consistent.  Without a key, low entropy values such as MAC addresses can be
+// recovered by hashing every possible value.
+func HashLabel(key []byte) LabelTransformer {
+	return func(value string) string {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(value))
+		return hex.EncodeToString(h.Sum(nil))[:hashLength]
+	}
+}
+
+// RedactLabel produces a LabelTransformer that replaces every value with the given
+// replacement.  If replacement is empty, DefaultRedaction is used.  All series that
+// differ only in a redacted label are combined into one series.
+func RedactLabel(replacement string) LabelTransformer {
+	if len(replacement) == 0 {
+		replacement = DefaultRedaction
+	}
+
+	return func(string) string {
+		return replacement
+	}
+}
+
+// LabelTransforms describes the label transformations a Factory applies to the
+// metrics it creates.  Transformations are applied when metrics are collected, so
+// call sites use the original values and the registry never exposes them.
+//
+// Only variable labels are transformed.  Constant labels are fixed when a metric
+// is created and are never transformed.
+type LabelTransforms struct {
+	// Global maps label names onto the transformers applied to that label
+	// in every metric.
+	Global map[string]LabelTransformer
+
+	// Metrics maps fully qualified metric names onto label transformers for that
+	// metric alone.  These take precedence over Global for the same label.
+	Metrics map[string]map[string]LabelTransformer
+}
+
+// forMetric returns the transformers for the given metric and label names.  If no
+// transformer applies, this method returns nil.
+func (lt LabelTransforms) forMetric(name string, labelNames []string) (t map[string]LabelTransformer) {
+	for _, ln := range labelNames {
+		f, ok := lt.Metrics[name][ln]
+		if !ok {
+			f, ok = lt.Global[ln]
+		}
+
+		if ok && f != nil {
+			if t == nil {
+				t = make(map[string]LabelTransformer)
+			}
+
+			t[ln] = f
+		}
+	}
+
+	return
+}
+
+// transformingCollector decorates a collector, transforming label values as its
+// metrics are collected.
+type transformingCollector struct {
+	collector  prometheus.Collector
+	transforms map[string]LabelTransformer
+
+	// the optional limit on the combinations of the variable labels, after transformation
+	limiter  *cardinalityLimiter
+	variable map[string]bool
+}
+
+// NewTransformingCollector decorates a collector so that the given transformers are
+// applied to the labels of each metric it emits.  The map is keyed by label name,
+// and must only name variable labels.  Metrics that become identical after transformation are combined:
+// counter, gauge, untyped, and histogram values are added, and summaries have their
+// counts and sums added and their quantiles dropped.
+//
+// The returned collector implements Unwrap, which returns c.  CollectorAs uses this
+// method, so that ExistingCollector continues to work with decorated collectors.
+func NewTransformingCollector(c prometheus.Collector, transforms map[string]LabelTransformer) prometheus.Collector {
+	return newTransformingCollector(c, transforms, nil, nil)
+}
+
+// newTransformingCollector creates a transformingCollector that also applies a cardinality
+// limit, if cl is not nil, to the given variable labels.
+func newTransformingCollector(c prometheus.Collector, transforms map[string]LabelTransformer, cl *cardinalityLimiter, labelNames []string) prometheus.Collector {
+	tc := &transformingCollector{
+		collector:  c,
+		transforms: transforms,
+		limiter:    cl,
+	}
+
+	if cl != nil {
+		tc.variable = make(map[string]bool, len(labelNames))
+		for _, ln := range labelNames {
+			tc.variable[ln] = true
+		}
+	}
+
+	return tc
+}
+
+// Unwrap returns the decorated collector.
+func (tc *transformingCollector) Unwrap() prometheus.Collector {
+	return tc.collector
+}
+
+func (tc *transformingCollector) Describe(ch chan<- *prometheus.Desc) {
+	tc.collector.Describe(ch)
+}
+
+// transformedMetric is a metric whose labels and values have been rewritten.
+type transformedMetric struct {
+	desc   *prometheus.Desc
+	metric *dto.Metric
+}
+
+func (tm *transformedMetric) Desc() *prometheus.Desc {
+	return tm.desc
+}
+
+func (tm *transformedMetric) Write(out *dto.Metric) error {
+	out.Label = tm.metric.Label
+	out.Counter = tm.metric.Counter
+	out.Gauge = tm.metric.Gauge
+	out.Untyped = tm.metric.Untyped
+	out.Histogram = tm.metric.Histogram
+	out.Summary = tm.metric.Summary
+	out.TimestampMs = tm.metric.TimestampMs
+	return nil
+}
+
+// transform rewrites the label values of a metric, returning a key that identifies
+// the resulting series.  Metrics commonly share their label pairs with the
+// collector that wrote them, so the label pairs are copied rather than modified.
+func (tc *transformingCollector) transform(desc *prometheus.Desc, m *dto.Metric) string {
+	labels := make([]*dto.LabelPair, len(m.Label))
+	for i, lp := range m.Label {
+		labels[i] = lp
+		if f, ok := tc.transforms[lp.GetName()]; ok {
+			v := f(lp.GetValue())
+			labels[i] = &dto.LabelPair{Name: lp.Name, Value: &v}
+		}
+	}
+
+	m.Label = labels
+	if tc.limiter != nil {
+		tc.limiter.limitedMetric(tc.variable, m)
+	}
+
+	var key strings.Builder
+	key.WriteString(desc.String())
+	for _, lp := range m.Label {
+		key.WriteByte(0xff)
+		key.WriteString(lp.GetValue())
+	}
+
+	return key.String()
+}
+
+// earliest returns the earlier of two created timestamps, either of which may be nil.
+func earliest(a, b *timestamppb.Timestamp) *timestamppb.Timestamp {
+	if a == nil || (b != nil && b.AsTime().Before(a.AsTime())) {
+		return b
+	}
+
+	return a
+}
+
+// latest returns the more recent of two exemplars, either of which may be nil.
+func latest(a, b *dto.Exemplar) *dto.Exemplar {
+	if a == nil || (b != nil && b.GetTimestamp().AsTime().After(a.GetTimestamp().AsTime())) {
+		return b
+	}
+
+	return a
+}
+
+// combine adds the values of next into current.  Both metrics have the same Desc,
+// and so the same type and classic histogram buckets.  Native histogram buckets are
+// merged, and the most recent exemplars are kept.
+func combine(current, next *dto.Metric) {
+	switch {
+	case current.Counter != nil && next.Counter != nil:
+		v := current.Counter.GetValue() + next.Counter.GetValue()
+		current.Counter = &dto.Counter{
+			Value:            &v,
+			Exemplar:         latest(current.Counter.Exemplar, next.Counter.Exemplar),
+			CreatedTimestamp: earliest(current.Counter.CreatedTimestamp, next.Counter.CreatedTimestamp),
+		}
+
+	case current.Gauge != nil && next.Gauge != nil:
+		v := current.Gauge.GetValue() + next.Gauge.GetValue()
+		current.Gauge = &dto.Gauge{Value: &v}
+
+	case current.Untyped != nil && next.Untyped != nil:
+		v := current.Untyped.GetValue() + next.Untyped.GetValue()
+		current.Untyped = &dto.Untyped{Value: &v}
+
+	case current.Histogram != nil && next.Histogram != nil:
+		count := current.Histogram.GetSampleCount() + next.Histogram.GetSampleCount()
+		sum := current.Histogram.GetSampleSum() + next.Histogram.GetSampleSum()
+		h := &dto.Histogram{
+			SampleCount:      &count,
+			SampleSum:        &sum,
+			CreatedTimestamp: earliest(current.Histogram.CreatedTimestamp, next.Histogram.CreatedTimestamp),
+		}
+		for i, b := range current.Histogram.GetBucket() {
+			cumulative := b.GetCumulativeCount()
+			exemplar := b.Exemplar
+			if i < len(next.Histogram.GetBucket()) {
+				cumulative += next.Histogram.GetBucket()[i].GetCumulativeCount()
+				exemplar = latest(exemplar, next.Histogram.GetBucket()[i].Exemplar)
+			}
+
+			h.Bucket = append(h.Bucket, &dto.Bucket{
+				UpperBound:      b.UpperBound,
+				CumulativeCount: &cumulative,
+				Exemplar:        exemplar,
+			})
+		}
+
+		if isNative(current.Histogram) || isNative(next.Histogram) {
+			combineNative(h, current.Histogram, next.Histogram)
+		}
+
+		current.Histogram = h
+
+	case current.Summary != nil && next.Summary != nil:
+		count := current.Summary.GetSampleCount() + next.Summary.GetSampleCount()
+		sum := current.Summary.GetSampleSum() + next.Summary.GetSampleSum()
+		current.Summary = &dto.Summary{
+			SampleCount:      &count,
+			SampleSum:        &sum,
+			CreatedTimestamp: earliest(current.Summary.CreatedTimestamp, next.Summary.CreatedTimestamp),
+		}
+	}
+}
+
+func (tc *transformingCollector) Collect(ch chan<- prometheus.Metric) {
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		tc.collector.Collect(metrics)
+		close(metrics)
+	}()
+
+	var (
+		order    []string
+		combined = make(map[string]*transformedMetric)
+	)
+
+	for m := range metrics {
+		written := new(dto.Metric)
+		if err := m.Write(written); err != nil {
+			ch <- prometheus.NewInvalidMetric(m.Desc(), err)
+			continue
+		}
+
+		key := tc.transform(m.Desc(), written)
+		if existing, ok := combined[key]; ok {
+			combine(existing.metric, written)
+			continue
+		}
+
+		order = append(order, key)
+		combined[key] = &transformedMetric{desc: m.Desc(), metric: written}
+	}
+
+	for _, key := range order {
+		ch <- combined[key]
+	}
+}
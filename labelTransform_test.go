@@ -0,0 +1,263 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+type LabelTransformTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *LabelTransformTestSuite) newFactory(lt LabelTransforms) (*Factory, prometheus.Gatherer) {
+	cfg := Config{
+		Pedantic:                  true,
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := New(cfg)
+	suite.Require().NoError(err)
+	return NewFactory(cfg, suite.logger, r, WithLabelTransforms(lt)), g
+}
+
+func (suite *LabelTransformTestSuite) TestHashLabel() {
+	var (
+		h1 = HashLabel([]byte("key"))
+		h2 = HashLabel([]byte("another key"))
+	)
+
+	v := h1("00:11:22:33:44:55")
+	suite.Len(v, hashLength)
+	suite.NotContains(v, "00:11")
+	suite.Equal(v, h1("00:11:22:33:44:55"))
+	suite.NotEqual(v, h1("00:11:22:33:44:56"))
+	suite.NotEqual(v, h2("00:11:22:33:44:55"))
+}
+
+func (suite *LabelTransformTestSuite) TestRedactLabel() {
+	suite.Equal(DefaultRedaction, RedactLabel("")("secret"))
+	suite.Equal("***", RedactLabel("***")("secret"))
+}
+
+func (suite *LabelTransformTestSuite) TestGlobal() {
+	f, g := suite.newFactory(LabelTransforms{
+		Global: map[string]LabelTransformer{
+			"device": RedactLabel(""),
+		},
+	})
+
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Name: "requests", Help: "requests"}, "device", "code")
+	suite.Require().NoError(err)
+	cv.WithLabelValues("mac:1", "200").Inc()
+	cv.WithLabelValues("mac:2", "200").Add(2.0)
+	cv.WithLabelValues("mac:2", "500").Inc()
+
+	hv, err := f.NewHistogramVec(prometheus.HistogramOpts{Name: "duration", Help: "duration", Buckets: []float64{1.0}}, "device")
+	suite.Require().NoError(err)
+	hv.WithLabelValues("mac:1").Observe(0.5)
+	hv.WithLabelValues("mac:2").Observe(2.0)
+
+	sv, err := f.NewSummaryVec(prometheus.SummaryOpts{Name: "size", Help: "size"}, "device")
+	suite.Require().NoError(err)
+	sv.WithLabelValues("mac:1").Observe(1.0)
+	sv.WithLabelValues("mac:2").Observe(3.0)
+
+	// unrelated metrics are not decorated
+	gv, err := f.NewGaugeVec(prometheus.GaugeOpts{Name: "connections", Help: "connections"}, "pool")
+	suite.Require().NoError(err)
+	gv.WithLabelValues("main").Set(4.0)
+
+	suite.NoError(
+		testutil.GatherAndCompare(g, strings.NewReader(`
+# HELP connections connections
+# TYPE connections gauge
+connections{pool="main"} 4
+# HELP duration duration
+# TYPE duration histogram
+duration_bucket{device="REDACTED",le="1"} 1
+duration_bucket{device="REDACTED",le="+Inf"} 2
+duration_sum{device="REDACTED"} 2.5
+duration_count{device="REDACTED"} 2
+# HELP requests requests
+# TYPE requests counter
+requests{code="200",device="REDACTED"} 3
+requests{code="500",device="REDACTED"} 1
+# HELP size size
+# TYPE size summary
+size_sum{device="REDACTED"} 4
+size_count{device="REDACTED"} 2
+`)),
+	)
+
+	// the original values remain in use at the call sites
+	suite.Equal(2.0, testutil.ToFloat64(cv.WithLabelValues("mac:2", "200")))
+}
+
+func (suite *LabelTransformTestSuite) TestCombineNative() {
+	f, g := suite.newFactory(LabelTransforms{
+		Global: map[string]LabelTransformer{
+			"device": RedactLabel(""),
+		},
+	})
+
+	opts := prometheus.HistogramOpts{
+		Name:                         "duration",
+		Help:                         "duration",
+		Buckets:                      []float64{1.0},
+		NativeHistogramBucketFactor:  1.1,
+		NativeHistogramZeroThreshold: 0.001,
+	}
+
+	hv, err := f.NewHistogramVec(opts, "device")
+	suite.Require().NoError(err)
+
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Name: "requests", Help: "requests"}, "device")
+	suite.Require().NoError(err)
+
+	// the combined series must match a single histogram with every observation
+	expected := prometheus.NewHistogram(opts)
+	for i, v := range []float64{0.0, 0.5, 1.5, 1.5, -2.0, 30.0} {
+		device := "mac:" + strconv.Itoa(i%2)
+		hv.WithLabelValues(device).Observe(v)
+		expected.Observe(v)
+	}
+
+	cv.WithLabelValues("mac:0").Inc()
+	cv.WithLabelValues("mac:1").(prometheus.ExemplarAdder).AddWithExemplar(1.0, prometheus.Labels{"trace": "abc"})
+
+	families, err := g.Gather()
+	suite.Require().NoError(err)
+	suite.Require().Len(families, 2)
+
+	suite.Require().Len(families[0].GetMetric(), 1)
+	actual := families[0].GetMetric()[0].GetHistogram()
+
+	var want dto.Metric
+	suite.Require().NoError(expected.Write(&want))
+	suite.Equal(want.GetHistogram().GetSampleCount(), actual.GetSampleCount())
+	suite.Equal(want.GetHistogram().GetSchema(), actual.GetSchema())
+	suite.Equal(want.GetHistogram().GetZeroThreshold(), actual.GetZeroThreshold())
+	suite.Equal(want.GetHistogram().GetZeroCount(), actual.GetZeroCount())
+	suite.Equal(
+		decodeBuckets(want.GetHistogram().GetPositiveSpan(), want.GetHistogram().GetPositiveDelta(), nil),
+		decodeBuckets(actual.GetPositiveSpan(), actual.GetPositiveDelta(), nil),
+	)
+
+	suite.Equal(
+		decodeBuckets(want.GetHistogram().GetNegativeSpan(), want.GetHistogram().GetNegativeDelta(), nil),
+		decodeBuckets(actual.GetNegativeSpan(), actual.GetNegativeDelta(), nil),
+	)
+
+	suite.Require().Len(families[1].GetMetric(), 1)
+	counter := families[1].GetMetric()[0].GetCounter()
+	suite.Equal(2.0, counter.GetValue())
+	suite.Require().NotNil(counter.GetExemplar())
+	suite.Equal("abc", counter.GetExemplar().GetLabel()[0].GetValue())
+}
+
+func (suite *LabelTransformTestSuite) TestPerMetric() {
+	f, g := suite.newFactory(LabelTransforms{
+		Global: map[string]LabelTransformer{
+			"device": RedactLabel(""),
+		},
+		Metrics: map[string]map[string]LabelTransformer{
+			"test_requests": {
+				"device": func(v string) string { return strings.ToUpper(v) },
+			},
+		},
+	})
+
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Namespace: "test", Name: "requests", Help: "requests"}, "device")
+	suite.Require().NoError(err)
+	cv.WithLabelValues("abc").Inc()
+
+	gv, err := f.NewGaugeVec(prometheus.GaugeOpts{Namespace: "test", Name: "sessions", Help: "sessions"}, "device")
+	suite.Require().NoError(err)
+	gv.WithLabelValues("abc").Set(1.0)
+	gv.WithLabelValues("def").Set(2.0)
+
+	suite.NoError(
+		testutil.GatherAndCompare(g, strings.NewReader(`
+# HELP test_requests requests
+# TYPE test_requests counter
+test_requests{device="ABC"} 1
+# HELP test_sessions sessions
+# TYPE test_sessions gauge
+test_sessions{device="REDACTED"} 3
+`)),
+	)
+
+	// repeated gathers must not transform already transformed values
+	suite.NoError(
+		testutil.GatherAndCompare(g, strings.NewReader(`
+# HELP test_requests requests
+# TYPE test_requests counter
+test_requests{device="ABC"} 1
+`), "test_requests"),
+	)
+}
+
+func (suite *LabelTransformTestSuite) TestExistingCollector() {
+	f, _ := suite.newFactory(LabelTransforms{
+		Global: map[string]LabelTransformer{
+			"device": HashLabel([]byte("key")),
+		},
+	})
+
+	first, err := NewCurriedCounterVec(f, prometheus.CounterOpts{Name: "requests", Help: "requests"}, []string{"device"}, nil)
+	suite.Require().NoError(err)
+
+	second, err := NewCurriedCounterVec(f, prometheus.CounterOpts{Name: "requests", Help: "requests"}, []string{"device"}, nil)
+	suite.Require().NoError(err)
+
+	first.WithLabelValues("abc").Inc()
+	suite.Equal(1.0, testutil.ToFloat64(second.WithLabelValues("abc")))
+}
+
+func (suite *LabelTransformTestSuite) TestProvide() {
+	var g prometheus.Gatherer
+	var f *Factory
+
+	app := suite.newTestApp(
+		Provide(),
+		fx.Supply(
+			LabelTransforms{
+				Global: map[string]LabelTransformer{
+					"device": RedactLabel("x"),
+				},
+			},
+		),
+		fx.Populate(&g, &f),
+	)
+
+	app.RequireStart()
+	defer app.RequireStop()
+
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Name: "requests", Help: "requests"}, "device")
+	suite.Require().NoError(err)
+	cv.WithLabelValues("abc").Inc()
+
+	suite.NoError(
+		testutil.GatherAndCompare(g, strings.NewReader(`
+# HELP requests requests
+# TYPE requests counter
+requests{device="x"} 1
+`), "requests"),
+	)
+}
+
+func TestLabelTransform(t *testing.T) {
+	suite.Run(t, new(LabelTransformTestSuite))
+}
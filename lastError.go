@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// LastErrorSuffix is appended to the name of an error counter, less any _count,
+	// _total, or _error suffix, to produce the name of its last error gauge.
+	// See LastErrorOpts.
+	LastErrorSuffix = "_last_error_info"
+
+	// MessageHashLabel is the label of a LastError that holds the hash of the most
+	// recent error message.
+	MessageHashLabel = "message_hash"
+)
+
+// MessageHash returns the label value used by a LastError for the given error message.
+// This is the 32-bit FNV-1a hash of the message, as 8 hexadecimal digits, which bounds
+// the size of the label while still allowing a message to be matched with log output.
+func MessageHash(message string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(message))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// LastErrorOpts derives the options of a last error gauge from the options of the error
+// counter it accompanies.  The namespace, subsystem, and const labels are the same,
+// and the name is the counter's name with any _count or _total suffix and then any _error
+// or _errors suffix replaced by LastErrorSuffix, e.g. client_error_count becomes
+// client_last_error_info.
+func LastErrorOpts(o prometheus.CounterOpts) prometheus.GaugeOpts {
+	name := o.Name
+	for _, suffixes := range [][]string{{"_count", "_total"}, {"_errors", "_error"}} {
+		for _, s := range suffixes {
+			if trimmed := strings.TrimSuffix(name, s); trimmed != name {
+				name = trimmed
+				break
+			}
+		}
+	}
+
+	return prometheus.GaugeOpts{
+		Namespace:   o.Namespace,
+		Subsystem:   o.Subsystem,
+		Name:        name + LastErrorSuffix,
+		Help:        fmt.Sprintf("The most recent failure counted by %s", o.Name),
+		ConstLabels: o.ConstLabels,
+	}
+}
+
+// LastError maintains a gauge with a single series, with the value 1, describing the most
+// recent error.  The ReasonLabel holds the reason for the error, and the MessageHashLabel
+// holds the MessageHash of its message.  Each new error replaces the previous series.
+//
+// A LastError accompanies an error counter, making it possible to see what the most recent
+// failure was from metrics alone, e.g. in environments without centralized logging.
+//
+// A LastError is safe for concurrent use.
+type LastError struct {
+	vec *prometheus.GaugeVec
+
+	lock    sync.Mutex
+	current []string
+}
+
+// NewLastError creates a LastError that maintains the given gauge vector.  The vector's
+// only uncurried labels must be ReasonLabel and MessageHashLabel, in that order.  The vector
+// may be curried, in which case only the series with the curried labels are replaced.
+func NewLastError(gv *prometheus.GaugeVec) *LastError {
+	return &LastError{
+		vec: gv,
+	}
+}
+
+// Set records err as the most recent error with the given reason.  A nil error is ignored.
+func (le *LastError) Set(reason string, err error) {
+	if err == nil {
+		return
+	}
+
+	next := []string{reason, MessageHash(err.Error())}
+	le.lock.Lock()
+	defer le.lock.Unlock()
+
+	g, getErr := le.vec.GetMetricWithLabelValues(next...)
+	if getErr != nil {
+		return
+	}
+
+	if le.current != nil && (le.current[0] != next[0] || le.current[1] != next[1]) {
+		le.vec.DeleteLabelValues(le.current...)
+	}
+
+	g.Set(1.0)
+	le.current = next
+}
+
+// Clear removes the series describing the most recent error, if any.
+func (le *LastError) Clear() {
+	le.lock.Lock()
+	defer le.lock.Unlock()
+
+	if le.current != nil {
+		le.vec.DeleteLabelValues(le.current...)
+		le.current = nil
+	}
+}
+
+// NewLastError creates and registers a gauge vector with the ReasonLabel and
+// MessageHashLabel, then returns a LastError that maintains it.  See LastErrorOpts
+// for deriving the options from an error counter.
+//
+// This method returns an error if the options do not specify a name.  Both namespace
+// and subsystem are defaulted appropriately if not set in the options.
+func (f *Factory) NewLastError(o prometheus.GaugeOpts) (*LastError, error) {
+	gv, err := f.NewGaugeVec(o, ReasonLabel, MessageHashLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLastError(gv), nil
+}
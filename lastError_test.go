@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type LastErrorTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *LastErrorTestSuite) TestMessageHash() {
+	suite.Len(MessageHash(""), 8)
+	suite.Len(MessageHash(strings.Repeat("a long message ", 100)), 8)
+	suite.Equal(MessageHash("connection refused"), MessageHash("connection refused"))
+	suite.NotEqual(MessageHash("connection refused"), MessageHash("connection reset"))
+}
+
+func (suite *LastErrorTestSuite) TestLastErrorOpts() {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "client_error_count", expected: "client_last_error_info"},
+		{name: "dial_errors_total", expected: "dial_last_error_info"},
+		{name: "logins", expected: "logins_last_error_info"},
+		{name: "error_count", expected: "error_last_error_info"},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.name, func() {
+			o := LastErrorOpts(prometheus.CounterOpts{
+				Namespace:   "ns",
+				Subsystem:   "sub",
+				Name:        testCase.name,
+				ConstLabels: prometheus.Labels{"key": "value"},
+			})
+
+			suite.Equal("ns", o.Namespace)
+			suite.Equal("sub", o.Subsystem)
+			suite.Equal(testCase.expected, o.Name)
+			suite.Contains(o.Help, testCase.name)
+			suite.Equal(prometheus.Labels{"key": "value"}, o.ConstLabels)
+		})
+	}
+}
+
+func (suite *LastErrorTestSuite) TestSet() {
+	gv := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "last_error_info", Help: "test"},
+		[]string{"client", ReasonLabel, MessageHashLabel},
+	)
+
+	var (
+		main = NewLastError(gv.MustCurryWith(prometheus.Labels{"client": "main"}))
+		alt  = NewLastError(gv.MustCurryWith(prometheus.Labels{"client": "alt"}))
+	)
+
+	main.Set("timeout", nil)
+	suite.Zero(testutil.CollectAndCount(gv))
+
+	main.Set("timeout", errors.New("first"))
+	alt.Set("refused", errors.New("other"))
+	main.Set("timeout", errors.New("first"))
+	suite.NoError(testutil.CollectAndCompare(gv, strings.NewReader(`
+# HELP last_error_info test
+# TYPE last_error_info gauge
+last_error_info{client="alt",message_hash="`+MessageHash("other")+`",reason="refused"} 1
+last_error_info{client="main",message_hash="`+MessageHash("first")+`",reason="timeout"} 1
+`)))
+
+	// each error replaces the previous one, without touching the other client
+	main.Set("refused", errors.New("second"))
+	suite.NoError(testutil.CollectAndCompare(gv, strings.NewReader(`
+# HELP last_error_info test
+# TYPE last_error_info gauge
+last_error_info{client="alt",message_hash="`+MessageHash("other")+`",reason="refused"} 1
+last_error_info{client="main",message_hash="`+MessageHash("second")+`",reason="refused"} 1
+`)))
+
+	// an invalid reason leaves the previous error in place
+	main.Set("\xff", errors.New("third"))
+	suite.Equal(2, testutil.CollectAndCount(gv))
+
+	main.Clear()
+	main.Clear()
+	suite.Equal(1, testutil.CollectAndCount(gv))
+}
+
+func (suite *LastErrorTestSuite) TestFactory() {
+	g, r, err := New(Config{})
+	suite.Require().NoError(err)
+
+	f := NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r)
+	le, err := f.NewLastError(LastErrorOpts(prometheus.CounterOpts{Name: "logins_total", Help: "test"}))
+	suite.Require().NoError(err)
+	suite.Require().NotNil(le)
+
+	le.Set("denied", errors.New("expected"))
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+
+	var found bool
+	for _, mf := range mfs {
+		found = found || mf.GetName() == "test_logins_last_error_info"
+	}
+
+	suite.True(found)
+
+	_, err = f.NewLastError(prometheus.GaugeOpts{})
+	suite.Error(err)
+}
+
+func TestLastError(t *testing.T) {
+	suite.Run(t, new(LastErrorTestSuite))
+}
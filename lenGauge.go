@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Lener is implemented by containers that can report their length, e.g.
+// *list.List or *ring.Ring from the standard library.
+type Lener interface {
+	Len() int
+}
+
+// NewLenGauge creates and registers a gauge that reports the length of
+// a container each time metrics are gathered.  This is the typical way of
+// exposing the depth of a queue or buffer.
+//
+// The lener must be safe for concurrent use, since it will be invoked on
+// the goroutine that gathers metrics.
+func (f *Factory) NewLenGauge(o prometheus.GaugeOpts, lener Lener) (prometheus.GaugeFunc, error) {
+	return f.NewGaugeFunc(o, func() float64 {
+		return float64(lener.Len())
+	})
+}
+
+// NewChanDepthGauge creates and registers a gauge that reports the number of
+// elements queued in a channel each time metrics are gathered.
+//
+// This function is generic, so it cannot be a method of Factory.  Any MetricFactory
+// may be used to create the gauge.
+func NewChanDepthGauge[T any](f MetricFactory, o prometheus.GaugeOpts, ch chan T) (prometheus.GaugeFunc, error) {
+	return f.NewGaugeFunc(o, func() float64 {
+		return float64(len(ch))
+	})
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"container/list"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type LenGaugeTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *LenGaugeTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{}, suite.logger, r)
+}
+
+func (suite *LenGaugeTestSuite) TestNewLenGauge() {
+	f := suite.newFactory()
+	l := list.New()
+
+	m, err := f.NewLenGauge(prometheus.GaugeOpts{Name: "test", Help: "test"}, l)
+	suite.Require().NoError(err)
+	suite.Zero(testutil.ToFloat64(m))
+
+	l.PushBack("a")
+	l.PushBack("b")
+	suite.Equal(2.0, testutil.ToFloat64(m))
+
+	_, err = f.NewLenGauge(prometheus.GaugeOpts{}, l)
+	suite.ErrorIs(err, ErrNoMetricName)
+}
+
+func (suite *LenGaugeTestSuite) TestNewChanDepthGauge() {
+	f := suite.newFactory()
+	ch := make(chan int, 10)
+
+	m, err := NewChanDepthGauge(f, prometheus.GaugeOpts{Name: "test", Help: "test"}, ch)
+	suite.Require().NoError(err)
+	suite.Zero(testutil.ToFloat64(m))
+
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	suite.Equal(3.0, testutil.ToFloat64(m))
+
+	<-ch
+	suite.Equal(2.0, testutil.ToFloat64(m))
+
+	_, err = NewChanDepthGauge(f, prometheus.GaugeOpts{}, ch)
+	suite.ErrorIs(err, ErrNoMetricName)
+}
+
+func TestLenGauge(t *testing.T) {
+	suite.Run(t, new(LenGaugeTestSuite))
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"strings"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// printerWriter is a zapcore.WriteSyncer that writes each encoded entry to an fx.Printer.
+type printerWriter struct {
+	printer fx.Printer
+}
+
+func (pw printerWriter) Write(p []byte) (int, error) {
+	pw.printer.Printf("%s", strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+func (pw printerWriter) Sync() error {
+	return nil
+}
+
+// NewPrinterLogger adapts an fx.Printer into a *zap.Logger.  Each entry is written
+// to the Printer as a single line containing the level, the message, and any fields.
+//
+// The *zap.Logger is the single logging component used by all touchstone packages,
+// including the Factory and the touchhttp metrics handler.  Applications that only
+// have an fx.Printer can use this function to supply one:
+//
+//	app := fx.New(
+//	  fx.Provide(touchstone.NewPrinterLogger),
+//	  touchstone.Provide(),
+//	  touchhttp.Provide(),
+//	)
+func NewPrinterLogger(p fx.Printer) *zap.Logger {
+	ec := zap.NewDevelopmentEncoderConfig()
+	ec.TimeKey = ""
+	ec.CallerKey = ""
+	ec.StacktraceKey = ""
+	ec.ConsoleSeparator = " "
+
+	return zap.New(
+		zapcore.NewCore(
+			zapcore.NewConsoleEncoder(ec),
+			printerWriter{printer: p},
+			zapcore.DebugLevel,
+		),
+	)
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type LoggingTestSuite struct {
+	suite.Suite
+
+	lines []string
+}
+
+// Printf lets this suite be seen as an fx.Printer.
+func (suite *LoggingTestSuite) Printf(format string, args ...interface{}) {
+	suite.lines = append(suite.lines, fmt.Sprintf(format, args...))
+}
+
+func (suite *LoggingTestSuite) SetupTest() {
+	suite.lines = nil
+}
+
+func (suite *LoggingTestSuite) TestNewPrinterLogger() {
+	l := NewPrinterLogger(suite)
+	suite.Require().NotNil(l)
+
+	l.Warn("first", zap.String("name", "value"))
+	l.Debug("second %d")
+	suite.NoError(l.Sync())
+
+	suite.Require().Len(suite.lines, 2)
+	suite.Contains(suite.lines[0], "WARN")
+	suite.Contains(suite.lines[0], "first")
+	suite.Contains(suite.lines[0], `"name": "value"`)
+	suite.NotContains(suite.lines[0], "\n")
+	suite.Contains(suite.lines[1], "second %d")
+}
+
+func (suite *LoggingTestSuite) TestFactoryWarnings() {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+
+	f := NewFactory(Config{}, NewPrinterLogger(suite), r)
+	_, err = f.NewCounter(prometheus.CounterOpts{Name: "no_help"})
+	suite.Require().NoError(err)
+
+	suite.Require().Len(suite.lines, 1)
+	suite.Contains(suite.lines[0], "No help set for metric")
+	suite.Contains(suite.lines[0], "no_help")
+}
+
+func TestLogging(t *testing.T) {
+	suite.Run(t, new(LoggingTestSuite))
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// MetricFactory is the behavior of a Factory.  Code that creates metrics should
+// depend on this interface rather than *Factory, which allows metric creation to
+// be decorated, e.g. with test doubles or quota enforcement.
+//
+// A decorator will typically embed a MetricFactory and override only the methods
+// it cares about:
+//
+//	type countingFactory struct {
+//	  touchstone.MetricFactory
+//	  created int
+//	}
+//
+//	func (cf *countingFactory) NewCounterVec(o prometheus.CounterOpts, labelNames ...string) (*prometheus.CounterVec, error) {
+//	  cf.created++
+//	  return cf.MetricFactory.NewCounterVec(o, labelNames...)
+//	}
+//
+// Within an fx.App, use fx.Decorate to replace the MetricFactory component.  All of
+// this module's packages consume the MetricFactory component.
+type MetricFactory interface {
+	// DefaultNamespace returns the namespace applied to metrics that do not specify one.
+	DefaultNamespace() string
+
+	// DefaultSubsystem returns the subsystem applied to metrics that do not specify one.
+	DefaultSubsystem() string
+
+	// New creates a dynamically typed metric based on the type of options passed.
+	New(o interface{}) (prometheus.Collector, error)
+
+	// NewVec creates a dynamically typed metric vector based on the type of options passed.
+	NewVec(o interface{}, labelNames ...string) (prometheus.Collector, error)
+
+	// NewCounter creates a prometheus.Counter.
+	NewCounter(o prometheus.CounterOpts) (prometheus.Counter, error)
+
+	// NewCounterFunc creates a prometheus.CounterFunc.
+	NewCounterFunc(o prometheus.CounterOpts, fn func() float64) (prometheus.CounterFunc, error)
+
+	// NewCounterVec creates a *prometheus.CounterVec.
+	NewCounterVec(o prometheus.CounterOpts, labelNames ...string) (*prometheus.CounterVec, error)
+
+	// NewGauge creates a prometheus.Gauge.
+	NewGauge(o prometheus.GaugeOpts) (prometheus.Gauge, error)
+
+	// NewGaugeFunc creates a prometheus.GaugeFunc.
+	NewGaugeFunc(o prometheus.GaugeOpts, fn func() float64) (prometheus.GaugeFunc, error)
+
+	// NewGaugeVec creates a *prometheus.GaugeVec.
+	NewGaugeVec(o prometheus.GaugeOpts, labelNames ...string) (*prometheus.GaugeVec, error)
+
+	// NewUntypedFunc creates a prometheus.UntypedFunc.
+	NewUntypedFunc(o prometheus.UntypedOpts, fn interface{}) (prometheus.UntypedFunc, error)
+
+	// NewHistogram creates a histogram, returned as a prometheus.Observer.
+	NewHistogram(o prometheus.HistogramOpts) (prometheus.Observer, error)
+
+	// NewHistogramVec creates a histogram vector, returned as a prometheus.ObserverVec.
+	NewHistogramVec(o prometheus.HistogramOpts, labelNames ...string) (prometheus.ObserverVec, error)
+
+	// NewSummary creates a summary, returned as a prometheus.Observer.
+	NewSummary(o prometheus.SummaryOpts) (prometheus.Observer, error)
+
+	// NewSummaryVec creates a summary vector, returned as a prometheus.ObserverVec.
+	NewSummaryVec(o prometheus.SummaryOpts, labelNames ...string) (prometheus.ObserverVec, error)
+
+	// NewObserver creates a histogram or summary based on the type of options passed.
+	NewObserver(o interface{}) (prometheus.Observer, error)
+
+	// NewObserverVec creates a histogram or summary vector based on the type of options passed.
+	NewObserverVec(o interface{}, labelNames ...string) (prometheus.ObserverVec, error)
+
+	// NewStateSet creates a *StateSet.
+	NewStateSet(o prometheus.GaugeOpts, label string, states ...string) (*StateSet, error)
+
+	// NewOutcome creates an *Outcome.
+	NewOutcome(o prometheus.CounterOpts, reasons ...string) (*Outcome, error)
+
+	// Clock returns the Clock that metrics created from this factory should use
+	// to compute durations.
+	Clock() Clock
+}
+
+var _ MetricFactory = (*Factory)(nil)
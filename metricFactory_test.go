@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+// countingFactory is a decorator that records the names of the counters it creates.
+type countingFactory struct {
+	MetricFactory
+	names []string
+}
+
+func (cf *countingFactory) NewCounter(o prometheus.CounterOpts) (prometheus.Counter, error) {
+	cf.names = append(cf.names, o.Name)
+	return cf.MetricFactory.NewCounter(o)
+}
+
+func (cf *countingFactory) NewCounterVec(o prometheus.CounterOpts, labelNames ...string) (*prometheus.CounterVec, error) {
+	cf.names = append(cf.names, o.Name)
+	return cf.MetricFactory.NewCounterVec(o, labelNames...)
+}
+
+type MetricFactoryTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *MetricFactoryTestSuite) TestDecorate() {
+	type metrics struct {
+		fx.In
+
+		C  prometheus.Counter     `name:"counter"`
+		CV *prometheus.CounterVec `name:"counterVec"`
+		G  prometheus.Gauge       `name:"gauge"`
+	}
+
+	var (
+		cf = new(countingFactory)
+		m  metrics
+	)
+
+	app := suite.newTestApp(
+		Provide(),
+		fx.Decorate(
+			func(mf MetricFactory) MetricFactory {
+				cf.MetricFactory = mf
+				return cf
+			},
+		),
+		Counter(prometheus.CounterOpts{Name: "counter"}),
+		CounterVec(prometheus.CounterOpts{Name: "counterVec"}, "label"),
+		Gauge(prometheus.GaugeOpts{Name: "gauge"}),
+		fx.Populate(&m),
+	)
+
+	app.RequireStart()
+	suite.NotNil(m.C)
+	suite.NotNil(m.CV)
+	suite.NotNil(m.G)
+	suite.ElementsMatch([]string{"counter", "counterVec"}, cf.names)
+	app.RequireStop()
+}
+
+func TestMetricFactory(t *testing.T) {
+	suite.Run(t, new(MetricFactoryTestSuite))
+}
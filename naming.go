@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import "strings"
+
+// NamingPolicy is a cross-cutting strategy for naming metrics.  A Factory consults
+// its policy for every metric it creates, including the metrics created by
+// touchbundle, touchhttp, and the other packages in this module.  This allows
+// organization-wide naming conventions and migrations to happen in one place.
+//
+// A NamingPolicy is applied to the Name field of an *Opts struct, after defaults
+// have been applied.  The Namespace and Subsystem are unaffected.
+type NamingPolicy interface {
+	// MetricName returns the name to use in place of the given name.
+	MetricName(name string) string
+}
+
+// NamingPolicyFunc is a function type that implements NamingPolicy.
+type NamingPolicyFunc func(string) string
+
+// MetricName satisfies the NamingPolicy interface.
+func (npf NamingPolicyFunc) MetricName(name string) string {
+	return npf(name)
+}
+
+// Naming is a configurable NamingPolicy.  The zero value of this type leaves
+// names unchanged.
+type Naming struct {
+	// Prefix is prepended to each metric name, separated by an underscore.  Names that
+	// already begin with this prefix are left as is.
+	Prefix string `json:"prefix" yaml:"prefix"`
+
+	// Suffix is appended to each metric name, separated by an underscore.  Names that
+	// already end with this suffix are left as is.
+	Suffix string `json:"suffix" yaml:"suffix"`
+
+	// Rename maps legacy metric names onto their replacements.  Replacement names are
+	// used exactly as given, without the Prefix or Suffix.
+	Rename map[string]string `json:"rename" yaml:"rename"`
+}
+
+var _ NamingPolicy = Naming{}
+
+// MetricName applies this naming policy to a metric name.
+func (n Naming) MetricName(name string) string {
+	if renamed, ok := n.Rename[name]; ok {
+		return renamed
+	}
+
+	if len(n.Prefix) > 0 && !strings.HasPrefix(name, n.Prefix+"_") {
+		name = n.Prefix + "_" + name
+	}
+
+	if len(n.Suffix) > 0 && !strings.HasSuffix(name, "_"+n.Suffix) {
+		name = name + "_" + n.Suffix
+	}
+
+	return name
+}
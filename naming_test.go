@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+type NamingTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *NamingTestSuite) TestMetricName() {
+	testCases := []struct {
+		naming   Naming
+		name     string
+		expected string
+	}{
+		{
+			naming:   Naming{},
+			name:     "requests",
+			expected: "requests",
+		},
+		{
+			naming:   Naming{Prefix: "acme"},
+			name:     "requests",
+			expected: "acme_requests",
+		},
+		{
+			naming:   Naming{Prefix: "acme"},
+			name:     "acme_requests",
+			expected: "acme_requests",
+		},
+		{
+			naming:   Naming{Suffix: "total"},
+			name:     "requests",
+			expected: "requests_total",
+		},
+		{
+			naming:   Naming{Prefix: "acme", Suffix: "total"},
+			name:     "requests_total",
+			expected: "acme_requests_total",
+		},
+		{
+			naming: Naming{
+				Prefix: "acme",
+				Rename: map[string]string{"legacy_requests": "requests_total"},
+			},
+			name:     "legacy_requests",
+			expected: "requests_total",
+		},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.name, func() {
+			suite.Equal(testCase.expected, testCase.naming.MetricName(testCase.name))
+		})
+	}
+}
+
+func (suite *NamingTestSuite) TestFactory() {
+	g, r, err := New(Config{DefaultNamespace: "n"})
+	suite.Require().NoError(err)
+
+	f := NewFactory(
+		Config{DefaultNamespace: "n"},
+		suite.logger,
+		r,
+		WithNamingPolicy(NamingPolicyFunc(strings.ToLower)),
+	)
+
+	c, err := f.NewCounter(prometheus.CounterOpts{Name: "REQUESTS", Help: "test"})
+	suite.Require().NoError(err)
+	c.Inc()
+
+	suite.NoError(
+		testutil.GatherAndCompare(
+			g,
+			strings.NewReader("# HELP n_requests test\n# TYPE n_requests counter\nn_requests 1\n"),
+			"n_requests",
+		),
+	)
+}
+
+func (suite *NamingTestSuite) TestProvide() {
+	var c prometheus.Counter
+	app := suite.newTestApp(
+		Provide(),
+		fx.Supply(
+			fx.Annotate(
+				Naming{Prefix: "acme"},
+				fx.As(new(NamingPolicy)),
+			),
+		),
+		fx.Invoke(func(f MetricFactory) (err error) {
+			c, err = f.NewCounter(prometheus.CounterOpts{Name: "requests"})
+			return
+		}),
+	)
+
+	app.RequireStart()
+	suite.Require().NotNil(c)
+	suite.Contains(c.Desc().String(), `"acme_requests"`)
+	app.RequireStop()
+}
+
+func TestNaming(t *testing.T) {
+	suite.Run(t, new(NamingTestSuite))
+}
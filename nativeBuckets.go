@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"math"
+	"sort"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// nativeBuckets holds the absolute counts of one side, positive or negative, of a
+// native histogram keyed by bucket index.
+type nativeBuckets map[int32]float64
+
+// isNative tests if a histogram carries native histogram data.  The prometheus
+// client always sets the schema and zero threshold of native histograms.
+func isNative(h *dto.Histogram) bool {
+	return h.Schema != nil || h.ZeroThreshold != nil
+}
+
+// isFloat tests if a native histogram uses float counts rather than integer deltas.
+func isFloat(h *dto.Histogram) bool {
+	return len(h.GetPositiveCount()) > 0 || len(h.GetNegativeCount()) > 0 ||
+		h.GetZeroCountFloat() > 0 || h.GetSampleCountFloat() > 0
+}
+
+// decodeBuckets expands spans and either integer deltas or float counts into
+// absolute counts.
+func decodeBuckets(spans []*dto.BucketSpan, deltas []int64, counts []float64) nativeBuckets {
+	var (
+		nb    = make(nativeBuckets)
+		index int32
+		last  int64
+		i     int
+	)
+
+	for _, s := range spans {
+		index += s.GetOffset()
+		for j := uint32(0); j < s.GetLength(); j, i, index = j+1, i+1, index+1 {
+			switch {
+			case i < len(deltas):
+				last += deltas[i]
+				nb[index] += float64(last)
+
+			case i < len(counts):
+				nb[index] += counts[i]
+			}
+		}
+	}
+
+	return nb
+}
+
+// upperBound returns the upper bound of the bucket with the given index and schema.
+func upperBound(index, schema int32) float64 {
+	return math.Exp2(float64(index) * math.Exp2(-float64(schema)))
+}
+
+// rescale moves these buckets from one schema to another, coarser schema.  Buckets
+// that lie within the zero threshold are removed, and their total is returned.
+func (nb nativeBuckets) rescale(from, to int32, zeroThreshold float64) (nativeBuckets, float64) {
+	var (
+		rescaled = make(nativeBuckets, len(nb))
+		zero     float64
+	)
+
+	for index, count := range nb {
+		if upperBound(index, from) <= zeroThreshold {
+			zero += count
+			continue
+		}
+
+		if from > to {
+			index = ((index - 1) >> (from - to)) + 1
+		}
+
+		rescaled[index] += count
+	}
+
+	return rescaled, zero
+}
+
+// add adds the counts of another set of buckets to these.
+func (nb nativeBuckets) add(other nativeBuckets) {
+	for index, count := range other {
+		nb[index] += count
+	}
+}
+
+// encode produces the spans and either the integer deltas or the float counts
+// of these buckets.
+func (nb nativeBuckets) encode(float bool) (spans []*dto.BucketSpan, deltas []int64, counts []float64) {
+	indices := make([]int32, 0, len(nb))
+	for index := range nb {
+		indices = append(indices, index)
+	}
+
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+	var last int64
+	for i, index := range indices {
+		if i == 0 || index != indices[i-1]+1 {
+			offset := index
+			if i > 0 {
+				offset = index - indices[i-1] - 1
+			}
+
+			spans = append(spans, &dto.BucketSpan{Offset: &offset, Length: new(uint32)})
+		}
+
+		*spans[len(spans)-1].Length++
+		if float {
+			counts = append(counts, nb[index])
+		} else {
+			count := int64(nb[index])
+			deltas = append(deltas, count-last)
+			last = count
+		}
+	}
+
+	return
+}
+
+// combineNative adds the native buckets of next into the combined histogram h.  The
+// result uses the coarser schema and the wider zero bucket of the two.
+func combineNative(h, current, next *dto.Histogram) {
+	var (
+		schema        = current.GetSchema()
+		zeroThreshold = math.Max(current.GetZeroThreshold(), next.GetZeroThreshold())
+		float         = isFloat(current) || isFloat(next)
+		positive      = make(nativeBuckets)
+		negative      = make(nativeBuckets)
+		zero          float64
+	)
+
+	if next.GetSchema() < schema {
+		schema = next.GetSchema()
+	}
+
+	for _, side := range []*dto.Histogram{current, next} {
+		zero += float64(side.GetZeroCount()) + side.GetZeroCountFloat()
+
+		p, pz := decodeBuckets(side.GetPositiveSpan(), side.GetPositiveDelta(), side.GetPositiveCount()).
+			rescale(side.GetSchema(), schema, zeroThreshold)
+		n, nz := decodeBuckets(side.GetNegativeSpan(), side.GetNegativeDelta(), side.GetNegativeCount()).
+			rescale(side.GetSchema(), schema, zeroThreshold)
+
+		positive.add(p)
+		negative.add(n)
+		zero += pz + nz
+	}
+
+	h.Schema = &schema
+	h.ZeroThreshold = &zeroThreshold
+	h.PositiveSpan, h.PositiveDelta, h.PositiveCount = positive.encode(float)
+	h.NegativeSpan, h.NegativeDelta, h.NegativeCount = negative.encode(float)
+	h.Exemplars = append(append([]*dto.Exemplar(nil), current.GetExemplars()...), next.GetExemplars()...)
+
+	if float {
+		sampleCount := float64(h.GetSampleCount()) + current.GetSampleCountFloat() + next.GetSampleCountFloat()
+		h.SampleCount = nil
+		h.SampleCountFloat = &sampleCount
+		h.ZeroCountFloat = &zero
+	} else {
+		zeroCount := uint64(zero)
+		h.ZeroCount = &zeroCount
+	}
+
+	if len(h.PositiveSpan) == 0 && len(h.NegativeSpan) == 0 && zero == 0 && zeroThreshold == 0 {
+		// the prometheus client marks an empty native histogram with an empty span
+		h.PositiveSpan = []*dto.BucketSpan{{Offset: new(int32), Length: new(uint32)}}
+	}
+}
@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/proto"
+)
+
+type NativeBucketsSuite struct {
+	suite.Suite
+}
+
+func (suite *NativeBucketsSuite) TestEncode() {
+	nb := nativeBuckets{-2: 1, 0: 3, 1: 2, 5: 4}
+
+	spans, deltas, counts := nb.encode(false)
+	suite.Len(spans, 3)
+	suite.Equal([]int64{1, 2, -1, 2}, deltas)
+	suite.Empty(counts)
+	suite.Equal(nb, decodeBuckets(spans, deltas, nil))
+
+	spans, deltas, counts = nb.encode(true)
+	suite.Empty(deltas)
+	suite.Equal([]float64{1, 3, 2, 4}, counts)
+	suite.Equal(nb, decodeBuckets(spans, nil, counts))
+
+	spans, deltas, counts = nativeBuckets{}.encode(false)
+	suite.Empty(spans)
+	suite.Empty(deltas)
+	suite.Empty(counts)
+}
+
+func (suite *NativeBucketsSuite) TestRescale() {
+	// at schema 1, bucket 3 is (2, 2.83] and bucket 4 is (2.83, 4]
+	rescaled, zero := nativeBuckets{1: 1, 2: 2, 3: 3, 4: 4}.rescale(1, 0, 0.0)
+	suite.Equal(nativeBuckets{1: 3, 2: 7}, rescaled)
+	suite.Zero(zero)
+
+	// buckets within a wider zero threshold move to the zero bucket
+	rescaled, zero = nativeBuckets{-1: 1, 0: 2, 1: 3}.rescale(0, 0, 1.0)
+	suite.Equal(nativeBuckets{1: 3}, rescaled)
+	suite.Equal(3.0, zero)
+}
+
+func (suite *NativeBucketsSuite) TestCombineNative() {
+	var (
+		current = &dto.Histogram{
+			SampleCount:   proto.Uint64(3),
+			Schema:        proto.Int32(1),
+			ZeroThreshold: proto.Float64(0.5),
+			ZeroCount:     proto.Uint64(1),
+			PositiveSpan:  []*dto.BucketSpan{{Offset: proto.Int32(3), Length: proto.Uint32(2)}},
+			PositiveDelta: []int64{1, 0},
+			Exemplars:     []*dto.Exemplar{{Value: proto.Float64(2.5)}},
+		}
+
+		next = &dto.Histogram{
+			SampleCount:   proto.Uint64(2),
+			Schema:        proto.Int32(0),
+			ZeroThreshold: proto.Float64(1.0),
+			NegativeSpan:  []*dto.BucketSpan{{Offset: proto.Int32(0), Length: proto.Uint32(2)}},
+			NegativeDelta: []int64{1, 0},
+		}
+
+		h = &dto.Histogram{SampleCount: proto.Uint64(5)}
+	)
+
+	combineNative(h, current, next)
+	suite.Equal(int32(0), h.GetSchema())
+	suite.Equal(1.0, h.GetZeroThreshold())
+	suite.Equal(uint64(2), h.GetZeroCount())
+	suite.Equal(nativeBuckets{2: 2}, decodeBuckets(h.GetPositiveSpan(), h.GetPositiveDelta(), nil))
+	suite.Equal(nativeBuckets{1: 1}, decodeBuckets(h.GetNegativeSpan(), h.GetNegativeDelta(), nil))
+	suite.Len(h.GetExemplars(), 1)
+}
+
+func (suite *NativeBucketsSuite) TestCombineNativeFloat() {
+	var (
+		current = &dto.Histogram{
+			SampleCountFloat: proto.Float64(1.5),
+			Schema:           proto.Int32(0),
+			ZeroThreshold:    proto.Float64(0.0),
+			PositiveSpan:     []*dto.BucketSpan{{Offset: proto.Int32(1), Length: proto.Uint32(1)}},
+			PositiveCount:    []float64{1.5},
+		}
+
+		next = &dto.Histogram{
+			SampleCount:   proto.Uint64(1),
+			Schema:        proto.Int32(0),
+			ZeroThreshold: proto.Float64(0.0),
+			ZeroCount:     proto.Uint64(1),
+		}
+
+		h = &dto.Histogram{SampleCount: proto.Uint64(1)}
+	)
+
+	combineNative(h, current, next)
+	suite.Nil(h.SampleCount)
+	suite.Equal(2.5, h.GetSampleCountFloat())
+	suite.Equal(1.0, h.GetZeroCountFloat())
+	suite.Equal([]float64{1.5}, h.GetPositiveCount())
+	suite.Empty(h.GetPositiveDelta())
+}
+
+func (suite *NativeBucketsSuite) TestCombineEmpty() {
+	var (
+		current = &dto.Histogram{Schema: proto.Int32(3), ZeroThreshold: proto.Float64(0.0)}
+		next    = &dto.Histogram{Schema: proto.Int32(3), ZeroThreshold: proto.Float64(0.0)}
+		h       = new(dto.Histogram)
+	)
+
+	combineNative(h, current, next)
+	suite.Len(h.GetPositiveSpan(), 1)
+	suite.Zero(h.GetPositiveSpan()[0].GetLength())
+	suite.True(isNative(h))
+}
+
+func TestNativeBuckets(t *testing.T) {
+	suite.Run(t, new(NativeBucketsSuite))
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/multierr"
+)
+
+// NativeHistograms holds the defaults for the native histogram parameters of every
+// histogram created by a Factory.  Each field corresponds to a NativeHistogram field of
+// prometheus.HistogramOpts, and is only applied to histograms that do not set that field.
+// With a BucketFactor, every histogram opts in to native histograms without any per-metric
+// configuration.  Classic buckets are still exposed alongside native ones.
+//
+// An individual histogram can opt out by setting its NativeHistogramBucketFactor to 1,
+// which the prometheus client treats as disabling native histograms.
+//
+// See: https://pkg.go.dev/github.com/prometheus/client_golang/prometheus#HistogramOpts
+type NativeHistograms struct {
+	// BucketFactor is the growth factor between adjacent native buckets, e.g. 1.1.
+	// If unset, native histograms are not enabled by default.  If set, it must be greater than 1.
+	BucketFactor float64 `json:"bucketFactor" yaml:"bucketFactor"`
+
+	// ZeroThreshold is the width of the zero bucket.  If unset, the prometheus client's
+	// default is used.  A negative value, e.g. prometheus.NativeHistogramZeroThresholdZero,
+	// requests a zero bucket that only holds observations of exactly zero.
+	ZeroThreshold float64 `json:"zeroThreshold" yaml:"zeroThreshold"`
+
+	// MaxBucketNumber limits the number of native buckets.  If unset, there is no limit.
+	MaxBucketNumber uint32 `json:"maxBucketNumber" yaml:"maxBucketNumber"`
+
+	// MinResetDuration is the minimum time between resets of a histogram that has
+	// exceeded MaxBucketNumber.
+	MinResetDuration time.Duration `json:"minResetDuration" yaml:"minResetDuration"`
+
+	// MaxZeroThreshold is the largest width to which the zero bucket may grow in order
+	// to stay within MaxBucketNumber.
+	MaxZeroThreshold float64 `json:"maxZeroThreshold" yaml:"maxZeroThreshold"`
+
+	// MaxExemplars is the maximum number of exemplars retained for native histograms.
+	// A negative value disables exemplars specific to native histograms.
+	MaxExemplars int `json:"maxExemplars" yaml:"maxExemplars"`
+
+	// ExemplarTTL is how long an exemplar is retained before it may be replaced.
+	// A negative value means the oldest exemplar is always replaced first.
+	ExemplarTTL time.Duration `json:"exemplarTTL" yaml:"exemplarTTL"`
+}
+
+// Validate checks that these parameters can be used by the prometheus client.
+func (nh NativeHistograms) Validate() (err error) {
+	if nh.BucketFactor != 0 && nh.BucketFactor <= 1 {
+		err = multierr.Append(err, errors.New("BucketFactor must be greater than 1"))
+	}
+
+	if nh.MaxZeroThreshold < 0 {
+		err = multierr.Append(err, errors.New("MaxZeroThreshold cannot be negative"))
+	}
+
+	if nh.MinResetDuration < 0 {
+		err = multierr.Append(err, errors.New("MinResetDuration cannot be negative"))
+	}
+
+	return
+}
+
+// apply sets any unset native histogram parameters of the given options.
+func (nh NativeHistograms) apply(o *prometheus.HistogramOpts) {
+	if o.NativeHistogramBucketFactor == 0 {
+		o.NativeHistogramBucketFactor = nh.BucketFactor
+	}
+
+	if o.NativeHistogramZeroThreshold == 0 {
+		o.NativeHistogramZeroThreshold = nh.ZeroThreshold
+	}
+
+	if o.NativeHistogramMaxBucketNumber == 0 {
+		o.NativeHistogramMaxBucketNumber = nh.MaxBucketNumber
+	}
+
+	if o.NativeHistogramMinResetDuration == 0 {
+		o.NativeHistogramMinResetDuration = nh.MinResetDuration
+	}
+
+	if o.NativeHistogramMaxZeroThreshold == 0 {
+		o.NativeHistogramMaxZeroThreshold = nh.MaxZeroThreshold
+	}
+
+	if o.NativeHistogramMaxExemplars == 0 {
+		o.NativeHistogramMaxExemplars = nh.MaxExemplars
+	}
+
+	if o.NativeHistogramExemplarTTL == 0 {
+		o.NativeHistogramExemplarTTL = nh.ExemplarTTL
+	}
+}
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+)
+
+type NativeHistogramsTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *NativeHistogramsTestSuite) TestValidate() {
+	suite.NoError(NativeHistograms{}.Validate())
+	suite.NoError(NativeHistograms{BucketFactor: 1.1, MaxBucketNumber: 100, MinResetDuration: time.Hour}.Validate())
+	suite.Error(NativeHistograms{BucketFactor: 1}.Validate())
+	suite.Error(NativeHistograms{BucketFactor: -2}.Validate())
+	suite.Error(NativeHistograms{MaxZeroThreshold: -1}.Validate())
+	suite.Error(NativeHistograms{MinResetDuration: -time.Second}.Validate())
+
+	// negative values that the prometheus client gives a meaning to
+	suite.NoError(NativeHistograms{ZeroThreshold: prometheus.NativeHistogramZeroThresholdZero}.Validate())
+	suite.NoError(NativeHistograms{ExemplarTTL: -time.Second}.Validate())
+	suite.NoError(NativeHistograms{MaxExemplars: -1}.Validate())
+}
+
+func (suite *NativeHistogramsTestSuite) TestApply() {
+	nh := NativeHistograms{
+		BucketFactor:     1.1,
+		ZeroThreshold:    0.001,
+		MaxBucketNumber:  160,
+		MinResetDuration: time.Hour,
+		MaxZeroThreshold: 0.01,
+		MaxExemplars:     10,
+		ExemplarTTL:      time.Minute,
+	}
+
+	suite.Run("Unset", func() {
+		var o prometheus.HistogramOpts
+		nh.apply(&o)
+		suite.Equal(1.1, o.NativeHistogramBucketFactor)
+		suite.Equal(0.001, o.NativeHistogramZeroThreshold)
+		suite.Equal(uint32(160), o.NativeHistogramMaxBucketNumber)
+		suite.Equal(time.Hour, o.NativeHistogramMinResetDuration)
+		suite.Equal(0.01, o.NativeHistogramMaxZeroThreshold)
+		suite.Equal(10, o.NativeHistogramMaxExemplars)
+		suite.Equal(time.Minute, o.NativeHistogramExemplarTTL)
+	})
+
+	suite.Run("Set", func() {
+		o := prometheus.HistogramOpts{
+			NativeHistogramBucketFactor:    1.5,
+			NativeHistogramMaxBucketNumber: 20,
+		}
+
+		nh.apply(&o)
+		suite.Equal(1.5, o.NativeHistogramBucketFactor)
+		suite.Equal(uint32(20), o.NativeHistogramMaxBucketNumber)
+		suite.Equal(time.Hour, o.NativeHistogramMinResetDuration)
+	})
+}
+
+// gatherHistogram returns the single histogram sample with the given name.
+func (suite *NativeHistogramsTestSuite) gatherHistogram(g prometheus.Gatherer, name string) *dto.Histogram {
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+	for _, mf := range mfs {
+		if mf.GetName() == name {
+			suite.Require().Len(mf.GetMetric(), 1)
+			return mf.GetMetric()[0].GetHistogram()
+		}
+	}
+
+	suite.Require().Failf("missing histogram", "no histogram named %s", name)
+	return nil
+}
+
+func (suite *NativeHistogramsTestSuite) TestFactory() {
+	r := prometheus.NewPedanticRegistry()
+	f := NewFactory(
+		Config{
+			DefaultNamespace: "test",
+			NativeHistograms: NativeHistograms{BucketFactor: 1.1},
+		},
+		suite.logger,
+		r,
+	)
+
+	suite.Run("Histogram", func() {
+		h, err := f.NewHistogram(prometheus.HistogramOpts{Name: "native", Help: "native"})
+		suite.Require().NoError(err)
+		h.Observe(1.5)
+		suite.NotNil(suite.gatherHistogram(r, "test_native").Schema)
+	})
+
+	suite.Run("HistogramVec", func() {
+		hv, err := f.NewHistogramVec(prometheus.HistogramOpts{Name: "native_vec", Help: "native"}, "label")
+		suite.Require().NoError(err)
+		hv.WithLabelValues("value").Observe(1.5)
+		suite.NotNil(suite.gatherHistogram(r, "test_native_vec").Schema)
+	})
+
+	suite.Run("Subsystem", func() {
+		h, err := f.withSubsystem("sub").NewHistogram(prometheus.HistogramOpts{Name: "native", Help: "native"})
+		suite.Require().NoError(err)
+		h.Observe(1.5)
+		suite.NotNil(suite.gatherHistogram(r, "test_sub_native").Schema)
+	})
+
+	suite.Run("OptOut", func() {
+		h, err := f.NewHistogram(prometheus.HistogramOpts{Name: "classic", Help: "classic", NativeHistogramBucketFactor: 1})
+		suite.Require().NoError(err)
+		h.Observe(1.5)
+		suite.Nil(suite.gatherHistogram(r, "test_classic").Schema)
+	})
+}
+
+func TestNativeHistograms(t *testing.T) {
+	suite.Run(t, new(NativeHistogramsTestSuite))
+}
@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	// OutcomeLabel is the label of an Outcome that distinguishes successes from failures.
+	OutcomeLabel = "outcome"
+
+	// ReasonLabel is the label of an Outcome that holds the reason for a failure.
+	// Successes have an empty reason.
+	ReasonLabel = "reason"
+
+	// OutcomeSuccess is the OutcomeLabel value for successes.
+	OutcomeSuccess = "success"
+
+	// OutcomeFailure is the OutcomeLabel value for failures.
+	OutcomeFailure = "failure"
+
+	// ReasonOther is the ReasonLabel value for failures whose reason was not declared
+	// when an Outcome was created, or which is not a valid label value.
+	ReasonOther = "other"
+)
+
+// Outcome counts the successes and failures of an operation in a single counter
+// vector with an OutcomeLabel and a ReasonLabel.  This is the most common shape of
+// application metric, and using this type keeps its labels consistent everywhere.
+//
+// An Outcome may declare the reasons for its failures, in which case any other reason
+// is counted as ReasonOther.  This bounds the cardinality of the counter.  An Outcome
+// without declared reasons records each reason as is.
+//
+// An Outcome is a prometheus.Collector and is safe for concurrent use.
+type Outcome struct {
+	vec     *prometheus.CounterVec
+	success prometheus.Counter
+
+	// reasons are the counters of the declared reasons, nil if there are none,
+	// and other is the counter for any other reason
+	reasons map[string]prometheus.Counter
+	other   prometheus.Counter
+
+	// lastError is the optional LastError updated by Record
+	lastError *LastError
+}
+
+// NewOutcome creates an Outcome that is not registered with any registry.  The series
+// for success and for each declared reason are created with zero values, as is the
+// series for ReasonOther when there are declared reasons.
+//
+// Unlike the Factory method, this function applies no defaults or naming policy.
+func NewOutcome(o prometheus.CounterOpts, reasons ...string) (*Outcome, error) {
+	vec := prometheus.NewCounterVec(o, []string{OutcomeLabel, ReasonLabel})
+	success, err := vec.GetMetricWithLabelValues(OutcomeSuccess, "")
+	if err != nil {
+		return nil, err
+	}
+
+	oc := &Outcome{
+		vec:     vec,
+		success: success,
+	}
+
+	if len(reasons) > 0 {
+		oc.other = vec.WithLabelValues(OutcomeFailure, ReasonOther)
+		oc.reasons = make(map[string]prometheus.Counter, len(reasons))
+		for _, r := range reasons {
+			c, err := vec.GetMetricWithLabelValues(OutcomeFailure, r)
+			if err != nil {
+				return nil, fmt.Errorf("invalid reason %q: %w", r, err)
+			}
+
+			oc.reasons[r] = c
+		}
+	}
+
+	return oc, nil
+}
+
+// Success counts a successful operation.
+func (oc *Outcome) Success() {
+	oc.success.Inc()
+}
+
+// Failure counts a failed operation with the given reason.
+func (oc *Outcome) Failure(reason string) {
+	oc.failure(reason).Inc()
+}
+
+// TrackLastError sets the LastError that Record updates with each failure.  This
+// method must be called before the Outcome is used, and returns this Outcome so that
+// it can be chained with creation.
+func (oc *Outcome) TrackLastError(le *LastError) *Outcome {
+	oc.lastError = le
+	return oc
+}
+
+// Record counts a success if err is nil, or a failure with the given reason otherwise.
+// If a LastError is being tracked, a failure also replaces the most recent error.
+// The error is returned as is, so that this method can wrap a return statement:
+//
+//	return oc.Record(db.Ping(), "ping")
+func (oc *Outcome) Record(err error, reason string) error {
+	if err == nil {
+		oc.Success()
+	} else {
+		oc.Failure(reason)
+		if oc.lastError != nil {
+			if _, declared := oc.reasons[reason]; oc.reasons != nil && !declared {
+				reason = ReasonOther
+			}
+
+			oc.lastError.Set(reason, err)
+		}
+	}
+
+	return err
+}
+
+// failure returns the counter for the given reason.
+func (oc *Outcome) failure(reason string) prometheus.Counter {
+	if oc.reasons != nil {
+		if c, ok := oc.reasons[reason]; ok {
+			return c
+		}
+
+		return oc.other
+	}
+
+	c, err := oc.vec.GetMetricWithLabelValues(OutcomeFailure, reason)
+	if err != nil {
+		return oc.vec.WithLabelValues(OutcomeFailure, ReasonOther)
+	}
+
+	return c
+}
+
+// Describe implements prometheus.Collector.
+func (oc *Outcome) Describe(ch chan<- *prometheus.Desc) {
+	oc.vec.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (oc *Outcome) Collect(ch chan<- prometheus.Metric) {
+	oc.vec.Collect(ch)
+}
+
+// NewOutcome creates and registers an Outcome.  See the package-level NewOutcome.
+//
+// This method returns an error if the options do not specify a name.  Both namespace
+// and subsystem are defaulted appropriately if not set in the options.
+func (f *Factory) NewOutcome(o prometheus.CounterOpts, reasons ...string) (oc *Outcome, err error) {
+	err = f.checkName(o.Name)
+	if err == nil {
+		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
+		f.warnOnNoHelp(o.Name, o.Help)
+
+		oc, err = NewOutcome(o, reasons...)
+	}
+
+	if err == nil {
+		err = f.register(oc, RegistrationEvent{
+			Type:       dto.MetricType_COUNTER,
+			Opts:       prometheus.Opts(o),
+			LabelNames: []string{OutcomeLabel, ReasonLabel},
+		})
+	}
+
+	if err != nil {
+		oc = nil
+	}
+
+	return
+}
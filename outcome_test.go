@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type OutcomeTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *OutcomeTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r)
+}
+
+func (suite *OutcomeTestSuite) TestNewOutcome() {
+	suite.Run("DeclaredReasons", func() {
+		oc, err := NewOutcome(prometheus.CounterOpts{Name: "requests_total", Help: "the requests"}, "timeout", "refused")
+		suite.Require().NoError(err)
+
+		// every declared series exists before it is used
+		suite.NoError(
+			testutil.CollectAndCompare(oc, strings.NewReader(`
+# HELP requests_total the requests
+# TYPE requests_total counter
+requests_total{outcome="failure",reason="other"} 0
+requests_total{outcome="failure",reason="refused"} 0
+requests_total{outcome="failure",reason="timeout"} 0
+requests_total{outcome="success",reason=""} 0
+`)),
+		)
+
+		oc.Success()
+		oc.Success()
+		oc.Failure("timeout")
+		oc.Failure("undeclared")
+		oc.Failure("\xff")
+
+		suite.NoError(
+			testutil.CollectAndCompare(oc, strings.NewReader(`
+# HELP requests_total the requests
+# TYPE requests_total counter
+requests_total{outcome="failure",reason="other"} 2
+requests_total{outcome="failure",reason="refused"} 0
+requests_total{outcome="failure",reason="timeout"} 1
+requests_total{outcome="success",reason=""} 2
+`)),
+		)
+	})
+
+	suite.Run("AnyReason", func() {
+		oc, err := NewOutcome(prometheus.CounterOpts{Name: "requests_total", Help: "the requests"})
+		suite.Require().NoError(err)
+		suite.Equal(1, testutil.CollectAndCount(oc))
+
+		oc.Failure("timeout")
+		oc.Failure("\xff")
+		suite.NoError(
+			testutil.CollectAndCompare(oc, strings.NewReader(`
+# HELP requests_total the requests
+# TYPE requests_total counter
+requests_total{outcome="failure",reason="other"} 1
+requests_total{outcome="failure",reason="timeout"} 1
+requests_total{outcome="success",reason=""} 0
+`)),
+		)
+	})
+
+	suite.Run("InvalidReason", func() {
+		_, err := NewOutcome(prometheus.CounterOpts{Name: "requests_total"}, "\xff")
+		suite.Error(err)
+	})
+}
+
+func (suite *OutcomeTestSuite) TestRecord() {
+	oc, err := NewOutcome(prometheus.CounterOpts{Name: "requests_total"}, "ping")
+	suite.Require().NoError(err)
+
+	expectedErr := errors.New("expected")
+	suite.NoError(oc.Record(nil, "ping"))
+	suite.Same(expectedErr, oc.Record(expectedErr, "ping"))
+
+	suite.Equal(1.0, testutil.ToFloat64(oc.success))
+	suite.Equal(1.0, testutil.ToFloat64(oc.reasons["ping"]))
+}
+
+func (suite *OutcomeTestSuite) TestTrackLastError() {
+	oc, err := NewOutcome(prometheus.CounterOpts{Name: "requests_total"}, "ping")
+	suite.Require().NoError(err)
+
+	gv := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "requests_last_error_info", Help: "test"},
+		[]string{ReasonLabel, MessageHashLabel},
+	)
+
+	suite.Same(oc, oc.TrackLastError(NewLastError(gv)))
+	suite.NoError(oc.Record(nil, "ping"))
+	oc.Failure("ping")
+	suite.Zero(testutil.CollectAndCount(gv))
+
+	suite.Error(oc.Record(errors.New("first"), "ping"))
+	suite.Equal(1.0, testutil.ToFloat64(gv.WithLabelValues("ping", MessageHash("first"))))
+
+	// undeclared reasons are recorded as ReasonOther
+	suite.Error(oc.Record(errors.New("second"), "undeclared"))
+	suite.Equal(1, testutil.CollectAndCount(gv))
+	suite.Equal(1.0, testutil.ToFloat64(gv.WithLabelValues(ReasonOther, MessageHash("second"))))
+}
+
+func (suite *OutcomeTestSuite) TestFactory() {
+	f := suite.newFactory()
+	oc, err := f.NewOutcome(prometheus.CounterOpts{Name: "logins_total", Help: "the logins"}, "denied")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(oc)
+
+	oc.Failure("denied")
+	v, err := Value(oc, prometheus.Labels{OutcomeLabel: OutcomeFailure, ReasonLabel: "denied"})
+	suite.Require().NoError(err)
+	suite.Equal(1.0, v)
+
+	descs := make(chan *prometheus.Desc, 1)
+	oc.Describe(descs)
+	suite.Contains((<-descs).String(), `"test_logins_total"`)
+
+	suite.Run("Duplicate", func() {
+		oc, err := f.NewOutcome(prometheus.CounterOpts{Name: "logins_total", Help: "the logins"})
+		suite.Error(err)
+		suite.Nil(oc)
+	})
+
+	suite.Run("NoName", func() {
+		oc, err := f.NewOutcome(prometheus.CounterOpts{})
+		suite.Error(err)
+		suite.Nil(oc)
+	})
+}
+
+func TestOutcome(t *testing.T) {
+	suite.Run(t, new(OutcomeTestSuite))
+}
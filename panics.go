@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	// PanicCountName is the name of the counter of recovered panics.  Unlike
+	// WriteErrorCountName, this counter describes the application, so it is subject
+	// to the Factory's defaults and naming policy.
+	PanicCountName = "panic_count"
+
+	// SourceLabel is the label of PanicCountName that identifies where a panic
+	// was recovered, e.g. the name of a goroutine or a server.
+	SourceLabel = "source"
+)
+
+// PanicHook is a callback for recovered panics.  The source identifies where the
+// panic was recovered, and value is the value returned by recover.
+type PanicHook func(source string, value interface{})
+
+// LogPanics produces a PanicHook that logs each recovered panic as an error,
+// along with the stack of the panicking goroutine.
+func LogPanics(l *zap.Logger) PanicHook {
+	return func(source string, value interface{}) {
+		l.Error(
+			"Recovered from panic",
+			zap.String("source", source),
+			zap.Any("value", value),
+			zap.Stack("stack"),
+		)
+	}
+}
+
+// Panics counts the panics recovered throughout an application.  Recovery
+// code, such as HTTP middleware and the wrappers produced by InstrumentPanics,
+// share a single Panics so that all panics are accounted for in one counter.
+//
+// A nil *Panics is valid.  It still recovers panics, but does not record them.
+type Panics struct {
+	count *prometheus.CounterVec
+	hooks []PanicHook
+}
+
+// NewPanics creates a Panics whose counter is created by the given factory.
+// The counter is shared:  each Panics created against the same registry
+// increments the same counter.
+func NewPanics(f MetricFactory, hooks ...PanicHook) (*Panics, error) {
+	count, err := f.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: PanicCountName,
+			Help: "the total number of panics recovered since startup",
+		},
+		SourceLabel,
+	)
+
+	if err = ExistingCollector(&count, err); err != nil {
+		return nil, err
+	}
+
+	return &Panics{
+		count: count,
+		hooks: append([]PanicHook{}, hooks...),
+	}, nil
+}
+
+// Record records a panic recovered elsewhere, e.g. by a framework's own
+// recovery code.
+func (p *Panics) Record(source string, value interface{}) {
+	if p == nil {
+		return
+	}
+
+	p.count.WithLabelValues(source).Inc()
+	for _, h := range p.hooks {
+		h(source, value)
+	}
+}
+
+// Recover recovers and records any panic in progress.  This method must be
+// deferred directly:
+//
+//	defer p.Recover("worker")
+func (p *Panics) Recover(source string) {
+	if v := recover(); v != nil {
+		p.Record(source, v)
+	}
+}
+
+// Instrument wraps a function so that any panic it raises is recovered and
+// recorded with the given source.
+func (p *Panics) Instrument(source string, fn func()) func() {
+	return func() {
+		defer p.Recover(source)
+		fn()
+	}
+}
+
+// defaultPanics is the process-wide Panics used by InstrumentPanics and RecoverPanic.
+var defaultPanics atomic.Pointer[Panics]
+
+// Panics returns the Panics that InvokePanics created with this Factory, or nil if
+// InvokePanics has not run against it.  Factories derived from this one, e.g. with
+// Subsystem, share the same Panics.
+func (f *Factory) Panics() *Panics {
+	if f.panics == nil {
+		return nil
+	}
+
+	return f.panics.Load()
+}
+
+// SetDefaultPanics installs the process-wide Panics.  A nil *Panics stops the
+// recording, but not the recovery, of panics.  Applications normally use InvokePanics,
+// which installs and uninstalls the process-wide Panics with the application.
+func SetDefaultPanics(p *Panics) {
+	defaultPanics.Store(p)
+}
+
+// DefaultPanics returns the process-wide Panics, which is nil if none has been installed.
+func DefaultPanics() *Panics {
+	return defaultPanics.Load()
+}
+
+// RecoverPanic recovers any panic in progress and records it with the process-wide
+// Panics.  Like Panics.Recover, this function must be deferred directly.
+func RecoverPanic(source string) {
+	if v := recover(); v != nil {
+		DefaultPanics().Record(source, v)
+	}
+}
+
+// InstrumentPanics wraps a function so that any panic it raises is recovered and
+// recorded with the process-wide Panics.  The Panics is consulted when a panic
+// occurs, so functions may be wrapped before InvokePanics has run:
+//
+//	go touchstone.InstrumentPanics("worker", worker.Run)()
+func InstrumentPanics(source string, fn func()) func() {
+	return func() {
+		defer RecoverPanic(source)
+		fn()
+	}
+}
+
+// PanicsIn is the set of dependencies used by InvokePanics.
+type PanicsIn struct {
+	fx.In
+
+	// Factory is the required MetricFactory used to create the panic counter.  If it
+	// is a *Factory, the Panics created by InvokePanics is owned by that Factory.
+	Factory MetricFactory
+
+	// Lifecycle is used to uninstall the process-wide Panics when the application stops.
+	Lifecycle fx.Lifecycle
+
+	// Logger is the optional logger.  If supplied, recovered panics are logged.
+	Logger *zap.Logger `optional:"true"`
+}
+
+// InvokePanics creates a Panics with the application's MetricFactory and installs it with
+// SetDefaultPanics.  When that MetricFactory is a *Factory, the Panics is also available
+// through Factory.Panics.  When the application stops, the
+// process-wide Panics is uninstalled unless something else has replaced it in the meantime.
+// If a *zap.Logger is present, recovered panics are also logged.
+func InvokePanics(hooks ...PanicHook) fx.Option {
+	return fx.Invoke(
+		func(in PanicsIn) error {
+			all := append([]PanicHook{}, hooks...)
+			if in.Logger != nil {
+				all = append(all, LogPanics(in.Logger))
+			}
+
+			p, err := NewPanics(in.Factory, all...)
+			if err != nil {
+				return err
+			}
+
+			if f, ok := in.Factory.(*Factory); ok && f.panics != nil {
+				f.panics.Store(p)
+			}
+
+			SetDefaultPanics(p)
+			in.Lifecycle.Append(fx.Hook{
+				OnStop: func(context.Context) error {
+					defaultPanics.CompareAndSwap(p, nil)
+					return nil
+				},
+			})
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+type PanicsTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *PanicsTestSuite) TearDownTest() {
+	SetDefaultPanics(nil)
+}
+
+func (suite *PanicsTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r)
+}
+
+func (suite *PanicsTestSuite) newPanics(f *Factory, hooks ...PanicHook) *Panics {
+	p, err := NewPanics(f, hooks...)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(p)
+	return p
+}
+
+func (suite *PanicsTestSuite) panics(p *Panics, source string) float64 {
+	return testutil.ToFloat64(p.count.WithLabelValues(source))
+}
+
+func (suite *PanicsTestSuite) TestNewPanics() {
+	f := suite.newFactory()
+	first := suite.newPanics(f)
+	second := suite.newPanics(f)
+	suite.Same(first.count, second.count)
+	suite.Contains(first.count.WithLabelValues("x").Desc().String(), `"test_panic_count"`)
+}
+
+func (suite *PanicsTestSuite) TestRecover() {
+	var values []interface{}
+	p := suite.newPanics(suite.newFactory(), func(source string, value interface{}) {
+		suite.Equal("worker", source)
+		values = append(values, value)
+	})
+
+	suite.NotPanics(p.Instrument("worker", func() { panic("expected") }))
+	suite.NotPanics(p.Instrument("worker", func() {}))
+	suite.NotPanics(func() {
+		defer p.Recover("worker")
+		panic("again")
+	})
+
+	suite.Equal(2.0, suite.panics(p, "worker"))
+	suite.Equal([]interface{}{"expected", "again"}, values)
+}
+
+func (suite *PanicsTestSuite) TestNil() {
+	var p *Panics
+	suite.NotPanics(p.Instrument("worker", func() { panic("expected") }))
+	suite.NotPanics(func() { p.Record("worker", "value") })
+}
+
+func (suite *PanicsTestSuite) TestInstrumentPanics() {
+	// functions may be wrapped before a Panics is installed
+	fn := InstrumentPanics("worker", func() { panic("expected") })
+	suite.NotPanics(fn)
+
+	p := suite.newPanics(suite.newFactory())
+	SetDefaultPanics(p)
+	suite.Same(p, DefaultPanics())
+	suite.NotPanics(fn)
+	suite.NotPanics(func() {
+		defer RecoverPanic("deferred")
+		panic("expected")
+	})
+
+	suite.Equal(1.0, suite.panics(p, "worker"))
+	suite.Equal(1.0, suite.panics(p, "deferred"))
+}
+
+func (suite *PanicsTestSuite) TestInvokePanics() {
+	var (
+		sources []string
+		f       *Factory
+	)
+
+	app := suite.newTestApp(
+		Provide(),
+		fx.Populate(&f),
+		InvokePanics(func(source string, _ interface{}) {
+			sources = append(sources, source)
+		}),
+		fx.Invoke(func() {
+			InstrumentPanics("startup", func() { panic("expected") })()
+		}),
+	)
+
+	app.RequireStart()
+	suite.Require().NotNil(DefaultPanics())
+	suite.Same(f.Panics(), DefaultPanics())
+	suite.Equal(1.0, suite.panics(DefaultPanics(), "startup"))
+	suite.Equal([]string{"startup"}, sources)
+
+	// the process-wide Panics does not outlive the application
+	app.RequireStop()
+	suite.Nil(DefaultPanics())
+}
+
+// decoratedFactory is a MetricFactory that is not a *Factory.
+type decoratedFactory struct {
+	MetricFactory
+}
+
+func (suite *PanicsTestSuite) TestInvokePanicsDecorated() {
+	var f *Factory
+	app := suite.newTestApp(
+		Provide(),
+		fx.Decorate(func(mf MetricFactory) MetricFactory {
+			return decoratedFactory{MetricFactory: mf}
+		}),
+		InvokePanics(),
+		fx.Populate(&f),
+	)
+
+	app.RequireStart()
+	defer app.RequireStop()
+
+	suite.Require().NotNil(DefaultPanics())
+	suite.Nil(f.Panics())
+
+	InstrumentPanics("decorated", func() { panic("expected") })()
+	suite.Equal(1.0, suite.panics(DefaultPanics(), "decorated"))
+}
+
+func (suite *PanicsTestSuite) TestInvokePanicsReplaced() {
+	app := suite.newTestApp(
+		Provide(),
+		InvokePanics(),
+	)
+
+	app.RequireStart()
+	replacement := suite.newPanics(suite.newFactory())
+	SetDefaultPanics(replacement)
+	app.RequireStop()
+	suite.Same(replacement, DefaultPanics())
+}
+
+func TestPanics(t *testing.T) {
+	suite.Run(t, new(PanicsTestSuite))
+}
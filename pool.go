@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// PoolActiveName is the name of the gauge of tasks currently running in each pool.
+	PoolActiveName = "pool_active_goroutines"
+
+	// PoolTasksName is the name of the counter of finished tasks in each pool.  It has
+	// an OutcomeLabel that distinguishes completed tasks from failed ones.
+	PoolTasksName = "pool_task_count"
+
+	// PoolTaskDurationName is the name of the histogram of task durations, in milliseconds,
+	// in each pool.
+	PoolTaskDurationName = "pool_task_duration_ms"
+
+	// PoolLabel is the label that identifies the pool in each of the pool metrics.
+	PoolLabel = "pool"
+)
+
+// Pool instruments the tasks run by a group of background goroutines, such as
+// an errgroup.Group or a hand-rolled worker pool.  It tracks how many tasks are
+// active, how many have completed or failed, and how long each took.
+//
+// The underlying metrics are shared:  every Pool created against the same registry
+// uses the same vectors, distinguished by the PoolLabel.
+//
+// A Pool does not start goroutines itself.  Instead, it wraps the tasks that
+// the caller runs however it likes:
+//
+//	var g errgroup.Group
+//	g.Go(pool.Wrap(worker.Run))
+type Pool struct {
+	active    prometheus.Gauge
+	completed prometheus.Counter
+	failed    prometheus.Counter
+	duration  prometheus.Observer
+	clock     Clock
+}
+
+// NewPool creates a Pool with the given name, which becomes the value of the PoolLabel.
+// The Factory's Clock is used to measure task durations.
+func NewPool(f MetricFactory, name string) (*Pool, error) {
+	active, err := f.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: PoolActiveName,
+			Help: "the number of tasks currently running in each pool",
+		},
+		PoolLabel,
+	)
+
+	if err = ExistingCollector(&active, err); err != nil {
+		return nil, err
+	}
+
+	tasks, err := f.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: PoolTasksName,
+			Help: "the total number of tasks finished by each pool",
+		},
+		PoolLabel, OutcomeLabel,
+	)
+
+	if err = ExistingCollector(&tasks, err); err != nil {
+		return nil, err
+	}
+
+	duration, err := f.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    PoolTaskDurationName,
+			Help:    "the duration in milliseconds of the tasks run by each pool",
+			Buckets: []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+		},
+		PoolLabel,
+	)
+
+	if err = ExistingCollector(&duration, err); err != nil {
+		return nil, err
+	}
+
+	p := &Pool{
+		clock: f.Clock(),
+	}
+
+	if p.active, err = active.GetMetricWithLabelValues(name); err != nil {
+		return nil, err
+	}
+
+	p.completed = tasks.WithLabelValues(name, OutcomeSuccess)
+	p.failed = tasks.WithLabelValues(name, OutcomeFailure)
+	p.duration = duration.WithLabelValues(name)
+	return p, nil
+}
+
+// Run runs the given task in the current goroutine and records it with this Pool.
+// A task that returns an error or panics is counted as failed.  A panic is
+// propagated after it has been recorded.
+func (p *Pool) Run(task func() error) (err error) {
+	start := p.clock.Now()
+	p.active.Inc()
+
+	finished := false
+	defer func() {
+		p.active.Dec()
+		p.duration.Observe(float64(p.clock.Now().Sub(start)) / float64(time.Millisecond))
+		if finished && err == nil {
+			p.completed.Inc()
+		} else {
+			p.failed.Inc()
+		}
+	}()
+
+	err = task()
+	finished = true
+	return
+}
+
+// Wrap produces a closure that runs the given task via Run.  The closure's
+// signature matches errgroup.Group.Go.
+func (p *Pool) Wrap(task func() error) func() error {
+	return func() error {
+		return p.Run(task)
+	}
+}
+
+// Go starts a goroutine that runs the given task via Run.  Any error the task
+// returns is counted, then discarded.
+func (p *Pool) Go(task func() error) {
+	go func() {
+		_ = p.Run(task)
+	}()
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type PoolTestSuite struct {
+	FxTestSuite
+
+	registry *prometheus.Registry
+	current  time.Time
+}
+
+func (suite *PoolTestSuite) SetupTest() {
+	suite.registry = prometheus.NewPedanticRegistry()
+	suite.current = time.Now()
+}
+
+// now advances the fake clock by one second each time it is called.
+func (suite *PoolTestSuite) now() time.Time {
+	suite.current = suite.current.Add(time.Second)
+	return suite.current
+}
+
+func (suite *PoolTestSuite) newFactory() *Factory {
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, suite.registry, WithClock(ClockFunc(suite.now)))
+}
+
+func (suite *PoolTestSuite) newPool(f MetricFactory, name string) *Pool {
+	p, err := NewPool(f, name)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(p)
+	return p
+}
+
+func (suite *PoolTestSuite) TestRun() {
+	p := suite.newPool(suite.newFactory(), "workers")
+	suite.NoError(p.Run(func() error {
+		suite.Equal(1.0, testutil.ToFloat64(p.active))
+		return nil
+	}))
+
+	expectedErr := errors.New("expected")
+	suite.ErrorIs(p.Run(func() error { return expectedErr }), expectedErr)
+	suite.Panics(func() {
+		_ = p.Run(func() error { panic("expected") })
+	})
+
+	suite.Zero(testutil.ToFloat64(p.active))
+	suite.Equal(1.0, testutil.ToFloat64(p.completed))
+	suite.Equal(2.0, testutil.ToFloat64(p.failed))
+
+	// each task took exactly one tick of the fake clock
+	mfs, err := suite.registry.Gather()
+	suite.Require().NoError(err)
+	for _, mf := range mfs {
+		if mf.GetName() == "test_"+PoolTaskDurationName {
+			h := mf.GetMetric()[0].GetHistogram()
+			suite.Equal(uint64(3), h.GetSampleCount())
+			suite.Equal(3000.0, h.GetSampleSum())
+		}
+	}
+}
+
+func (suite *PoolTestSuite) TestWrap() {
+	p := suite.newPool(suite.newFactory(), "workers")
+	var called bool
+	task := p.Wrap(func() error {
+		called = true
+		return nil
+	})
+
+	suite.False(called)
+	suite.NoError(task())
+	suite.True(called)
+	suite.Equal(1.0, testutil.ToFloat64(p.completed))
+}
+
+func (suite *PoolTestSuite) TestGo() {
+	var (
+		wg sync.WaitGroup
+		p  = suite.newPool(suite.newFactory(), "workers")
+	)
+
+	// the fake clock isn't safe for concurrent use
+	p.clock = SystemClock{}
+
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		p.Go(func() error {
+			defer wg.Done()
+			return nil
+		})
+	}
+
+	wg.Wait()
+	suite.Eventually(
+		func() bool { return testutil.ToFloat64(p.completed) == 3.0 },
+		time.Second,
+		10*time.Millisecond,
+	)
+}
+
+func (suite *PoolTestSuite) TestShared() {
+	f := suite.newFactory()
+	first := suite.newPool(f, "first")
+	second := suite.newPool(f, "second")
+	suite.NoError(first.Run(func() error { return nil }))
+	suite.Error(second.Run(func() error { return errors.New("expected") }))
+
+	suite.Equal(2, testutil.CollectAndCount(suite.registry, "test_"+PoolActiveName))
+	suite.Equal(4, testutil.CollectAndCount(suite.registry, "test_"+PoolTasksName))
+	suite.Equal(2, testutil.CollectAndCount(suite.registry, "test_"+PoolTaskDurationName))
+}
+
+func TestPool(t *testing.T) {
+	suite.Run(t, new(PoolTestSuite))
+}
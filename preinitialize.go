@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// ErrNotAVector indicates that an object passed to Preinitialize was not a
+// supported metric vector.
+var ErrNotAVector = errors.New("Only *prometheus.CounterVec, *prometheus.GaugeVec, prometheus.ObserverVec, and *prometheus.MetricVec can be preinitialized")
+
+// Preinitialize creates the children of a metric vector for each of the given sets
+// of labels.  This ensures that expected series exist, with zero values, before
+// they are first used.  Without preinitialization, a series such as a count of
+// 404 responses is absent until the first 404 occurs, which shows up as a gap in
+// dashboards and confuses rate() and absent() expressions.
+//
+// The vec must be a *prometheus.CounterVec, *prometheus.GaugeVec, prometheus.ObserverVec,
+// e.g. a *prometheus.HistogramVec, or a *prometheus.MetricVec.  Curried vectors are
+// supported, in which case each set of labels must omit the curried labels.
+//
+// Any label transforms or limits of the Factory that created the vector are applied
+// when the preinitialized series are collected, just as for any other series.
+//
+// All label sets are attempted, and any errors are aggregated into the returned error.
+func Preinitialize(vec interface{}, labelSets ...prometheus.Labels) (err error) {
+	var create func(prometheus.Labels) error
+	switch v := vec.(type) {
+	case *prometheus.CounterVec:
+		create = func(l prometheus.Labels) error {
+			_, err := v.GetMetricWith(l)
+			return err
+		}
+
+	case *prometheus.GaugeVec:
+		create = func(l prometheus.Labels) error {
+			_, err := v.GetMetricWith(l)
+			return err
+		}
+
+	case prometheus.ObserverVec:
+		create = func(l prometheus.Labels) error {
+			_, err := v.GetMetricWith(l)
+			return err
+		}
+
+	case *prometheus.MetricVec:
+		create = func(l prometheus.Labels) error {
+			_, err := v.GetMetricWith(l)
+			return err
+		}
+
+	default:
+		return fmt.Errorf("%w: %T", ErrNotAVector, vec)
+	}
+
+	for _, l := range labelSets {
+		multierr.AppendInto(&err, create(l))
+	}
+
+	return
+}
+
+// Preinitialize creates the children of a metric vector for each of the given sets
+// of labels, just like the package-level Preinitialize function.  Any failure is
+// also logged with this Factory's logger, so that a misconfigured label set is
+// visible even when the caller only checks the error at startup.
+func (f *Factory) Preinitialize(vec interface{}, labelSets ...prometheus.Labels) error {
+	err := Preinitialize(vec, labelSets...)
+	if err != nil && f.logger != nil {
+		f.logger.Warn("Unable to preinitialize metric vector", zap.Error(err))
+	}
+
+	return err
+}
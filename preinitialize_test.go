@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type PreinitializeTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *PreinitializeTestSuite) newFactory() (*Factory, prometheus.Gatherer) {
+	cfg := Config{
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := New(cfg)
+	suite.Require().NoError(err)
+	return NewFactory(cfg, suite.logger, r), g
+}
+
+func (suite *PreinitializeTestSuite) TestVectors() {
+	f, g := suite.newFactory()
+
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Name: "counter", Help: "counter"}, "code", "method")
+	suite.Require().NoError(err)
+
+	gv, err := f.NewGaugeVec(prometheus.GaugeOpts{Name: "gauge", Help: "gauge"}, "code")
+	suite.Require().NoError(err)
+
+	hv, err := f.NewHistogramVec(prometheus.HistogramOpts{Name: "histogram", Help: "histogram", Buckets: []float64{1}}, "code")
+	suite.Require().NoError(err)
+
+	curried, err := cv.CurryWith(prometheus.Labels{"method": "GET"})
+	suite.Require().NoError(err)
+
+	suite.NoError(Preinitialize(cv, prometheus.Labels{"code": "200", "method": "POST"}))
+	suite.NoError(Preinitialize(curried, prometheus.Labels{"code": "404"}, prometheus.Labels{"code": "500"}))
+	suite.NoError(Preinitialize(gv, prometheus.Labels{"code": "200"}))
+	suite.NoError(Preinitialize(hv, prometheus.Labels{"code": "200"}))
+	suite.NoError(Preinitialize(cv.MetricVec))
+	suite.NoError(
+		testutil.GatherAndCompare(
+			g,
+			strings.NewReader(`
+# HELP counter counter
+# TYPE counter counter
+counter{code="200",method="POST"} 0
+counter{code="404",method="GET"} 0
+counter{code="500",method="GET"} 0
+# HELP gauge gauge
+# TYPE gauge gauge
+gauge{code="200"} 0
+`),
+			"counter", "gauge",
+		),
+	)
+
+	count, err := testutil.GatherAndCount(g, "histogram")
+	suite.NoError(err)
+	suite.Equal(1, count)
+}
+
+func (suite *PreinitializeTestSuite) TestTransforms() {
+	cfg := Config{
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := New(cfg)
+	suite.Require().NoError(err)
+	f := NewFactory(cfg, suite.logger, r, WithLabelTransforms(LabelTransforms{
+		Global: map[string]LabelTransformer{"device": strings.ToUpper},
+	}))
+
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Name: "counter", Help: "counter"}, "device")
+	suite.Require().NoError(err)
+	suite.Require().NoError(Preinitialize(cv, prometheus.Labels{"device": "abc"}))
+
+	// the Factory's transforms apply to preinitialized series when collected
+	suite.NoError(
+		testutil.GatherAndCompare(
+			g,
+			strings.NewReader(`
+# HELP counter counter
+# TYPE counter counter
+counter{device="ABC"} 0
+`),
+			"counter",
+		),
+	)
+}
+
+func (suite *PreinitializeTestSuite) TestErrors() {
+	f, _ := suite.newFactory()
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Name: "counter", Help: "counter"}, "code")
+	suite.Require().NoError(err)
+
+	suite.Error(
+		Preinitialize(cv,
+			prometheus.Labels{"code": "200"},
+			prometheus.Labels{"nosuch": "value"},
+			prometheus.Labels{},
+		),
+	)
+
+	suite.Equal(1, testutil.CollectAndCount(cv))
+
+	c, err := f.NewCounter(prometheus.CounterOpts{Name: "scalar", Help: "scalar"})
+	suite.Require().NoError(err)
+	suite.ErrorIs(Preinitialize(c), ErrNotAVector)
+}
+
+func (suite *PreinitializeTestSuite) TestFactory() {
+	core, logs := observer.New(zap.WarnLevel)
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	f := NewFactory(Config{}, zap.New(core), r)
+
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Name: "counter", Help: "counter"}, "code")
+	suite.Require().NoError(err)
+
+	suite.NoError(f.Preinitialize(cv, prometheus.Labels{"code": "200"}))
+	suite.Equal(1, testutil.CollectAndCount(cv))
+	suite.Zero(logs.Len())
+
+	suite.Error(f.Preinitialize(cv, prometheus.Labels{"nosuch": "value"}))
+	suite.Equal(1, logs.Len())
+
+	suite.ErrorIs(f.Preinitialize("not a vector"), ErrNotAVector)
+	suite.Equal(2, logs.Len())
+}
+
+func TestPreinitialize(t *testing.T) {
+	suite.Run(t, new(PreinitializeTestSuite))
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+)
+
+// ErrReadOnlyRegisterer indicates an attempt to register a collector through a
+// read-only Registerer.
+var ErrReadOnlyRegisterer = errors.New("Registration is not permitted")
+
+// readOnlyRegisterer is a prometheus.Registerer that rejects all registrations.
+type readOnlyRegisterer struct {
+	scope string
+}
+
+// NewReadOnlyRegisterer creates a prometheus.Registerer that rejects every registration
+// with an error that wraps ErrReadOnlyRegisterer and identifies the given scope, e.g.
+// the name of a plugin.  Unregister always returns false.
+//
+// Use this Registerer to prevent untrusted code, such as embedded third-party plugins,
+// from adding collectors to an application's registry.  Gathering is unaffected, as
+// prometheus.Gatherer is a separate component.
+func NewReadOnlyRegisterer(scope string) prometheus.Registerer {
+	return readOnlyRegisterer{scope: scope}
+}
+
+func (ror readOnlyRegisterer) Register(c prometheus.Collector) error {
+	name := "unknown"
+	if d := firstDesc(c); d != nil {
+		name = descName(d)
+	}
+
+	return fmt.Errorf("%w: scope '%s' attempted to register '%s' [%T]", ErrReadOnlyRegisterer, ror.scope, name, c)
+}
+
+func (ror readOnlyRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := ror.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (ror readOnlyRegisterer) Unregister(prometheus.Collector) bool {
+	return false
+}
+
+// firstDesc returns the first descriptor of a collector, or nil if it has none.
+func firstDesc(c prometheus.Collector) (first *prometheus.Desc) {
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		c.Describe(ch)
+		close(ch)
+	}()
+
+	for d := range ch {
+		if first == nil {
+			first = d
+		}
+	}
+
+	return
+}
+
+// withRegisterer creates a Factory identical to this one, but that registers
+// metrics with the given Registerer.  Registration listeners are not copied, nor is
+// any Router, so that every metric is registered with the given Registerer.
+func (f *Factory) withRegisterer(r prometheus.Registerer) *Factory {
+	return &Factory{
+		defaults:         f.defaults,
+		logger:           f.logger,
+		registerer:       r,
+		naming:           f.naming,
+		clock:            f.clock,
+		transforms:       f.transforms,
+		noCreated:        f.noCreated,
+		limiter:          f.limiter,
+		native:           f.native,
+		summaries:        f.summaries,
+		registered:       f.registered,
+		panics:           f.panics,
+		cardinalityLimit: f.cardinalityLimit,
+	}
+}
+
+// ReadOnly decorates the prometheus.Registerer, *Factory, and MetricFactory components
+// within an fx scope so that no metrics can be registered.  Place this option within
+// the fx.Module of an untrusted plugin:
+//
+//	fx.New(
+//	  touchstone.Provide(),
+//	  fx.Module(
+//	    "plugin",
+//	    touchstone.ReadOnly("plugin"),
+//	    plugin.Provide(),
+//	  ),
+//	)
+//
+// Any attempt by the plugin to register a metric fails with an error wrapping
+// ErrReadOnlyRegisterer.  The prometheus.Gatherer is unaffected, so the plugin may
+// still read metrics.
+func ReadOnly(scope string) fx.Option {
+	ror := NewReadOnlyRegisterer(scope)
+	return fx.Decorate(
+		func(prometheus.Registerer) prometheus.Registerer {
+			return ror
+		},
+		func(f *Factory) *Factory {
+			return f.withRegisterer(ror)
+		},
+		func(MetricFactory, f *Factory) MetricFactory {
+			return f
+		},
+	)
+}
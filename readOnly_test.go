@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+type ReadOnlyTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *ReadOnlyTestSuite) TestNewReadOnlyRegisterer() {
+	var (
+		r = NewReadOnlyRegisterer("plugin")
+		c = prometheus.NewCounter(prometheus.CounterOpts{Name: "requests"})
+	)
+
+	err := r.Register(c)
+	suite.ErrorIs(err, ErrReadOnlyRegisterer)
+	suite.Contains(err.Error(), "plugin")
+	suite.Contains(err.Error(), "requests")
+
+	suite.Panics(func() {
+		r.MustRegister(c)
+	})
+
+	suite.NotPanics(func() {
+		r.MustRegister()
+	})
+
+	suite.False(r.Unregister(c))
+}
+
+func (suite *ReadOnlyTestSuite) TestReadOnly() {
+	type pluginIn struct {
+		fx.In
+		Registerer    prometheus.Registerer
+		Factory       *Factory
+		MetricFactory MetricFactory
+		Gatherer      prometheus.Gatherer
+	}
+
+	var (
+		app    pluginIn
+		plugin pluginIn
+	)
+
+	fxApp := suite.newTestApp(
+		Provide(),
+		fx.Populate(&app),
+		fx.Module(
+			"plugin",
+			ReadOnly("plugin"),
+			fx.Invoke(func(in pluginIn) {
+				plugin = in
+			}),
+		),
+	)
+
+	fxApp.RequireStart()
+	defer fxApp.RequireStop()
+
+	_, err := plugin.Factory.NewCounter(prometheus.CounterOpts{Name: "plugin_requests"})
+	suite.ErrorIs(err, ErrReadOnlyRegisterer)
+
+	_, err = plugin.MetricFactory.NewGauge(prometheus.GaugeOpts{Name: "plugin_gauge"})
+	suite.ErrorIs(err, ErrReadOnlyRegisterer)
+
+	suite.ErrorIs(
+		plugin.Registerer.Register(prometheus.NewCounter(prometheus.CounterOpts{Name: "plugin_direct"})),
+		ErrReadOnlyRegisterer,
+	)
+
+	// the rest of the application is unaffected
+	_, err = app.Factory.NewCounter(prometheus.CounterOpts{Name: "app_requests"})
+	suite.NoError(err)
+	suite.NoError(
+		app.Registerer.Register(prometheus.NewCounter(prometheus.CounterOpts{Name: "app_direct"})),
+	)
+
+	// and the plugin can still gather
+	suite.Same(app.Gatherer, plugin.Gatherer)
+	mfs, err := plugin.Gatherer.Gather()
+	suite.NoError(err)
+	suite.NotEmpty(mfs)
+}
+
+func TestReadOnly(t *testing.T) {
+	suite.Run(t, new(ReadOnlyTestSuite))
+}
@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// RegistrationEvent describes an attempt by a Factory to register a collector.
+// Events are emitted for failed registrations as well, including duplicates
+// that ExistingCollector later resolves.
+type RegistrationEvent struct {
+	// Name is the fully qualified name of the metric, after defaults and any
+	// naming policy have been applied.  This field is empty for arbitrary
+	// collectors, e.g. those registered by RegisterWithTimeout.
+	Name string
+
+	// Type is the type of metric.  Arbitrary collectors are reported as
+	// dto.MetricType_UNTYPED.
+	Type dto.MetricType
+
+	// Opts holds the common options of the metric, with defaults and any naming policy
+	// applied.  Type-specific options such as histogram buckets are not included.
+	Opts prometheus.Opts
+
+	// LabelNames are the variable labels of a vector.  This field is empty
+	// for metrics that are not vectors.
+	LabelNames []string
+
+	// Collector is the collector that was registered.
+	Collector prometheus.Collector
+
+	// Err is the error returned by the prometheus.Registerer, if any.
+	Err error
+}
+
+// RegistrationListener is a callback for RegistrationEvents.
+type RegistrationListener func(RegistrationEvent)
+
+type registrationListener struct {
+	id int
+	l  RegistrationListener
+}
+
+// registrationListeners is the concurrent-safe set of listeners for a Factory.
+type registrationListeners struct {
+	lock      sync.Mutex
+	nextID    int
+	listeners []registrationListener
+}
+
+func (rl *registrationListeners) add(l RegistrationListener) (cancel func()) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	id := rl.nextID
+	rl.nextID++
+	rl.listeners = append(rl.listeners, registrationListener{id: id, l: l})
+
+	return func() {
+		rl.lock.Lock()
+		defer rl.lock.Unlock()
+
+		for i, e := range rl.listeners {
+			if e.id == id {
+				// copy on write, so that a dispatch in progress is unaffected
+				rl.listeners = append(
+					append([]registrationListener{}, rl.listeners[:i]...),
+					rl.listeners[i+1:]...,
+				)
+
+				return
+			}
+		}
+	}
+}
+
+func (rl *registrationListeners) dispatch(e RegistrationEvent) {
+	rl.lock.Lock()
+	listeners := rl.listeners
+	rl.lock.Unlock()
+
+	// listeners are invoked outside the lock so that they may create metrics
+	for _, rl := range listeners {
+		rl.l(e)
+	}
+}
+
+// OnRegister adds a listener that is invoked synchronously each time this Factory
+// registers a collector.  This allows features such as documentation generators,
+// warm-up logic, and self-metrics to observe every metric without modifying the
+// Factory.  Listeners may themselves create metrics through this Factory.
+//
+// The returned function removes the listener.  It is idempotent.
+func (f *Factory) OnRegister(l RegistrationListener) (cancel func()) {
+	return f.listeners.add(l)
+}
+
+// register registers a collector and dispatches the resulting event.  If any
+// label transforms or a label value limit apply to the collector, it is decorated
+// before registration.
+func (f *Factory) register(c prometheus.Collector, e RegistrationEvent) error {
+	if len(e.Opts.Name) > 0 {
+		e.Name = prometheus.BuildFQName(e.Opts.Namespace, e.Opts.Subsystem, e.Opts.Name)
+	}
+
+	registered := c
+	t := f.limiter.forMetric(e.Name, e.LabelNames, f.transforms.forMetric(e.Name, e.LabelNames))
+	cl := f.cardinalityFor(c, e.Name, e.LabelNames)
+	if t != nil || cl != nil {
+		registered = newTransformingCollector(c, t, cl, e.LabelNames)
+	}
+
+	if f.noCreated {
+		registered = noCreatedCollector{collector: registered}
+	}
+
+	r := f.registererFor(e.Opts.Namespace)
+	e.Collector = c
+	e.Err = r.Register(registered)
+	f.summaries.add(e)
+	if e.Err == nil {
+		f.registered.add(registeredCollector{
+			name:       e.Name,
+			collector:  registered,
+			source:     c,
+			registerer: r,
+			owner:      f,
+		})
+	}
+
+	for d := f; d != nil; d = d.parent {
+		d.listeners.dispatch(e)
+	}
+
+	return e.Err
+}
+
+// registererFor returns the registerer for metrics in the given namespace, which
+// is the routed registerer if there is one.
+func (f *Factory) registererFor(namespace string) prometheus.Registerer {
+	if r := f.router.Registerer(namespace); r != nil {
+		return r
+	}
+
+	return f.registerer
+}
+
+// histogramOpts extracts the common options of a histogram.
+func histogramOpts(o prometheus.HistogramOpts) prometheus.Opts {
+	return prometheus.Opts{
+		Namespace:   o.Namespace,
+		Subsystem:   o.Subsystem,
+		Name:        o.Name,
+		Help:        o.Help,
+		ConstLabels: o.ConstLabels,
+	}
+}
+
+// summaryOpts extracts the common options of a summary.
+func summaryOpts(o prometheus.SummaryOpts) prometheus.Opts {
+	return prometheus.Opts{
+		Namespace:   o.Namespace,
+		Subsystem:   o.Subsystem,
+		Name:        o.Name,
+		Help:        o.Help,
+		ConstLabels: o.ConstLabels,
+	}
+}
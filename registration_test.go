@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+)
+
+type RegistrationTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *RegistrationTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(
+		Config{DefaultNamespace: "test"},
+		suite.logger,
+		r,
+		WithNamingPolicy(Naming{Prefix: "acme"}),
+	)
+}
+
+func (suite *RegistrationTestSuite) record(f *Factory) *[]RegistrationEvent {
+	events := new([]RegistrationEvent)
+	f.OnRegister(func(e RegistrationEvent) {
+		*events = append(*events, e)
+	})
+
+	return events
+}
+
+func (suite *RegistrationTestSuite) TestEvents() {
+	f := suite.newFactory()
+	events := suite.record(f)
+
+	c, err := f.NewCounter(prometheus.CounterOpts{Name: "requests", Help: "requests"})
+	suite.Require().NoError(err)
+	_, err = f.NewGaugeVec(prometheus.GaugeOpts{Subsystem: "pool", Name: "connections"}, "server")
+	suite.Require().NoError(err)
+	_, err = f.NewHistogram(prometheus.HistogramOpts{Name: "duration"})
+	suite.Require().NoError(err)
+	_, err = f.NewSummaryVec(prometheus.SummaryOpts{Name: "size"}, "method")
+	suite.Require().NoError(err)
+	_, err = f.NewUntypedFunc(prometheus.UntypedOpts{Name: "value"}, func() int { return 1 })
+	suite.Require().NoError(err)
+	_, err = f.NewStateSet(prometheus.GaugeOpts{Name: "role"}, "role", "leader", "follower")
+	suite.Require().NoError(err)
+
+	suite.Require().Len(*events, 6)
+	suite.Equal("test_acme_requests", (*events)[0].Name)
+	suite.Equal(dto.MetricType_COUNTER, (*events)[0].Type)
+	suite.Equal("requests", (*events)[0].Opts.Help)
+	suite.Equal(c, (*events)[0].Collector)
+	suite.Empty((*events)[0].LabelNames)
+	suite.NoError((*events)[0].Err)
+
+	suite.Equal("test_pool_acme_connections", (*events)[1].Name)
+	suite.Equal(dto.MetricType_GAUGE, (*events)[1].Type)
+	suite.Equal([]string{"server"}, (*events)[1].LabelNames)
+
+	suite.Equal("test_acme_duration", (*events)[2].Name)
+	suite.Equal(dto.MetricType_HISTOGRAM, (*events)[2].Type)
+
+	suite.Equal("test_acme_size", (*events)[3].Name)
+	suite.Equal(dto.MetricType_SUMMARY, (*events)[3].Type)
+	suite.Equal([]string{"method"}, (*events)[3].LabelNames)
+
+	suite.Equal(dto.MetricType_UNTYPED, (*events)[4].Type)
+
+	suite.Equal("test_acme_role", (*events)[5].Name)
+	suite.Equal([]string{"role"}, (*events)[5].LabelNames)
+}
+
+func (suite *RegistrationTestSuite) TestFailure() {
+	f := suite.newFactory()
+	_, err := f.NewCounter(prometheus.CounterOpts{Name: "requests"})
+	suite.Require().NoError(err)
+
+	events := suite.record(f)
+	_, err = f.NewCounter(prometheus.CounterOpts{Name: "requests"})
+	suite.Require().Error(err)
+
+	var are prometheus.AlreadyRegisteredError
+	suite.Require().Len(*events, 1)
+	suite.True(errors.As((*events)[0].Err, &are))
+
+	// no event is emitted for invalid options that are never registered
+	_, err = f.NewCounter(prometheus.CounterOpts{})
+	suite.ErrorIs(err, ErrNoMetricName)
+	suite.Len(*events, 1)
+}
+
+func (suite *RegistrationTestSuite) TestArbitraryCollector() {
+	f := suite.newFactory()
+	events := suite.record(f)
+
+	_, err := f.RegisterWithTimeout(
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "slow"}),
+		time.Second,
+		prometheus.CounterOpts{Name: "timeouts"},
+	)
+
+	suite.Require().NoError(err)
+	suite.Require().Len(*events, 2)
+	suite.Equal("test_acme_timeouts", (*events)[0].Name)
+	suite.Empty((*events)[1].Name)
+	suite.Equal(dto.MetricType_UNTYPED, (*events)[1].Type)
+	suite.NotNil((*events)[1].Collector)
+}
+
+func (suite *RegistrationTestSuite) TestCancel() {
+	var (
+		f              = suite.newFactory()
+		first, second  int
+		cancelFirst    = f.OnRegister(func(RegistrationEvent) { first++ })
+		_              = f.OnRegister(func(RegistrationEvent) { second++ })
+		_, counterErr1 = f.NewCounter(prometheus.CounterOpts{Name: "first"})
+	)
+
+	suite.Require().NoError(counterErr1)
+	suite.Equal(1, first)
+	suite.Equal(1, second)
+
+	cancelFirst()
+	cancelFirst() // idempotent
+
+	_, err := f.NewCounter(prometheus.CounterOpts{Name: "second"})
+	suite.Require().NoError(err)
+	suite.Equal(1, first)
+	suite.Equal(2, second)
+}
+
+func (suite *RegistrationTestSuite) TestListenerCreatesMetrics() {
+	f := suite.newFactory()
+	f.OnRegister(func(e RegistrationEvent) {
+		if e.Name == "test_acme_requests" {
+			_, err := f.NewGauge(prometheus.GaugeOpts{Name: "requests_registered"})
+			suite.NoError(err)
+		}
+	})
+
+	events := suite.record(f)
+	_, err := f.NewCounter(prometheus.CounterOpts{Name: "requests"})
+	suite.Require().NoError(err)
+	suite.Len(*events, 2)
+}
+
+func TestRegistration(t *testing.T) {
+	suite.Run(t, new(RegistrationTestSuite))
+}
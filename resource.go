@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// AttributeServiceName is the OpenTelemetry resource attribute for the logical
+	// name of a service.
+	AttributeServiceName = "service.name"
+
+	// AttributeServiceInstanceID is the OpenTelemetry resource attribute for the unique
+	// identifier of a service instance.
+	AttributeServiceInstanceID = "service.instance.id"
+
+	// AttributeDeploymentEnvironment is the OpenTelemetry resource attribute for the
+	// deployment environment, e.g. staging or production.
+	AttributeDeploymentEnvironment = "deployment.environment"
+
+	// EnvResourceAttributes is the standard OpenTelemetry environment variable holding
+	// resource attributes as comma-separated key=value pairs.
+	EnvResourceAttributes = "OTEL_RESOURCE_ATTRIBUTES"
+
+	// EnvServiceName is the standard OpenTelemetry environment variable holding the
+	// service name.  It takes precedence over any service.name in EnvResourceAttributes.
+	EnvServiceName = "OTEL_SERVICE_NAME"
+)
+
+var (
+	// ErrInvalidResourceAttributes indicates that resource attributes could not be parsed.
+	ErrInvalidResourceAttributes = errors.New("invalid resource attributes")
+
+	// ErrDuplicateResourceLabel indicates that distinct resource keys produce the same
+	// label name, e.g. service.name and service_name.
+	ErrDuplicateResourceLabel = errors.New("duplicate resource label name")
+)
+
+// DefaultResourceKeys returns the resource attributes that are applied as labels
+// when Resource.Keys is empty.  A distinct slice is returned each time.
+func DefaultResourceKeys() []string {
+	return []string{
+		AttributeServiceName,
+		AttributeServiceInstanceID,
+		AttributeDeploymentEnvironment,
+	}
+}
+
+// Resource describes OpenTelemetry resource attributes that are applied as constant
+// labels to every metric, so that prometheus series carry the same identifying
+// labels as OpenTelemetry signals from the same process.  The labels are applied with
+// prometheus.WrapRegistererWith, so they cannot be overridden by individual metrics.
+//
+// Attribute keys are converted into label names by ResourceLabelName, e.g. service.name
+// becomes service_name.  The job and instance labels that OpenTelemetry maps these
+// attributes onto are left to the scraper.
+type Resource struct {
+	// FromEnvironment controls whether attributes are read from the standard
+	// OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME environment variables.
+	FromEnvironment bool `json:"fromEnvironment" yaml:"fromEnvironment"`
+
+	// Attributes are explicitly configured resource attributes.  These take
+	// precedence over any attributes read from the environment.
+	Attributes map[string]string `json:"attributes" yaml:"attributes"`
+
+	// Keys are the resource attributes applied as labels.  Any other attributes are
+	// ignored, which keeps arbitrary attributes from the environment from changing
+	// the label names of every metric.  If unset, DefaultResourceKeys is used.
+	Keys []string `json:"keys" yaml:"keys"`
+}
+
+// ParseResourceAttributes parses resource attributes in the format of the
+// OTEL_RESOURCE_ATTRIBUTES environment variable, i.e. comma-separated key=value
+// pairs with percent-encoded values.  Whitespace around keys and values is trimmed.
+func ParseResourceAttributes(v string) (map[string]string, error) {
+	attributes := make(map[string]string)
+	for _, pair := range strings.Split(v, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || len(key) == 0 {
+			return nil, fmt.Errorf("%w: %q is not a key=value pair", ErrInvalidResourceAttributes, pair)
+		}
+
+		decoded, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %s", ErrInvalidResourceAttributes, pair, err)
+		}
+
+		attributes[key] = decoded
+	}
+
+	return attributes, nil
+}
+
+// ResourceLabelName converts a resource attribute key into a prometheus label name
+// by replacing each character that is not valid in a label name with an underscore.
+// A leading digit is prefixed with "key_", following the OpenTelemetry conventions.
+func ResourceLabelName(key string) string {
+	var b strings.Builder
+	b.Grow(len(key))
+	if len(key) > 0 && key[0] >= '0' && key[0] <= '9' {
+		b.WriteString("key_")
+	}
+
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	return b.String()
+}
+
+// attributes merges the environment and configured attributes.
+func (r Resource) attributes() (map[string]string, error) {
+	attributes := make(map[string]string)
+	if r.FromEnvironment {
+		env, err := ParseResourceAttributes(os.Getenv(EnvResourceAttributes))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvResourceAttributes, err)
+		}
+
+		for k, v := range env {
+			attributes[k] = v
+		}
+
+		if serviceName := strings.TrimSpace(os.Getenv(EnvServiceName)); len(serviceName) > 0 {
+			attributes[AttributeServiceName] = serviceName
+		}
+	}
+
+	for k, v := range r.Attributes {
+		attributes[k] = v
+	}
+
+	return attributes, nil
+}
+
+// Labels returns the constant labels described by this Resource.  Attributes that
+// are empty or absent produce no label.  If no labels result, this method returns
+// an empty map.  Distinct keys that produce the same label name result in an error
+// that wraps ErrDuplicateResourceLabel.
+func (r Resource) Labels() (prometheus.Labels, error) {
+	attributes, err := r.attributes()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := r.Keys
+	if len(keys) == 0 {
+		keys = DefaultResourceKeys()
+	}
+
+	var (
+		labels = make(prometheus.Labels, len(keys))
+		names  = make(map[string]string, len(keys))
+	)
+
+	for _, key := range keys {
+		name := ResourceLabelName(key)
+		if existing, ok := names[name]; ok && existing != key {
+			return nil, fmt.Errorf("%w: %q and %q are both %s", ErrDuplicateResourceLabel, existing, key, name)
+		}
+
+		names[name] = key
+		if v := attributes[key]; len(v) > 0 {
+			labels[name] = v
+		}
+	}
+
+	return labels, nil
+}
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+)
+
+type ResourceTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ResourceTestSuite) TestParseResourceAttributes() {
+	suite.Run("Valid", func() {
+		attributes, err := ParseResourceAttributes(
+			" service.name = api ,deployment.environment=prod%2Ceast,,team=a%20b",
+		)
+
+		suite.Require().NoError(err)
+		suite.Equal(
+			map[string]string{
+				AttributeServiceName:           "api",
+				AttributeDeploymentEnvironment: "prod,east",
+				"team":                         "a b",
+			},
+			attributes,
+		)
+	})
+
+	suite.Run("Empty", func() {
+		attributes, err := ParseResourceAttributes("")
+		suite.Require().NoError(err)
+		suite.Empty(attributes)
+	})
+
+	for _, invalid := range []string{"service.name", "=value", "key=%zz"} {
+		suite.Run(invalid, func() {
+			_, err := ParseResourceAttributes(invalid)
+			suite.ErrorIs(err, ErrInvalidResourceAttributes)
+		})
+	}
+}
+
+func (suite *ResourceTestSuite) TestResourceLabelName() {
+	testCases := []struct {
+		key      string
+		expected string
+	}{
+		{key: AttributeServiceName, expected: "service_name"},
+		{key: AttributeServiceInstanceID, expected: "service_instance_id"},
+		{key: "k8s.pod-name", expected: "k8s_pod_name"},
+		{key: "9lives", expected: "key_9lives"},
+		{key: "already_valid", expected: "already_valid"},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.key, func() {
+			suite.Equal(testCase.expected, ResourceLabelName(testCase.key))
+		})
+	}
+}
+
+func (suite *ResourceTestSuite) TestLabels() {
+	suite.Run("Zero", func() {
+		suite.T().Setenv(EnvServiceName, "ignored")
+		labels, err := Resource{}.Labels()
+		suite.NoError(err)
+		suite.Empty(labels)
+	})
+
+	suite.Run("FromEnvironment", func() {
+		suite.T().Setenv(EnvResourceAttributes, "service.name=fromAttributes,service.instance.id=i-1,team=core")
+		suite.T().Setenv(EnvServiceName, "fromServiceName")
+
+		labels, err := Resource{FromEnvironment: true}.Labels()
+		suite.NoError(err)
+		suite.Equal(
+			prometheus.Labels{
+				"service_name":        "fromServiceName",
+				"service_instance_id": "i-1",
+			},
+			labels,
+		)
+	})
+
+	suite.Run("Attributes", func() {
+		suite.T().Setenv(EnvResourceAttributes, "service.name=fromEnvironment,team=core")
+
+		labels, err := Resource{
+			FromEnvironment: true,
+			Attributes: map[string]string{
+				AttributeServiceName:           "configured",
+				AttributeDeploymentEnvironment: "",
+			},
+			Keys: []string{AttributeServiceName, AttributeDeploymentEnvironment, "team"},
+		}.Labels()
+
+		suite.NoError(err)
+		suite.Equal(
+			prometheus.Labels{
+				"service_name": "configured",
+				"team":         "core",
+			},
+			labels,
+		)
+	})
+
+	suite.Run("InvalidEnvironment", func() {
+		suite.T().Setenv(EnvResourceAttributes, "service.name")
+		_, err := Resource{FromEnvironment: true}.Labels()
+		suite.ErrorIs(err, ErrInvalidResourceAttributes)
+	})
+
+	suite.Run("DuplicateLabel", func() {
+		_, err := Resource{
+			Attributes: map[string]string{AttributeServiceName: "api"},
+			Keys:       []string{AttributeServiceName, "service_name"},
+		}.Labels()
+
+		suite.ErrorIs(err, ErrDuplicateResourceLabel)
+	})
+
+	suite.Run("RepeatedKey", func() {
+		labels, err := Resource{
+			Attributes: map[string]string{AttributeServiceName: "api"},
+			Keys:       []string{AttributeServiceName, AttributeServiceName},
+		}.Labels()
+
+		suite.NoError(err)
+		suite.Equal(prometheus.Labels{"service_name": "api"}, labels)
+	})
+}
+
+func TestResource(t *testing.T) {
+	suite.Run(t, new(ResourceTestSuite))
+}
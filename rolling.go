@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrInvalidWindow indicates that a rolling window or its number of buckets was
+// not positive, or that the window was too small to divide into that many buckets.
+var ErrInvalidWindow = errors.New("A rolling window and its bucket count must be positive, and the window must be at least one nanosecond per bucket")
+
+// rollingBucket holds the values recorded during one slice of a rolling window.
+type rollingBucket struct {
+	// epoch identifies the slice of time this bucket currently holds
+	epoch int64
+
+	sum   float64
+	count int64
+	max   float64
+}
+
+// rolling is a ring of buckets that together cover a window of time.  Buckets
+// are lazily recycled as time moves on, so no background goroutine is needed.
+type rolling struct {
+	lock    sync.Mutex
+	width   int64
+	buckets []rollingBucket
+	now     func() time.Time
+}
+
+func newRolling(window time.Duration, buckets int) (*rolling, error) {
+	if window <= 0 || buckets <= 0 || int64(window) < int64(buckets) {
+		return nil, ErrInvalidWindow
+	}
+
+	r := &rolling{
+		width:   int64(window) / int64(buckets),
+		buckets: make([]rollingBucket, buckets),
+		now:     time.Now,
+	}
+
+	for i := range r.buckets {
+		r.buckets[i].epoch = math.MinInt64
+	}
+
+	return r, nil
+}
+
+func (r *rolling) epoch() int64 {
+	return r.now().UnixNano() / r.width
+}
+
+// record adds a value to the current bucket.
+func (r *rolling) record(v float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	e := r.epoch()
+	b := &r.buckets[int(e%int64(len(r.buckets)))]
+	if b.epoch != e {
+		*b = rollingBucket{epoch: e, max: v}
+	} else if v > b.max {
+		b.max = v
+	}
+
+	b.sum += v
+	b.count++
+}
+
+// visit invokes f for each bucket that lies within the current window.
+func (r *rolling) visit(f func(rollingBucket)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	oldest := r.epoch() - int64(len(r.buckets)) + 1
+	for _, b := range r.buckets {
+		if b.epoch >= oldest && b.count > 0 {
+			f(b)
+		}
+	}
+}
+
+// RollingCounter tracks the sum of values added over a recent window of time,
+// e.g. the last 5 minutes.  This allows code to make in-process decisions, such as
+// load shedding, on recent rates rather than on the lifetime totals a prometheus
+// counter holds.
+//
+// The window is divided into a fixed number of buckets, which determines the
+// granularity with which old values expire.
+//
+// A RollingCounter is safe for concurrent use.
+type RollingCounter struct {
+	rolling *rolling
+	window  time.Duration
+	next    prometheus.Counter
+}
+
+// NewRollingCounter creates a RollingCounter over the given window, divided into the given
+// number of buckets.  If next is not nil, every value added to the RollingCounter is
+// also added to next.  This allows the same instrumentation to feed both a prometheus
+// counter and in-process decisions.
+func NewRollingCounter(window time.Duration, buckets int, next prometheus.Counter) (*RollingCounter, error) {
+	r, err := newRolling(window, buckets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RollingCounter{
+		rolling: r,
+		window:  window,
+		next:    next,
+	}, nil
+}
+
+// Inc adds 1 to this counter.
+func (rc *RollingCounter) Inc() {
+	rc.Add(1.0)
+}
+
+// Add adds the given value to this counter.  As with prometheus counters, the
+// value must not be negative.
+func (rc *RollingCounter) Add(v float64) {
+	rc.rolling.record(v)
+	if rc.next != nil {
+		rc.next.Add(v)
+	}
+}
+
+// Sum returns the total of the values added within the window.
+func (rc *RollingCounter) Sum() (sum float64) {
+	rc.rolling.visit(func(b rollingBucket) {
+		sum += b.sum
+	})
+
+	return
+}
+
+// Rate returns the per-second rate of the values added within the window.
+func (rc *RollingCounter) Rate() float64 {
+	return rc.Sum() / rc.window.Seconds()
+}
+
+// RollingGauge tracks values set over a recent window of time, reporting their
+// mean and maximum.  Typical uses are queue depths or concurrency levels that
+// drive in-process decisions.
+//
+// A RollingGauge is safe for concurrent use.
+type RollingGauge struct {
+	rolling *rolling
+	next    prometheus.Gauge
+}
+
+// NewRollingGauge creates a RollingGauge over the given window, divided into the given
+// number of buckets.  If next is not nil, every value set on the RollingGauge is also
+// set on next.
+func NewRollingGauge(window time.Duration, buckets int, next prometheus.Gauge) (*RollingGauge, error) {
+	r, err := newRolling(window, buckets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RollingGauge{
+		rolling: r,
+		next:    next,
+	}, nil
+}
+
+// Set records a value for this gauge.
+func (rg *RollingGauge) Set(v float64) {
+	rg.rolling.record(v)
+	if rg.next != nil {
+		rg.next.Set(v)
+	}
+}
+
+// Mean returns the average of the values set within the window.  If no values
+// were set within the window, this method returns 0.
+func (rg *RollingGauge) Mean() float64 {
+	var (
+		sum   float64
+		count int64
+	)
+
+	rg.rolling.visit(func(b rollingBucket) {
+		sum += b.sum
+		count += b.count
+	})
+
+	if count == 0 {
+		return 0.0
+	}
+
+	return sum / float64(count)
+}
+
+// Max returns the largest value set within the window.  If no values were set
+// within the window, this method returns 0.
+func (rg *RollingGauge) Max() (max float64) {
+	first := true
+	rg.rolling.visit(func(b rollingBucket) {
+		if first || b.max > max {
+			max, first = b.max, false
+		}
+	})
+
+	return
+}
+
+// NewRollingCounter creates a RollingCounter and registers a gauge that reports
+// its windowed Sum each time metrics are gathered.  The RollingCounter uses this
+// Factory's Clock.
+//
+// This method returns an error if the options do not specify a name.  Both namespace
+// and subsystem are defaulted appropriately if not set in the options.
+func (f *Factory) NewRollingCounter(o prometheus.GaugeOpts, window time.Duration, buckets int) (*RollingCounter, error) {
+	rc, err := NewRollingCounter(window, buckets, nil)
+	if err == nil {
+		rc.rolling.now = f.Clock().Now
+		_, err = f.NewGaugeFunc(o, rc.Sum)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rc, nil
+}
+
+// NewRollingGauge creates a RollingGauge and registers a gauge that reports its
+// windowed Mean each time metrics are gathered.  The RollingGauge uses this
+// Factory's Clock.
+//
+// This method returns an error if the options do not specify a name.  Both namespace
+// and subsystem are defaulted appropriately if not set in the options.
+func (f *Factory) NewRollingGauge(o prometheus.GaugeOpts, window time.Duration, buckets int) (*RollingGauge, error) {
+	rg, err := NewRollingGauge(window, buckets, nil)
+	if err == nil {
+		rg.rolling.now = f.Clock().Now
+		_, err = f.NewGaugeFunc(o, rg.Mean)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rg, nil
+}
@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type RollingTestSuite struct {
+	FxTestSuite
+
+	current time.Time
+}
+
+func (suite *RollingTestSuite) SetupTest() {
+	suite.current = time.Unix(1700000000, 0)
+}
+
+func (suite *RollingTestSuite) now() time.Time {
+	return suite.current
+}
+
+func (suite *RollingTestSuite) advance(d time.Duration) {
+	suite.current = suite.current.Add(d)
+}
+
+func (suite *RollingTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{}, suite.logger, r)
+}
+
+func (suite *RollingTestSuite) TestInvalidWindow() {
+	for _, tc := range []struct {
+		window  time.Duration
+		buckets int
+	}{
+		{window: 0, buckets: 10},
+		{window: time.Minute, buckets: 0},
+		{window: 5, buckets: 10},
+	} {
+		_, err := NewRollingCounter(tc.window, tc.buckets, nil)
+		suite.ErrorIs(err, ErrInvalidWindow)
+
+		_, err = NewRollingGauge(tc.window, tc.buckets, nil)
+		suite.ErrorIs(err, ErrInvalidWindow)
+	}
+}
+
+func (suite *RollingTestSuite) TestRollingCounter() {
+	next := prometheus.NewCounter(prometheus.CounterOpts{Name: "test"})
+	rc, err := NewRollingCounter(time.Minute, 6, next)
+	suite.Require().NoError(err)
+	rc.rolling.now = suite.now
+
+	suite.Zero(rc.Sum())
+
+	rc.Inc()
+	rc.Add(2.0)
+	suite.Equal(3.0, rc.Sum())
+
+	suite.advance(30 * time.Second)
+	rc.Add(3.0)
+	suite.Equal(6.0, rc.Sum())
+	suite.Equal(0.1, rc.Rate())
+
+	// the first values expire
+	suite.advance(40 * time.Second)
+	suite.Equal(3.0, rc.Sum())
+
+	// everything expires
+	suite.advance(time.Hour)
+	suite.Zero(rc.Sum())
+
+	// the next counter holds the lifetime total
+	suite.Equal(6.0, testutil.ToFloat64(next))
+}
+
+func (suite *RollingTestSuite) TestRollingGauge() {
+	next := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test"})
+	rg, err := NewRollingGauge(time.Minute, 6, next)
+	suite.Require().NoError(err)
+	rg.rolling.now = suite.now
+
+	suite.Zero(rg.Mean())
+	suite.Zero(rg.Max())
+
+	rg.Set(-4.0)
+	suite.Equal(-4.0, rg.Max())
+
+	rg.Set(2.0)
+	suite.advance(30 * time.Second)
+	rg.Set(8.0)
+	suite.Equal(2.0, rg.Mean())
+	suite.Equal(8.0, rg.Max())
+	suite.Equal(8.0, testutil.ToFloat64(next))
+
+	suite.advance(40 * time.Second)
+	suite.Equal(8.0, rg.Mean())
+	suite.Equal(8.0, rg.Max())
+
+	suite.advance(time.Hour)
+	suite.Zero(rg.Mean())
+	suite.Zero(rg.Max())
+}
+
+func (suite *RollingTestSuite) TestFactory() {
+	f := suite.newFactory()
+
+	rc, err := f.NewRollingCounter(prometheus.GaugeOpts{Name: "recent_requests", Help: "test"}, time.Minute, 6)
+	suite.Require().NoError(err)
+	rc.Add(5.0)
+
+	rg, err := f.NewRollingGauge(prometheus.GaugeOpts{Name: "recent_depth", Help: "test"}, time.Minute, 6)
+	suite.Require().NoError(err)
+	rg.Set(2.0)
+	rg.Set(4.0)
+
+	_, err = f.NewRollingCounter(prometheus.GaugeOpts{}, time.Minute, 6)
+	suite.ErrorIs(err, ErrNoMetricName)
+
+	_, err = f.NewRollingGauge(prometheus.GaugeOpts{Name: "invalid"}, 0, 6)
+	suite.ErrorIs(err, ErrInvalidWindow)
+}
+
+func TestRolling(t *testing.T) {
+	suite.Run(t, new(RollingTestSuite))
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+)
+
+const (
+	// RegisterersGroup is the fx value group of NamedRegisterer components that
+	// Provide consults for Config.Routes.
+	RegisterersGroup = "touchstone.registerers"
+)
+
+// ErrNoSuchRegisterer indicates that a Route referred to a Registerer that was not supplied.
+var ErrNoSuchRegisterer = errors.New("No such registerer")
+
+// Route sends metrics whose namespace matches a pattern to an alternate, named
+// Registerer, e.g. a debug or per-tenant registry that has its own scrape endpoint.
+type Route struct {
+	// Namespace is the metric namespace to route.  This may be an exact namespace
+	// or a glob pattern as understood by path.Match, e.g. "debug_*".  The namespace
+	// is matched after any Config.DefaultNamespace has been applied.
+	Namespace string `json:"namespace" yaml:"namespace"`
+
+	// Registerer is the name of the Registerer that receives matching metrics.
+	Registerer string `json:"registerer" yaml:"registerer"`
+}
+
+// NamedRegisterer associates a name with a Registerer that Routes may refer to.
+type NamedRegisterer struct {
+	Name       string
+	Registerer prometheus.Registerer
+}
+
+// Router selects the Registerer for each metric based on its namespace.  Routes
+// are consulted in order, and the first match wins.  Metrics that match no route,
+// as well as collectors without a namespace, use the Factory's Registerer.
+type Router struct {
+	routes      []Route
+	registerers map[string]prometheus.Registerer
+}
+
+// NewRouter validates a set of routes against the available registerers.  Each route
+// must have a well formed pattern and must refer to one of the registerers.
+func NewRouter(routes []Route, registerers ...NamedRegisterer) (*Router, error) {
+	r := &Router{
+		routes:      append([]Route{}, routes...),
+		registerers: make(map[string]prometheus.Registerer, len(registerers)),
+	}
+
+	for _, nr := range registerers {
+		r.registerers[nr.Name] = nr.Registerer
+	}
+
+	for _, route := range r.routes {
+		if err := checkPatterns([]string{route.Namespace}); err != nil {
+			return nil, err
+		}
+
+		if r.registerers[route.Registerer] == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNoSuchRegisterer, route.Registerer)
+		}
+	}
+
+	return r, nil
+}
+
+// Registerer returns the Registerer for the given namespace.  If no route matches,
+// this method returns nil.  A nil Router matches nothing.
+func (r *Router) Registerer(namespace string) prometheus.Registerer {
+	if r == nil || len(namespace) == 0 {
+		return nil
+	}
+
+	for _, route := range r.routes {
+		if matchesAny([]string{route.Namespace}, namespace) {
+			return r.registerers[route.Registerer]
+		}
+	}
+
+	return nil
+}
+
+// WithRouter sets the Router that selects alternate registerers for the metrics a
+// Factory creates.  A nil Router registers all metrics with the Factory's Registerer.
+func WithRouter(r *Router) FactoryOption {
+	return func(f *Factory) {
+		f.router = r
+	}
+}
+
+// SupplyRegisterer supplies a named Registerer to the enclosing fx.App, making it
+// available to Config.Routes.
+func SupplyRegisterer(name string, r prometheus.Registerer) fx.Option {
+	return fx.Supply(
+		fx.Annotate(
+			NamedRegisterer{Name: name, Registerer: r},
+			fx.ResultTags(fmt.Sprintf(`group:"%s"`, RegisterersGroup)),
+		),
+	)
+}
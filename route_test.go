@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+type RouteTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *RouteTestSuite) TestNewRouter() {
+	debug := prometheus.NewRegistry()
+	r, err := NewRouter(
+		[]Route{
+			{Namespace: "debug_*", Registerer: "debug"},
+			{Namespace: "internal", Registerer: "debug"},
+		},
+		NamedRegisterer{Name: "debug", Registerer: debug},
+	)
+
+	suite.Require().NoError(err)
+	suite.Same(debug, r.Registerer("debug_cache"))
+	suite.Same(debug, r.Registerer("internal"))
+	suite.Nil(r.Registerer("app"))
+	suite.Nil(r.Registerer(""))
+
+	var nilRouter *Router
+	suite.Nil(nilRouter.Registerer("debug_cache"))
+
+	suite.Run("NoSuchRegisterer", func() {
+		_, err := NewRouter([]Route{{Namespace: "debug", Registerer: "nosuch"}})
+		suite.ErrorIs(err, ErrNoSuchRegisterer)
+	})
+
+	suite.Run("InvalidPattern", func() {
+		_, err := NewRouter(
+			[]Route{{Namespace: "[", Registerer: "debug"}},
+			NamedRegisterer{Name: "debug", Registerer: debug},
+		)
+
+		suite.Error(err)
+	})
+}
+
+func (suite *RouteTestSuite) TestFactory() {
+	var (
+		main  = prometheus.NewPedanticRegistry()
+		debug = prometheus.NewPedanticRegistry()
+	)
+
+	router, err := NewRouter(
+		[]Route{{Namespace: "debug", Registerer: "debug"}},
+		NamedRegisterer{Name: "debug", Registerer: debug},
+	)
+
+	suite.Require().NoError(err)
+	f := NewFactory(Config{DefaultNamespace: "app"}, suite.logger, main, WithRouter(router))
+
+	_, err = f.NewCounter(prometheus.CounterOpts{Name: "requests", Help: "requests"})
+	suite.Require().NoError(err)
+
+	_, err = f.NewGaugeVec(prometheus.GaugeOpts{Namespace: "debug", Name: "cache_size", Help: "cache size"}, "cache")
+	suite.Require().NoError(err)
+
+	c, err := f.NewCounter(prometheus.CounterOpts{Namespace: "debug", Name: "evictions", Help: "evictions"})
+	suite.Require().NoError(err)
+	c.Inc()
+
+	count, err := testutil.GatherAndCount(main)
+	suite.Require().NoError(err)
+	suite.Equal(1, count)
+
+	count, err = testutil.GatherAndCount(debug, "debug_evictions")
+	suite.Require().NoError(err)
+	suite.Equal(1, count)
+}
+
+func (suite *RouteTestSuite) TestProvide() {
+	debug := prometheus.NewPedanticRegistry()
+
+	var f *Factory
+	app := suite.newTestApp(
+		fx.Supply(Config{
+			Routes: []Route{{Namespace: "debug", Registerer: "debug"}},
+		}),
+		SupplyRegisterer("debug", debug),
+		Provide(),
+		fx.Populate(&f),
+	)
+
+	app.RequireStart()
+	defer app.RequireStop()
+
+	c, err := f.NewCounter(prometheus.CounterOpts{Namespace: "debug", Name: "evictions", Help: "evictions"})
+	suite.Require().NoError(err)
+	c.Inc()
+
+	count, err := testutil.GatherAndCount(debug, "debug_evictions")
+	suite.Require().NoError(err)
+	suite.Equal(1, count)
+}
+
+func (suite *RouteTestSuite) TestProvideNoSuchRegisterer() {
+	app := fx.New(
+		fx.NopLogger,
+		fx.Supply(Config{
+			Routes: []Route{{Namespace: "debug", Registerer: "nosuch"}},
+		}),
+		Provide(),
+		fx.Invoke(func(*Factory) {}),
+	)
+
+	suite.ErrorIs(app.Err(), ErrNoSuchRegisterer)
+}
+
+func TestRoute(t *testing.T) {
+	suite.Run(t, new(RouteTestSuite))
+}
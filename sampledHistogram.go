@@ -0,0 +1,249 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// DefaultSamplingWindow is the interval over which adaptive sampling measures
+// the rate of observations when Sampling.Window is unset.
+const DefaultSamplingWindow = time.Second
+
+// ErrInvalidSampling indicates that a Sampling had a negative budget or window,
+// or that histogram buckets were not in increasing order.
+var ErrInvalidSampling = errors.New("A sampling budget and window cannot be negative, and buckets must be in increasing order")
+
+// Sampling describes how a SampledHistogram selects the observations it records.
+// The zero value records every observation.
+type Sampling struct {
+	// Every records one in every Every observations.  Values less than 2 record every
+	// observation.  This field is ignored when Budget is set.
+	Every uint64 `json:"every" yaml:"every"`
+
+	// Budget enables adaptive sampling.  If positive, it is the number of observations
+	// per second to record, and the sampling rate is recomputed every Window from
+	// the actual rate of observations.
+	Budget float64 `json:"budget" yaml:"budget"`
+
+	// Window is the interval over which adaptive sampling measures the rate of
+	// observations.  If unset, DefaultSamplingWindow is used.
+	Window time.Duration `json:"window" yaml:"window"`
+}
+
+// SampledHistogram is a histogram for code paths measured so often, e.g. millions of
+// times per second, that even a lock-free prometheus histogram shows up in profiles.
+// Only a sample of observations are recorded, and each recorded observation is weighted
+// by the sampling rate in effect when it was taken.  The bucket counts, count, and sum
+// therefore remain estimates of the totals of all observations, and rates and quantiles
+// computed from them remain meaningful.
+//
+// Sampling is deterministic, selecting one in every N observations, so that no random
+// numbers are generated in the hot path.  Adaptive sampling only consults the clock
+// for observations that are recorded.
+//
+// A SampledHistogram is a prometheus.Collector and is safe for concurrent use.
+type SampledHistogram struct {
+	desc        *prometheus.Desc
+	upperBounds []float64
+
+	// buckets holds the weighted count of observations in each bucket, not cumulative
+	buckets []atomic.Uint64
+	count   atomic.Uint64
+	sumBits atomic.Uint64
+
+	calls atomic.Uint64
+	every atomic.Uint64
+
+	// adaptive sampling, only used when budget is positive
+	budget      float64
+	window      int64
+	now         func() time.Time
+	windowStart atomic.Int64
+	windowCalls atomic.Uint64
+}
+
+// NewSampledHistogram creates a SampledHistogram that is not registered with any
+// registry.  If o has no buckets, prometheus.DefBuckets is used.
+//
+// Unlike the Factory method, this function applies no defaults or naming policy, and
+// adaptive sampling uses the system clock.
+func NewSampledHistogram(o prometheus.HistogramOpts, s Sampling) (*SampledHistogram, error) {
+	return newSampledHistogram(o, s, time.Now)
+}
+
+func newSampledHistogram(o prometheus.HistogramOpts, s Sampling, now func() time.Time) (*SampledHistogram, error) {
+	if s.Budget < 0 || s.Window < 0 {
+		return nil, ErrInvalidSampling
+	}
+
+	upperBounds := o.Buckets
+	if len(upperBounds) == 0 {
+		upperBounds = prometheus.DefBuckets
+	}
+
+	if math.IsInf(upperBounds[len(upperBounds)-1], +1) {
+		upperBounds = upperBounds[:len(upperBounds)-1]
+	}
+
+	for i := 1; i < len(upperBounds); i++ {
+		if upperBounds[i] <= upperBounds[i-1] {
+			return nil, ErrInvalidSampling
+		}
+	}
+
+	sh := &SampledHistogram{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(o.Namespace, o.Subsystem, o.Name),
+			o.Help,
+			nil,
+			o.ConstLabels,
+		),
+		upperBounds: append([]float64{}, upperBounds...),
+		buckets:     make([]atomic.Uint64, len(upperBounds)),
+		budget:      s.Budget,
+		window:      int64(s.Window),
+		now:         now,
+	}
+
+	if sh.window == 0 {
+		sh.window = int64(DefaultSamplingWindow)
+	}
+
+	every := s.Every
+	if every < 1 || s.Budget > 0 {
+		every = 1
+	}
+
+	sh.every.Store(every)
+	if sh.budget > 0 {
+		sh.windowStart.Store(now().UnixNano())
+	}
+
+	return sh, nil
+}
+
+// Every returns the current sampling rate, i.e. one in every N observations is recorded.
+// For adaptive sampling, this value changes over time.
+func (sh *SampledHistogram) Every() uint64 {
+	return sh.every.Load()
+}
+
+// Observe samples an observation.  This method implements prometheus.Observer.
+func (sh *SampledHistogram) Observe(v float64) {
+	n := sh.calls.Add(1)
+	every := sh.every.Load()
+	if n%every != 0 {
+		return
+	}
+
+	sh.record(v, every)
+	if sh.budget > 0 {
+		sh.adapt(n)
+	}
+}
+
+// record adds an observation with the given weight.
+func (sh *SampledHistogram) record(v float64, weight uint64) {
+	if i := sort.SearchFloat64s(sh.upperBounds, v); i < len(sh.buckets) {
+		sh.buckets[i].Add(weight)
+	}
+
+	sh.count.Add(weight)
+	for {
+		old := sh.sumBits.Load()
+		updated := math.Float64bits(math.Float64frombits(old) + v*float64(weight))
+		if sh.sumBits.CompareAndSwap(old, updated) {
+			return
+		}
+	}
+}
+
+// adapt recomputes the sampling rate once the current window has elapsed.  Only one
+// goroutine recomputes the rate for any given window.
+func (sh *SampledHistogram) adapt(calls uint64) {
+	now := sh.now().UnixNano()
+	start := sh.windowStart.Load()
+	elapsed := now - start
+	if elapsed < sh.window || !sh.windowStart.CompareAndSwap(start, now) {
+		return
+	}
+
+	windowCalls := calls - sh.windowCalls.Swap(calls)
+	rate := float64(windowCalls) / time.Duration(elapsed).Seconds()
+	every := uint64(math.Ceil(rate / sh.budget))
+	if every < 1 {
+		every = 1
+	}
+
+	sh.every.Store(every)
+}
+
+// Describe implements prometheus.Collector.
+func (sh *SampledHistogram) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sh.desc
+}
+
+// Collect implements prometheus.Collector.
+func (sh *SampledHistogram) Collect(ch chan<- prometheus.Metric) {
+	// record adds to a bucket before the count, so loading the count first means that
+	// the buckets can only be ahead of it.  Capping them keeps the histogram consistent.
+	var (
+		count      = sh.count.Load()
+		cumulative uint64
+		buckets    = make(map[float64]uint64, len(sh.upperBounds))
+	)
+
+	for i, ub := range sh.upperBounds {
+		cumulative += sh.buckets[i].Load()
+		if cumulative > count {
+			cumulative = count
+		}
+
+		buckets[ub] = cumulative
+	}
+
+	ch <- prometheus.MustNewConstHistogram(
+		sh.desc,
+		count,
+		math.Float64frombits(sh.sumBits.Load()),
+		buckets,
+	)
+}
+
+// NewSampledHistogram creates and registers a SampledHistogram.  See the package-level
+// NewSampledHistogram.  Adaptive sampling uses this Factory's Clock.
+//
+// This method returns an error if the options do not specify a name.  Both namespace
+// and subsystem are defaulted appropriately if not set in the options.
+func (f *Factory) NewSampledHistogram(o prometheus.HistogramOpts, s Sampling) (sh *SampledHistogram, err error) {
+	err = f.checkName(o.Name)
+	if err == nil {
+		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
+		f.warnOnNoHelp(o.Name, o.Help)
+
+		sh, err = newSampledHistogram(o, s, f.Clock().Now)
+	}
+
+	if err == nil {
+		err = f.register(sh, RegistrationEvent{
+			Type: dto.MetricType_HISTOGRAM,
+			Opts: histogramOpts(o),
+		})
+	}
+
+	if err != nil {
+		sh = nil
+	}
+
+	return
+}
@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+)
+
+type SampledHistogramTestSuite struct {
+	FxTestSuite
+
+	current time.Time
+}
+
+func (suite *SampledHistogramTestSuite) SetupTest() {
+	suite.current = time.Unix(1700000000, 0)
+}
+
+func (suite *SampledHistogramTestSuite) now() time.Time {
+	return suite.current
+}
+
+func (suite *SampledHistogramTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r, WithClock(ClockFunc(suite.now)))
+}
+
+func (suite *SampledHistogramTestSuite) TestInvalid() {
+	for _, tc := range []struct {
+		buckets  []float64
+		sampling Sampling
+	}{
+		{sampling: Sampling{Budget: -1.0}},
+		{sampling: Sampling{Window: -time.Second}},
+		{buckets: []float64{1.0, 1.0}},
+		{buckets: []float64{2.0, 1.0}},
+	} {
+		sh, err := NewSampledHistogram(
+			prometheus.HistogramOpts{Name: "test", Buckets: tc.buckets},
+			tc.sampling,
+		)
+
+		suite.ErrorIs(err, ErrInvalidSampling)
+		suite.Nil(sh)
+	}
+}
+
+func (suite *SampledHistogramTestSuite) TestUnsampled() {
+	sh, err := NewSampledHistogram(
+		prometheus.HistogramOpts{Name: "test", Buckets: []float64{1.0, 2.0, math.Inf(+1)}},
+		Sampling{},
+	)
+
+	suite.Require().NoError(err)
+	suite.Equal(uint64(1), sh.Every())
+
+	sh.Observe(0.5)
+	sh.Observe(1.5)
+	sh.Observe(3.0)
+
+	hd, err := HistogramValue(sh, nil)
+	suite.Require().NoError(err)
+	suite.Equal(uint64(3), hd.Count)
+	suite.Equal(5.0, hd.Sum)
+	suite.Equal(
+		map[float64]uint64{1.0: 1, 2.0: 2},
+		hd.Buckets,
+	)
+}
+
+func (suite *SampledHistogramTestSuite) TestCollectInFlight() {
+	sh, err := NewSampledHistogram(
+		prometheus.HistogramOpts{Name: "test", Buckets: []float64{1.0, 2.0}},
+		Sampling{},
+	)
+
+	suite.Require().NoError(err)
+	sh.Observe(1.5)
+
+	// an observation that has reached its bucket, but not yet the count
+	sh.buckets[0].Add(1)
+
+	hd, err := HistogramValue(sh, nil)
+	suite.Require().NoError(err)
+	suite.Equal(uint64(1), hd.Count)
+	suite.Equal(
+		map[float64]uint64{1.0: 1, 2.0: 1},
+		hd.Buckets,
+	)
+}
+
+func (suite *SampledHistogramTestSuite) TestEvery() {
+	sh, err := NewSampledHistogram(
+		prometheus.HistogramOpts{Name: "test", Buckets: []float64{1.0, 2.0}},
+		Sampling{Every: 4},
+	)
+
+	suite.Require().NoError(err)
+	suite.Equal(uint64(4), sh.Every())
+
+	for i := 0; i < 100; i++ {
+		sh.Observe(0.5)
+	}
+
+	for i := 0; i < 20; i++ {
+		sh.Observe(1.5)
+	}
+
+	hd, err := HistogramValue(sh, nil)
+	suite.Require().NoError(err)
+	suite.Equal(uint64(120), hd.Count)
+	suite.InDelta(80.0, hd.Sum, 0.0001)
+	suite.Equal(
+		map[float64]uint64{1.0: 100, 2.0: 120},
+		hd.Buckets,
+	)
+}
+
+func (suite *SampledHistogramTestSuite) TestAdaptive() {
+	f := suite.newFactory()
+	sh, err := f.NewSampledHistogram(
+		prometheus.HistogramOpts{Name: "sampled", Help: "test", Buckets: []float64{1.0}},
+		Sampling{Every: 100, Budget: 10.0},
+	)
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(sh)
+	suite.Equal(uint64(1), sh.Every(), "a budget should start by recording everything")
+
+	for i := 0; i < 1000; i++ {
+		sh.Observe(0.5)
+	}
+
+	suite.Equal(uint64(1), sh.Every(), "the window has not elapsed")
+
+	// 1000 calls in the window, plus this one, at 10 per second
+	suite.current = suite.current.Add(time.Second)
+	sh.Observe(0.5)
+	suite.Equal(uint64(101), sh.Every())
+
+	for i := 0; i < 1010; i++ {
+		sh.Observe(0.5)
+	}
+
+	hd, err := HistogramValue(sh, nil)
+	suite.Require().NoError(err)
+	suite.Equal(uint64(2011), hd.Count)
+	suite.Equal(map[float64]uint64{1.0: 2011}, hd.Buckets)
+
+	// a quiet window should reduce the sampling rate back to everything
+	suite.current = suite.current.Add(time.Hour)
+	for i := uint64(0); i < sh.Every(); i++ {
+		sh.Observe(0.5)
+	}
+
+	suite.Equal(uint64(1), sh.Every())
+}
+
+func (suite *SampledHistogramTestSuite) TestFactoryNoName() {
+	f := suite.newFactory()
+	sh, err := f.NewSampledHistogram(prometheus.HistogramOpts{}, Sampling{})
+	suite.Error(err)
+	suite.Nil(sh)
+}
+
+func TestSampledHistogram(t *testing.T) {
+	suite.Run(t, new(SampledHistogramTestSuite))
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	// ErrNoStates indicates that a StateSet was created without any states.
+	ErrNoStates = errors.New("A StateSet requires at least one state")
+
+	// ErrDuplicateState indicates that a StateSet was created with the same state
+	// more than once.
+	ErrDuplicateState = errors.New("StateSet states must be unique")
+
+	// ErrNoSuchState indicates an attempt to transition a StateSet into a state
+	// it was not created with.
+	ErrNoSuchState = errors.New("No such state")
+)
+
+// StateSet reports a set of mutually exclusive states, e.g. leader and follower,
+// as a gauge with one series per state.  The series for the current state has the
+// value 1, and every other series has the value 0.
+//
+// A StateSet is a prometheus.Collector that emits all of its series under a single
+// lock.  A gather therefore never observes a transition in progress, such as
+// two states with the value 1 or no state with the value 1.  This is not the case
+// when a *prometheus.GaugeVec is updated one series at a time.
+//
+// A StateSet is safe for concurrent use.
+type StateSet struct {
+	desc   *prometheus.Desc
+	states []string
+
+	lock    sync.RWMutex
+	current int
+}
+
+// NewStateSet creates a StateSet that is not registered with any registry.  The label
+// is the name of the label whose values are the states, and the initial state is
+// the first state.
+//
+// Unlike the Factory method, this function applies no defaults or naming policy.
+func NewStateSet(o prometheus.GaugeOpts, label string, states ...string) (*StateSet, error) {
+	if len(states) == 0 {
+		return nil, ErrNoStates
+	}
+
+	seen := make(map[string]bool, len(states))
+	for _, s := range states {
+		if seen[s] {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateState, s)
+		}
+
+		seen[s] = true
+	}
+
+	return &StateSet{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(o.Namespace, o.Subsystem, o.Name),
+			o.Help,
+			[]string{label},
+			o.ConstLabels,
+		),
+		states: append([]string{}, states...),
+	}, nil
+}
+
+// States returns a copy of the states of this set, in the order they were supplied.
+func (ss *StateSet) States() []string {
+	return append([]string{}, ss.states...)
+}
+
+// State returns the current state.
+func (ss *StateSet) State() string {
+	ss.lock.RLock()
+	defer ss.lock.RUnlock()
+	return ss.states[ss.current]
+}
+
+// Set transitions this set into the given state.  If the state is not one of
+// the states of this set, this method returns ErrNoSuchState and the current
+// state is unchanged.
+func (ss *StateSet) Set(state string) error {
+	for i, s := range ss.states {
+		if s == state {
+			ss.lock.Lock()
+			ss.current = i
+			ss.lock.Unlock()
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrNoSuchState, state)
+}
+
+// Describe implements prometheus.Collector.
+func (ss *StateSet) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ss.desc
+}
+
+// Collect implements prometheus.Collector.
+func (ss *StateSet) Collect(ch chan<- prometheus.Metric) {
+	ss.lock.RLock()
+	current := ss.current
+	ss.lock.RUnlock()
+
+	for i, s := range ss.states {
+		var v float64
+		if i == current {
+			v = 1.0
+		}
+
+		ch <- prometheus.MustNewConstMetric(ss.desc, prometheus.GaugeValue, v, s)
+	}
+}
+
+// NewStateSet creates and registers a StateSet.  See the package-level NewStateSet.
+//
+// This method returns an error if the options do not specify a name.  Both namespace
+// and subsystem are defaulted appropriately if not set in the options.
+func (f *Factory) NewStateSet(o prometheus.GaugeOpts, label string, states ...string) (ss *StateSet, err error) {
+	err = f.checkName(o.Name)
+	if err == nil {
+		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
+		f.warnOnNoHelp(o.Name, o.Help)
+
+		ss, err = NewStateSet(o, label, states...)
+	}
+
+	if err == nil {
+		err = f.register(ss, RegistrationEvent{
+			Type:       dto.MetricType_GAUGE,
+			Opts:       prometheus.Opts(o),
+			LabelNames: []string{label},
+		})
+	}
+
+	if err != nil {
+		ss = nil
+	}
+
+	return
+}
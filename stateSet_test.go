@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type StateSetTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *StateSetTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r)
+}
+
+func (suite *StateSetTestSuite) TestNewStateSet() {
+	suite.Run("NoStates", func() {
+		_, err := NewStateSet(prometheus.GaugeOpts{Name: "role"}, "role")
+		suite.ErrorIs(err, ErrNoStates)
+	})
+
+	suite.Run("DuplicateState", func() {
+		_, err := NewStateSet(prometheus.GaugeOpts{Name: "role"}, "role", "leader", "follower", "leader")
+		suite.ErrorIs(err, ErrDuplicateState)
+	})
+
+	suite.Run("Transitions", func() {
+		ss, err := NewStateSet(prometheus.GaugeOpts{Name: "role", Help: "the role"}, "role", "follower", "leader")
+		suite.Require().NoError(err)
+		suite.Equal([]string{"follower", "leader"}, ss.States())
+		suite.Equal("follower", ss.State())
+
+		suite.NoError(
+			testutil.CollectAndCompare(ss, strings.NewReader(`
+# HELP role the role
+# TYPE role gauge
+role{role="follower"} 1
+role{role="leader"} 0
+`)),
+		)
+
+		suite.NoError(ss.Set("leader"))
+		suite.Equal("leader", ss.State())
+		suite.NoError(
+			testutil.CollectAndCompare(ss, strings.NewReader(`
+# HELP role the role
+# TYPE role gauge
+role{role="follower"} 0
+role{role="leader"} 1
+`)),
+		)
+
+		suite.ErrorIs(ss.Set("candidate"), ErrNoSuchState)
+		suite.Equal("leader", ss.State())
+	})
+}
+
+func (suite *StateSetTestSuite) TestConcurrentTransitions() {
+	ss, err := NewStateSet(prometheus.GaugeOpts{Name: "role", Help: "the role"}, "role", "primary", "standby")
+	suite.Require().NoError(err)
+
+	var (
+		wg   sync.WaitGroup
+		done = make(chan struct{})
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				_ = ss.Set(ss.States()[i%2])
+			}
+		}
+	}()
+
+	r := prometheus.NewPedanticRegistry()
+	suite.Require().NoError(r.Register(ss))
+	for i := 0; i < 200; i++ {
+		mfs, err := r.Gather()
+		suite.Require().NoError(err)
+		suite.Require().Len(mfs, 1)
+
+		var total float64
+		for _, m := range mfs[0].GetMetric() {
+			total += m.GetGauge().GetValue()
+		}
+
+		suite.Require().Equal(1.0, total)
+	}
+
+	close(done)
+	wg.Wait()
+}
+
+func (suite *StateSetTestSuite) TestFactory() {
+	f := suite.newFactory()
+	ss, err := f.NewStateSet(prometheus.GaugeOpts{Name: "role", Help: "the role"}, "role", "leader", "follower")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(ss)
+	suite.Equal(2, testutil.CollectAndCount(ss, "test_role"))
+
+	ss, err = f.NewStateSet(prometheus.GaugeOpts{}, "role", "leader")
+	suite.ErrorIs(err, ErrNoMetricName)
+	suite.Nil(ss)
+
+	ss, err = f.NewStateSet(prometheus.GaugeOpts{Name: "other"}, "role")
+	suite.ErrorIs(err, ErrNoStates)
+	suite.Nil(ss)
+
+	// already registered
+	ss, err = f.NewStateSet(prometheus.GaugeOpts{Name: "role", Help: "the role"}, "role", "leader", "follower")
+	suite.Error(err)
+	suite.Nil(ss)
+}
+
+func TestStateSet(t *testing.T) {
+	suite.Run(t, new(StateSetTestSuite))
+}
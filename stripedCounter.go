@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// errStripedCounterDecrease is the panic value used when a StripedCounter is
+// asked to decrease, matching the behavior of prometheus counters.
+var errStripedCounterDecrease = errors.New("counter cannot decrease in value")
+
+// cacheLineSize is the assumed size of a CPU cache line.  Stripes are padded
+// to this size so that concurrent updates to different stripes do not contend.
+const cacheLineSize = 64
+
+// stripe is a single, cache-line padded shard of a StripedCounter.  As with
+// prometheus counters, integral increments are kept separately from float
+// increments so that the common Inc path never needs a CAS loop.
+type stripe struct {
+	ints  atomic.Uint64
+	float atomic.Uint64
+	_     [cacheLineSize - 16]byte
+}
+
+func (s *stripe) add(v float64) {
+	for {
+		old := s.float.Load()
+		updated := math.Float64bits(math.Float64frombits(old) + v)
+		if s.float.CompareAndSwap(old, updated) {
+			return
+		}
+	}
+}
+
+// StripedCounter is a prometheus.Counter for extremely hot code paths where contention
+// on the single atomic value of a prometheus counter is measurable.  Updates are spread
+// over a number of cache-line padded stripes, one per available CPU, and the stripes are
+// summed only when the counter is written or collected.
+//
+// Go does not expose the CPU a goroutine is running on, so a stripe is chosen using the
+// runtime's per-thread random source.  This spreads concurrent updates without any
+// shared state.  A StripedCounter uses more memory than a prometheus counter, and reading
+// it is proportionally more expensive, so it should be reserved for counters that show
+// up in profiles.
+type StripedCounter struct {
+	desc    *prometheus.Desc
+	labels  []*dto.LabelPair
+	stripes []stripe
+	mask    uint32
+}
+
+var _ prometheus.Counter = (*StripedCounter)(nil)
+
+// NewStripedCounter creates a StripedCounter that is not registered with any registry.
+// The number of stripes is the smallest power of 2 not less than runtime.GOMAXPROCS
+// at the time of this call.
+func NewStripedCounter(o prometheus.CounterOpts) *StripedCounter {
+	n := 1
+	for n < runtime.GOMAXPROCS(0) {
+		n <<= 1
+	}
+
+	desc := prometheus.NewDesc(
+		prometheus.BuildFQName(o.Namespace, o.Subsystem, o.Name),
+		o.Help,
+		nil,
+		o.ConstLabels,
+	)
+
+	return &StripedCounter{
+		desc:    desc,
+		labels:  prometheus.MakeLabelPairs(desc, nil),
+		stripes: make([]stripe, n),
+		mask:    uint32(n - 1),
+	}
+}
+
+func (sc *StripedCounter) stripe() *stripe {
+	//nolint:gosec // the random number is only used to spread contention
+	return &sc.stripes[rand.Uint32()&sc.mask]
+}
+
+// Inc increments this counter by 1.
+func (sc *StripedCounter) Inc() {
+	sc.stripe().ints.Add(1)
+}
+
+// Add adds the given value to this counter.  As with prometheus counters, this
+// method panics if v is negative.
+func (sc *StripedCounter) Add(v float64) {
+	if v < 0 {
+		panic(errStripedCounterDecrease)
+	}
+
+	s := sc.stripe()
+	if ival := uint64(v); float64(ival) == v {
+		s.ints.Add(ival)
+		return
+	}
+
+	s.add(v)
+}
+
+// Get returns the current value of this counter, summed over all stripes.
+func (sc *StripedCounter) Get() float64 {
+	var (
+		ints  uint64
+		float float64
+	)
+
+	for i := range sc.stripes {
+		ints += sc.stripes[i].ints.Load()
+		float += math.Float64frombits(sc.stripes[i].float.Load())
+	}
+
+	return float64(ints) + float
+}
+
+// Desc implements prometheus.Metric.
+func (sc *StripedCounter) Desc() *prometheus.Desc {
+	return sc.desc
+}
+
+// Write implements prometheus.Metric.
+func (sc *StripedCounter) Write(m *dto.Metric) error {
+	m.Label = sc.labels
+	m.Counter = &dto.Counter{
+		Value: proto.Float64(sc.Get()),
+	}
+
+	return nil
+}
+
+// Describe implements prometheus.Collector.
+func (sc *StripedCounter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sc.desc
+}
+
+// Collect implements prometheus.Collector.
+func (sc *StripedCounter) Collect(ch chan<- prometheus.Metric) {
+	ch <- sc
+}
+
+// NewStripedCounter creates and registers a StripedCounter.  See the package-level
+// NewStripedCounter.
+//
+// This method returns an error if the options do not specify a name.  Both namespace
+// and subsystem are defaulted appropriately if not set in the options.
+func (f *Factory) NewStripedCounter(o prometheus.CounterOpts) (sc *StripedCounter, err error) {
+	err = f.checkName(o.Name)
+	if err == nil {
+		ApplyDefaults(&o, f.defaults)
+		o.Name = f.metricName(o.Name)
+		f.warnOnNoHelp(o.Name, o.Help)
+
+		sc = NewStripedCounter(o)
+		err = f.register(sc, RegistrationEvent{Type: dto.MetricType_COUNTER, Opts: prometheus.Opts(o)})
+	}
+
+	if err != nil {
+		sc = nil
+	}
+
+	return
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type StripedCounterTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *StripedCounterTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r)
+}
+
+func (suite *StripedCounterTestSuite) TestIncAndAdd() {
+	sc := NewStripedCounter(prometheus.CounterOpts{
+		Name:        "test",
+		ConstLabels: prometheus.Labels{"foo": "bar"},
+	})
+
+	sc.Inc()
+	sc.Add(2.0)
+	sc.Add(0.5)
+	suite.Equal(3.5, sc.Get())
+	suite.Equal(3.5, testutil.ToFloat64(sc))
+
+	v, err := Value(sc, prometheus.Labels{"foo": "bar"})
+	suite.Require().NoError(err)
+	suite.Equal(3.5, v)
+
+	suite.Panics(func() {
+		sc.Add(-1.0)
+	})
+}
+
+func (suite *StripedCounterTestSuite) TestConcurrent() {
+	const (
+		goroutines = 8
+		increments = 1000
+	)
+
+	var (
+		sc = NewStripedCounter(prometheus.CounterOpts{Name: "test"})
+		wg sync.WaitGroup
+	)
+
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				sc.Inc()
+				sc.Add(0.5)
+			}
+		}()
+	}
+
+	wg.Wait()
+	suite.Equal(goroutines*increments*1.5, sc.Get())
+}
+
+func (suite *StripedCounterTestSuite) TestFactory() {
+	f := suite.newFactory()
+	sc, err := f.NewStripedCounter(prometheus.CounterOpts{Name: "striped", Help: "test"})
+	suite.Require().NoError(err)
+	suite.Require().NotNil(sc)
+
+	sc.Inc()
+	suite.Equal(1.0, testutil.ToFloat64(sc))
+	suite.Equal("test_striped", descName(sc.Desc()))
+
+	sc, err = f.NewStripedCounter(prometheus.CounterOpts{})
+	suite.Error(err)
+	suite.Nil(sc)
+}
+
+func TestStripedCounter(t *testing.T) {
+	suite.Run(t, new(StripedCounterTestSuite))
+}
+
+func BenchmarkStripedCounter(b *testing.B) {
+	sc := NewStripedCounter(prometheus.CounterOpts{Name: "test"})
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sc.Inc()
+		}
+	})
+}
+
+func BenchmarkPrometheusCounter(b *testing.B) {
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "test"})
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Inc()
+		}
+	})
+}
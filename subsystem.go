@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"strings"
+
+	"go.uber.org/fx"
+)
+
+// SubsystemName converts an fx module name into a metric subsystem.  Each character
+// that is not valid in a metric name is replaced with an underscore, e.g. "auth.tokens"
+// becomes "auth_tokens".  A leading digit is also replaced.
+func SubsystemName(moduleName string) string {
+	var b strings.Builder
+	for i, r := range moduleName {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			b.WriteRune(r)
+
+		case r >= '0' && r <= '9' && i > 0:
+			b.WriteRune(r)
+
+		default:
+			b.WriteByte('_')
+		}
+	}
+
+	return b.String()
+}
+
+// derive creates a Factory identical to this one.  Registration events are dispatched
+// to this Factory's listeners as well as to any listeners added to the returned Factory,
+// and this Factory's Unregister and Close apply to the metrics the returned Factory creates.
+func (f *Factory) derive() *Factory {
+	return &Factory{
+		defaults:         f.defaults,
+		logger:           f.logger,
+		registerer:       f.registerer,
+		naming:           f.naming,
+		clock:            f.clock,
+		transforms:       f.transforms,
+		router:           f.router,
+		noCreated:        f.noCreated,
+		limiter:          f.limiter,
+		native:           f.native,
+		summaries:        f.summaries,
+		registered:       f.registered,
+		panics:           f.panics,
+		cardinalityLimit: f.cardinalityLimit,
+		parent:           f,
+	}
+}
+
+// withSubsystem creates a Factory derived from this one, but with the given default subsystem.
+func (f *Factory) withSubsystem(subsystem string) *Factory {
+	d := f.derive()
+	d.defaults.Subsystem = subsystem
+	return d
+}
+
+// Subsystem decorates the *Factory and MetricFactory components within an fx scope
+// so that metrics without a subsystem are created with the given subsystem.  Metrics
+// that specify a subsystem are unaffected.  Place this option within an fx.Module:
+//
+//	fx.New(
+//	  touchstone.Provide(),
+//	  fx.Module(
+//	    "auth",
+//	    touchstone.Subsystem("auth"),
+//	    auth.Provide(), // metrics land in the auth subsystem
+//	  ),
+//	)
+//
+// Like ReadOnly, the decorated MetricFactory is the decorated *Factory, so any
+// decoration of the MetricFactory from outside the scope is not applied.
+func Subsystem(subsystem string) fx.Option {
+	return fx.Decorate(
+		func(f *Factory) *Factory {
+			return f.withSubsystem(subsystem)
+		},
+		func(MetricFactory, f *Factory) MetricFactory {
+			return f
+		},
+	)
+}
+
+// SubsystemModule creates an fx.Module with the given name, whose metrics are created
+// in the subsystem derived from that name by SubsystemName.  This is shorthand for
+// an fx.Module that includes a Subsystem option:
+//
+//	fx.New(
+//	  touchstone.Provide(),
+//	  touchstone.SubsystemModule(
+//	    "auth",
+//	    auth.Provide(), // metrics land in the auth subsystem
+//	  ),
+//	)
+func SubsystemModule(name string, opts ...fx.Option) fx.Option {
+	return fx.Module(
+		name,
+		append([]fx.Option{Subsystem(SubsystemName(name))}, opts...)...,
+	)
+}
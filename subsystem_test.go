@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+type SubsystemTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *SubsystemTestSuite) TestSubsystemName() {
+	testCases := []struct {
+		moduleName string
+		expected   string
+	}{
+		{moduleName: "", expected: ""},
+		{moduleName: "auth", expected: "auth"},
+		{moduleName: "auth.tokens", expected: "auth_tokens"},
+		{moduleName: "http-server v2", expected: "http_server_v2"},
+		{moduleName: "2fa", expected: "_fa"},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.moduleName, func() {
+			suite.Equal(testCase.expected, SubsystemName(testCase.moduleName))
+		})
+	}
+}
+
+func (suite *SubsystemTestSuite) TestSubsystemModule() {
+	type moduleIn struct {
+		fx.In
+		Factory       *Factory
+		MetricFactory MetricFactory
+	}
+
+	var (
+		app, auth moduleIn
+		events    []RegistrationEvent
+	)
+
+	fxApp := suite.newTestApp(
+		fx.Supply(Config{DefaultNamespace: "test", DefaultSubsystem: "app"}),
+		Provide(),
+		fx.Populate(&app),
+		SubsystemModule(
+			"auth.tokens",
+			fx.Invoke(func(in moduleIn) {
+				auth = in
+			}),
+		),
+	)
+
+	fxApp.RequireStart()
+	defer fxApp.RequireStop()
+
+	app.Factory.OnRegister(func(e RegistrationEvent) {
+		events = append(events, e)
+	})
+
+	suite.Equal("app", app.Factory.DefaultSubsystem())
+	suite.Equal("auth_tokens", auth.Factory.DefaultSubsystem())
+	suite.Equal("auth_tokens", auth.MetricFactory.DefaultSubsystem())
+	suite.Equal("test", auth.Factory.DefaultNamespace())
+
+	_, err := auth.MetricFactory.NewCounter(prometheus.CounterOpts{Name: "issued", Help: "test"})
+	suite.Require().NoError(err)
+
+	_, err = auth.Factory.NewCounter(prometheus.CounterOpts{Subsystem: "explicit", Name: "issued", Help: "test"})
+	suite.Require().NoError(err)
+
+	_, err = app.Factory.NewCounter(prometheus.CounterOpts{Name: "issued", Help: "test"})
+	suite.Require().NoError(err)
+
+	// the application's listeners see the module's metrics
+	suite.Require().Len(events, 3)
+	suite.Equal("test_auth_tokens_issued", events[0].Name)
+	suite.Equal("test_explicit_issued", events[1].Name)
+	suite.Equal("test_app_issued", events[2].Name)
+}
+
+func (suite *SubsystemTestSuite) TestPanics() {
+	var auth *Factory
+	fxApp := suite.newTestApp(
+		Provide(),
+		InvokePanics(),
+		SubsystemModule(
+			"auth",
+			fx.Populate(&auth),
+		),
+	)
+
+	fxApp.RequireStart()
+	defer fxApp.RequireStop()
+	defer SetDefaultPanics(nil)
+
+	p := auth.Panics()
+	suite.Require().NotNil(p)
+	suite.Same(DefaultPanics(), p)
+
+	suite.NotPanics(p.Instrument("auth", func() { panic("expected") }))
+	suite.Equal(1.0, testutil.ToFloat64(p.count.WithLabelValues("auth")))
+}
+
+func TestSubsystem(t *testing.T) {
+	suite.Run(t, new(SubsystemTestSuite))
+}
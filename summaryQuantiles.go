@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	// ErrNoSuchSummary indicates that a Factory has not registered a summary with a given name.
+	ErrNoSuchSummary = errors.New("No summary with that name has been registered")
+
+	// ErrNoSuchQuantile indicates that a summary does not have a given quantile as an objective.
+	ErrNoSuchQuantile = errors.New("The summary has no such objective")
+)
+
+// summaryIndex tracks the summaries registered by a Factory, and any Factory derived from
+// it, by fully qualified name.
+type summaryIndex struct {
+	lock   sync.RWMutex
+	byName map[string]prometheus.Collector
+}
+
+// add records the summary from a registration event, if any.  A summary that was already
+// registered is recorded as the existing collector, unless this index already has it.
+func (si *summaryIndex) add(e RegistrationEvent) {
+	if si == nil || e.Type != dto.MetricType_SUMMARY || len(e.Name) == 0 {
+		return
+	}
+
+	c := e.Collector
+	if e.Err != nil {
+		are := AsAlreadyRegisteredError(e.Err)
+		if are == nil {
+			return
+		}
+
+		c = are.ExistingCollector
+	}
+
+	si.lock.Lock()
+	defer si.lock.Unlock()
+
+	if si.byName == nil {
+		si.byName = make(map[string]prometheus.Collector)
+	}
+
+	if _, exists := si.byName[e.Name]; !exists || e.Err == nil {
+		si.byName[e.Name] = c
+	}
+}
+
+func (si *summaryIndex) get(name string) (c prometheus.Collector, ok bool) {
+	if si != nil {
+		si.lock.RLock()
+		c, ok = si.byName[name]
+		si.lock.RUnlock()
+	}
+
+	return
+}
+
+// remove forgets the summary with the given name, if any.
+func (si *summaryIndex) remove(name string) {
+	if si != nil {
+		si.lock.Lock()
+		delete(si.byName, name)
+		si.lock.Unlock()
+	}
+}
+
+// SummaryQuantiles reads the current quantile estimates of a summary registered by this
+// Factory, or by any Factory derived from it, e.g. with Subsystem.  This allows code such
+// as admission control to use the current p99 of a latency without scraping its own metrics.
+//
+// The name is the fully qualified name of the summary, after defaults and any naming
+// policy have been applied, as in RegistrationEvent.Name.  For a summary vector, the labels
+// select the series exactly as with SummaryValue.  For a summary without variable labels,
+// the labels may be nil.
+//
+// The returned map holds the current estimate for each objective of the summary.  An
+// estimate is NaN if there have been no observations within the summary's MaxAge.
+// ErrNoSuchSummary is returned if no such summary has been registered.
+//
+// Like SummaryValue, this method collects every series of the summary, so it should not
+// be called for each request with large vectors.  Cache the result if necessary.
+func (f *Factory) SummaryQuantiles(name string, labels prometheus.Labels) (map[float64]float64, error) {
+	c, ok := f.summaries.get(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoSuchSummary, name)
+	}
+
+	sd, err := SummaryValue(c, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	return sd.Quantiles, nil
+}
+
+// SummaryQuantile reads the current estimate of a single quantile, e.g. 0.99, of a summary.
+// The summary is selected exactly as with SummaryQuantiles.  ErrNoSuchQuantile is returned
+// if the quantile is not one of the summary's objectives.
+func (f *Factory) SummaryQuantile(name string, labels prometheus.Labels, q float64) (float64, error) {
+	quantiles, err := f.SummaryQuantiles(name, labels)
+	if err != nil {
+		return 0.0, err
+	}
+
+	v, ok := quantiles[q]
+	if !ok {
+		return 0.0, fmt.Errorf("%w: %s %v", ErrNoSuchQuantile, name, q)
+	}
+
+	return v, nil
+}
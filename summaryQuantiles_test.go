@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"math"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+)
+
+type SummaryQuantilesTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *SummaryQuantilesTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r)
+}
+
+func (suite *SummaryQuantilesTestSuite) TestSummary() {
+	f := suite.newFactory()
+	s, err := f.NewSummary(prometheus.SummaryOpts{
+		Name:       "latency",
+		Help:       "latency",
+		Objectives: map[float64]float64{0.5: 0.0, 0.99: 0.0},
+	})
+
+	suite.Require().NoError(err)
+
+	quantiles, err := f.SummaryQuantiles("test_latency", nil)
+	suite.Require().NoError(err)
+	suite.Require().Len(quantiles, 2)
+	suite.True(math.IsNaN(quantiles[0.99]))
+
+	for v := 1; v <= 100; v++ {
+		s.Observe(float64(v))
+	}
+
+	quantiles, err = f.SummaryQuantiles("test_latency", nil)
+	suite.Require().NoError(err)
+	suite.Equal(map[float64]float64{0.5: 50.0, 0.99: 99.0}, quantiles)
+
+	p99, err := f.SummaryQuantile("test_latency", nil, 0.99)
+	suite.NoError(err)
+	suite.Equal(99.0, p99)
+
+	_, err = f.SummaryQuantile("test_latency", nil, 0.9)
+	suite.ErrorIs(err, ErrNoSuchQuantile)
+}
+
+func (suite *SummaryQuantilesTestSuite) TestSummaryVec() {
+	f := suite.newFactory()
+	sv, err := f.NewSummaryVec(prometheus.SummaryOpts{
+		Name:       "latency",
+		Help:       "latency",
+		Objectives: map[float64]float64{0.99: 0.0},
+	}, "route")
+
+	suite.Require().NoError(err)
+	sv.WithLabelValues("/a").Observe(1.0)
+	sv.WithLabelValues("/b").Observe(2.0)
+
+	p99, err := f.SummaryQuantile("test_latency", prometheus.Labels{"route": "/b"}, 0.99)
+	suite.NoError(err)
+	suite.Equal(2.0, p99)
+
+	_, err = f.SummaryQuantiles("test_latency", nil)
+	suite.ErrorIs(err, ErrAmbiguousSeries)
+
+	_, err = f.SummaryQuantiles("test_latency", prometheus.Labels{"route": "/c"})
+	suite.ErrorIs(err, ErrNoSuchSeries)
+}
+
+func (suite *SummaryQuantilesTestSuite) TestDerived() {
+	f := suite.newFactory()
+	s, err := f.withSubsystem("sub").NewSummary(prometheus.SummaryOpts{
+		Name:       "latency",
+		Help:       "latency",
+		Objectives: map[float64]float64{0.5: 0.0},
+	})
+
+	suite.Require().NoError(err)
+	s.Observe(3.0)
+
+	p50, err := f.SummaryQuantile("test_sub_latency", nil, 0.5)
+	suite.NoError(err)
+	suite.Equal(3.0, p50)
+}
+
+func (suite *SummaryQuantilesTestSuite) TestExisting() {
+	f := suite.newFactory()
+	o := prometheus.SummaryOpts{Name: "latency", Help: "latency", Objectives: map[float64]float64{0.5: 0.0}}
+	s, err := f.NewSummary(o)
+	suite.Require().NoError(err)
+
+	_, err = f.NewSummary(o)
+	suite.Error(err)
+
+	// the failed registration does not replace the registered summary
+	s.Observe(5.0)
+	p50, err := f.SummaryQuantile("test_latency", nil, 0.5)
+	suite.NoError(err)
+	suite.Equal(5.0, p50)
+}
+
+func (suite *SummaryQuantilesTestSuite) TestNoSuchSummary() {
+	f := suite.newFactory()
+	_, err := f.NewHistogram(prometheus.HistogramOpts{Name: "histogram", Help: "histogram"})
+	suite.Require().NoError(err)
+
+	_, err = f.SummaryQuantiles("test_histogram", nil)
+	suite.ErrorIs(err, ErrNoSuchSummary)
+
+	_, err = f.SummaryQuantile("test_missing", nil, 0.5)
+	suite.ErrorIs(err, ErrNoSuchSummary)
+}
+
+func TestSummaryQuantiles(t *testing.T) {
+	suite.Run(t, new(SummaryQuantilesTestSuite))
+}
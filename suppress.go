@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// descName extracts the fully qualified metric name from a prometheus.Desc.
+// The prometheus client does not expose this, so it is parsed from the
+// descriptor's string form.
+func descName(d *prometheus.Desc) string {
+	const prefix = "Desc{fqName: "
+	s := d.String()
+	if !strings.HasPrefix(s, prefix) {
+		return ""
+	}
+
+	quoted, err := strconv.QuotedPrefix(s[len(prefix):])
+	if err != nil {
+		return ""
+	}
+
+	name, _ := strconv.Unquote(quoted)
+	return name
+}
+
+// checkPatterns verifies that each of a set of metric name patterns is well formed.
+func checkPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid metric name pattern '%s': %w", p, err)
+		}
+	}
+
+	return nil
+}
+
+// matchesAny tests if a metric name matches any of a set of patterns.  The patterns
+// must have been validated with checkPatterns.
+func matchesAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if matched, _ := path.Match(p, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// suppressingCollector decorates a prometheus.Collector and filters out any
+// metric families whose names match a set of patterns.
+type suppressingCollector struct {
+	collector prometheus.Collector
+	patterns  []string
+}
+
+func (sc suppressingCollector) Describe(ch chan<- *prometheus.Desc) {
+	descs := make(chan *prometheus.Desc)
+	go func() {
+		sc.collector.Describe(descs)
+		close(descs)
+	}()
+
+	for d := range descs {
+		if !matchesAny(sc.patterns, descName(d)) {
+			ch <- d
+		}
+	}
+}
+
+func (sc suppressingCollector) Collect(ch chan<- prometheus.Metric) {
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		sc.collector.Collect(metrics)
+		close(metrics)
+	}()
+
+	for m := range metrics {
+		if !matchesAny(sc.patterns, descName(m.Desc())) {
+			ch <- m
+		}
+	}
+}
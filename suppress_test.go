@@ -0,0 +1,18 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescName(t *testing.T) {
+	assert.Equal(t,
+		"ns_sub_name",
+		descName(prometheus.NewDesc("ns_sub_name", "help with \"quotes\"", []string{"a"}, prometheus.Labels{"b": "c"})),
+	)
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// ErrorLabel is the conventional label for the class of an error, as produced by
+	// an ErrorClassifier.  See CountErrors.
+	ErrorLabel = "error"
+
+	// ErrorClassCanceled is the error class of operations that were canceled.
+	ErrorClassCanceled = "canceled"
+
+	// ErrorClassDeadlineExceeded is the error class of operations that timed out.
+	ErrorClassDeadlineExceeded = "deadline_exceeded"
+
+	// ErrorClassOther is the error class of any other failed operation.
+	ErrorClassOther = "error"
+)
+
+// ErrorClassifier maps errors onto a small, bounded set of label values.  An
+// ErrorClassifier must never return an error's message, as that would produce
+// unbounded cardinality.
+type ErrorClassifier func(error) string
+
+// ClassifyError is the default ErrorClassifier.  It distinguishes cancellation and
+// timeouts from all other errors.
+func ClassifyError(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassDeadlineExceeded
+
+	default:
+		return ErrorClassOther
+	}
+}
+
+type timeOptions struct {
+	unit        time.Duration
+	clock       Clock
+	errors      *prometheus.CounterVec
+	classify    ErrorClassifier
+	writeErrors *WriteErrors
+}
+
+// TimeOption is a configurable option for Time and TimeCtx.
+type TimeOption func(*timeOptions)
+
+// TimeUnit sets the unit in which durations are observed, e.g. time.Millisecond.
+// The default is time.Second, which is the prometheus convention.  A nonpositive
+// unit is ignored.
+func TimeUnit(unit time.Duration) TimeOption {
+	return func(to *timeOptions) {
+		if unit > 0 {
+			to.unit = unit
+		}
+	}
+}
+
+// TimeClock sets the Clock used to measure durations, e.g. the Factory's Clock.
+// The default is the system time.
+func TimeClock(c Clock) TimeOption {
+	return func(to *timeOptions) {
+		if c != nil {
+			to.clock = c
+		}
+	}
+}
+
+// TimeWriteErrors sets the WriteErrors that records failures to count errors, e.g.
+// because the vector given to CountErrors has the wrong labels.
+func TimeWriteErrors(we *WriteErrors) TimeOption {
+	return func(to *timeOptions) {
+		to.writeErrors = we
+	}
+}
+
+// CountErrors counts failed operations in the given counter vector.  The vector must
+// have exactly one label that is not curried, typically ErrorLabel, which receives the
+// class of the error.  If classify is nil, ClassifyError is used.
+//
+// If the vector's labels do not meet this requirement, errors cannot be counted.  Each
+// such failure is recorded with the WriteErrors set by TimeWriteErrors, if any.
+func CountErrors(cv *prometheus.CounterVec, classify ErrorClassifier) TimeOption {
+	return func(to *timeOptions) {
+		to.errors = cv
+		to.classify = classify
+	}
+}
+
+func newTimeOptions(opts []TimeOption) timeOptions {
+	to := timeOptions{
+		unit:     time.Second,
+		clock:    SystemClock{},
+		classify: ClassifyError,
+	}
+
+	for _, o := range opts {
+		o(&to)
+	}
+
+	if to.classify == nil {
+		to.classify = ClassifyError
+	}
+
+	return to
+}
+
+// record observes the duration of an operation that began at start, and counts
+// any error with the given class.
+func (to timeOptions) record(obs prometheus.Observer, start time.Time, err error, class string) {
+	obs.Observe(float64(to.clock.Now().Sub(start)) / float64(to.unit))
+	if err != nil && to.errors != nil {
+		to.writeErrors.CounterWithLabelValues(to.errors, class).Inc()
+	}
+}
+
+// Time invokes fn and observes how long it took, whether or not it failed.  The
+// error returned by fn is returned as is.
+//
+//	err := touchstone.Time(
+//	  queryDuration,
+//	  func() error { return db.Ping() },
+//	  touchstone.CountErrors(queryErrors, nil),
+//	)
+func Time(obs prometheus.Observer, fn func() error, opts ...TimeOption) error {
+	to := newTimeOptions(opts)
+	start := to.clock.Now()
+	err := fn()
+
+	var class string
+	if err != nil {
+		class = to.classify(err)
+	}
+
+	to.record(obs, start, err, class)
+	return err
+}
+
+// TimeCtx is like Time, but for an operation that takes a context.  If the operation
+// fails after the context has been canceled or has timed out, the error is counted
+// with the class of the context's error.  Operations frequently wrap or replace
+// context errors, so this keeps cancellations from being counted as other failures.
+func TimeCtx(ctx context.Context, obs prometheus.Observer, fn func(context.Context) error, opts ...TimeOption) error {
+	to := newTimeOptions(opts)
+	start := to.clock.Now()
+	err := fn(ctx)
+
+	var class string
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			class = to.classify(ctxErr)
+		} else {
+			class = to.classify(err)
+		}
+	}
+
+	to.record(obs, start, err, class)
+	return err
+}
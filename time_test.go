@@ -0,0 +1,245 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type TimeTestSuite struct {
+	suite.Suite
+
+	now time.Time
+}
+
+func (suite *TimeTestSuite) SetupTest() {
+	suite.now = time.Now()
+}
+
+// clock returns a Clock that advances by step each time it is queried.
+func (suite *TimeTestSuite) clock(step time.Duration) Clock {
+	current := suite.now
+	return ClockFunc(func() time.Time {
+		t := current
+		current = current.Add(step)
+		return t
+	})
+}
+
+func (suite *TimeTestSuite) newObserver() prometheus.Histogram {
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "test_duration",
+		Help:    "test_duration",
+		Buckets: []float64{1.0, 10.0, 1000.0},
+	})
+}
+
+func (suite *TimeTestSuite) newErrors() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "test_errors_total",
+			Help: "test_errors_total",
+		},
+		[]string{ErrorLabel},
+	)
+}
+
+func (suite *TimeTestSuite) histogramSum(h prometheus.Histogram) (count uint64, sum float64) {
+	m := new(dto.Metric)
+	suite.Require().NoError(h.Write(m))
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
+
+func (suite *TimeTestSuite) TestClassifyError() {
+	testCases := []struct {
+		err      error
+		expected string
+	}{
+		{err: context.Canceled, expected: ErrorClassCanceled},
+		{err: fmt.Errorf("wrapped: %w", context.Canceled), expected: ErrorClassCanceled},
+		{err: context.DeadlineExceeded, expected: ErrorClassDeadlineExceeded},
+		{err: errors.New("expected"), expected: ErrorClassOther},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.err.Error(), func() {
+			suite.Equal(testCase.expected, ClassifyError(testCase.err))
+		})
+	}
+}
+
+func (suite *TimeTestSuite) TestTime() {
+	suite.Run("Success", func() {
+		var (
+			obs    = suite.newObserver()
+			errs   = suite.newErrors()
+			called bool
+		)
+
+		err := Time(
+			obs,
+			func() error { called = true; return nil },
+			TimeClock(suite.clock(2*time.Second)),
+			CountErrors(errs, nil),
+		)
+
+		suite.NoError(err)
+		suite.True(called)
+
+		count, sum := suite.histogramSum(obs)
+		suite.Equal(uint64(1), count)
+		suite.Equal(2.0, sum)
+		suite.Zero(testutil.CollectAndCount(errs))
+	})
+
+	suite.Run("Error", func() {
+		var (
+			obs      = suite.newObserver()
+			errs     = suite.newErrors()
+			expected = errors.New("expected")
+		)
+
+		err := Time(
+			obs,
+			func() error { return expected },
+			TimeClock(suite.clock(3*time.Millisecond)),
+			TimeUnit(time.Millisecond),
+			CountErrors(errs, nil),
+		)
+
+		suite.Same(expected, err)
+
+		count, sum := suite.histogramSum(obs)
+		suite.Equal(uint64(1), count)
+		suite.Equal(3.0, sum)
+		suite.Equal(1.0, testutil.ToFloat64(errs.WithLabelValues(ErrorClassOther)))
+	})
+
+	suite.Run("CustomClassifier", func() {
+		var (
+			obs  = suite.newObserver()
+			errs = suite.newErrors()
+		)
+
+		err := Time(
+			obs,
+			func() error { return errors.New("expected") },
+			CountErrors(errs, func(error) string { return "custom" }),
+		)
+
+		suite.Error(err)
+		suite.Equal(1.0, testutil.ToFloat64(errs.WithLabelValues("custom")))
+	})
+
+	suite.Run("BadLabels", func() {
+		var (
+			obs  = suite.newObserver()
+			errs = prometheus.NewCounterVec(
+				prometheus.CounterOpts{
+					Name: "test_errors_total",
+					Help: "test_errors_total",
+				},
+				[]string{"code", ErrorLabel},
+			)
+		)
+
+		var recorded []string
+		we, wErr := NewWriteErrors(
+			NewFactory(Config{}, zap.NewNop(), prometheus.NewPedanticRegistry()),
+			func(metric string, _ error) { recorded = append(recorded, metric) },
+		)
+
+		suite.Require().NoError(wErr)
+
+		err := Time(
+			obs,
+			func() error { return errors.New("expected") },
+			CountErrors(errs, nil),
+			TimeWriteErrors(we),
+		)
+
+		suite.Error(err)
+		suite.Zero(testutil.CollectAndCount(errs))
+		suite.Equal([]string{"test_errors_total"}, recorded)
+
+		count, _ := suite.histogramSum(obs)
+		suite.Equal(uint64(1), count)
+	})
+}
+
+func (suite *TimeTestSuite) TestTimeCtx() {
+	suite.Run("Success", func() {
+		var (
+			obs = suite.newObserver()
+			ctx = context.WithValue(context.Background(), "key", "value") //nolint:staticcheck
+		)
+
+		err := TimeCtx(
+			ctx,
+			obs,
+			func(actual context.Context) error {
+				suite.Equal(ctx, actual)
+				return nil
+			},
+			TimeClock(suite.clock(time.Second)),
+		)
+
+		suite.NoError(err)
+		count, sum := suite.histogramSum(obs)
+		suite.Equal(uint64(1), count)
+		suite.Equal(1.0, sum)
+	})
+
+	suite.Run("Canceled", func() {
+		var (
+			obs         = suite.newObserver()
+			errs        = suite.newErrors()
+			ctx, cancel = context.WithCancel(context.Background())
+		)
+
+		err := TimeCtx(
+			ctx,
+			obs,
+			func(context.Context) error {
+				cancel()
+				return errors.New("the operation replaced the context error")
+			},
+			CountErrors(errs, nil),
+		)
+
+		suite.Error(err)
+		suite.Equal(1.0, testutil.ToFloat64(errs.WithLabelValues(ErrorClassCanceled)))
+	})
+
+	suite.Run("Error", func() {
+		var (
+			obs  = suite.newObserver()
+			errs = suite.newErrors()
+		)
+
+		err := TimeCtx(
+			context.Background(),
+			obs,
+			func(context.Context) error { return errors.New("expected") },
+			CountErrors(errs, nil),
+		)
+
+		suite.Error(err)
+		suite.Equal(1.0, testutil.ToFloat64(errs.WithLabelValues(ErrorClassOther)))
+	})
+}
+
+func TestTime(t *testing.T) {
+	suite.Run(t, new(TimeTestSuite))
+}
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// ErrInvalidTimeout indicates that a collector timeout was not positive.
+var ErrInvalidTimeout = errors.New("A collector timeout must be positive")
+
+// ContextCollector is an optional interface for prometheus collectors that can
+// honor a context while collecting.  Collectors backed by IO, such as database
+// statistics, should implement this interface so that they can abandon work
+// once a scrape has timed out.
+type ContextCollector interface {
+	prometheus.Collector
+
+	// CollectContext is the context-aware analog of Collect.  Implementations
+	// should return promptly once the context is canceled.
+	CollectContext(context.Context, chan<- prometheus.Metric)
+}
+
+// collection is a single run of a decorated collector, which may outlive the
+// scrape that started it.
+type collection struct {
+	done     chan struct{}
+	complete bool
+	metrics  []prometheus.Metric
+}
+
+// timeoutCollector decorates a prometheus.Collector so that a slow collection
+// cannot stall an entire scrape.
+type timeoutCollector struct {
+	collector prometheus.Collector
+	timeout   time.Duration
+	errors    prometheus.Counter
+	now       func() time.Time
+
+	lock     sync.Mutex
+	pending  *collection
+	last     []prometheus.Metric
+	lastTime time.Time
+}
+
+// NewTimeoutCollector decorates a collector so that each collection is bounded by
+// the given timeout.  If the collector implements ContextCollector, its CollectContext
+// method receives a context that is canceled when the timeout elapses.  Otherwise,
+// its Collect method is invoked as normal.
+//
+// When a collection times out, the metrics from the last successful collection
+// are emitted instead, each with an explicit timestamp of when it was collected.
+// This marks the data as stale to prometheus without leaving a gap in the series.
+// If errorCount is not nil, it is incremented for each timeout.
+//
+// A collection that times out continues on its own goroutine until the decorated
+// collector returns.  Only one collection runs at a time:  scrapes made while a
+// collection is still running wait on that collection rather than starting another,
+// so a hung collector never accumulates goroutines.  Collectors that can block
+// indefinitely should still implement ContextCollector.
+func NewTimeoutCollector(c prometheus.Collector, timeout time.Duration, errorCount prometheus.Counter) (prometheus.Collector, error) {
+	if timeout <= 0 {
+		return nil, ErrInvalidTimeout
+	}
+
+	return &timeoutCollector{
+		collector: c,
+		timeout:   timeout,
+		errors:    errorCount,
+		now:       time.Now,
+	}, nil
+}
+
+func (tc *timeoutCollector) Describe(ch chan<- *prometheus.Desc) {
+	tc.collector.Describe(ch)
+}
+
+// collect runs the decorated collector to completion, buffering its metrics.
+func (tc *timeoutCollector) collect(ctx context.Context) []prometheus.Metric {
+	var (
+		metrics   = make(chan prometheus.Metric)
+		done      = make(chan struct{})
+		collected []prometheus.Metric
+	)
+
+	go func() {
+		defer close(done)
+		for m := range metrics {
+			collected = append(collected, m)
+		}
+	}()
+
+	if cc, ok := tc.collector.(ContextCollector); ok {
+		cc.CollectContext(ctx, metrics)
+	} else {
+		tc.collector.Collect(metrics)
+	}
+
+	close(metrics)
+	<-done
+	return collected
+}
+
+// start returns the collection in progress, starting one if necessary.
+func (tc *timeoutCollector) start() *collection {
+	tc.lock.Lock()
+	defer tc.lock.Unlock()
+	if tc.pending != nil {
+		return tc.pending
+	}
+
+	c := &collection{done: make(chan struct{})}
+	tc.pending = c
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+		defer cancel()
+		c.metrics = tc.collect(ctx)
+		c.complete = ctx.Err() == nil
+
+		tc.lock.Lock()
+		tc.pending = nil
+		if c.complete {
+			tc.last = c.metrics
+			tc.lastTime = tc.now()
+		}
+
+		tc.lock.Unlock()
+		close(c.done)
+	}()
+
+	return c
+}
+
+func (tc *timeoutCollector) Collect(ch chan<- prometheus.Metric) {
+	timer := time.NewTimer(tc.timeout)
+	defer timer.Stop()
+
+	c := tc.start()
+	select {
+	case <-c.done:
+		if c.complete {
+			for _, m := range c.metrics {
+				ch <- m
+			}
+
+			return
+		}
+
+	case <-timer.C:
+	}
+
+	// either this scrape gave up waiting or the collection ran past its deadline
+	if tc.errors != nil {
+		tc.errors.Inc()
+	}
+
+	tc.lock.Lock()
+	last, lastTime := tc.last, tc.lastTime
+	tc.lock.Unlock()
+	for _, m := range last {
+		ch <- prometheus.NewMetricWithTimestamp(lastTime, m)
+	}
+}
+
+// RegisterWithTimeout decorates a collector with NewTimeoutCollector and registers
+// it.  The supplied options describe the counter of collection timeouts, which is
+// created and registered through this Factory and returned.
+func (f *Factory) RegisterWithTimeout(c prometheus.Collector, timeout time.Duration, o prometheus.CounterOpts) (prometheus.Counter, error) {
+	errorCount, err := f.NewCounter(o)
+	if err != nil {
+		return nil, err
+	}
+
+	tc, err := NewTimeoutCollector(c, timeout, errorCount)
+	if err == nil {
+		err = f.register(tc, RegistrationEvent{Type: dto.MetricType_UNTYPED})
+	}
+
+	if err != nil {
+		f.unregisterCollector(errorCount)
+		return nil, err
+	}
+
+	return errorCount, nil
+}
@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+// slowCollector is a ContextCollector that blocks until released or canceled.
+type slowCollector struct {
+	gauge    prometheus.Gauge
+	block    bool
+	canceled chan struct{}
+}
+
+func (sc *slowCollector) Describe(ch chan<- *prometheus.Desc) {
+	sc.gauge.Describe(ch)
+}
+
+func (sc *slowCollector) Collect(ch chan<- prometheus.Metric) {
+	sc.CollectContext(context.Background(), ch)
+}
+
+func (sc *slowCollector) CollectContext(ctx context.Context, ch chan<- prometheus.Metric) {
+	if sc.block {
+		<-ctx.Done()
+		close(sc.canceled)
+		return
+	}
+
+	ch <- sc.gauge
+}
+
+// hungCollector is a plain collector whose first collection blocks until released.
+type hungCollector struct {
+	gauge   prometheus.Gauge
+	calls   *int32
+	release chan struct{}
+}
+
+func (hc *hungCollector) Describe(ch chan<- *prometheus.Desc) {
+	hc.gauge.Describe(ch)
+}
+
+func (hc *hungCollector) Collect(ch chan<- prometheus.Metric) {
+	if atomic.AddInt32(hc.calls, 1) == 1 {
+		<-hc.release
+	}
+
+	ch <- hc.gauge
+}
+
+type TimeoutTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *TimeoutTestSuite) newFactory() (*Factory, prometheus.Gatherer) {
+	cfg := Config{
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := New(cfg)
+	suite.Require().NoError(err)
+	return NewFactory(cfg, suite.logger, r), g
+}
+
+func (suite *TimeoutTestSuite) TestNewTimeoutCollector() {
+	suite.Run("InvalidTimeout", func() {
+		_, err := NewTimeoutCollector(prometheus.NewGauge(prometheus.GaugeOpts{Name: "test"}), 0, nil)
+		suite.ErrorIs(err, ErrInvalidTimeout)
+	})
+
+	suite.Run("PlainCollector", func() {
+		g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test", Help: "test"})
+		g.Set(12.0)
+		tc, err := NewTimeoutCollector(g, time.Second, nil)
+		suite.Require().NoError(err)
+		suite.Equal(1, testutil.CollectAndCount(tc))
+	})
+}
+
+func (suite *TimeoutTestSuite) TestRegisterWithTimeout() {
+	f, r := suite.newFactory()
+	sc := &slowCollector{
+		gauge:    prometheus.NewGauge(prometheus.GaugeOpts{Name: "slow", Help: "slow"}),
+		canceled: make(chan struct{}),
+	}
+
+	sc.gauge.Set(5.0)
+	errorCount, err := f.RegisterWithTimeout(
+		sc,
+		50*time.Millisecond,
+		prometheus.CounterOpts{Name: "slow_collector_errors", Help: "slow collector timeouts"},
+	)
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(errorCount)
+
+	// a successful collection is emitted as is
+	mfs, err := r.Gather()
+	suite.Require().NoError(err)
+	suite.Require().Len(mfs, 2)
+	suite.Equal("slow", mfs[0].GetName())
+	suite.Equal(5.0, mfs[0].GetMetric()[0].GetGauge().GetValue())
+	suite.Nil(mfs[0].GetMetric()[0].TimestampMs)
+
+	// a timed out collection emits the last metrics with a timestamp
+	sc.block = true
+	mfs, err = r.Gather()
+	suite.Require().NoError(err)
+	suite.Require().Len(mfs, 2)
+	suite.Equal("slow", mfs[0].GetName())
+	suite.NotNil(mfs[0].GetMetric()[0].TimestampMs)
+	suite.Equal(1.0, testutil.ToFloat64(errorCount))
+
+	select {
+	case <-sc.canceled:
+	case <-time.After(5 * time.Second):
+		suite.Fail("The collector's context was not canceled")
+	}
+
+	suite.Run("NoName", func() {
+		f, _ := suite.newFactory()
+		_, err := f.RegisterWithTimeout(sc, time.Second, prometheus.CounterOpts{})
+		suite.ErrorIs(err, ErrNoMetricName)
+	})
+
+	suite.Run("InvalidTimeout", func() {
+		f, r := suite.newFactory()
+		_, err := f.RegisterWithTimeout(sc, 0, prometheus.CounterOpts{Name: "errors", Help: "errors"})
+		suite.ErrorIs(err, ErrInvalidTimeout)
+
+		// the error counter should not have been left behind
+		mfs, err := r.Gather()
+		suite.Require().NoError(err)
+		suite.Empty(mfs)
+		suite.Empty(f.registered.entries)
+	})
+}
+
+func (suite *TimeoutTestSuite) TestOneInFlight() {
+	var (
+		calls   int32
+		release = make(chan struct{})
+		gauge   = prometheus.NewGauge(prometheus.GaugeOpts{Name: "hung", Help: "hung"})
+	)
+
+	tc, err := NewTimeoutCollector(&hungCollector{gauge: gauge, calls: &calls, release: release}, 10*time.Millisecond, nil)
+	suite.Require().NoError(err)
+
+	// scrapes made while the collector is hung do not start more collections
+	for i := 0; i < 3; i++ {
+		suite.Zero(testutil.CollectAndCount(tc))
+	}
+
+	suite.Equal(int32(1), atomic.LoadInt32(&calls))
+
+	close(release)
+	suite.Eventually(
+		func() bool { return testutil.CollectAndCount(tc) == 1 },
+		5*time.Second,
+		10*time.Millisecond,
+	)
+}
+
+func TestTimeout(t *testing.T) {
+	suite.Run(t, new(TimeoutTestSuite))
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbreaker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/multierr"
+)
+
+const (
+	// DefaultState is the default name of the gauge that tracks the current
+	// state of each circuit breaker.
+	DefaultState = "circuit_breaker_state"
+
+	// DefaultTrips is the default name of the counter that tracks the number
+	// of times a circuit breaker has transitioned to the open state.
+	DefaultTrips = "circuit_breaker_trips"
+
+	// DefaultShortCircuits is the default name of the counter that tracks the
+	// number of requests rejected by an open or half-open circuit breaker.
+	DefaultShortCircuits = "circuit_breaker_short_circuits"
+
+	// BreakerLabel is the metric label containing the name of the circuit breaker.
+	BreakerLabel = "breaker"
+
+	// StateLabel is the metric label containing the circuit breaker state.
+	StateLabel = "state"
+)
+
+var (
+	// ErrReservedLabelName indicates that labels supplied to build Metrics
+	// had one or more reserved label names.
+	ErrReservedLabelName = fmt.Errorf(
+		"%s and %s are reserved label names and are supplied automatically",
+		BreakerLabel,
+		StateLabel,
+	)
+
+	// ErrInvalidLabelCount indicates that an odd number of name/value pairs were
+	// passed when creating metrics.
+	ErrInvalidLabelCount = errors.New("The number of label names and values must be even")
+
+	defaultState = prometheus.GaugeOpts{
+		Name: DefaultState,
+		Help: "the current state of the circuit breaker (1 for the active state, 0 otherwise)",
+	}
+
+	defaultTrips = prometheus.CounterOpts{
+		Name: DefaultTrips,
+		Help: "the total number of times the circuit breaker has opened since startup",
+	}
+
+	defaultShortCircuits = prometheus.CounterOpts{
+		Name: DefaultShortCircuits,
+		Help: "the total number of requests rejected by the circuit breaker since startup",
+	}
+)
+
+// labelNames takes a sequence of name/value pairs and converts that into
+// a slice of names and a prometheus.Labels which should be used to curry
+// the associated metric.
+func labelNames(lvs []string) (names []string, curry prometheus.Labels, err error) {
+	if len(lvs)%2 != 0 {
+		return nil, nil, ErrInvalidLabelCount
+	}
+
+	names = make([]string, 0, len(lvs)/2)
+	curry = make(prometheus.Labels, len(lvs)/2)
+	for i := 0; i < len(lvs); i += 2 {
+		if lvs[i] == BreakerLabel || lvs[i] == StateLabel {
+			return nil, nil, ErrReservedLabelName
+		}
+
+		names = append(names, lvs[i])
+		curry[lvs[i]] = lvs[i+1]
+	}
+
+	return
+}
+
+// Bundle describes the circuit breaker metrics.  The zero value of this
+// type uses the default metric names.
+type Bundle struct {
+	// State describes the options used for the state gauge.
+	State prometheus.GaugeOpts
+
+	// Trips describes the options used for the counter of transitions to open.
+	Trips prometheus.CounterOpts
+
+	// ShortCircuits describes the options used for the counter of rejected requests.
+	ShortCircuits prometheus.CounterOpts
+}
+
+func (b Bundle) newState(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (*prometheus.GaugeVec, error) {
+	touchstone.ApplyDefaults(&b.State, defaultState)
+	gv, err := f.NewGaugeVec(b.State, labelNames...)
+	err = touchstone.ExistingCollector(&gv, err)
+	if err == nil {
+		gv, err = gv.CurryWith(curry)
+	}
+
+	return gv, err
+}
+
+func (b Bundle) newCounterVec(f touchstone.MetricFactory, o, defaults prometheus.CounterOpts, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
+	touchstone.ApplyDefaults(&o, defaults)
+	cv, err := f.NewCounterVec(o, labelNames...)
+	err = touchstone.ExistingCollector(&cv, err)
+	if err == nil {
+		cv, err = cv.CurryWith(curry)
+	}
+
+	return cv, err
+}
+
+// NewMetrics creates a constructor that can be passed to fx.Provide or annotated
+// as needed.
+//
+// The namesAndValues are any extra, curried labels to apply to all the created
+// metrics.  The BreakerLabel and StateLabel are reserved.
+//
+// Typical usage:
+//
+//	app := fx.New(
+//	  touchstone.Provide(),
+//	  fx.Provide(
+//	    touchbreaker.Bundle{}.NewMetrics(),
+//	  ),
+//	)
+func (b Bundle) NewMetrics(namesAndValues ...string) func(touchstone.MetricFactory) (Metrics, error) {
+	return func(f touchstone.MetricFactory) (m Metrics, err error) {
+		var (
+			extraNames []string
+			curry      prometheus.Labels
+		)
+
+		extraNames, curry, err = labelNames(namesAndValues)
+		if err != nil {
+			return
+		}
+
+		breakerNames := append(append([]string{}, extraNames...), BreakerLabel)
+		stateNames := append(append([]string{}, breakerNames...), StateLabel)
+
+		var metricErr error
+		m.state, metricErr = b.newState(f, stateNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		m.trips, metricErr = b.newCounterVec(f, b.Trips, defaultTrips, breakerNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		m.shortCircuits, metricErr = b.newCounterVec(f, b.ShortCircuits, defaultShortCircuits, breakerNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		return
+	}
+}
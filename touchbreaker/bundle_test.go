@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbreaker
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type BundleSuite struct {
+	suite.Suite
+}
+
+func (suite *BundleSuite) newMetrics(b Bundle, namesAndValues ...string) Metrics {
+	var (
+		m Metrics
+
+		app = fxtest.New(
+			suite.T(),
+			touchstone.Provide(),
+			fx.Provide(
+				b.NewMetrics(namesAndValues...),
+			),
+			fx.Populate(&m),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	return m
+}
+
+func (suite *BundleSuite) state(m Metrics, name string, s State) float64 {
+	return testutil.ToFloat64(
+		m.state.With(prometheus.Labels{BreakerLabel: name, StateLabel: s.String()}),
+	)
+}
+
+func (suite *BundleSuite) count(cv *prometheus.CounterVec, name string) float64 {
+	return testutil.ToFloat64(
+		cv.With(prometheus.Labels{BreakerLabel: name}),
+	)
+}
+
+func (suite *BundleSuite) TestStateString() {
+	suite.Equal("closed", StateClosed.String())
+	suite.Equal("half-open", StateHalfOpen.String())
+	suite.Equal("open", StateOpen.String())
+	suite.Equal("unknown(17)", State(17).String())
+}
+
+func (suite *BundleSuite) TestInvalidLabels() {
+	f := func(namesAndValues ...string) error {
+		_, r, err := touchstone.New(touchstone.Config{})
+		suite.Require().NoError(err)
+		_, err = Bundle{}.NewMetrics(namesAndValues...)(
+			touchstone.NewFactory(touchstone.Config{}, nil, r),
+		)
+
+		return err
+	}
+
+	suite.ErrorIs(f("odd"), ErrInvalidLabelCount)
+	suite.ErrorIs(f(BreakerLabel, "value"), ErrReservedLabelName)
+	suite.ErrorIs(f(StateLabel, "value"), ErrReservedLabelName)
+}
+
+func (suite *BundleSuite) TestStateChanges() {
+	m := suite.newMetrics(Bundle{}, "client", "main")
+
+	m.Initialize("backend", StateClosed)
+	suite.Equal(1.0, suite.state(m, "backend", StateClosed))
+	suite.Zero(suite.state(m, "backend", StateOpen))
+	suite.Zero(suite.count(m.trips, "backend"))
+
+	m.OnStateChange("backend", StateClosed, StateOpen)
+	suite.Zero(suite.state(m, "backend", StateClosed))
+	suite.Equal(1.0, suite.state(m, "backend", StateOpen))
+	suite.Equal(1.0, suite.count(m.trips, "backend"))
+
+	m.OnStateChange("backend", StateOpen, StateHalfOpen)
+	suite.Equal(1.0, suite.state(m, "backend", StateHalfOpen))
+	suite.Zero(suite.state(m, "backend", StateOpen))
+	suite.Equal(1.0, suite.count(m.trips, "backend"))
+
+	m.OnStateChange("backend", StateHalfOpen, StateOpen)
+	suite.Equal(2.0, suite.count(m.trips, "backend"))
+}
+
+func (suite *BundleSuite) TestShortCircuit() {
+	m := suite.newMetrics(Bundle{
+		ShortCircuits: prometheus.CounterOpts{
+			Name: "custom_short_circuits",
+		},
+	})
+
+	m.ShortCircuit("backend")
+	m.ShortCircuit("backend")
+	suite.Equal(2.0, suite.count(m.shortCircuits, "backend"))
+}
+
+func TestBundle(t *testing.T) {
+	suite.Run(t, new(BundleSuite))
+}
@@ -0,0 +1,20 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package touchbreaker exposes circuit breaker state as prometheus metrics.
+
+A Bundle describes the metrics, and the Metrics it produces are driven by callbacks
+that fit the shape of sony/gobreaker-style breakers.  This package does not depend
+on any particular circuit breaker implementation.  Rather, client code adapts
+the breaker's callbacks:
+
+	var m touchbreaker.Metrics // injected or created via a Bundle
+	cb := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+	  Name: "backend",
+	  OnStateChange: func(name string, from, to gobreaker.State) {
+	    m.OnStateChange(name, touchbreaker.State(from), touchbreaker.State(to))
+	  },
+	})
+*/
+package touchbreaker
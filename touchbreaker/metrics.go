@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbreaker
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// State is the state of a circuit breaker.  The values of this type are
+// ordered the same as sony/gobreaker's State, which allows a simple conversion.
+type State int
+
+const (
+	// StateClosed indicates that requests are allowed through the breaker.
+	StateClosed State = iota
+
+	// StateHalfOpen indicates that a limited number of requests are allowed
+	// through the breaker in order to test the backend.
+	StateHalfOpen
+
+	// StateOpen indicates that requests are rejected by the breaker.
+	StateOpen
+)
+
+// states is the complete set of States, in order.
+var states = [...]State{StateClosed, StateHalfOpen, StateOpen}
+
+// String returns the label value for this State.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+
+	case StateHalfOpen:
+		return "half-open"
+
+	case StateOpen:
+		return "open"
+
+	default:
+		return "unknown(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// Metrics is the set of circuit breaker metrics created from a Bundle.  The methods
+// of this type are intended to be invoked from the callbacks exposed by circuit breakers.
+type Metrics struct {
+	state         *prometheus.GaugeVec
+	trips         *prometheus.CounterVec
+	shortCircuits *prometheus.CounterVec
+}
+
+// Initialize records the given State for a breaker without counting a transition.
+// Use this method at startup so that a breaker's state is visible before it
+// changes for the first time.
+func (m Metrics) Initialize(name string, s State) {
+	for _, candidate := range states {
+		v := 0.0
+		if candidate == s {
+			v = 1.0
+		}
+
+		m.state.With(prometheus.Labels{
+			BreakerLabel: name,
+			StateLabel:   candidate.String(),
+		}).Set(v)
+	}
+}
+
+// OnStateChange records a transition of a breaker's state.  Any transition to
+// StateOpen counts as a trip.  This method has the same signature, aside from
+// the State type, as sony/gobreaker's Settings.OnStateChange.
+func (m Metrics) OnStateChange(name string, from, to State) {
+	m.Initialize(name, to)
+	if to == StateOpen && from != StateOpen {
+		m.trips.With(prometheus.Labels{BreakerLabel: name}).Inc()
+	}
+}
+
+// ShortCircuit records a request that was rejected by a breaker without being
+// attempted, e.g. when gobreaker returns ErrOpenState or ErrTooManyRequests.
+func (m Metrics) ShortCircuit(name string) {
+	m.shortCircuits.With(prometheus.Labels{BreakerLabel: name}).Inc()
+}
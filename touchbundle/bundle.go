@@ -6,7 +6,9 @@ package touchbundle
 import (
 	"fmt"
 	"reflect"
+	"sort"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/xmidt-org/touchstone"
 	"go.uber.org/fx"
 	"go.uber.org/multierr"
@@ -15,59 +17,306 @@ import (
 // Bundle represents a group of metrics.  A bundle must always be a non-nil pointer to struct.
 type Bundle interface{}
 
+// FieldResult is the outcome of populating a single metric field of a bundle.
+type FieldResult struct {
+	// Index is the index of the field within the bundle struct.
+	Index int
+
+	// Field is the struct field.
+	Field reflect.StructField
+
+	// Err is the error that prevented this field from being populated, if any.  Multiple
+	// errors for the same field are aggregated in the order they were found.
+	Err error
+}
+
+// PopulateReport describes the outcome of populating each metric field of a bundle.
+// Tooling can use a report to render which fields succeeded or failed, and because
+// fields are always ordered by index, a report and its aggregate error are stable
+// enough to be compared in snapshot tests.
+type PopulateReport struct {
+	// Fields holds the result for each metric field, ordered by field index.  Fields
+	// that are skipped, e.g. unexported fields or fields tagged with "-", and fields whose
+	// types are not metrics are not included.
+	Fields []FieldResult
+}
+
+// Err aggregates the errors of all fields, ordered by field index.  This method
+// returns nil if every field was populated.
+func (pr PopulateReport) Err() (err error) {
+	for _, fr := range pr.Fields {
+		err = multierr.Append(err, fr.Err)
+	}
+
+	return
+}
+
+// Populated returns the results of the fields that were populated.
+func (pr PopulateReport) Populated() (results []FieldResult) {
+	for _, fr := range pr.Fields {
+		if fr.Err == nil {
+			results = append(results, fr)
+		}
+	}
+
+	return
+}
+
+// Failed returns the results of the fields that could not be populated.
+func (pr PopulateReport) Failed() (results []FieldResult) {
+	for _, fr := range pr.Fields {
+		if fr.Err != nil {
+			results = append(results, fr)
+		}
+	}
+
+	return
+}
+
+// populateField creates the metric for a single field and, if successful, sets it.
+// The returned flag indicates whether the field is a metric field at all.
+func populateField(factory touchstone.MetricFactory, f metricField, field reflect.Value) (bool, error) {
+	instrumenter, ok, err := f.newInstrumenter(factory)
+	if ok {
+		if err == nil {
+			field.Set(reflect.ValueOf(instrumenter))
+		}
+
+		return true, err
+	}
+
+	opts, labelNames, err := f.newOpts()
+	if opts == nil || err != nil {
+		return opts != nil || err != nil, err
+	}
+
+	var metric interface{}
+	switch {
+	case f.Type == stateSetType:
+		states, _ := f.states(nil)
+		metric, err = factory.NewStateSet(opts.(prometheus.GaugeOpts), labelNames[0], states...)
+
+	case f.Type == outcomeType:
+		metric, err = newOutcome(factory, f, opts.(prometheus.CounterOpts))
+
+	case len(labelNames) > 0:
+		metric, err = factory.NewVec(opts, labelNames...)
+
+	default:
+		metric, err = factory.New(opts)
+	}
+
+	if labelSets, _ := f.labelSets(labelNames, nil); err == nil && len(labelSets) > 0 {
+		err = f.appendError(nil, touchstone.Preinitialize(metric, labelSets...))
+	}
+
+	if err == nil {
+		field.Set(reflect.ValueOf(f.adapt(metric)))
+	}
+
+	return true, err
+}
+
+// newOutcome creates the outcome for a field along with its optional last error gauge.
+func newOutcome(factory touchstone.MetricFactory, f metricField, o prometheus.CounterOpts) (*touchstone.Outcome, error) {
+	oc, err := factory.NewOutcome(o, f.reasons()...)
+	if enabled, _ := f.lastError(nil); err == nil && enabled {
+		var gv *prometheus.GaugeVec
+		gv, err = factory.NewGaugeVec(
+			touchstone.LastErrorOpts(o),
+			touchstone.ReasonLabel, touchstone.MessageHashLabel,
+		)
+
+		if err == nil {
+			oc.TrackLastError(touchstone.NewLastError(gv))
+		}
+	}
+
+	return oc, err
+}
+
+// Factories are the named factories that bundle fields select with TagFactory.
+type Factories map[string]touchstone.MetricFactory
+
+// factoryFor returns the factory that creates the metric for a field.  A nil Factories
+// ignores TagFactory, so that every metric is created with the bundle's factory.
+func (fs Factories) factoryFor(factory touchstone.MetricFactory, f metricField) (touchstone.MetricFactory, error) {
+	name, ok := f.factoryName()
+	if !ok || fs == nil {
+		return factory, nil
+	}
+
+	if named := fs[name]; named != nil {
+		return named, nil
+	}
+
+	return nil, f.fieldErrorf("no factory named '%s'", name)
+}
+
+// factoryNames returns the sorted, distinct names of the factories selected by the
+// fields of a bundle struct.
+func factoryNames(structType reflect.Type) (names []string) {
+	seen := make(map[string]bool)
+	for i := 0; i < structType.NumField(); i++ {
+		f := metricField(structType.Field(i))
+		if name, ok := f.factoryName(); ok && !f.skip() && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return
+}
+
 // populate is the common function for filling out a bundle struct.  The supplied reflect.Value
-// must be an addressable, settable struct.
-func populate(factory *touchstone.Factory, bundle reflect.Value) (err error) {
+// must be an addressable, settable struct.  Fields are processed strictly in index order.
+// Fields without help tags use any help supplied by a HelpProvider.  Fields with TagFactory
+// use the named factory, unless named is nil.
+func populate(factory touchstone.MetricFactory, named Factories, bundle reflect.Value) (report PopulateReport) {
+	help := bundleHelp(bundle.Type())
 	for i := 0; i < bundle.NumField(); i++ {
 		f := metricField(bundle.Type().Field(i))
 		if f.skip() {
 			continue
 		}
 
-		opts, labelNames, fieldErr := f.newOpts()
-		err = multierr.Append(err, fieldErr)
-		if opts == nil || fieldErr != nil {
-			continue
-		}
-
-		var metric interface{}
-		if len(labelNames) > 0 {
-			metric, fieldErr = factory.NewVec(opts, labelNames...)
-		} else {
-			metric, fieldErr = factory.New(opts)
+		f = f.withHelp(help)
+		fieldFactory, err := named.factoryFor(factory, f)
+		if err == nil {
+			var ok bool
+			if ok, err = populateField(fieldFactory, f, bundle.Field(i)); !ok {
+				continue
+			}
 		}
 
-		err = multierr.Append(err, fieldErr)
-		if fieldErr == nil {
-			bundle.Field(i).Set(reflect.ValueOf(metric))
-		}
+		report.Fields = append(report.Fields, FieldResult{
+			Index: i,
+			Field: bundle.Type().Field(i),
+			Err:   err,
+		})
 	}
 
 	return
 }
 
-// Populate fills out a bundle with metrics created by the given Factory.
-func Populate(f *touchstone.Factory, b Bundle) error {
+// bundleValue returns the settable struct that a bundle points to.
+func bundleValue(b Bundle) (reflect.Value, error) {
 	bv := reflect.ValueOf(b)
 	if bv.Kind() == reflect.Ptr && !bv.IsNil() {
 		bv = bv.Elem()
 	}
 
 	if bv.Kind() != reflect.Struct || !bv.CanAddr() {
-		return fmt.Errorf(
+		return reflect.Value{}, fmt.Errorf(
 			"'%T' is not a valid bundle.  It must be a non-nil pointer to a struct.",
 			b,
 		)
 	}
 
-	return populate(f, bv)
+	return bv, nil
+}
+
+// Populate fills out a bundle with metrics created by the given Factory.  Fields are
+// processed in order, and the returned error aggregates the errors of each field
+// ordered by field index.  See PopulateWithReport.
+//
+// Fields with TagFactory result in errors.  Use PopulateWithFactories for such bundles.
+func Populate(f touchstone.MetricFactory, b Bundle) error {
+	return PopulateWithFactories(f, nil, b)
+}
+
+// PopulateWithFactories is like Populate, but fields with TagFactory are created by the
+// named factories.  Fields without that tag are created by f.
+func PopulateWithFactories(f touchstone.MetricFactory, named Factories, b Bundle) error {
+	bv, err := bundleValue(b)
+	if err != nil {
+		return err
+	}
+
+	if named == nil {
+		named = Factories{}
+	}
+
+	return populate(f, named, bv).Err()
+}
+
+// PopulateWithReport is like Populate, but also returns the result of each field.  The
+// returned error is the same as the report's Err, unless the bundle itself is invalid,
+// in which case the report is empty.
+func PopulateWithReport(f touchstone.MetricFactory, b Bundle) (PopulateReport, error) {
+	bv, err := bundleValue(b)
+	if err != nil {
+		return PopulateReport{}, err
+	}
+
+	report := populate(f, Factories{}, bv)
+	return report, report.Err()
 }
 
 var (
-	errorType   = reflect.TypeOf((*error)(nil)).Elem()
-	factoryType = reflect.TypeOf((*touchstone.Factory)(nil))
+	errorType        = reflect.TypeOf((*error)(nil)).Elem()
+	factoryType      = reflect.TypeOf((*touchstone.MetricFactory)(nil)).Elem()
+	namedFactoryType = reflect.TypeOf((*touchstone.Factory)(nil))
 )
 
+// provideOptions holds the configuration built by ProvideOptions.
+type provideOptions struct {
+	unregisterOnStop bool
+}
+
+// ProvideOption customizes how Provide and ProvideFields emit a bundle.
+type ProvideOption func(*provideOptions)
+
+// UnregisterOnStop causes a bundle's metrics to be unregistered when the enclosing
+// fx.App stops.  This allows many apps to be started and stopped against the same
+// registry, e.g. in integration test harnesses, without duplicate registration errors.
+//
+// The bundle is provided within its own fx.Module that uses touchstone.UnregisterOnStop,
+// so every collector that the *touchstone.Factory component registers for the bundle is
+// unregistered, including collectors registered through a Route.  Collectors that were
+// already registered, and so were shared via touchstone.ExistingCollector, are left alone.
+// As with touchstone.UnregisterOnStop, the bundle is created by the *touchstone.Factory
+// component, so any decoration of the touchstone.MetricFactory component is not applied.
+func UnregisterOnStop() ProvideOption {
+	return func(po *provideOptions) {
+		po.unregisterOnStop = true
+	}
+}
+
+// scope applies these options to the fx option that provides a bundle.
+func (po provideOptions) scope(o fx.Option) fx.Option {
+	if po.unregisterOnStop {
+		return fx.Module("touchbundle", touchstone.UnregisterOnStop(), o)
+	}
+
+	return o
+}
+
+// prototypeTypes determines the component type of a bundle prototype, along with
+// the underlying struct type.
+func prototypeTypes(prototype interface{}) (componentType, structType reflect.Type, err error) {
+	componentType = reflect.TypeOf(prototype)
+	switch {
+	case componentType == nil:
+		err = fmt.Errorf("A nil interface is not a valid bundle prototype")
+
+	case componentType.Kind() == reflect.Struct:
+		structType = componentType
+
+	case componentType.Kind() == reflect.Ptr && componentType.Elem().Kind() == reflect.Struct:
+		structType = componentType.Elem()
+
+	default:
+		err = fmt.Errorf(
+			"'%T' is not a valid bundle prototype.  It is not a struct or pointer to struct.",
+			prototype,
+		)
+	}
+
+	return
+}
+
 // Provide emits a bundle as an uber/fx component.  The supplied prototype must be
 // either a struct or a pointer to struct.  The returned component will be a new
 // instance of the same type as the prototype.
@@ -92,46 +341,72 @@ var (
 //	        },
 //	    ),
 //	)
-func Provide(prototype interface{}) fx.Option {
-	var (
-		componentType = reflect.TypeOf(prototype)
-		structType    reflect.Type
-	)
+//
+// Fields with TagFactory are created by the *touchstone.Factory component with that
+// name, which must be supplied to the application.
+//
+// Options may be supplied to further customize the component, e.g. UnregisterOnStop.
+// To also emit each field as its own component, use ProvideFields.
+func Provide(prototype interface{}, opts ...ProvideOption) fx.Option {
+	componentType, structType, err := prototypeTypes(prototype)
+	if err != nil {
+		return fx.Error(err)
+	}
 
-	switch {
-	case componentType.Kind() == reflect.Struct:
-		structType = componentType
+	var po provideOptions
+	for _, o := range opts {
+		o(&po)
+	}
 
-	case componentType.Kind() == reflect.Ptr && componentType.Elem().Kind() == reflect.Struct:
-		structType = componentType.Elem()
+	names := factoryNames(structType)
+	paramTypes, paramTags := factoryParams(names)
+	ctor := newBundleConstructor(componentType, structType, names, paramTypes)
+	if len(names) > 0 {
+		return po.scope(fx.Provide(
+			fx.Annotate(ctor.Interface(), fx.ParamTags(paramTags...)),
+		))
+	}
 
-	default:
-		return fx.Error(
-			fmt.Errorf(
-				"'%T' is not a valid bundle prototype.  It is not a struct or pointer to struct.",
-				prototype,
-			),
-		)
+	return po.scope(fx.Provide(ctor.Interface()))
+}
+
+// factoryParams returns the parameter types and fx tags of a bundle's constructor.
+// The bundle's factory is followed by each named factory, in order.
+func factoryParams(names []string) (paramTypes []reflect.Type, paramTags []string) {
+	paramTypes = append(make([]reflect.Type, 0, len(names)+1), factoryType)
+	paramTags = append(make([]string, 0, len(names)+1), "")
+	for _, name := range names {
+		paramTypes = append(paramTypes, namedFactoryType)
+		paramTags = append(paramTags, fmt.Sprintf(`name:"%s"`, name))
 	}
 
-	ctor := reflect.MakeFunc(
+	return
+}
+
+// newBundleConstructor creates the constructor of a bundle component, which accepts
+// the given parameter types and returns the component along with any error from
+// populating its metrics.
+func newBundleConstructor(componentType, structType reflect.Type, names []string, paramTypes []reflect.Type) reflect.Value {
+	return reflect.MakeFunc(
 		reflect.FuncOf(
-			[]reflect.Type{
-				factoryType,
-			},
+			paramTypes,
 			[]reflect.Type{componentType, errorType},
 			false,
 		),
 		func(in []reflect.Value) (out []reflect.Value) {
 			out = make([]reflect.Value, 2)
+			named := make(Factories, len(names))
+			for i, name := range names {
+				named[name] = in[i+1].Interface().(*touchstone.Factory)
+			}
+
 			var (
-				factory     = in[0].Interface().(*touchstone.Factory)
+				factory     = in[0].Interface().(touchstone.MetricFactory)
 				errValue    = reflect.New(errorType)
 				bundleValue = reflect.New(structType)
-				err         = populate(factory, bundleValue.Elem())
 			)
 
-			if err != nil {
+			if err := populate(factory, named, bundleValue.Elem()).Err(); err != nil {
 				errValue.Elem().Set(
 					reflect.ValueOf(err),
 				)
@@ -147,6 +422,4 @@ func Provide(prototype interface{}) fx.Option {
 			return
 		},
 	)
-
-	return fx.Provide(ctor.Interface())
 }
@@ -3,14 +3,24 @@
 package touchbundle
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/suite"
 	"github.com/xmidt-org/touchstone"
+	"github.com/xmidt-org/touchstone/touchhttp"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
 	"go.uber.org/fx/fxtest"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
 
@@ -76,6 +86,30 @@ func (suite *BundleSuite) testPopulateCounters() {
 	suite.Nil(b.Ignore1, "Ignore1 is not nil")
 	suite.Nil(b.Ignore2, "Ignore2 is not nil")
 
+	suite.Run("CreatedTimestamps", func() {
+		cfg := touchstone.Config{
+			DisableGoCollector:        true,
+			DisableProcessCollector:   true,
+			DisableBuildInfoCollector: true,
+			DisableCreatedTimestamps:  true,
+		}
+
+		g, r, err := touchstone.New(cfg)
+		suite.Require().NoError(err)
+
+		var b bundle
+		suite.Require().NoError(Populate(touchstone.NewFactory(cfg, zap.L(), r), &b))
+		b.Counter1.Inc()
+		b.Counter3.WithLabelValues("a", "b").Inc()
+
+		mfs, err := g.Gather()
+		suite.Require().NoError(err)
+		suite.Require().Len(mfs, 3)
+		for _, mf := range mfs {
+			suite.Nil(mf.GetMetric()[0].GetCounter().GetCreatedTimestamp(), mf.GetName())
+		}
+	})
+
 	suite.Run("LabelNamesOnNonVector", func() {
 		type bundle struct {
 			C prometheus.Counter `labelNames:"should,cause,an,error"`
@@ -463,7 +497,613 @@ func (suite *BundleSuite) testPopulateObserverVecs() {
 	})
 }
 
+func (suite *BundleSuite) testPopulateDurationObservers() {
+	type bundle struct {
+		Seconds      DurationObserver
+		Milliseconds DurationObserver    `durationUnit:"ms" objectives:"0.5:0.05"`
+		Vec          DurationObserverVec `durationUnit:"ms" labelNames:"code"`
+	}
+
+	var b bundle
+	suite.successfulPopulate(&b)
+	suite.Require().NotNil(b.Seconds)
+	suite.Require().NotNil(b.Milliseconds)
+	suite.Require().NotNil(b.Vec)
+
+	b.Seconds.ObserveDuration(1500 * time.Millisecond)
+	suite.Equal(1.5, sampleSum(b.Seconds))
+
+	b.Milliseconds.ObserveDuration(1500 * time.Millisecond)
+	suite.Equal(1500.0, sampleSum(b.Milliseconds))
+
+	b.Vec.DurationWithLabelValues("200").ObserveDuration(2 * time.Second)
+	suite.Equal(2000.0, sampleSum(b.Vec.WithLabelValues("200")))
+
+	suite.Run("InvalidUnit", func() {
+		type bundle struct {
+			D DurationObserver `durationUnit:"fortnights"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("NotAllowed", func() {
+		type bundle struct {
+			O prometheus.Observer `durationUnit:"ms"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("LabelNames", func() {
+		type bundle struct {
+			D DurationObserver `labelNames:"not,allowed"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+}
+
+func (suite *BundleSuite) testPopulateDurationGauges() {
+	type bundle struct {
+		Timeout *touchstone.DurationGauge `help:"the configured timeout"`
+		Age     *touchstone.DurationGauge `durationUnit:"ms"`
+		Ignore  *touchstone.DurationGauge `touchstone:"-"`
+	}
+
+	var b bundle
+	suite.successfulPopulate(&b)
+	suite.Require().NotNil(b.Timeout)
+	suite.Require().NotNil(b.Age)
+	suite.Nil(b.Ignore)
+
+	b.Timeout.Set(1500 * time.Millisecond)
+	suite.Equal(1.5, testutil.ToFloat64(b.Timeout))
+
+	b.Age.Set(1500 * time.Millisecond)
+	suite.Equal(1500.0, testutil.ToFloat64(b.Age))
+
+	suite.Run("InvalidUnit", func() {
+		type bundle struct {
+			D *touchstone.DurationGauge `durationUnit:"fortnights"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("LabelNames", func() {
+		type bundle struct {
+			D *touchstone.DurationGauge `labelNames:"not,allowed"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("Type", func() {
+		type bundle struct {
+			D *touchstone.DurationGauge `type:"histogram"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+}
+
+func (suite *BundleSuite) testPopulateStateSets() {
+	type bundle struct {
+		Connection *touchstone.StateSet `states:"idle, connecting, connected" help:"the connection state"`
+		Role       *touchstone.StateSet `name:"custom_role" states:"leader,follower" stateLabel:"role"`
+		Ignore     *touchstone.StateSet `touchstone:"-"`
+	}
+
+	var b bundle
+	suite.successfulPopulate(&b)
+	suite.Require().NotNil(b.Connection)
+	suite.Require().NotNil(b.Role)
+	suite.Nil(b.Ignore)
+
+	suite.Equal([]string{"idle", "connecting", "connected"}, b.Connection.States())
+	suite.Equal("idle", b.Connection.State())
+	suite.NoError(b.Connection.Set("connected"))
+	suite.Equal("connected", b.Connection.State())
+	suite.Equal(3, testutil.CollectAndCount(b.Connection, "connection"))
+
+	suite.Equal("leader", b.Role.State())
+	descs := make(chan *prometheus.Desc, 1)
+	b.Role.Describe(descs)
+	suite.Contains((<-descs).String(), `variableLabels: {role}`)
+
+	suite.Run("MissingStates", func() {
+		type bundle struct {
+			S *touchstone.StateSet
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("DuplicateStates", func() {
+		type bundle struct {
+			S *touchstone.StateSet `states:"a,b,a"`
+		}
+
+		var b bundle
+		suite.ErrorIs(
+			Populate(suite.newFactory(), &b),
+			touchstone.ErrDuplicateState,
+		)
+	})
+
+	suite.Run("LabelNames", func() {
+		type bundle struct {
+			S *touchstone.StateSet `states:"a,b" labelNames:"not,allowed"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("NotAllowed", func() {
+		type bundle struct {
+			G prometheus.Gauge `states:"a,b"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+}
+
+func (suite *BundleSuite) testPopulateOutcomes() {
+	type bundle struct {
+		Logins   *touchstone.Outcome `reasons:"denied, expired" help:"the logins"`
+		Requests *touchstone.Outcome `name:"custom_requests"`
+		Ignore   *touchstone.Outcome `touchstone:"-"`
+	}
+
+	var b bundle
+	suite.successfulPopulate(&b)
+	suite.Require().NotNil(b.Logins)
+	suite.Require().NotNil(b.Requests)
+	suite.Nil(b.Ignore)
+
+	// success, denied, expired, and other are created eagerly
+	suite.Equal(4, testutil.CollectAndCount(b.Logins, "logins"))
+	suite.Equal(1, testutil.CollectAndCount(b.Requests, "custom_requests"))
+
+	b.Logins.Failure("denied")
+	v, err := touchstone.Value(b.Logins, prometheus.Labels{touchstone.ReasonLabel: "denied"})
+	suite.Require().NoError(err)
+	suite.Equal(1.0, v)
+
+	suite.Run("Describe", func() {
+		metrics, err := Describe(bundle{}, prometheus.Opts{}, nil)
+		suite.Require().NoError(err)
+		suite.Require().Len(metrics, 2)
+		suite.Equal(TypeCounter, metrics[0].Type)
+		suite.Equal([]string{touchstone.OutcomeLabel, touchstone.ReasonLabel}, metrics[0].LabelNames)
+	})
+
+	suite.Run("LastError", func() {
+		type bundle struct {
+			Logins *touchstone.Outcome `reasons:"denied" lastError:"true"`
+			Plain  *touchstone.Outcome `lastError:"false"`
+		}
+
+		var (
+			b bundle
+			f = suite.newFactory()
+		)
+
+		suite.Require().NoError(Populate(f, &b))
+		suite.Require().NotNil(b.Logins)
+		suite.Require().NotNil(b.Plain)
+
+		suite.Error(b.Logins.Record(errors.New("expected"), "denied"))
+
+		// the gauge was registered with the outcome's factory, so it cannot be created again
+		_, err := f.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "logins" + touchstone.LastErrorSuffix},
+			touchstone.ReasonLabel, touchstone.MessageHashLabel,
+		)
+
+		suite.Error(err)
+	})
+
+	suite.Run("InvalidLastError", func() {
+		type bundle struct {
+			O *touchstone.Outcome `lastError:"not a bool"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("LabelNames", func() {
+		type bundle struct {
+			O *touchstone.Outcome `labelNames:"not,allowed"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("NotAllowed", func() {
+		type bundle struct {
+			C prometheus.Counter `reasons:"a,b"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("LastErrorNotAllowed", func() {
+		type bundle struct {
+			C prometheus.Counter `lastError:"true"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+}
+
+func (suite *BundleSuite) testPopulateEagerVectors() {
+	type bundle struct {
+		Requests  *prometheus.CounterVec   `labelNames:"code,method" labelValues:"200|404, GET|POST" lazy:"false"`
+		Sizes     prometheus.ObserverVec   `labelNames:"method" labelValues:"GET|PUT" lazy:"false" buckets:"1,10"`
+		Durations DurationObserverVec      `labelNames:"method" labelValues:"GET" lazy:"false"`
+		Lazy      *prometheus.GaugeVec     `labelNames:"pool" labelValues:"a|b"`
+		Explicit  *prometheus.SummaryVec   `labelNames:"pool" labelValues:"a|b" lazy:"true"`
+		Ignore    *prometheus.CounterVec   `touchstone:"-" labelNames:"pool" labelValues:"a" lazy:"false"`
+		Unrelated *prometheus.HistogramVec `labelNames:"pool"`
+		State     *touchstone.StateSet     `states:"on,off"`
+		Trailing  *prometheus.CounterVec   `labelNames:"a,b" labelValues:"x, y|z |" lazy:"false"`
+	}
+
+	var b bundle
+	suite.successfulPopulate(&b)
+	suite.Equal(4, testutil.CollectAndCount(b.Requests))
+	suite.Zero(testutil.ToFloat64(b.Requests.WithLabelValues("404", "POST")))
+	suite.Equal(2, testutil.CollectAndCount(b.Sizes.(prometheus.Collector)))
+	suite.Equal(1, testutil.CollectAndCount(b.Durations.(prometheus.Collector)))
+	suite.Zero(testutil.CollectAndCount(b.Lazy))
+	suite.Zero(testutil.CollectAndCount(b.Explicit))
+	suite.Zero(testutil.CollectAndCount(b.Unrelated))
+	suite.Equal(2, testutil.CollectAndCount(b.Trailing))
+
+	suite.Run("MissingLabelValues", func() {
+		type bundle struct {
+			C *prometheus.CounterVec `labelNames:"code" lazy:"false"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("WrongNumberOfLabelValues", func() {
+		type bundle struct {
+			C *prometheus.CounterVec `labelNames:"code,method" labelValues:"200|404" lazy:"false"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("EmptyLabelValues", func() {
+		type bundle struct {
+			C *prometheus.CounterVec `labelNames:"code,method" labelValues:"200, |" lazy:"false"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("InvalidLazy", func() {
+		type bundle struct {
+			C *prometheus.CounterVec `labelNames:"code" labelValues:"200" lazy:"sometimes"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("NotAVector", func() {
+		type bundle struct {
+			C prometheus.Counter `labelValues:"200" lazy:"false"`
+		}
+
+		var b bundle
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+}
+
+func (suite *BundleSuite) testPopulateInstrumenters() {
+	cfg := touchstone.Config{
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := touchstone.New(cfg)
+	suite.Require().NoError(err)
+
+	type bundle struct {
+		Requests prometheus.Counter
+		Main     touchhttp.ServerInstrumenter `server:"servers.main"`
+		Admin    touchhttp.ServerInstrumenter `server:"servers.admin"`
+		Auth     touchhttp.ClientInstrumenter `client:"clients.auth"`
+		Ignore   touchhttp.ServerInstrumenter `touchstone:"-"`
+	}
+
+	var b bundle
+	suite.Require().NoError(
+		Populate(touchstone.NewFactory(cfg, zap.L(), r), &b),
+	)
+
+	suite.Require().NotNil(b.Requests)
+	h := b.Main.Then(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.NoError(
+		testutil.GatherAndCompare(g, strings.NewReader(`
+# HELP server_request_count the total number of requests received since startup
+# TYPE server_request_count counter
+server_request_count{code="202",method="GET",server="servers.main"} 1
+`), touchhttp.DefaultServerCount),
+	)
+
+	suite.Run("NoLabel", func() {
+		var b struct {
+			Server touchhttp.ServerInstrumenter
+			Client touchhttp.ClientInstrumenter `client:""`
+		}
+
+		suite.NoError(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("InconsistentLabels", func() {
+		var b struct {
+			Server1 touchhttp.ServerInstrumenter
+			Server2 touchhttp.ServerInstrumenter `server:"servers.main"`
+		}
+
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("NotAllowed", func() {
+		var b struct {
+			Server touchhttp.ServerInstrumenter `client:"clients.auth"`
+		}
+
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("MetricTag", func() {
+		var b struct {
+			Client touchhttp.ClientInstrumenter `name:"custom"`
+		}
+
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("NotAnInstrumenter", func() {
+		var b struct {
+			C prometheus.Counter `server:"servers.main"`
+		}
+
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+
+	suite.Run("LatencyClass", func() {
+		g, r, err := touchstone.New(cfg)
+		suite.Require().NoError(err)
+
+		var b struct {
+			Server touchhttp.ServerInstrumenter `latencyClass:"fast"`
+		}
+
+		suite.Require().NoError(
+			Populate(touchstone.NewFactory(cfg, zap.L(), r), &b),
+		)
+
+		b.Server.Then(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		mfs, err := g.Gather()
+		suite.Require().NoError(err)
+
+		var buckets []float64
+		for _, mf := range mfs {
+			if mf.GetName() == touchhttp.DefaultServerDuration {
+				for _, b := range mf.GetMetric()[0].GetHistogram().GetBucket() {
+					buckets = append(buckets, b.GetUpperBound())
+				}
+			}
+		}
+
+		expected, err := touchhttp.LatencyFast.Buckets()
+		suite.Require().NoError(err)
+		suite.Equal(expected, buckets)
+	})
+
+	suite.Run("UnknownLatencyClass", func() {
+		var b struct {
+			Server touchhttp.ServerInstrumenter `latencyClass:"nosuch"`
+		}
+
+		suite.ErrorIs(
+			Populate(suite.newFactory(), &b),
+			touchhttp.ErrUnknownLatencyClass,
+		)
+	})
+
+	suite.Run("LatencyClassNotAllowed", func() {
+		var b struct {
+			H prometheus.Histogram `latencyClass:"fast"`
+		}
+
+		suite.Error(
+			Populate(suite.newFactory(), &b),
+		)
+	})
+}
+
+func (suite *BundleSuite) testPopulateNamingPolicy() {
+	_, r, err := touchstone.New(touchstone.Config{})
+	suite.Require().NoError(err)
+
+	f := touchstone.NewFactory(
+		touchstone.Config{},
+		zap.L(),
+		r,
+		touchstone.WithNamingPolicy(touchstone.Naming{Prefix: "acme"}),
+	)
+
+	var bundle struct {
+		Requests prometheus.Counter
+	}
+
+	suite.Require().NoError(Populate(f, &bundle))
+	suite.Require().NotNil(bundle.Requests)
+	suite.Contains(bundle.Requests.Desc().String(), `"acme_requests"`)
+}
+
+func (suite *BundleSuite) testPopulateReport() {
+	type bundle struct {
+		First      prometheus.Counter   `name:"first"`
+		BadBuckets prometheus.Histogram `name:"bad_buckets" buckets:"x"`
+		NotAMetric string
+		unexported prometheus.Counter
+		Skipped    prometheus.Counter   `touchstone:"-"`
+		Duplicate  prometheus.Counter   `name:"first"`
+		BadType    prometheus.Observer  `name:"bad_type" type:"counter"`
+		Last       *prometheus.GaugeVec `name:"last" labelNames:"code"`
+	}
+
+	var b bundle
+	report, err := PopulateWithReport(suite.newFactory(), &b)
+	suite.Require().Error(err)
+	suite.Equal(report.Err().Error(), err.Error())
+	suite.NotNil(b.First)
+	suite.NotNil(b.Last)
+
+	var indices, names []string
+	for _, fr := range report.Fields {
+		indices = append(indices, strconv.Itoa(fr.Index))
+		names = append(names, fr.Field.Name)
+	}
+
+	suite.Equal([]string{"0", "1", "5", "6", "7"}, indices)
+	suite.Equal([]string{"First", "BadBuckets", "Duplicate", "BadType", "Last"}, names)
+
+	suite.Run("Populated", func() {
+		var populated []string
+		for _, fr := range report.Populated() {
+			suite.NoError(fr.Err)
+			populated = append(populated, fr.Field.Name)
+		}
+
+		suite.Equal([]string{"First", "Last"}, populated)
+	})
+
+	suite.Run("Failed", func() {
+		var failed []string
+		for _, fr := range report.Failed() {
+			suite.Error(fr.Err)
+			failed = append(failed, fr.Field.Name)
+		}
+
+		suite.Equal([]string{"BadBuckets", "Duplicate", "BadType"}, failed)
+	})
+
+	suite.Run("Ordering", func() {
+		errs := multierr.Errors(err)
+		failed := report.Failed()
+		suite.Require().Len(errs, 3)
+		for i, fr := range failed {
+			suite.Equal(fr.Err, errs[i])
+		}
+
+		suite.ErrorAs(errs[1], new(prometheus.AlreadyRegisteredError))
+
+		// the aggregate error is stable across runs
+		for i := 0; i < 5; i++ {
+			var b bundle
+			suite.Equal(err.Error(), Populate(suite.newFactory(), &b).Error())
+		}
+	})
+
+	suite.Run("InvalidBundle", func() {
+		report, err := PopulateWithReport(suite.newFactory(), bundle{})
+		suite.Error(err)
+		suite.Empty(report.Fields)
+	})
+
+	suite.Run("Success", func() {
+		var b struct {
+			Counter prometheus.Counter `name:"counter"`
+		}
+
+		report, err := PopulateWithReport(suite.newFactory(), &b)
+		suite.NoError(err)
+		suite.NoError(report.Err())
+		suite.Len(report.Populated(), 1)
+		suite.Empty(report.Failed())
+	})
+}
+
 func (suite *BundleSuite) TestPopulate() {
+	suite.Run("NamingPolicy", suite.testPopulateNamingPolicy)
 	suite.Run("NonPointer", suite.testPopulateNonPointer)
 	suite.Run("NonStruct", suite.testPopulateNonStruct)
 	suite.Run("Counters", suite.testPopulateCounters)
@@ -472,6 +1112,13 @@ func (suite *BundleSuite) TestPopulate() {
 	suite.Run("Summaries", suite.testPopulateSummaries)
 	suite.Run("Observers", suite.testPopulateObservers)
 	suite.Run("ObserverVecs", suite.testPopulateObserverVecs)
+	suite.Run("DurationObservers", suite.testPopulateDurationObservers)
+	suite.Run("DurationGauges", suite.testPopulateDurationGauges)
+	suite.Run("StateSets", suite.testPopulateStateSets)
+	suite.Run("Outcomes", suite.testPopulateOutcomes)
+	suite.Run("EagerVectors", suite.testPopulateEagerVectors)
+	suite.Run("Instrumenters", suite.testPopulateInstrumenters)
+	suite.Run("Report", suite.testPopulateReport)
 }
 
 func (suite *BundleSuite) newApp(options ...fx.Option) *fx.App {
@@ -481,7 +1128,9 @@ func (suite *BundleSuite) newApp(options ...fx.Option) *fx.App {
 				fx.WithLogger(func() fxevent.Logger {
 					return fxtest.NewTestLogger(suite.T())
 				}),
-				fx.Supply(suite.newFactory()),
+				fx.Supply(
+					fx.Annotate(suite.newFactory(), fx.As(new(touchstone.MetricFactory))),
+				),
 			},
 			options...,
 		)...,
@@ -496,7 +1145,9 @@ func (suite *BundleSuite) newTestApp(options ...fx.Option) *fxtest.App {
 		suite.T(),
 		append(
 			[]fx.Option{
-				fx.Supply(suite.newFactory()),
+				fx.Supply(
+					fx.Annotate(suite.newFactory(), fx.As(new(touchstone.MetricFactory))),
+				),
 			},
 			options...,
 		)...,
@@ -587,6 +1238,184 @@ func (suite *BundleSuite) TestProvide() {
 	suite.Run("Pointer", suite.testProvidePointer)
 }
 
+// newGatheredFactory creates a factory along with the gatherer for its registry.
+func (suite *BundleSuite) newGatheredFactory() (*touchstone.Factory, prometheus.Gatherer) {
+	cfg := touchstone.Config{
+		Pedantic:                  true,
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := touchstone.New(cfg)
+	suite.Require().NoError(err)
+	return touchstone.NewFactory(cfg, zap.L(), r), g
+}
+
+// assertGathered asserts that a gatherer has exactly the given metric families.
+func (suite *BundleSuite) assertGathered(g prometheus.Gatherer, expected ...string) {
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+
+	var names []string
+	for _, mf := range mfs {
+		names = append(names, mf.GetName())
+	}
+
+	suite.ElementsMatch(expected, names)
+}
+
+func (suite *BundleSuite) TestFactories() {
+	type bundle struct {
+		Requests prometheus.Counter     `help:"the requests"`
+		Devices  *prometheus.GaugeVec   `factory:"debug" labelNames:"device" help:"the devices"`
+		Sizes    prometheus.ObserverVec `factory:"debug" labelNames:"device" help:"the sizes" buckets:"1,10"`
+	}
+
+	suite.Run("PopulateWithFactories", func() {
+		var (
+			b               bundle
+			public, publicG = suite.newGatheredFactory()
+			debug, debugG   = suite.newGatheredFactory()
+		)
+
+		suite.Require().NoError(
+			PopulateWithFactories(public, Factories{"debug": debug}, &b),
+		)
+
+		b.Requests.Inc()
+		b.Devices.WithLabelValues("a").Set(1.0)
+		b.Sizes.WithLabelValues("a").Observe(1.0)
+		suite.assertGathered(publicG, "requests")
+		suite.assertGathered(debugG, "devices", "sizes")
+	})
+
+	suite.Run("NoSuchFactory", func() {
+		var b bundle
+		err := PopulateWithFactories(suite.newFactory(), Factories{"other": suite.newFactory()}, &b)
+		suite.Require().Error(err)
+		suite.Contains(err.Error(), "debug")
+		suite.NotNil(b.Requests)
+		suite.Nil(b.Devices)
+
+		suite.Error(Populate(suite.newFactory(), &b))
+	})
+
+	suite.Run("Clone", func() {
+		f, g := suite.newGatheredFactory()
+		clone, err := Clone(f, bundle{})
+		suite.Require().NoError(err)
+		suite.Require().IsType(bundle{}, clone)
+
+		clone.(bundle).Devices.WithLabelValues("a").Set(1.0)
+		clone.(bundle).Sizes.WithLabelValues("a").Observe(1.0)
+		suite.assertGathered(g, "requests", "devices", "sizes")
+	})
+
+	suite.Run("Provide", func() {
+		var (
+			b               bundle
+			public, publicG = suite.newGatheredFactory()
+			debug, debugG   = suite.newGatheredFactory()
+
+			app = fxtest.New(
+				suite.T(),
+				fx.Supply(
+					fx.Annotate(public, fx.As(new(touchstone.MetricFactory))),
+					fx.Annotated{Name: "debug", Target: debug},
+				),
+				Provide(bundle{}),
+				fx.Populate(&b),
+			)
+		)
+
+		app.RequireStart()
+		b.Requests.Inc()
+		b.Devices.WithLabelValues("a").Set(1.0)
+		b.Sizes.WithLabelValues("a").Observe(1.0)
+		suite.assertGathered(publicG, "requests")
+		suite.assertGathered(debugG, "devices", "sizes")
+		app.RequireStop()
+	})
+
+	suite.Run("ProvideMissingFactory", func() {
+		var b bundle
+		app := fx.New(
+			fx.NopLogger,
+			fx.Supply(fx.Annotate(suite.newFactory(), fx.As(new(touchstone.MetricFactory)))),
+			Provide(bundle{}),
+			fx.Populate(&b),
+		)
+
+		suite.Error(app.Err())
+	})
+}
+
+func (suite *BundleSuite) TestUnregisterOnStop() {
+	type bundle struct {
+		Requests prometheus.Counter     `help:"the requests"`
+		Devices  *prometheus.GaugeVec   `labelNames:"device" help:"the devices"`
+		Calls    *touchstone.Outcome    `lastError:"true" help:"the calls"`
+		Sizes    prometheus.ObserverVec `labelNames:"device" help:"the sizes" buckets:"1,10"`
+	}
+
+	cfg := touchstone.Config{
+		Pedantic:                  true,
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := touchstone.New(cfg)
+	suite.Require().NoError(err)
+	f := touchstone.NewFactory(cfg, zap.L(), r)
+
+	// metrics created outside the bundle are not unregistered
+	outside, err := f.NewCounter(prometheus.CounterOpts{Name: "outside", Help: "not in the bundle"})
+	suite.Require().NoError(err)
+	outside.Inc()
+
+	newApp := func(opts ...ProvideOption) (*fx.App, *bundle) {
+		b := new(bundle)
+		app := fx.New(
+			fx.NopLogger,
+			fx.Supply(
+				f,
+				fx.Annotate(f, fx.As(new(touchstone.MetricFactory))),
+				fx.Annotate(r, fx.As(new(prometheus.Registerer))),
+			),
+			Provide(bundle{}, opts...),
+			fx.Populate(b),
+		)
+
+		return app, b
+	}
+
+	for i := 0; i < 2; i++ {
+		app, b := newApp(UnregisterOnStop())
+		suite.Require().NoError(app.Err())
+		suite.Require().NoError(app.Start(context.Background()))
+
+		b.Requests.Inc()
+		b.Devices.WithLabelValues("a").Set(1.0)
+		b.Calls.Record(errors.New("expected"), "")
+		b.Sizes.WithLabelValues("a").Observe(1.0)
+		suite.assertGathered(g, "outside", "requests", "devices", "calls", "calls_last_error_info", "sizes")
+
+		suite.Require().NoError(app.Stop(context.Background()))
+		suite.assertGathered(g, "outside")
+	}
+
+	// without the option, the metrics remain registered after the app stops
+	app, _ := newApp()
+	suite.Require().NoError(app.Err())
+	suite.Require().NoError(app.Start(context.Background()))
+	suite.Require().NoError(app.Stop(context.Background()))
+
+	app, _ = newApp()
+	suite.Error(app.Err())
+}
+
 func TestBundle(t *testing.T) {
 	suite.Run(t, new(BundleSuite))
 }
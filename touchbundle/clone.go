@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbundle
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/xmidt-org/touchstone"
+)
+
+// Clone re-creates the metrics of a bundle with another factory, e.g. one backed by
+// an internal debug registry.  This allows the same bundle struct to be used in
+// dual-registry setups without duplicating its definition.
+//
+// The src bundle must be either a struct or a non-nil pointer to a struct, and the
+// returned bundle is of the same type.  Metrics are created from the struct tags
+// exactly as Populate does, so they keep their names and labels, subject to the
+// defaults and naming policy of the given factory.  Every metric is created by the
+// given factory, including those of fields with TagFactory.  Every other field, including
+// fields ignored with TagTouchstone, is copied from src as is.
+//
+// If any metric cannot be created, this function returns a nil bundle and the error.
+func Clone(f touchstone.MetricFactory, src Bundle) (Bundle, error) {
+	sv := reflect.ValueOf(src)
+	isPtr := sv.Kind() == reflect.Ptr
+	if isPtr && !sv.IsNil() {
+		sv = sv.Elem()
+	}
+
+	if sv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf(
+			"'%T' is not a valid bundle.  It must be a struct or a non-nil pointer to a struct.",
+			src,
+		)
+	}
+
+	clone := reflect.New(sv.Type())
+	clone.Elem().Set(sv)
+	if err := populate(f, nil, clone.Elem()).Err(); err != nil {
+		return nil, err
+	}
+
+	if isPtr {
+		return clone.Interface(), nil
+	}
+
+	return clone.Elem().Interface(), nil
+}
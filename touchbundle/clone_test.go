@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbundle
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/xmidt-org/touchstone"
+)
+
+type cloneBundle struct {
+	Requests prometheus.Counter     `help:"the total requests"`
+	Errors   *prometheus.CounterVec `labelNames:"code"`
+	Ignored  prometheus.Gauge       `touchstone:"-"`
+	Name     string
+}
+
+func (suite *BundleSuite) testClonePointer() {
+	var src cloneBundle
+	suite.successfulPopulate(&src)
+	src.Ignored = prometheus.NewGauge(prometheus.GaugeOpts{Name: "ignored"})
+	src.Name = "primary"
+
+	b, err := Clone(suite.newFactory(), &src)
+	suite.Require().NoError(err)
+	suite.Require().IsType((*cloneBundle)(nil), b)
+
+	clone := b.(*cloneBundle)
+	suite.Require().NotNil(clone.Requests)
+	suite.Require().NotNil(clone.Errors)
+	suite.NotSame(src.Errors, clone.Errors)
+	suite.Equal(src.Requests.Desc().String(), clone.Requests.Desc().String())
+	suite.Same(src.Ignored, clone.Ignored)
+	suite.Equal("primary", clone.Name)
+
+	// the clone's metrics are independent of the source's
+	clone.Requests.Inc()
+	suite.Zero(testutil.ToFloat64(src.Requests))
+	suite.Equal(1.0, testutil.ToFloat64(clone.Requests))
+}
+
+func (suite *BundleSuite) testCloneStruct() {
+	var src cloneBundle
+	suite.successfulPopulate(&src)
+
+	b, err := Clone(suite.newFactory(), src)
+	suite.Require().NoError(err)
+	suite.Require().IsType(cloneBundle{}, b)
+	suite.NotNil(b.(cloneBundle).Requests)
+}
+
+func (suite *BundleSuite) testCloneUnpopulated() {
+	b, err := Clone(suite.newFactory(), cloneBundle{})
+	suite.Require().NoError(err)
+	suite.NotNil(b.(cloneBundle).Requests)
+}
+
+func (suite *BundleSuite) testCloneSameFactory() {
+	var (
+		f   = suite.newFactory()
+		src cloneBundle
+	)
+
+	suite.Require().NoError(Populate(f, &src))
+	b, err := Clone(f, &src)
+	suite.Nil(b)
+	suite.NotNil(touchstone.AsAlreadyRegisteredError(err))
+}
+
+func (suite *BundleSuite) testCloneInvalid() {
+	for _, src := range []Bundle{123, (*cloneBundle)(nil), nil} {
+		b, err := Clone(suite.newFactory(), src)
+		suite.Nil(b)
+		suite.Error(err)
+	}
+}
+
+func (suite *BundleSuite) TestClone() {
+	suite.Run("Pointer", suite.testClonePointer)
+	suite.Run("Struct", suite.testCloneStruct)
+	suite.Run("Unpopulated", suite.testCloneUnpopulated)
+	suite.Run("SameFactory", suite.testCloneSameFactory)
+	suite.Run("Invalid", suite.testCloneInvalid)
+}
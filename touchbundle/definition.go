@@ -0,0 +1,367 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbundle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/fx"
+	"go.uber.org/multierr"
+	"gopkg.in/yaml.v3"
+)
+
+// Objective is a single quantile of a summary, along with its allowed error.
+type Objective struct {
+	Quantile float64 `json:"quantile" yaml:"quantile"`
+	Error    float64 `json:"error" yaml:"error"`
+}
+
+// Definition declares a single metric of a bundle in configuration rather than with
+// struct tags.  The fields mirror the struct field tags of this package.
+type Definition struct {
+	// Name is the metric name, without any namespace or subsystem.  It is also the name
+	// by which a ConfigBundle looks up the metric.  This field is required.
+	Name string `json:"name" yaml:"name"`
+
+	// Namespace is the metric namespace.  If unset, the Factory's default is used.
+	Namespace string `json:"namespace" yaml:"namespace"`
+
+	// Subsystem is the metric subsystem.  If unset, the Factory's default is used.
+	Subsystem string `json:"subsystem" yaml:"subsystem"`
+
+	// Help is the metric help.
+	Help string `json:"help" yaml:"help"`
+
+	// Type is the kind of metric:  TypeCounter, TypeGauge, TypeHistogram, or TypeSummary.
+	// This field is required.
+	Type string `json:"type" yaml:"type"`
+
+	// LabelNames are the label names of the metric.  If set, a vector is created.
+	LabelNames []string `json:"labelNames" yaml:"labelNames"`
+
+	// ConstLabels are constant labels applied to every series of the metric.
+	ConstLabels map[string]string `json:"constLabels" yaml:"constLabels"`
+
+	// Buckets are the buckets of a histogram.  If unset, the Factory's default is used.
+	// This field is only valid for histograms.
+	Buckets []float64 `json:"buckets" yaml:"buckets"`
+
+	// Objectives are the quantiles of a summary.  This field is only valid for summaries.
+	Objectives []Objective `json:"objectives" yaml:"objectives"`
+
+	// MaxAge is the summary MaxAge.  This field is only valid for summaries.
+	MaxAge time.Duration `json:"maxAge" yaml:"maxAge"`
+
+	// AgeBuckets is the summary AgeBuckets.  This field is only valid for summaries.
+	AgeBuckets uint32 `json:"ageBuckets" yaml:"ageBuckets"`
+
+	// BufCap is the summary BufCap.  This field is only valid for summaries.
+	BufCap uint32 `json:"bufCap" yaml:"bufCap"`
+}
+
+// Definitions is the set of metrics of a bundle declared in configuration.
+type Definitions []Definition
+
+// ParseDefinitions parses a YAML or JSON document holding a list of Definitions, e.g.:
+//
+//   - name: requests_total
+//     type: counter
+//     help: the total number of requests
+//     labelNames: [code]
+//   - name: request_duration_seconds
+//     type: histogram
+//     buckets: [0.1, 0.5, 1]
+//
+// Since YAML is a superset of JSON, the equivalent JSON array is also accepted.
+// In YAML, MaxAge may be written as a duration string such as "10m".  Unknown keys
+// are rejected, so that misspelled options are not silently ignored.
+func ParseDefinitions(data []byte) (defs Definitions, err error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(&defs); err != nil {
+		defs = nil
+	}
+
+	return
+}
+
+// DefinitionError represents an error while processing a Definition.
+type DefinitionError struct {
+	// Definition is the metric definition that could not be processed.
+	Definition Definition
+
+	// Cause is the wrapped error that caused this definition error.
+	Cause error
+
+	// Message is the error message associated with the definition.
+	Message string
+}
+
+func (de *DefinitionError) Unwrap() error {
+	return de.Cause
+}
+
+func (de *DefinitionError) Error() string {
+	if de.Cause != nil {
+		return fmt.Sprintf("'%s %s': %s: %s", de.Definition.Name, de.Definition.Type, de.Message, de.Cause)
+	}
+
+	return fmt.Sprintf("'%s %s': %s", de.Definition.Name, de.Definition.Type, de.Message)
+}
+
+// errNotAllowed is the cause of a DefinitionError for an option that does not apply
+// to a definition's type.
+var errNotAllowed = errors.New("option is not allowed for this metric type")
+
+// notAllowed returns an error for each option that is set but does not apply.
+func (d Definition) notAllowed(err error, set map[string]bool) error {
+	names := make([]string, 0, len(set))
+	for name, isSet := range set {
+		if isSet {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		err = multierr.Append(err, &DefinitionError{
+			Definition: d,
+			Cause:      errNotAllowed,
+			Message:    fmt.Sprintf("'%s' cannot be set", name),
+		})
+	}
+
+	return err
+}
+
+// newOpts creates the *Opts struct for this definition.
+func (d Definition) newOpts() (opts interface{}, err error) {
+	var (
+		mo           = d.metricOpts()
+		histogramSet = map[string]bool{"buckets": len(d.Buckets) > 0}
+		summarySet   = map[string]bool{
+			"objectives": len(d.Objectives) > 0,
+			"maxAge":     d.MaxAge != 0,
+			"ageBuckets": d.AgeBuckets != 0,
+			"bufCap":     d.BufCap != 0,
+		}
+	)
+
+	switch d.Type {
+	case TypeCounter:
+		opts = mo.counterOpts()
+		err = d.notAllowed(err, histogramSet)
+		err = d.notAllowed(err, summarySet)
+
+	case TypeGauge:
+		opts = mo.gaugeOpts()
+		err = d.notAllowed(err, histogramSet)
+		err = d.notAllowed(err, summarySet)
+
+	case TypeHistogram:
+		opts = mo.histogramOpts(d.Buckets)
+		err = d.notAllowed(err, summarySet)
+
+	case TypeSummary:
+		opts = mo.summaryOpts(d.objectives(), d.MaxAge, d.AgeBuckets, d.BufCap)
+		err = d.notAllowed(err, histogramSet)
+
+	default:
+		err = &DefinitionError{
+			Definition: d,
+			Message:    fmt.Sprintf("'%s' is not a valid metric type", d.Type),
+		}
+	}
+
+	if err != nil {
+		opts = nil
+	}
+
+	return
+}
+
+// metricOpts returns the options common to every kind of metric for this definition.
+func (d Definition) metricOpts() metricOpts {
+	return metricOpts{
+		namespace:   d.Namespace,
+		subsystem:   d.Subsystem,
+		name:        d.Name,
+		help:        d.Help,
+		constLabels: d.ConstLabels,
+	}
+}
+
+// objectives converts the Objectives of this definition into the form used by
+// prometheus.SummaryOpts.  If there are no objectives, this method returns nil.
+func (d Definition) objectives() map[float64]float64 {
+	if len(d.Objectives) == 0 {
+		return nil
+	}
+
+	objectives := make(map[float64]float64, len(d.Objectives))
+	for _, o := range d.Objectives {
+		objectives[o.Quantile] = o.Error
+	}
+
+	return objectives
+}
+
+// ConfigBundle is a bundle of metrics created from Definitions.  Each metric is
+// looked up by the name in its Definition.
+//
+// The typed accessors return nil if there is no such metric or if the metric is of
+// a different type.  Vector metrics are only returned by the vector accessors.
+type ConfigBundle struct {
+	metrics map[string]interface{}
+	names   []string
+}
+
+// NewConfigBundle creates and registers the metrics of a ConfigBundle using the given
+// factory.  Each definition must have a unique name and a valid type, and any options
+// that do not apply to that type must be unset.  All problems are returned, combined
+// with go.uber.org/multierr, and the returned bundle holds the metrics that were
+// created successfully.
+func NewConfigBundle(f touchstone.MetricFactory, defs Definitions) (cb *ConfigBundle, err error) {
+	cb = &ConfigBundle{
+		metrics: make(map[string]interface{}, len(defs)),
+	}
+
+	for _, d := range defs {
+		if len(d.Name) == 0 {
+			err = multierr.Append(err, &DefinitionError{Definition: d, Message: "a name is required"})
+			continue
+		}
+
+		if _, exists := cb.metrics[d.Name]; exists {
+			err = multierr.Append(err, &DefinitionError{Definition: d, Message: "duplicate metric name"})
+			continue
+		}
+
+		opts, optsErr := d.newOpts()
+		if optsErr != nil {
+			err = multierr.Append(err, optsErr)
+			continue
+		}
+
+		var (
+			metric    interface{}
+			metricErr error
+		)
+
+		if len(d.LabelNames) > 0 {
+			metric, metricErr = f.NewVec(opts, d.LabelNames...)
+		} else {
+			metric, metricErr = f.New(opts)
+		}
+
+		if metricErr != nil {
+			err = multierr.Append(err, &DefinitionError{
+				Definition: d,
+				Cause:      metricErr,
+				Message:    "unable to create metric",
+			})
+
+			continue
+		}
+
+		cb.metrics[d.Name] = metric
+		cb.names = append(cb.names, d.Name)
+	}
+
+	return
+}
+
+// Names returns the names of this bundle's metrics, in the order they were defined.
+func (cb *ConfigBundle) Names() []string {
+	return append([]string{}, cb.names...)
+}
+
+// Metric returns the metric with the given name, e.g. a prometheus.Counter or
+// a *prometheus.HistogramVec.
+func (cb *ConfigBundle) Metric(name string) (metric interface{}, ok bool) {
+	metric, ok = cb.metrics[name]
+	return
+}
+
+// Counter returns the counter with the given name.
+func (cb *ConfigBundle) Counter(name string) prometheus.Counter {
+	c, _ := cb.metrics[name].(prometheus.Counter)
+	return c
+}
+
+// CounterVec returns the counter vector with the given name.
+func (cb *ConfigBundle) CounterVec(name string) *prometheus.CounterVec {
+	cv, _ := cb.metrics[name].(*prometheus.CounterVec)
+	return cv
+}
+
+// Gauge returns the gauge with the given name.
+func (cb *ConfigBundle) Gauge(name string) prometheus.Gauge {
+	g, _ := cb.metrics[name].(prometheus.Gauge)
+	return g
+}
+
+// GaugeVec returns the gauge vector with the given name.
+func (cb *ConfigBundle) GaugeVec(name string) *prometheus.GaugeVec {
+	gv, _ := cb.metrics[name].(*prometheus.GaugeVec)
+	return gv
+}
+
+// Observer returns the histogram or summary with the given name.
+func (cb *ConfigBundle) Observer(name string) prometheus.Observer {
+	o, _ := cb.metrics[name].(prometheus.Observer)
+	return o
+}
+
+// ObserverVec returns the histogram or summary vector with the given name.
+func (cb *ConfigBundle) ObserverVec(name string) prometheus.ObserverVec {
+	ov, _ := cb.metrics[name].(prometheus.ObserverVec)
+	return ov
+}
+
+// ProvideFromConfig emits a *ConfigBundle as an uber/fx component named with the given key.
+// The bundle is built from the Definitions component with the same name, which is typically
+// a section of application configuration:
+//
+//	app := fx.New(
+//	    touchstone.Provide(),
+//	    fx.Provide(
+//	        fx.Annotate(
+//	            func(cfg AppConfig) touchbundle.Definitions { return cfg.Metrics },
+//	            fx.ResultTags(`name:"metrics"`),
+//	        ),
+//	    ),
+//	    touchbundle.ProvideFromConfig("metrics"),
+//	    fx.Invoke(
+//	        fx.Annotate(
+//	            func(cb *touchbundle.ConfigBundle) {
+//	                cb.CounterVec("requests_total").WithLabelValues("200").Inc()
+//	            },
+//	            fx.ParamTags(`name:"metrics"`),
+//	        ),
+//	    ),
+//	)
+//
+// Options may be supplied to further customize the component, e.g. UnregisterOnStop.
+func ProvideFromConfig(key string, opts ...ProvideOption) fx.Option {
+	var po provideOptions
+	for _, o := range opts {
+		o(&po)
+	}
+
+	nameTag := fmt.Sprintf(`name:"%s"`, key)
+	return po.scope(fx.Provide(
+		fx.Annotate(
+			NewConfigBundle,
+			fx.ParamTags("", nameTag),
+			fx.ResultTags(nameTag),
+		),
+	))
+}
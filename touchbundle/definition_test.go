@@ -0,0 +1,247 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbundle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const testDefinitions = `
+- name: requests_total
+  type: counter
+  help: the total number of requests
+  labelNames: [code]
+- name: connections
+  type: gauge
+  constLabels:
+    pool: main
+- name: request_duration_seconds
+  type: histogram
+  buckets: [0.1, 0.5, 1]
+- name: payload_bytes
+  type: summary
+  labelNames: [direction]
+  objectives:
+    - quantile: 0.5
+      error: 0.05
+  maxAge: 10m
+`
+
+type DefinitionSuite struct {
+	suite.Suite
+}
+
+func (suite *DefinitionSuite) newFactory() (*touchstone.Factory, prometheus.Gatherer, prometheus.Registerer) {
+	cfg := touchstone.Config{
+		Pedantic:                  true,
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := touchstone.New(cfg)
+	suite.Require().NoError(err)
+	return touchstone.NewFactory(cfg, zap.L(), r), g, r
+}
+
+func (suite *DefinitionSuite) parse(data string) Definitions {
+	defs, err := ParseDefinitions([]byte(data))
+	suite.Require().NoError(err)
+	return defs
+}
+
+func (suite *DefinitionSuite) TestParseDefinitions() {
+	suite.Run("YAML", func() {
+		defs := suite.parse(testDefinitions)
+		suite.Require().Len(defs, 4)
+		suite.Equal([]string{"code"}, defs[0].LabelNames)
+		suite.Equal(map[string]string{"pool": "main"}, defs[1].ConstLabels)
+		suite.Equal([]float64{0.1, 0.5, 1}, defs[2].Buckets)
+		suite.Equal([]Objective{{Quantile: 0.5, Error: 0.05}}, defs[3].Objectives)
+		suite.Equal(10*time.Minute, defs[3].MaxAge)
+	})
+
+	suite.Run("JSON", func() {
+		defs := suite.parse(`[{"name": "requests_total", "type": "counter", "labelNames": ["code"]}]`)
+		suite.Equal(
+			Definitions{{Name: "requests_total", Type: TypeCounter, LabelNames: []string{"code"}}},
+			defs,
+		)
+	})
+
+	suite.Run("UnknownField", func() {
+		defs, err := ParseDefinitions([]byte(`[{"name": "requests_total", "type": "counter", "label": "code"}]`))
+		suite.Error(err)
+		suite.Nil(defs)
+	})
+}
+
+func (suite *DefinitionSuite) TestNewConfigBundle() {
+	f, g, _ := suite.newFactory()
+	cb, err := NewConfigBundle(f, suite.parse(testDefinitions))
+	suite.Require().NoError(err)
+	suite.Require().NotNil(cb)
+
+	suite.Equal(
+		[]string{"requests_total", "connections", "request_duration_seconds", "payload_bytes"},
+		cb.Names(),
+	)
+
+	suite.Require().NotNil(cb.CounterVec("requests_total"))
+	suite.Nil(cb.Counter("requests_total"))
+	cb.CounterVec("requests_total").WithLabelValues("200").Inc()
+
+	suite.Require().NotNil(cb.Gauge("connections"))
+	cb.Gauge("connections").Set(3.0)
+	suite.Equal(3.0, testutil.ToFloat64(cb.Gauge("connections")))
+
+	suite.Require().NotNil(cb.Observer("request_duration_seconds"))
+	suite.Nil(cb.ObserverVec("request_duration_seconds"))
+	cb.Observer("request_duration_seconds").Observe(0.2)
+
+	suite.Require().NotNil(cb.ObserverVec("payload_bytes"))
+	cb.ObserverVec("payload_bytes").WithLabelValues("in").Observe(100.0)
+
+	suite.Nil(cb.GaugeVec("connections"))
+	suite.Nil(cb.Counter("missing"))
+
+	metric, ok := cb.Metric("connections")
+	suite.True(ok)
+	suite.NotNil(metric)
+
+	_, ok = cb.Metric("missing")
+	suite.False(ok)
+
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+	suite.Len(mfs, 4)
+}
+
+func (suite *DefinitionSuite) TestNewConfigBundleErrors() {
+	testCases := []struct {
+		name string
+		defs Definitions
+	}{
+		{"NoName", Definitions{{Type: TypeCounter}}},
+		{"InvalidType", Definitions{{Name: "test", Type: "timer"}}},
+		{"NoType", Definitions{{Name: "test"}}},
+		{"Duplicate", Definitions{{Name: "test", Type: TypeCounter}, {Name: "test", Type: TypeGauge}}},
+		{"CounterBuckets", Definitions{{Name: "test", Type: TypeCounter, Buckets: []float64{1}}}},
+		{"GaugeMaxAge", Definitions{{Name: "test", Type: TypeGauge, MaxAge: time.Minute}}},
+		{"HistogramObjectives", Definitions{{Name: "test", Type: TypeHistogram, Objectives: []Objective{{0.5, 0.05}}}}},
+		{"SummaryBuckets", Definitions{{Name: "test", Type: TypeSummary, Buckets: []float64{1}}}},
+		{"InvalidLabelName", Definitions{{Name: "test", Type: TypeCounter, LabelNames: []string{"__reserved"}}}},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.name, func() {
+			f, _, _ := suite.newFactory()
+			cb, err := NewConfigBundle(f, testCase.defs)
+			suite.Require().NotNil(cb)
+
+			var de *DefinitionError
+			suite.Require().ErrorAs(err, &de)
+			suite.NotEmpty(de.Error())
+		})
+	}
+
+	suite.Run("NotAllowed", func() {
+		f, _, _ := suite.newFactory()
+		_, err := NewConfigBundle(f, Definitions{{Name: "test", Type: TypeCounter, BufCap: 10}})
+		suite.ErrorIs(err, errNotAllowed)
+	})
+
+	suite.Run("Partial", func() {
+		f, _, _ := suite.newFactory()
+		cb, err := NewConfigBundle(f, Definitions{{Name: "good", Type: TypeCounter}, {Name: "bad", Type: "timer"}})
+		suite.Error(err)
+		suite.Equal([]string{"good"}, cb.Names())
+		suite.NotNil(cb.Counter("good"))
+	})
+}
+
+func (suite *DefinitionSuite) TestProvideFromConfig() {
+	f, g, r := suite.newFactory()
+	newApp := func(opts ...ProvideOption) (*fx.App, *ConfigBundle) {
+		var cb *ConfigBundle
+		app := fx.New(
+			fx.NopLogger,
+			fx.Supply(
+				f,
+				fx.Annotate(f, fx.As(new(touchstone.MetricFactory))),
+				fx.Annotate(r, fx.As(new(prometheus.Registerer))),
+				fx.Annotate(suite.parse(testDefinitions), fx.ResultTags(`name:"metrics"`)),
+			),
+			ProvideFromConfig("metrics", opts...),
+			fx.Invoke(
+				fx.Annotate(
+					func(in *ConfigBundle) { cb = in },
+					fx.ParamTags(`name:"metrics"`),
+				),
+			),
+		)
+
+		return app, cb
+	}
+
+	for i := 0; i < 2; i++ {
+		app, cb := newApp(UnregisterOnStop())
+		suite.Require().NoError(app.Err())
+		suite.Require().NotNil(cb)
+		suite.Require().NoError(app.Start(context.Background()))
+
+		cb.CounterVec("requests_total").WithLabelValues("200").Inc()
+		mfs, err := g.Gather()
+		suite.Require().NoError(err)
+		suite.Len(mfs, 3) // the summary vector has no children yet
+
+		suite.Require().NoError(app.Stop(context.Background()))
+		mfs, err = g.Gather()
+		suite.Require().NoError(err)
+		suite.Empty(mfs)
+	}
+
+	app, cb := newApp()
+	suite.Require().NoError(app.Err())
+	suite.NotNil(cb.Gauge("connections"))
+
+	// the metrics are still registered, so a second app fails
+	app, _ = newApp()
+	suite.Error(app.Err())
+}
+
+func (suite *DefinitionSuite) TestProvideFromConfigInvalid() {
+	f, _, _ := suite.newFactory()
+	app := fx.New(
+		fx.NopLogger,
+		fx.Supply(
+			fx.Annotate(f, fx.As(new(touchstone.MetricFactory))),
+			fx.Annotate(Definitions{{Name: "test", Type: "timer"}}, fx.ResultTags(`name:"metrics"`)),
+		),
+		ProvideFromConfig("metrics"),
+		fx.Invoke(
+			fx.Annotate(
+				func(*ConfigBundle) {},
+				fx.ParamTags(`name:"metrics"`),
+			),
+		),
+	)
+
+	var de *DefinitionError
+	suite.True(errors.As(app.Err(), &de))
+}
+
+func TestDefinition(t *testing.T) {
+	suite.Run(t, new(DefinitionSuite))
+}
@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbundle
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/multierr"
+)
+
+const (
+	// TypeCounter is the Metric.Type of counters and counter vectors.
+	TypeCounter = "counter"
+
+	// TypeGauge is the Metric.Type of gauges and gauge vectors.
+	TypeGauge = "gauge"
+)
+
+// Metric describes a single metric that a bundle field produces.  Metric
+// descriptions allow tooling, e.g. dashboards and alert rules, to stay in sync
+// with the metrics actually created by code.
+type Metric struct {
+	// Field is the name of the bundle struct field.
+	Field string
+
+	// Name is the fully qualified metric name, including any namespace and subsystem.
+	Name string
+
+	// Type is the kind of metric:  TypeCounter, TypeGauge, TypeHistogram, or TypeSummary.
+	Type string
+
+	// Help is the metric's help text, if any.
+	Help string
+
+	// LabelNames are the label names of a vector metric.  This field is unset
+	// for non-vector metrics.
+	LabelNames []string
+}
+
+// describe produces the Metric for an *Opts struct.
+func describe(field string, opts interface{}, labelNames []string, defaults prometheus.Opts, policy touchstone.NamingPolicy) (m Metric) {
+	var o prometheus.Opts
+	switch t := opts.(type) {
+	case prometheus.CounterOpts:
+		o, m.Type = prometheus.Opts(t), TypeCounter
+
+	case prometheus.GaugeOpts:
+		o, m.Type = prometheus.Opts(t), TypeGauge
+
+	case prometheus.HistogramOpts:
+		o, m.Type = prometheus.Opts{Namespace: t.Namespace, Subsystem: t.Subsystem, Name: t.Name, Help: t.Help}, TypeHistogram
+
+	case prometheus.SummaryOpts:
+		o, m.Type = prometheus.Opts{Namespace: t.Namespace, Subsystem: t.Subsystem, Name: t.Name, Help: t.Help}, TypeSummary
+	}
+
+	if len(o.Namespace) == 0 {
+		o.Namespace = defaults.Namespace
+	}
+
+	if len(o.Subsystem) == 0 {
+		o.Subsystem = defaults.Subsystem
+	}
+
+	if policy != nil {
+		o.Name = policy.MetricName(o.Name)
+	}
+
+	m.Field = field
+	m.Name = prometheus.BuildFQName(o.Namespace, o.Subsystem, o.Name)
+	m.Help = o.Help
+	m.LabelNames = labelNames
+	return
+}
+
+// Describe returns descriptions of the metrics a bundle would create, in field order,
+// without creating or registering any metrics.  The prototype must be a struct or
+// a pointer to struct, which can be nil.  The defaults are the namespace and subsystem
+// applied by the touchstone.Factory, e.g. from touchstone.Config, and the policy is the
+// Factory's NamingPolicy, which can be nil if there is none.
+func Describe(prototype interface{}, defaults prometheus.Opts, policy touchstone.NamingPolicy) (metrics []Metric, err error) {
+	t := reflect.TypeOf(prototype)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf(
+			"'%T' is not a valid bundle prototype.  It is not a struct or pointer to struct.",
+			prototype,
+		)
+	}
+
+	help := bundleHelp(t)
+	for i := 0; i < t.NumField(); i++ {
+		f := metricField(t.Field(i))
+		if f.skip() {
+			continue
+		}
+
+		f = f.withHelp(help)
+		opts, labelNames, fieldErr := f.newOpts()
+		err = multierr.Append(err, fieldErr)
+		if opts == nil || fieldErr != nil {
+			continue
+		}
+
+		metrics = append(metrics, describe(f.Name, opts, labelNames, defaults, policy))
+	}
+
+	return
+}
+
+// Descriptors converts a bundle prototype into prometheus descriptors, in field order,
+// without creating or registering any metrics.  This allows a custom prometheus.Collector
+// to use a bundle's struct tags as the source of its Describe method.
+//
+// The prototype is interpreted the same way as with Describe.  Since no Factory is
+// involved, only namespaces and subsystems given by struct tags are applied.
+func Descriptors(prototype interface{}) (descs []*prometheus.Desc, err error) {
+	var metrics []Metric
+	metrics, err = Describe(prototype, prometheus.Opts{}, nil)
+	if err != nil {
+		return
+	}
+
+	descs = make([]*prometheus.Desc, 0, len(metrics))
+	for _, m := range metrics {
+		descs = append(descs, prometheus.NewDesc(m.Name, m.Help, m.LabelNames, nil))
+	}
+
+	return
+}
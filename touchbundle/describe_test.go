@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package touchbundle
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+)
+
+type DescribeSuite struct {
+	suite.Suite
+}
+
+func (suite *DescribeSuite) TestInvalid() {
+	_, err := Describe(nil, prometheus.Opts{}, nil)
+	suite.Error(err)
+
+	_, err = Describe(123, prometheus.Opts{}, nil)
+	suite.Error(err)
+
+	_, err = Describe(struct {
+		Bad prometheus.Observer `type:"nosuch"`
+	}{}, prometheus.Opts{}, nil)
+
+	suite.Error(err)
+}
+
+func (suite *DescribeSuite) TestDescribe() {
+	type bundle struct {
+		RequestCount *prometheus.CounterVec `labelNames:"code" help:"the requests"`
+		InFlight     prometheus.Gauge       `namespace:"custom"`
+		Duration     prometheus.ObserverVec `labelNames:"code" buckets:"1,2,3"`
+		Latency      prometheus.Summary     `name:"*_seconds"`
+		Ignored      prometheus.Counter     `touchstone:"-"`
+		NotAMetric   string
+		unexported   prometheus.Counter //nolint:unused
+	}
+
+	for _, prototype := range []interface{}{bundle{}, (*bundle)(nil)} {
+		metrics, err := Describe(prototype, prometheus.Opts{Namespace: "xmidt", Subsystem: "test"}, nil)
+		suite.Require().NoError(err)
+		suite.Equal(
+			[]Metric{
+				{Field: "RequestCount", Name: "xmidt_test_request_count", Type: TypeCounter, Help: "the requests", LabelNames: []string{"code"}},
+				{Field: "InFlight", Name: "custom_test_in_flight", Type: TypeGauge},
+				{Field: "Duration", Name: "xmidt_test_duration", Type: TypeHistogram, LabelNames: []string{"code"}},
+				{Field: "Latency", Name: "xmidt_test_latency_seconds", Type: TypeSummary},
+			},
+			metrics,
+		)
+	}
+}
+
+func (suite *DescribeSuite) TestDescribeNamingPolicy() {
+	type bundle struct {
+		RequestCount *prometheus.CounterVec `labelNames:"code"`
+		InFlight     prometheus.Gauge
+	}
+
+	metrics, err := Describe(bundle{}, prometheus.Opts{Namespace: "xmidt"}, touchstone.Naming{
+		Prefix: "p",
+		Rename: map[string]string{"in_flight": "requests_in_flight"},
+	})
+
+	suite.Require().NoError(err)
+	suite.Require().Len(metrics, 2)
+	suite.Equal("xmidt_p_request_count", metrics[0].Name)
+	suite.Equal("xmidt_requests_in_flight", metrics[1].Name)
+}
+
+func (suite *DescribeSuite) TestDescriptors() {
+	_, err := Descriptors(123)
+	suite.Error(err)
+
+	type bundle struct {
+		RequestCount *prometheus.CounterVec `labelNames:"code,method" help:"the requests"`
+		InFlight     prometheus.Gauge       `namespace:"custom"`
+		Ignored      prometheus.Counter     `touchstone:"-"`
+	}
+
+	descs, err := Descriptors((*bundle)(nil))
+	suite.Require().NoError(err)
+	suite.Require().Len(descs, 2)
+	suite.Equal(
+		prometheus.NewDesc("request_count", "the requests", []string{"code", "method"}, nil).String(),
+		descs[0].String(),
+	)
+
+	suite.Equal(
+		prometheus.NewDesc("custom_in_flight", "", nil, nil).String(),
+		descs[1].String(),
+	)
+}
+
+func TestDescribe(t *testing.T) {
+	suite.Run(t, new(DescribeSuite))
+}
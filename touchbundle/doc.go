@@ -5,4 +5,12 @@
 // from configuration.  The basic idea is that a single description of metrics
 // can be used to both (1) create application metrics, and (2) verify those
 // metrics for tests.
+//
+// A bundle may also contain touchhttp.ServerInstrumenter and touchhttp.ClientInstrumenter
+// fields, which are created from the default touchhttp bundles.  This allows the entire
+// metrics surface of a service, custom and HTTP, to be declared in one struct.
+// The TagLatencyClass tag selects a touchhttp.LatencyClass for such fields.
+//
+// Metrics may also be declared in application configuration, as YAML or JSON
+// Definitions, rather than with struct tags.  See ConfigBundle and ProvideFromConfig.
 package touchbundle
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbundle
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// UnitSeconds is the TagDurationUnit value indicating that durations are
+	// observed in seconds.  This is the default, as it is the prometheus convention.
+	UnitSeconds = "s"
+
+	// UnitMilliseconds is the TagDurationUnit value indicating that durations
+	// are observed in milliseconds.
+	UnitMilliseconds = "ms"
+)
+
+// DurationObserver is a prometheus.Observer that can also observe time.Duration values,
+// converting them to a fixed unit.  Fields of this type are populated with a histogram
+// or summary, as with prometheus.Observer fields.
+type DurationObserver interface {
+	prometheus.Observer
+
+	// ObserveDuration observes the given duration, converted to this observer's unit.
+	ObserveDuration(time.Duration)
+}
+
+type durationObserver struct {
+	prometheus.Observer
+	unit time.Duration
+}
+
+func (do durationObserver) ObserveDuration(d time.Duration) {
+	do.Observe(float64(d) / float64(do.unit))
+}
+
+// NewDurationObserver decorates an Observer so that durations are observed in the
+// given unit, e.g. time.Second or time.Millisecond.
+func NewDurationObserver(o prometheus.Observer, unit time.Duration) DurationObserver {
+	return durationObserver{
+		Observer: o,
+		unit:     unit,
+	}
+}
+
+// DurationObserverVec is a prometheus.ObserverVec whose children are DurationObservers.
+// Fields of this type are populated with a histogram or summary vector, as with
+// prometheus.ObserverVec fields.
+type DurationObserverVec interface {
+	prometheus.ObserverVec
+
+	// DurationWith returns the DurationObserver for the given labels.  Like the
+	// With method, this method panics if the labels are invalid.
+	DurationWith(prometheus.Labels) DurationObserver
+
+	// DurationWithLabelValues returns the DurationObserver for the given label values.
+	// Like the WithLabelValues method, this method panics if the label values are invalid.
+	DurationWithLabelValues(...string) DurationObserver
+}
+
+type durationObserverVec struct {
+	prometheus.ObserverVec
+	unit time.Duration
+}
+
+func (dov durationObserverVec) DurationWith(l prometheus.Labels) DurationObserver {
+	return NewDurationObserver(dov.With(l), dov.unit)
+}
+
+func (dov durationObserverVec) DurationWithLabelValues(lvs ...string) DurationObserver {
+	return NewDurationObserver(dov.WithLabelValues(lvs...), dov.unit)
+}
+
+// NewDurationObserverVec decorates an ObserverVec so that its children observe durations
+// in the given unit, e.g. time.Second or time.Millisecond.
+func NewDurationObserverVec(ov prometheus.ObserverVec, unit time.Duration) DurationObserverVec {
+	return durationObserverVec{
+		ObserverVec: ov,
+		unit:        unit,
+	}
+}
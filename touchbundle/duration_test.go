@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbundle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+)
+
+// sampleSum returns the sum of observations of a histogram or summary, which
+// may be decorated as a DurationObserver.
+func sampleSum(o prometheus.Observer) float64 {
+	if do, ok := o.(durationObserver); ok {
+		o = do.Observer
+	}
+
+	var m dto.Metric
+	if err := o.(prometheus.Metric).Write(&m); err != nil {
+		panic(err)
+	}
+
+	if m.Histogram != nil {
+		return m.Histogram.GetSampleSum()
+	}
+
+	return m.Summary.GetSampleSum()
+}
+
+type DurationSuite struct {
+	suite.Suite
+}
+
+func (suite *DurationSuite) TestNewDurationObserver() {
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test"})
+	do := NewDurationObserver(h, time.Millisecond)
+	do.ObserveDuration(250 * time.Microsecond)
+	do.Observe(1.0)
+	suite.Equal(1.25, sampleSum(h))
+}
+
+func (suite *DurationSuite) TestNewDurationObserverVec() {
+	hv := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test"}, []string{"code"})
+	dov := NewDurationObserverVec(hv, time.Second)
+	dov.DurationWith(prometheus.Labels{"code": "200"}).ObserveDuration(time.Second)
+	dov.DurationWithLabelValues("200").ObserveDuration(500 * time.Millisecond)
+	suite.Equal(1.5, sampleSum(hv.WithLabelValues("200")))
+}
+
+func TestDuration(t *testing.T) {
+	suite.Run(t, new(DurationSuite))
+}
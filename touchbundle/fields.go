@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbundle
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+)
+
+const (
+	// MetricsGroup is the fx value group into which ProvideFields emits every
+	// metric of a bundle as a prometheus.Collector.
+	MetricsGroup = "touchbundle.metrics"
+)
+
+var (
+	// ErrNoSuchField indicates that a field name did not refer to a field that
+	// ProvideFields emits.
+	ErrNoSuchField = errors.New("No such bundle field")
+
+	collectorType = reflect.TypeOf((*prometheus.Collector)(nil)).Elem()
+)
+
+// fieldNames returns the names of the fields of a bundle struct that ProvideFields emits.
+func fieldNames(structType reflect.Type) (names []string) {
+	for i := 0; i < structType.NumField(); i++ {
+		if f := metricField(structType.Field(i)); !f.skip() {
+			names = append(names, f.Name)
+		}
+	}
+
+	return
+}
+
+// FieldName returns the fx component name that ProvideFields uses for a bundle field,
+// which is the name of the bundle's struct type and the field name separated by a dot,
+// e.g. "ServerMetrics.Requests".  The prototype must be a named struct type or a
+// pointer to one, and can be nil.
+func FieldName(prototype interface{}, field string) (string, error) {
+	_, structType, err := prototypeTypes(prototype)
+	if err != nil {
+		return "", err
+	}
+
+	if len(structType.Name()) == 0 {
+		return "", fmt.Errorf("'%T' is not a named struct type", prototype)
+	}
+
+	if f, ok := structType.FieldByName(field); !ok || metricField(f).skip() || len(f.Index) > 1 {
+		return "", fmt.Errorf("%w: %s.%s", ErrNoSuchField, structType.Name(), field)
+	}
+
+	return structType.Name() + "." + field, nil
+}
+
+// FieldTags returns the fx name tags for the fields that ProvideFields emits, keyed by
+// field name, e.g. `name:"ServerMetrics.Requests"`.  These tags can be passed to
+// fx.ParamTags, so that consumers need not hardcode component names.  See ParamTags.
+func FieldTags(prototype interface{}) (map[string]string, error) {
+	_, structType, err := prototypeTypes(prototype)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string)
+	for _, field := range fieldNames(structType) {
+		name, err := FieldName(prototype, field)
+		if err != nil {
+			return nil, err
+		}
+
+		tags[field] = fmt.Sprintf(`name:"%s"`, name)
+	}
+
+	return tags, nil
+}
+
+// ParamTags returns the fx name tags for the given fields, in order, suitable for
+// fx.ParamTags:
+//
+//	tags, err := touchbundle.ParamTags(ServerMetrics{}, "Requests", "Errors")
+//	if err != nil {
+//	  // handle the error, e.g. a misspelled field
+//	}
+//
+//	fx.Provide(
+//	  fx.Annotate(
+//	    NewServer, // func(prometheus.Counter, *prometheus.CounterVec) *Server
+//	    fx.ParamTags(tags...),
+//	  ),
+//	)
+func ParamTags(prototype interface{}, fields ...string) ([]string, error) {
+	tags := make([]string, 0, len(fields))
+	for _, field := range fields {
+		name, err := FieldName(prototype, field)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, fmt.Sprintf(`name:"%s"`, name))
+	}
+
+	return tags, nil
+}
+
+// ProvideFields emits a bundle as Provide does, and also emits each of the bundle's
+// fields as a separate, named component.  The component names are given by FieldName,
+// and FieldTags and ParamTags produce the corresponding fx tags.  In addition, every
+// field that is a prometheus.Collector is emitted into the MetricsGroup value group.
+//
+// The prototype must be a named struct type or a pointer to one.  Any options are
+// passed to Provide.
+func ProvideFields(prototype interface{}, opts ...ProvideOption) fx.Option {
+	componentType, structType, err := prototypeTypes(prototype)
+	if err != nil {
+		return fx.Error(err)
+	}
+
+	tags, err := FieldTags(prototype)
+	if err != nil {
+		return fx.Error(err)
+	}
+
+	options := []fx.Option{Provide(prototype, opts...)}
+	for _, field := range fieldNames(structType) {
+		field := field
+		sf, _ := structType.FieldByName(field)
+		extract := reflect.MakeFunc(
+			reflect.FuncOf([]reflect.Type{componentType}, []reflect.Type{sf.Type}, false),
+			func(in []reflect.Value) []reflect.Value {
+				return []reflect.Value{reflect.Indirect(in[0]).FieldByName(field)}
+			},
+		)
+
+		options = append(options, fx.Provide(
+			fx.Annotate(extract.Interface(), fx.ResultTags(tags[field])),
+		))
+	}
+
+	collectors := reflect.MakeFunc(
+		reflect.FuncOf([]reflect.Type{componentType}, []reflect.Type{reflect.TypeOf([]prometheus.Collector{})}, false),
+		func(in []reflect.Value) []reflect.Value {
+			var (
+				bundle = reflect.Indirect(in[0])
+				cs     []prometheus.Collector
+			)
+
+			for _, field := range fieldNames(structType) {
+				v := bundle.FieldByName(field)
+				if v.Type().Implements(collectorType) && !v.IsZero() {
+					cs = append(cs, v.Interface().(prometheus.Collector))
+				}
+			}
+
+			return []reflect.Value{reflect.ValueOf(cs)}
+		},
+	)
+
+	options = append(options, fx.Provide(
+		fx.Annotate(collectors.Interface(), fx.ResultTags(fmt.Sprintf(`group:"%s,flatten"`, MetricsGroup))),
+	))
+
+	return fx.Options(options...)
+}
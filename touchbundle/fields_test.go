@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbundle
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone/touchhttp"
+	"go.uber.org/fx"
+)
+
+type fieldsBundle struct {
+	Requests prometheus.Counter     `help:"the total requests"`
+	Errors   *prometheus.CounterVec `labelNames:"code"`
+	Server   touchhttp.ServerInstrumenter
+	Ignored  prometheus.Gauge `touchstone:"-"`
+	internal prometheus.Gauge //nolint:unused
+}
+
+func (suite *BundleSuite) testFieldName() {
+	name, err := FieldName(fieldsBundle{}, "Requests")
+	suite.NoError(err)
+	suite.Equal("fieldsBundle.Requests", name)
+
+	name, err = FieldName((*fieldsBundle)(nil), "Server")
+	suite.NoError(err)
+	suite.Equal("fieldsBundle.Server", name)
+
+	for _, field := range []string{"Ignored", "internal", "NoSuch"} {
+		_, err = FieldName(fieldsBundle{}, field)
+		suite.ErrorIs(err, ErrNoSuchField, field)
+	}
+
+	_, err = FieldName(struct{ C prometheus.Counter }{}, "C")
+	suite.Error(err, "unnamed struct types are not allowed")
+
+	_, err = FieldName(123, "C")
+	suite.Error(err)
+}
+
+func (suite *BundleSuite) testFieldTags() {
+	tags, err := FieldTags(fieldsBundle{})
+	suite.NoError(err)
+	suite.Equal(
+		map[string]string{
+			"Requests": `name:"fieldsBundle.Requests"`,
+			"Errors":   `name:"fieldsBundle.Errors"`,
+			"Server":   `name:"fieldsBundle.Server"`,
+		},
+		tags,
+	)
+
+	_, err = FieldTags(nil)
+	suite.Error(err)
+}
+
+func (suite *BundleSuite) testParamTags() {
+	tags, err := ParamTags(fieldsBundle{}, "Errors", "Requests")
+	suite.NoError(err)
+	suite.Equal([]string{`name:"fieldsBundle.Errors"`, `name:"fieldsBundle.Requests"`}, tags)
+
+	_, err = ParamTags(fieldsBundle{}, "Requests", "NoSuch")
+	suite.ErrorIs(err, ErrNoSuchField)
+}
+
+func (suite *BundleSuite) testProvideFields() {
+	for _, prototype := range []interface{}{fieldsBundle{}, (*fieldsBundle)(nil)} {
+		tags, err := ParamTags(prototype, "Requests", "Errors", "Server")
+		suite.Require().NoError(err)
+
+		var (
+			requests   prometheus.Counter
+			errors     *prometheus.CounterVec
+			server     touchhttp.ServerInstrumenter
+			collectors []prometheus.Collector
+		)
+
+		app := suite.newTestApp(
+			ProvideFields(prototype),
+			fx.Invoke(
+				fx.Annotate(
+					func(r prometheus.Counter, e *prometheus.CounterVec, s touchhttp.ServerInstrumenter) {
+						requests, errors, server = r, e, s
+					},
+					fx.ParamTags(tags...),
+				),
+			),
+			fx.Invoke(
+				fx.Annotate(
+					func(cs []prometheus.Collector) {
+						collectors = cs
+					},
+					fx.ParamTags(`group:"`+MetricsGroup+`"`),
+				),
+			),
+		)
+
+		app.RequireStart()
+		suite.NotNil(requests)
+		suite.NotNil(errors)
+		suite.NotZero(server)
+		suite.ElementsMatch([]prometheus.Collector{requests, errors}, collectors)
+		app.RequireStop()
+	}
+
+	suite.Run("InvalidPrototype", func() {
+		suite.Error(suite.newApp(ProvideFields(123)).Err())
+		suite.Error(suite.newApp(ProvideFields(struct{ C prometheus.Counter }{})).Err())
+	})
+}
+
+func (suite *BundleSuite) TestFields() {
+	suite.Run("FieldName", suite.testFieldName)
+	suite.Run("FieldTags", suite.testFieldTags)
+	suite.Run("ParamTags", suite.testParamTags)
+	suite.Run("ProvideFields", suite.testProvideFields)
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbundle
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// HelpProvider is implemented by bundles that supply default help text for their metric
+// fields, keyed by struct field name.  A field's TagHelp, if present, takes precedence
+// over the help from this interface.
+//
+// Implementations are normally generated from the doc comments of the bundle's fields
+// by the touchbundle-help command, so that help text does not have to be duplicated
+// between comments and struct tags:
+//
+//	//go:generate go run github.com/xmidt-org/touchstone/cmd/touchbundle-help -type MyBundle
+type HelpProvider interface {
+	// BundleHelp returns the help text for the bundle's fields.  This method
+	// is invoked on the zero value of the bundle's type.
+	BundleHelp() map[string]string
+}
+
+var helpProviderType = reflect.TypeOf((*HelpProvider)(nil)).Elem()
+
+// bundleHelp returns the default help text for a bundle struct type, which is
+// nil if the type does not implement HelpProvider.
+func bundleHelp(t reflect.Type) map[string]string {
+	if reflect.PointerTo(t).Implements(helpProviderType) {
+		return reflect.New(t).Interface().(HelpProvider).BundleHelp()
+	}
+
+	return nil
+}
+
+// withHelp returns a copy of this field whose TagHelp is defaulted from the given help.
+func (mf metricField) withHelp(help map[string]string) metricField {
+	if _, ok := mf.Tag.Lookup(TagHelp); !ok {
+		if h, ok := help[mf.Name]; ok {
+			mf.Tag = reflect.StructTag(fmt.Sprintf("%s %s:%s", mf.Tag, TagHelp, strconv.Quote(h)))
+		}
+	}
+
+	return mf
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+package touchbundle
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/zap"
+)
+
+type helpBundle struct {
+	Requests prometheus.Counter
+	Errors   prometheus.Counter `help:"from the tag"`
+	InFlight prometheus.Gauge
+}
+
+func (helpBundle) BundleHelp() map[string]string {
+	return map[string]string{
+		"Requests": `the "total" requests`,
+		"Errors":   "from the map",
+	}
+}
+
+type HelpSuite struct {
+	suite.Suite
+}
+
+func (suite *HelpSuite) TestDescribe() {
+	for _, prototype := range []interface{}{helpBundle{}, (*helpBundle)(nil)} {
+		metrics, err := Describe(prototype, prometheus.Opts{}, nil)
+		suite.Require().NoError(err)
+		suite.Require().Len(metrics, 3)
+		suite.Equal(`the "total" requests`, metrics[0].Help)
+		suite.Equal("from the tag", metrics[1].Help)
+		suite.Empty(metrics[2].Help)
+	}
+}
+
+func (suite *HelpSuite) TestPopulate() {
+	_, r, err := touchstone.New(touchstone.Config{})
+	suite.Require().NoError(err)
+
+	var b helpBundle
+	suite.Require().NoError(
+		Populate(touchstone.NewFactory(touchstone.Config{}, zap.L(), r), &b),
+	)
+
+	suite.Require().NotNil(b.Requests)
+	suite.Require().NotNil(b.Errors)
+	suite.Require().NotNil(b.InFlight)
+
+	suite.Contains(b.Requests.Desc().String(), `help: "the \"total\" requests"`)
+	suite.Contains(b.Errors.Desc().String(), `help: "from the tag"`)
+	suite.Equal(1, testutil.CollectAndCount(b.InFlight))
+}
+
+func TestHelp(t *testing.T) {
+	suite.Run(t, new(HelpSuite))
+}
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbundle
+
+import (
+	"reflect"
+
+	"github.com/xmidt-org/touchstone"
+	"github.com/xmidt-org/touchstone/touchhttp"
+)
+
+const (
+	// TagServer is the struct field tag specifying the value of the touchhttp.ServerLabel
+	// for a touchhttp.ServerInstrumenter field, e.g. `server:"servers.main"`.  If absent,
+	// or if empty, the instrumenter's metrics have no server label.  This tag is only
+	// valid for that field type.
+	TagServer = "server"
+
+	// TagClient is the struct field tag specifying the value of the touchhttp.ClientLabel
+	// for a touchhttp.ClientInstrumenter field, e.g. `client:"clients.auth"`.  If absent,
+	// or if empty, the instrumenter's metrics have no client label.  This tag is only
+	// valid for that field type.
+	TagClient = "client"
+
+	// TagLatencyClass is the struct field tag specifying the touchhttp.LatencyClass of
+	// the duration histogram of an HTTP instrumenter field, e.g. `latencyClass:"fast"`.
+	// This tag is only valid for instrumenter fields.
+	TagLatencyClass = "latencyClass"
+)
+
+var (
+	serverInstrumenterType = reflect.TypeOf(touchhttp.ServerInstrumenter{})
+	clientInstrumenterType = reflect.TypeOf(touchhttp.ClientInstrumenter{})
+
+	// instrumenterTagNames are the tags that describe a single metric, which
+	// have no meaning for an HTTP instrumenter.
+	instrumenterTagNames = append(
+		[]string{
+			TagNamespace, TagSubsystem, TagName, TagHelp, TagLabelNames, TagType,
+			TagDurationUnit, TagStates, TagStateLabel, TagReasons,
+		},
+		observerTagNames...,
+	)
+)
+
+// namesAndValues returns the extra label for an HTTP instrumenter, if the given
+// tag is present and not empty.
+func (mf metricField) namesAndValues(tagName, label string) []string {
+	if v := mf.Tag.Get(tagName); len(v) > 0 {
+		return []string{label, v}
+	}
+
+	return nil
+}
+
+// newInstrumenter creates a touchhttp instrumenter for this field using the default
+// touchhttp bundle.  If this field is not an instrumenter, this method returns
+// false and the field should be processed as a metric.
+func (mf metricField) newInstrumenter(factory touchstone.MetricFactory) (instrumenter interface{}, ok bool, err error) {
+	var (
+		cause error
+		lc    = touchhttp.LatencyClass(mf.Tag.Get(TagLatencyClass))
+	)
+
+	switch mf.Type {
+	case serverInstrumenterType:
+		err = mf.checkTagNotAllowed(err, TagClient)
+		instrumenter, cause = touchhttp.ServerBundle{LatencyClass: lc}.NewInstrumenter(
+			mf.namesAndValues(TagServer, touchhttp.ServerLabel)...,
+		)(factory)
+
+	case clientInstrumenterType:
+		err = mf.checkTagNotAllowed(err, TagServer)
+		instrumenter, cause = touchhttp.ClientBundle{LatencyClass: lc}.NewInstrumenter(
+			mf.namesAndValues(TagClient, touchhttp.ClientLabel)...,
+		)(factory)
+
+	default:
+		return
+	}
+
+	ok = true
+	err = mf.checkTagNotAllowed(err, instrumenterTagNames...)
+	err = mf.appendError(err, cause)
+	return
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
 	"go.uber.org/multierr"
 )
 
@@ -68,12 +69,67 @@ const (
 	// vector types.
 	TagLabelNames = "labelNames"
 
+	// TagLabelValues specifies the expected values of each label of a vector metric.
+	// The format of this tag is a comma-delimited list with one entry per label, in the
+	// same order as TagLabelNames.  Each entry holds that label's values separated by '|',
+	// e.g. labelNames:"code,method" labelValues:"200|404,GET|POST".  Internal whitespace
+	// is allowed.  This tag is only valid for vector types.
+	TagLabelValues = "labelValues"
+
+	// TagLazy is the struct field tag controlling whether the children of a vector metric
+	// are created lazily, on first use, which is the default.  Setting this tag to "false"
+	// causes Populate to preinitialize a child for every combination of TagLabelValues,
+	// so that the expected series exist before they are used.  See touchstone.Preinitialize.
+	// When this tag is "false", TagLabelValues is required.  This tag is only valid for
+	// vector types.
+	TagLazy = "lazy"
+
 	// TagType is the struct field tag indicating the type of metric, e.g. histogram
 	// or summary.  This tag is only valid when the struct field type doesn't
 	// uniquely specify a metric, e.g. prometheus.Observer.  If the struct field type
 	// does specify a metric, this tag cannot be supplied or an error is raised.
 	TagType = "type"
 
+	// TagDurationUnit is the struct field tag specifying the unit in which a DurationObserver
+	// or DurationObserverVec observes durations, or in which a *touchstone.DurationGauge
+	// reports them.  The value must be UnitSeconds or UnitMilliseconds.  If absent,
+	// UnitSeconds is used.  This tag is only valid for those field types.
+	TagDurationUnit = "durationUnit"
+
+	// TagStates is the struct field tag specifying the comma-delimited states of a
+	// *touchstone.StateSet field, e.g. "idle,connecting,connected".  The first state
+	// is the initial state.  This tag is required for, and only valid for, that field type.
+	TagStates = "states"
+
+	// TagStateLabel is the struct field tag specifying the name of the label whose values
+	// are the states of a *touchstone.StateSet field.  If absent, DefaultStateLabel is used.
+	// This tag is only valid for that field type.
+	TagStateLabel = "stateLabel"
+
+	// TagReasons is the struct field tag specifying the comma-delimited failure reasons
+	// declared by a *touchstone.Outcome field, e.g. "timeout,refused".  If absent, the
+	// outcome records each reason as is.  This tag is only valid for that field type.
+	TagReasons = "reasons"
+
+	// TagLastError is the struct field tag controlling whether a *touchstone.Outcome field
+	// also maintains a gauge describing its most recent failure.  Setting this tag to "true"
+	// creates that gauge, named and described by touchstone.LastErrorOpts, and tracks it with
+	// touchstone.Outcome.TrackLastError.  This tag is only valid for that field type.
+	TagLastError = "lastError"
+
+	// TagFactory is the struct field tag naming the factory that creates the field's
+	// metric, e.g. factory:"debug".  Within an fx.App, this is the name of a
+	// *touchstone.Factory component.  Outside of fx, the named factories are passed to
+	// PopulateWithFactories.  If absent, the bundle's factory is used.
+	//
+	// This allows a single bundle to mix registries, e.g. with most metrics public and a
+	// few on an internal, high-cardinality registry.
+	TagFactory = "factory"
+
+	// DefaultStateLabel is the label name used for the states of a *touchstone.StateSet
+	// field when there is no TagStateLabel.
+	DefaultStateLabel = "state"
+
 	// TypeHistogram is the TagType value indicating that the metric is a histogram
 	// or histogram vector.
 	TypeHistogram = "histogram"
@@ -120,6 +176,12 @@ var (
 	observerType     = reflect.TypeOf((*prometheus.Observer)(nil)).Elem()
 	observerVecType  = reflect.TypeOf((*prometheus.ObserverVec)(nil)).Elem()
 
+	durationObserverType    = reflect.TypeOf((*DurationObserver)(nil)).Elem()
+	durationObserverVecType = reflect.TypeOf((*DurationObserverVec)(nil)).Elem()
+	durationGaugeType       = reflect.TypeOf((*touchstone.DurationGauge)(nil))
+	stateSetType            = reflect.TypeOf((*touchstone.StateSet)(nil))
+	outcomeType             = reflect.TypeOf((*touchstone.Outcome)(nil))
+
 	histogramTagNames = []string{TagBuckets}
 	summaryTagNames   = []string{TagObjectives, TagMaxAge, TagAgeBuckets, TagBufCap}
 	observerTagNames  = append(
@@ -150,13 +212,7 @@ func (mf metricField) name() string {
 // if any are present, this method returns an error.
 func (mf metricField) newCounterOpts() (opts prometheus.CounterOpts, err error) {
 	err = mf.checkTagNotAllowed(err, observerTagNames...)
-	opts = prometheus.CounterOpts{
-		Name:      mf.name(),
-		Help:      mf.help(),
-		Namespace: mf.namespace(),
-		Subsystem: mf.subsystem(),
-	}
-
+	opts = mf.metricOpts().counterOpts()
 	return
 }
 
@@ -165,47 +221,42 @@ func (mf metricField) newCounterOpts() (opts prometheus.CounterOpts, err error)
 // if any are present, this method returns an error.
 func (mf metricField) newGaugeOpts() (opts prometheus.GaugeOpts, err error) {
 	err = mf.checkTagNotAllowed(err, observerTagNames...)
-	opts = prometheus.GaugeOpts{
-		Name:      mf.name(),
-		Help:      mf.help(),
-		Namespace: mf.namespace(),
-		Subsystem: mf.subsystem(),
-	}
-
+	opts = mf.metricOpts().gaugeOpts()
 	return
 }
 
 func (mf metricField) newHistogramOpts() (opts prometheus.HistogramOpts, err error) {
-	opts = prometheus.HistogramOpts{
-		Name:      mf.name(),
-		Help:      mf.help(),
-		Namespace: mf.namespace(),
-		Subsystem: mf.subsystem(),
-	}
-
-	var parseErr error
-	opts.Buckets, parseErr = mf.buckets()
-	err = multierr.Append(err, parseErr)
-
+	buckets, err := mf.buckets()
+	opts = mf.metricOpts().histogramOpts(buckets)
 	return
 }
 
 func (mf metricField) newSummaryOpts() (opts prometheus.SummaryOpts, err error) {
-	opts = prometheus.SummaryOpts{
-		Name:      mf.name(),
-		Help:      mf.help(),
-		Namespace: mf.namespace(),
-		Subsystem: mf.subsystem(),
-	}
-
-	opts.Objectives, err = mf.objectives(err)
-	opts.MaxAge, err = mf.maxAge(err)
-	opts.AgeBuckets, err = mf.ageBuckets(err)
-	opts.BufCap, err = mf.bufCap(err)
+	var (
+		objectives map[float64]float64
+		maxAge     time.Duration
+		ageBuckets uint32
+		bufCap     uint32
+	)
 
+	objectives, err = mf.objectives(err)
+	maxAge, err = mf.maxAge(err)
+	ageBuckets, err = mf.ageBuckets(err)
+	bufCap, err = mf.bufCap(err)
+	opts = mf.metricOpts().summaryOpts(objectives, maxAge, ageBuckets, bufCap)
 	return
 }
 
+// metricOpts returns the options common to every kind of metric for this field.
+func (mf metricField) metricOpts() metricOpts {
+	return metricOpts{
+		namespace: mf.namespace(),
+		subsystem: mf.subsystem(),
+		name:      mf.name(),
+		help:      mf.help(),
+	}
+}
+
 func (mf metricField) newObserverOpts() (opts interface{}, err error) {
 	var (
 		metricType        = mf.Tag.Get(TagType)
@@ -246,58 +297,224 @@ func (mf metricField) newObserverOpts() (opts interface{}, err error) {
 // field is of a type supported by touchstone.
 func (mf metricField) newOpts() (opts interface{}, labelNames []string, err error) {
 	switch mf.Type {
-	case counterType:
-		opts, err = mf.newCounterOpts()
-		err = mf.checkTagNotAllowed(err, TagType, TagLabelNames)
+	case counterType, counterVecType, gaugeType, gaugeVecType:
+		opts, labelNames, err = mf.newCounterOrGaugeOpts()
 
-	case counterVecType:
-		opts, err = mf.newCounterOpts()
-		err = mf.checkTagNotAllowed(err, TagType)
-		labelNames, err = mf.labelNames(err)
+	case histogramType, histogramVecType, summaryType, summaryVecType:
+		opts, labelNames, err = mf.newHistogramOrSummaryOpts()
 
-	case gaugeType:
-		opts, err = mf.newGaugeOpts()
-		err = mf.checkTagNotAllowed(err, TagType, TagLabelNames)
+	case observerType, observerVecType, durationObserverType, durationObserverVecType:
+		opts, labelNames, err = mf.newObserverFieldOpts()
 
-	case gaugeVecType:
+	case durationGaugeType, stateSetType, outcomeType:
+		opts, labelNames, err = mf.newCompositeOpts()
+	}
+
+	if opts != nil {
+		err = mf.checkOtherTypeTags(labelNames, err)
+	}
+
+	return
+}
+
+// vectorLabelNames parses the label names of a vector field, or checks that a field
+// which is not a vector has none.
+func (mf metricField) vectorLabelNames(appendErr error, vector bool) ([]string, error) {
+	if vector {
+		return mf.labelNames(appendErr)
+	}
+
+	return nil, mf.checkTagNotAllowed(appendErr, TagLabelNames)
+}
+
+// newCounterOrGaugeOpts creates the options of counter and gauge fields, and their vectors.
+func (mf metricField) newCounterOrGaugeOpts() (opts interface{}, labelNames []string, err error) {
+	if mf.Type == counterType || mf.Type == counterVecType {
+		opts, err = mf.newCounterOpts()
+	} else {
 		opts, err = mf.newGaugeOpts()
-		err = mf.checkTagNotAllowed(err, TagType)
-		labelNames, err = mf.labelNames(err)
+	}
 
-	case histogramType:
-		opts, err = mf.newHistogramOpts()
-		err = mf.checkTagNotAllowed(err, TagType, TagLabelNames)
-		err = mf.checkTagNotAllowed(err, summaryTagNames...)
+	err = mf.checkTagNotAllowed(err, TagType)
+	labelNames, err = mf.vectorLabelNames(err, mf.Type == counterVecType || mf.Type == gaugeVecType)
+	return
+}
 
-	case histogramVecType:
+// newHistogramOrSummaryOpts creates the options of histogram and summary fields, and their
+// vectors.  The tags of the other kind of observer are not allowed.
+func (mf metricField) newHistogramOrSummaryOpts() (opts interface{}, labelNames []string, err error) {
+	if mf.Type == histogramType || mf.Type == histogramVecType {
 		opts, err = mf.newHistogramOpts()
 		err = mf.checkTagNotAllowed(err, TagType)
 		err = mf.checkTagNotAllowed(err, summaryTagNames...)
-		labelNames, err = mf.labelNames(err)
-
-	case summaryType:
-		opts, err = mf.newSummaryOpts()
-		err = mf.checkTagNotAllowed(err, TagType, TagLabelNames)
-		err = mf.checkTagNotAllowed(err, histogramTagNames...)
-
-	case summaryVecType:
+	} else {
 		opts, err = mf.newSummaryOpts()
 		err = mf.checkTagNotAllowed(err, TagType)
 		err = mf.checkTagNotAllowed(err, histogramTagNames...)
-		labelNames, err = mf.labelNames(err)
+	}
+
+	labelNames, err = mf.vectorLabelNames(err, mf.Type == histogramVecType || mf.Type == summaryVecType)
+	return
+}
+
+// newObserverFieldOpts creates the options of observer and duration observer fields, and
+// their vectors, which may be either histograms or summaries.
+func (mf metricField) newObserverFieldOpts() (opts interface{}, labelNames []string, err error) {
+	opts, err = mf.newObserverOpts()
+	labelNames, err = mf.vectorLabelNames(err, mf.Type == observerVecType || mf.Type == durationObserverVecType)
+	if mf.Type == durationObserverType || mf.Type == durationObserverVecType {
+		_, err = mf.durationUnit(err)
+	}
+
+	return
+}
+
+// newCompositeOpts creates the options of the touchstone metrics that are built on a
+// prometheus metric: duration gauges, state sets, and outcomes.
+func (mf metricField) newCompositeOpts() (opts interface{}, labelNames []string, err error) {
+	switch mf.Type {
+	case durationGaugeType:
+		opts, err = mf.newGaugeOpts()
+		err = mf.checkTagNotAllowed(err, TagType, TagLabelNames)
+		_, err = mf.durationUnit(err)
+
+	case stateSetType:
+		opts, err = mf.newGaugeOpts()
+		err = mf.checkTagNotAllowed(err, TagType, TagLabelNames)
+		_, err = mf.states(err)
+		labelNames = []string{mf.stateLabel()}
+
+	case outcomeType:
+		opts, err = mf.newCounterOpts()
+		err = mf.checkTagNotAllowed(err, TagType, TagLabelNames, TagLabelValues, TagLazy)
+		_, err = mf.lastError(err)
+		labelNames = []string{touchstone.OutcomeLabel, touchstone.ReasonLabel}
+	}
+
+	return
+}
+
+// checkOtherTypeTags checks that the tags which only apply to other types of metrics are
+// not present, and validates the label values of vectors.
+func (mf metricField) checkOtherTypeTags(labelNames []string, appendErr error) (err error) {
+	err = appendErr
+	if mf.Type != durationObserverType && mf.Type != durationObserverVecType && mf.Type != durationGaugeType {
+		err = mf.checkTagNotAllowed(err, TagDurationUnit)
+	}
+
+	if mf.Type != stateSetType {
+		err = mf.checkTagNotAllowed(err, TagStates, TagStateLabel)
+	}
+
+	if mf.Type != outcomeType {
+		err = mf.checkTagNotAllowed(err, TagReasons, TagLastError)
+	}
+
+	err = mf.checkTagNotAllowed(err, TagServer, TagClient, TagLatencyClass)
+	if mf.Type != stateSetType && mf.Type != outcomeType && len(labelNames) > 0 {
+		_, err = mf.labelSets(labelNames, err)
+	} else {
+		err = mf.checkTagNotAllowed(err, TagLabelValues, TagLazy)
+	}
+
+	return
+}
+
+// states parses the TagStates field tag, which is required for state sets.
+func (mf metricField) states(appendErr error) (values []string, err error) {
+	err = appendErr
+	for _, s := range strings.Split(mf.Tag.Get(TagStates), ",") {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			values = append(values, s)
+		}
+	}
+
+	if len(values) == 0 {
+		err = multierr.Append(err,
+			mf.fieldErrorf("tag '%s' is required and cannot be empty for state sets", TagStates),
+		)
+	}
+
+	return
+}
+
+// reasons parses the optional TagReasons field tag of an outcome.
+func (mf metricField) reasons() (values []string) {
+	for _, r := range strings.Split(mf.Tag.Get(TagReasons), ",") {
+		if r = strings.TrimSpace(r); len(r) > 0 {
+			values = append(values, r)
+		}
+	}
+
+	return
+}
+
+// lastError parses the optional TagLastError field tag of an outcome.
+func (mf metricField) lastError(appendErr error) (enabled bool, err error) {
+	err = appendErr
+	if v, ok := mf.Tag.Lookup(TagLastError); ok {
+		var parseErr error
+		enabled, parseErr = strconv.ParseBool(v)
+		err = mf.appendError(err, parseErr)
+	}
+
+	return
+}
 
-	case observerType:
-		opts, err = mf.newObserverOpts()
-		err = mf.checkTagNotAllowed(err, TagLabelNames)
+// factoryName returns the name of the factory selected by any TagFactory field tag.
+func (mf metricField) factoryName() (string, bool) {
+	return mf.Tag.Lookup(TagFactory)
+}
 
-	case observerVecType:
-		opts, err = mf.newObserverOpts()
-		labelNames, err = mf.labelNames(err)
+// stateLabel returns the label name for the states of a state set.
+func (mf metricField) stateLabel() string {
+	if v := mf.Tag.Get(TagStateLabel); len(v) > 0 {
+		return v
+	}
+
+	return DefaultStateLabel
+}
+
+// durationUnit parses any TagDurationUnit field tag, defaulting to seconds.
+func (mf metricField) durationUnit(appendErr error) (unit time.Duration, err error) {
+	err = appendErr
+	switch v := mf.Tag.Get(TagDurationUnit); v {
+	case "", UnitSeconds:
+		unit = time.Second
+
+	case UnitMilliseconds:
+		unit = time.Millisecond
+
+	default:
+		err = multierr.Append(err,
+			mf.fieldErrorf("'%s' is not a valid duration unit", v),
+		)
 	}
 
 	return
 }
 
+// adapt converts a metric created from this field's options into the field's type.
+// Only the duration types require adapting.
+func (mf metricField) adapt(metric interface{}) interface{} {
+	switch mf.Type {
+	case durationObserverType:
+		unit, _ := mf.durationUnit(nil)
+		return NewDurationObserver(metric.(prometheus.Observer), unit)
+
+	case durationObserverVecType:
+		unit, _ := mf.durationUnit(nil)
+		return NewDurationObserverVec(metric.(prometheus.ObserverVec), unit)
+
+	case durationGaugeType:
+		unit, _ := mf.durationUnit(nil)
+		return touchstone.NewDurationGauge(metric.(prometheus.Gauge), unit)
+
+	default:
+		return metric
+	}
+}
+
 func (mf metricField) help() string {
 	return mf.Tag.Get(TagHelp)
 }
@@ -459,6 +676,92 @@ func (mf metricField) labelNames(appendErr error) (values []string, err error) {
 	return
 }
 
+// labelSets returns the combinations of TagLabelValues that should be preinitialized.
+// If the field is lazy, which is the default, this method returns no label sets
+// but still validates the tags.
+func (mf metricField) labelSets(labelNames []string, appendErr error) (sets []prometheus.Labels, err error) {
+	err = appendErr
+	lazy := true
+	if v, ok := mf.Tag.Lookup(TagLazy); ok {
+		var parseErr error
+		lazy, parseErr = strconv.ParseBool(v)
+		err = mf.appendError(err, parseErr)
+	}
+
+	v, ok := mf.Tag.Lookup(TagLabelValues)
+	if !ok {
+		if !lazy {
+			err = multierr.Append(err,
+				mf.fieldErrorf("tag '%s' is required when '%s' is false", TagLabelValues, TagLazy),
+			)
+		}
+
+		return
+	}
+
+	entries := strings.Split(v, ",")
+	if len(entries) != len(labelNames) {
+		err = multierr.Append(err,
+			mf.fieldErrorf("tag '%s' must have exactly one entry for each label name", TagLabelValues),
+		)
+
+		return
+	}
+
+	values, parseErr := mf.labelValues(labelNames, entries)
+	if parseErr != nil {
+		return nil, multierr.Append(err, parseErr)
+	}
+
+	if !lazy {
+		sets = labelCombinations(labelNames, values)
+	}
+
+	return
+}
+
+// labelValues parses the entries of the TagLabelValues tag, one for each label name,
+// into the values of each label.  Each entry is a list of values separated by '|'.
+func (mf metricField) labelValues(labelNames, entries []string) ([][]string, error) {
+	values := make([][]string, len(entries))
+	for i, entry := range entries {
+		for _, lv := range strings.Split(entry, "|") {
+			if lv = strings.TrimSpace(lv); len(lv) > 0 {
+				values[i] = append(values[i], lv)
+			}
+		}
+
+		if len(values[i]) == 0 {
+			return nil, mf.fieldErrorf("tag '%s' has no values for label '%s'", TagLabelValues, labelNames[i])
+		}
+	}
+
+	return values, nil
+}
+
+// labelCombinations produces every combination of the given values of each label.
+func labelCombinations(labelNames []string, values [][]string) []prometheus.Labels {
+	sets := []prometheus.Labels{{}}
+	for i, lvs := range values {
+		next := make([]prometheus.Labels, 0, len(sets)*len(lvs))
+		for _, set := range sets {
+			for _, lv := range lvs {
+				combination := make(prometheus.Labels, len(set)+1)
+				for k, v := range set {
+					combination[k] = v
+				}
+
+				combination[labelNames[i]] = lv
+				next = append(next, combination)
+			}
+		}
+
+		sets = next
+	}
+
+	return sets
+}
+
 func (mf metricField) fieldErrorf(format string, args ...interface{}) *FieldError {
 	return &FieldError{
 		Field:   reflect.StructField(mf),
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchbundle
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricOpts holds the options common to every kind of metric.  Both struct fields
+// and Definitions build their prometheus *Opts structs with it.
+type metricOpts struct {
+	namespace   string
+	subsystem   string
+	name        string
+	help        string
+	constLabels prometheus.Labels
+}
+
+func (mo metricOpts) counterOpts() prometheus.CounterOpts {
+	return prometheus.CounterOpts{
+		Namespace:   mo.namespace,
+		Subsystem:   mo.subsystem,
+		Name:        mo.name,
+		Help:        mo.help,
+		ConstLabels: mo.constLabels,
+	}
+}
+
+func (mo metricOpts) gaugeOpts() prometheus.GaugeOpts {
+	return prometheus.GaugeOpts{
+		Namespace:   mo.namespace,
+		Subsystem:   mo.subsystem,
+		Name:        mo.name,
+		Help:        mo.help,
+		ConstLabels: mo.constLabels,
+	}
+}
+
+func (mo metricOpts) histogramOpts(buckets []float64) prometheus.HistogramOpts {
+	return prometheus.HistogramOpts{
+		Namespace:   mo.namespace,
+		Subsystem:   mo.subsystem,
+		Name:        mo.name,
+		Help:        mo.help,
+		ConstLabels: mo.constLabels,
+		Buckets:     buckets,
+	}
+}
+
+func (mo metricOpts) summaryOpts(objectives map[float64]float64, maxAge time.Duration, ageBuckets, bufCap uint32) prometheus.SummaryOpts {
+	return prometheus.SummaryOpts{
+		Namespace:   mo.namespace,
+		Subsystem:   mo.subsystem,
+		Name:        mo.name,
+		Help:        mo.help,
+		ConstLabels: mo.constLabels,
+		Objectives:  objectives,
+		MaxAge:      maxAge,
+		AgeBuckets:  ageBuckets,
+		BufCap:      bufCap,
+	}
+}
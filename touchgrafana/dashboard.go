@@ -0,0 +1,364 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchgrafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+	"github.com/xmidt-org/touchstone/touchhttp"
+)
+
+const (
+	// DefaultRateInterval is the range used in rate() expressions when none is configured.
+	DefaultRateInterval = "5m"
+
+	// SchemaVersion is the Grafana dashboard schema version emitted by this package.
+	SchemaVersion = 39
+
+	// panelWidth is the width of each generated panel.  Grafana's grid is 24 units wide.
+	panelWidth = 6
+
+	// panelHeight is the height of each generated panel.
+	panelHeight = 8
+)
+
+// DefaultQuantiles are the latency quantiles charted when none are configured.
+var DefaultQuantiles = []float64{0.5, 0.9, 0.99}
+
+// Datasource refers to a Grafana datasource.
+type Datasource struct {
+	Type string `json:"type,omitempty"`
+	UID  string `json:"uid,omitempty"`
+}
+
+// GridPos is the position of a panel within a dashboard.
+type GridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Target is a single query within a panel.
+type Target struct {
+	Datasource   *Datasource `json:"datasource,omitempty"`
+	Expr         string      `json:"expr"`
+	LegendFormat string      `json:"legendFormat,omitempty"`
+	RefID        string      `json:"refId"`
+}
+
+// FieldDefaults holds the default field configuration for a panel.
+type FieldDefaults struct {
+	Unit string `json:"unit,omitempty"`
+}
+
+// FieldConfig holds the field configuration for a panel.
+type FieldConfig struct {
+	Defaults FieldDefaults `json:"defaults"`
+}
+
+// Panel is a single Grafana panel.  Rows are also represented as panels.
+type Panel struct {
+	ID          int          `json:"id"`
+	Type        string       `json:"type"`
+	Title       string       `json:"title"`
+	GridPos     GridPos      `json:"gridPos"`
+	Datasource  *Datasource  `json:"datasource,omitempty"`
+	Targets     []Target     `json:"targets,omitempty"`
+	FieldConfig *FieldConfig `json:"fieldConfig,omitempty"`
+	Collapsed   *bool        `json:"collapsed,omitempty"`
+}
+
+// Time is the default time range of a dashboard.
+type Time struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Dashboard is the Grafana dashboard model.  Only the subset of the model
+// needed by this package is represented.
+type Dashboard struct {
+	UID           string   `json:"uid,omitempty"`
+	Title         string   `json:"title"`
+	Tags          []string `json:"tags,omitempty"`
+	SchemaVersion int      `json:"schemaVersion"`
+	Editable      bool     `json:"editable"`
+	Time          Time     `json:"time"`
+	Panels        []Panel  `json:"panels"`
+}
+
+// Server describes the metrics of a single HTTP server to chart.
+type Server struct {
+	// Bundle is the ServerBundle used to create the server's instrumenter.
+	Bundle touchhttp.ServerBundle
+
+	// Name is the value of the touchhttp.ServerLabel for this server.  If unset,
+	// the panels do not select by server label.
+	Name string
+}
+
+// Client describes the metrics of a single HTTP client to chart.
+type Client struct {
+	// Bundle is the ClientBundle used to create the client's instrumenter.
+	Bundle touchhttp.ClientBundle
+
+	// Name is the value of the touchhttp.ClientLabel for this client.  If unset,
+	// the panels do not select by client label.
+	Name string
+}
+
+// Config describes the dashboard to generate.
+type Config struct {
+	// Title is the dashboard title.
+	Title string
+
+	// UID is the optional dashboard UID.
+	UID string
+
+	// Tags are optional dashboard tags.
+	Tags []string
+
+	// Datasource is the optional datasource used by all panels.
+	Datasource *Datasource
+
+	// Defaults holds the Namespace and Subsystem applied by the touchstone.Factory
+	// that created the metrics, e.g. from touchstone.Config.
+	Defaults prometheus.Opts
+
+	// Naming is the NamingPolicy of the touchstone.Factory that created the metrics,
+	// if any.
+	Naming touchstone.NamingPolicy
+
+	// RateInterval is the range used in rate() expressions.  DefaultRateInterval
+	// is used if this field is unset.
+	RateInterval string
+
+	// Quantiles are the latency quantiles to chart.  DefaultQuantiles are used
+	// if this field is unset.
+	Quantiles []float64
+
+	// Servers are the HTTP servers to chart, in order.
+	Servers []Server
+
+	// Clients are the HTTP clients to chart, in order.
+	Clients []Client
+}
+
+// builder accumulates panels into a dashboard.
+type builder struct {
+	cfg    Config
+	nextID int
+	y      int
+	panels []Panel
+}
+
+func (b *builder) id() int {
+	b.nextID++
+	return b.nextID
+}
+
+func (b *builder) row(title string) {
+	collapsed := false
+	b.panels = append(b.panels, Panel{
+		ID:        b.id(),
+		Type:      "row",
+		Title:     title,
+		GridPos:   GridPos{H: 1, W: 24, X: 0, Y: b.y},
+		Collapsed: &collapsed,
+	})
+
+	b.y++
+}
+
+// charts appends a row's worth of timeseries panels.
+func (b *builder) charts(panels ...Panel) {
+	for i := range panels {
+		panels[i].ID = b.id()
+		panels[i].Type = "timeseries"
+		panels[i].Datasource = b.cfg.Datasource
+		panels[i].GridPos = GridPos{H: panelHeight, W: panelWidth, X: (i * panelWidth) % 24, Y: b.y + (i*panelWidth)/24*panelHeight}
+		for j := range panels[i].Targets {
+			panels[i].Targets[j].Datasource = b.cfg.Datasource
+			panels[i].Targets[j].RefID = string(rune('A' + j))
+		}
+	}
+
+	b.panels = append(b.panels, panels...)
+	b.y += ((len(panels)*panelWidth + 23) / 24) * panelHeight
+}
+
+func selector(label, value string) string {
+	if len(value) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(`%s=%q`, label, value)
+}
+
+func braces(matchers ...string) string {
+	var s string
+	for _, m := range matchers {
+		if len(m) == 0 {
+			continue
+		}
+
+		if len(s) > 0 {
+			s += ","
+		}
+
+		s += m
+	}
+
+	if len(s) == 0 {
+		return ""
+	}
+
+	return "{" + s + "}"
+}
+
+// latencyTargets produces the quantile queries for a duration observer.
+func (b *builder) latencyTargets(name string, t touchhttp.ObserverType, sel string) (targets []Target) {
+	for _, q := range b.cfg.Quantiles {
+		var expr string
+		if t == touchhttp.ObserverSummary {
+			expr = fmt.Sprintf(`max(%s%s)`, name, braces(sel, fmt.Sprintf(`quantile="%g"`, q)))
+		} else {
+			expr = fmt.Sprintf(
+				`histogram_quantile(%g, sum by (le) (rate(%s_bucket%s[%s])))`,
+				q, name, braces(sel), b.cfg.RateInterval,
+			)
+		}
+
+		targets = append(targets, Target{
+			Expr:         expr,
+			LegendFormat: fmt.Sprintf("p%g", q*100),
+		})
+	}
+
+	return
+}
+
+// red appends the standard RED panels for a single server or client.
+func (b *builder) red(title, count, inFlight, duration string, durationType touchhttp.ObserverType, sel string, errorCount string) {
+	b.row(title)
+
+	errorTargets := []Target{
+		{
+			Expr: fmt.Sprintf(
+				`sum(rate(%s%s[%s])) / sum(rate(%s%s[%s]))`,
+				count, braces(sel, `code=~"5.."`), b.cfg.RateInterval,
+				count, braces(sel), b.cfg.RateInterval,
+			),
+			LegendFormat: "5xx ratio",
+		},
+	}
+
+	if len(errorCount) > 0 {
+		errorTargets = append(errorTargets, Target{
+			Expr: fmt.Sprintf(
+				`sum(rate(%s%s[%s])) / sum(rate(%s%s[%s]))`,
+				errorCount, braces(sel), b.cfg.RateInterval,
+				count, braces(sel), b.cfg.RateInterval,
+			),
+			LegendFormat: "error ratio",
+		})
+	}
+
+	b.charts(
+		Panel{
+			Title: "Request rate",
+			Targets: []Target{
+				{
+					Expr:         fmt.Sprintf(`sum by (code) (rate(%s%s[%s]))`, count, braces(sel), b.cfg.RateInterval),
+					LegendFormat: "{{code}}",
+				},
+			},
+			FieldConfig: &FieldConfig{Defaults: FieldDefaults{Unit: "reqps"}},
+		},
+		Panel{
+			Title:       "Errors",
+			Targets:     errorTargets,
+			FieldConfig: &FieldConfig{Defaults: FieldDefaults{Unit: "percentunit"}},
+		},
+		Panel{
+			Title:       "Duration",
+			Targets:     b.latencyTargets(duration, durationType, sel),
+			FieldConfig: &FieldConfig{Defaults: FieldDefaults{Unit: "ms"}},
+		},
+		Panel{
+			Title: "In flight",
+			Targets: []Target{
+				{
+					Expr:         fmt.Sprintf(`sum(%s%s)`, inFlight, braces(sel)),
+					LegendFormat: "in flight",
+				},
+			},
+		},
+	)
+}
+
+// New generates a Dashboard from the given configuration.
+func New(cfg Config) Dashboard {
+	if len(cfg.RateInterval) == 0 {
+		cfg.RateInterval = DefaultRateInterval
+	}
+
+	if len(cfg.Quantiles) == 0 {
+		cfg.Quantiles = DefaultQuantiles
+	}
+
+	b := builder{cfg: cfg}
+	for _, s := range cfg.Servers {
+		names := s.Bundle.MetricNames(cfg.Defaults, cfg.Naming)
+		title := "Server"
+		if len(s.Name) > 0 {
+			title += ": " + s.Name
+		}
+
+		b.red(
+			title,
+			names.Count, names.InFlight, names.Duration, names.DurationType,
+			selector(touchhttp.ServerLabel, s.Name), "",
+		)
+	}
+
+	for _, c := range cfg.Clients {
+		names := c.Bundle.MetricNames(cfg.Defaults, cfg.Naming)
+		title := "Client"
+		if len(c.Name) > 0 {
+			title += ": " + c.Name
+		}
+
+		b.red(
+			title,
+			names.Count, names.InFlight, names.Duration, names.DurationType,
+			selector(touchhttp.ClientLabel, c.Name), names.ErrorCount,
+		)
+	}
+
+	if b.panels == nil {
+		b.panels = []Panel{}
+	}
+
+	return Dashboard{
+		UID:           cfg.UID,
+		Title:         cfg.Title,
+		Tags:          cfg.Tags,
+		SchemaVersion: SchemaVersion,
+		Editable:      true,
+		Time:          Time{From: "now-6h", To: "now"},
+		Panels:        b.panels,
+	}
+}
+
+// Write generates a Dashboard and writes it as indented JSON to the given writer.
+func Write(w io.Writer, cfg Config) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(New(cfg))
+}
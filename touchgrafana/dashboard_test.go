@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchgrafana
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"github.com/xmidt-org/touchstone/touchhttp"
+)
+
+type DashboardSuite struct {
+	suite.Suite
+}
+
+func (suite *DashboardSuite) TestEmpty() {
+	d := New(Config{Title: "empty"})
+	suite.Equal("empty", d.Title)
+	suite.Equal(SchemaVersion, d.SchemaVersion)
+	suite.NotNil(d.Panels)
+	suite.Empty(d.Panels)
+}
+
+func (suite *DashboardSuite) TestServersAndClients() {
+	d := New(Config{
+		Title:      "test",
+		Datasource: &Datasource{Type: "prometheus", UID: "prom"},
+		Defaults:   prometheus.Opts{Namespace: "xmidt"},
+		Servers: []Server{
+			{Name: "servers.main"},
+			{
+				Name: "servers.health",
+				Bundle: touchhttp.ServerBundle{
+					Duration: prometheus.SummaryOpts{},
+				},
+			},
+		},
+		Clients: []Client{
+			{},
+		},
+	})
+
+	// 3 rows, each with a row panel and 4 charts
+	suite.Require().Len(d.Panels, 15)
+
+	ids := make(map[int]bool)
+	for _, p := range d.Panels {
+		suite.False(ids[p.ID], "duplicate panel id %d", p.ID)
+		ids[p.ID] = true
+	}
+
+	suite.Equal("row", d.Panels[0].Type)
+	suite.Equal("Server: servers.main", d.Panels[0].Title)
+	suite.Equal(
+		`sum by (code) (rate(xmidt_server_request_count{server="servers.main"}[5m]))`,
+		d.Panels[1].Targets[0].Expr,
+	)
+
+	suite.Equal(
+		`histogram_quantile(0.5, sum by (le) (rate(xmidt_server_request_duration_ms_bucket{server="servers.main"}[5m])))`,
+		d.Panels[3].Targets[0].Expr,
+	)
+
+	suite.Equal("Server: servers.health", d.Panels[5].Title)
+	suite.Equal(
+		`max(xmidt_server_request_duration_ms{server="servers.health",quantile="0.5"})`,
+		d.Panels[8].Targets[0].Expr,
+	)
+
+	suite.Equal("Client", d.Panels[10].Title)
+	suite.Equal(`sum(xmidt_client_requests_in_flight)`, d.Panels[14].Targets[0].Expr)
+
+	// clients also chart the error counter
+	suite.Len(d.Panels[12].Targets, 2)
+	suite.Equal("B", d.Panels[12].Targets[1].RefID)
+}
+
+func (suite *DashboardSuite) TestNamingPolicy() {
+	d := New(Config{
+		Defaults: prometheus.Opts{Namespace: "xmidt"},
+		Naming:   touchstone.Naming{Prefix: "p"},
+		Servers:  []Server{{Name: "main"}},
+	})
+
+	suite.Require().Len(d.Panels, 5)
+	suite.Equal(
+		`sum by (code) (rate(xmidt_p_server_request_count{server="main"}[5m]))`,
+		d.Panels[1].Targets[0].Expr,
+	)
+}
+
+func (suite *DashboardSuite) TestWrite() {
+	var b bytes.Buffer
+	suite.Require().NoError(
+		Write(&b, Config{
+			Title:        "test",
+			RateInterval: "1m",
+			Quantiles:    []float64{0.99},
+			Servers:      []Server{{}},
+		}),
+	)
+
+	var d Dashboard
+	suite.Require().NoError(json.Unmarshal(b.Bytes(), &d))
+	suite.Equal("test", d.Title)
+	suite.Len(d.Panels, 5)
+	suite.Contains(d.Panels[1].Targets[0].Expr, "[1m]")
+	suite.Len(d.Panels[3].Targets, 1)
+	suite.Equal("p99", d.Panels[3].Targets[0].LegendFormat)
+}
+
+func TestDashboard(t *testing.T) {
+	suite.Run(t, new(DashboardSuite))
+}
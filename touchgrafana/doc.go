@@ -0,0 +1,13 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package touchgrafana generates Grafana dashboard JSON from touchhttp bundle
+configurations.  Because the dashboards are derived from the same ServerBundle
+and ClientBundle values used to create the metrics, the panels always refer to
+the metric names the application actually exposes.
+
+Each server or client gets a row of RED (rate, errors, duration) panels along
+with an in-flight panel.
+*/
+package touchgrafana
@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchgrpc
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/multierr"
+)
+
+const (
+	// DefaultServerCount is the default name of the counter that tracks the
+	// total number of completed gRPC calls.
+	DefaultServerCount = "grpc_server_request_count"
+
+	// DefaultServerDuration is the default name of the observer that tracks
+	// the total time taken by the server to complete calls.
+	DefaultServerDuration = "grpc_server_request_duration_ms"
+
+	// DefaultServerInFlight is the default name of the gauge that tracks the
+	// instantaneous view of how many calls the server is currently handling.
+	DefaultServerInFlight = "grpc_server_requests_in_flight"
+
+	// DefaultServerReceivedSize is the default name of the observer that tracks the
+	// sizes of messages received by the server.
+	DefaultServerReceivedSize = "grpc_server_received_message_size"
+
+	// DefaultServerSentSize is the default name of the observer that tracks the
+	// sizes of messages sent by the server.
+	DefaultServerSentSize = "grpc_server_sent_message_size"
+)
+
+var (
+	defaultServerCount = prometheus.CounterOpts{
+		Name: DefaultServerCount,
+		Help: "the total number of gRPC calls completed since startup",
+	}
+
+	defaultServerInFlight = prometheus.GaugeOpts{
+		Name: DefaultServerInFlight,
+		Help: "the instantaneous number of gRPC calls currently being handled",
+	}
+
+	defaultServerDuration = prometheus.HistogramOpts{
+		Name:    DefaultServerDuration,
+		Help:    "the gRPC call duration in milliseconds",
+		Buckets: []float64{62.5, 125, 250, 500, 1000, 5000, 10000, 20000, 40000, 80000, 160000},
+	}
+
+	defaultServerReceivedSize = prometheus.HistogramOpts{
+		Name:    DefaultServerReceivedSize,
+		Help:    "the size of received gRPC messages in bytes",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+	}
+
+	defaultServerSentSize = prometheus.HistogramOpts{
+		Name:    DefaultServerSentSize,
+		Help:    "the size of sent gRPC messages in bytes",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+	}
+)
+
+// ServerBundle describes the metrics used to instrument gRPC servers.
+// Each call is recorded with the CodeLabel, ServiceLabel, and MethodLabel,
+// in addition to any extra labels passed to NewInstrumenter.
+type ServerBundle struct {
+	// Count describes the options used for the total call counter
+	Count prometheus.CounterOpts
+
+	// InFlight describes the options used for the instantaneous call gauge.  This
+	// gauge has the ServiceLabel and MethodLabel, but not the CodeLabel.
+	InFlight prometheus.GaugeOpts
+
+	// Duration describes the options for the call duration observer.  If this field is
+	// set, it must be either a prometheus.HistogramOpts or a prometheus.SummaryOpts.
+	// The type of Opts struct will determine the type of metric created.
+	Duration interface{}
+
+	// ReceivedSize describes the options for the observer of received message sizes.
+	// If this field is set, it must be either a prometheus.HistogramOpts or a
+	// prometheus.SummaryOpts.  This observer does not have the CodeLabel, since each
+	// message is recorded as it is received.  Only protobuf messages are recorded.
+	ReceivedSize interface{}
+
+	// SentSize describes the options for the observer of sent message sizes.  If this
+	// field is set, it must be either a prometheus.HistogramOpts or a prometheus.SummaryOpts.
+	// This observer does not have the CodeLabel, since each message is recorded as it is
+	// sent.  Only protobuf messages are recorded.
+	SentSize interface{}
+
+	// WriteErrorHooks are optional callbacks invoked when a metric cannot be written
+	// at runtime, e.g. because of an invalid label value.  Such failures are always
+	// counted by the touchstone.WriteErrorCountName counter.  See touchstone.LogWriteErrors.
+	WriteErrorHooks []touchstone.WriteErrorHook
+
+	// Clock is the source of the current time.  If unset, the Clock of the
+	// MetricFactory is used.
+	Clock touchstone.Clock
+}
+
+func (sb ServerBundle) newCount(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
+	touchstone.ApplyDefaults(&sb.Count, defaultServerCount)
+	return touchstone.NewCurriedCounterVec(f, sb.Count, labelNames, curry)
+}
+
+func (sb ServerBundle) newInFlight(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (*prometheus.GaugeVec, error) {
+	touchstone.ApplyDefaults(&sb.InFlight, defaultServerInFlight)
+	return touchstone.NewCurriedGaugeVec(f, sb.InFlight, labelNames, curry)
+}
+
+// newObserverVec creates an observer vector from either a HistogramOpts or SummaryOpts,
+// to which the given defaults are applied.  A nil o uses the defaults as is.
+func newObserverVec(f touchstone.MetricFactory, field string, o interface{}, defaults prometheus.HistogramOpts, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+	var opts interface{}
+	switch t := o.(type) {
+	case nil:
+		opts = defaults
+
+	case prometheus.HistogramOpts:
+		touchstone.ApplyDefaults(&t, defaults)
+		opts = t
+
+	case prometheus.SummaryOpts:
+		touchstone.ApplyDefaults(&t, defaults)
+		opts = t
+
+	default:
+		return nil, errors.New("ServerBundle." + field + " must be nil, a prometheus.HistogramOpts, or a prometheus.SummaryOpts")
+	}
+
+	return touchstone.NewCurriedObserverVec(f, opts, labelNames, curry)
+}
+
+// NewInstrumenter creates a constructor that can be passed to fx.Provide or annotated
+// as needed.
+//
+// The namesAndValues are any extra, curried labels to apply to all the created
+// metrics.  If multiple calls to this method on the same ServerBundle instance are made,
+// the extra label names must match though the values may differ.
+//
+// If namesAndValues contains an odd number of entries or if it contains any of
+// the reserved label names used by this package, an error is returned by the returned
+// constructor.
+//
+// Typical usage:
+//
+//	app := fx.New(
+//	  touchstone.Provide(), // bootstraps the metrics environment
+//
+//	  fx.Provide(
+//	    touchgrpc.ServerBundle{}.NewInstrumenter(
+//	      touchgrpc.ServerLabel, "main",
+//	    ),
+//	    func(si touchgrpc.ServerInstrumenter) *grpc.Server {
+//	      return grpc.NewServer(
+//	        grpc.ChainUnaryInterceptor(si.Unary),
+//	        grpc.ChainStreamInterceptor(si.Stream),
+//	      )
+//	    },
+//	  ),
+//	)
+func (sb ServerBundle) NewInstrumenter(namesAndValues ...string) func(touchstone.MetricFactory) (ServerInstrumenter, error) {
+	return func(f touchstone.MetricFactory) (si ServerInstrumenter, err error) {
+		var (
+			extraNames []string
+			curry      prometheus.Labels
+		)
+
+		extraNames, curry, err = labelNames(namesAndValues)
+		if err != nil {
+			return
+		}
+
+		// callNames are used by metrics that don't know the outcome of a call
+		callNames := make([]string, 0, len(extraNames)+2)
+		callNames = append(callNames, extraNames...)
+		callNames = append(callNames, ServiceLabel, MethodLabel)
+
+		// fullNames additionally include the status code
+		fullNames := make([]string, 0, len(callNames)+1)
+		fullNames = append(fullNames, callNames...)
+		fullNames = append(fullNames, CodeLabel)
+
+		si.writeErrors, err = touchstone.NewWriteErrors(f, sb.WriteErrorHooks...)
+		if err != nil {
+			return
+		}
+
+		clock := sb.Clock
+		if clock == nil {
+			clock = f.Clock()
+		}
+
+		si.now = clock.Now
+
+		var metricErr error
+
+		si.count, metricErr = sb.newCount(f, fullNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		si.inFlight, metricErr = sb.newInFlight(f, callNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		si.duration, metricErr = newObserverVec(f, "Duration", sb.Duration, defaultServerDuration, fullNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		si.receivedSize, metricErr = newObserverVec(f, "ReceivedSize", sb.ReceivedSize, defaultServerReceivedSize, callNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		si.sentSize, metricErr = newObserverVec(f, "SentSize", sb.SentSize, defaultServerSentSize, callNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		return
+	}
+}
@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchgrpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+type BundleSuite struct {
+	suite.Suite
+
+	// now is a known start time for all clocks
+	now time.Time
+}
+
+func (suite *BundleSuite) SetupTest() {
+	suite.now = time.Now()
+}
+
+func (suite *BundleSuite) newRegistry() (*touchstone.Factory, prometheus.Gatherer) {
+	cfg := touchstone.Config{
+		Pedantic:                  true,
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := touchstone.New(cfg)
+	suite.Require().NoError(err)
+	return touchstone.NewFactory(cfg, zap.L(), r), g
+}
+
+// clock returns a Clock that advances by the given step on each call.
+func (suite *BundleSuite) clock(step time.Duration) touchstone.Clock {
+	current := suite.now
+	return touchstone.ClockFunc(func() time.Time {
+		t := current
+		current = current.Add(step)
+		return t
+	})
+}
+
+type ServerBundleSuite struct {
+	BundleSuite
+}
+
+func (suite *ServerBundleSuite) testNewInstrumenterDefaults() {
+	var (
+		si ServerInstrumenter
+
+		app = fxtest.New(
+			suite.T(),
+			touchstone.Provide(),
+			fx.Provide(
+				ServerBundle{}.NewInstrumenter(),
+			),
+			fx.Populate(&si),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	suite.NotNil(si.count)
+	suite.NotNil(si.inFlight)
+	suite.NotNil(si.duration)
+	suite.NotNil(si.receivedSize)
+	suite.NotNil(si.sentSize)
+}
+
+func (suite *ServerBundleSuite) testNewInstrumenterNamed() {
+	var (
+		sb ServerBundle
+
+		app = fxtest.New(
+			suite.T(),
+			touchstone.Provide(),
+			fx.Provide(
+				fx.Annotated{
+					Name: "servers.main",
+					Target: sb.NewInstrumenter(
+						ServerLabel, "servers.main",
+					),
+				},
+				fx.Annotated{
+					Name: "servers.admin",
+					Target: sb.NewInstrumenter(
+						ServerLabel, "servers.admin",
+					),
+				},
+			),
+			fx.Invoke(
+				fx.Annotate(
+					func(ServerInstrumenter) {},
+					fx.ParamTags(`name:"servers.main"`),
+				),
+				fx.Annotate(
+					func(ServerInstrumenter) {},
+					fx.ParamTags(`name:"servers.admin"`),
+				),
+			),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+}
+
+func (suite *ServerBundleSuite) testNewInstrumenterSummaries() {
+	f, _ := suite.newRegistry()
+	si, err := ServerBundle{
+		Duration:     prometheus.SummaryOpts{},
+		ReceivedSize: prometheus.SummaryOpts{Name: "received"},
+		SentSize:     prometheus.HistogramOpts{Name: "sent"},
+	}.NewInstrumenter()(f)
+
+	suite.Require().NoError(err)
+	suite.IsType((*prometheus.SummaryVec)(nil), si.duration)
+	suite.IsType((*prometheus.SummaryVec)(nil), si.receivedSize)
+	suite.IsType((*prometheus.HistogramVec)(nil), si.sentSize)
+}
+
+func (suite *ServerBundleSuite) testNewInstrumenterInvalidLabels() {
+	f, _ := suite.newRegistry()
+	_, err := ServerBundle{}.NewInstrumenter(ServerLabel)(f)
+	suite.ErrorIs(err, ErrInvalidLabelCount)
+
+	_, err = ServerBundle{}.NewInstrumenter(ServiceLabel, "value")(f)
+	suite.ErrorIs(err, ErrReservedLabelName)
+}
+
+func (suite *ServerBundleSuite) testNewInstrumenterInvalidOpts() {
+	testCases := []struct {
+		name string
+		sb   ServerBundle
+	}{
+		{"Duration", ServerBundle{Duration: prometheus.CounterOpts{}}},
+		{"ReceivedSize", ServerBundle{ReceivedSize: "invalid"}},
+		{"SentSize", ServerBundle{SentSize: 123}},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.name, func() {
+			f, _ := suite.newRegistry()
+			_, err := testCase.sb.NewInstrumenter()(f)
+			suite.ErrorContains(err, testCase.name)
+		})
+	}
+}
+
+func (suite *ServerBundleSuite) TestNewInstrumenter() {
+	suite.Run("Defaults", suite.testNewInstrumenterDefaults)
+	suite.Run("Named", suite.testNewInstrumenterNamed)
+	suite.Run("Summaries", suite.testNewInstrumenterSummaries)
+	suite.Run("InvalidLabels", suite.testNewInstrumenterInvalidLabels)
+	suite.Run("InvalidOpts", suite.testNewInstrumenterInvalidOpts)
+}
+
+func TestServerBundle(t *testing.T) {
+	suite.Run(t, new(ServerBundleSuite))
+}
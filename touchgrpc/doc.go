@@ -0,0 +1,12 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package touchgrpc defines the gRPC-specific behavior for metrics within
+an uber/fx app which uses the touchstone package.
+
+ServerBundle is a prebaked, opinionated set of metrics for instrumenting
+gRPC servers, analogous to touchhttp.ServerBundle.  It produces unary and
+stream server interceptors given the labels for a particular server.
+*/
+package touchgrpc
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchgrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ServerInstrumenter records metrics for gRPC server calls.  Its Unary and Stream
+// methods are interceptors, e.g. for grpc.ChainUnaryInterceptor and
+// grpc.ChainStreamInterceptor.
+//
+// Instances are created with ServerBundle.NewInstrumenter.
+type ServerInstrumenter struct {
+	count        *prometheus.CounterVec
+	inFlight     *prometheus.GaugeVec
+	duration     prometheus.ObserverVec
+	receivedSize prometheus.ObserverVec
+	sentSize     prometheus.ObserverVec
+	writeErrors  *touchstone.WriteErrors
+	now          func() time.Time
+}
+
+// call holds the state of a single gRPC call as it is recorded.
+type call struct {
+	si      *ServerInstrumenter
+	service string
+	method  string
+	start   time.Time
+}
+
+// begin starts recording a call to the given full method.
+func (si *ServerInstrumenter) begin(fullMethod string) *call {
+	c := &call{si: si}
+	c.service, c.method = splitFullMethod(fullMethod)
+	si.writeErrors.Gauge(si.inFlight, c.labels()).Inc()
+	c.start = si.now()
+	return c
+}
+
+// labels returns the labels of this call that don't depend on its outcome.
+func (c *call) labels() prometheus.Labels {
+	return prometheus.Labels{
+		ServiceLabel: c.service,
+		MethodLabel:  c.method,
+	}
+}
+
+// observeSize records the size of a message, if it is a protobuf message.
+func (c *call) observeSize(vec prometheus.ObserverVec, m interface{}) {
+	if pm, ok := m.(proto.Message); ok {
+		c.si.writeErrors.Observer(vec, c.labels()).Observe(float64(proto.Size(pm)))
+	}
+}
+
+// end finishes recording a call with the status of the given error.
+func (c *call) end(err error) {
+	elapsed := c.si.now().Sub(c.start)
+	l := c.labels()
+	c.si.writeErrors.Gauge(c.si.inFlight, l).Dec()
+
+	l[CodeLabel] = status.Code(err).String()
+	c.si.writeErrors.Counter(c.si.count, l).Inc()
+	c.si.writeErrors.Observer(c.si.duration, l).Observe(
+		float64(elapsed) / float64(time.Millisecond),
+	)
+}
+
+// Unary is a grpc.UnaryServerInterceptor that records metrics for unary calls.
+func (si *ServerInstrumenter) Unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	c := si.begin(info.FullMethod)
+	defer func() {
+		c.end(err)
+	}()
+
+	c.observeSize(si.receivedSize, req)
+	resp, err = handler(ctx, req)
+	if err == nil {
+		c.observeSize(si.sentSize, resp)
+	}
+
+	return
+}
+
+// Stream is a grpc.StreamServerInterceptor that records metrics for streaming calls.
+// Each message received or sent over the stream is recorded separately.
+func (si *ServerInstrumenter) Stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	c := si.begin(info.FullMethod)
+	defer func() {
+		c.end(err)
+	}()
+
+	err = handler(srv, &serverStream{ServerStream: ss, c: c})
+	return
+}
+
+// serverStream decorates a grpc.ServerStream to record the sizes of messages.
+type serverStream struct {
+	grpc.ServerStream
+	c *call
+}
+
+func (ss *serverStream) SendMsg(m interface{}) error {
+	err := ss.ServerStream.SendMsg(m)
+	if err == nil {
+		ss.c.observeSize(ss.c.si.sentSize, m)
+	}
+
+	return err
+}
+
+func (ss *serverStream) RecvMsg(m interface{}) error {
+	err := ss.ServerStream.RecvMsg(m)
+	if err == nil {
+		ss.c.observeSize(ss.c.si.receivedSize, m)
+	}
+
+	return err
+}
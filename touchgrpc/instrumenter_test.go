@@ -0,0 +1,334 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchgrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	testService    = "test.Service"
+	testMethod     = "Method"
+	testFullMethod = "/" + testService + "/" + testMethod
+)
+
+// testServerStream is a stub grpc.ServerStream that receives and sends from slices.
+type testServerStream struct {
+	grpc.ServerStream
+	received []interface{}
+	sent     []interface{}
+	err      error
+}
+
+func (tss *testServerStream) Context() context.Context {
+	return context.Background()
+}
+
+func (tss *testServerStream) SendMsg(m interface{}) error {
+	if tss.err != nil {
+		return tss.err
+	}
+
+	tss.sent = append(tss.sent, m)
+	return nil
+}
+
+func (tss *testServerStream) RecvMsg(m interface{}) error {
+	if tss.err != nil {
+		return tss.err
+	}
+
+	proto.Merge(m.(proto.Message), tss.received[0].(proto.Message))
+	tss.received = tss.received[1:]
+	return nil
+}
+
+type ServerInstrumenterSuite struct {
+	BundleSuite
+}
+
+func (suite *ServerInstrumenterSuite) newInstrumenter() ServerInstrumenter {
+	f, _ := suite.newRegistry()
+	si, err := ServerBundle{
+		Clock: suite.clock(100 * time.Millisecond),
+	}.NewInstrumenter(ServerLabel, "test")(f)
+
+	suite.Require().NoError(err)
+	return si
+}
+
+func (suite *ServerInstrumenterSuite) labels() prometheus.Labels {
+	return prometheus.Labels{
+		ServiceLabel: testService,
+		MethodLabel:  testMethod,
+	}
+}
+
+func (suite *ServerInstrumenterSuite) codeLabels(c codes.Code) prometheus.Labels {
+	l := suite.labels()
+	l[CodeLabel] = c.String()
+	return l
+}
+
+// histogram returns the state of a child of a histogram vector.
+func (suite *ServerInstrumenterSuite) histogram(vec prometheus.ObserverVec, l prometheus.Labels) *dto.Histogram {
+	o, err := vec.GetMetricWith(l)
+	suite.Require().NoError(err)
+
+	var m dto.Metric
+	suite.Require().NoError(o.(prometheus.Metric).Write(&m))
+	return m.GetHistogram()
+}
+
+func (suite *ServerInstrumenterSuite) assertCall(si ServerInstrumenter, c codes.Code) {
+	suite.Equal(1.0, testutil.ToFloat64(si.count.With(suite.codeLabels(c))))
+	suite.Zero(testutil.ToFloat64(si.inFlight.With(suite.labels())))
+
+	duration := suite.histogram(si.duration, suite.codeLabels(c))
+	suite.Equal(uint64(1), duration.GetSampleCount())
+	suite.Equal(100.0, duration.GetSampleSum())
+}
+
+func (suite *ServerInstrumenterSuite) testUnarySuccess() {
+	var (
+		si      = suite.newInstrumenter()
+		request = wrapperspb.String("request")
+		reply   = wrapperspb.String("a longer reply")
+	)
+
+	resp, err := si.Unary(
+		context.Background(),
+		request,
+		&grpc.UnaryServerInfo{FullMethod: testFullMethod},
+		func(_ context.Context, req interface{}) (interface{}, error) {
+			suite.Same(request, req)
+			suite.Equal(1.0, testutil.ToFloat64(si.inFlight.With(suite.labels())))
+			return reply, nil
+		},
+	)
+
+	suite.NoError(err)
+	suite.Same(reply, resp)
+	suite.assertCall(si, codes.OK)
+
+	received := suite.histogram(si.receivedSize, suite.labels())
+	suite.Equal(uint64(1), received.GetSampleCount())
+	suite.Equal(float64(proto.Size(request)), received.GetSampleSum())
+
+	sent := suite.histogram(si.sentSize, suite.labels())
+	suite.Equal(uint64(1), sent.GetSampleCount())
+	suite.Equal(float64(proto.Size(reply)), sent.GetSampleSum())
+}
+
+func (suite *ServerInstrumenterSuite) testUnaryError() {
+	testCases := []struct {
+		name     string
+		err      error
+		expected codes.Code
+	}{
+		{"Status", status.Error(codes.NotFound, "expected"), codes.NotFound},
+		{"Other", errors.New("expected"), codes.Unknown},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.name, func() {
+			si := suite.newInstrumenter()
+			resp, err := si.Unary(
+				context.Background(),
+				wrapperspb.String("request"),
+				&grpc.UnaryServerInfo{FullMethod: testFullMethod},
+				func(context.Context, interface{}) (interface{}, error) {
+					return nil, testCase.err
+				},
+			)
+
+			suite.Nil(resp)
+			suite.Same(testCase.err, err)
+			suite.assertCall(si, testCase.expected)
+			suite.Equal(1, testutil.CollectAndCount(si.receivedSize))
+			suite.Zero(testutil.CollectAndCount(si.sentSize))
+		})
+	}
+}
+
+func (suite *ServerInstrumenterSuite) testUnaryNotProto() {
+	si := suite.newInstrumenter()
+	resp, err := si.Unary(
+		context.Background(),
+		"request",
+		&grpc.UnaryServerInfo{FullMethod: testFullMethod},
+		func(context.Context, interface{}) (interface{}, error) {
+			return "reply", nil
+		},
+	)
+
+	suite.NoError(err)
+	suite.Equal("reply", resp)
+	suite.assertCall(si, codes.OK)
+	suite.Zero(testutil.CollectAndCount(si.receivedSize))
+	suite.Zero(testutil.CollectAndCount(si.sentSize))
+}
+
+func (suite *ServerInstrumenterSuite) TestUnary() {
+	suite.Run("Success", suite.testUnarySuccess)
+	suite.Run("Error", suite.testUnaryError)
+	suite.Run("NotProto", suite.testUnaryNotProto)
+}
+
+func (suite *ServerInstrumenterSuite) testStreamSuccess() {
+	var (
+		si     = suite.newInstrumenter()
+		first  = wrapperspb.String("first")
+		second = wrapperspb.String("second message")
+		reply  = wrapperspb.Int64(1234)
+		tss    = &testServerStream{received: []interface{}{first, second}}
+	)
+
+	err := si.Stream(
+		"server",
+		tss,
+		&grpc.StreamServerInfo{FullMethod: testFullMethod, IsClientStream: true},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			suite.Equal("server", srv)
+			suite.Equal(1.0, testutil.ToFloat64(si.inFlight.With(suite.labels())))
+			for i := 0; i < 2; i++ {
+				suite.Require().NoError(ss.RecvMsg(new(wrapperspb.StringValue)))
+			}
+
+			return ss.SendMsg(reply)
+		},
+	)
+
+	suite.NoError(err)
+	suite.Equal([]interface{}{reply}, tss.sent)
+	suite.assertCall(si, codes.OK)
+
+	received := suite.histogram(si.receivedSize, suite.labels())
+	suite.Equal(uint64(2), received.GetSampleCount())
+	suite.Equal(float64(proto.Size(first)+proto.Size(second)), received.GetSampleSum())
+
+	sent := suite.histogram(si.sentSize, suite.labels())
+	suite.Equal(uint64(1), sent.GetSampleCount())
+	suite.Equal(float64(proto.Size(reply)), sent.GetSampleSum())
+}
+
+func (suite *ServerInstrumenterSuite) testStreamError() {
+	var (
+		si          = suite.newInstrumenter()
+		expectedErr = status.Error(codes.Unavailable, "expected")
+		tss         = &testServerStream{err: expectedErr}
+	)
+
+	err := si.Stream(
+		"server",
+		tss,
+		&grpc.StreamServerInfo{FullMethod: testFullMethod},
+		func(_ interface{}, ss grpc.ServerStream) error {
+			suite.Same(expectedErr, ss.SendMsg(wrapperspb.String("reply")))
+			return ss.RecvMsg(new(wrapperspb.StringValue))
+		},
+	)
+
+	suite.Same(expectedErr, err)
+	suite.assertCall(si, codes.Unavailable)
+	suite.Zero(testutil.CollectAndCount(si.receivedSize))
+	suite.Zero(testutil.CollectAndCount(si.sentSize))
+}
+
+func (suite *ServerInstrumenterSuite) TestStream() {
+	suite.Run("Success", suite.testStreamSuccess)
+	suite.Run("Error", suite.testStreamError)
+}
+
+// TestServer verifies the interceptors with a real gRPC server and client.
+func (suite *ServerInstrumenterSuite) TestServer() {
+	f, _ := suite.newRegistry()
+	si, err := ServerBundle{}.NewInstrumenter()(f)
+	suite.Require().NoError(err)
+
+	var (
+		listener = bufconn.Listen(1024 * 1024)
+		server   = grpc.NewServer(
+			grpc.ChainUnaryInterceptor(si.Unary),
+			grpc.ChainStreamInterceptor(si.Stream),
+		)
+	)
+
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+
+	suite.Require().NoError(err)
+	defer conn.Close()
+
+	client := grpc_health_v1.NewHealthClient(conn)
+	_, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	suite.Require().NoError(err)
+
+	_, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "missing"})
+	suite.Equal(codes.NotFound, status.Code(err))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watch, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	suite.Require().NoError(err)
+	_, err = watch.Recv()
+	suite.Require().NoError(err)
+	cancel()
+
+	const healthService = "grpc.health.v1.Health"
+	for _, c := range []struct {
+		method string
+		code   codes.Code
+	}{
+		{"Check", codes.OK},
+		{"Check", codes.NotFound},
+		{"Watch", codes.Canceled},
+	} {
+		suite.Eventually(
+			func() bool {
+				return testutil.ToFloat64(si.count.With(prometheus.Labels{
+					ServiceLabel: healthService,
+					MethodLabel:  c.method,
+					CodeLabel:    c.code.String(),
+				})) == 1.0
+			},
+			5*time.Second,
+			10*time.Millisecond,
+			"%s %s", c.method, c.code,
+		)
+	}
+
+	suite.Equal(2, testutil.CollectAndCount(si.receivedSize))
+	suite.Equal(2, testutil.CollectAndCount(si.sentSize))
+}
+
+func TestServerInstrumenter(t *testing.T) {
+	suite.Run(t, new(ServerInstrumenterSuite))
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchgrpc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// CodeLabel is the metric label containing the gRPC status code of a call, e.g. OK or NotFound.
+	CodeLabel = "code"
+
+	// ServiceLabel is the metric label containing the fully qualified name of the gRPC service,
+	// e.g. grpc.health.v1.Health.
+	ServiceLabel = "service"
+
+	// MethodLabel is the metric label containing the name of the gRPC method, e.g. Check.
+	MethodLabel = "method"
+
+	// ServerLabel is the canonical metric label name containing the name of the gRPC server.
+	// This label is not automatically supplied.
+	ServerLabel = "server"
+
+	// Unknown is used for the service and method labels when a call's full method
+	// name cannot be parsed.
+	Unknown = "unknown"
+)
+
+var (
+	// ErrReservedLabelName indicates that labels supplied to build an instrumenter
+	// had one or more reserved label names.
+	ErrReservedLabelName = fmt.Errorf(
+		"%s, %s, and %s are reserved label names and are supplied automatically",
+		CodeLabel,
+		ServiceLabel,
+		MethodLabel,
+	)
+
+	// ErrInvalidLabelCount indicates that an odd number of name/value pairs were
+	// passed when creating metrics.
+	ErrInvalidLabelCount = errors.New("The number of label names and values must be even")
+)
+
+// labelNames takes a sequence of name/value pairs and converts that into
+// a slice of names and a prometheus.Labels which should be used to curry
+// the associated metric.
+func labelNames(lvs []string) (names []string, curry prometheus.Labels, err error) {
+	if len(lvs)%2 != 0 {
+		err = ErrInvalidLabelCount
+	}
+
+	if err == nil {
+		names = make([]string, 0, len(lvs)/2)
+		curry = make(prometheus.Labels, len(lvs)/2)
+		for i, j := 0, 1; err == nil && i < len(lvs); i, j = i+2, j+2 {
+			switch lvs[i] {
+			case CodeLabel, ServiceLabel, MethodLabel:
+				err = ErrReservedLabelName
+				continue
+			}
+
+			names = append(names, lvs[i])
+			curry[lvs[i]] = lvs[j]
+		}
+	}
+
+	return
+}
+
+// splitFullMethod splits a gRPC full method name, of the form /package.Service/Method,
+// into its service and method.  Malformed names produce Unknown for both.
+func splitFullMethod(fullMethod string) (service, method string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndexByte(fullMethod, '/'); i > 0 && i < len(fullMethod)-1 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+
+	return Unknown, Unknown
+}
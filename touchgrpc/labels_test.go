@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchgrpc
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+)
+
+type LabelsSuite struct {
+	suite.Suite
+}
+
+func (suite *LabelsSuite) TestLabelNames() {
+	suite.Run("Empty", func() {
+		names, curry, err := labelNames(nil)
+		suite.NoError(err)
+		suite.Empty(names)
+		suite.Empty(curry)
+	})
+
+	suite.Run("Valid", func() {
+		names, curry, err := labelNames([]string{ServerLabel, "main", "region", "east"})
+		suite.NoError(err)
+		suite.Equal([]string{ServerLabel, "region"}, names)
+		suite.Equal(prometheus.Labels{ServerLabel: "main", "region": "east"}, curry)
+	})
+
+	suite.Run("Odd", func() {
+		_, _, err := labelNames([]string{ServerLabel})
+		suite.ErrorIs(err, ErrInvalidLabelCount)
+	})
+
+	for _, reserved := range []string{CodeLabel, ServiceLabel, MethodLabel} {
+		suite.Run(reserved, func() {
+			_, _, err := labelNames([]string{reserved, "value"})
+			suite.ErrorIs(err, ErrReservedLabelName)
+		})
+	}
+}
+
+func (suite *LabelsSuite) TestSplitFullMethod() {
+	testCases := []struct {
+		fullMethod      string
+		expectedService string
+		expectedMethod  string
+	}{
+		{"/grpc.health.v1.Health/Check", "grpc.health.v1.Health", "Check"},
+		{"/Service/Method", "Service", "Method"},
+		{"grpc.health.v1.Health/Watch", "grpc.health.v1.Health", "Watch"},
+		{"", Unknown, Unknown},
+		{"/", Unknown, Unknown},
+		{"/Method", Unknown, Unknown},
+		{"/Service/", Unknown, Unknown},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.fullMethod, func() {
+			service, method := splitFullMethod(testCase.fullMethod)
+			suite.Equal(testCase.expectedService, service)
+			suite.Equal(testCase.expectedMethod, method)
+		})
+	}
+}
+
+func TestLabels(t *testing.T) {
+	suite.Run(t, new(LabelsSuite))
+}
@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// batchKey identifies the label values of the core metrics of a transaction.
+// The extra, curried labels are the same for every transaction of an instrumenter.
+type batchKey struct {
+	code       int
+	method     string
+	tenant     string
+	connection [maxConnectionLabels]string
+}
+
+// batch holds the children of the core metrics for one combination of label values.
+// Children that are not written are nil, e.g. the response size for clients.
+type batch struct {
+	count            prometheus.Counter
+	duration         prometheus.Observer
+	requestSize      prometheus.Observer
+	responseSize     prometheus.Observer
+	migratedCount    prometheus.Counter
+	migratedDuration prometheus.Observer
+
+	// the batcher's generation when these children were obtained, unused when not batching
+	generation uint64
+}
+
+// observe applies the core observations of a completed transaction, with the transaction's
+// exemplar, if any.  The original count and duration are only written when legacy is true.
+// See migration.legacy.
+func (b *batch) observe(t transaction, elapsed time.Duration, legacy bool) {
+	if legacy && b.count != nil {
+		incWithExemplar(b.count, t.exemplar)
+		observeWithExemplar(b.duration, float64(elapsed/time.Millisecond), t.exemplar)
+	}
+
+	if b.migratedCount != nil {
+		incWithExemplar(b.migratedCount, t.exemplar)
+		observeWithExemplar(b.migratedDuration, elapsed.Seconds(), t.exemplar)
+	}
+
+	b.requestSize.Observe(float64(t.requestSize))
+	if b.responseSize != nil {
+		b.responseSize.Observe(float64(t.responseSize))
+	}
+}
+
+// batcher caches a batch for each combination of label values seen by an instrumenter.
+//
+// Without a batcher, each core metric of each transaction looks up its child in its
+// vector, which hashes the label values and takes that vector's read lock.  Under high
+// concurrency, the shared reader counts of those locks are a source of contention.  With
+// a batcher, a transaction finds all its children with a single lookup in a copy-on-write
+// map, which takes no locks.  The map is only copied the first time a combination of label
+// values is seen, or after series have been deleted, which is rare once a server has warmed up.
+//
+// Deleting a series orphans its children, and any updates to an orphan are lost.  Each
+// deletion through an instrumenter advances the batcher's generation, and a cached batch
+// from an earlier generation is replaced the next time it is used.
+type batcher struct {
+	lock       sync.Mutex
+	batches    atomic.Pointer[map[batchKey]*batch]
+	generation atomic.Uint64
+}
+
+// invalidate advances the generation of this batcher, so that every cached batch is
+// replaced.  This must be done after series are deleted.
+func (b *batcher) invalidate() {
+	b.generation.Add(1)
+}
+
+// get returns the batch for the given key, creating it with newBatch if necessary.
+// A cached batch from an earlier generation is replaced.  If newBatch fails, e.g. because
+// of an invalid label value, nil is returned and nothing is cached.
+func (b *batcher) get(k batchKey, newBatch func() (*batch, error)) *batch {
+	generation := b.generation.Load()
+	if m := b.batches.Load(); m != nil {
+		if existing, ok := (*m)[k]; ok && existing.generation == generation {
+			return existing
+		}
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// the generation is read before any children are obtained, so that children obtained
+	// before a concurrent deletion are never cached as current
+	generation = b.generation.Load()
+	var current map[batchKey]*batch
+	if m := b.batches.Load(); m != nil {
+		current = *m
+		if existing, ok := current[k]; ok && existing.generation == generation {
+			return existing
+		}
+	}
+
+	created, err := newBatch()
+	if err != nil {
+		return nil
+	}
+
+	created.generation = generation
+	updated := make(map[batchKey]*batch, len(current)+1)
+	for ck, cb := range current {
+		// batches from earlier generations are dropped rather than copied
+		if cb.generation == generation {
+			updated[ck] = cb
+		}
+	}
+
+	updated[k] = created
+	b.batches.Store(&updated)
+	return created
+}
+
+// newBatch obtains the children of the core metrics for the given labels.  Once the
+// original families of a migration have been retired, their children are not obtained,
+// so that retired series are not recreated.
+func (i instrumenter) newBatch(l prometheus.Labels, legacy bool) (b *batch, err error) {
+	b = new(batch)
+	if legacy {
+		if b.count, err = i.count.GetMetricWith(l); err != nil {
+			return
+		}
+
+		if b.duration, err = i.duration.GetMetricWith(l); err != nil {
+			return
+		}
+	}
+
+	if i.migration != nil {
+		if b.migratedCount, err = i.migration.count.GetMetricWith(l); err != nil {
+			return
+		}
+
+		if b.migratedDuration, err = i.migration.duration.GetMetricWith(l); err != nil {
+			return
+		}
+	}
+
+	if b.requestSize, err = i.requestSize.GetMetricWith(l); err != nil {
+		return
+	}
+
+	if i.responseSize != nil {
+		b.responseSize, err = i.responseSize.GetMetricWith(l)
+	}
+
+	return
+}
+
+// resolveBatch obtains the children of the core metrics for the given labels for a
+// single transaction.  Any child that cannot be obtained is recorded as a write error.
+func (i instrumenter) resolveBatch(l prometheus.Labels, legacy bool) (b batch) {
+	if legacy {
+		b.count = i.writeErrors.Counter(i.count, l)
+		b.duration = i.writeErrors.Observer(i.duration, l)
+	}
+
+	if i.migration != nil {
+		b.migratedCount = i.writeErrors.Counter(i.migration.count, l)
+		b.migratedDuration = i.writeErrors.Observer(i.migration.duration, l)
+	}
+
+	b.requestSize = i.writeErrors.Observer(i.requestSize, l)
+	if i.responseSize != nil {
+		b.responseSize = i.writeErrors.Observer(i.responseSize, l)
+	}
+
+	return
+}
+
+// batchFor returns the children of the core metrics for a transaction with the given labels.
+// If this instrumenter batches updates, the children are cached by label values.  The
+// children are returned by value, so that the unbatched path does not allocate.
+func (i instrumenter) batchFor(t transaction, l prometheus.Labels, legacy bool) batch {
+	if i.batcher != nil {
+		k := batchKey{
+			code:       t.code,
+			method:     l[MethodLabel],
+			tenant:     t.tenant,
+			connection: t.connection,
+		}
+
+		b := i.batcher.get(
+			k,
+			func() (*batch, error) { return i.newBatch(l, legacy) },
+		)
+
+		if b != nil {
+			return *b
+		}
+	}
+
+	return i.resolveBatch(l, legacy)
+}
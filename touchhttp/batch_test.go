@@ -0,0 +1,259 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/httpaux/client"
+	"github.com/xmidt-org/touchstone"
+)
+
+type BatchSuite struct {
+	BundleSuite
+}
+
+func (suite *BatchSuite) TestBatcher() {
+	var (
+		b       batcher
+		created int
+		k       = batchKey{code: http.StatusOK, method: http.MethodGet}
+	)
+
+	newBatch := func() (*batch, error) {
+		created++
+		return new(batch), nil
+	}
+
+	first := b.get(k, newBatch)
+	suite.Require().NotNil(first)
+	suite.Same(first, b.get(k, newBatch))
+	suite.Equal(1, created)
+
+	second := b.get(batchKey{code: http.StatusNotFound, method: http.MethodGet}, newBatch)
+	suite.Require().NotNil(second)
+	suite.NotSame(first, second)
+	suite.Equal(2, created)
+	suite.Same(first, b.get(k, newBatch))
+
+	suite.Nil(b.get(batchKey{code: http.StatusTeapot}, func() (*batch, error) {
+		return nil, errors.New("expected")
+	}))
+
+	suite.Len(*b.batches.Load(), 2)
+
+	// a batch from an earlier generation is replaced, and the others are dropped
+	b.invalidate()
+	replaced := b.get(k, newBatch)
+	suite.Require().NotNil(replaced)
+	suite.NotSame(first, replaced)
+	suite.Equal(3, created)
+	suite.Same(replaced, b.get(k, newBatch))
+	suite.Len(*b.batches.Load(), 1)
+}
+
+func (suite *BatchSuite) TestServer() {
+	si, err := ServerBundle{
+		BatchUpdates: true,
+		Responses:    &Responses{},
+		Migration:    &Migration{Period: time.Second},
+		Clock:        suite.clock(250 * time.Millisecond),
+	}.NewInstrumenter(ServerLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(si.batcher)
+
+	h := si.Then(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("hello"))
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Len(*si.batcher.batches.Load(), 1)
+
+	l := prometheus.Labels{CodeLabel: "200", MethodLabel: http.MethodGet}
+	suite.Equal(1.0, testutil.ToFloat64(si.count.With(l)))
+	suite.Equal(1.0, testutil.ToFloat64(si.migration.count.With(l)))
+	suite.Equal(1, testutil.CollectAndCount(si.requestSize))
+	suite.Equal(1, testutil.CollectAndCount(si.responseSize))
+
+	// the period ends with the second request, which reuses the cached batch
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Len(*si.batcher.batches.Load(), 1)
+	suite.Zero(testutil.CollectAndCount(si.count))
+	suite.Zero(testutil.CollectAndCount(si.duration))
+	suite.Equal(2.0, testutil.ToFloat64(si.migration.count.With(l)))
+
+	// a new combination of labels after the period does not recreate the retired series
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	suite.Len(*si.batcher.batches.Load(), 2)
+	suite.Zero(testutil.CollectAndCount(si.count))
+	suite.Zero(testutil.CollectAndCount(si.duration))
+	suite.Equal(2, testutil.CollectAndCount(si.migration.count))
+	suite.Equal(2, testutil.CollectAndCount(si.requestSize))
+}
+
+func (suite *BatchSuite) TestDeleteThenRecord() {
+	si, err := ServerBundle{
+		BatchUpdates: true,
+	}.NewInstrumenter(ServerLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	var (
+		h = si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		l = prometheus.Labels{CodeLabel: "200", MethodLabel: http.MethodGet}
+	)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Equal(1.0, testutil.ToFloat64(si.count.With(l)))
+
+	suite.Run("Code", func() {
+		suite.Equal(3, si.DeleteWhere(prometheus.Labels{CodeLabel: "200"}))
+
+		for n := 0; n < 10; n++ {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+
+		suite.Equal(10.0, testutil.ToFloat64(si.count.With(l)))
+		suite.Equal(1, testutil.CollectAndCount(si.duration))
+		suite.Equal(1, testutil.CollectAndCount(si.requestSize))
+	})
+
+	suite.Run("Method", func() {
+		suite.Equal(3, si.DeleteWhere(prometheus.Labels{MethodLabel: http.MethodGet}))
+		suite.Zero(si.DeleteWhere(prometheus.Labels{}))
+
+		for n := 0; n < 3; n++ {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+
+		suite.Equal(3.0, testutil.ToFloat64(si.count.With(l)))
+		suite.Equal(1, testutil.CollectAndCount(si.duration))
+		suite.Equal(1, testutil.CollectAndCount(si.requestSize))
+	})
+
+	suite.Len(*si.batcher.batches.Load(), 1)
+}
+
+func (suite *BatchSuite) TestServerConcurrent() {
+	si, err := ServerBundle{
+		BatchUpdates: true,
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	var (
+		h  = si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		wg sync.WaitGroup
+	)
+
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}
+		}()
+	}
+
+	wg.Wait()
+	suite.Len(*si.batcher.batches.Load(), 1)
+	suite.Equal(
+		800.0,
+		testutil.ToFloat64(si.count.With(prometheus.Labels{CodeLabel: "200", MethodLabel: http.MethodGet})),
+	)
+}
+
+func (suite *BatchSuite) TestClient() {
+	ci, err := ClientBundle{
+		BatchUpdates: true,
+	}.NewInstrumenter(ClientLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(ci.batcher)
+
+	c := ci.Then(client.Func(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusAccepted, Proto: "HTTP/1.1"}, nil
+	}))
+
+	for n := 0; n < 3; n++ {
+		response, err := c.Do(httptest.NewRequest(http.MethodPost, "/", nil))
+		suite.Require().NoError(err)
+		suite.Require().NotNil(response)
+	}
+
+	suite.Len(*ci.batcher.batches.Load(), 1)
+	suite.Equal(
+		3.0,
+		testutil.ToFloat64(ci.count.With(prometheus.Labels{CodeLabel: "202", MethodLabel: http.MethodPost})),
+	)
+
+	suite.Equal(1, testutil.CollectAndCount(ci.requestSize))
+	suite.Zero(testutil.CollectAndCount(ci.errorCount))
+}
+
+func TestBatch(t *testing.T) {
+	suite.Run(t, new(BatchSuite))
+}
+
+func benchmarkServerParallel(b *testing.B, sb ServerBundle) {
+	_, r, err := touchstone.New(touchstone.Config{
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	})
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	si, err := sb.NewInstrumenter(ServerLabel, "main")(
+		touchstone.NewFactory(touchstone.Config{}, nil, r),
+	)
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	codes := []int{http.StatusOK, http.StatusNotFound, http.StatusInternalServerError}
+	h := si.Then(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(r.Header.Get("X-Code"))
+		rw.WriteHeader(code)
+	}))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		response := httptest.NewRecorder()
+		requests := make([]*http.Request, len(codes))
+		for j, code := range codes {
+			requests[j] = httptest.NewRequest(http.MethodGet, "/", nil)
+			requests[j].Header.Set("X-Code", strconv.Itoa(code))
+		}
+
+		for n := 0; pb.Next(); n++ {
+			h.ServeHTTP(response, requests[n%len(requests)])
+		}
+	})
+}
+
+func BenchmarkServerInstrumenterParallel(b *testing.B) {
+	b.Run("Unbatched", func(b *testing.B) {
+		benchmarkServerParallel(b, ServerBundle{Responses: &Responses{}})
+	})
+
+	b.Run("Batched", func(b *testing.B) {
+		benchmarkServerParallel(b, ServerBundle{Responses: &Responses{}, BatchUpdates: true})
+	})
+}
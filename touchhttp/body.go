@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+)
+
+const (
+	// DefaultServerBodyReadDuration is the default name of the optional observer that
+	// records the time handlers spent reading request bodies.
+	DefaultServerBodyReadDuration = "server_request_body_read_duration_ms"
+)
+
+var defaultServerBodyReadDuration = prometheus.HistogramOpts{
+	Name:    DefaultServerBodyReadDuration,
+	Help:    "the time in milliseconds that handlers spent reading request bodies",
+	Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000, 10000},
+}
+
+// BodyRead describes the optional recording of how long handlers spend reading
+// request bodies.  This time is included in the server's duration metric, but
+// separating it out distinguishes slow clients, e.g. slowloris attacks, from slow
+// processing.
+//
+// Only the time spent blocked in reads of the body is recorded, and requests without
+// a body are not observed.  The observer has the same labels as the duration metric.
+type BodyRead struct {
+	// Duration describes the options for the body read duration observer.  If set, it
+	// must be either a prometheus.HistogramOpts or a prometheus.SummaryOpts.
+	Duration interface{}
+}
+
+// newObserverVec creates the body read duration observer for a server.
+func (br BodyRead) newObserverVec(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+	var opts interface{}
+	switch t := br.Duration.(type) {
+	case nil:
+		clone := defaultServerBodyReadDuration
+		opts = clone
+
+	case prometheus.HistogramOpts:
+		touchstone.ApplyDefaults(&t, defaultServerBodyReadDuration)
+		opts = t
+
+	case prometheus.SummaryOpts:
+		touchstone.ApplyDefaults(&t, defaultServerBodyReadDuration)
+		opts = t
+
+	default:
+		return nil, errors.New("BodyRead.Duration must be nil, a prometheus.HistogramOpts, or a prometheus.SummaryOpts")
+	}
+
+	return newObserverVec(f, opts, labelNames, curry)
+}
+
+// countingBody is a request body that counts the bytes read from it and, optionally,
+// the time spent reading.  A transport may read a request body on a separate goroutine,
+// so the count and elapsed time are atomic.
+type countingBody struct {
+	io.ReadCloser
+	n atomic.Int64
+
+	// now is the optional source of time used to measure reads
+	now     func() time.Time
+	elapsed atomic.Int64
+}
+
+func (cb *countingBody) Read(p []byte) (n int, err error) {
+	if cb.now == nil {
+		n, err = cb.ReadCloser.Read(p)
+	} else {
+		start := cb.now()
+		n, err = cb.ReadCloser.Read(p)
+		cb.elapsed.Add(int64(cb.now().Sub(start)))
+	}
+
+	cb.n.Add(int64(n))
+	return
+}
+
+// count returns the number of bytes read so far.
+func (cb *countingBody) count() int64 {
+	return cb.n.Load()
+}
+
+// duration returns the total time spent reading so far.  This is always zero
+// if the body was not created with a source of time.
+func (cb *countingBody) duration() time.Duration {
+	return time.Duration(cb.elapsed.Load())
+}
+
+// countBody returns a shallow copy of the request whose body counts the bytes read.
+// If now is not nil, the body also measures the time spent reading.  If the request
+// has no body, this function returns the original request and a nil countingBody.
+func countBody(request *http.Request, now func() time.Time) (*http.Request, *countingBody) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return request, nil
+	}
+
+	body := &countingBody{ReadCloser: request.Body, now: now}
+	clone := *request
+	clone.Body = body
+	return &clone, body
+}
@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+)
+
+type BodySuite struct {
+	BundleSuite
+}
+
+func (suite *BodySuite) TestCountBody() {
+	suite.Run("NoBody", func() {
+		for _, body := range []io.Reader{nil, http.NoBody} {
+			request := httptest.NewRequest(http.MethodGet, "/", body)
+			clone, cb := countBody(request, nil)
+			suite.Same(request, clone)
+			suite.Nil(cb)
+		}
+	})
+
+	suite.Run("Body", func() {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello, world"))
+		clone, cb := countBody(request, nil)
+		suite.Require().NotNil(cb)
+		suite.NotSame(request, clone)
+
+		data, err := io.ReadAll(clone.Body)
+		suite.NoError(err)
+		suite.Equal("hello, world", string(data))
+		suite.Equal(int64(12), cb.count())
+		suite.NoError(clone.Body.Close())
+	})
+}
+
+// received starts a server that records the size of each request body it reads.
+func (suite *BodySuite) received() (*httptest.Server, *[]int64) {
+	sizes := new([]int64)
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		n, err := io.Copy(io.Discard, r.Body)
+		suite.NoError(err)
+		*sizes = append(*sizes, n)
+	}))
+
+	return server, sizes
+}
+
+func (suite *BodySuite) TestCountRequestBodies() {
+	server, sizes := suite.received()
+	defer server.Close()
+
+	var observations []Observation
+	ci, err := ClientBundle{
+		CountRequestBodies: true,
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+	c := ci.Then(server.Client())
+
+	// a multipart body streamed through a pipe has no content length
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		part, _ := mw.CreateFormFile("file", "test.txt")
+		_, _ = part.Write(bytes.Repeat([]byte("x"), 4096))
+		_ = mw.Close()
+		_ = pw.Close()
+	}()
+
+	request, err := http.NewRequest(http.MethodPost, server.URL, pr)
+	suite.Require().NoError(err)
+	request.Header.Set("Content-Type", mw.FormDataContentType())
+	response, err := c.Do(request)
+	suite.Require().NoError(err)
+	response.Body.Close()
+
+	// a gzipped body is counted as sent, i.e. compressed
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, _ = gw.Write(bytes.Repeat([]byte("y"), 4096))
+	suite.Require().NoError(gw.Close())
+
+	request, err = http.NewRequest(http.MethodPost, server.URL, io.NopCloser(&compressed))
+	suite.Require().NoError(err)
+	request.Header.Set("Content-Encoding", "gzip")
+	response, err = c.Do(request)
+	suite.Require().NoError(err)
+	response.Body.Close()
+
+	// requests without bodies are unaffected
+	request, err = http.NewRequest(http.MethodGet, server.URL, nil)
+	suite.Require().NoError(err)
+	response, err = c.Do(request)
+	suite.Require().NoError(err)
+	response.Body.Close()
+
+	suite.Require().Len(*sizes, 3)
+	suite.Require().Len(observations, 3)
+	for i, o := range observations {
+		suite.Equal((*sizes)[i], o.RequestSize)
+	}
+
+	suite.Greater(observations[0].RequestSize, int64(4096))
+	suite.Less(observations[1].RequestSize, int64(4096))
+	suite.Zero(observations[2].RequestSize)
+}
+
+func (suite *BodySuite) TestContentLength() {
+	server, _ := suite.received()
+	defer server.Close()
+
+	var observations []Observation
+	ci, err := ClientBundle{
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("streamed"))
+		_ = pw.Close()
+	}()
+
+	request, err := http.NewRequest(http.MethodPost, server.URL, pr)
+	suite.Require().NoError(err)
+	response, err := ci.Then(server.Client()).Do(request)
+	suite.Require().NoError(err)
+	response.Body.Close()
+
+	// without counting, a streaming body has an unknown size, reported as zero
+	suite.Require().Len(observations, 1)
+	suite.Zero(observations[0].RequestSize)
+}
+
+func (suite *BodySuite) TestBodyRead() {
+	si, err := ServerBundle{
+		BodyRead: &BodyRead{},
+		Clock:    suite.clock(time.Millisecond),
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(si.bodyReadDuration)
+
+	h := si.Then(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_, err := io.Copy(io.Discard, r.Body)
+		suite.NoError(err)
+	}))
+
+	// each read advances the clock by one step, and io.Copy reads until EOF
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello, world")))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)) // not observed
+
+	hd, err := touchstone.HistogramValue(si.bodyReadDuration, prometheus.Labels{MethodLabel: http.MethodPost})
+	suite.Require().NoError(err)
+	suite.Equal(uint64(1), hd.Count)
+	suite.Equal(2.0, hd.Sum)
+
+	_, err = touchstone.HistogramValue(si.bodyReadDuration, prometheus.Labels{MethodLabel: http.MethodGet})
+	suite.ErrorIs(err, touchstone.ErrNoSuchSeries)
+
+	suite.Run("Summary", func() {
+		si, err := ServerBundle{
+			BodyRead: &BodyRead{Duration: prometheus.SummaryOpts{Name: "custom_body_read"}},
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.Require().NoError(err)
+		suite.Require().NotNil(si.bodyReadDuration)
+	})
+
+	suite.Run("InvalidDuration", func() {
+		_, err := ServerBundle{
+			BodyRead: &BodyRead{Duration: prometheus.GaugeOpts{}},
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.Error(err)
+	})
+}
+
+func TestBody(t *testing.T) {
+	suite.Run(t, new(BodySuite))
+}
@@ -6,10 +6,11 @@ package touchhttp
 import (
 	"errors"
 	"fmt"
-	"time"
+	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/xmidt-org/touchstone"
+	"go.uber.org/fx"
 	"go.uber.org/multierr"
 )
 
@@ -49,6 +50,14 @@ const (
 	// DefaultClientErrorCount is the default name of the count of total number of errors
 	// (nil responses) that occurred since startup.
 	DefaultClientErrorCount = "client_error_count"
+
+	// DefaultClientProtocolCount is the default name of the optional counter that tracks
+	// responses by HTTP protocol version.
+	DefaultClientProtocolCount = "client_response_protocol_count"
+
+	// DefaultClientRedirectCount is the default name of the optional counter that tracks
+	// the number of redirects followed by a client.
+	DefaultClientRedirectCount = "client_redirect_count"
 )
 
 var (
@@ -112,6 +121,16 @@ var (
 		Name: DefaultClientErrorCount,
 		Help: "the total number of errors (nil responses) since startup",
 	}
+
+	defaultClientProtocolCount = prometheus.CounterOpts{
+		Name: DefaultClientProtocolCount,
+		Help: "the total number of responses received since startup, by HTTP protocol version",
+	}
+
+	defaultClientRedirectCount = prometheus.CounterOpts{
+		Name: DefaultClientRedirectCount,
+		Help: "the total number of redirects followed since startup",
+	}
 )
 
 // labelNames takes a sequence of name/value pairs and converts that into
@@ -139,34 +158,134 @@ func labelNames(lvs []string) (names []string, curry prometheus.Labels, err erro
 	return
 }
 
-func newCounterVec(f *touchstone.Factory, o prometheus.CounterOpts, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
-	cv, err := f.NewCounterVec(o, labelNames...)
-	err = touchstone.ExistingCollector(&cv, err)
+// newLabelNames converts the extra label names and values given to an instrumenter into
+// the extra label names, the full label names of the metrics for each transaction, and the
+// curried labels.  The full label names are the extra names plus the code and method labels.
+func newLabelNames(namesAndValues []string) (extraNames, fullNames []string, curry prometheus.Labels, err error) {
+	extraNames, curry, err = labelNames(namesAndValues)
 	if err == nil {
-		cv, err = cv.CurryWith(curry)
+		fullNames = make([]string, 0, len(extraNames)+2)
+		fullNames = append(fullNames, extraNames...)
+		fullNames = append(fullNames, CodeLabel, MethodLabel)
+	}
+
+	return
+}
+
+// clockOf returns the given clock, or the clock of the MetricFactory if it is nil.
+func clockOf(f touchstone.MetricFactory, c touchstone.Clock) touchstone.Clock {
+	if c == nil {
+		c = f.Clock()
+	}
+
+	return c
+}
+
+// coreMetrics is implemented by both ServerBundle and ClientBundle to create the
+// core metrics of their instrumenters.
+type coreMetrics interface {
+	newRequestCount(touchstone.MetricFactory, []string, prometheus.Labels) (*prometheus.CounterVec, error)
+	newInFlight(touchstone.MetricFactory, []string, prometheus.Labels) (prometheus.Gauge, error)
+	newRequestSize(touchstone.MetricFactory, []string, prometheus.Labels) (prometheus.ObserverVec, error)
+	newDuration(touchstone.MetricFactory, []string, prometheus.Labels) (prometheus.ObserverVec, error)
+}
+
+// newCoreMetrics creates the request counter, in-flight gauge, request size, and duration
+// of this instrumenter.  Every metric is attempted, and all the errors are returned.
+func (i *instrumenter) newCoreMetrics(cm coreMetrics, f touchstone.MetricFactory, extraNames, fullNames []string, curry prometheus.Labels) (err error) {
+	var metricErr error
+	i.count, metricErr = cm.newRequestCount(f, fullNames, curry)
+	multierr.AppendInto(&err, metricErr)
+
+	// InFlight is slightly different, as it doesn't have code or method labels
+	i.inFlight, metricErr = cm.newInFlight(f, extraNames, curry)
+	multierr.AppendInto(&err, metricErr)
+
+	i.requestSize, metricErr = cm.newRequestSize(f, fullNames, curry)
+	multierr.AppendInto(&err, metricErr)
+
+	i.duration, metricErr = cm.newDuration(f, fullNames, curry)
+	multierr.AppendInto(&err, metricErr)
+	return
+}
+
+// preinitialize creates the given series of the core metrics, including any migrated ones.
+func (i instrumenter) preinitialize(labelSets []prometheus.Labels) (err error) {
+	multierr.AppendInto(&err, touchstone.Preinitialize(i.count, labelSets...))
+	multierr.AppendInto(&err, touchstone.Preinitialize(i.requestSize, labelSets...))
+	multierr.AppendInto(&err, touchstone.Preinitialize(i.duration, labelSets...))
+	if i.migration != nil {
+		multierr.AppendInto(&err, touchstone.Preinitialize(i.migration.count, labelSets...))
+		multierr.AppendInto(&err, touchstone.Preinitialize(i.migration.duration, labelSets...))
+	}
+
+	return
+}
+
+// preinitialized applies the CodeMapper and Methods of an instrumenter to label combinations,
+// so that the preinitialized series are the ones that transactions actually record.
+func (i instrumenter) preinitialized(ls []Labels) []Labels {
+	formatted := make([]Labels, len(ls))
+	for j, l := range ls {
+		f := make(Labels, len(l))
+		for k, v := range l {
+			f[k] = v
+		}
+
+		if code, err := strconv.Atoi(l[CodeLabel]); err == nil && i.codeMapper != nil {
+			f.SetCode(i.codeMapper(code))
+		}
+
+		if method, ok := l[MethodLabel]; ok {
+			f.set(MethodLabel, i.methods.format(method))
+		}
+
+		formatted[j] = f
+	}
+
+	return formatted
+}
+
+// preinitializeLabels converts touchhttp Labels into the form accepted by touchstone.Preinitialize.
+func preinitializeLabels(ls []Labels) []prometheus.Labels {
+	labelSets := make([]prometheus.Labels, len(ls))
+	for i, l := range ls {
+		labelSets[i] = prometheus.Labels(l)
 	}
 
-	return cv, err
+	return labelSets
 }
 
-func newGauge(f *touchstone.Factory, o prometheus.GaugeOpts, labelNames []string, curry prometheus.Labels) (prometheus.Gauge, error) {
-	gv, err := f.NewGaugeVec(o, labelNames...)
-	err = touchstone.ExistingCollector(&gv, err)
+func newCounterVec(f touchstone.MetricFactory, o prometheus.CounterOpts, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
+	return touchstone.NewCurriedCounterVec(f, o, labelNames, curry)
+}
+
+func newGauge(f touchstone.MetricFactory, o prometheus.GaugeOpts, labelNames []string, curry prometheus.Labels) (prometheus.Gauge, error) {
+	gv, err := touchstone.NewCurriedGaugeVec(f, o, labelNames, curry)
 	if err == nil {
-		return gv.GetMetricWith(curry)
+		return gv.GetMetricWith(nil)
 	}
 
 	return nil, err
 }
 
-func newObserverVec(f *touchstone.Factory, o interface{}, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
-	ov, err := f.NewObserverVec(o, labelNames...)
-	err = touchstone.ExistingCollector(&ov, err)
-	if err == nil {
-		ov, err = ov.CurryWith(curry)
+// latencyDefaults returns the default duration options with the buckets of the given
+// latency class, if any.
+func latencyDefaults(defaults prometheus.HistogramOpts, lc LatencyClass) (prometheus.HistogramOpts, error) {
+	if len(lc) > 0 {
+		buckets, err := lc.Buckets()
+		if err != nil {
+			return prometheus.HistogramOpts{}, err
+		}
+
+		defaults.Buckets = buckets
 	}
 
-	return ov, err
+	return defaults, nil
+}
+
+func newObserverVec(f touchstone.MetricFactory, o interface{}, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+	return touchstone.NewCurriedObserverVec(f, o, labelNames, curry)
 }
 
 type ServerBundle struct {
@@ -186,22 +305,112 @@ type ServerBundle struct {
 	// The type of Opts struct will determine the type of metric created.
 	Duration interface{}
 
-	// Now is the strategy for extracting the current system time.  If unset,
-	// time.Now is used.
-	Now func() time.Time
+	// LatencyClass optionally selects the preset bucket boundaries of the duration
+	// histogram.  Buckets set explicitly in Duration take precedence, and summaries
+	// are unaffected.  If unset, this package's default buckets are used.
+	LatencyClass LatencyClass
+
+	// Sampling enables the optional recording of full resolution durations for a
+	// sample of transactions.  If this field is nil, no sampling is done.
+	Sampling *Sampling
+
+	// QueueTime enables the optional recording of how long requests spent upstream,
+	// as reported by a request header.  If this field is nil, queue time is not recorded.
+	QueueTime *QueueTime
+
+	// BodyRead enables the optional recording of how long handlers spend reading
+	// request bodies.  If this field is nil, body read time is not recorded.
+	BodyRead *BodyRead
+
+	// Responses enables the optional recording of response sizes and of handlers
+	// that call WriteHeader after the final response header.  If this field is nil, neither is recorded.
+	Responses *Responses
+
+	// Phases enables the optional recording of the named phases of requests, which
+	// handlers report through the ServerTransaction in each request's context.  If
+	// this field is nil, phases are still available to hooks but are not recorded.
+	Phases *Phases
+
+	// Transactions places a ServerTransaction into the context of each request, so that
+	// handlers can use FromContext.  This is always done when Phases or Hooks are set.
+	// Otherwise, it is only done when this field is true, as each transaction then costs
+	// a few more allocations.
+	Transactions bool
+
+	// Tenancy optionally partitions metrics by tenant.  If this field is nil,
+	// the TenantLabel is not used.
+	Tenancy *Tenancy
+
+	// Migration optionally emits the request counter and duration observer under new
+	// names, alongside the original metric families, while dashboards are migrated.  If
+	// this field is nil, only the original families are emitted.
+	Migration *Migration
+
+	// Connection optionally adds labels describing the TLS version, TLS cipher suite,
+	// and IP family of the connection that carried each request.  If this field is nil,
+	// none of these labels are used.
+	Connection *Connection
+
+	// ExemplarExtractor optionally produces an exemplar for each transaction, e.g. from a
+	// trace ID in the request's context, which is attached to the request counter and
+	// duration observer.  If this field is nil, no exemplars are recorded.
+	ExemplarExtractor ExemplarExtractor
+
+	// BatchUpdates controls how the children of the request counter, duration, and size
+	// observers are found.  By default, each of these metrics looks up its child for every
+	// transaction.  If this field is true, the children for each combination of label values
+	// are looked up once and cached, so that each transaction updates all of them after a
+	// single lookup in a lock-free cache.  This reduces contention in servers with many
+	// concurrent requests, at the cost of memory for each combination of label values.
+	//
+	// Series must then be deleted with ServerInstrumenter.DeleteWhere, which replaces the cached
+	// children of deleted series.  Updates to series deleted directly from the metrics,
+	// e.g. by Reset, are lost.
+	BatchUpdates bool
+
+	// CodeMapper optionally replaces the status code of each transaction before it is
+	// recorded, e.g. to collapse nonstandard codes onto standard ones.  See CodeMap and
+	// CodeRange.  If this field is nil, status codes are recorded as is.
+	CodeMapper CodeMapper
+
+	// Methods is the optional set of HTTP methods recorded in the MethodLabel.  Any other
+	// standard method, e.g. TRACE or CONNECT, is recorded as MethodOther, which trims the
+	// series for rarely used methods.  Nonstandard methods may be included, and any that
+	// are not are recorded as MethodUnrecognized.  If this field is empty, all standard
+	// methods are recorded.
+	//
+	// This set and the CodeMapper are also applied to Preinitialize.
+	Methods []string
+
+	// Preinitialize are the code and method label combinations created with zero values
+	// at startup, so that these series exist before they are first used.  Each element
+	// must contain only the CodeLabel and MethodLabel.  See LabelCombinations.
+	Preinitialize []Labels
+
+	// WriteErrorHooks are optional callbacks invoked when a metric cannot be written
+	// at runtime, e.g. because of an invalid label value.  Such failures are always
+	// counted by the touchstone.WriteErrorCountName counter.  See touchstone.LogWriteErrors.
+	WriteErrorHooks []touchstone.WriteErrorHook
+
+	// Hooks are optional callbacks invoked after each transaction has been recorded.
+	Hooks []Hook
+
+	// Clock is the source of the current time.  If unset, the Clock of the
+	// MetricFactory is used.
+	Clock touchstone.Clock
 }
 
-func (sb ServerBundle) newRequestCount(f *touchstone.Factory, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
+func (sb ServerBundle) newRequestCount(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
 	touchstone.ApplyDefaults(&sb.Count, defaultServerCount)
 	return newCounterVec(f, sb.Count, labelNames, curry)
 }
 
-func (sb ServerBundle) newInFlight(f *touchstone.Factory, labelNames []string, curry prometheus.Labels) (prometheus.Gauge, error) {
+func (sb ServerBundle) newInFlight(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (prometheus.Gauge, error) {
 	touchstone.ApplyDefaults(&sb.InFlight, defaultServerInFlight)
 	return newGauge(f, sb.InFlight, labelNames, curry)
 }
 
-func (sb ServerBundle) newRequestSize(f *touchstone.Factory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+func (sb ServerBundle) newRequestSize(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
 	var opts interface{}
 	if sb.RequestSize != nil {
 		switch t := sb.RequestSize.(type) {
@@ -224,24 +433,28 @@ func (sb ServerBundle) newRequestSize(f *touchstone.Factory, labelNames []string
 	return newObserverVec(f, opts, labelNames, curry)
 }
 
-func (sb ServerBundle) newDuration(f *touchstone.Factory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+func (sb ServerBundle) newDuration(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+	defaults, err := latencyDefaults(defaultServerDuration, sb.LatencyClass)
+	if err != nil {
+		return nil, err
+	}
+
 	var opts interface{}
 	if sb.Duration != nil {
 		switch t := sb.Duration.(type) {
 		case prometheus.HistogramOpts:
-			touchstone.ApplyDefaults(&t, defaultServerDuration)
+			touchstone.ApplyDefaults(&t, defaults)
 			opts = t
 
 		case prometheus.SummaryOpts:
-			touchstone.ApplyDefaults(&t, defaultServerDuration)
+			touchstone.ApplyDefaults(&t, defaults)
 			opts = t
 
 		default:
 			return nil, errors.New("ServerBundle.Duration must be nil, a prometheus.HistogramOpts, or a prometheus.SummaryOpts")
 		}
 	} else {
-		clone := defaultServerDuration
-		opts = clone
+		opts = defaults
 	}
 
 	return newObserverVec(f, opts, labelNames, curry)
@@ -276,45 +489,163 @@ func (sb ServerBundle) newDuration(f *touchstone.Factory, labelNames []string, c
 //	    },
 //	  ),
 //	)
-func (sb ServerBundle) NewInstrumenter(namesAndValues ...string) func(*touchstone.Factory) (ServerInstrumenter, error) {
-	return func(f *touchstone.Factory) (si ServerInstrumenter, err error) {
-		var (
-			extraNames []string
-			curry      prometheus.Labels
-		)
-
-		extraNames, curry, err = labelNames(namesAndValues)
+func (sb ServerBundle) NewInstrumenter(namesAndValues ...string) func(touchstone.MetricFactory) (ServerInstrumenter, error) {
+	return func(f touchstone.MetricFactory) (si ServerInstrumenter, err error) {
+		extraNames, fullNames, curry, err := sb.newLabels(f, &si, namesAndValues)
 		if err != nil {
 			return
 		}
 
-		// fullNames will include the extra names plus code and method labels
-		fullNames := make([]string, 0, len(extraNames)+2)
-		fullNames = append(fullNames, extraNames...)
-		fullNames = append(fullNames, CodeLabel, MethodLabel)
+		if err = sb.configure(f, &si, curry); err != nil {
+			return
+		}
 
-		si.now = sb.Now
-		if si.now == nil {
-			si.now = time.Now
+		err = si.newCoreMetrics(sb, f, extraNames, fullNames, curry)
+		if err == nil {
+			err = sb.newMigration(f, &si, fullNames, curry)
 		}
 
-		var metricErr error
+		multierr.AppendInto(&err, sb.newOptionalMetrics(f, &si, extraNames, fullNames, curry))
+		if err == nil && len(sb.Preinitialize) > 0 {
+			err = si.preinitialize(sb.preinitializeLabels(si.preinitialized(sb.Preinitialize)))
+		}
+
+		return
+	}
+}
+
+// newLabels determines the label names of a server instrumenter's metrics, setting up
+// the tenant and connection labels if this bundle uses them.
+func (sb ServerBundle) newLabels(f touchstone.MetricFactory, si *ServerInstrumenter, namesAndValues []string) (extraNames, fullNames []string, curry prometheus.Labels, err error) {
+	extraNames, fullNames, curry, err = newLabelNames(namesAndValues)
+	if err == nil && sb.Tenancy != nil {
+		si.tenancy, err = sb.Tenancy.new(f, defaultServerTenants, extraNames, curry)
+		fullNames = append(fullNames, TenantLabel)
+	}
+
+	if err == nil && sb.Connection != nil {
+		si.connection, err = sb.Connection.new(curry)
+		for _, cl := range si.connection {
+			fullNames = append(fullNames, cl.name)
+		}
+	}
 
-		si.count, metricErr = sb.newRequestCount(f, fullNames, curry)
+	return
+}
+
+// configure applies the settings of this bundle that do not create metrics, including
+// whether transactions are attached to requests and whether updates are batched.
+func (sb ServerBundle) configure(f touchstone.MetricFactory, si *ServerInstrumenter, curry prometheus.Labels) (err error) {
+	si.hooks = sb.Hooks
+	si.transactions = sb.Transactions || sb.Phases != nil || len(sb.Hooks) > 0
+	si.codeMapper = sb.CodeMapper
+	si.exemplars = sb.ExemplarExtractor
+	if sb.BatchUpdates {
+		si.batcher = new(batcher)
+	}
+
+	si.curry = curry
+	si.methods = newMethodSet(sb.Methods)
+	si.now = clockOf(f, sb.Clock).Now
+	si.writeErrors, err = touchstone.NewWriteErrors(f, sb.WriteErrorHooks...)
+	return
+}
+
+// newMigration creates the migrated request counter and duration, if this bundle has a Migration.
+// This must only be done once the original duration has been created.
+func (sb ServerBundle) newMigration(f touchstone.MetricFactory, si *ServerInstrumenter, fullNames []string, curry prometheus.Labels) (err error) {
+	if sb.Migration != nil {
+		// the duration was created, so its defaults are known to be valid
+		legacyDefaults, _ := latencyDefaults(defaultServerDuration, sb.LatencyClass)
+		si.migration, err = sb.Migration.new(
+			f,
+			defaultMigratedServerCount,
+			migratedDurationDefaults(defaultMigratedServerDuration, sb.Duration, legacyDefaults),
+			&si.instrumenter,
+			fullNames,
+			curry,
+		)
+	}
+
+	return
+}
+
+// newOptionalMetrics creates the server metrics that this bundle enables: the sampled
+// duration, queue time, body read duration, response metrics, and phases.
+func (sb ServerBundle) newOptionalMetrics(f touchstone.MetricFactory, si *ServerInstrumenter, extraNames, fullNames []string, curry prometheus.Labels) (err error) {
+	var metricErr error
+	if sb.Sampling != nil {
+		si.sampledDuration, si.sample, metricErr = sb.Sampling.newObserverVec(f, defaultServerSampledDuration, fullNames, curry)
 		multierr.AppendInto(&err, metricErr)
+	}
 
-		// InFlight is slightly different, as it doesn't have code or method labels
-		si.inFlight, metricErr = sb.newInFlight(f, extraNames, curry)
+	if sb.QueueTime != nil {
+		si.queueTime, metricErr = sb.QueueTime.new(f, extraNames, curry)
 		multierr.AppendInto(&err, metricErr)
+		if si.queueTime != nil {
+			si.queueTime.methods = si.methods
+		}
+	}
 
-		si.requestSize, metricErr = sb.newRequestSize(f, fullNames, curry)
+	if sb.BodyRead != nil {
+		si.bodyReadDuration, metricErr = sb.BodyRead.newObserverVec(f, fullNames, curry)
 		multierr.AppendInto(&err, metricErr)
+	}
 
-		si.duration, metricErr = sb.newDuration(f, fullNames, curry)
+	if sb.Responses != nil {
+		si.responseSize, metricErr = sb.Responses.newResponseSize(f, fullNames, curry)
 		multierr.AppendInto(&err, metricErr)
 
-		return
+		si.superfluousWriteCount, metricErr = sb.Responses.newSuperfluousWriteHeaderCount(f, fullNames, curry)
+		multierr.AppendInto(&err, metricErr)
+	}
+
+	if sb.Phases != nil {
+		si.phaseDuration, metricErr = sb.Phases.newObserverVec(f, fullNames, curry)
+		multierr.AppendInto(&err, metricErr)
+		si.phases = sb.Phases.newPhaseSet()
+	}
+
+	return
+}
+
+// preinitializeLabels produces the label sets of the series to preinitialize from the
+// formatted combinations, adding the tenant and connection labels if this bundle uses them.
+func (sb ServerBundle) preinitializeLabels(combinations []Labels) []prometheus.Labels {
+	labelSets := preinitializeLabels(combinations)
+	if sb.Tenancy != nil {
+		labelSets = sb.Tenancy.preinitializeLabels(combinations)
 	}
+
+	if sb.Connection != nil {
+		labelSets = sb.Connection.preinitializeLabels(labelSets)
+	}
+
+	return labelSets
+}
+
+// NewInstrumenters returns an fx option that provides one named ServerInstrumenter for
+// each of the given server names.  Each instrumenter has the ServerLabel curried with its
+// server name, which is also its fx name.
+//
+// This method replaces an fx.Annotated for each server:
+//
+//	app := fx.New(
+//	  touchstone.Provide(), // bootstraps the metrics environment
+//
+//	  // provides ServerInstrumenters named "servers.main" and "servers.health"
+//	  touchhttp.ServerBundle{}.NewInstrumenters("servers.main", "servers.health"),
+//	)
+func (sb ServerBundle) NewInstrumenters(names ...string) fx.Option {
+	providers := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		providers = append(providers, fx.Annotated{
+			Name:   name,
+			Target: sb.NewInstrumenter(ServerLabel, name),
+		})
+	}
+
+	return fx.Provide(providers...)
 }
 
 type ClientBundle struct {
@@ -333,25 +664,130 @@ type ClientBundle struct {
 	// will result if this field is not either a prometheus.HistogramOpts or a prometheus.SummaryOpts.
 	Duration interface{}
 
+	// LatencyClass optionally selects the preset bucket boundaries of the duration
+	// histogram.  Buckets set explicitly in Duration take precedence, and summaries
+	// are unaffected.  If unset, this package's default buckets are used.
+	LatencyClass LatencyClass
+
 	// ErrorCount describes the options for the error counter.
 	ErrorCount prometheus.CounterOpts
 
-	// Now is the strategy for extracting the current system time.  If unset,
-	// time.Now is used.
-	Now func() time.Time
+	// ErrorCoder optionally maps the errors from transactions that produced no
+	// response onto synthetic status codes, e.g. TransportErrorCoder.  If this field
+	// is nil, such transactions are recorded with StatusNoResponse.
+	ErrorCoder ErrorCoder
+
+	// LastError describes the options for the optional gauge describing the most recent
+	// transaction error.  The gauge has a single series, with the value 1, labeled with
+	// any extra labels, the touchstone.ReasonLabel, and the touchstone.MessageHashLabel.
+	// The reason is the code recorded in the CodeLabel for the error.  Unset options default
+	// to those derived from ErrorCount, e.g. client_last_error_info.  If this field is nil,
+	// this gauge is not created.
+	LastError *prometheus.GaugeOpts
+
+	// ProtocolCount describes the options for the optional counter of responses by
+	// HTTP protocol version, e.g. HTTP/1.1 or HTTP/2.0.  The version is recorded in
+	// the ProtocolLabel.  If this field is nil, this counter is not created.
+	ProtocolCount *prometheus.CounterOpts
+
+	// RedirectCount describes the options for the optional counter of redirects followed
+	// by the client, labeled by request method.  If this field is nil, this counter is
+	// not created.
+	RedirectCount *prometheus.CounterOpts
+
+	// RetryCount describes the options for the optional counter of retries made by an
+	// httpaux retry.Client that encloses the instrumented client, labeled by request
+	// method.  If this field is nil, this counter is not created.
+	RetryCount *prometheus.CounterOpts
+
+	// RejectedCount describes the options for the optional counter of requests rejected
+	// by the ClientInstrumenter.Busy middleware, labeled by request method.  If this
+	// field is nil, this counter is not created.
+	RejectedCount *prometheus.CounterOpts
+
+	// CountRequestBodies controls how the request size is determined.  By default,
+	// the request's ContentLength is used, which is unknown and so reported as zero or -1
+	// for streaming bodies, e.g. bodies produced through an io.Pipe by a multipart.Writer.
+	// If this field is true, outgoing bodies are instead wrapped with a reader that
+	// counts the bytes the transport actually sends, whatever their encoding.
+	//
+	// The count is taken when the transaction ends.  A transport may continue to send
+	// a body after a response has been received, in which case those bytes are not counted.
+	CountRequestBodies bool
+
+	// Sampling enables the optional recording of full resolution durations for a
+	// sample of transactions.  If this field is nil, no sampling is done.
+	Sampling *Sampling
+
+	// Tenancy optionally partitions metrics by tenant.  If this field is nil,
+	// the TenantLabel is not used.
+	Tenancy *Tenancy
+
+	// Migration optionally emits the request counter and duration observer under new
+	// names, alongside the original metric families, while dashboards are migrated.  If
+	// this field is nil, only the original families are emitted.
+	Migration *Migration
+
+	// ExemplarExtractor optionally produces an exemplar for each transaction, e.g. from a
+	// trace ID in the request's context, which is attached to the request counter and
+	// duration observer.  If this field is nil, no exemplars are recorded.
+	ExemplarExtractor ExemplarExtractor
+
+	// BatchUpdates controls how the children of the request counter, duration, and size
+	// observers are found.  By default, each of these metrics looks up its child for every
+	// transaction.  If this field is true, the children for each combination of label values
+	// are looked up once and cached, so that each transaction updates all of them after a
+	// single lookup in a lock-free cache.  This reduces contention in servers with many
+	// concurrent requests, at the cost of memory for each combination of label values.
+	//
+	// Series must then be deleted with ClientInstrumenter.DeleteWhere, which replaces the cached
+	// children of deleted series.  Updates to series deleted directly from the metrics,
+	// e.g. by Reset, are lost.
+	BatchUpdates bool
+
+	// CodeMapper optionally replaces the status code of each transaction before it is
+	// recorded, e.g. to collapse nonstandard codes onto standard ones.  See CodeMap and
+	// CodeRange.  If this field is nil, status codes are recorded as is.
+	CodeMapper CodeMapper
+
+	// Methods is the optional set of HTTP methods recorded in the MethodLabel.  Any other
+	// standard method, e.g. TRACE or CONNECT, is recorded as MethodOther, which trims the
+	// series for rarely used methods.  Nonstandard methods may be included, and any that
+	// are not are recorded as MethodUnrecognized.  If this field is empty, all standard
+	// methods are recorded.
+	//
+	// This set and the CodeMapper are also applied to Preinitialize.
+	Methods []string
+
+	// Preinitialize are the code and method label combinations created with zero values
+	// at startup, so that these series exist before they are first used.  Each element
+	// must contain only the CodeLabel and MethodLabel.  See LabelCombinations.
+	Preinitialize []Labels
+
+	// WriteErrorHooks are optional callbacks invoked when a metric cannot be written
+	// at runtime, e.g. because of an invalid label value.  Such failures are always
+	// counted by the touchstone.WriteErrorCountName counter.  See touchstone.LogWriteErrors.
+	WriteErrorHooks []touchstone.WriteErrorHook
+
+	// Hooks are optional callbacks invoked after each transaction has been recorded.
+	Hooks []Hook
+
+	// Clock is the source of the current time.  If unset, the Clock of the
+	// MetricFactory is used.
+	Clock touchstone.Clock
 }
 
-func (cb ClientBundle) newRequestCount(f *touchstone.Factory, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
+func (cb ClientBundle) newRequestCount(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
 	touchstone.ApplyDefaults(&cb.Count, defaultClientCount)
 	return newCounterVec(f, cb.Count, labelNames, curry)
 }
 
-func (cb ClientBundle) newInFlight(f *touchstone.Factory, labelNames []string, curry prometheus.Labels) (prometheus.Gauge, error) {
+func (cb ClientBundle) newInFlight(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (prometheus.Gauge, error) {
 	touchstone.ApplyDefaults(&cb.InFlight, defaultClientInFlight)
 	return newGauge(f, cb.InFlight, labelNames, curry)
 }
 
-func (cb ClientBundle) newRequestSize(f *touchstone.Factory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+func (cb ClientBundle) newRequestSize(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
 	var opts interface{}
 	if cb.RequestSize != nil {
 		switch t := cb.RequestSize.(type) {
@@ -374,36 +810,59 @@ func (cb ClientBundle) newRequestSize(f *touchstone.Factory, labelNames []string
 	return newObserverVec(f, opts, labelNames, curry)
 }
 
-func (cb ClientBundle) newDuration(f *touchstone.Factory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+func (cb ClientBundle) newDuration(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+	defaults, err := latencyDefaults(defaultClientDuration, cb.LatencyClass)
+	if err != nil {
+		return nil, err
+	}
+
 	var opts interface{}
 	if cb.Duration != nil {
 		switch t := cb.Duration.(type) {
 		case prometheus.HistogramOpts:
-			touchstone.ApplyDefaults(&t, defaultClientDuration)
+			touchstone.ApplyDefaults(&t, defaults)
 			opts = t
 
 		case prometheus.SummaryOpts:
-			touchstone.ApplyDefaults(&t, defaultClientDuration)
+			touchstone.ApplyDefaults(&t, defaults)
 			opts = t
 
 		default:
 			return nil, errors.New("ClientBundle.Duration must be nil, a prometheus.HistogramOpts, or a prometheus.SummaryOpts")
 		}
 	} else {
-		clone := defaultClientDuration
-		opts = clone
+		opts = defaults
 	}
 
 	return newObserverVec(f, opts, labelNames, curry)
 }
 
-func (cb ClientBundle) newErrorCount(f *touchstone.Factory, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
+func (cb ClientBundle) newErrorCount(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
 	touchstone.ApplyDefaults(&cb.ErrorCount, defaultClientErrorCount)
 	return newCounterVec(f, cb.ErrorCount, labelNames, curry)
 }
 
+// newOptionalCount creates one of the optional client counters.  If o is nil, no
+// counter is created and this method returns nil.
+func (cb ClientBundle) newOptionalCount(f touchstone.MetricFactory, o *prometheus.CounterOpts, defaults prometheus.CounterOpts, extraNames []string, label string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
+	if o == nil {
+		return nil, nil
+	}
+
+	if _, reserved := curry[label]; reserved {
+		return nil, fmt.Errorf("%w: %s", ErrReservedLabelName, label)
+	}
+
+	clone := *o
+	touchstone.ApplyDefaults(&clone, defaults)
+	labelNames := make([]string, 0, len(extraNames)+1)
+	labelNames = append(labelNames, extraNames...)
+	labelNames = append(labelNames, label)
+	return newCounterVec(f, clone, labelNames, curry)
+}
+
 // NewInstrumenter creates a constructor that can be passed to fx.Provide.  The returned constructor
-// creates a ClientInstrumenter given a *touchstone.Factory.
+// creates a ClientInstrumenter given a touchstone.MetricFactory.
 //
 // Similar typical usage to ServerBundle.NewInstrumenter:
 //
@@ -423,46 +882,106 @@ func (cb ClientBundle) newErrorCount(f *touchstone.Factory, labelNames []string,
 //	    },
 //	  ),
 //	)
-func (cb ClientBundle) NewInstrumenter(namesAndValues ...string) func(*touchstone.Factory) (ClientInstrumenter, error) {
-	return func(f *touchstone.Factory) (ci ClientInstrumenter, err error) {
-		var (
-			extraNames []string
-			curry      prometheus.Labels
-		)
+func (cb ClientBundle) NewInstrumenter(namesAndValues ...string) func(touchstone.MetricFactory) (ClientInstrumenter, error) {
+	return func(f touchstone.MetricFactory) (ci ClientInstrumenter, err error) {
+		extraNames, fullNames, curry, err := newLabelNames(namesAndValues)
+		if err == nil && cb.Tenancy != nil {
+			ci.tenancy, err = cb.Tenancy.new(f, defaultClientTenants, extraNames, curry)
+			fullNames = append(fullNames, TenantLabel)
+		}
 
-		extraNames, curry, err = labelNames(namesAndValues)
 		if err != nil {
 			return
 		}
 
-		// fullNames will include the extra names plus code and method labels
-		fullNames := make([]string, 0, len(extraNames)+2)
-		fullNames = append(fullNames, extraNames...)
-		fullNames = append(fullNames, CodeLabel, MethodLabel)
+		if err = cb.configure(f, &ci); err != nil {
+			return
+		}
 
-		ci.now = cb.Now
-		if ci.now == nil {
-			ci.now = time.Now
+		err = ci.newCoreMetrics(cb, f, extraNames, fullNames, curry)
+		if err == nil {
+			err = cb.newMigration(f, &ci, fullNames, curry)
 		}
 
-		var metricErr error
+		multierr.AppendInto(&err, cb.newOptionalMetrics(f, &ci, extraNames, fullNames, curry))
+		if err == nil && len(cb.Preinitialize) > 0 {
+			combinations := ci.preinitialized(cb.Preinitialize)
+			labelSets := preinitializeLabels(combinations)
+			if cb.Tenancy != nil {
+				labelSets = cb.Tenancy.preinitializeLabels(combinations)
+			}
 
-		ci.count, metricErr = cb.newRequestCount(f, fullNames, curry)
-		multierr.AppendInto(&err, metricErr)
+			err = ci.preinitialize(labelSets)
+		}
 
-		// InFlight is slightly different, as it doesn't have code or method labels
-		ci.inFlight, metricErr = cb.newInFlight(f, extraNames, curry)
-		multierr.AppendInto(&err, metricErr)
+		return
+	}
+}
 
-		ci.requestSize, metricErr = cb.newRequestSize(f, fullNames, curry)
-		multierr.AppendInto(&err, metricErr)
+// configure applies the settings of this bundle that do not create metrics, including
+// how errors are coded and whether updates are batched.
+func (cb ClientBundle) configure(f touchstone.MetricFactory, ci *ClientInstrumenter) (err error) {
+	ci.hooks = cb.Hooks
+	ci.codeMapper = cb.CodeMapper
+	ci.exemplars = cb.ExemplarExtractor
+	if cb.BatchUpdates {
+		ci.batcher = new(batcher)
+	}
 
-		ci.duration, metricErr = cb.newDuration(f, fullNames, curry)
-		multierr.AppendInto(&err, metricErr)
+	ci.methods = newMethodSet(cb.Methods)
+	ci.errorCoder = cb.ErrorCoder
+	ci.countBodies = cb.CountRequestBodies
+	ci.now = clockOf(f, cb.Clock).Now
+	ci.writeErrors, err = touchstone.NewWriteErrors(f, cb.WriteErrorHooks...)
+	return
+}
 
-		ci.errorCount, metricErr = cb.newErrorCount(f, fullNames, curry)
-		multierr.AppendInto(&err, metricErr)
+// newMigration creates the migrated request counter and duration, if this bundle has a Migration.
+// This must only be done once the original duration has been created.
+func (cb ClientBundle) newMigration(f touchstone.MetricFactory, ci *ClientInstrumenter, fullNames []string, curry prometheus.Labels) (err error) {
+	if cb.Migration != nil {
+		// the duration was created, so its defaults are known to be valid
+		legacyDefaults, _ := latencyDefaults(defaultClientDuration, cb.LatencyClass)
+		ci.migration, err = cb.Migration.new(
+			f,
+			defaultMigratedClientCount,
+			migratedDurationDefaults(defaultMigratedClientDuration, cb.Duration, legacyDefaults),
+			&ci.instrumenter,
+			fullNames,
+			curry,
+		)
+	}
 
-		return
+	return
+}
+
+// newOptionalMetrics creates the client metrics beyond the core metrics: the sampled
+// duration, the error metrics, and the optional protocol, redirect, retry, and rejected counters.
+func (cb ClientBundle) newOptionalMetrics(f touchstone.MetricFactory, ci *ClientInstrumenter, extraNames, fullNames []string, curry prometheus.Labels) (err error) {
+	var metricErr error
+	if cb.Sampling != nil {
+		ci.sampledDuration, ci.sample, metricErr = cb.Sampling.newObserverVec(f, defaultClientSampledDuration, fullNames, curry)
+		multierr.AppendInto(&err, metricErr)
 	}
+
+	ci.errorCount, metricErr = cb.newErrorCount(f, fullNames, curry)
+	multierr.AppendInto(&err, metricErr)
+
+	errorCount := cb.ErrorCount
+	touchstone.ApplyDefaults(&errorCount, defaultClientErrorCount)
+	ci.lastError, metricErr = newLastError(f, cb.LastError, errorCount, extraNames, curry)
+	multierr.AppendInto(&err, metricErr)
+
+	ci.protocolCount, metricErr = cb.newOptionalCount(f, cb.ProtocolCount, defaultClientProtocolCount, extraNames, ProtocolLabel, curry)
+	multierr.AppendInto(&err, metricErr)
+
+	ci.redirectCount, metricErr = cb.newOptionalCount(f, cb.RedirectCount, defaultClientRedirectCount, extraNames, MethodLabel, curry)
+	multierr.AppendInto(&err, metricErr)
+
+	ci.retryCount, metricErr = cb.newOptionalCount(f, cb.RetryCount, defaultClientRetryCount, extraNames, MethodLabel, curry)
+	multierr.AppendInto(&err, metricErr)
+
+	ci.rejectedCount, metricErr = cb.newOptionalCount(f, cb.RejectedCount, defaultClientRejectedCount, extraNames, MethodLabel, curry)
+	multierr.AppendInto(&err, metricErr)
+	return
 }
@@ -4,13 +4,20 @@
 package touchhttp
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/httpaux/client"
 	"github.com/xmidt-org/touchstone"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
 )
 
 type BundleSuite struct {
@@ -24,6 +31,29 @@ func (suite *BundleSuite) SetupTest() {
 	suite.now = time.Now()
 }
 
+func (suite *BundleSuite) newFactory(opts ...touchstone.FactoryOption) *touchstone.Factory {
+	cfg := touchstone.Config{
+		Pedantic:                  true,
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	_, r, err := touchstone.New(cfg)
+	suite.Require().NoError(err)
+	return touchstone.NewFactory(cfg, zap.L(), r, opts...)
+}
+
+// clock returns a Clock that advances by the given step on each call.
+func (suite *BundleSuite) clock(step time.Duration) touchstone.Clock {
+	current := suite.now
+	return touchstone.ClockFunc(func() time.Time {
+		t := current
+		current = current.Add(step)
+		return t
+	})
+}
+
 type ServerBundleSuite struct {
 	BundleSuite
 }
@@ -84,15 +114,231 @@ func (suite *ServerBundleSuite) testNewInstrumenterNamed() {
 	app.RequireStop()
 }
 
+func (suite *ServerBundleSuite) testNewInstrumenterHooks() {
+	var observations []Observation
+	si, err := ServerBundle{
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+		Clock: suite.clock(100 * time.Millisecond),
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	h := si.Then(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/", nil))
+	suite.Equal(
+		[]Observation{
+			{Code: http.StatusServiceUnavailable, Method: http.MethodPut, Duration: 100 * time.Millisecond},
+		},
+		observations,
+	)
+}
+
+func (suite *ServerBundleSuite) testNewInstrumenterCodeMapper() {
+	var observations []Observation
+	si, err := ServerBundle{
+		CodeMapper: CodeRange{Min: 520, Max: 529, Code: http.StatusInternalServerError}.Map,
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	h := si.Then(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(522)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Require().Len(observations, 1)
+	suite.Equal(http.StatusInternalServerError, observations[0].Code)
+	suite.Equal(1.0, testutil.ToFloat64(si.count.WithLabelValues("500", http.MethodGet)))
+	suite.Equal(1, testutil.CollectAndCount(si.count))
+}
+
+func (suite *ServerBundleSuite) testNewInstrumenterFactoryClock() {
+	var observations []Observation
+	si, err := ServerBundle{
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+	}.NewInstrumenter()(suite.newFactory(
+		touchstone.WithClock(suite.clock(250 * time.Millisecond)),
+	))
+
+	suite.Require().NoError(err)
+
+	h := si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Require().Len(observations, 1)
+	suite.Equal(250*time.Millisecond, observations[0].Duration)
+}
+
+func (suite *ServerBundleSuite) testNewInstrumenterMethods() {
+	si, err := ServerBundle{
+		Methods: []string{http.MethodGet, http.MethodPost},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	h := si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	for _, method := range []string{http.MethodGet, http.MethodTrace, http.MethodConnect, "PROPFIND"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/", nil))
+	}
+
+	suite.Equal(3, testutil.CollectAndCount(si.count))
+	suite.Equal(1.0, testutil.ToFloat64(si.count.WithLabelValues("200", http.MethodGet)))
+	suite.Equal(2.0, testutil.ToFloat64(si.count.WithLabelValues("200", MethodOther)))
+	suite.Equal(1.0, testutil.ToFloat64(si.count.WithLabelValues("200", MethodUnrecognized)))
+}
+
+func (suite *ServerBundleSuite) testNewInstrumenterPreinitialize() {
+	f := suite.newFactory()
+	si, err := ServerBundle{
+		Preinitialize: LabelCombinations(
+			[]int{http.StatusOK, http.StatusNotFound},
+			[]string{http.MethodGet},
+		),
+	}.NewInstrumenter(ServerLabel, "main")(f)
+
+	suite.Require().NoError(err)
+	suite.Equal(2, testutil.CollectAndCount(si.count))
+	suite.Equal(2, testutil.CollectAndCount(si.duration))
+	suite.Equal(2, testutil.CollectAndCount(si.requestSize))
+	suite.Zero(testutil.ToFloat64(si.count.With(prometheus.Labels{CodeLabel: "404", MethodLabel: http.MethodGet})))
+
+	_, err = ServerBundle{
+		Preinitialize: []Labels{{"nosuch": "value"}},
+	}.NewInstrumenter(ServerLabel, "other")(f)
+
+	suite.Error(err)
+
+	suite.Run("CodeMapperAndMethods", func() {
+		si, err := ServerBundle{
+			CodeMapper: CodeMap{499: http.StatusBadRequest}.Map,
+			Methods:    []string{http.MethodGet},
+			Preinitialize: LabelCombinations(
+				[]int{499},
+				[]string{http.MethodGet, http.MethodPost},
+			),
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.Require().NoError(err)
+		suite.Equal(2, testutil.CollectAndCount(si.count))
+
+		// the preinitialized series are the ones that transactions record
+		for _, method := range []string{http.MethodGet, MethodOther} {
+			_, err := touchstone.Value(si.count, prometheus.Labels{CodeLabel: "400", MethodLabel: method})
+			suite.NoError(err, method)
+		}
+	})
+}
+
+func (suite *ServerBundleSuite) testNewInstrumenterNamingPolicy() {
+	var (
+		si ServerInstrumenter
+
+		app = fxtest.New(
+			suite.T(),
+			touchstone.Provide(),
+			fx.Supply(
+				fx.Annotate(
+					touchstone.Naming{
+						Prefix: "acme",
+						Rename: map[string]string{DefaultServerCount: "http_requests_total"},
+					},
+					fx.As(new(touchstone.NamingPolicy)),
+				),
+			),
+			fx.Provide(
+				ServerBundle{}.NewInstrumenter(),
+			),
+			fx.Populate(&si),
+		)
+	)
+
+	app.RequireStart()
+	suite.Contains(si.count.WithLabelValues("200", http.MethodGet).Desc().String(), `"http_requests_total"`)
+	suite.Contains(si.inFlight.Desc().String(), `"acme_`+DefaultServerInFlight+`"`)
+	app.RequireStop()
+}
+
+func (suite *ServerBundleSuite) TestNewInstrumenters() {
+	suite.Run("Named", func() {
+		var (
+			main, health ServerInstrumenter
+
+			app = fxtest.New(
+				suite.T(),
+				touchstone.Provide(),
+				ServerBundle{}.NewInstrumenters("servers.main", "servers.health"),
+				fx.Invoke(
+					fx.Annotate(
+						func(si ServerInstrumenter) { main = si },
+						fx.ParamTags(`name:"servers.main"`),
+					),
+					fx.Annotate(
+						func(si ServerInstrumenter) { health = si },
+						fx.ParamTags(`name:"servers.health"`),
+					),
+				),
+			)
+		)
+
+		app.RequireStart()
+		main.count.WithLabelValues("200", http.MethodGet).Inc()
+		suite.Equal(1.0, testutil.ToFloat64(main.count.WithLabelValues("200", http.MethodGet)))
+		suite.Zero(testutil.ToFloat64(health.count.WithLabelValues("200", http.MethodGet)))
+		app.RequireStop()
+	})
+
+	suite.Run("None", func() {
+		app := fxtest.New(
+			suite.T(),
+			touchstone.Provide(),
+			ServerBundle{}.NewInstrumenters(),
+		)
+
+		app.RequireStart()
+		app.RequireStop()
+	})
+
+	suite.Run("Duplicate", func() {
+		app := fx.New(
+			fx.NopLogger,
+			touchstone.Provide(),
+			ServerBundle{}.NewInstrumenters("servers.main", "servers.main"),
+		)
+
+		suite.Error(app.Err())
+	})
+}
+
 func (suite *ServerBundleSuite) TestNewInstrumenter() {
+	suite.Run("NamingPolicy", suite.testNewInstrumenterNamingPolicy)
 	suite.Run("Defaults", suite.testNewInstrumenterDefaults)
 	suite.Run("Named", suite.testNewInstrumenterNamed)
+	suite.Run("Hooks", suite.testNewInstrumenterHooks)
+	suite.Run("FactoryClock", suite.testNewInstrumenterFactoryClock)
+	suite.Run("CodeMapper", suite.testNewInstrumenterCodeMapper)
+	suite.Run("Methods", suite.testNewInstrumenterMethods)
+	suite.Run("Preinitialize", suite.testNewInstrumenterPreinitialize)
 }
 
 func TestServerBundle(t *testing.T) {
 	suite.Run(t, new(ServerBundleSuite))
 }
 
+type clientTransport func(*http.Request) (*http.Response, error)
+
+func (ct clientTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return ct(r)
+}
+
 type ClientBundleSuite struct {
 	BundleSuite
 }
@@ -153,9 +399,219 @@ func (suite *ClientBundleSuite) testNewInstrumenterNamed() {
 	app.RequireStop()
 }
 
+func (suite *ClientBundleSuite) testNewInstrumenterHooks() {
+	var (
+		observations []Observation
+		expectedErr  = errors.New("expected")
+	)
+
+	ci, err := ClientBundle{
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+		Clock: suite.clock(time.Second),
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	c := ci.Then(client.Func(func(*http.Request) (*http.Response, error) {
+		return nil, expectedErr
+	}))
+
+	response, err := c.Do(httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Nil(response)
+	suite.ErrorIs(err, expectedErr)
+	suite.Equal(
+		[]Observation{
+			{Code: -1, Method: http.MethodGet, Duration: time.Second, Err: expectedErr},
+		},
+		observations,
+	)
+}
+
+func (suite *ClientBundleSuite) testNewInstrumenterPreinitialize() {
+	ci, err := ClientBundle{
+		Preinitialize: LabelCombinations(
+			[]int{http.StatusOK, http.StatusNotFound, http.StatusServiceUnavailable},
+			[]string{http.MethodPut},
+		),
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Equal(3, testutil.CollectAndCount(ci.count))
+	suite.Equal(3, testutil.CollectAndCount(ci.duration))
+	suite.Zero(testutil.CollectAndCount(ci.errorCount))
+}
+
+func (suite *ClientBundleSuite) testNewInstrumenterProtocolAndRedirects() {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(rw, r, "/b", http.StatusFound)
+		case "/b":
+			http.Redirect(rw, r, "/c", http.StatusFound)
+		default:
+			rw.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	defer server.Close()
+
+	var observations []Observation
+	ci, err := ClientBundle{
+		ProtocolCount: &prometheus.CounterOpts{},
+		RedirectCount: &prometheus.CounterOpts{},
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+	}.NewInstrumenter(ClientLabel, "test")(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(ci.protocolCount)
+	suite.Require().NotNil(ci.redirectCount)
+
+	c := ci.Then(server.Client())
+	for _, path := range []string{"/a", "/c"} {
+		request, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		suite.Require().NoError(err)
+		response, err := c.Do(request)
+		suite.Require().NoError(err)
+		response.Body.Close()
+	}
+
+	suite.Equal(2.0, testutil.ToFloat64(ci.protocolCount.WithLabelValues("HTTP/1.1")))
+	suite.Equal(2.0, testutil.ToFloat64(ci.redirectCount.WithLabelValues(http.MethodGet)))
+	suite.Require().Len(observations, 2)
+	suite.Equal("HTTP/1.1", observations[0].Protocol)
+	suite.Equal(2, observations[0].Redirects)
+	suite.Zero(observations[1].Redirects)
+
+	suite.Run("ReservedLabel", func() {
+		_, err := ClientBundle{
+			ProtocolCount: &prometheus.CounterOpts{},
+		}.NewInstrumenter(ProtocolLabel, "test")(suite.newFactory())
+
+		suite.ErrorIs(err, ErrReservedLabelName)
+	})
+
+	suite.Run("Disabled", func() {
+		ci, err := ClientBundle{}.NewInstrumenter()(suite.newFactory())
+		suite.Require().NoError(err)
+		suite.Nil(ci.protocolCount)
+		suite.Nil(ci.redirectCount)
+	})
+}
+
+func (suite *ClientBundleSuite) testNewInstrumenterErrorCoder() {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	url := server.URL
+	server.Close() // connections will now be refused
+
+	var observations []Observation
+	ci, err := ClientBundle{
+		ErrorCoder: TransportErrorCoder,
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	c := ci.Then(new(http.Client))
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	suite.Require().NoError(err)
+	response, err := c.Do(request)
+	suite.Nil(response)
+	suite.Require().Error(err)
+
+	suite.Require().Len(observations, 1)
+	suite.Equal(StatusConnectionRefused, observations[0].Code)
+	suite.Equal(1.0, testutil.ToFloat64(ci.count.WithLabelValues("598", http.MethodGet)))
+	suite.Equal(1.0, testutil.ToFloat64(ci.errorCount.WithLabelValues("598", http.MethodGet)))
+}
+
+func (suite *ClientBundleSuite) testNewInstrumenterCodeMapper() {
+	ci, err := ClientBundle{
+		ErrorCoder: func(error) int { return StatusClientTimeout },
+		CodeMapper: CodeMap{
+			499:                 http.StatusBadRequest,
+			StatusClientTimeout: http.StatusGatewayTimeout,
+		}.Map,
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	c := ci.Then(&http.Client{
+		Transport: clientTransport(func(r *http.Request) (*http.Response, error) {
+			if r.Method == http.MethodDelete {
+				return nil, errors.New("expected")
+			}
+
+			return &http.Response{StatusCode: 499, Body: http.NoBody}, nil
+		}),
+	})
+
+	request, err := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+	suite.Require().NoError(err)
+	response, err := c.Do(request)
+	suite.Require().NoError(err)
+	response.Body.Close()
+
+	request, err = http.NewRequest(http.MethodDelete, "http://localhost/", nil)
+	suite.Require().NoError(err)
+	_, err = c.Do(request)
+	suite.Require().Error(err)
+
+	suite.Equal(1.0, testutil.ToFloat64(ci.count.WithLabelValues("400", http.MethodGet)))
+	suite.Equal(1.0, testutil.ToFloat64(ci.count.WithLabelValues("504", http.MethodDelete)))
+	suite.Equal(1.0, testutil.ToFloat64(ci.errorCount.WithLabelValues("504", http.MethodDelete)))
+	suite.Equal(2, testutil.CollectAndCount(ci.count))
+}
+
+func (suite *ClientBundleSuite) testNewInstrumenterWriteErrors() {
+	var failed []string
+	ci, err := ClientBundle{
+		ProtocolCount: &prometheus.CounterOpts{},
+		WriteErrorHooks: []touchstone.WriteErrorHook{
+			func(metric string, err error) {
+				suite.Error(err)
+				failed = append(failed, metric)
+			},
+		},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(ci.writeErrors)
+
+	c := ci.Then(&http.Client{
+		Transport: clientTransport(func(*http.Request) (*http.Response, error) {
+			// not valid UTF-8, so the protocol cannot be used as a label value
+			return &http.Response{StatusCode: http.StatusOK, Proto: "HTTP/\xff", Body: http.NoBody}, nil
+		}),
+	})
+
+	request, err := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+	suite.Require().NoError(err)
+	suite.NotPanics(func() {
+		response, err := c.Do(request)
+		suite.Require().NoError(err)
+		response.Body.Close()
+	})
+
+	suite.Equal([]string{DefaultClientProtocolCount}, failed)
+	suite.Zero(testutil.CollectAndCount(ci.protocolCount))
+	suite.Equal(1.0, testutil.ToFloat64(ci.count.WithLabelValues("200", http.MethodGet)))
+}
+
 func (suite *ClientBundleSuite) TestNewInstrumenter() {
 	suite.Run("Defaults", suite.testNewInstrumenterDefaults)
 	suite.Run("Named", suite.testNewInstrumenterNamed)
+	suite.Run("Hooks", suite.testNewInstrumenterHooks)
+	suite.Run("Preinitialize", suite.testNewInstrumenterPreinitialize)
+	suite.Run("ProtocolAndRedirects", suite.testNewInstrumenterProtocolAndRedirects)
+	suite.Run("ErrorCoder", suite.testNewInstrumenterErrorCoder)
+	suite.Run("CodeMapper", suite.testNewInstrumenterCodeMapper)
+	suite.Run("WriteErrors", suite.testNewInstrumenterWriteErrors)
 }
 
 func TestClientBundle(t *testing.T) {
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/httpaux"
+	"github.com/xmidt-org/httpaux/busy"
+	"github.com/xmidt-org/httpaux/client"
+)
+
+const (
+	// DefaultClientRejectedCount is the default name of the optional counter that tracks
+	// the number of requests a client rejected because too many were in flight.
+	DefaultClientRejectedCount = "client_rejected_count"
+)
+
+// ErrClientBusy is returned by a client decorated with ClientInstrumenter.Busy when its
+// limiter rejects a request.
+var ErrClientBusy = errors.New("the client has too many requests in flight")
+
+var defaultClientRejectedCount = prometheus.CounterOpts{
+	Name: DefaultClientRejectedCount,
+	Help: "the total number of requests rejected by the client's concurrency limit since startup",
+}
+
+// Busy is a client middleware that limits the concurrent transactions of a client with
+// an httpaux busy.Limiter, e.g. a busy.MaxRequestLimiter.  Rejected requests are not
+// sent and fail with ErrClientBusy.  If the ClientBundle has a RejectedCount, each
+// rejection is counted by method in that counter, curried with the same extra labels
+// as this instrumenter.
+//
+// Rejected requests never reach the next client, so to record only transactions that
+// were actually sent, Busy should enclose the instrumented client:
+//
+//	c := client.NewChain(
+//	  ci.Busy(&busy.MaxRequestLimiter{MaxRequests: 10}),
+//	  ci.Then,
+//	).Then(http.DefaultClient)
+//
+// If l is nil, the returned middleware does not limit transactions.
+func (ci ClientInstrumenter) Busy(l busy.Limiter) client.Constructor {
+	return func(next httpaux.Client) httpaux.Client {
+		if l == nil {
+			return next
+		}
+
+		return client.Func(func(request *http.Request) (*http.Response, error) {
+			done, ok := l.Check(request)
+			if !ok {
+				if ci.rejectedCount != nil {
+					ci.writeErrors.CounterWithLabelValues(ci.rejectedCount, ci.methods.format(request.Method)).Inc()
+				}
+
+				return nil, ErrClientBusy
+			}
+
+			defer done()
+			return next.Do(request)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/httpaux/busy"
+	"github.com/xmidt-org/httpaux/client"
+	"github.com/xmidt-org/touchstone"
+)
+
+type BusySuite struct {
+	BundleSuite
+}
+
+func (suite *BusySuite) TestBusy() {
+	ci, err := ClientBundle{
+		RejectedCount: &prometheus.CounterOpts{},
+	}.NewInstrumenter(ClientLabel, "test")(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(ci.rejectedCount)
+
+	var (
+		inner     http.Client
+		requests  = make(chan *http.Request)
+		responses = make(chan *http.Response)
+		c         = client.NewChain(
+			ci.Busy(&busy.MaxRequestLimiter{MaxRequests: 1}),
+			ci.Then,
+		).Then(&inner)
+	)
+
+	inner.Transport = clientTransport(func(r *http.Request) (*http.Response, error) {
+		requests <- r
+		return <-responses, nil
+	})
+
+	// the first request occupies the only slot until it receives a response
+	done := make(chan error, 1)
+	go func() {
+		request, _ := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+		response, err := c.Do(request)
+		if err == nil {
+			response.Body.Close()
+		}
+
+		done <- err
+	}()
+
+	<-requests
+	request, err := http.NewRequest(http.MethodPut, "http://localhost/", nil)
+	suite.Require().NoError(err)
+	_, err = c.Do(request)
+	suite.ErrorIs(err, ErrClientBusy)
+
+	responses <- &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}
+	suite.NoError(<-done)
+
+	v, err := touchstone.Value(ci.rejectedCount, prometheus.Labels{ClientLabel: "test", MethodLabel: http.MethodPut})
+	suite.Require().NoError(err)
+	suite.Equal(1.0, v)
+
+	// the rejected request was never sent, so only one transaction was recorded
+	v, err = touchstone.Value(ci.count, nil)
+	suite.Require().NoError(err)
+	suite.Equal(1.0, v)
+
+	suite.Run("NilLimiter", func() {
+		next := client.Func(func(*http.Request) (*http.Response, error) { return nil, nil })
+		suite.NotNil(ci.Busy(nil)(next))
+	})
+
+	suite.Run("NoRejectedCount", func() {
+		ci, err := ClientBundle{}.NewInstrumenter()(suite.newFactory())
+		suite.Require().NoError(err)
+		suite.Nil(ci.rejectedCount)
+
+		c := ci.Busy(&busy.MaxRequestLimiter{MaxRequests: 1})(
+			client.Func(func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			}),
+		)
+
+		request, err := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+		suite.Require().NoError(err)
+		response, err := c.Do(request)
+		suite.Require().NoError(err)
+		suite.Equal(http.StatusOK, response.StatusCode)
+	})
+}
+
+func TestBusy(t *testing.T) {
+	suite.Run(t, new(BusySuite))
+}
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+// CodeMapper post-processes the status code of each transaction before it is
+// recorded, e.g. to collapse nonstandard codes from upstream services onto
+// standard ones.  The returned code is used for the CodeLabel of every metric
+// and is the code passed to hooks.
+//
+// A CodeMapper is invoked for every transaction, so it should be fast.
+type CodeMapper func(code int) int
+
+// CodeMap is a table of status codes and their replacements.  Codes that
+// are not keys in the table are left unchanged.
+//
+//	touchhttp.ServerBundle{
+//	  CodeMapper: touchhttp.CodeMap{499: 400}.Map,
+//	}
+type CodeMap map[int]int
+
+// Map is a CodeMapper that replaces codes according to this table.
+func (cm CodeMap) Map(code int) int {
+	if mapped, ok := cm[code]; ok {
+		return mapped
+	}
+
+	return code
+}
+
+// CodeRange replaces every status code in an inclusive range with a single code.
+// Codes outside the range are left unchanged.
+//
+//	touchhttp.ClientBundle{
+//	  // collapse the nonstandard 52x codes of a CDN onto 500
+//	  CodeMapper: touchhttp.CodeRange{Min: 520, Max: 529, Code: 500}.Map,
+//	}
+type CodeRange struct {
+	// Min is the smallest code in the range.
+	Min int
+
+	// Max is the largest code in the range.
+	Max int
+
+	// Code is the code that replaces any code in the range.
+	Code int
+}
+
+// Map is a CodeMapper that replaces any code within this range.
+func (cr CodeRange) Map(code int) int {
+	if code >= cr.Min && code <= cr.Max {
+		return cr.Code
+	}
+
+	return code
+}
+
+// CodeMappers combines several CodeMappers into one, which applies each
+// in order.  Nil mappers are skipped.
+func CodeMappers(mappers ...CodeMapper) CodeMapper {
+	return func(code int) int {
+		for _, m := range mappers {
+			if m != nil {
+				code = m(code)
+			}
+		}
+
+		return code
+	}
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeMap(t *testing.T) {
+	cm := CodeMap{
+		499: http.StatusBadRequest,
+		520: http.StatusInternalServerError,
+	}
+
+	testCases := []struct {
+		code     int
+		expected int
+	}{
+		{499, http.StatusBadRequest},
+		{520, http.StatusInternalServerError},
+		{http.StatusOK, http.StatusOK},
+		{521, 521},
+		{StatusNoResponse, StatusNoResponse},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", testCase.code), func(t *testing.T) {
+			assert.Equal(t, testCase.expected, cm.Map(testCase.code))
+		})
+	}
+}
+
+func TestCodeRange(t *testing.T) {
+	cr := CodeRange{Min: 520, Max: 529, Code: http.StatusInternalServerError}
+
+	testCases := []struct {
+		code     int
+		expected int
+	}{
+		{519, 519},
+		{520, http.StatusInternalServerError},
+		{525, http.StatusInternalServerError},
+		{529, http.StatusInternalServerError},
+		{530, 530},
+		{http.StatusOK, http.StatusOK},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", testCase.code), func(t *testing.T) {
+			assert.Equal(t, testCase.expected, cr.Map(testCase.code))
+		})
+	}
+}
+
+func TestCodeMappers(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		assert.Equal(t, 520, CodeMappers()(520))
+	})
+
+	t.Run("InOrder", func(t *testing.T) {
+		m := CodeMappers(
+			CodeMap{499: 599}.Map,
+			nil,
+			CodeRange{Min: 520, Max: 599, Code: http.StatusInternalServerError}.Map,
+		)
+
+		assert.Equal(t, http.StatusInternalServerError, m(499))
+		assert.Equal(t, http.StatusInternalServerError, m(520))
+		assert.Equal(t, http.StatusNotFound, m(http.StatusNotFound))
+	})
+}
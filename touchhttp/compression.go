@@ -0,0 +1,289 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// EncodingGzip is the content encoding for gzip compression.
+	EncodingGzip = "gzip"
+
+	// EncodingZstd is the content encoding for zstd compression.
+	EncodingZstd = "zstd"
+
+	// EncodingIdentity is the content encoding for uncompressed output.
+	EncodingIdentity = "identity"
+
+	// MaxZstdLevel is the largest zstd compression level allowed in Compression.ZstdLevel.
+	MaxZstdLevel = 22
+)
+
+// DefaultEncodings returns the content encodings offered, in order of preference,
+// when Compression.Encodings is unset.
+func DefaultEncodings() []string {
+	return []string{EncodingGzip, EncodingZstd}
+}
+
+// InvalidCompressionError is the error returned when a Compression has an unrecognized
+// encoding or an out of range compression level.
+type InvalidCompressionError struct {
+	// Value is a description of the invalid configuration.
+	Value string
+}
+
+// Error satisfies the error interface.
+func (e *InvalidCompressionError) Error() string {
+	return fmt.Sprintf("Invalid Compression: %s", e.Value)
+}
+
+// Compression controls how the metrics Handler compresses its output.  The promhttp
+// handler compresses with fixed levels, which can use significant CPU for large
+// expositions.  When any field of Compression is set, promhttp compression is
+// disabled and the Handler is decorated with NewCompressionHandler instead.
+type Compression struct {
+	// Encodings is the list of content encodings offered during negotiation with the
+	// Accept-Encoding header, in order of preference.  Allowed values are gzip, zstd,
+	// and identity.  If unset, DefaultEncodings is used.
+	Encodings []string `json:"encodings" yaml:"encodings"`
+
+	// GzipLevel is the compress/gzip compression level, from gzip.HuffmanOnly through
+	// gzip.BestCompression.  If unset, gzip.DefaultCompression is used.  To disable
+	// compression for a client, omit gzip from Encodings instead.
+	GzipLevel int `json:"gzipLevel" yaml:"gzipLevel"`
+
+	// ZstdLevel is the standard zstd compression level, from 1 through MaxZstdLevel.
+	// It is mapped to the nearest level supported by the encoder.  If unset, the
+	// fastest level is used, which is what promhttp uses.
+	ZstdLevel int `json:"zstdLevel" yaml:"zstdLevel"`
+}
+
+// enabled tests if any compression option has been set.
+func (c Compression) enabled() bool {
+	return len(c.Encodings) > 0 || c.GzipLevel != 0 || c.ZstdLevel != 0
+}
+
+// validate checks that the encodings and levels are supported.
+func (c Compression) validate() error {
+	for _, e := range c.Encodings {
+		switch e {
+		case EncodingGzip, EncodingZstd, EncodingIdentity:
+		default:
+			return &InvalidCompressionError{Value: "unsupported encoding " + e}
+		}
+	}
+
+	if c.GzipLevel < gzip.HuffmanOnly || c.GzipLevel > gzip.BestCompression {
+		return &InvalidCompressionError{Value: fmt.Sprintf("gzip level %d", c.GzipLevel)}
+	}
+
+	if c.ZstdLevel < 0 || c.ZstdLevel > MaxZstdLevel {
+		return &InvalidCompressionError{Value: fmt.Sprintf("zstd level %d", c.ZstdLevel)}
+	}
+
+	return nil
+}
+
+// compressionHandler is the http.Handler decorator that performs compression.
+type compressionHandler struct {
+	next      http.Handler
+	encodings []string
+
+	gzipPool sync.Pool
+	zstdPool sync.Pool
+}
+
+// NewCompressionHandler decorates next so that its successful responses are compressed
+// with the encoding negotiated from the request's Accept-Encoding header.  Responses
+// other than 200 OK, and responses that already have a Content-Encoding, are passed
+// through unchanged.  If no offered encoding is acceptable to the client, the response
+// is not compressed.
+//
+// Encoders are pooled, so compression allocates little per request.
+func NewCompressionHandler(c Compression, next http.Handler) (http.Handler, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	ch := &compressionHandler{
+		next:      next,
+		encodings: append([]string{}, c.Encodings...),
+	}
+
+	if len(ch.encodings) == 0 {
+		ch.encodings = DefaultEncodings()
+	}
+
+	gzipLevel := c.GzipLevel
+	if gzipLevel == 0 {
+		gzipLevel = gzip.DefaultCompression
+	}
+
+	ch.gzipPool.New = func() interface{} {
+		// the level has already been validated
+		gz, _ := gzip.NewWriterLevel(io.Discard, gzipLevel)
+		return gz
+	}
+
+	zstdLevel := zstd.SpeedFastest
+	if c.ZstdLevel > 0 {
+		zstdLevel = zstd.EncoderLevelFromZstd(c.ZstdLevel)
+	}
+
+	ch.zstdPool.New = func() interface{} {
+		// the options are always valid
+		z, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstdLevel), zstd.WithEncoderConcurrency(1))
+		return z
+	}
+
+	return ch, nil
+}
+
+// encoder obtains a pooled encoder that writes to w, along with a function that
+// flushes the encoder and returns it to its pool.
+func (ch *compressionHandler) encoder(encoding string, w io.Writer) (io.Writer, func()) {
+	switch encoding {
+	case EncodingGzip:
+		gz := ch.gzipPool.Get().(*gzip.Writer)
+		gz.Reset(w)
+		return gz, func() {
+			_ = gz.Close()
+			ch.gzipPool.Put(gz)
+		}
+
+	case EncodingZstd:
+		z := ch.zstdPool.Get().(*zstd.Encoder)
+		z.Reset(w)
+		return z, func() {
+			_ = z.Close()
+			ch.zstdPool.Put(z)
+		}
+
+	default:
+		return w, func() {}
+	}
+}
+
+func (ch *compressionHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Add("Vary", "Accept-Encoding")
+	encoding := negotiateEncoding(r, ch.encodings)
+	if encoding == "" || encoding == EncodingIdentity {
+		ch.next.ServeHTTP(rw, r)
+		return
+	}
+
+	cw := &compressWriter{
+		ResponseWriter: rw,
+		handler:        ch,
+		encoding:       encoding,
+	}
+
+	defer cw.close()
+	ch.next.ServeHTTP(cw, r)
+}
+
+// compressWriter is the http.ResponseWriter decorator that compresses the body.  The
+// decision to compress is made when the header is written.
+type compressWriter struct {
+	http.ResponseWriter
+	handler  *compressionHandler
+	encoding string
+
+	wroteHeader bool
+	w           io.Writer
+	release     func()
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	cw.wroteHeader = true
+	h := cw.Header()
+	if code == http.StatusOK && len(h.Get("Content-Encoding")) == 0 {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		cw.w, cw.release = cw.handler.encoder(cw.encoding, cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if cw.w != nil {
+		return cw.w.Write(p)
+	}
+
+	return cw.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the decorated http.ResponseWriter.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// close flushes any compressed output.
+func (cw *compressWriter) close() {
+	if cw.release != nil {
+		cw.release()
+	}
+}
+
+// negotiateEncoding selects the offered encoding with the highest quality in the
+// request's Accept-Encoding header.  Ties are broken by the order of offered.  An
+// empty string is returned if no offered encoding is acceptable.
+func negotiateEncoding(r *http.Request, offered []string) (selected string) {
+	var (
+		explicit = make(map[string]float64, len(offered))
+		wildcard = -1.0
+		best     = 0.0
+	)
+
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(value, ",") {
+			coding, params, _ := strings.Cut(part, ";")
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			q := 1.0
+			if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+
+			if coding == "*" {
+				wildcard = q
+			} else if len(coding) > 0 {
+				explicit[coding] = q
+			}
+		}
+	}
+
+	for _, e := range offered {
+		q, ok := explicit[e]
+		if !ok {
+			q = wildcard
+		}
+
+		if q > best {
+			selected, best = e, q
+		}
+	}
+
+	return
+}
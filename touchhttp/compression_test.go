@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/suite"
+)
+
+type CompressionSuite struct {
+	suite.Suite
+}
+
+func (suite *CompressionSuite) body() string {
+	return strings.Repeat("test_metric 1\n", 100)
+}
+
+func (suite *CompressionSuite) newHandler(c Compression, code int) http.Handler {
+	h, err := NewCompressionHandler(c, http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.WriteHeader(code)
+		_, _ = io.WriteString(rw, suite.body())
+	}))
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(h)
+	return h
+}
+
+func (suite *CompressionSuite) serve(h http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if len(acceptEncoding) > 0 {
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	response := httptest.NewRecorder()
+	h.ServeHTTP(response, request)
+	return response
+}
+
+func (suite *CompressionSuite) decode(response *httptest.ResponseRecorder) string {
+	var (
+		r   io.Reader
+		err error
+	)
+
+	switch response.Header().Get("Content-Encoding") {
+	case EncodingGzip:
+		r, err = gzip.NewReader(response.Body)
+
+	case EncodingZstd:
+		var z *zstd.Decoder
+		z, err = zstd.NewReader(response.Body)
+		if err == nil {
+			defer z.Close()
+			r = z
+		}
+
+	default:
+		r = response.Body
+	}
+
+	suite.Require().NoError(err)
+	b, err := io.ReadAll(r)
+	suite.Require().NoError(err)
+	return string(b)
+}
+
+func (suite *CompressionSuite) TestNegotiateEncoding() {
+	testCases := []struct {
+		acceptEncoding string
+		offered        []string
+		expected       string
+	}{
+		{acceptEncoding: "", offered: DefaultEncodings(), expected: ""},
+		{acceptEncoding: "gzip", offered: DefaultEncodings(), expected: EncodingGzip},
+		{acceptEncoding: "zstd", offered: DefaultEncodings(), expected: EncodingZstd},
+		{acceptEncoding: "zstd, gzip", offered: DefaultEncodings(), expected: EncodingGzip},
+		{acceptEncoding: "gzip;q=0.5, zstd", offered: DefaultEncodings(), expected: EncodingZstd},
+		{acceptEncoding: "GZIP", offered: DefaultEncodings(), expected: EncodingGzip},
+		{acceptEncoding: "*", offered: []string{EncodingZstd, EncodingGzip}, expected: EncodingZstd},
+		{acceptEncoding: "*, zstd;q=0", offered: DefaultEncodings(), expected: EncodingGzip},
+		{acceptEncoding: "br", offered: DefaultEncodings(), expected: ""},
+		{acceptEncoding: "gzip", offered: []string{EncodingZstd}, expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.acceptEncoding, func() {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set("Accept-Encoding", testCase.acceptEncoding)
+			suite.Equal(testCase.expected, negotiateEncoding(request, testCase.offered))
+		})
+	}
+}
+
+func (suite *CompressionSuite) TestInvalid() {
+	for _, c := range []Compression{
+		{Encodings: []string{"br"}},
+		{GzipLevel: gzip.BestCompression + 1},
+		{GzipLevel: gzip.HuffmanOnly - 1},
+		{ZstdLevel: -1},
+		{ZstdLevel: MaxZstdLevel + 1},
+	} {
+		h, err := NewCompressionHandler(c, http.NotFoundHandler())
+		suite.Nil(h)
+
+		var ice *InvalidCompressionError
+		suite.Require().True(errors.As(err, &ice))
+		suite.NotEmpty(ice.Error())
+	}
+}
+
+func (suite *CompressionSuite) TestCompress() {
+	testCases := []struct {
+		name           string
+		compression    Compression
+		acceptEncoding string
+		expected       string
+	}{
+		{name: "gzip", compression: Compression{GzipLevel: gzip.BestSpeed}, acceptEncoding: "gzip", expected: EncodingGzip},
+		{name: "zstd", compression: Compression{ZstdLevel: 3}, acceptEncoding: "zstd", expected: EncodingZstd},
+		{name: "identity", compression: Compression{Encodings: []string{EncodingIdentity, EncodingGzip}}, acceptEncoding: "identity, gzip", expected: ""},
+		{name: "none", compression: Compression{GzipLevel: gzip.BestSpeed}, acceptEncoding: "", expected: ""},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.name, func() {
+			h := suite.newHandler(testCase.compression, http.StatusOK)
+
+			// run twice to exercise pooled encoders
+			for i := 0; i < 2; i++ {
+				response := suite.serve(h, testCase.acceptEncoding)
+				suite.Equal(http.StatusOK, response.Code)
+				suite.Equal(testCase.expected, response.Header().Get("Content-Encoding"))
+				suite.Equal("Accept-Encoding", response.Header().Get("Vary"))
+				suite.Equal(suite.body(), suite.decode(response))
+			}
+		})
+	}
+}
+
+func (suite *CompressionSuite) TestErrorResponse() {
+	h := suite.newHandler(Compression{GzipLevel: gzip.BestSpeed}, http.StatusInternalServerError)
+	response := suite.serve(h, "gzip")
+	suite.Equal(http.StatusInternalServerError, response.Code)
+	suite.Empty(response.Header().Get("Content-Encoding"))
+	suite.Equal(suite.body(), response.Body.String())
+}
+
+func (suite *CompressionSuite) TestUnwrap() {
+	rw := httptest.NewRecorder()
+	cw := &compressWriter{ResponseWriter: rw}
+	suite.Same(rw, cw.Unwrap())
+}
+
+func TestCompression(t *testing.T) {
+	suite.Run(t, new(CompressionSuite))
+}
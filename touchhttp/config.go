@@ -10,7 +10,10 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/xmidt-org/touchstone"
 	"go.uber.org/fx"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
 )
 
 const (
@@ -36,9 +39,26 @@ type ErrorPrinter struct {
 
 // Println satisfies the promhttp.Logger interface.
 func (ep ErrorPrinter) Println(values ...interface{}) {
-	var msg strings.Builder
+	ep.Printer.Printf(sprintln(values...))
+}
+
+// ErrorLogger adapts a *zap.Logger and allows it to be used as an error Logger
+// for prometheus.  Each message is logged at the error level.
+//
+// Provide prefers this adapter over ErrorPrinter when a *zap.Logger is available, so that
+// the metrics handler logs to the same sink as the touchstone.Factory.
+type ErrorLogger struct {
+	*zap.Logger
+}
 
-	// we want Fprintln behavior, but we don't want the trailing newline
+// Println satisfies the promhttp.Logger interface.
+func (el ErrorLogger) Println(values ...interface{}) {
+	el.Logger.Error(sprintln(values...))
+}
+
+// sprintln has fmt.Sprintln behavior, but without the trailing newline.
+func sprintln(values ...interface{}) string {
+	var msg strings.Builder
 	for i, v := range values {
 		if i > 0 {
 			msg.WriteRune(' ')
@@ -47,7 +67,7 @@ func (ep ErrorPrinter) Println(values ...interface{}) {
 		fmt.Fprint(&msg, v)
 	}
 
-	ep.Printer.Printf(msg.String())
+	return msg.String()
 }
 
 // InvalidErrorHandlingError is the error returned when Config.ErrorHandling
@@ -73,16 +93,51 @@ type Config struct {
 	// DisableCompression disables compression on metrics output.
 	DisableCompression bool `json:"disableCompression" yaml:"disableCompression"`
 
+	// Compression chooses the encodings and compression levels for metrics output.  If
+	// any of its fields are set, the Handler uses NewCompressionHandler rather than the
+	// fixed compression of promhttp.  This field is ignored if DisableCompression is set.
+	Compression Compression `json:"compression" yaml:"compression"`
+
 	// MaxRequestsInFlight controls the number of concurrent HTTP metrics requests.
+	// If this field is zero, there is no limit.  It must not be negative.
 	MaxRequestsInFlight int `json:"maxRequestsInFlight" yaml:"maxRequestsInFlight"`
 
-	// Timeout is the time period after which the handler will return a 503.
+	// Timeout is the time period after which the handler will return a 503.  If this
+	// field is zero, there is no timeout.  It must not be negative.
 	Timeout time.Duration `json:"timeout" yaml:"timeout"`
 
 	// EnableOpenMetrics controls whether open metrics encoding is available
 	// during content negotiation.
 	EnableOpenMetrics bool `json:"enableOpenMetrics" yaml:"enableOpenMetrics"`
 
+	// EnableCreatedLines controls whether the OpenMetrics encoding includes a _created
+	// line for each series with a created timestamp.  This field requires that
+	// EnableOpenMetrics is also set.  See NewCreatedLinesHandler.
+	EnableCreatedLines bool `json:"enableCreatedLines" yaml:"enableCreatedLines"`
+
+	// EnableJSON controls whether the JSON form of metrics is available during content
+	// negotiation.  When enabled, requests that prefer application/json are served
+	// the output of touchstone.WriteJSON.
+	EnableJSON bool `json:"enableJSON" yaml:"enableJSON"`
+
+	// EnableInventory controls whether the InventoryHandler serves the JSON inventory
+	// of metric families.  When disabled, the InventoryHandler responds with a 404.
+	EnableInventory bool `json:"enableInventory" yaml:"enableInventory"`
+
+	// EnableHealthCheck controls whether the HealthHandler checks that metrics can be
+	// gathered.  When disabled, the HealthHandler responds with a 404.  See NewHealthHandler.
+	EnableHealthCheck bool `json:"enableHealthCheck" yaml:"enableHealthCheck"`
+
+	// HealthCheckTimeout bounds the time the HealthHandler allows for gathering metrics.
+	// If this field is zero, touchstone.DefaultGatherTimeout is used.  It must not be negative.
+	HealthCheckTimeout time.Duration `json:"healthCheckTimeout" yaml:"healthCheckTimeout"`
+
+	// EnablePayloadMetrics controls whether the Handler maintains gauges for the size,
+	// family count, and series count of its own output.  See Payload.  The size is
+	// measured before compression, so enabling this option means the Handler, rather
+	// than promhttp, compresses output as described by Compression.
+	EnablePayloadMetrics bool `json:"enablePayloadMetrics" yaml:"enablePayloadMetrics"`
+
 	// InstrumentMetricHandler indicates whether the http.Handler that renders
 	// prometheus metrics will itself be decorated with metrics.
 	//
@@ -90,6 +145,64 @@ type Config struct {
 	InstrumentMetricHandler bool `json:"instrumentMetricHandler" yaml:"instrumentMetricHandler"`
 }
 
+// Validate checks this Config for contradictory or out-of-range values, returning
+// an error for each problem.  An unrecognized ErrorHandling results in an
+// *InvalidErrorHandlingError, and every other problem results in a *touchstone.ConfigError.
+//
+// NewHandlerOpts, and thus Provide, call this method so that problems are reported when
+// the application starts rather than when metrics are first served.
+func (cfg Config) Validate() (err error) {
+	switch cfg.ErrorHandling {
+	case "", HTTPErrorOnError, ContinueOnError, PanicOnError:
+		// valid
+
+	default:
+		err = multierr.Append(err, &InvalidErrorHandlingError{Value: cfg.ErrorHandling})
+	}
+
+	if cfg.MaxRequestsInFlight < 0 {
+		err = multierr.Append(err, &touchstone.ConfigError{
+			Field:   "MaxRequestsInFlight",
+			Message: fmt.Sprintf("%d is negative; use 0 for no limit", cfg.MaxRequestsInFlight),
+		})
+	}
+
+	if cfg.Timeout < 0 {
+		err = multierr.Append(err, &touchstone.ConfigError{
+			Field:   "Timeout",
+			Message: fmt.Sprintf("%s is negative; use 0 for no timeout", cfg.Timeout),
+		})
+	}
+
+	if cfg.HealthCheckTimeout < 0 {
+		err = multierr.Append(err, &touchstone.ConfigError{
+			Field:   "HealthCheckTimeout",
+			Message: fmt.Sprintf("%s is negative; use 0 for the default timeout", cfg.HealthCheckTimeout),
+		})
+	}
+
+	if cfg.EnableCreatedLines && !cfg.EnableOpenMetrics {
+		err = multierr.Append(err, &touchstone.ConfigError{
+			Field:   "EnableCreatedLines",
+			Message: "created lines are only written in the OpenMetrics format; also set EnableOpenMetrics",
+		})
+	}
+
+	if cfg.compressInHandler() {
+		if compressionErr := cfg.Compression.validate(); compressionErr != nil {
+			err = multierr.Append(err, compressionErr)
+		}
+	}
+
+	return
+}
+
+// compressInHandler tests if the Handler is decorated with NewCompressionHandler
+// instead of relying on promhttp compression.
+func (cfg Config) compressInHandler() bool {
+	return !cfg.DisableCompression && (cfg.Compression.enabled() || cfg.EnablePayloadMetrics)
+}
+
 // NewHandlerOpts creates a basic HandlerOpts from an Config configuration.
 func NewHandlerOpts(cfg Config, p fx.Printer, r prometheus.Registerer) (opts promhttp.HandlerOpts, err error) {
 	opts = promhttp.HandlerOpts{
@@ -100,6 +213,15 @@ func NewHandlerOpts(cfg Config, p fx.Printer, r prometheus.Registerer) (opts pro
 		Registry:            r,
 	}
 
+	if err = cfg.Validate(); err != nil {
+		return
+	}
+
+	if cfg.compressInHandler() {
+		// the Handler performs compression instead of promhttp
+		opts.DisableCompression = true
+	}
+
 	if p != nil {
 		opts.ErrorLog = ErrorPrinter{Printer: p}
 	}
@@ -117,9 +239,6 @@ func NewHandlerOpts(cfg Config, p fx.Printer, r prometheus.Registerer) (opts pro
 
 	case PanicOnError:
 		opts.ErrorHandling = promhttp.PanicOnError
-
-	default:
-		err = &InvalidErrorHandlingError{Value: cfg.ErrorHandling}
 	}
 
 	return
@@ -13,6 +13,11 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type ErrorPrinterTestSuite struct {
@@ -58,6 +63,17 @@ func (suite *ErrorPrinterTestSuite) TestPrintln() {
 	}
 }
 
+func (suite *ErrorPrinterTestSuite) TestErrorLogger() {
+	core, logs := observer.New(zapcore.DebugLevel)
+	el := ErrorLogger{Logger: zap.New(core)}
+
+	el.Println("test", 123, 18*time.Millisecond)
+	entries := logs.AllUntimed()
+	suite.Require().Len(entries, 1)
+	suite.Equal(zapcore.ErrorLevel, entries[0].Level)
+	suite.Equal("test 123 18ms", entries[0].Message)
+}
+
 func TestErrorPrinter(t *testing.T) {
 	suite.Run(t, new(ErrorPrinterTestSuite))
 }
@@ -160,6 +176,129 @@ func (suite *NewHandlerOptsTestSuite) TestErrorHandlingValues() {
 	})
 }
 
+func (suite *NewHandlerOptsTestSuite) TestCompression() {
+	suite.Run("Valid", func() {
+		ho, err := NewHandlerOpts(
+			Config{
+				Compression: Compression{GzipLevel: 1},
+			}, nil, nil,
+		)
+
+		suite.NoError(err)
+		suite.True(ho.DisableCompression, "the Handler should perform compression")
+	})
+
+	suite.Run("Invalid", func() {
+		_, err := NewHandlerOpts(
+			Config{
+				Compression: Compression{Encodings: []string{"br"}},
+			}, nil, nil,
+		)
+
+		var ice *InvalidCompressionError
+		suite.True(errors.As(err, &ice))
+	})
+
+	suite.Run("Disabled", func() {
+		_, err := NewHandlerOpts(
+			Config{
+				DisableCompression: true,
+				Compression:        Compression{Encodings: []string{"br"}},
+			}, nil, nil,
+		)
+
+		suite.NoError(err)
+	})
+}
+
 func TestNewHandlerOpts(t *testing.T) {
 	suite.Run(t, new(NewHandlerOptsTestSuite))
 }
+
+type ConfigValidateTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ConfigValidateTestSuite) TestValid() {
+	suite.NoError(Config{}.Validate())
+	suite.NoError(Config{
+		ErrorHandling:       PanicOnError,
+		MaxRequestsInFlight: 10,
+		Timeout:             time.Second,
+		EnableOpenMetrics:   true,
+		EnableCreatedLines:  true,
+	}.Validate())
+}
+
+func (suite *ConfigValidateTestSuite) TestInvalid() {
+	testCases := []struct {
+		name   string
+		cfg    Config
+		fields []string
+	}{
+		{
+			name:   "MaxRequestsInFlight",
+			cfg:    Config{MaxRequestsInFlight: -1},
+			fields: []string{"MaxRequestsInFlight"},
+		},
+		{
+			name:   "Timeout",
+			cfg:    Config{Timeout: -time.Second},
+			fields: []string{"Timeout"},
+		},
+		{
+			name:   "HealthCheckTimeout",
+			cfg:    Config{HealthCheckTimeout: -time.Second},
+			fields: []string{"HealthCheckTimeout"},
+		},
+		{
+			name:   "EnableCreatedLines",
+			cfg:    Config{EnableCreatedLines: true},
+			fields: []string{"EnableCreatedLines"},
+		},
+		{
+			name:   "Several",
+			cfg:    Config{MaxRequestsInFlight: -5, Timeout: -time.Minute},
+			fields: []string{"MaxRequestsInFlight", "Timeout"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.name, func() {
+			err := testCase.cfg.Validate()
+			suite.Require().Error(err)
+
+			errs := multierr.Errors(err)
+			suite.Require().Len(errs, len(testCase.fields))
+			for i, field := range testCase.fields {
+				var ce *touchstone.ConfigError
+				suite.Require().ErrorAs(errs[i], &ce)
+				suite.Equal(field, ce.Field)
+				suite.Contains(ce.Error(), field)
+			}
+
+			_, err = NewHandlerOpts(testCase.cfg, nil, nil)
+			suite.Error(err)
+		})
+	}
+}
+
+func (suite *ConfigValidateTestSuite) TestErrorHandlingAndCompression() {
+	err := Config{
+		ErrorHandling: "unknown",
+		Compression:   Compression{ZstdLevel: MaxZstdLevel + 1},
+	}.Validate()
+
+	var (
+		iehe *InvalidErrorHandlingError
+		ice  *InvalidCompressionError
+	)
+
+	suite.Len(multierr.Errors(err), 2)
+	suite.ErrorAs(err, &iehe)
+	suite.ErrorAs(err, &ice)
+}
+
+func TestConfigValidate(t *testing.T) {
+	suite.Run(t, new(ConfigValidateTestSuite))
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/netip"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// TLSVersionLabel is the metric label containing the TLS version of the connection
+	// that carried a request.  This label is only used when a ServerBundle's Connection
+	// enables TLSVersion.
+	TLSVersionLabel = "tls_version"
+
+	// TLSCipherLabel is the metric label containing the class of the TLS cipher suite
+	// of the connection that carried a request.  This label is only used when a
+	// ServerBundle's Connection enables TLSCipher.
+	TLSCipherLabel = "tls_cipher"
+
+	// IPFamilyLabel is the metric label containing the IP family of the client's address.
+	// This label is only used when a ServerBundle's Connection enables IPFamily.
+	IPFamilyLabel = "ip_family"
+
+	// TLSNone is the TLSVersionLabel and TLSCipherLabel value for requests that were
+	// not received over TLS.
+	TLSNone = "none"
+
+	// TLSOther is the TLSVersionLabel and TLSCipherLabel value for TLS versions and
+	// cipher suites that the crypto/tls package does not know about.
+	TLSOther = "other"
+
+	// TLSCipherSecure is the TLSCipherLabel value for cipher suites without known
+	// security issues.  See tls.CipherSuites.
+	TLSCipherSecure = "secure"
+
+	// TLSCipherInsecure is the TLSCipherLabel value for cipher suites with known
+	// security issues.  See tls.InsecureCipherSuites.
+	TLSCipherInsecure = "insecure"
+
+	// IPFamilyV4 is the IPFamilyLabel value for IPv4 clients, including IPv4 addresses
+	// mapped into IPv6.
+	IPFamilyV4 = "ipv4"
+
+	// IPFamilyV6 is the IPFamilyLabel value for IPv6 clients.
+	IPFamilyV6 = "ipv6"
+
+	// IPFamilyUnknown is the IPFamilyLabel value for requests whose remote address is
+	// not an IP address, e.g. requests received over a unix socket.
+	IPFamilyUnknown = "unknown"
+)
+
+var (
+	// tlsVersions maps the TLS versions known to crypto/tls onto label values
+	tlsVersions = map[uint16]string{
+		tls.VersionTLS10: "1.0",
+		tls.VersionTLS11: "1.1",
+		tls.VersionTLS12: "1.2",
+		tls.VersionTLS13: "1.3",
+	}
+
+	// tlsCiphers maps the cipher suites known to crypto/tls onto label values
+	tlsCiphers = cipherClasses()
+)
+
+func cipherClasses() map[uint16]string {
+	classes := make(map[uint16]string)
+	for _, cs := range tls.CipherSuites() {
+		classes[cs.ID] = TLSCipherSecure
+	}
+
+	for _, cs := range tls.InsecureCipherSuites() {
+		classes[cs.ID] = TLSCipherInsecure
+	}
+
+	return classes
+}
+
+// FormatTLSVersion returns the TLSVersionLabel value for the given connection state.
+// A nil state, i.e. a request that was not received over TLS, results in TLSNone.
+func FormatTLSVersion(cs *tls.ConnectionState) string {
+	if cs == nil {
+		return TLSNone
+	} else if v, ok := tlsVersions[cs.Version]; ok {
+		return v
+	}
+
+	return TLSOther
+}
+
+// FormatTLSCipher returns the TLSCipherLabel value for the given connection state.
+// A nil state, i.e. a request that was not received over TLS, results in TLSNone.
+func FormatTLSCipher(cs *tls.ConnectionState) string {
+	if cs == nil {
+		return TLSNone
+	} else if c, ok := tlsCiphers[cs.CipherSuite]; ok {
+		return c
+	}
+
+	return TLSOther
+}
+
+// FormatIPFamily returns the IPFamilyLabel value for a remote address, such as
+// http.Request.RemoteAddr.  The address may or may not have a port.
+func FormatIPFamily(remoteAddr string) string {
+	var addr netip.Addr
+	if ap, err := netip.ParseAddrPort(remoteAddr); err == nil {
+		addr = ap.Addr()
+	} else if addr, err = netip.ParseAddr(remoteAddr); err != nil {
+		return IPFamilyUnknown
+	}
+
+	if addr.Unmap().Is4() {
+		return IPFamilyV4
+	}
+
+	return IPFamilyV6
+}
+
+// Connection adds labels describing the connection that carried each request to every
+// server metric that has the CodeLabel and MethodLabel.  Each label has a small, fixed
+// set of values, so enabling them does not allow unbounded cardinality.
+type Connection struct {
+	// TLSVersion adds the TLSVersionLabel, which holds the TLS version of each request,
+	// e.g. "1.2", or TLSNone for plaintext requests.
+	TLSVersion bool
+
+	// TLSCipher adds the TLSCipherLabel, which holds whether the cipher suite of each
+	// request is TLSCipherSecure or TLSCipherInsecure, or TLSNone for plaintext requests.
+	TLSCipher bool
+
+	// IPFamily adds the IPFamilyLabel, which holds IPFamilyV4 or IPFamilyV6 based on the
+	// client's address.
+	IPFamily bool
+}
+
+// maxConnectionLabels is the number of labels that a Connection can add.
+const maxConnectionLabels = 3
+
+// connectionLabel describes one of the labels that a Connection can add.
+type connectionLabel struct {
+	name   string
+	values []string
+	format func(*http.Request) string
+}
+
+// labels returns the enabled labels, in a fixed order.  There are never more
+// than maxConnectionLabels.
+func (c Connection) labels() (cl []connectionLabel) {
+	if c.TLSVersion {
+		cl = append(cl, connectionLabel{
+			name:   TLSVersionLabel,
+			values: []string{TLSNone, "1.0", "1.1", "1.2", "1.3", TLSOther},
+			format: func(r *http.Request) string { return FormatTLSVersion(r.TLS) },
+		})
+	}
+
+	if c.TLSCipher {
+		cl = append(cl, connectionLabel{
+			name:   TLSCipherLabel,
+			values: []string{TLSNone, TLSCipherSecure, TLSCipherInsecure, TLSOther},
+			format: func(r *http.Request) string { return FormatTLSCipher(r.TLS) },
+		})
+	}
+
+	if c.IPFamily {
+		cl = append(cl, connectionLabel{
+			name:   IPFamilyLabel,
+			values: []string{IPFamilyV4, IPFamilyV6, IPFamilyUnknown},
+			format: func(r *http.Request) string { return FormatIPFamily(r.RemoteAddr) },
+		})
+	}
+
+	return
+}
+
+// new validates this Connection against the curried labels and returns the enabled
+// labels.  A Connection that enables nothing results in nil.
+func (c Connection) new(curry prometheus.Labels) ([]connectionLabel, error) {
+	cl := c.labels()
+	for _, l := range cl {
+		if _, reserved := curry[l.name]; reserved {
+			return nil, fmt.Errorf("%w: %s", ErrReservedLabelName, l.name)
+		}
+	}
+
+	return cl, nil
+}
+
+// preinitializeLabels expands each label set with every value of the enabled labels.
+func (c Connection) preinitializeLabels(labelSets []prometheus.Labels) []prometheus.Labels {
+	for _, l := range c.labels() {
+		expanded := make([]prometheus.Labels, 0, len(labelSets)*len(l.values))
+		for _, ls := range labelSets {
+			for _, v := range l.values {
+				labelSet := make(prometheus.Labels, len(ls)+1)
+				for k, lv := range ls {
+					labelSet[k] = lv
+				}
+
+				labelSet[l.name] = v
+				expanded = append(expanded, labelSet)
+			}
+		}
+
+		labelSets = expanded
+	}
+
+	return labelSets
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConnectionSuite struct {
+	BundleSuite
+}
+
+func (suite *ConnectionSuite) TestFormatTLSVersion() {
+	suite.Equal(TLSNone, FormatTLSVersion(nil))
+	suite.Equal("1.0", FormatTLSVersion(&tls.ConnectionState{Version: tls.VersionTLS10}))
+	suite.Equal("1.1", FormatTLSVersion(&tls.ConnectionState{Version: tls.VersionTLS11}))
+	suite.Equal("1.2", FormatTLSVersion(&tls.ConnectionState{Version: tls.VersionTLS12}))
+	suite.Equal("1.3", FormatTLSVersion(&tls.ConnectionState{Version: tls.VersionTLS13}))
+	suite.Equal(TLSOther, FormatTLSVersion(&tls.ConnectionState{Version: 0x1234}))
+}
+
+func (suite *ConnectionSuite) TestFormatTLSCipher() {
+	suite.Equal(TLSNone, FormatTLSCipher(nil))
+	suite.Equal(TLSCipherSecure, FormatTLSCipher(&tls.ConnectionState{CipherSuite: tls.TLS_AES_128_GCM_SHA256}))
+	suite.Equal(TLSCipherInsecure, FormatTLSCipher(&tls.ConnectionState{CipherSuite: tls.TLS_RSA_WITH_RC4_128_SHA}))
+	suite.Equal(TLSOther, FormatTLSCipher(&tls.ConnectionState{CipherSuite: 0xffff}))
+}
+
+func (suite *ConnectionSuite) TestFormatIPFamily() {
+	testCases := []struct {
+		remoteAddr string
+		expected   string
+	}{
+		{"192.0.2.1:1234", IPFamilyV4},
+		{"192.0.2.1", IPFamilyV4},
+		{"[::ffff:192.0.2.1]:1234", IPFamilyV4},
+		{"[2001:db8::1]:1234", IPFamilyV6},
+		{"2001:db8::1", IPFamilyV6},
+		{"@", IPFamilyUnknown},
+		{"", IPFamilyUnknown},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.remoteAddr, func() {
+			suite.Equal(testCase.expected, FormatIPFamily(testCase.remoteAddr))
+		})
+	}
+}
+
+func (suite *ConnectionSuite) TestServer() {
+	si, err := ServerBundle{
+		Connection: &Connection{
+			TLSVersion: true,
+			TLSCipher:  true,
+			IPFamily:   true,
+		},
+		Transactions: true,
+	}.NewInstrumenter(ServerLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	var labels prometheus.Labels
+	h := si.Then(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		st, _ := FromContext(r.Context())
+		labels = st.Labels()
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "[2001:db8::1]:1234"
+	r.TLS = &tls.ConnectionState{Version: tls.VersionTLS13, CipherSuite: tls.TLS_AES_128_GCM_SHA256}
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	suite.Equal("1.3", labels[TLSVersionLabel])
+	suite.Equal(TLSCipherSecure, labels[TLSCipherLabel])
+	suite.Equal(IPFamilyV6, labels[IPFamilyLabel])
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	count := func(version, cipher, family string) float64 {
+		return testutil.ToFloat64(si.count.With(prometheus.Labels{
+			CodeLabel:       "200",
+			MethodLabel:     http.MethodGet,
+			TLSVersionLabel: version,
+			TLSCipherLabel:  cipher,
+			IPFamilyLabel:   family,
+		}))
+	}
+
+	suite.Equal(1.0, count("1.3", TLSCipherSecure, IPFamilyV6))
+	suite.Equal(1.0, count(TLSNone, TLSNone, IPFamilyV4))
+	suite.Equal(2, testutil.CollectAndCount(si.duration))
+}
+
+func (suite *ConnectionSuite) TestPreinitialize() {
+	si, err := ServerBundle{
+		Connection:    &Connection{IPFamily: true},
+		Preinitialize: LabelCombinations([]int{http.StatusOK}, []string{http.MethodGet, http.MethodPost}),
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Equal(6, testutil.CollectAndCount(si.count))
+}
+
+func (suite *ConnectionSuite) TestReserved() {
+	_, err := ServerBundle{
+		Connection: &Connection{TLSVersion: true},
+	}.NewInstrumenter(TLSVersionLabel, "value")(suite.newFactory())
+
+	suite.ErrorIs(err, ErrReservedLabelName)
+}
+
+func TestConnection(t *testing.T) {
+	suite.Run(t, new(ConnectionSuite))
+}
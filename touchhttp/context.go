@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Phase is the total time a server transaction spent in a named phase.
+type Phase struct {
+	// Name is the name of the phase, which is the value of the PhaseLabel.
+	Name string
+
+	// Duration is the total time recorded for this phase.
+	Duration time.Duration
+}
+
+// ServerTransaction is the handle for a server request that is being instrumented.
+// A ServerInstrumenter places a ServerTransaction into the context of each request
+// when its bundle enables Transactions, Phases, or Hooks, where downstream handlers
+// can obtain it with FromContext.
+//
+// A ServerTransaction allows handlers to attach extra observations to the same labels
+// as the request's metrics, either by recording phases or by using Labels with their
+// own metrics.  Phases are timed with TimePhase, StartSegment and EndSegment, or
+// reported directly with AddPhase.  It is safe for concurrent use, e.g. by goroutines
+// that a handler starts to call several backends at once.
+type ServerTransaction struct {
+	start time.Time
+	now   func() time.Time
+
+	// the label values known when the transaction began, from which Labels is built
+	curry      prometheus.Labels
+	method     string
+	tenancy    bool
+	tenant     string
+	connection []connectionLabel
+	connValues [maxConnectionLabels]string
+
+	lock     sync.Mutex
+	phases   []Phase
+	segments map[string]time.Time
+}
+
+type serverTransactionKey struct{}
+
+// FromContext returns the ServerTransaction for the request that the context belongs
+// to.  If the request was not instrumented by a ServerInstrumenter, or that instrumenter
+// does not attach transactions, this function returns nil and false.
+// See ServerBundle.Transactions.
+func FromContext(ctx context.Context) (*ServerTransaction, bool) {
+	st, ok := ctx.Value(serverTransactionKey{}).(*ServerTransaction)
+	return st, ok
+}
+
+// withServerTransaction returns a child context that holds the given transaction.
+func withServerTransaction(ctx context.Context, st *ServerTransaction) context.Context {
+	return context.WithValue(ctx, serverTransactionKey{}, st)
+}
+
+// Start returns the time at which the transaction started.
+func (st *ServerTransaction) Start() time.Time {
+	return st.start
+}
+
+// Elapsed returns the time since the transaction started.
+func (st *ServerTransaction) Elapsed() time.Duration {
+	return st.now().Sub(st.start)
+}
+
+// Labels returns the labels of this transaction that are known before it completes.
+// These are any extra labels given to the instrumenter, the MethodLabel, the
+// TenantLabel if the bundle has a Tenancy, and any labels enabled by the bundle's
+// Connection.  The CodeLabel is not known until the handler completes, so it is
+// never included.
+//
+// The returned labels are created on each call and may be modified, e.g. to add labels
+// for a custom metric.
+func (st *ServerTransaction) Labels() prometheus.Labels {
+	l := make(prometheus.Labels, len(st.curry)+len(st.connection)+2)
+	for k, v := range st.curry {
+		l[k] = v
+	}
+
+	l[MethodLabel] = st.method
+	if st.tenancy {
+		l[TenantLabel] = st.tenant
+	}
+
+	for j, cl := range st.connection {
+		l[cl.name] = st.connValues[j]
+	}
+
+	return l
+}
+
+// AddPhase adds time to the named phase.  Time added to the same phase more than
+// once, e.g. for each call to a backend, is summed.  The phases are recorded when
+// the transaction completes.
+func (st *ServerTransaction) AddPhase(name string, d time.Duration) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.addPhase(name, d)
+}
+
+// addPhase adds time to a phase.  The lock must be held.
+func (st *ServerTransaction) addPhase(name string, d time.Duration) {
+	for i := range st.phases {
+		if st.phases[i].Name == name {
+			st.phases[i].Duration += d
+			return
+		}
+	}
+
+	st.phases = append(st.phases, Phase{Name: name, Duration: d})
+}
+
+// TimePhase starts timing the named phase.  The returned function stops timing and
+// adds the elapsed time to the phase.  Typical usage is:
+//
+//	defer st.TimePhase("database")()
+func (st *ServerTransaction) TimePhase(name string) func() {
+	start := st.now()
+	return func() {
+		st.AddPhase(name, st.now().Sub(start))
+	}
+}
+
+// StartSegment begins timing the named phase.  The segment ends with a call to
+// EndSegment with the same name, or when the handler returns, whichever comes first.
+// Starting a segment that is already in progress restarts it.
+//
+// Segments allow phases to be timed across functions that do not share a stack
+// frame.  When the start and end are in the same function, TimePhase is simpler.
+func (st *ServerTransaction) StartSegment(name string) {
+	now := st.now()
+
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	if st.segments == nil {
+		st.segments = make(map[string]time.Time)
+	}
+
+	st.segments[name] = now
+}
+
+// EndSegment ends the named segment, adding its elapsed time to the phase of the
+// same name and returning that elapsed time.  If no such segment is in progress,
+// this method does nothing and returns zero.
+func (st *ServerTransaction) EndSegment(name string) time.Duration {
+	now := st.now()
+
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	start, ok := st.segments[name]
+	if !ok {
+		return 0
+	}
+
+	delete(st.segments, name)
+	d := now.Sub(start)
+	st.addPhase(name, d)
+	return d
+}
+
+// complete ends every segment that is still in progress and returns the final
+// phases.  This is done when the handler returns.
+func (st *ServerTransaction) complete() []Phase {
+	st.lock.Lock()
+	if len(st.segments) > 0 {
+		// end the segments in a predictable order
+		names := make([]string, 0, len(st.segments))
+		for name := range st.segments {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+		now := st.now()
+		for _, name := range names {
+			st.addPhase(name, now.Sub(st.segments[name]))
+		}
+
+		st.segments = nil
+	}
+
+	st.lock.Unlock()
+	return st.Phases()
+}
+
+// Phases returns a copy of the phases recorded so far, in the order in which
+// each phase was first recorded.  Segments still in progress are not included.
+func (st *ServerTransaction) Phases() []Phase {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+
+	if len(st.phases) == 0 {
+		return nil
+	}
+
+	return append([]Phase{}, st.phases...)
+}
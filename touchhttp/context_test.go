@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+)
+
+type ContextSuite struct {
+	BundleSuite
+}
+
+func (suite *ContextSuite) TestFromContextMissing() {
+	st, ok := FromContext(context.Background())
+	suite.Nil(st)
+	suite.False(ok)
+}
+
+func (suite *ContextSuite) TestNotAttached() {
+	si, err := ServerBundle{}.NewInstrumenter()(suite.newFactory())
+	suite.Require().NoError(err)
+
+	attached := true
+	h := si.Then(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_, attached = FromContext(r.Context())
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.False(attached, "a transaction should only be attached when it is needed")
+}
+
+func (suite *ContextSuite) TestTransactions() {
+	si, err := ServerBundle{
+		Transactions: true,
+	}.NewInstrumenter(ServerLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	var labels prometheus.Labels
+	h := si.Then(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		st, ok := FromContext(r.Context())
+		suite.Require().True(ok)
+		labels = st.Labels()
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Equal(
+		prometheus.Labels{
+			ServerLabel: "main",
+			MethodLabel: http.MethodGet,
+		},
+		labels,
+	)
+}
+
+func (suite *ContextSuite) TestServerTransaction() {
+	var observations []Observation
+	si, err := ServerBundle{
+		Tenancy: &Tenancy{
+			Config:    TenantConfig{Allow: []string{"a"}},
+			Extractor: TenantFromHeader(tenantHeader),
+		},
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+		Clock: suite.clock(time.Millisecond),
+	}.NewInstrumenter(ServerLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Nil(si.phaseDuration)
+
+	h := si.Then(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		st, ok := FromContext(r.Context())
+		suite.Require().True(ok)
+		suite.Require().NotNil(st)
+
+		suite.Equal(suite.now, st.Start())
+		suite.Equal(
+			prometheus.Labels{
+				ServerLabel: "main",
+				MethodLabel: http.MethodPut,
+				TenantLabel: "a",
+			},
+			st.Labels(),
+		)
+
+		st.Labels()[ServerLabel] = "modified"
+		suite.Equal("main", st.Labels()[ServerLabel], "Labels should return a copy")
+		suite.Positive(st.Elapsed())
+		suite.Nil(st.Phases())
+
+		// the clock advances by one step on each call
+		stop := st.TimePhase("database")
+		stop()
+		st.AddPhase("backend", 3*time.Millisecond)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				st.AddPhase("backend", time.Millisecond)
+			}()
+		}
+
+		wg.Wait()
+		suite.Equal(
+			[]Phase{
+				{Name: "database", Duration: time.Millisecond},
+				{Name: "backend", Duration: 5 * time.Millisecond},
+			},
+			st.Phases(),
+		)
+	}))
+
+	request := httptest.NewRequest(http.MethodPut, "/", nil)
+	request.Header.Set(tenantHeader, "a")
+	h.ServeHTTP(httptest.NewRecorder(), request)
+
+	suite.Require().Len(observations, 1)
+	suite.Len(observations[0].Phases, 2)
+}
+
+func (suite *ContextSuite) TestSegments() {
+	si, err := ServerBundle{
+		Phases: &Phases{Names: []string{"database", "cache"}},
+		Clock:  suite.clock(time.Millisecond),
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(si.phaseDuration)
+
+	var observations []Observation
+	si.hooks = append(si.hooks, func(o Observation) { observations = append(observations, o) })
+
+	h := si.Then(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		st, _ := FromContext(r.Context())
+		suite.Zero(st.EndSegment("database"), "no segment is in progress")
+
+		// each call to the clock advances it by one step
+		st.StartSegment("database")
+		suite.Equal(time.Millisecond, st.EndSegment("database"))
+		st.StartSegment("database")
+		suite.Equal(time.Millisecond, st.EndSegment("database"))
+
+		st.StartSegment("unknown")
+		suite.Equal(time.Millisecond, st.EndSegment("unknown"))
+
+		// these are ended when the handler returns
+		st.StartSegment("cache")
+		st.StartSegment("auth")
+		suite.Len(st.Phases(), 2, "segments in progress are not included")
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Require().Len(observations, 1)
+	suite.Equal(
+		[]Phase{
+			{Name: "database", Duration: 2 * time.Millisecond},
+			{Name: "unknown", Duration: time.Millisecond},
+			{Name: "auth", Duration: 2 * time.Millisecond},
+			{Name: "cache", Duration: 3 * time.Millisecond},
+		},
+		observations[0].Phases,
+	)
+
+	hd, err := touchstone.HistogramValue(si.phaseDuration, prometheus.Labels{PhaseLabel: "database"})
+	suite.Require().NoError(err)
+	suite.Equal(2.0, hd.Sum)
+
+	hd, err = touchstone.HistogramValue(si.phaseDuration, prometheus.Labels{PhaseLabel: "cache"})
+	suite.Require().NoError(err)
+	suite.Equal(3.0, hd.Sum)
+
+	// both unknown and auth are bounded by the allowed names
+	hd, err = touchstone.HistogramValue(si.phaseDuration, prometheus.Labels{PhaseLabel: PhaseOther})
+	suite.Require().NoError(err)
+	suite.Equal(uint64(2), hd.Count)
+
+	_, err = touchstone.HistogramValue(si.phaseDuration, prometheus.Labels{PhaseLabel: "auth"})
+	suite.ErrorIs(err, touchstone.ErrNoSuchSeries)
+}
+
+func TestContext(t *testing.T) {
+	suite.Run(t, new(ContextSuite))
+}
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// NewCreatedLinesHandler returns an http.Handler that serves the OpenMetrics text
+// form of the metrics gathered from g, including a _created line for each series
+// with a created timestamp.  The promhttp handler omits these lines.
+//
+// This handler does not compress its output.  If gathering fails, this handler
+// responds with a 500 status.
+func NewCreatedLinesHandler(g prometheus.Gatherer) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		mfs, err := g.Gather()
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var (
+			body   bytes.Buffer
+			format = expfmt.NegotiateIncludingOpenMetrics(r.Header)
+		)
+
+		if format.FormatType() != expfmt.TypeOpenMetrics {
+			format = expfmt.NewFormat(expfmt.TypeOpenMetrics)
+		}
+
+		enc := expfmt.NewEncoder(&body, format, expfmt.WithCreatedLines())
+		for _, mf := range mfs {
+			if err = enc.Encode(mf); err != nil {
+				break
+			}
+		}
+
+		if closer, ok := enc.(expfmt.Closer); ok && err == nil {
+			err = closer.Close()
+		}
+
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", string(format))
+		rw.WriteHeader(http.StatusOK)
+		_, _ = body.WriteTo(rw)
+	})
+}
+
+// NegotiateCreatedLines produces an http.Handler that serves requests negotiating
+// OpenMetrics with the created handler and all other requests with next.  Typically,
+// created is created with NewCreatedLinesHandler and next is the promhttp handler.
+func NegotiateCreatedLines(created, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if expfmt.NegotiateIncludingOpenMetrics(r.Header).FormatType() == expfmt.TypeOpenMetrics {
+			created.ServeHTTP(rw, r)
+		} else {
+			next.ServeHTTP(rw, r)
+		}
+	})
+}
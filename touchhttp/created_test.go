@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+)
+
+const openMetricsAccept = "application/openmetrics-text;version=1.0.0"
+
+type CreatedSuite struct {
+	BundleSuite
+}
+
+func (suite *CreatedSuite) TestNewCreatedLinesHandler() {
+	r := prometheus.NewPedanticRegistry()
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_counter_total", Help: "test"})
+	c.Inc()
+	suite.Require().NoError(r.Register(c))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Accept", openMetricsAccept)
+	response := httptest.NewRecorder()
+	NewCreatedLinesHandler(r).ServeHTTP(response, request)
+
+	suite.Equal(http.StatusOK, response.Code)
+	suite.Contains(response.Header().Get("Content-Type"), "application/openmetrics-text")
+	suite.Contains(response.Body.String(), "test_counter_total 1")
+	suite.Contains(response.Body.String(), "test_counter_created ")
+	suite.Contains(response.Body.String(), "# EOF")
+
+	suite.Run("Error", func() {
+		g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return nil, errors.New("expected")
+		})
+
+		response := httptest.NewRecorder()
+		NewCreatedLinesHandler(g).ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+		suite.Equal(http.StatusInternalServerError, response.Code)
+	})
+}
+
+func (suite *CreatedSuite) TestNegotiateCreatedLines() {
+	var (
+		created = http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			rw.WriteHeader(299)
+		})
+
+		next = http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			rw.WriteHeader(298)
+		})
+
+		h = NegotiateCreatedLines(created, next)
+	)
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Accept", openMetricsAccept)
+	response := httptest.NewRecorder()
+	h.ServeHTTP(response, request)
+	suite.Equal(299, response.Code)
+
+	response = httptest.NewRecorder()
+	h.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Equal(298, response.Code)
+}
+
+// TestBundleCounters verifies that the counters of the touchhttp bundles carry
+// created timestamps unless the factory strips them.
+func (suite *CreatedSuite) TestBundleCounters() {
+	cfg := touchstone.Config{
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	for _, enabled := range []bool{true, false} {
+		g, r, err := touchstone.New(cfg)
+		suite.Require().NoError(err)
+
+		si, err := ServerBundle{}.NewInstrumenter()(
+			touchstone.NewFactory(cfg, nil, r, touchstone.WithCreatedTimestamps(enabled)),
+		)
+
+		suite.Require().NoError(err)
+		si.Then(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		mfs, err := g.Gather()
+		suite.Require().NoError(err)
+
+		var found bool
+		for _, mf := range mfs {
+			if mf.GetName() == DefaultServerCount {
+				found = true
+				suite.Equal(enabled, mf.GetMetric()[0].GetCounter().GetCreatedTimestamp() != nil)
+			}
+		}
+
+		suite.True(found)
+	}
+}
+
+func TestCreated(t *testing.T) {
+	suite.Run(t, new(CreatedSuite))
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/fx"
+)
+
+// DefaultClientName is the ClientLabel value of the metrics recorded for
+// http.DefaultTransport.  See InstrumentDefaultTransport.
+const DefaultClientName = "default"
+
+// DefaultTransportIn defines the dependencies used to instrument http.DefaultTransport.
+type DefaultTransportIn struct {
+	fx.In
+
+	// Factory is the required touchstone MetricFactory instance.
+	Factory touchstone.MetricFactory
+
+	// Lifecycle is used to restore the original http.DefaultTransport.
+	Lifecycle fx.Lifecycle
+
+	// Bundle is the optional ClientBundle supplied in the application.  If not
+	// present, the default metrics are used.  Since the metrics are labeled with
+	// ClientLabel, any other instrumenters created from this bundle must also be
+	// labeled with ClientLabel.
+	Bundle ClientBundle `optional:"true"`
+}
+
+// defaultTransport is the instrumented http.DefaultTransport.  It is always used through
+// a pointer, so that the installed transport can be recognized when the application stops.
+type defaultTransport struct {
+	http.RoundTripper
+}
+
+// CloseIdleConnections closes the idle connections of the decorated transport, if supported.
+func (dt *defaultTransport) CloseIdleConnections() {
+	if ci, ok := dt.RoundTripper.(interface{ CloseIdleConnections() }); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+// InstrumentDefaultTransport is an opt-in fx option that replaces http.DefaultTransport
+// with a ClientInstrumenter that decorates the original transport, with the ClientLabel
+// set to DefaultClientName.  This records metrics for libraries that use http.DefaultClient,
+// or that construct their own http.Client from the defaults, and so bypass any instrumented
+// clients in the application.
+//
+// http.DefaultTransport is replaced when the enclosing fx.App is constructed, so that
+// clients created during construction capture the instrumented transport.  The original
+// transport is restored when the application stops, unless http.DefaultTransport has
+// since been replaced by something else.
+//
+// Since http.DefaultTransport is global, only one fx.App in a process should use this option
+// at a time.  Clients that are already instrumented should not use http.DefaultTransport,
+// or their transactions will be recorded twice.
+func InstrumentDefaultTransport() fx.Option {
+	return fx.Invoke(
+		func(in DefaultTransportIn) error {
+			ci, err := in.Bundle.NewInstrumenter(ClientLabel, DefaultClientName)(in.Factory)
+			if err != nil {
+				return err
+			}
+
+			var (
+				original     = http.DefaultTransport
+				instrumented = &defaultTransport{RoundTripper: ci.ThenRoundTripper(original)}
+			)
+
+			http.DefaultTransport = instrumented
+			in.Lifecycle.Append(fx.Hook{
+				OnStop: func(context.Context) error {
+					// don't clobber a transport that was installed after ours
+					if current, ok := http.DefaultTransport.(*defaultTransport); ok && current == instrumented {
+						http.DefaultTransport = original
+					}
+
+					return nil
+				},
+			})
+
+			return nil
+		},
+	)
+}
@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type DefaultTransportSuite struct {
+	BundleSuite
+
+	server *httptest.Server
+}
+
+func (suite *DefaultTransportSuite) SetupTest() {
+	suite.BundleSuite.SetupTest()
+	suite.server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chain":
+			http.Redirect(rw, r, "/redirect", http.StatusFound)
+			return
+
+		case "/redirect":
+			http.Redirect(rw, r, "/", http.StatusFound)
+			return
+		}
+
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+}
+
+func (suite *DefaultTransportSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *DefaultTransportSuite) get(c *http.Client, path string) {
+	response, err := c.Get(suite.server.URL + path)
+	suite.Require().NoError(err)
+	io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+}
+
+func (suite *DefaultTransportSuite) TestThenRoundTripper() {
+	ci, err := ClientBundle{}.NewInstrumenter(ClientLabel, "test")(suite.newFactory())
+	suite.Require().NoError(err)
+
+	transport := new(http.Transport)
+	defer transport.CloseIdleConnections()
+
+	rt := ci.ThenRoundTripper(transport)
+	_, ok := rt.(interface{ CloseIdleConnections() })
+	suite.True(ok)
+
+	c := &http.Client{Transport: rt}
+	suite.get(c, "/")
+	suite.get(c, "/redirect")
+
+	// each hop of a redirect is its own transaction
+	suite.Equal(2.0, testutil.ToFloat64(ci.count.WithLabelValues("202", http.MethodGet)))
+	suite.Equal(1.0, testutil.ToFloat64(ci.count.WithLabelValues("302", http.MethodGet)))
+}
+
+func (suite *DefaultTransportSuite) TestThenRoundTripperRedirects() {
+	var observations []Observation
+	ci, err := ClientBundle{
+		RedirectCount: &prometheus.CounterOpts{},
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+	}.NewInstrumenter(ClientLabel, "test")(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	transport := new(http.Transport)
+	defer transport.CloseIdleConnections()
+
+	c := &http.Client{Transport: ci.ThenRoundTripper(transport)}
+	suite.get(c, "/chain")
+
+	// two redirects, each counted once by the hop it led to
+	suite.Equal(2.0, testutil.ToFloat64(ci.redirectCount.WithLabelValues(http.MethodGet)))
+	suite.Require().Len(observations, 3)
+	suite.Zero(observations[0].Redirects)
+	suite.Equal(1, observations[1].Redirects)
+	suite.Equal(1, observations[2].Redirects)
+}
+
+func (suite *DefaultTransportSuite) TestInstrumentDefaultTransport() {
+	var (
+		original = http.DefaultTransport
+		g        prometheus.Gatherer
+
+		app = fxtest.New(
+			suite.T(),
+			touchstone.Provide(),
+			InstrumentDefaultTransport(),
+			fx.Populate(&g),
+		)
+	)
+
+	suite.NotEqual(original, http.DefaultTransport)
+	app.RequireStart()
+
+	suite.get(http.DefaultClient, "/")
+
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+
+	var found bool
+	for _, mf := range mfs {
+		if mf.GetName() != DefaultClientCount {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() == ClientLabel {
+					found = true
+					suite.Equal(DefaultClientName, lp.GetValue())
+					suite.Equal(1.0, m.GetCounter().GetValue())
+				}
+			}
+		}
+	}
+
+	suite.True(found)
+
+	app.RequireStop()
+	suite.Equal(original, http.DefaultTransport)
+}
+
+func (suite *DefaultTransportSuite) TestInstrumentDefaultTransportReplaced() {
+	var (
+		original    = http.DefaultTransport
+		replacement = new(http.Transport)
+
+		app = fxtest.New(
+			suite.T(),
+			touchstone.Provide(),
+			InstrumentDefaultTransport(),
+		)
+	)
+
+	defer func() { http.DefaultTransport = original }()
+
+	app.RequireStart()
+	_, ok := http.DefaultTransport.(interface{ CloseIdleConnections() })
+	suite.True(ok)
+
+	http.DefaultTransport = replacement
+	app.RequireStop()
+	suite.Equal(replacement, http.DefaultTransport)
+}
+
+func (suite *DefaultTransportSuite) TestInstrumentDefaultTransportError() {
+	original := http.DefaultTransport
+	app := fx.New(
+		fx.NopLogger,
+		touchstone.Provide(),
+		fx.Supply(ClientBundle{Duration: "not an opts struct"}),
+		InstrumentDefaultTransport(),
+	)
+
+	suite.Error(app.Err())
+	suite.Equal(original, http.DefaultTransport)
+}
+
+func TestDefaultTransport(t *testing.T) {
+	suite.Run(t, new(DefaultTransportSuite))
+}
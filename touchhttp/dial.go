@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/multierr"
+)
+
+const (
+	// DefaultClientDialCount is the default name of the counter of connections dialed by clients.
+	DefaultClientDialCount = "client_dial_count"
+
+	// DefaultClientDialDuration is the default name of the observer of client dial durations.
+	DefaultClientDialDuration = "client_dial_duration_ms"
+
+	// DefaultClientDialErrorCount is the default name of the counter of failed client dials.
+	DefaultClientDialErrorCount = "client_dial_error_count"
+)
+
+var (
+	defaultClientDialCount = prometheus.CounterOpts{
+		Name: DefaultClientDialCount,
+		Help: "the total number of connections dialed by clients",
+	}
+
+	defaultClientDialDuration = prometheus.HistogramOpts{
+		Name:    DefaultClientDialDuration,
+		Help:    "the time in milliseconds to dial a connection, including any name resolution",
+		Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000, 10000},
+	}
+
+	defaultClientDialErrorCount = prometheus.CounterOpts{
+		Name: DefaultClientDialErrorCount,
+		Help: "the total number of dials that failed",
+	}
+)
+
+// DialContext is the signature of a function that dials connections, e.g.
+// (*net.Dialer).DialContext.  This is also the type of http.Transport.DialContext.
+type DialContext func(ctx context.Context, network, address string) (net.Conn, error)
+
+// DialerBundle describes the metrics for the connections dialed by an HTTP client's
+// transport.  Request level metrics, such as those from a ClientBundle, cannot show
+// connection setup problems such as slow name resolution or refused connections.
+//
+// All metrics are labeled with any extra labels plus the NetworkLabel.  Supplying the
+// same extra labels as the ClientBundle, e.g. a ClientLabel, allows dial metrics to be
+// correlated with request metrics.
+type DialerBundle struct {
+	// Count describes the options used for the dial counter.
+	Count prometheus.CounterOpts
+
+	// Duration describes the options for the dial duration observer.  If set, it must
+	// be either a prometheus.HistogramOpts or a prometheus.SummaryOpts.
+	Duration interface{}
+
+	// ErrorCount describes the options for the counter of failed dials.
+	ErrorCount prometheus.CounterOpts
+
+	// LastError describes the options for the optional gauge describing the most recent
+	// failed dial.  The gauge has a single series, with the value 1, labeled with any extra
+	// labels, the network as the touchstone.ReasonLabel, and the touchstone.MessageHashLabel.
+	// Unset options default to those derived from ErrorCount, e.g. client_dial_last_error_info.
+	// If this field is nil, this gauge is not created.
+	LastError *prometheus.GaugeOpts
+
+	// Clock is the source of the current time.  If unset, the Clock of the
+	// MetricFactory is used.
+	Clock touchstone.Clock
+}
+
+func (db DialerBundle) newDuration(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+	var opts interface{}
+	switch t := db.Duration.(type) {
+	case nil:
+		clone := defaultClientDialDuration
+		opts = clone
+
+	case prometheus.HistogramOpts:
+		touchstone.ApplyDefaults(&t, defaultClientDialDuration)
+		opts = t
+
+	case prometheus.SummaryOpts:
+		touchstone.ApplyDefaults(&t, defaultClientDialDuration)
+		opts = t
+
+	default:
+		return nil, errors.New("DialerBundle.Duration must be nil, a prometheus.HistogramOpts, or a prometheus.SummaryOpts")
+	}
+
+	return newObserverVec(f, opts, labelNames, curry)
+}
+
+// NewInstrumenter creates a constructor that can be passed to fx.Provide.  The returned
+// constructor creates a DialInstrumenter given a touchstone.MetricFactory.  As with the
+// other bundles, namesAndValues are extra labels that distinguish this dialer's metrics.
+func (db DialerBundle) NewInstrumenter(namesAndValues ...string) func(touchstone.MetricFactory) (DialInstrumenter, error) {
+	return func(f touchstone.MetricFactory) (di DialInstrumenter, err error) {
+		var (
+			extraNames []string
+			curry      prometheus.Labels
+		)
+
+		extraNames, curry, err = labelNames(namesAndValues)
+		if err != nil {
+			return
+		}
+
+		if _, reserved := curry[NetworkLabel]; reserved {
+			err = fmt.Errorf("%w: %s", ErrReservedLabelName, NetworkLabel)
+			return
+		}
+
+		fullNames := make([]string, 0, len(extraNames)+1)
+		fullNames = append(fullNames, extraNames...)
+		fullNames = append(fullNames, NetworkLabel)
+
+		clock := db.Clock
+		if clock == nil {
+			clock = f.Clock()
+		}
+
+		di.now = clock.Now
+
+		var metricErr error
+
+		touchstone.ApplyDefaults(&db.Count, defaultClientDialCount)
+		di.count, metricErr = newCounterVec(f, db.Count, fullNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		di.duration, metricErr = db.newDuration(f, fullNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		touchstone.ApplyDefaults(&db.ErrorCount, defaultClientDialErrorCount)
+		di.errorCount, metricErr = newCounterVec(f, db.ErrorCount, fullNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		di.lastError, metricErr = newLastError(f, db.LastError, db.ErrorCount, extraNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		return
+	}
+}
+
+// DialInstrumenter is a middleware for DialContext functions that records
+// dial metrics.
+type DialInstrumenter struct {
+	count      *prometheus.CounterVec
+	duration   prometheus.ObserverVec
+	errorCount *prometheus.CounterVec
+	lastError  *touchstone.LastError
+
+	now func() time.Time
+}
+
+// Then decorates a DialContext with metrics.  If next is nil, the DialContext
+// method of a zero value net.Dialer is used.
+//
+// The result can be used directly with an http.Transport:
+//
+//	transport := &http.Transport{
+//	  DialContext: di.Then((&net.Dialer{Timeout: 5 * time.Second}).DialContext),
+//	}
+func (di DialInstrumenter) Then(next DialContext) DialContext {
+	if next == nil {
+		next = new(net.Dialer).DialContext
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		start := di.now()
+		conn, err := next(ctx, network, address)
+
+		// the vectors are curried with any extra labels, leaving only the network
+		di.count.WithLabelValues(network).Inc()
+		di.duration.WithLabelValues(network).Observe(
+			float64(di.now().Sub(start)) / float64(time.Millisecond),
+		)
+
+		if err != nil {
+			di.errorCount.WithLabelValues(network).Inc()
+			if di.lastError != nil {
+				di.lastError.Set(network, err)
+			}
+		}
+
+		return conn, err
+	}
+}
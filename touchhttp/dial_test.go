@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type DialerBundleSuite struct {
+	BundleSuite
+}
+
+func (suite *DialerBundleSuite) TestThen() {
+	di, err := DialerBundle{
+		Clock: suite.clock(10 * time.Millisecond),
+	}.NewInstrumenter(ClientLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	c := &http.Client{
+		Transport: &http.Transport{
+			DialContext: di.Then(nil),
+		},
+	}
+
+	response, err := c.Get(server.URL)
+	suite.Require().NoError(err)
+	response.Body.Close()
+
+	suite.Equal(1.0, testutil.ToFloat64(di.count.WithLabelValues("tcp")))
+	suite.Zero(testutil.ToFloat64(di.errorCount.WithLabelValues("tcp")))
+	suite.Equal(1, testutil.CollectAndCount(di.duration))
+
+	expectedErr := errors.New("expected")
+	dial := di.Then(func(context.Context, string, string) (net.Conn, error) {
+		return nil, expectedErr
+	})
+
+	conn, err := dial(context.Background(), "tcp4", "localhost:1234")
+	suite.Nil(conn)
+	suite.ErrorIs(err, expectedErr)
+	suite.Equal(1.0, testutil.ToFloat64(di.count.WithLabelValues("tcp4")))
+	suite.Equal(1.0, testutil.ToFloat64(di.errorCount.WithLabelValues("tcp4")))
+}
+
+func (suite *DialerBundleSuite) TestNewInstrumenter() {
+	suite.Run("Summary", func() {
+		di, err := DialerBundle{
+			Duration: prometheus.SummaryOpts{Name: "custom_dial"},
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.Require().NoError(err)
+		suite.IsType((*prometheus.SummaryVec)(nil), di.duration)
+	})
+
+	suite.Run("InvalidDuration", func() {
+		_, err := DialerBundle{
+			Duration: prometheus.GaugeOpts{},
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.Error(err)
+	})
+
+	suite.Run("ReservedLabel", func() {
+		_, err := DialerBundle{}.NewInstrumenter(NetworkLabel, "tcp")(suite.newFactory())
+		suite.ErrorIs(err, ErrReservedLabelName)
+	})
+
+	suite.Run("InvalidLabelCount", func() {
+		_, err := DialerBundle{}.NewInstrumenter(ClientLabel)(suite.newFactory())
+		suite.ErrorIs(err, ErrInvalidLabelCount)
+	})
+}
+
+func TestDialerBundle(t *testing.T) {
+	suite.Run(t, new(DialerBundleSuite))
+}
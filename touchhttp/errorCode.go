@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+)
+
+const (
+	// StatusNoResponse is the code recorded for a client transaction that received
+	// no response, when no ErrorCoder is configured or the ErrorCoder does not
+	// recognize the error.
+	StatusNoResponse = -1
+
+	// StatusClientTimeout is the synthetic code recorded by TransportErrorCoder
+	// when a client transaction timed out before a response was received.  This
+	// follows the common convention of proxies such as nginx.
+	StatusClientTimeout = 599
+
+	// StatusConnectionRefused is the synthetic code recorded by TransportErrorCoder
+	// when the server refused the client's connection.
+	StatusConnectionRefused = 598
+)
+
+// ErrorCoder maps the error from a client transaction that produced no response
+// onto a status code.  Returning StatusNoResponse indicates that the error has no
+// more specific code.
+//
+// Synthetic codes should be in the 5xx range, so that PromQL expressions that
+// compute error rates from the CodeLabel treat clients and servers consistently.
+type ErrorCoder func(error) int
+
+// TransportErrorCoder is an ErrorCoder that maps timeouts onto StatusClientTimeout
+// and refused connections onto StatusConnectionRefused.  Any other error results
+// in StatusNoResponse.
+func TransportErrorCoder(err error) int {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return StatusClientTimeout
+
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return StatusClientTimeout
+
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return StatusConnectionRefused
+
+	default:
+		return StatusNoResponse
+	}
+}
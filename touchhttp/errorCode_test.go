@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// timeoutError is a net.Error that reports a timeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestTransportErrorCoder(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected int
+	}{
+		{
+			err:      errors.New("unrecognized"),
+			expected: StatusNoResponse,
+		},
+		{
+			err:      context.DeadlineExceeded,
+			expected: StatusClientTimeout,
+		},
+		{
+			err:      &url.Error{Op: "Get", URL: "http://example.com", Err: timeoutError{}},
+			expected: StatusClientTimeout,
+		},
+		{
+			err: &url.Error{
+				Op:  "Get",
+				URL: "http://example.com",
+				Err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			},
+			expected: StatusConnectionRefused,
+		},
+		{
+			err:      context.Canceled,
+			expected: StatusNoResponse,
+		},
+	}
+
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			assert.Equal(t, testCase.expected, TransportErrorCoder(testCase.err))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// ExemplarExtractor produces the exemplar labels for an HTTP transaction, e.g. a trace ID
+// taken from the request's context.  A nil or empty result means the transaction has no
+// exemplar.
+//
+// Exemplars are attached to the request counter and duration observer, including those
+// of any Migration.  Summaries do not support exemplars, so a summary duration is
+// observed without one.  Exemplars only appear in the OpenMetrics exposition format.
+type ExemplarExtractor func(*http.Request) prometheus.Labels
+
+// checkExemplar verifies that the given labels are accepted by the prometheus client,
+// which panics when given an invalid exemplar.
+func checkExemplar(l prometheus.Labels) error {
+	var runes int
+	for name, value := range l {
+		if !model.LabelName(name).IsValid() || strings.HasPrefix(name, model.ReservedLabelPrefix) {
+			return fmt.Errorf("exemplar label name %q is invalid", name)
+		}
+
+		if !utf8.ValidString(value) {
+			return fmt.Errorf("exemplar label value %q is not valid UTF-8", value)
+		}
+
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+	}
+
+	if runes > prometheus.ExemplarMaxRunes {
+		return fmt.Errorf("exemplar labels have %d runes, exceeding the limit of %d", runes, prometheus.ExemplarMaxRunes)
+	}
+
+	return nil
+}
+
+// exemplar extracts the exemplar for a transaction.  An exemplar that cannot be used is
+// recorded as a write error against the request counter, and the transaction proceeds
+// without one.
+func (i instrumenter) exemplar(r *http.Request) prometheus.Labels {
+	if i.exemplars == nil {
+		return nil
+	}
+
+	l := i.exemplars(r)
+	if len(l) == 0 {
+		return nil
+	}
+
+	if err := checkExemplar(l); err != nil {
+		i.writeErrors.Record(i.count, err)
+		return nil
+	}
+
+	return l
+}
+
+// incWithExemplar increments a counter, attaching the exemplar if there is one
+// and the counter supports exemplars.
+func incWithExemplar(c prometheus.Counter, e prometheus.Labels) {
+	if ea, ok := c.(prometheus.ExemplarAdder); ok && len(e) > 0 {
+		ea.AddWithExemplar(1.0, e)
+	} else {
+		c.Inc()
+	}
+}
+
+// observeWithExemplar observes a value, attaching the exemplar if there is one
+// and the observer supports exemplars.
+func observeWithExemplar(o prometheus.Observer, v float64, e prometheus.Labels) {
+	if eo, ok := o.(prometheus.ExemplarObserver); ok && len(e) > 0 {
+		eo.ObserveWithExemplar(v, e)
+	} else {
+		o.Observe(v)
+	}
+}
@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/httpaux/client"
+	"github.com/xmidt-org/touchstone"
+)
+
+type ExemplarSuite struct {
+	BundleSuite
+}
+
+// traceID is an ExemplarExtractor that uses a request header.
+func traceID(r *http.Request) prometheus.Labels {
+	if id := r.Header.Get("X-Trace-Id"); len(id) > 0 {
+		return prometheus.Labels{"trace_id": id}
+	}
+
+	return nil
+}
+
+// write returns the current state of a child metric.
+func (suite *ExemplarSuite) write(m interface{}) *dto.Metric {
+	var metric dto.Metric
+	suite.Require().NoError(m.(prometheus.Metric).Write(&metric))
+	return &metric
+}
+
+// bucketExemplar returns the exemplar of the first histogram bucket that has one.
+func (suite *ExemplarSuite) bucketExemplar(m *dto.Metric) *dto.Exemplar {
+	for _, b := range m.GetHistogram().GetBucket() {
+		if b.Exemplar != nil {
+			return b.Exemplar
+		}
+	}
+
+	return nil
+}
+
+func (suite *ExemplarSuite) exemplarLabels(e *dto.Exemplar) map[string]string {
+	suite.Require().NotNil(e)
+	labels := make(map[string]string)
+	for _, lp := range e.GetLabel() {
+		labels[lp.GetName()] = lp.GetValue()
+	}
+
+	return labels
+}
+
+func (suite *ExemplarSuite) TestCheckExemplar() {
+	suite.NoError(checkExemplar(nil))
+	suite.NoError(checkExemplar(prometheus.Labels{"trace_id": "abc"}))
+	suite.Error(checkExemplar(prometheus.Labels{"__trace_id": "abc"}))
+	suite.Error(checkExemplar(prometheus.Labels{"trace_id": "\xff"}))
+	suite.Error(checkExemplar(prometheus.Labels{"trace_id": strings.Repeat("x", prometheus.ExemplarMaxRunes)}))
+}
+
+func (suite *ExemplarSuite) testServer(batch bool) {
+	si, err := ServerBundle{
+		ExemplarExtractor: traceID,
+		Migration:         &Migration{},
+		BatchUpdates:      batch,
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	h := si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("X-Trace-Id", "1234")
+	h.ServeHTTP(httptest.NewRecorder(), request)
+
+	l := prometheus.Labels{CodeLabel: "200", MethodLabel: http.MethodGet}
+	expected := map[string]string{"trace_id": "1234"}
+	suite.Equal(expected, suite.exemplarLabels(suite.write(si.count.With(l)).GetCounter().GetExemplar()))
+	suite.Equal(expected, suite.exemplarLabels(suite.bucketExemplar(suite.write(si.duration.With(l)))))
+	suite.Equal(expected, suite.exemplarLabels(suite.write(si.migration.count.With(l)).GetCounter().GetExemplar()))
+	suite.Equal(expected, suite.exemplarLabels(suite.bucketExemplar(suite.write(si.migration.duration.With(l)))))
+
+	// without a trace ID, the exemplar is unchanged
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	counter := suite.write(si.count.With(l)).GetCounter()
+	suite.Equal(2.0, counter.GetValue())
+	suite.Equal(expected, suite.exemplarLabels(counter.GetExemplar()))
+}
+
+func (suite *ExemplarSuite) TestServer() {
+	suite.Run("Unbatched", func() {
+		suite.testServer(false)
+	})
+
+	suite.Run("Batched", func() {
+		suite.testServer(true)
+	})
+}
+
+func (suite *ExemplarSuite) TestSummary() {
+	si, err := ServerBundle{
+		ExemplarExtractor: traceID,
+		Duration:          prometheus.SummaryOpts{},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	h := si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("X-Trace-Id", "1234")
+	suite.NotPanics(func() {
+		h.ServeHTTP(httptest.NewRecorder(), request)
+	})
+
+	l := prometheus.Labels{CodeLabel: "200", MethodLabel: http.MethodGet}
+	suite.Equal(uint64(1), suite.write(si.duration.With(l)).GetSummary().GetSampleCount())
+	suite.NotNil(suite.write(si.count.With(l)).GetCounter().GetExemplar())
+}
+
+func (suite *ExemplarSuite) TestInvalid() {
+	var failed []string
+	si, err := ServerBundle{
+		ExemplarExtractor: traceID,
+		WriteErrorHooks: []touchstone.WriteErrorHook{
+			func(metric string, err error) {
+				suite.Error(err)
+				failed = append(failed, metric)
+			},
+		},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	h := si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("X-Trace-Id", strings.Repeat("x", prometheus.ExemplarMaxRunes))
+	suite.NotPanics(func() {
+		h.ServeHTTP(httptest.NewRecorder(), request)
+	})
+
+	suite.Equal([]string{DefaultServerCount}, failed)
+
+	counter := suite.write(si.count.With(prometheus.Labels{CodeLabel: "200", MethodLabel: http.MethodGet})).GetCounter()
+	suite.Equal(1.0, counter.GetValue())
+	suite.Nil(counter.GetExemplar())
+}
+
+func (suite *ExemplarSuite) TestClient() {
+	ci, err := ClientBundle{
+		ExemplarExtractor: traceID,
+	}.NewInstrumenter(ClientLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	c := ci.Then(client.Func(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("X-Trace-Id", "5678")
+	_, err = c.Do(request)
+	suite.Require().NoError(err)
+
+	l := prometheus.Labels{CodeLabel: "200", MethodLabel: http.MethodGet}
+	expected := map[string]string{"trace_id": "5678"}
+	suite.Equal(expected, suite.exemplarLabels(suite.write(ci.count.With(l)).GetCounter().GetExemplar()))
+	suite.Equal(expected, suite.exemplarLabels(suite.bucketExemplar(suite.write(ci.duration.With(l)))))
+}
+
+func TestExemplar(t *testing.T) {
+	suite.Run(t, new(ExemplarSuite))
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+)
+
+// HealthHandler is a type alias for http.Handler that reports whether metrics can be
+// gathered.  Like Handler, this type allows injection by type without interfering with
+// other http.Handler components.  It is meant to be mounted on a liveness or health route.
+type HealthHandler http.Handler
+
+// NewHealthHandler returns an http.Handler that checks g with touchstone.CheckGatherer
+// on each request.  The check is bounded by the given timeout, or by
+// touchstone.DefaultGatherTimeout if the timeout is not positive, as well as by the
+// request's context.
+//
+// If the check passes, this handler responds with a 200 status.  Otherwise, it responds
+// with a 503 status and the error as the body.
+func NewHealthHandler(g prometheus.Gatherer, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		timeout = touchstone.DefaultGatherTimeout
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		if err := touchstone.CheckGatherer(ctx, g); err != nil {
+			http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rw.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(rw, "OK\n")
+	})
+}
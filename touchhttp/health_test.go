@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthSuite struct {
+	suite.Suite
+}
+
+func (suite *HealthSuite) TestHealthy() {
+	response := httptest.NewRecorder()
+	NewHealthHandler(prometheus.NewPedanticRegistry(), 0).ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Equal(http.StatusOK, response.Code)
+	suite.Equal("OK\n", response.Body.String())
+}
+
+func (suite *HealthSuite) TestError() {
+	g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return nil, errors.New("expected")
+	})
+
+	response := httptest.NewRecorder()
+	NewHealthHandler(g, time.Second).ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Equal(http.StatusServiceUnavailable, response.Code)
+	suite.Contains(response.Body.String(), "expected")
+}
+
+func (suite *HealthSuite) TestTimeout() {
+	release := make(chan struct{})
+	defer close(release)
+	g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		<-release
+		return nil, nil
+	})
+
+	response := httptest.NewRecorder()
+	NewHealthHandler(g, 10*time.Millisecond).ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Equal(http.StatusServiceUnavailable, response.Code)
+}
+
+func TestHealth(t *testing.T) {
+	suite.Run(t, new(HealthSuite))
+}
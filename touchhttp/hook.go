@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import "time"
+
+// Observation describes a completed HTTP transaction, as seen by an instrumenter.
+type Observation struct {
+	// Code is the HTTP status code of the response.  For clients, this will be
+	// StatusNoResponse if no response was received, unless an ErrorCoder supplied
+	// a synthetic code.
+	Code int
+
+	// Method is the HTTP method of the request.
+	Method string
+
+	// Duration is the elapsed time of the transaction.
+	Duration time.Duration
+
+	// RequestSize is the content length of the request, which can be negative
+	// if unknown.
+	RequestSize int64
+
+	// ResponseSize is the number of bytes a handler wrote to the response body, which is
+	// zero if it wrote nothing.  This field is always zero for clients.
+	ResponseSize int64
+
+	// Err is any error returned by a client.  This field is always nil for servers.
+	Err error
+
+	// Protocol is the HTTP protocol version of a client's response, e.g. HTTP/1.1.
+	// This field is empty for servers and when no response was received.
+	Protocol string
+
+	// Redirects is the number of redirects a client followed.  For an instrumented
+	// http.RoundTripper, where each hop is its own transaction, this is 1 for a hop
+	// that was produced by a redirect.  This field is always zero for servers.
+	Redirects int
+
+	// Attempt is the 0-based attempt of a client transaction made by an enclosing
+	// httpaux retry.Client, so that a positive value indicates a retry.  This field is
+	// always zero for servers and for clients without retries.
+	Attempt int
+
+	// Tenant is the partitioned tenant of the transaction, which is either an allowed
+	// tenant or TenantOther.  This field is empty if the bundle has no Tenancy.
+	Tenant string
+
+	// Phases are the named phases recorded by handlers through the ServerTransaction.
+	// This field is always nil for clients.
+	Phases []Phase
+}
+
+// Hook is a callback invoked by an instrumenter after each HTTP transaction
+// has been recorded.  Hooks allow derived metrics, e.g. SLO event counters, to
+// be fed by the same instrumentation as the standard HTTP metrics.
+//
+// Hooks are invoked synchronously on the goroutine that handled the transaction,
+// and so must be safe for concurrent use and should not block.
+type Hook func(Observation)
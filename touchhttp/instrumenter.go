@@ -11,6 +11,7 @@ import (
 	"github.com/xmidt-org/httpaux"
 	"github.com/xmidt-org/httpaux/client"
 	"github.com/xmidt-org/httpaux/observe"
+	"github.com/xmidt-org/httpaux/roundtrip"
 	"github.com/xmidt-org/touchstone"
 	"go.uber.org/fx"
 )
@@ -22,6 +23,20 @@ type transaction struct {
 	method      string
 	err         error // that came from a client
 	requestSize int64
+	protocol    string             // only set for clients
+	redirects   int                // only set for clients
+	attempt     int                // only set for clients
+	tenant      string             // only set when there is a tenancy
+	body        *countingBody      // only set when server body reads are timed
+	server      *ServerTransaction // only set for servers
+	exemplar    prometheus.Labels  // only set when there is an ExemplarExtractor
+
+	// only set for servers with a Connection, in the same order as the instrumenter's labels
+	connection [maxConnectionLabels]string
+
+	// only set for servers with Responses
+	responseSize int64
+	headers      *headerCounter
 }
 
 // instrumenter is the common logic that decorates HTTP transactions for
@@ -32,8 +47,53 @@ type instrumenter struct {
 	requestSize prometheus.ObserverVec
 	duration    prometheus.ObserverVec
 
+	// optional tenant partitioning
+	tenancy *tenancy
+
+	// optional migration of count and duration to new metric families
+	migration *migration
+
+	// optional source of exemplars for the count and duration
+	exemplars ExemplarExtractor
+
+	// optional cache of the children of the core metrics, shared by copies of this instrumenter
+	batcher *batcher
+
+	// optional sampled, full resolution durations
+	sampledDuration prometheus.ObserverVec
+	sample          func() bool
+
+	// only used in servers
+	queueTime             *queueTime
+	bodyReadDuration      prometheus.ObserverVec
+	responseSize          prometheus.ObserverVec
+	superfluousWriteCount *prometheus.CounterVec
+	phaseDuration         prometheus.ObserverVec
+	phases                phaseSet
+	connection            []connectionLabel
+	curry                 prometheus.Labels
+	transactions          bool
+
 	// only used in clients
-	errorCount *prometheus.CounterVec
+	errorCount    *prometheus.CounterVec
+	lastError     *touchstone.LastError
+	protocolCount *prometheus.CounterVec
+	redirectCount *prometheus.CounterVec
+	retryCount    *prometheus.CounterVec
+	rejectedCount *prometheus.CounterVec
+	errorCoder    ErrorCoder
+	countBodies   bool
+
+	// the optional replacement of status codes before they are recorded
+	codeMapper CodeMapper
+
+	// the optional set of methods recorded as is
+	methods methodSet
+
+	// records children that could not be obtained from the vectors
+	writeErrors *touchstone.WriteErrors
+
+	hooks []Hook
 
 	now func() time.Time
 }
@@ -41,23 +101,71 @@ type instrumenter struct {
 // begin records the start of an HTTP transaction
 func (i instrumenter) begin(r *http.Request) transaction {
 	i.inFlight.Inc()
-	return transaction{
+	t := transaction{
 		start:       i.now(),
 		method:      r.Method,
 		requestSize: r.ContentLength,
 	}
+
+	if i.tenancy != nil {
+		t.tenant = i.tenancy.tenant(r)
+	}
+
+	t.exemplar = i.exemplar(r)
+
+	for j, l := range i.connection {
+		t.connection[j] = l.format(r)
+	}
+
+	if i.queueTime != nil {
+		i.queueTime.observe(r, t.start)
+	}
+
+	return t
 }
 
-func (i instrumenter) endHandle(sc observe.StatusCoder, t transaction) {
-	t.code = sc.StatusCode()
+// endHandle records the end of a server transaction.  A handler that returns without
+// writing anything produces a 200 response, just as net/http does.  A handler that
+// panicked before writing is recorded with a code of zero, as net/http sends no response.
+func (i instrumenter) endHandle(w observe.Writer, t transaction, completed bool) {
+	t.code = w.StatusCode()
+	if t.code == 0 && completed {
+		t.code = http.StatusOK
+	}
+
+	t.responseSize = w.ContentLength()
 	i.end(t)
 }
 
-func (i instrumenter) endDo(response *http.Response, err error, t transaction) {
+// redirects counts the number of redirects that were followed to produce a response.
+// Each redirected request carries the response that caused it.
+func redirects(response *http.Response) (n int) {
+	for r := response.Request; r != nil && r.Response != nil; r = r.Response.Request {
+		n++
+	}
+
+	return
+}
+
+// hopRedirects counts the redirect that led to a single round trip, if any.  Since each
+// hop of a redirect is its own round trip, this counts each redirect exactly once.
+func hopRedirects(response *http.Response) int {
+	if r := response.Request; r != nil && r.Response != nil {
+		return 1
+	}
+
+	return 0
+}
+
+func (i instrumenter) endDo(response *http.Response, err error, t transaction, countRedirects func(*http.Response) int) {
 	if response != nil {
 		t.code = response.StatusCode
+		t.protocol = response.Proto
+		t.redirects = countRedirects(response)
+	} else if i.errorCoder != nil {
+		t.code = i.errorCoder(err)
 	} else {
-		t.code = -1
+		t.code = StatusNoResponse
 	}
 
 	t.err = err
@@ -67,24 +175,175 @@ func (i instrumenter) endDo(response *http.Response, err error, t transaction) {
 // end records the end of an HTTP transaction
 func (i instrumenter) end(t transaction) {
 	i.inFlight.Dec()
+	if i.codeMapper != nil {
+		t.code = i.codeMapper(t.code)
+	}
 
-	l := prometheus.Labels(NewLabels(t.code, t.method))
+	pooled := AcquireLabels()
+	defer ReleaseLabels(pooled)
+	i.setLabels(pooled, t)
+	l := prometheus.Labels(pooled)
 
-	i.count.With(l).Inc()
 	elapsed := i.now().Sub(t.start)
-	i.duration.With(l).Observe(
-		float64(elapsed / time.Millisecond),
-	)
+	legacy := i.migration == nil || i.migration.legacy(t.start.Add(elapsed))
+	b := i.batchFor(t, l, legacy)
+	b.observe(t, elapsed, legacy)
 
-	i.requestSize.With(l).Observe(
-		float64(t.requestSize),
-	)
+	i.observeOptional(t, l, elapsed)
+	phases := i.observePhases(t, pooled)
+	i.observeClient(t, l)
+	i.runHooks(t, elapsed, phases)
+}
+
+// setLabels sets the labels of the core metrics for a completed transaction.
+func (i instrumenter) setLabels(l Labels, t transaction) {
+	l.SetCode(t.code)
+	l.set(MethodLabel, i.methods.format(t.method))
+	if i.tenancy != nil {
+		l.SetTenant(t.tenant)
+	}
+
+	for j, cl := range i.connection {
+		l.set(cl.name, t.connection[j])
+	}
+}
+
+// observeOptional records the optional metrics that share the labels of the core metrics:
+// the sampled duration, the body read duration, and superfluous calls to WriteHeader.
+func (i instrumenter) observeOptional(t transaction, l prometheus.Labels, elapsed time.Duration) {
+	if i.sampledDuration != nil && i.sample() {
+		i.writeErrors.Observer(i.sampledDuration, l).Observe(
+			float64(elapsed) / float64(time.Millisecond),
+		)
+	}
+
+	if i.bodyReadDuration != nil && t.body != nil {
+		i.writeErrors.Observer(i.bodyReadDuration, l).Observe(
+			float64(t.body.duration()) / float64(time.Millisecond),
+		)
+	}
+
+	if i.superfluousWriteCount != nil && t.headers != nil && t.headers.superfluous() {
+		i.writeErrors.Counter(i.superfluousWriteCount, l).Inc()
+	}
+}
+
+// observePhases completes the phases of a server transaction, recording them if this
+// instrumenter has a phase duration.  The phase label is added to the given labels for
+// each phase, and removed afterward.  The completed phases are returned.
+func (i instrumenter) observePhases(t transaction, l Labels) (phases []Phase) {
+	if t.server != nil {
+		phases = t.server.complete()
+	}
+
+	if i.phaseDuration != nil && len(phases) > 0 {
+		for _, p := range phases {
+			l.set(PhaseLabel, i.phases.format(p.Name))
+			i.writeErrors.Observer(i.phaseDuration, prometheus.Labels(l)).Observe(
+				float64(p.Duration) / float64(time.Millisecond),
+			)
+		}
+
+		delete(l, PhaseLabel)
+	}
 
+	return
+}
+
+// observeClient records the metrics that only clients have:  errors, the last error,
+// and the protocol, redirect, and retry counters.
+func (i instrumenter) observeClient(t transaction, l prometheus.Labels) {
 	if i.errorCount != nil && t.err != nil {
-		i.errorCount.With(l).Inc()
+		i.writeErrors.Counter(i.errorCount, l).Inc()
+	}
+
+	if i.lastError != nil && t.err != nil {
+		i.lastError.Set(formatCode(t.code), t.err)
+	}
+
+	if i.protocolCount != nil && len(t.protocol) > 0 {
+		// the vector is curried with any extra labels, leaving only the protocol
+		i.writeErrors.CounterWithLabelValues(i.protocolCount, t.protocol).Inc()
+	}
+
+	if i.redirectCount != nil && t.redirects > 0 {
+		i.writeErrors.CounterWithLabelValues(i.redirectCount, i.methods.format(t.method)).Add(float64(t.redirects))
+	}
+
+	if i.retryCount != nil && t.attempt > 0 {
+		i.writeErrors.CounterWithLabelValues(i.retryCount, i.methods.format(t.method)).Inc()
+	}
+}
+
+// runHooks invokes this instrumenter's hooks with the observation of a completed transaction.
+func (i instrumenter) runHooks(t transaction, elapsed time.Duration, phases []Phase) {
+	if len(i.hooks) == 0 {
+		return
+	}
+
+	o := Observation{
+		Code:         t.code,
+		Method:       t.method,
+		Duration:     elapsed,
+		RequestSize:  t.requestSize,
+		ResponseSize: t.responseSize,
+		Err:          t.err,
+		Protocol:     t.protocol,
+		Redirects:    t.redirects,
+		Attempt:      t.attempt,
+		Tenant:       t.tenant,
+		Phases:       phases,
+	}
+
+	for _, h := range i.hooks {
+		h(o)
 	}
 }
 
+// DeleteWhere deletes every series of this instrumenter's metrics whose labels include
+// all of the partial labels, returning the number of series deleted.  As with
+// touchstone.DeleteWhere, an empty partial deletes nothing.  Only the series of this
+// instrumenter's extra labels are deleted, even when its metrics are shared with other
+// instrumenters, so the partial labels should not include those extra labels.
+//
+// When the bundle enables BatchUpdates, series must be deleted with this method rather
+// than directly from the metrics, so that the cached children of deleted series are replaced.
+func (i instrumenter) DeleteWhere(partial prometheus.Labels) int {
+	deleted := touchstone.DeleteAllWhere(partial, i.deleters()...)
+	if i.batcher != nil {
+		i.batcher.invalidate()
+	}
+
+	return deleted
+}
+
+// deleters returns the metrics of this instrumenter that are labeled with the
+// labels of each transaction.
+func (i instrumenter) deleters() []touchstone.PartialDeleter {
+	vecs := []interface{}{i.count, i.duration, i.requestSize, i.sampledDuration, i.bodyReadDuration, i.responseSize, i.phaseDuration}
+	if i.migration != nil {
+		vecs = append(vecs, i.migration.count, i.migration.duration)
+	}
+
+	if i.superfluousWriteCount != nil {
+		vecs = append(vecs, i.superfluousWriteCount)
+	}
+
+	if i.errorCount != nil {
+		vecs = append(vecs, i.errorCount)
+	}
+
+	deleters := make([]touchstone.PartialDeleter, 0, len(vecs))
+	for _, v := range vecs {
+		// the optional observers are nil interfaces when unused
+		if d, ok := v.(touchstone.PartialDeleter); ok {
+			deleters = append(deleters, d)
+		}
+	}
+
+	return deleters
+}
+
 // ServerInstrumenter is a serverside middleware that provides http.Handler
 // metrics.
 type ServerInstrumenter struct {
@@ -93,16 +352,50 @@ type ServerInstrumenter struct {
 
 // Then is a server middleware that instruments the given handler.  This middleware
 // is compatible with justinas/alice and gorilla/mux.
+//
+// If the bundle enables Transactions, Phases, or Hooks, the request passed to the next
+// handler carries a ServerTransaction in its context.  See FromContext.
 func (si ServerInstrumenter) Then(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		w := observe.New(rw)
 		t := si.begin(r)
-		defer si.endHandle(w, t)
+		if si.transactions {
+			t.server = si.newServerTransaction(t)
+			r = r.WithContext(withServerTransaction(r.Context(), t.server))
+		}
+
+		if si.superfluousWriteCount != nil {
+			rw, t.headers = countHeaders(rw)
+		}
+
+		w := observe.New(rw)
+		if si.bodyReadDuration != nil {
+			r, t.body = countBody(r, si.now)
+		}
+
+		completed := false
+		defer func() {
+			si.endHandle(w, t, completed)
+		}()
 
 		next.ServeHTTP(w, r)
+		completed = true
 	})
 }
 
+// newServerTransaction creates the handle for a transaction that has begun.
+func (si ServerInstrumenter) newServerTransaction(t transaction) *ServerTransaction {
+	return &ServerTransaction{
+		start:      t.start,
+		now:        si.now,
+		curry:      si.curry,
+		method:     si.methods.format(t.method),
+		tenancy:    si.tenancy != nil,
+		tenant:     t.tenant,
+		connection: si.connection,
+		connValues: t.connection,
+	}
+}
+
 // ClientInstrumenter is a clientside middleware that provides HTTP client
 // metrics.
 type ClientInstrumenter struct {
@@ -111,23 +404,58 @@ type ClientInstrumenter struct {
 
 // Then is a client middleware that instruments the given client.  This middleware
 // is compatible with httpaux.
+//
+// When enclosed by an httpaux retry.Client, each attempt is recorded as its own
+// transaction, and retries are counted in the bundle's RetryCount, if any.
 func (ci ClientInstrumenter) Then(next httpaux.Client) httpaux.Client {
+	return ci.then(next, redirects)
+}
+
+// then instruments a client, using countRedirects to determine how many redirects
+// each transaction followed.
+func (ci ClientInstrumenter) then(next httpaux.Client, countRedirects func(*http.Response) int) httpaux.Client {
 	return client.Func(func(request *http.Request) (response *http.Response, err error) {
 		t := ci.begin(request)
+		t.attempt = attempt(request.Context())
+		var body *countingBody
+		if ci.countBodies {
+			request, body = countBody(request, nil)
+		}
+
 		response, err = next.Do(request)
-		ci.endDo(response, err, t)
+		if body != nil {
+			t.requestSize = body.count()
+		}
+
+		ci.endDo(response, err, t, countRedirects)
 		return
 	})
 }
 
 var _ client.Constructor = ClientInstrumenter{}.Then
 
+// ThenRoundTripper is a client middleware that instruments the given http.RoundTripper,
+// e.g. an *http.Transport.  Each round trip is recorded as its own transaction, so a
+// request that follows redirects is recorded once for each hop, and each hop counts
+// at most the one redirect that led to it.  This middleware is
+// compatible with httpaux roundtrip.Chain, and the returned http.RoundTripper supports
+// CloseIdleConnections if next does.
+func (ci ClientInstrumenter) ThenRoundTripper(next http.RoundTripper) http.RoundTripper {
+	c := ci.then(client.Func(next.RoundTrip), hopRedirects)
+	return roundtrip.PreserveCloseIdler(
+		next,
+		roundtrip.Func(c.Do),
+	)
+}
+
+var _ roundtrip.Constructor = ClientInstrumenter{}.ThenRoundTripper
+
 // ServerInstrumenterIn defines the set of dependencies required to build a ServerInstrumenter.
 type ServerInstrumenterIn struct {
 	fx.In
 
-	// Factory is the required touchstone Factory instance.
-	Factory *touchstone.Factory
+	// Factory is the required touchstone MetricFactory instance.
+	Factory touchstone.MetricFactory
 
 	// Bundle is the optional ServerBundle supplied in the application.
 	// If not present, the default metrics are used.
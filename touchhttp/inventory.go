@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+)
+
+// InventoryHandler is a type alias for http.Handler that serves the inventory of
+// registered metric families.  Like Handler, this type allows injection by type
+// without interfering with other http.Handler components.  It is meant to be
+// mounted on an internal route separate from the metrics endpoint.
+type InventoryHandler http.Handler
+
+// NewInventoryHandler returns an http.Handler that serves the JSON inventory of the
+// metric families gathered from g.  See touchstone.WriteInventory.
+//
+// If gathering fails, this handler responds with a 500 status.
+func NewInventoryHandler(g prometheus.Gatherer) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		var body bytes.Buffer
+		if err := touchstone.WriteInventory(&body, g); err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", JSONContentType)
+		rw.WriteHeader(http.StatusOK)
+		_, _ = body.WriteTo(rw)
+	})
+}
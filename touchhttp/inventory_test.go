@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+)
+
+type InventorySuite struct {
+	suite.Suite
+}
+
+func (suite *InventorySuite) TestNewInventoryHandler() {
+	r := prometheus.NewPedanticRegistry()
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_counter", Help: "test"}, []string{"code"})
+	c.WithLabelValues("200").Inc()
+	c.WithLabelValues("404").Inc()
+	suite.Require().NoError(r.Register(c))
+
+	response := httptest.NewRecorder()
+	NewInventoryHandler(r).ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Equal(http.StatusOK, response.Code)
+	suite.Equal(JSONContentType, response.Header().Get("Content-Type"))
+
+	var families []touchstone.InventoryFamily
+	suite.Require().NoError(json.Unmarshal(response.Body.Bytes(), &families))
+	suite.Equal(
+		[]touchstone.InventoryFamily{
+			{
+				Name:       "test_counter",
+				Help:       "test",
+				Type:       "counter",
+				LabelNames: []string{"code"},
+				Series:     2,
+			},
+		},
+		families,
+	)
+
+	suite.Run("Error", func() {
+		g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return nil, errors.New("expected")
+		})
+
+		response := httptest.NewRecorder()
+		NewInventoryHandler(g).ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+		suite.Equal(http.StatusInternalServerError, response.Code)
+	})
+}
+
+func TestInventory(t *testing.T) {
+	suite.Run(t, new(InventorySuite))
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+)
+
+// JSONContentType is the media type of the JSON form of metrics.
+const JSONContentType = "application/json"
+
+// NewJSONHandler returns an http.Handler that serves the JSON form of the metrics
+// gathered from g.  See touchstone.WriteJSON.
+//
+// If gathering fails, this handler responds with a 500 status.
+func NewJSONHandler(g prometheus.Gatherer) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		var body bytes.Buffer
+		if err := touchstone.WriteJSON(&body, g); err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", JSONContentType)
+		rw.WriteHeader(http.StatusOK)
+		_, _ = body.WriteTo(rw)
+	})
+}
+
+// prefersJSON examines the Accept header of a request to determine whether JSON
+// is listed before any of the prometheus exposition formats.  Wildcards never
+// select JSON, so that scrapers and browsers receive the usual text format.
+func prefersJSON(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(value, ",") {
+			mediaType, _, err := mime.ParseMediaType(mediaRange)
+			if err != nil {
+				continue
+			}
+
+			switch mediaType {
+			case JSONContentType:
+				return true
+
+			case "text/plain", "application/openmetrics-text", "application/vnd.google.protobuf":
+				return false
+			}
+		}
+	}
+
+	return false
+}
+
+// NegotiateJSON produces an http.Handler that serves requests preferring JSON with
+// the json handler and all other requests with next.  Typically, json is created
+// with NewJSONHandler and next is the promhttp handler.
+func NegotiateJSON(json, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if prefersJSON(r) {
+			json.ServeHTTP(rw, r)
+		} else {
+			next.ServeHTTP(rw, r)
+		}
+	})
+}
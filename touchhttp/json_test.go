@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+)
+
+type JSONSuite struct {
+	suite.Suite
+}
+
+func (suite *JSONSuite) TestPrefersJSON() {
+	testCases := []struct {
+		accept   []string
+		expected bool
+	}{
+		{accept: nil, expected: false},
+		{accept: []string{"*/*"}, expected: false},
+		{accept: []string{"application/json"}, expected: true},
+		{accept: []string{"application/json; charset=utf-8"}, expected: true},
+		{accept: []string{"text/html,application/json;q=0.9"}, expected: true},
+		{accept: []string{"text/plain;version=0.0.4,application/json"}, expected: false},
+		{accept: []string{"application/openmetrics-text;version=1.0.0,*/*;q=0.1"}, expected: false},
+		{accept: []string{"this is not valid", "application/json"}, expected: true},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run("", func() {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, v := range testCase.accept {
+				request.Header.Add("Accept", v)
+			}
+
+			suite.Equal(testCase.expected, prefersJSON(request))
+		})
+	}
+}
+
+func (suite *JSONSuite) TestNewJSONHandler() {
+	r := prometheus.NewPedanticRegistry()
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_counter", Help: "test"})
+	c.Add(3.0)
+	suite.Require().NoError(r.Register(c))
+
+	response := httptest.NewRecorder()
+	NewJSONHandler(r).ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Equal(http.StatusOK, response.Code)
+	suite.Equal(JSONContentType, response.Header().Get("Content-Type"))
+
+	var families []touchstone.JSONFamily
+	suite.Require().NoError(json.Unmarshal(response.Body.Bytes(), &families))
+	suite.Require().Len(families, 1)
+	suite.Equal("test_counter", families[0].Name)
+	suite.Equal("3", families[0].Metrics[0].Value)
+
+	suite.Run("Error", func() {
+		g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+			return nil, errors.New("expected")
+		})
+
+		response := httptest.NewRecorder()
+		NewJSONHandler(g).ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+		suite.Equal(http.StatusInternalServerError, response.Code)
+	})
+}
+
+func (suite *JSONSuite) TestNegotiateJSON() {
+	var (
+		json = http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			rw.WriteHeader(299)
+		})
+
+		next = http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			rw.WriteHeader(298)
+		})
+
+		h = NegotiateJSON(json, next)
+	)
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Accept", JSONContentType)
+	response := httptest.NewRecorder()
+	h.ServeHTTP(response, request)
+	suite.Equal(299, response.Code)
+
+	response = httptest.NewRecorder()
+	h.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Equal(298, response.Code)
+}
+
+func TestJSON(t *testing.T) {
+	suite.Run(t, new(JSONSuite))
+}
@@ -5,6 +5,8 @@ package touchhttp
 
 import (
 	"net/http"
+	"sync"
+	"sync/atomic"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -16,6 +18,14 @@ const (
 	// MethodLabel is the metric label containing the HTTP request's method.
 	MethodLabel = "method"
 
+	// ProtocolLabel is the metric label containing the HTTP protocol version of a response,
+	// e.g. HTTP/1.1.  This label is only used by the optional ClientBundle.ProtocolCount.
+	ProtocolLabel = "protocol"
+
+	// NetworkLabel is the metric label containing the network of a dialed connection,
+	// e.g. tcp or tcp4.  This label is only used by DialerBundle metrics.
+	NetworkLabel = "network"
+
 	// ServerLabel is the canonical metric label name containing the name of the HTTP server.
 	// This label is not automatically supplied.
 	ServerLabel = "server"
@@ -27,6 +37,10 @@ const (
 	// MethodUnrecognized is used when an HTTP method is not one of the
 	// standard methods, as enumerated in the net/http package.
 	MethodUnrecognized = "UNRECOGNIZED"
+
+	// MethodOther is used when a standard HTTP method is not one of the methods
+	// a bundle has been configured to record.  See ServerBundle.Methods.
+	MethodOther = "OTHER"
 )
 
 var (
@@ -47,30 +61,61 @@ var (
 	statusUnknown = "-1"
 	statusOK      = "200"
 
-	// preformattedCodes is used to avoid extra allocation overhead for
-	// common status codes
-	preformattedCodes = map[int]string{
-		200: statusOK,
-		201: "201",
-		202: "202",
-		204: "204",
-		400: "400",
-		401: "401",
-		403: "403",
-		404: "404",
-		405: "405",
-		429: "429",
-		500: "500",
-		502: "502",
-		503: "503",
-		504: "504",
+	// labelsPool holds Labels for reuse in hot paths.  Maps are pointer-shaped,
+	// so storing them in a pool doesn't allocate.
+	labelsPool = sync.Pool{
+		New: func() interface{} {
+			return make(Labels, 4)
+		},
 	}
+
+	// formattedCodes caches the string form of every valid status code, indexed by
+	// code - minCode.  Entries are created lazily the first time a code is formatted,
+	// and the common codes are created at startup.
+	formattedCodes [maxCode - minCode + 1]atomic.Pointer[string]
+)
+
+const (
+	// minCode and maxCode bound the status codes that are formatted.  Any
+	// other code is recorded as statusUnknown.
+	minCode = 100
+	maxCode = 599
 )
 
+func init() {
+	PreformatCodes(
+		200, 201, 202, 204,
+		400, 401, 403, 404, 405, 429,
+		500, 502, 503, 504,
+	)
+}
+
+// PreformatCodes ensures that the label values for the given status codes are
+// created up front.  Every valid code is cached the first time it is formatted,
+// so this function is only an optimization for services that know which
+// uncommon codes, e.g. 207 or 308, they will use.  Codes outside the range of
+// valid HTTP status codes are ignored.
+//
+// This function is safe for concurrent use.
+func PreformatCodes(codes ...int) {
+	for _, v := range codes {
+		if v >= minCode && v <= maxCode {
+			formatCode(v)
+		}
+	}
+}
+
+// FormatCode returns the CodeLabel value for the given status code.  A code of 0
+// is treated as 200, which is what an http.Handler that never calls WriteHeader
+// produces.  Any other code that is not a valid HTTP status code results in "-1".
+func FormatCode(v int) string {
+	return formatCode(v)
+}
+
 // formatCode is an efficient, zero-copy formatter for 3-digit HTTP response codes.
 // this function avoids the general stdlib in favor of an unwound loop specific to
-// 3-digit integers in the valid range of HTTP status codes.  It also uses preallocated
-// strings for common status codes.
+// 3-digit integers in the valid range of HTTP status codes.  Each formatted code
+// is cached, so that a given code is only formatted once.
 func formatCode(v int) string {
 	switch {
 	case v == 0:
@@ -78,25 +123,30 @@ func formatCode(v int) string {
 		// but a 200 is assumed
 		return statusOK
 
-	case v < 100:
+	case v < minCode:
 		return statusUnknown
 
-	case v > 599:
+	case v > maxCode:
 		return statusUnknown
 	}
 
-	if vv, ok := preformattedCodes[v]; ok {
-		return vv
+	entry := &formattedCodes[v-minCode]
+	if cached := entry.Load(); cached != nil {
+		return *cached
 	}
 
 	var code [3]byte
-	code[2] = '0' + byte(v%10)
-	v /= 10
-	code[1] = '0' + byte(v%10)
-	v /= 10
-	code[0] = '0' + byte(v%10)
+	n := v
+	code[2] = '0' + byte(n%10)
+	n /= 10
+	code[1] = '0' + byte(n%10)
+	n /= 10
+	code[0] = '0' + byte(n%10)
 
-	return string(code[:])
+	// concurrent formatting of the same code is harmless, as the results are equal
+	formatted := string(code[:])
+	entry.Store(&formatted)
+	return formatted
 }
 
 // formatMethod ensures that its argument is a valid HTTP method and
@@ -109,6 +159,44 @@ func formatMethod(v string) string {
 	return MethodUnrecognized
 }
 
+// methodSet is a configured set of HTTP methods that are recorded as is.
+// A nil methodSet records all the standard methods.
+type methodSet map[string]bool
+
+// newMethodSet creates a methodSet from a bundle's configuration.  If no
+// methods are supplied, this function returns nil.
+func newMethodSet(methods []string) methodSet {
+	if len(methods) == 0 {
+		return nil
+	}
+
+	ms := make(methodSet, len(methods))
+	for _, m := range methods {
+		ms[m] = true
+	}
+
+	return ms
+}
+
+// format returns the label value for the given method.  Methods in this set are
+// returned as is.  Any other standard method is collapsed into MethodOther, and
+// any nonstandard method into MethodUnrecognized.
+func (ms methodSet) format(v string) string {
+	switch {
+	case ms == nil:
+		return formatMethod(v)
+
+	case ms[v]:
+		return v
+
+	case recognizedMethods[v]:
+		return MethodOther
+
+	default:
+		return MethodUnrecognized
+	}
+}
+
 // Labels is a convenient extension for a prometheus.Labels that
 // adds support for the reserved and de facto labels in this package.
 //
@@ -136,6 +224,12 @@ func (l *Labels) SetMethod(v string) {
 	l.set(MethodLabel, formatMethod(v))
 }
 
+// SetTenant updates this set of Labels with the given tenant label value.
+// Use Tenants.Partition to bound the values of this label.
+func (l *Labels) SetTenant(v string) {
+	l.set(TenantLabel, v)
+}
+
 // SetServer updates this set of Labels with the given server name.
 func (l *Labels) SetServer(v string) {
 	l.set(ServerLabel, v)
@@ -153,3 +247,48 @@ func NewLabels(code int, method string) Labels {
 		MethodLabel: formatMethod(method),
 	}
 }
+
+// LabelCombinations produces Labels for every combination of the given status codes
+// and methods.  This is useful for preinitializing series in a ServerBundle or ClientBundle:
+//
+//	touchhttp.ServerBundle{
+//	  Preinitialize: touchhttp.LabelCombinations(
+//	    []int{200, 404, 500},
+//	    []string{http.MethodGet, http.MethodPost},
+//	  ),
+//	}
+func LabelCombinations(codes []int, methods []string) []Labels {
+	combinations := make([]Labels, 0, len(codes)*len(methods))
+	for _, code := range codes {
+		for _, method := range methods {
+			combinations = append(combinations, NewLabels(code, method))
+		}
+	}
+
+	return combinations
+}
+
+// AcquireLabels obtains an empty Labels from an internal pool.  Use this function
+// in hot paths, such as middleware, to avoid allocating a map for each request.
+//
+// The returned Labels must be passed to ReleaseLabels when no longer needed.  Callers
+// must not retain the Labels, or pass it to anything that retains it, after release.
+// Passing it to the With or GetMetricWith methods of a prometheus vector is safe,
+// as vectors do not retain the map.
+func AcquireLabels() Labels {
+	return labelsPool.Get().(Labels)
+}
+
+// ReleaseLabels clears the given Labels and returns it to the internal pool.
+// A nil Labels is ignored.
+func ReleaseLabels(l Labels) {
+	if l == nil {
+		return
+	}
+
+	for k := range l {
+		delete(l, k)
+	}
+
+	labelsPool.Put(l)
+}
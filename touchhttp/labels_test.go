@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+)
+
+type LabelsSuite struct {
+	suite.Suite
+}
+
+func (suite *LabelsSuite) TestNewLabels() {
+	suite.Equal(
+		Labels{CodeLabel: "200", MethodLabel: http.MethodGet},
+		NewLabels(0, http.MethodGet),
+	)
+
+	suite.Equal(
+		Labels{CodeLabel: "418", MethodLabel: MethodUnrecognized},
+		NewLabels(418, "BREW"),
+	)
+
+	suite.Equal(
+		Labels{CodeLabel: statusUnknown, MethodLabel: http.MethodPost},
+		NewLabels(-1, http.MethodPost),
+	)
+}
+
+func (suite *LabelsSuite) TestFormatCode() {
+	testCases := []struct {
+		code     int
+		expected string
+	}{
+		{code: 0, expected: "200"},
+		{code: 99, expected: statusUnknown},
+		{code: 100, expected: "100"},
+		{code: 207, expected: "207"},
+		{code: 308, expected: "308"},
+		{code: 418, expected: "418"},
+		{code: 599, expected: "599"},
+		{code: 600, expected: statusUnknown},
+		{code: -1, expected: statusUnknown},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(strconv.Itoa(testCase.code), func() {
+			// the second call exercises the cache
+			suite.Equal(testCase.expected, FormatCode(testCase.code))
+			suite.Equal(testCase.expected, FormatCode(testCase.code))
+		})
+	}
+}
+
+func (suite *LabelsSuite) TestPreformatCodes() {
+	PreformatCodes(-1, 99, 600, 226)
+	cached := formattedCodes[226-minCode].Load()
+	suite.Require().NotNil(cached)
+	suite.Equal("226", *cached)
+
+	// common codes are preformatted at startup
+	suite.NotNil(formattedCodes[http.StatusNotFound-minCode].Load())
+}
+
+func (suite *LabelsSuite) TestMethodSet() {
+	suite.Run("Default", func() {
+		ms := newMethodSet(nil)
+		suite.Nil(ms)
+		suite.Equal(http.MethodTrace, ms.format(http.MethodTrace))
+		suite.Equal(MethodUnrecognized, ms.format("PROPFIND"))
+	})
+
+	suite.Run("Custom", func() {
+		ms := newMethodSet([]string{http.MethodGet, http.MethodPost, "PROPFIND"})
+		suite.Equal(http.MethodGet, ms.format(http.MethodGet))
+		suite.Equal(http.MethodPost, ms.format(http.MethodPost))
+		suite.Equal("PROPFIND", ms.format("PROPFIND"))
+		suite.Equal(MethodOther, ms.format(http.MethodTrace))
+		suite.Equal(MethodOther, ms.format(http.MethodConnect))
+		suite.Equal(MethodUnrecognized, ms.format("MKCOL"))
+	})
+}
+
+func (suite *LabelsSuite) TestSetters() {
+	var l Labels
+	l.SetCode(http.StatusNotFound)
+	l.SetMethod(http.MethodPut)
+	l.SetServer("main")
+	l.SetClient("consul")
+
+	suite.Equal(
+		Labels{
+			CodeLabel:   "404",
+			MethodLabel: http.MethodPut,
+			ServerLabel: "main",
+			ClientLabel: "consul",
+		},
+		l,
+	)
+}
+
+func (suite *LabelsSuite) TestLabelCombinations() {
+	suite.Empty(LabelCombinations(nil, []string{http.MethodGet}))
+	suite.Equal(
+		[]Labels{
+			{CodeLabel: "200", MethodLabel: http.MethodGet},
+			{CodeLabel: "200", MethodLabel: http.MethodPost},
+			{CodeLabel: "404", MethodLabel: http.MethodGet},
+			{CodeLabel: "404", MethodLabel: http.MethodPost},
+		},
+		LabelCombinations(
+			[]int{http.StatusOK, http.StatusNotFound},
+			[]string{http.MethodGet, http.MethodPost},
+		),
+	)
+}
+
+func (suite *LabelsSuite) TestAcquireRelease() {
+	l := AcquireLabels()
+	suite.Require().NotNil(l)
+	suite.Empty(l)
+
+	l.SetCode(http.StatusOK)
+	l.SetServer("main")
+	suite.Len(l, 2)
+
+	ReleaseLabels(l)
+	suite.Empty(l)
+	ReleaseLabels(nil)
+
+	// whatever comes out of the pool is always empty
+	suite.Empty(AcquireLabels())
+}
+
+func TestLabels(t *testing.T) {
+	suite.Run(t, new(LabelsSuite))
+}
+
+func BenchmarkNewLabels(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = NewLabels(http.StatusOK, http.MethodGet)
+	}
+}
+
+func BenchmarkAcquireLabels(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l := AcquireLabels()
+		l.SetCode(http.StatusOK)
+		l.SetMethod(http.MethodGet)
+		ReleaseLabels(l)
+	}
+}
+
+// newInstrumentedHandler creates a handler that does nothing, instrumented by the default
+// ServerBundle, along with a request and response for it.
+func newInstrumentedHandler(tb testing.TB) (http.Handler, *httptest.ResponseRecorder, *http.Request) {
+	_, r, err := touchstone.New(touchstone.Config{
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	})
+
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	si, err := ServerBundle{}.NewInstrumenter(ServerLabel, "main")(
+		touchstone.NewFactory(touchstone.Config{}, nil, r),
+	)
+
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})),
+		httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodGet, "/", nil)
+}
+
+// TestServerInstrumenterAllocs guards the allocations of BenchmarkServerInstrumenter.
+func TestServerInstrumenterAllocs(t *testing.T) {
+	h, response, request := newInstrumentedHandler(t)
+	allocs := testing.AllocsPerRun(100, func() {
+		h.ServeHTTP(response, request)
+	})
+
+	if allocs > 2 {
+		t.Errorf("expected at most 2 allocations per transaction, got %v", allocs)
+	}
+}
+
+func BenchmarkServerInstrumenter(b *testing.B) {
+	h, response, request := newInstrumentedHandler(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.ServeHTTP(response, request)
+	}
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+)
+
+// newLastError creates the optional gauge describing the most recent error counted by
+// an error counter.  If o is nil, no gauge is created and this function returns nil.
+//
+// The gauge's name and help default to those derived from the error counter's options.
+// See touchstone.LastErrorOpts.
+func newLastError(f touchstone.MetricFactory, o *prometheus.GaugeOpts, errorCount prometheus.CounterOpts, extraNames []string, curry prometheus.Labels) (*touchstone.LastError, error) {
+	if o == nil {
+		return nil, nil
+	}
+
+	for _, label := range []string{touchstone.ReasonLabel, touchstone.MessageHashLabel} {
+		if _, reserved := curry[label]; reserved {
+			return nil, fmt.Errorf("%w: %s", ErrReservedLabelName, label)
+		}
+	}
+
+	clone := *o
+	touchstone.ApplyDefaults(&clone, touchstone.LastErrorOpts(errorCount))
+	labelNames := make([]string, 0, len(extraNames)+2)
+	labelNames = append(labelNames, extraNames...)
+	labelNames = append(labelNames, touchstone.ReasonLabel, touchstone.MessageHashLabel)
+
+	gv, err := touchstone.NewCurriedGaugeVec(f, clone, labelNames, curry)
+	if err != nil {
+		return nil, err
+	}
+
+	return touchstone.NewLastError(gv), nil
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+)
+
+type LastErrorSuite struct {
+	BundleSuite
+}
+
+func (suite *LastErrorSuite) TestClient() {
+	var (
+		f           = suite.newFactory()
+		expectedErr = errors.New("expected")
+	)
+
+	ci, err := ClientBundle{
+		LastError: &prometheus.GaugeOpts{},
+	}.NewInstrumenter(ClientLabel, "main")(f)
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(ci.lastError)
+
+	c := ci.Then(&http.Client{
+		Transport: clientTransport(func(*http.Request) (*http.Response, error) {
+			return nil, expectedErr
+		}),
+	})
+
+	request, err := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+	suite.Require().NoError(err)
+	response, doErr := c.Do(request)
+	suite.Nil(response)
+	suite.Require().Error(doErr)
+
+	// the gauge already exists, so an equivalent vector is returned rather than a new one
+	gv, err := f.NewCurriedGaugeVec(
+		touchstone.LastErrorOpts(defaultClientErrorCount),
+		[]string{ClientLabel, touchstone.ReasonLabel, touchstone.MessageHashLabel},
+		prometheus.Labels{ClientLabel: "main"},
+	)
+
+	suite.Require().NoError(err)
+	suite.Equal(1, testutil.CollectAndCount(gv, "client_last_error_info"))
+	suite.Equal(1.0, testutil.ToFloat64(
+		gv.WithLabelValues(formatCode(StatusNoResponse), touchstone.MessageHash(doErr.Error())),
+	))
+}
+
+func (suite *LastErrorSuite) TestClientDisabled() {
+	ci, err := ClientBundle{}.NewInstrumenter()(suite.newFactory())
+	suite.Require().NoError(err)
+	suite.Nil(ci.lastError)
+}
+
+func (suite *LastErrorSuite) TestClientReservedLabel() {
+	_, err := ClientBundle{
+		LastError: &prometheus.GaugeOpts{},
+	}.NewInstrumenter(touchstone.ReasonLabel, "value")(suite.newFactory())
+
+	suite.ErrorIs(err, ErrReservedLabelName)
+}
+
+func (suite *LastErrorSuite) TestDialer() {
+	f := suite.newFactory()
+	di, err := DialerBundle{
+		LastError: &prometheus.GaugeOpts{Name: "dial_failure_info"},
+	}.NewInstrumenter(ClientLabel, "main")(f)
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(di.lastError)
+
+	expectedErr := errors.New("expected")
+	dial := di.Then(func(context.Context, string, string) (net.Conn, error) {
+		return nil, expectedErr
+	})
+
+	conn, err := dial(context.Background(), "tcp4", "localhost:1234")
+	suite.Nil(conn)
+	suite.ErrorIs(err, expectedErr)
+
+	gv, err := f.NewCurriedGaugeVec(
+		prometheus.GaugeOpts{Name: "dial_failure_info", Help: touchstone.LastErrorOpts(defaultClientDialErrorCount).Help},
+		[]string{ClientLabel, touchstone.ReasonLabel, touchstone.MessageHashLabel},
+		prometheus.Labels{ClientLabel: "main"},
+	)
+
+	suite.Require().NoError(err)
+	suite.Equal(1.0, testutil.ToFloat64(
+		gv.WithLabelValues("tcp4", touchstone.MessageHash("expected")),
+	))
+}
+
+func TestLastError(t *testing.T) {
+	suite.Run(t, new(LastErrorSuite))
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LatencyClass names a preset of duration bucket boundaries, in milliseconds, for a
+// class of endpoint.  Selecting a class rather than supplying raw buckets keeps bucket
+// policy, and thus SLO latency thresholds, consistent across services.
+//
+// A LatencyClass may be unmarshaled from configuration.  Unrecognized classes are
+// rejected when unmarshaled.
+type LatencyClass string
+
+const (
+	// LatencyFast is the class of endpoints that respond within a few milliseconds,
+	// e.g. health checks and cache lookups.
+	LatencyFast LatencyClass = "fast"
+
+	// LatencyStandard is the class of typical API endpoints.
+	LatencyStandard LatencyClass = "standard"
+
+	// LatencySlow is the class of endpoints that routinely take seconds, e.g. those
+	// that fan out to several dependencies.
+	LatencySlow LatencyClass = "slow"
+
+	// LatencyBatch is the class of long running endpoints, e.g. bulk imports and
+	// reports, that may take minutes or hours.
+	LatencyBatch LatencyClass = "batch"
+)
+
+// ErrUnknownLatencyClass indicates that a LatencyClass was not one of the presets.
+var ErrUnknownLatencyClass = errors.New("Unknown latency class")
+
+var latencyBuckets = map[LatencyClass][]float64{
+	LatencyFast:     {1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000},
+	LatencyStandard: {10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+	LatencySlow:     {100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 120000},
+	LatencyBatch:    {1000, 5000, 15000, 30000, 60000, 300000, 900000, 1800000, 3600000, 7200000},
+}
+
+// Buckets returns a copy of the bucket boundaries, in milliseconds, for this class.
+func (lc LatencyClass) Buckets() ([]float64, error) {
+	if b, ok := latencyBuckets[lc]; ok {
+		return append([]float64{}, b...), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownLatencyClass, string(lc))
+}
+
+// UnmarshalText allows a LatencyClass to be unmarshaled from configuration.  An empty
+// value is allowed, and means that no class is selected.
+func (lc *LatencyClass) UnmarshalText(text []byte) error {
+	v := LatencyClass(text)
+	if len(v) > 0 {
+		if _, err := v.Buckets(); err != nil {
+			return err
+		}
+	}
+
+	*lc = v
+	return nil
+}
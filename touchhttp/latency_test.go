@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+)
+
+type LatencyClassSuite struct {
+	BundleSuite
+}
+
+// upperBounds returns the sorted bucket boundaries of a histogram.
+func (suite *LatencyClassSuite) upperBounds(ov prometheus.ObserverVec) []float64 {
+	hd, err := touchstone.HistogramValue(ov, nil)
+	suite.Require().NoError(err)
+
+	var bounds []float64
+	for b := range hd.Buckets {
+		bounds = append(bounds, b)
+	}
+
+	sort.Float64s(bounds)
+	return bounds
+}
+
+func (suite *LatencyClassSuite) TestBuckets() {
+	for _, lc := range []LatencyClass{LatencyFast, LatencyStandard, LatencySlow, LatencyBatch} {
+		suite.Run(string(lc), func() {
+			buckets, err := lc.Buckets()
+			suite.Require().NoError(err)
+			suite.Require().NotEmpty(buckets)
+			suite.True(sort.Float64sAreSorted(buckets))
+
+			// a copy is returned
+			buckets[0] = -1.0
+			again, err := lc.Buckets()
+			suite.Require().NoError(err)
+			suite.NotEqual(buckets[0], again[0])
+		})
+	}
+
+	_, err := LatencyClass("nosuch").Buckets()
+	suite.ErrorIs(err, ErrUnknownLatencyClass)
+}
+
+func (suite *LatencyClassSuite) TestUnmarshalText() {
+	var cfg struct {
+		Latency LatencyClass `json:"latency"`
+	}
+
+	suite.Require().NoError(json.Unmarshal([]byte(`{"latency": "slow"}`), &cfg))
+	suite.Equal(LatencySlow, cfg.Latency)
+
+	suite.Require().NoError(json.Unmarshal([]byte(`{"latency": ""}`), &cfg))
+	suite.Empty(cfg.Latency)
+
+	suite.ErrorIs(json.Unmarshal([]byte(`{"latency": "nosuch"}`), &cfg), ErrUnknownLatencyClass)
+}
+
+func (suite *LatencyClassSuite) TestServer() {
+	si, err := ServerBundle{LatencyClass: LatencyFast}.NewInstrumenter()(suite.newFactory())
+	suite.Require().NoError(err)
+
+	si.Then(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	expected, _ := LatencyFast.Buckets()
+	suite.Equal(expected, suite.upperBounds(si.duration))
+
+	suite.Run("ExplicitBuckets", func() {
+		si, err := ServerBundle{
+			LatencyClass: LatencyFast,
+			Duration:     prometheus.HistogramOpts{Buckets: []float64{1, 2, 3}},
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.Require().NoError(err)
+		si.Then(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		suite.Equal([]float64{1, 2, 3}, suite.upperBounds(si.duration))
+	})
+
+	suite.Run("Summary", func() {
+		_, err := ServerBundle{
+			LatencyClass: LatencyFast,
+			Duration:     prometheus.SummaryOpts{},
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.NoError(err)
+	})
+
+	suite.Run("Unknown", func() {
+		_, err := ServerBundle{LatencyClass: "nosuch"}.NewInstrumenter()(suite.newFactory())
+		suite.ErrorIs(err, ErrUnknownLatencyClass)
+	})
+}
+
+func (suite *LatencyClassSuite) TestClient() {
+	ci, err := ClientBundle{LatencyClass: LatencyBatch}.NewInstrumenter()(suite.newFactory())
+	suite.Require().NoError(err)
+
+	request, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	suite.Require().NoError(err)
+	_, err = ci.Then(&http.Client{Transport: clientTransport(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})}).Do(request)
+
+	suite.Require().NoError(err)
+	expected, _ := LatencyBatch.Buckets()
+	suite.Equal(expected, suite.upperBounds(ci.duration))
+
+	suite.Run("Unknown", func() {
+		_, err := ClientBundle{LatencyClass: "nosuch"}.NewInstrumenter()(suite.newFactory())
+		suite.ErrorIs(err, ErrUnknownLatencyClass)
+	})
+}
+
+func TestLatencyClass(t *testing.T) {
+	suite.Run(t, new(LatencyClassSuite))
+}
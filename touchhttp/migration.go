@@ -0,0 +1,247 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/xmidt-org/touchstone"
+)
+
+const (
+	// MigratedServerCount is the default name of the counter that replaces
+	// DefaultServerCount when a ServerBundle has a Migration.
+	MigratedServerCount = "server_requests_total"
+
+	// MigratedServerDuration is the default name of the observer, in seconds, that
+	// replaces DefaultServerDuration when a ServerBundle has a Migration.
+	MigratedServerDuration = "server_request_duration_seconds"
+
+	// MigratedClientCount is the default name of the counter that replaces
+	// DefaultClientCount when a ClientBundle has a Migration.
+	MigratedClientCount = "client_requests_total"
+
+	// MigratedClientDuration is the default name of the observer, in seconds, that
+	// replaces DefaultClientDuration when a ClientBundle has a Migration.
+	MigratedClientDuration = "client_request_duration_seconds"
+)
+
+var (
+	defaultMigratedServerCount = prometheus.CounterOpts{
+		Name: MigratedServerCount,
+		Help: "the total number of requests received since startup",
+	}
+
+	defaultMigratedServerDuration = prometheus.HistogramOpts{
+		Name: MigratedServerDuration,
+		Help: "the request duration in seconds",
+	}
+
+	defaultMigratedClientCount = prometheus.CounterOpts{
+		Name: MigratedClientCount,
+		Help: "the total number of requests sent since startup",
+	}
+
+	defaultMigratedClientDuration = prometheus.HistogramOpts{
+		Name: MigratedClientDuration,
+		Help: "the total time, in seconds, between sending a request and receiving a response",
+	}
+)
+
+// Migration emits a bundle's request counter and duration observer under new names,
+// alongside the original metric families, so that dashboards and alerts can move to
+// the new families without any gap in data.  The new duration observer records seconds
+// rather than milliseconds.
+//
+// Both sets of metrics have the same labels.  After the Period has elapsed, the original
+// families stop being updated and the instrumenter's series are deleted, so that they
+// disappear from subsequent scrapes even if no further requests arrive.  The series of
+// other instrumenters of the same metrics are unaffected.  Once a migration is complete, the bundle's Count and Duration should
+// be changed to the new names and this Migration removed.
+type Migration struct {
+	// Count describes the options for the new request counter.  Unset options default
+	// to those of MigratedServerCount or MigratedClientCount.
+	Count prometheus.CounterOpts
+
+	// Duration describes the options for the new duration observer, which records seconds.
+	// If this field is set, it must be either a prometheus.HistogramOpts or a prometheus.SummaryOpts.
+	// Unset options default to those of MigratedServerDuration or MigratedClientDuration, with
+	// the buckets of the original duration histogram converted to seconds.
+	Duration interface{}
+
+	// Period is how long the original families continue to be emitted after an instrumenter
+	// is created.  If unset, the original families are emitted indefinitely.
+	Period time.Duration
+}
+
+// migration is the runtime state of a Migration within an instrumenter.
+type migration struct {
+	count    *prometheus.CounterVec
+	duration prometheus.ObserverVec
+
+	// until is when the original families are retired, zero if they never are
+	until   time.Time
+	retired atomic.Bool
+	retire  func()
+}
+
+// secondsBuckets converts bucket boundaries in milliseconds to seconds.
+func secondsBuckets(ms []float64) []float64 {
+	if len(ms) == 0 {
+		return nil
+	}
+
+	s := make([]float64, len(ms))
+	for i, b := range ms {
+		s[i] = b / 1000
+	}
+
+	return s
+}
+
+// curriedDeleter is implemented by the curried views of vectors whose series can be deleted.
+type curriedDeleter interface {
+	prometheus.Collector
+	Delete(prometheus.Labels) bool
+}
+
+// deleteCurried deletes the series of a curried view of a vector, leaving the series
+// of other views of the same vector alone.  prometheus ignores curried labels in partial
+// matches, so each series of the view is found by collecting it.
+func deleteCurried(vec curriedDeleter, labelNames []string, curry prometheus.Labels) {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		vec.Collect(ch)
+		close(ch)
+	}()
+
+	var matched []prometheus.Labels
+	for m := range ch {
+		var pb dto.Metric
+		if m.Write(&pb) != nil {
+			continue
+		}
+
+		values := make(map[string]string, len(pb.GetLabel()))
+		for _, lp := range pb.GetLabel() {
+			values[lp.GetName()] = lp.GetValue()
+		}
+
+		labels := make(prometheus.Labels, len(labelNames))
+		for _, name := range labelNames {
+			v := values[name]
+			if cv, curried := curry[name]; !curried {
+				labels[name] = v
+			} else if cv != v {
+				labels = nil
+				break
+			}
+		}
+
+		if labels != nil {
+			matched = append(matched, labels)
+		}
+	}
+
+	for _, labels := range matched {
+		vec.Delete(labels)
+	}
+}
+
+// migratedDurationDefaults computes the defaults of a new duration observer.  Unless the
+// defaults have buckets, the buckets of the original duration histogram are converted to seconds.
+func migratedDurationDefaults(defaults prometheus.HistogramOpts, legacy interface{}, legacyDefaults prometheus.HistogramOpts) prometheus.HistogramOpts {
+	if len(defaults.Buckets) == 0 {
+		buckets := legacyDefaults.Buckets
+		if h, ok := legacy.(prometheus.HistogramOpts); ok && len(h.Buckets) > 0 {
+			buckets = h.Buckets
+		}
+
+		defaults.Buckets = secondsBuckets(buckets)
+	}
+
+	return defaults
+}
+
+// new creates the migration state for an instrumenter whose original metrics have been
+// created.  The series of those metrics with the given curried labels are deleted when
+// the original families are retired.
+func (m Migration) new(
+	f touchstone.MetricFactory,
+	countDefaults prometheus.CounterOpts,
+	durationDefaults prometheus.HistogramOpts,
+	i *instrumenter,
+	labelNames []string,
+	curry prometheus.Labels,
+) (mg *migration, err error) {
+	var opts interface{}
+	switch t := m.Duration.(type) {
+	case nil:
+		opts = durationDefaults
+
+	case prometheus.HistogramOpts:
+		touchstone.ApplyDefaults(&t, durationDefaults)
+		opts = t
+
+	case prometheus.SummaryOpts:
+		touchstone.ApplyDefaults(&t, durationDefaults)
+		opts = t
+
+	default:
+		return nil, errors.New("Migration.Duration must be nil, a prometheus.HistogramOpts, or a prometheus.SummaryOpts")
+	}
+
+	mg = new(migration)
+	touchstone.ApplyDefaults(&m.Count, countDefaults)
+	if mg.count, err = newCounterVec(f, m.Count, labelNames, curry); err != nil {
+		return nil, err
+	}
+
+	if mg.duration, err = newObserverVec(f, opts, labelNames, curry); err != nil {
+		return nil, err
+	}
+
+	if m.Period > 0 {
+		mg.until = i.now().Add(m.Period)
+
+		var (
+			once           sync.Once
+			legacyCount    = i.count
+			legacyDuration = i.duration
+		)
+
+		// the vectors are shared by every instrumenter of the same metrics, so
+		// only this instrumenter's series are deleted
+		mg.retire = func() {
+			once.Do(func() {
+				mg.retired.Store(true)
+				deleteCurried(legacyCount, labelNames, curry)
+				if cd, ok := legacyDuration.(curriedDeleter); ok {
+					deleteCurried(cd, labelNames, curry)
+				}
+			})
+		}
+
+		time.AfterFunc(m.Period, mg.retire)
+	}
+
+	return
+}
+
+// legacy tests whether the original families are still emitted at the given time.
+// The first time this method returns false, the instrumenter's series of the original
+// families are deleted, unless the period's timer has already done so.
+func (mg *migration) legacy(now time.Time) bool {
+	if mg.until.IsZero() || (now.Before(mg.until) && !mg.retired.Load()) {
+		return true
+	}
+
+	mg.retire()
+	return false
+}
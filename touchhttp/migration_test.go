@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/httpaux/client"
+)
+
+type MigrationSuite struct {
+	BundleSuite
+}
+
+func (suite *MigrationSuite) TestServer() {
+	si, err := ServerBundle{
+		Migration: &Migration{Period: time.Second},
+		Clock:     suite.clock(250 * time.Millisecond),
+	}.NewInstrumenter(ServerLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(si.migration)
+
+	h := si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// during the period, both families are emitted
+	suite.Equal(1, testutil.CollectAndCount(si.count))
+	suite.Equal(1, testutil.CollectAndCount(si.duration))
+	suite.Equal(1, testutil.CollectAndCount(si.migration.count))
+	suite.Equal(1, testutil.CollectAndCount(si.migration.duration))
+
+	// the second request completes at the end of the period
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Zero(testutil.CollectAndCount(si.count))
+	suite.Zero(testutil.CollectAndCount(si.duration))
+	suite.Equal(
+		2.0,
+		testutil.ToFloat64(si.migration.count.With(prometheus.Labels{CodeLabel: "200", MethodLabel: http.MethodGet})),
+	)
+
+	expected := `
+# HELP server_request_duration_seconds the request duration in seconds
+# TYPE server_request_duration_seconds histogram
+server_request_duration_seconds_bucket{code="200",method="GET",server="main",le="0.0625"} 0
+server_request_duration_seconds_bucket{code="200",method="GET",server="main",le="0.125"} 0
+server_request_duration_seconds_bucket{code="200",method="GET",server="main",le="0.25"} 2
+server_request_duration_seconds_bucket{code="200",method="GET",server="main",le="0.5"} 2
+server_request_duration_seconds_bucket{code="200",method="GET",server="main",le="1"} 2
+server_request_duration_seconds_bucket{code="200",method="GET",server="main",le="5"} 2
+server_request_duration_seconds_bucket{code="200",method="GET",server="main",le="10"} 2
+server_request_duration_seconds_bucket{code="200",method="GET",server="main",le="20"} 2
+server_request_duration_seconds_bucket{code="200",method="GET",server="main",le="40"} 2
+server_request_duration_seconds_bucket{code="200",method="GET",server="main",le="80"} 2
+server_request_duration_seconds_bucket{code="200",method="GET",server="main",le="160"} 2
+server_request_duration_seconds_bucket{code="200",method="GET",server="main",le="+Inf"} 2
+server_request_duration_seconds_sum{code="200",method="GET",server="main"} 0.5
+server_request_duration_seconds_count{code="200",method="GET",server="main"} 2
+`
+
+	suite.NoError(
+		testutil.CollectAndCompare(si.migration.duration.(prometheus.Collector), strings.NewReader(expected)),
+	)
+}
+
+func (suite *MigrationSuite) TestOtherInstrumenters() {
+	f := suite.newFactory()
+	health, err := ServerBundle{}.NewInstrumenter(ServerLabel, "health")(f)
+	suite.Require().NoError(err)
+
+	main, err := ServerBundle{
+		Migration: &Migration{Period: time.Second},
+		Clock:     suite.clock(time.Second),
+	}.NewInstrumenter(ServerLabel, "main")(f)
+
+	suite.Require().NoError(err)
+
+	healthHandler := health.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	for i := 0; i < 5; i++ {
+		healthHandler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	// this request completes after the period, retiring the original families of main
+	main.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	suite.Equal(1, testutil.CollectAndCount(health.count))
+	suite.Equal(
+		5.0,
+		testutil.ToFloat64(health.count.With(prometheus.Labels{CodeLabel: "200", MethodLabel: http.MethodGet})),
+	)
+
+	suite.Equal(1, testutil.CollectAndCount(health.duration))
+}
+
+func (suite *MigrationSuite) TestRetireWithoutRequests() {
+	si, err := ServerBundle{
+		Migration: &Migration{Period: 10 * time.Millisecond},
+	}.NewInstrumenter(ServerLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+	si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	suite.Eventually(
+		func() bool {
+			return testutil.CollectAndCount(si.count) == 0 && testutil.CollectAndCount(si.duration) == 0
+		},
+		time.Second,
+		5*time.Millisecond,
+	)
+
+	suite.True(si.migration.retired.Load())
+	suite.False(si.migration.legacy(time.Time{}))
+	suite.Equal(1, testutil.CollectAndCount(si.migration.count))
+}
+
+func (suite *MigrationSuite) TestClient() {
+	ci, err := ClientBundle{
+		Migration: &Migration{
+			Count:    prometheus.CounterOpts{Name: "custom_requests_total"},
+			Duration: prometheus.HistogramOpts{Buckets: []float64{0.1, 1}},
+		},
+		Preinitialize: LabelCombinations([]int{http.StatusOK}, []string{http.MethodGet}),
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(ci.migration)
+	suite.Equal(1, testutil.CollectAndCount(ci.migration.count, "custom_requests_total"))
+	suite.Equal(1, testutil.CollectAndCount(ci.migration.duration.(prometheus.Collector), MigratedClientDuration))
+
+	c := ci.Then(client.Func(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound}, nil
+	}))
+
+	_, err = c.Do(httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Require().NoError(err)
+
+	// without a period, both families are always emitted
+	suite.Equal(2, testutil.CollectAndCount(ci.count))
+	suite.Equal(2, testutil.CollectAndCount(ci.migration.count))
+}
+
+func (suite *MigrationSuite) TestInvalidDuration() {
+	_, err := ServerBundle{
+		Migration: &Migration{Duration: prometheus.GaugeOpts{}},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Error(err)
+}
+
+func (suite *MigrationSuite) TestMetricNames() {
+	names := ServerBundle{
+		Migration: &Migration{},
+	}.MetricNames(prometheus.Opts{Namespace: "n"}, nil)
+
+	suite.Equal("n_"+MigratedServerCount, names.MigratedCount)
+	suite.Equal("n_"+MigratedServerDuration, names.MigratedDuration)
+
+	clientNames := ClientBundle{
+		Migration: &Migration{Duration: prometheus.SummaryOpts{Name: "custom_seconds"}},
+	}.MetricNames(prometheus.Opts{}, nil)
+
+	suite.Equal(MigratedClientCount, clientNames.MigratedCount)
+	suite.Equal("custom_seconds", clientNames.MigratedDuration)
+}
+
+func TestMigration(t *testing.T) {
+	suite.Run(t, new(MigrationSuite))
+}
@@ -0,0 +1,238 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+)
+
+// ObserverType describes the kind of metric backing an observer.
+type ObserverType string
+
+const (
+	// ObserverHistogram indicates that an observer is backed by a histogram.
+	ObserverHistogram ObserverType = "histogram"
+
+	// ObserverSummary indicates that an observer is backed by a summary.
+	ObserverSummary ObserverType = "summary"
+)
+
+// ServerMetricNames holds the fully qualified names of the metrics created
+// by a ServerBundle.
+type ServerMetricNames struct {
+	Count        string
+	InFlight     string
+	RequestSize  string
+	Duration     string
+	DurationType ObserverType
+
+	// SampledDuration is empty if the bundle does not use Sampling.
+	SampledDuration string
+
+	// QueueDuration is empty if the bundle does not use QueueTime.
+	QueueDuration string
+
+	// BodyReadDuration is empty if the bundle does not use BodyRead.
+	BodyReadDuration string
+
+	// ResponseSize is empty if the bundle does not use Responses.
+	ResponseSize string
+
+	// SuperfluousWriteHeaderCount is empty if the bundle does not use Responses.
+	SuperfluousWriteHeaderCount string
+
+	// PhaseDuration is empty if the bundle does not use Phases.
+	PhaseDuration string
+
+	// Tenants is empty if the bundle does not use a Tenancy.
+	Tenants string
+
+	// MigratedCount and MigratedDuration are empty if the bundle does not use a Migration.
+	MigratedCount    string
+	MigratedDuration string
+}
+
+// ClientMetricNames holds the fully qualified names of the metrics created
+// by a ClientBundle.
+type ClientMetricNames struct {
+	Count        string
+	InFlight     string
+	RequestSize  string
+	Duration     string
+	DurationType ObserverType
+	ErrorCount   string
+
+	// SampledDuration is empty if the bundle does not use Sampling.
+	SampledDuration string
+
+	// ProtocolCount is empty if the bundle does not create this optional counter.
+	ProtocolCount string
+
+	// RedirectCount is empty if the bundle does not create this optional counter.
+	RedirectCount string
+
+	// RetryCount is empty if the bundle does not create this optional counter.
+	RetryCount string
+
+	// RejectedCount is empty if the bundle does not create this optional counter.
+	RejectedCount string
+
+	// Tenants is empty if the bundle does not use a Tenancy.
+	Tenants string
+
+	// MigratedCount and MigratedDuration are empty if the bundle does not use a Migration.
+	MigratedCount    string
+	MigratedDuration string
+}
+
+// metricNaming holds the factory defaults and naming policy used to compute
+// the fully qualified names of metrics.
+type metricNaming struct {
+	defaults prometheus.Opts
+	policy   touchstone.NamingPolicy
+}
+
+// fqName computes the fully qualified name of a metric given its options, the same
+// way a touchstone.Factory does.
+func (mn metricNaming) fqName(o prometheus.Opts) string {
+	if len(o.Namespace) == 0 {
+		o.Namespace = mn.defaults.Namespace
+	}
+
+	if len(o.Subsystem) == 0 {
+		o.Subsystem = mn.defaults.Subsystem
+	}
+
+	if mn.policy != nil {
+		o.Name = mn.policy.MetricName(o.Name)
+	}
+
+	return prometheus.BuildFQName(o.Namespace, o.Subsystem, o.Name)
+}
+
+// observerName computes the fully qualified name and type of an observer described by
+// the given options, which must be nil, a prometheus.HistogramOpts, or a prometheus.SummaryOpts.
+func (mn metricNaming) observerName(o interface{}, fallback prometheus.HistogramOpts) (string, ObserverType) {
+	switch t := o.(type) {
+	case prometheus.HistogramOpts:
+		if len(t.Name) == 0 {
+			t.Name = fallback.Name
+		}
+
+		return mn.fqName(prometheus.Opts{Namespace: t.Namespace, Subsystem: t.Subsystem, Name: t.Name}), ObserverHistogram
+
+	case prometheus.SummaryOpts:
+		if len(t.Name) == 0 {
+			t.Name = fallback.Name
+		}
+
+		return mn.fqName(prometheus.Opts{Namespace: t.Namespace, Subsystem: t.Subsystem, Name: t.Name}), ObserverSummary
+
+	default:
+		return mn.fqName(prometheus.Opts{Name: fallback.Name}), ObserverHistogram
+	}
+}
+
+func (mn metricNaming) counterName(o, fallback prometheus.CounterOpts) string {
+	if len(o.Name) == 0 {
+		o.Name = fallback.Name
+	}
+
+	return mn.fqName(prometheus.Opts(o))
+}
+
+func (mn metricNaming) gaugeName(o, fallback prometheus.GaugeOpts) string {
+	if len(o.Name) == 0 {
+		o.Name = fallback.Name
+	}
+
+	return mn.fqName(prometheus.Opts(o))
+}
+
+// MetricNames returns the fully qualified names of the metrics this bundle creates.
+// The defaults are the namespace and subsystem applied by the touchstone.Factory,
+// e.g. from touchstone.Config.  Only the Namespace and Subsystem of defaults are used.
+// The policy is the Factory's NamingPolicy, which can be nil if there is none.
+func (sb ServerBundle) MetricNames(defaults prometheus.Opts, policy touchstone.NamingPolicy) (names ServerMetricNames) {
+	mn := metricNaming{defaults: defaults, policy: policy}
+	names.Count = mn.counterName(sb.Count, defaultServerCount)
+	names.InFlight = mn.gaugeName(sb.InFlight, defaultServerInFlight)
+	names.RequestSize, _ = mn.observerName(sb.RequestSize, defaultServerRequestSize)
+	names.Duration, names.DurationType = mn.observerName(sb.Duration, defaultServerDuration)
+	if sb.Sampling != nil {
+		names.SampledDuration, _ = mn.observerName(sb.Sampling.Duration, defaultServerSampledDuration)
+	}
+
+	if sb.QueueTime != nil {
+		names.QueueDuration, _ = mn.observerName(sb.QueueTime.Duration, defaultServerQueueDuration)
+	}
+
+	if sb.BodyRead != nil {
+		names.BodyReadDuration, _ = mn.observerName(sb.BodyRead.Duration, defaultServerBodyReadDuration)
+	}
+
+	if sb.Responses != nil {
+		names.ResponseSize, _ = mn.observerName(sb.Responses.Size, defaultServerResponseSize)
+		names.SuperfluousWriteHeaderCount = mn.counterName(sb.Responses.SuperfluousWriteHeader, defaultServerSuperfluousWriteHeaderCount)
+	}
+
+	if sb.Phases != nil {
+		names.PhaseDuration, _ = mn.observerName(sb.Phases.Duration, defaultServerPhaseDuration)
+	}
+
+	if sb.Tenancy != nil {
+		names.Tenants = mn.gaugeName(sb.Tenancy.Tenants, defaultServerTenants)
+	}
+
+	if sb.Migration != nil {
+		names.MigratedCount = mn.counterName(sb.Migration.Count, defaultMigratedServerCount)
+		names.MigratedDuration, _ = mn.observerName(sb.Migration.Duration, defaultMigratedServerDuration)
+	}
+
+	return
+}
+
+// MetricNames returns the fully qualified names of the metrics this bundle creates.
+// The defaults are the namespace and subsystem applied by the touchstone.Factory,
+// e.g. from touchstone.Config.  Only the Namespace and Subsystem of defaults are used.
+// The policy is the Factory's NamingPolicy, which can be nil if there is none.
+func (cb ClientBundle) MetricNames(defaults prometheus.Opts, policy touchstone.NamingPolicy) (names ClientMetricNames) {
+	mn := metricNaming{defaults: defaults, policy: policy}
+	names.Count = mn.counterName(cb.Count, defaultClientCount)
+	names.InFlight = mn.gaugeName(cb.InFlight, defaultClientInFlight)
+	names.RequestSize, _ = mn.observerName(cb.RequestSize, defaultClientRequestSize)
+	names.Duration, names.DurationType = mn.observerName(cb.Duration, defaultClientDuration)
+	names.ErrorCount = mn.counterName(cb.ErrorCount, defaultClientErrorCount)
+	if cb.Sampling != nil {
+		names.SampledDuration, _ = mn.observerName(cb.Sampling.Duration, defaultClientSampledDuration)
+	}
+
+	if cb.Tenancy != nil {
+		names.Tenants = mn.gaugeName(cb.Tenancy.Tenants, defaultClientTenants)
+	}
+
+	if cb.Migration != nil {
+		names.MigratedCount = mn.counterName(cb.Migration.Count, defaultMigratedClientCount)
+		names.MigratedDuration, _ = mn.observerName(cb.Migration.Duration, defaultMigratedClientDuration)
+	}
+
+	if cb.ProtocolCount != nil {
+		names.ProtocolCount = mn.counterName(*cb.ProtocolCount, defaultClientProtocolCount)
+	}
+
+	if cb.RedirectCount != nil {
+		names.RedirectCount = mn.counterName(*cb.RedirectCount, defaultClientRedirectCount)
+	}
+
+	if cb.RetryCount != nil {
+		names.RetryCount = mn.counterName(*cb.RetryCount, defaultClientRetryCount)
+	}
+
+	if cb.RejectedCount != nil {
+		names.RejectedCount = mn.counterName(*cb.RejectedCount, defaultClientRejectedCount)
+	}
+
+	return
+}
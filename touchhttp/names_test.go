@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/zap"
+)
+
+type MetricNamesSuite struct {
+	suite.Suite
+}
+
+func (suite *MetricNamesSuite) TestServerDefaults() {
+	names := ServerBundle{}.MetricNames(prometheus.Opts{}, nil)
+	suite.Equal(
+		ServerMetricNames{
+			Count:        DefaultServerCount,
+			InFlight:     DefaultServerInFlight,
+			RequestSize:  DefaultServerRequestSize,
+			Duration:     DefaultServerDuration,
+			DurationType: ObserverHistogram,
+		},
+		names,
+	)
+}
+
+func (suite *MetricNamesSuite) TestServerCustom() {
+	names := ServerBundle{
+		Count: prometheus.CounterOpts{
+			Namespace: "custom",
+			Name:      "requests",
+		},
+		Duration: prometheus.SummaryOpts{
+			Subsystem: "custom",
+		},
+	}.MetricNames(prometheus.Opts{Namespace: "n", Subsystem: "s"}, nil)
+
+	suite.Equal("custom_s_requests", names.Count)
+	suite.Equal("n_s_"+DefaultServerInFlight, names.InFlight)
+	suite.Equal("n_s_"+DefaultServerRequestSize, names.RequestSize)
+	suite.Equal("n_custom_"+DefaultServerDuration, names.Duration)
+	suite.Equal(ObserverSummary, names.DurationType)
+}
+
+func (suite *MetricNamesSuite) TestClient() {
+	names := ClientBundle{
+		Duration: prometheus.HistogramOpts{
+			Name: "custom_duration",
+		},
+	}.MetricNames(prometheus.Opts{Namespace: "n"}, nil)
+
+	suite.Equal(
+		ClientMetricNames{
+			Count:        "n_" + DefaultClientCount,
+			InFlight:     "n_" + DefaultClientInFlight,
+			RequestSize:  "n_" + DefaultClientRequestSize,
+			Duration:     "n_custom_duration",
+			DurationType: ObserverHistogram,
+			ErrorCount:   "n_" + DefaultClientErrorCount,
+		},
+		names,
+	)
+}
+
+func (suite *MetricNamesSuite) TestClientOptional() {
+	names := ClientBundle{
+		ProtocolCount: &prometheus.CounterOpts{},
+		RedirectCount: &prometheus.CounterOpts{Name: "custom_redirects"},
+		RetryCount:    &prometheus.CounterOpts{},
+		RejectedCount: &prometheus.CounterOpts{Name: "custom_rejected"},
+		Sampling:      &Sampling{},
+	}.MetricNames(prometheus.Opts{Namespace: "n"}, nil)
+
+	suite.Equal("n_"+DefaultClientProtocolCount, names.ProtocolCount)
+	suite.Equal("n_custom_redirects", names.RedirectCount)
+	suite.Equal("n_"+DefaultClientRetryCount, names.RetryCount)
+	suite.Equal("n_custom_rejected", names.RejectedCount)
+	suite.Equal("n_"+DefaultClientSampledDuration, names.SampledDuration)
+}
+
+func (suite *MetricNamesSuite) TestServerSampling() {
+	names := ServerBundle{
+		Sampling: &Sampling{
+			Duration: prometheus.SummaryOpts{Name: "custom_sampled"},
+		},
+	}.MetricNames(prometheus.Opts{Namespace: "n"}, nil)
+
+	suite.Equal("n_custom_sampled", names.SampledDuration)
+}
+
+func (suite *MetricNamesSuite) TestServerQueueTime() {
+	names := ServerBundle{
+		QueueTime: &QueueTime{},
+	}.MetricNames(prometheus.Opts{Namespace: "n"}, nil)
+
+	suite.Equal("n_"+DefaultServerQueueDuration, names.QueueDuration)
+	suite.Empty(ServerBundle{}.MetricNames(prometheus.Opts{}, nil).QueueDuration)
+}
+
+func (suite *MetricNamesSuite) TestServerBodyRead() {
+	names := ServerBundle{
+		BodyRead: &BodyRead{},
+	}.MetricNames(prometheus.Opts{Namespace: "n"}, nil)
+
+	suite.Equal("n_"+DefaultServerBodyReadDuration, names.BodyReadDuration)
+	suite.Empty(ServerBundle{}.MetricNames(prometheus.Opts{}, nil).BodyReadDuration)
+}
+
+func (suite *MetricNamesSuite) TestServerResponses() {
+	names := ServerBundle{
+		Responses: &Responses{
+			SuperfluousWriteHeader: prometheus.CounterOpts{Name: "custom"},
+		},
+	}.MetricNames(prometheus.Opts{Namespace: "n"}, nil)
+
+	suite.Equal("n_"+DefaultServerResponseSize, names.ResponseSize)
+	suite.Equal("n_custom", names.SuperfluousWriteHeaderCount)
+
+	names = ServerBundle{}.MetricNames(prometheus.Opts{}, nil)
+	suite.Empty(names.ResponseSize)
+	suite.Empty(names.SuperfluousWriteHeaderCount)
+}
+
+func (suite *MetricNamesSuite) TestServerPhases() {
+	names := ServerBundle{
+		Phases: &Phases{},
+	}.MetricNames(prometheus.Opts{Namespace: "n"}, nil)
+
+	suite.Equal("n_"+DefaultServerPhaseDuration, names.PhaseDuration)
+	suite.Empty(ServerBundle{}.MetricNames(prometheus.Opts{}, nil).PhaseDuration)
+}
+
+func (suite *MetricNamesSuite) TestNamingPolicy() {
+	var (
+		cfg    = touchstone.Config{DefaultNamespace: "n"}
+		policy = touchstone.Naming{
+			Prefix: "p",
+			Rename: map[string]string{DefaultServerCount: "renamed"},
+		}
+	)
+
+	g, r, err := touchstone.New(cfg)
+	suite.Require().NoError(err)
+
+	f := touchstone.NewFactory(cfg, zap.NewNop(), r, touchstone.WithNamingPolicy(policy))
+	si, err := ServerBundle{}.NewInstrumenter(ServerLabel, "main")(f)
+	suite.Require().NoError(err)
+	si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	names := ServerBundle{}.MetricNames(prometheus.Opts{Namespace: "n"}, policy)
+	suite.Equal("n_renamed", names.Count)
+	suite.Equal("n_p_"+DefaultServerInFlight, names.InFlight)
+
+	// every name must match a metric the Factory actually created
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+
+	registered := make(map[string]bool, len(mfs))
+	for _, mf := range mfs {
+		registered[mf.GetName()] = true
+	}
+
+	for _, name := range []string{names.Count, names.InFlight, names.RequestSize, names.Duration} {
+		suite.Truef(registered[name], "metric not registered: %s", name)
+	}
+}
+
+func TestMetricNames(t *testing.T) {
+	suite.Run(t, new(MetricNamesSuite))
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/xmidt-org/httpaux/observe"
+	"github.com/xmidt-org/touchstone"
+)
+
+const (
+	// DefaultPayloadSize is the default name of the gauge holding the size of the
+	// most recent metrics exposition.
+	DefaultPayloadSize = "metrics_payload_size"
+
+	// DefaultPayloadFamilies is the default name of the gauge holding the number of
+	// metric families in the most recent gather.
+	DefaultPayloadFamilies = "metrics_payload_families"
+
+	// DefaultPayloadSeries is the default name of the gauge holding the number of
+	// series in the most recent gather.
+	DefaultPayloadSeries = "metrics_payload_series"
+)
+
+var (
+	defaultPayloadSize = prometheus.GaugeOpts{
+		Name: DefaultPayloadSize,
+		Help: "the size in bytes of the most recent uncompressed metrics exposition",
+	}
+
+	defaultPayloadFamilies = prometheus.GaugeOpts{
+		Name: DefaultPayloadFamilies,
+		Help: "the number of metric families in the most recent metrics exposition",
+	}
+
+	defaultPayloadSeries = prometheus.GaugeOpts{
+		Name: DefaultPayloadSeries,
+		Help: "the number of series in the most recent metrics exposition",
+	}
+)
+
+// Payload describes the gauges that track the metrics endpoint's own output.  These
+// gauges make it possible to alert on growth in scrape size, e.g. a release that
+// doubles the number of series, before a scraper starts rejecting the target.
+//
+// Any unset field in the options is defaulted.
+type Payload struct {
+	// Size describes the gauge holding the number of bytes in the most recent
+	// successful exposition.
+	Size prometheus.GaugeOpts
+
+	// Families describes the gauge holding the number of metric families in the
+	// most recent gather.
+	Families prometheus.GaugeOpts
+
+	// Series describes the gauge holding the number of series, across all families,
+	// in the most recent gather.
+	Series prometheus.GaugeOpts
+}
+
+// NewInstrumenter creates the gauges for the metrics endpoint payload.
+func (p Payload) NewInstrumenter(f touchstone.MetricFactory) (pi PayloadInstrumenter, err error) {
+	touchstone.ApplyDefaults(&p.Size, defaultPayloadSize)
+	touchstone.ApplyDefaults(&p.Families, defaultPayloadFamilies)
+	touchstone.ApplyDefaults(&p.Series, defaultPayloadSeries)
+
+	pi.size, err = f.NewGauge(p.Size)
+	if err == nil {
+		pi.families, err = f.NewGauge(p.Families)
+	}
+
+	if err == nil {
+		pi.series, err = f.NewGauge(p.Series)
+	}
+
+	return
+}
+
+// PayloadInstrumenter updates the payload gauges on each scrape.  Family and series
+// counts are updated by the Gatherer decorator, while the size is updated by the
+// http.Handler decorator.
+type PayloadInstrumenter struct {
+	size     prometheus.Gauge
+	families prometheus.Gauge
+	series   prometheus.Gauge
+}
+
+// Gatherer decorates g so that the family and series gauges are updated each time
+// g is gathered successfully.  Gauges set during a gather are exposed by the next one.
+func (pi PayloadInstrumenter) Gatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := g.Gather()
+		if err == nil {
+			series := 0
+			for _, mf := range mfs {
+				series += len(mf.GetMetric())
+			}
+
+			pi.families.Set(float64(len(mfs)))
+			pi.series.Set(float64(series))
+		}
+
+		return mfs, err
+	})
+}
+
+// Then decorates next so that the size gauge is set to the number of bytes written
+// by each successful response.  For the size to be uncompressed, next must not
+// compress its output, i.e. this decorator must be applied before any compression.
+//
+// This method may be used as an alice.Constructor.
+func (pi PayloadInstrumenter) Then(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		w := observe.New(rw)
+		next.ServeHTTP(w, r)
+		if w.StatusCode() == http.StatusOK {
+			pi.size.Set(float64(w.ContentLength()))
+		}
+	})
+}
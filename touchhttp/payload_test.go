@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/zap"
+)
+
+type PayloadSuite struct {
+	suite.Suite
+}
+
+func (suite *PayloadSuite) newFactory() (prometheus.Gatherer, *touchstone.Factory) {
+	cfg := touchstone.Config{
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := touchstone.New(cfg)
+	suite.Require().NoError(err)
+	return g, touchstone.NewFactory(cfg, zap.L(), r)
+}
+
+func (suite *PayloadSuite) value(g prometheus.Gatherer, name string) float64 {
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+	for _, mf := range mfs {
+		if mf.GetName() == name {
+			return mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+
+	suite.Failf("no such metric", "metric: %s", name)
+	return 0.0
+}
+
+func (suite *PayloadSuite) TestNewInstrumenter() {
+	g, f := suite.newFactory()
+	pi, err := Payload{
+		Series: prometheus.GaugeOpts{Name: "custom_series"},
+	}.NewInstrumenter(f)
+
+	suite.Require().NoError(err)
+
+	c, err := f.NewCounterVec(prometheus.CounterOpts{Name: "test_counter", Help: "test"}, "code")
+	suite.Require().NoError(err)
+	c.WithLabelValues("200").Inc()
+	c.WithLabelValues("404").Inc()
+
+	mfs, err := pi.Gatherer(g).Gather()
+	suite.Require().NoError(err)
+	suite.Len(mfs, 4)
+
+	suite.Equal(4.0, suite.value(g, DefaultPayloadFamilies))
+	// the counter has 2 series, and each gauge has 1
+	suite.Equal(5.0, suite.value(g, "custom_series"))
+
+	_, err = Payload{}.NewInstrumenter(f)
+	suite.Error(err, "the metrics are already registered")
+}
+
+func (suite *PayloadSuite) TestGatherError() {
+	g, f := suite.newFactory()
+	pi, err := Payload{}.NewInstrumenter(f)
+	suite.Require().NoError(err)
+
+	expectedErr := errors.New("expected")
+	_, err = pi.Gatherer(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return nil, expectedErr
+	})).Gather()
+
+	suite.ErrorIs(err, expectedErr)
+	suite.Zero(suite.value(g, DefaultPayloadFamilies))
+}
+
+func (suite *PayloadSuite) TestThen() {
+	g, f := suite.newFactory()
+	pi, err := Payload{}.NewInstrumenter(f)
+	suite.Require().NoError(err)
+
+	code := http.StatusOK
+	h := pi.Then(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(code)
+		_, _ = io.WriteString(rw, "0123456789")
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Equal(10.0, suite.value(g, DefaultPayloadSize))
+
+	code = http.StatusInternalServerError
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	suite.Equal(10.0, suite.value(g, DefaultPayloadSize), "errors should not update the size")
+}
+
+func TestPayload(t *testing.T) {
+	suite.Run(t, new(PayloadSuite))
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+)
+
+const (
+	// PhaseLabel is the label holding the name of a phase recorded through a
+	// ServerTransaction.
+	PhaseLabel = "phase"
+
+	// PhaseOther is the PhaseLabel value used for phases that are not among the
+	// names a bundle allows.  See Phases.Names.
+	PhaseOther = "other"
+
+	// DefaultServerPhaseDuration is the default name of the optional observer that
+	// records the phases of server transactions.
+	DefaultServerPhaseDuration = "server_phase_duration_ms"
+)
+
+// ErrNoPhaseNames indicates that Phases did not specify the allowed phase names.
+var ErrNoPhaseNames = errors.New("Phases.Names is required to bound the phase label")
+
+var defaultServerPhaseDuration = prometheus.HistogramOpts{
+	Name:    DefaultServerPhaseDuration,
+	Help:    "the time in milliseconds spent in named phases of requests",
+	Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000, 10000},
+}
+
+// Phases describes the optional recording of the named phases of server transactions,
+// e.g. the time spent waiting on a backend.  Handlers record phases through the
+// ServerTransaction obtained with FromContext.
+//
+// The observer has the same labels as the duration metric plus the PhaseLabel.  Phase
+// names become label values, so Names is required to bound them regardless of what
+// handlers record.
+type Phases struct {
+	// Duration describes the options for the phase duration observer.  If set, it
+	// must be either a prometheus.HistogramOpts or a prometheus.SummaryOpts.
+	Duration interface{}
+
+	// Names is the set of phase names recorded as is.  Any other phase is recorded
+	// as PhaseOther.  This field is required.
+	Names []string
+}
+
+// phaseSet bounds the values of the PhaseLabel.
+type phaseSet map[string]bool
+
+// newPhaseSet creates a phaseSet from a bundle's configuration.
+func (p Phases) newPhaseSet() phaseSet {
+	ps := make(phaseSet, len(p.Names))
+	for _, n := range p.Names {
+		ps[n] = true
+	}
+
+	return ps
+}
+
+// format returns the PhaseLabel value for the given phase name.
+func (ps phaseSet) format(name string) string {
+	if ps[name] {
+		return name
+	}
+
+	return PhaseOther
+}
+
+// newObserverVec creates the phase duration observer for a server.
+func (p Phases) newObserverVec(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+	if len(p.Names) == 0 {
+		return nil, ErrNoPhaseNames
+	}
+
+	var opts interface{}
+	switch t := p.Duration.(type) {
+	case nil:
+		clone := defaultServerPhaseDuration
+		opts = clone
+
+	case prometheus.HistogramOpts:
+		touchstone.ApplyDefaults(&t, defaultServerPhaseDuration)
+		opts = t
+
+	case prometheus.SummaryOpts:
+		touchstone.ApplyDefaults(&t, defaultServerPhaseDuration)
+		opts = t
+
+	default:
+		return nil, errors.New("Phases.Duration must be nil, a prometheus.HistogramOpts, or a prometheus.SummaryOpts")
+	}
+
+	names := make([]string, 0, len(labelNames)+1)
+	names = append(names, labelNames...)
+	names = append(names, PhaseLabel)
+	return newObserverVec(f, opts, names, curry)
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+)
+
+type PhasesSuite struct {
+	BundleSuite
+}
+
+func (suite *PhasesSuite) TestObserver() {
+	si, err := ServerBundle{
+		Phases: &Phases{Names: []string{"database", "cache"}},
+	}.NewInstrumenter(ServerLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(si.phaseDuration)
+
+	h := si.Then(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		st, _ := FromContext(r.Context())
+		st.AddPhase("database", 2*time.Millisecond)
+		st.AddPhase("cache", 500*time.Microsecond)
+		st.AddPhase("unexpected", time.Millisecond)
+		rw.WriteHeader(http.StatusNotFound)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	hd, err := touchstone.HistogramValue(si.phaseDuration, prometheus.Labels{
+		CodeLabel:   "404",
+		MethodLabel: http.MethodGet,
+		PhaseLabel:  "database",
+	})
+
+	suite.Require().NoError(err)
+	suite.Equal(uint64(1), hd.Count)
+	suite.Equal(2.0, hd.Sum)
+
+	hd, err = touchstone.HistogramValue(si.phaseDuration, prometheus.Labels{PhaseLabel: "cache"})
+	suite.Require().NoError(err)
+	suite.Equal(0.5, hd.Sum)
+
+	// phases that are not named are bounded
+	hd, err = touchstone.HistogramValue(si.phaseDuration, prometheus.Labels{PhaseLabel: PhaseOther})
+	suite.Require().NoError(err)
+	suite.Equal(1.0, hd.Sum)
+
+	// a request without phases observes nothing
+	h = si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	_, err = touchstone.HistogramValue(si.phaseDuration, prometheus.Labels{MethodLabel: http.MethodPost})
+	suite.ErrorIs(err, touchstone.ErrNoSuchSeries)
+
+	suite.Run("Summary", func() {
+		si, err := ServerBundle{
+			Phases: &Phases{Duration: prometheus.SummaryOpts{Name: "custom_phases"}, Names: []string{"database"}},
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.Require().NoError(err)
+		suite.Require().NotNil(si.phaseDuration)
+	})
+
+	suite.Run("InvalidDuration", func() {
+		_, err := ServerBundle{
+			Phases: &Phases{Duration: prometheus.GaugeOpts{}, Names: []string{"database"}},
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.Error(err)
+	})
+
+	suite.Run("NoNames", func() {
+		_, err := ServerBundle{
+			Phases: &Phases{},
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.ErrorIs(err, ErrNoPhaseNames)
+	})
+}
+
+func TestPhases(t *testing.T) {
+	suite.Run(t, new(PhasesSuite))
+}
@@ -5,11 +5,12 @@ package touchhttp
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/xmidt-org/touchstone"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 )
 
 // Handler is a type alias for http.Handler that makes dependency injection easier.
@@ -33,33 +34,94 @@ type In struct {
 	Config Config `optional:"true"`
 
 	// Printer is the fx.Printer to which this package writes messages.
-	// This is optional, and if unset no messages are written.
+	// This is optional, and if unset no messages are written.  Logger takes
+	// precedence over this component.
 	Printer fx.Printer `optional:"true"`
 
-	// Now is the optional current time function.  If supplied, this
-	// will be used for computing metric durations.
-	Now func() time.Time `optional:"true"`
+	// Logger is the *zap.Logger to which this package writes messages.  This is the
+	// same optional component used by touchstone.Provide, so that handler errors and
+	// Factory warnings end up in the same sink.  See touchstone.NewPrinterLogger.
+	Logger *zap.Logger `optional:"true"`
 }
 
 // Provide bootstraps the promhttp environment for an uber/fx app.  This
 // function creates the following component types:
 //
 //   - promhttp.HandlerOpts
+//     Errors are logged to the *zap.Logger component if supplied, and to the
+//     fx.Printer component otherwise.
 //   - touchhttp.Handler
 //     This is the http.Handler to use to serve prometheus metrics.
-//     It will be instrumented if Config.InstrumentMetricHandler is set to true.
+//     It will negotiate JSON if Config.EnableJSON is set to true, and it
+//     will be instrumented if Config.InstrumentMetricHandler is set to true.
+//     OpenMetrics output includes _created lines if Config.EnableCreatedLines
+//     is set to true.  Output is compressed with the encodings and levels in
+//     Config.Compression, if any are set.  Gauges describing the output are
+//     maintained if Config.EnablePayloadMetrics is set to true.
+//   - touchhttp.InventoryHandler
+//     This is the http.Handler that serves the inventory of metric families.
+//     It responds with a 404 unless Config.EnableInventory is set to true.
+//   - touchhttp.HealthHandler
+//     This is the http.Handler that reports whether metrics can be gathered.
+//     It responds with a 404 unless Config.EnableHealthCheck is set to true.
 func Provide() fx.Option {
 	return fx.Provide(
-		func(r prometheus.Registerer, in In) (promhttp.HandlerOpts, error) {
-			return NewHandlerOpts(in.Config, in.Printer, r)
+		func(r prometheus.Registerer, in In) (opts promhttp.HandlerOpts, err error) {
+			opts, err = NewHandlerOpts(in.Config, in.Printer, r)
+			if err == nil && in.Logger != nil {
+				opts.ErrorLog = ErrorLogger{Logger: in.Logger}
+			}
+
+			return
 		},
-		func(r prometheus.Registerer, g prometheus.Gatherer, opts promhttp.HandlerOpts, in In) (h Handler) {
+		func(r prometheus.Registerer, g prometheus.Gatherer, f touchstone.MetricFactory, opts promhttp.HandlerOpts, in In) (h Handler, err error) {
+			var pi PayloadInstrumenter
+			if in.Config.EnablePayloadMetrics {
+				if pi, err = (Payload{}).NewInstrumenter(f); err != nil {
+					return
+				}
+
+				g = pi.Gatherer(g)
+			}
+
 			h = promhttp.HandlerFor(g, opts)
+			if in.Config.EnableOpenMetrics && in.Config.EnableCreatedLines {
+				h = NegotiateCreatedLines(NewCreatedLinesHandler(g), h)
+			}
+
+			if in.Config.EnableJSON {
+				h = NegotiateJSON(NewJSONHandler(g), h)
+			}
+
+			if in.Config.EnablePayloadMetrics {
+				h = pi.Then(h)
+			}
+
+			if in.Config.compressInHandler() {
+				if h, err = NewCompressionHandler(in.Config.Compression, h); err != nil {
+					return
+				}
+			}
+
 			if in.Config.InstrumentMetricHandler {
 				h = promhttp.InstrumentMetricHandler(r, h)
 			}
 
 			return
 		},
+		func(g prometheus.Gatherer, in In) InventoryHandler {
+			if in.Config.EnableInventory {
+				return NewInventoryHandler(g)
+			}
+
+			return http.NotFoundHandler()
+		},
+		func(g prometheus.Gatherer, in In) HealthHandler {
+			if in.Config.EnableHealthCheck {
+				return NewHealthHandler(g, in.Config.HealthCheckTimeout)
+			}
+
+			return http.NotFoundHandler()
+		},
 	)
 }
@@ -4,7 +4,11 @@
 package touchhttp
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -12,6 +16,9 @@ import (
 	"github.com/xmidt-org/touchstone"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type ProvideTestSuite struct {
@@ -95,6 +102,270 @@ func (suite *ProvideTestSuite) TestInstrumentMetricHandler() {
 	app.RequireStop()
 }
 
+func (suite *ProvideTestSuite) TestEnableJSON() {
+	var (
+		h Handler
+
+		app = fxtest.New(
+			suite.T(),
+			fx.Supply(
+				Config{
+					EnableJSON: true,
+				},
+			),
+			touchstone.Provide(),
+			Provide(),
+			fx.Populate(&h),
+		)
+	)
+
+	suite.NoError(app.Err())
+	app.RequireStart()
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	request.Header.Set("Accept", JSONContentType)
+	response := httptest.NewRecorder()
+	h.ServeHTTP(response, request)
+	suite.Equal(http.StatusOK, response.Code)
+	suite.Equal(JSONContentType, response.Header().Get("Content-Type"))
+
+	response = httptest.NewRecorder()
+	h.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	suite.Equal(http.StatusOK, response.Code)
+	suite.NotEqual(JSONContentType, response.Header().Get("Content-Type"))
+
+	app.RequireStop()
+}
+
+func (suite *ProvideTestSuite) TestEnableCreatedLines() {
+	var (
+		h Handler
+
+		app = fxtest.New(
+			suite.T(),
+			fx.Supply(
+				Config{
+					EnableOpenMetrics:  true,
+					EnableCreatedLines: true,
+				},
+			),
+			touchstone.Provide(),
+			Provide(),
+			fx.Populate(&h),
+		)
+	)
+
+	suite.NoError(app.Err())
+	app.RequireStart()
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	request.Header.Set("Accept", "application/openmetrics-text;version=1.0.0")
+	response := httptest.NewRecorder()
+	h.ServeHTTP(response, request)
+	suite.Equal(http.StatusOK, response.Code)
+	// promhttp registers its own error counters
+	suite.Contains(response.Body.String(), "promhttp_metric_handler_errors_created")
+
+	app.RequireStop()
+}
+
+func (suite *ProvideTestSuite) TestEnableInventory() {
+	for _, enabled := range []bool{false, true} {
+		suite.Run(strconv.FormatBool(enabled), func() {
+			var (
+				ih InventoryHandler
+
+				app = fxtest.New(
+					suite.T(),
+					fx.Supply(
+						Config{
+							EnableInventory: enabled,
+						},
+					),
+					touchstone.Provide(),
+					Provide(),
+					fx.Populate(&ih),
+				)
+			)
+
+			suite.NoError(app.Err())
+			app.RequireStart()
+
+			response := httptest.NewRecorder()
+			ih.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/metrics/inventory", nil))
+			if enabled {
+				suite.Equal(http.StatusOK, response.Code)
+				suite.Equal(JSONContentType, response.Header().Get("Content-Type"))
+			} else {
+				suite.Equal(http.StatusNotFound, response.Code)
+			}
+
+			app.RequireStop()
+		})
+	}
+}
+
+func (suite *ProvideTestSuite) TestEnableHealthCheck() {
+	for _, enabled := range []bool{false, true} {
+		suite.Run(strconv.FormatBool(enabled), func() {
+			var (
+				hh HealthHandler
+
+				app = fxtest.New(
+					suite.T(),
+					fx.Supply(
+						Config{
+							EnableHealthCheck:  enabled,
+							HealthCheckTimeout: time.Second,
+						},
+					),
+					touchstone.Provide(),
+					Provide(),
+					fx.Populate(&hh),
+				)
+			)
+
+			suite.NoError(app.Err())
+			app.RequireStart()
+
+			response := httptest.NewRecorder()
+			hh.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/health", nil))
+			if enabled {
+				suite.Equal(http.StatusOK, response.Code)
+			} else {
+				suite.Equal(http.StatusNotFound, response.Code)
+			}
+
+			app.RequireStop()
+		})
+	}
+}
+
+func (suite *ProvideTestSuite) TestCompression() {
+	var (
+		h Handler
+
+		app = fxtest.New(
+			suite.T(),
+			fx.Supply(
+				Config{
+					Compression: Compression{
+						Encodings: []string{EncodingZstd},
+						ZstdLevel: 1,
+					},
+				},
+			),
+			touchstone.Provide(),
+			Provide(),
+			fx.Populate(&h),
+		)
+	)
+
+	suite.NoError(app.Err())
+	app.RequireStart()
+
+	for _, acceptEncoding := range []string{"gzip", "zstd"} {
+		request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+		response := httptest.NewRecorder()
+		h.ServeHTTP(response, request)
+		suite.Equal(http.StatusOK, response.Code)
+
+		if acceptEncoding == EncodingZstd {
+			suite.Equal(EncodingZstd, response.Header().Get("Content-Encoding"))
+		} else {
+			suite.Empty(response.Header().Get("Content-Encoding"), "gzip was not offered")
+		}
+	}
+
+	app.RequireStop()
+}
+
+func (suite *ProvideTestSuite) TestEnablePayloadMetrics() {
+	var (
+		h Handler
+		g prometheus.Gatherer
+
+		app = fxtest.New(
+			suite.T(),
+			fx.Supply(
+				Config{
+					EnablePayloadMetrics: true,
+				},
+			),
+			touchstone.Provide(),
+			Provide(),
+			fx.Populate(&h, &g),
+		)
+	)
+
+	suite.NoError(app.Err())
+	app.RequireStart()
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	response := httptest.NewRecorder()
+	h.ServeHTTP(response, request)
+	suite.Equal(http.StatusOK, response.Code)
+	suite.Equal(EncodingGzip, response.Header().Get("Content-Encoding"))
+
+	compressed := response.Body.Len()
+	response = httptest.NewRecorder()
+	h.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	suite.Equal(http.StatusOK, response.Code)
+	suite.Contains(response.Body.String(), DefaultPayloadSeries)
+
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+
+	var size float64
+	for _, mf := range mfs {
+		if mf.GetName() == DefaultPayloadSize {
+			size = mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+
+	suite.Equal(float64(response.Body.Len()), size)
+	suite.Greater(size, float64(compressed), "the size should be uncompressed")
+
+	app.RequireStop()
+}
+
+func (suite *ProvideTestSuite) TestLogger() {
+	var (
+		core, logs = observer.New(zapcore.DebugLevel)
+
+		ho promhttp.HandlerOpts
+		f  touchstone.MetricFactory
+
+		app = fxtest.New(
+			suite.T(),
+			fx.Supply(zap.New(core)),
+			touchstone.Provide(),
+			Provide(),
+			fx.Populate(&ho, &f),
+		)
+	)
+
+	suite.NoError(app.Err())
+	app.RequireStart()
+
+	suite.Require().IsType(ErrorLogger{}, ho.ErrorLog)
+	ho.ErrorLog.Println("error gathering metrics:", 2, "errors")
+
+	_, err := f.NewCounter(prometheus.CounterOpts{Name: "no_help"})
+	suite.Require().NoError(err)
+
+	// the handler and the Factory log to the same sink
+	entries := logs.AllUntimed()
+	suite.Require().Len(entries, 2)
+	suite.Equal(zapcore.ErrorLevel, entries[0].Level)
+	suite.Equal("error gathering metrics: 2 errors", entries[0].Message)
+	suite.Equal(zapcore.WarnLevel, entries[1].Level)
+
+	app.RequireStop()
+}
+
 func TestProvide(t *testing.T) {
 	suite.Run(t, new(ProvideTestSuite))
 }
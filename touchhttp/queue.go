@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+)
+
+const (
+	// DefaultServerQueueDuration is the default name of the optional observer that
+	// records the time requests spent queued upstream, e.g. in a load balancer.
+	DefaultServerQueueDuration = "server_queue_duration_ms"
+
+	// RequestStartHeader is the conventional header that proxies such as nginx and
+	// HAProxy use to convey when they first received a request.
+	RequestStartHeader = "X-Request-Start"
+)
+
+var (
+	// ErrInvalidQueueHeader indicates that a queue time header could not be parsed.
+	ErrInvalidQueueHeader = errors.New("Invalid queue time header")
+
+	defaultServerQueueDuration = prometheus.HistogramOpts{
+		Name:    DefaultServerQueueDuration,
+		Help:    "the time in milliseconds that requests spent upstream before being handled",
+		Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000},
+	}
+)
+
+// QueueTimeParser computes how long a request was queued from the value of a header.
+// The now parameter is the time the server began handling the request.
+type QueueTimeParser func(value string, now time.Time) (time.Duration, error)
+
+// ParseRequestStart is a QueueTimeParser for headers holding the time a request
+// was first received upstream, such as RequestStartHeader.  The value may be
+// prefixed by "t=" and is a unix timestamp in seconds, milliseconds, or microseconds.
+// The unit is inferred from the magnitude of the timestamp, as different proxies
+// use different units.
+func ParseRequestStart(value string, now time.Time) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "t=")
+	ts, err := strconv.ParseFloat(value, 64)
+	if err != nil || ts <= 0 {
+		return 0, ErrInvalidQueueHeader
+	}
+
+	var nanos float64
+	switch {
+	case ts >= 1e15: // microseconds
+		nanos = ts * float64(time.Microsecond)
+
+	case ts >= 1e12: // milliseconds
+		nanos = ts * float64(time.Millisecond)
+
+	default: // seconds
+		nanos = ts * float64(time.Second)
+	}
+
+	return now.Sub(time.Unix(0, int64(nanos))), nil
+}
+
+// ParseQueueMilliseconds is a QueueTimeParser for headers that directly hold the
+// number of milliseconds a request was queued.
+func ParseQueueMilliseconds(value string, _ time.Time) (time.Duration, error) {
+	ms, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || ms < 0 {
+		return 0, ErrInvalidQueueHeader
+	}
+
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
+
+// QueueTime describes the optional recording of how long requests spent upstream,
+// e.g. in load balancers and proxies, before reaching a server.  This time is
+// invisible to the server's own duration metric.
+//
+// The observer is labeled with any extra labels plus the MethodLabel.  Requests
+// without the header, or with an unparseable header, are not observed.  Negative
+// queue times, which can result from clock skew, are recorded as zero.
+type QueueTime struct {
+	// Header is the request header holding queue information.  If unset,
+	// RequestStartHeader is used.
+	Header string
+
+	// Parser computes the queue time from the header value.  If unset,
+	// ParseRequestStart is used.
+	Parser QueueTimeParser
+
+	// Duration describes the options for the queue duration observer.  If set, it must
+	// be either a prometheus.HistogramOpts or a prometheus.SummaryOpts.
+	Duration interface{}
+}
+
+// queueTime is the runtime strategy for observing queue durations.
+type queueTime struct {
+	header   string
+	parser   QueueTimeParser
+	duration prometheus.ObserverVec
+	methods  methodSet
+}
+
+// new creates the queue duration observer for a server.
+func (qt QueueTime) new(f touchstone.MetricFactory, extraNames []string, curry prometheus.Labels) (*queueTime, error) {
+	var opts interface{}
+	switch t := qt.Duration.(type) {
+	case nil:
+		clone := defaultServerQueueDuration
+		opts = clone
+
+	case prometheus.HistogramOpts:
+		touchstone.ApplyDefaults(&t, defaultServerQueueDuration)
+		opts = t
+
+	case prometheus.SummaryOpts:
+		touchstone.ApplyDefaults(&t, defaultServerQueueDuration)
+		opts = t
+
+	default:
+		return nil, errors.New("QueueTime.Duration must be nil, a prometheus.HistogramOpts, or a prometheus.SummaryOpts")
+	}
+
+	labelNames := make([]string, 0, len(extraNames)+1)
+	labelNames = append(labelNames, extraNames...)
+	labelNames = append(labelNames, MethodLabel)
+
+	duration, err := newObserverVec(f, opts, labelNames, curry)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &queueTime{
+		header:   qt.Header,
+		parser:   qt.Parser,
+		duration: duration,
+	}
+
+	if len(q.header) == 0 {
+		q.header = RequestStartHeader
+	}
+
+	if q.parser == nil {
+		q.parser = ParseRequestStart
+	}
+
+	return q, nil
+}
+
+// observe records the queue time of a request, if its header is present and valid.
+func (q *queueTime) observe(r *http.Request, now time.Time) {
+	value := r.Header.Get(q.header)
+	if len(value) == 0 {
+		return
+	}
+
+	d, err := q.parser(value, now)
+	if err != nil {
+		return
+	}
+
+	if d < 0 {
+		d = 0
+	}
+
+	// the vector is curried with any extra labels, leaving only the method
+	q.duration.WithLabelValues(q.methods.format(r.Method)).Observe(
+		float64(d) / float64(time.Millisecond),
+	)
+}
@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+)
+
+type QueueTimeSuite struct {
+	BundleSuite
+}
+
+// sampleSum returns the sum of the observations for a method.
+func (suite *QueueTimeSuite) sampleSum(ov prometheus.ObserverVec, method string) (float64, uint64) {
+	var m dto.Metric
+	suite.Require().NoError(ov.WithLabelValues(method).(prometheus.Metric).Write(&m))
+	if m.Histogram != nil {
+		return m.Histogram.GetSampleSum(), m.Histogram.GetSampleCount()
+	}
+
+	return m.Summary.GetSampleSum(), m.Summary.GetSampleCount()
+}
+
+func (suite *QueueTimeSuite) TestParseRequestStart() {
+	start := time.Unix(1700000000, 0)
+	now := start.Add(150 * time.Millisecond)
+
+	for _, value := range []string{
+		"t=1700000000",
+		"1700000000.000",
+		"t=1700000000000",
+		"t=1700000000000000",
+	} {
+		suite.Run(value, func() {
+			d, err := ParseRequestStart(value, now)
+			suite.Require().NoError(err)
+			suite.InDelta(150*time.Millisecond, d, float64(time.Millisecond))
+		})
+	}
+
+	for _, value := range []string{"", "t=", "t=abc", "-1"} {
+		_, err := ParseRequestStart(value, now)
+		suite.ErrorIs(err, ErrInvalidQueueHeader)
+	}
+}
+
+func (suite *QueueTimeSuite) TestParseQueueMilliseconds() {
+	d, err := ParseQueueMilliseconds(" 12.5 ", time.Time{})
+	suite.Require().NoError(err)
+	suite.Equal(12500*time.Microsecond, d)
+
+	_, err = ParseQueueMilliseconds("-3", time.Time{})
+	suite.ErrorIs(err, ErrInvalidQueueHeader)
+
+	_, err = ParseQueueMilliseconds("abc", time.Time{})
+	suite.ErrorIs(err, ErrInvalidQueueHeader)
+}
+
+func (suite *QueueTimeSuite) TestServer() {
+	si, err := ServerBundle{
+		QueueTime: &QueueTime{},
+		Clock:     suite.clock(time.Millisecond),
+	}.NewInstrumenter(ServerLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(si.queueTime)
+
+	h := si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	serve := func(value string) {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if len(value) > 0 {
+			request.Header.Set(RequestStartHeader, value)
+		}
+
+		h.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	queued := suite.now.Add(-250 * time.Millisecond)
+	serve("t=" + strconv.FormatInt(queued.UnixMicro(), 10))
+	serve("")                                                                 // not observed
+	serve("this is not valid")                                                // not observed
+	serve("t=" + strconv.FormatInt(suite.now.Add(time.Hour).UnixMilli(), 10)) // clock skew
+
+	sum, count := suite.sampleSum(si.queueTime.duration, http.MethodGet)
+	suite.Equal(uint64(2), count)
+	suite.InDelta(250.0, sum, 1.0)
+}
+
+func (suite *QueueTimeSuite) TestCustom() {
+	si, err := ServerBundle{
+		QueueTime: &QueueTime{
+			Header:   "X-Queue-Time-Ms",
+			Parser:   ParseQueueMilliseconds,
+			Duration: prometheus.SummaryOpts{Name: "custom_queue"},
+		},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+
+	h := si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	request := httptest.NewRequest(http.MethodPost, "/", nil)
+	request.Header.Set("X-Queue-Time-Ms", "42")
+	h.ServeHTTP(httptest.NewRecorder(), request)
+
+	sum, count := suite.sampleSum(si.queueTime.duration, http.MethodPost)
+	suite.Equal(uint64(1), count)
+	suite.Equal(42.0, sum)
+	suite.Contains(si.queueTime.duration.WithLabelValues(http.MethodPost).(prometheus.Metric).Desc().String(), `"custom_queue"`)
+
+	suite.Run("InvalidDuration", func() {
+		_, err := ServerBundle{
+			QueueTime: &QueueTime{Duration: prometheus.GaugeOpts{}},
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.Error(err)
+	})
+}
+
+func TestQueueTime(t *testing.T) {
+	suite.Run(t, new(QueueTimeSuite))
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+
+	"github.com/xmidt-org/httpaux/observe"
+	"github.com/xmidt-org/touchstone"
+)
+
+const (
+	// DefaultRecoverySource is the touchstone.SourceLabel value used by a Recovery
+	// with no Source.
+	DefaultRecoverySource = "http_server"
+)
+
+// Recovery is a server middleware that recovers panics from handlers.  Each panic is
+// recorded with a touchstone.Panics, and if no response has been written, the
+// handler responds with http.StatusInternalServerError.
+//
+// http.ErrAbortHandler is not recovered, as net/http uses that panic to abort responses.
+//
+// When used with a ServerInstrumenter, the instrumenter should decorate the Recovery
+// so that recovered requests are recorded with their final status code:
+//
+//	h := si.Then(touchhttp.Recovery{}.Then(handler))
+type Recovery struct {
+	// Source is the touchstone.SourceLabel value for recovered panics.  If unset,
+	// DefaultRecoverySource is used.
+	Source string
+
+	// Panics records the recovered panics.  If unset, the process-wide
+	// touchstone.DefaultPanics is used.  See touchstone.InvokePanics.
+	Panics *touchstone.Panics
+}
+
+// Then is a server middleware that recovers panics from the given handler.  This
+// middleware is compatible with justinas/alice and gorilla/mux.
+func (rc Recovery) Then(next http.Handler) http.Handler {
+	source := rc.Source
+	if len(source) == 0 {
+		source = DefaultRecoverySource
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		w := observe.New(rw)
+		defer func() {
+			v := recover()
+			switch {
+			case v == nil:
+				return
+
+			case v == http.ErrAbortHandler: //nolint:errorlint
+				panic(v)
+			}
+
+			p := rc.Panics
+			if p == nil {
+				p = touchstone.DefaultPanics()
+			}
+
+			p.Record(source, v)
+			if w.StatusCode() == 0 {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+)
+
+type RecoverySuite struct {
+	BundleSuite
+}
+
+func (suite *RecoverySuite) TearDownTest() {
+	touchstone.SetDefaultPanics(nil)
+}
+
+func (suite *RecoverySuite) newPanics(f touchstone.MetricFactory) (*touchstone.Panics, *[]string) {
+	sources := new([]string)
+	p, err := touchstone.NewPanics(f, func(source string, _ interface{}) {
+		*sources = append(*sources, source)
+	})
+
+	suite.Require().NoError(err)
+	return p, sources
+}
+
+func (suite *RecoverySuite) serve(h http.Handler) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	h.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+	return response
+}
+
+func (suite *RecoverySuite) TestPanic() {
+	f := suite.newFactory()
+	p, sources := suite.newPanics(f)
+	si, err := ServerBundle{}.NewInstrumenter()(f)
+	suite.Require().NoError(err)
+
+	h := si.Then(Recovery{Source: "main", Panics: p}.Then(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("expected")
+		}),
+	))
+
+	suite.Equal(http.StatusInternalServerError, suite.serve(h).Code)
+	suite.Equal([]string{"main"}, *sources)
+
+	// the instrumenter records the status written by the recovery
+	v, err := touchstone.Value(si.count, prometheus.Labels{CodeLabel: "500"})
+	suite.NoError(err)
+	suite.Equal(1.0, v)
+}
+
+func (suite *RecoverySuite) TestPanicAfterWrite() {
+	p, sources := suite.newPanics(suite.newFactory())
+	h := Recovery{Panics: p}.Then(
+		http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			rw.WriteHeader(http.StatusAccepted)
+			panic("expected")
+		}),
+	)
+
+	suite.Equal(http.StatusAccepted, suite.serve(h).Code)
+	suite.Equal([]string{DefaultRecoverySource}, *sources)
+}
+
+func (suite *RecoverySuite) TestNoPanic() {
+	p, sources := suite.newPanics(suite.newFactory())
+	h := Recovery{Panics: p}.Then(
+		http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			rw.WriteHeader(http.StatusNoContent)
+		}),
+	)
+
+	suite.Equal(http.StatusNoContent, suite.serve(h).Code)
+	suite.Empty(*sources)
+}
+
+func (suite *RecoverySuite) TestAbortHandler() {
+	p, sources := suite.newPanics(suite.newFactory())
+	h := Recovery{Panics: p}.Then(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(http.ErrAbortHandler)
+		}),
+	)
+
+	suite.PanicsWithValue(http.ErrAbortHandler, func() { suite.serve(h) })
+	suite.Empty(*sources)
+}
+
+func (suite *RecoverySuite) TestDefaultPanics() {
+	p, sources := suite.newPanics(suite.newFactory())
+	touchstone.SetDefaultPanics(p)
+
+	h := Recovery{}.Then(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("expected")
+		}),
+	)
+
+	suite.Equal(http.StatusInternalServerError, suite.serve(h).Code)
+	suite.Equal([]string{DefaultRecoverySource}, *sources)
+}
+
+func TestRecovery(t *testing.T) {
+	suite.Run(t, new(RecoverySuite))
+}
@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+)
+
+const (
+	// DefaultServerResponseSize is the default name of the optional observer that
+	// records the sizes of response bodies written by handlers.
+	DefaultServerResponseSize = "server_response_size"
+
+	// DefaultServerSuperfluousWriteHeaderCount is the default name of the optional
+	// counter of transactions whose handlers called WriteHeader after the final response header.
+	DefaultServerSuperfluousWriteHeaderCount = "server_superfluous_write_header_count"
+)
+
+var (
+	defaultServerResponseSize = prometheus.HistogramOpts{
+		Name: DefaultServerResponseSize,
+		Help: "the size of response bodies in bytes",
+	}
+
+	defaultServerSuperfluousWriteHeaderCount = prometheus.CounterOpts{
+		Name: DefaultServerSuperfluousWriteHeaderCount,
+		Help: "the total number of requests whose handlers called WriteHeader after the final response header",
+	}
+)
+
+// Responses describes the optional accounting of what handlers write.  Every
+// transaction observes a response size, including those whose handlers never
+// wrote anything, e.g. 204 responses or handlers that returned early because
+// a request was canceled.  Those are observed with a size of zero.
+//
+// Transactions whose handlers called WriteHeader after the final response header was
+// written, whether explicitly or implicitly through Write, are also counted.  net/http
+// ignores those calls, so these are always bugs.  Informational 1xx responses written
+// before the final header, e.g. 103 Early Hints, are legitimate and are not counted.
+// Counted transactions are recorded with the status code of the first call.
+//
+// Both metrics have the same labels as the duration metric.  Counting WriteHeader
+// calls requires decorating the http.ResponseWriter, which does not preserve
+// http.Pusher.
+type Responses struct {
+	// Size describes the options for the response size observer.  If set, it
+	// must be either a prometheus.HistogramOpts or a prometheus.SummaryOpts.
+	Size interface{}
+
+	// SuperfluousWriteHeader describes the options for the counter of transactions
+	// that called WriteHeader again after writing the final response header.  Informational
+	// 1xx responses, e.g. 103 Early Hints, written before the final header are not counted.
+	SuperfluousWriteHeader prometheus.CounterOpts
+}
+
+// newResponseSize creates the response size observer for a server.
+func (rs Responses) newResponseSize(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+	var opts interface{}
+	switch t := rs.Size.(type) {
+	case nil:
+		clone := defaultServerResponseSize
+		opts = clone
+
+	case prometheus.HistogramOpts:
+		touchstone.ApplyDefaults(&t, defaultServerResponseSize)
+		opts = t
+
+	case prometheus.SummaryOpts:
+		touchstone.ApplyDefaults(&t, defaultServerResponseSize)
+		opts = t
+
+	default:
+		return nil, errors.New("Responses.Size must be nil, a prometheus.HistogramOpts, or a prometheus.SummaryOpts")
+	}
+
+	return newObserverVec(f, opts, labelNames, curry)
+}
+
+// newSuperfluousWriteHeaderCount creates the counter of superfluous WriteHeader calls.
+func (rs Responses) newSuperfluousWriteHeaderCount(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
+	touchstone.ApplyDefaults(&rs.SuperfluousWriteHeader, defaultServerSuperfluousWriteHeaderCount)
+	return newCounterVec(f, rs.SuperfluousWriteHeader, labelNames, curry)
+}
+
+// headerCounter counts the calls to WriteHeader that reach the underlying
+// http.ResponseWriter.  It sits beneath the observe.Writer, which always
+// calls WriteHeader before the first Write, Flush, or ReadFrom.
+type headerCounter struct {
+	http.ResponseWriter
+	final bool
+	extra int
+}
+
+// informational tests if a status code is a 1xx response that, like 103 Early Hints,
+// may precede the final response.  101 Switching Protocols is itself a final response.
+func informational(statusCode int) bool {
+	return statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols
+}
+
+func (hc *headerCounter) WriteHeader(statusCode int) {
+	switch {
+	case hc.final:
+		hc.extra++
+
+	case !informational(statusCode):
+		hc.final = true
+	}
+
+	hc.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Unwrap allows http.ResponseController to reach the original http.ResponseWriter.
+func (hc *headerCounter) Unwrap() http.ResponseWriter {
+	return hc.ResponseWriter
+}
+
+// superfluous tests if WriteHeader was called again after the final response header.
+// Informational responses written before the final header are not superfluous.
+func (hc *headerCounter) superfluous() bool {
+	return hc.extra > 0
+}
+
+// countHeaders decorates a response writer so that its WriteHeader calls are counted.
+// The returned http.ResponseWriter implements the same http.Flusher, http.Hijacker, and
+// io.ReaderFrom interfaces as rw, so that an observe.Writer decorating it does as well.
+// HTTP/2 server push is not preserved, as browsers no longer support it.
+func countHeaders(rw http.ResponseWriter) (http.ResponseWriter, *headerCounter) {
+	hc := &headerCounter{ResponseWriter: rw}
+	f, isFlusher := rw.(http.Flusher)
+	h, isHijacker := rw.(http.Hijacker)
+	rf, isReaderFrom := rw.(io.ReaderFrom)
+
+	switch {
+	case isFlusher && isHijacker && isReaderFrom:
+		return struct {
+			*headerCounter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{hc, f, h, rf}, hc
+
+	case isFlusher && isHijacker:
+		return struct {
+			*headerCounter
+			http.Flusher
+			http.Hijacker
+		}{hc, f, h}, hc
+
+	case isFlusher && isReaderFrom:
+		return struct {
+			*headerCounter
+			http.Flusher
+			io.ReaderFrom
+		}{hc, f, rf}, hc
+
+	case isHijacker && isReaderFrom:
+		return struct {
+			*headerCounter
+			http.Hijacker
+			io.ReaderFrom
+		}{hc, h, rf}, hc
+
+	case isFlusher:
+		return struct {
+			*headerCounter
+			http.Flusher
+		}{hc, f}, hc
+
+	case isHijacker:
+		return struct {
+			*headerCounter
+			http.Hijacker
+		}{hc, h}, hc
+
+	case isReaderFrom:
+		return struct {
+			*headerCounter
+			io.ReaderFrom
+		}{hc, rf}, hc
+
+	default:
+		return hc, hc
+	}
+}
@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+)
+
+type ResponseSuite struct {
+	BundleSuite
+}
+
+// hijackingWriter is an http.ResponseWriter that also implements http.Hijacker.
+type hijackingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (hijackingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func (suite *ResponseSuite) TestCountHeaders() {
+	suite.Run("Flusher", func() {
+		rw, hc := countHeaders(httptest.NewRecorder())
+		suite.Implements((*http.Flusher)(nil), rw)
+		suite.NotImplements((*http.Hijacker)(nil), rw)
+		suite.NotImplements((*io.ReaderFrom)(nil), rw)
+
+		rw.WriteHeader(http.StatusAccepted)
+		suite.False(hc.superfluous())
+		rw.WriteHeader(http.StatusOK)
+		suite.True(hc.superfluous())
+		suite.Equal(http.StatusAccepted, hc.Unwrap().(*httptest.ResponseRecorder).Code)
+		suite.Implements((*interface{ Unwrap() http.ResponseWriter })(nil), rw)
+	})
+
+	suite.Run("Informational", func() {
+		rw, hc := countHeaders(httptest.NewRecorder())
+		rw.WriteHeader(http.StatusContinue)
+		rw.WriteHeader(http.StatusEarlyHints)
+		suite.False(hc.superfluous())
+		rw.WriteHeader(http.StatusOK)
+		suite.False(hc.superfluous())
+		rw.WriteHeader(http.StatusEarlyHints)
+		suite.True(hc.superfluous())
+	})
+
+	suite.Run("SwitchingProtocols", func() {
+		rw, hc := countHeaders(httptest.NewRecorder())
+		rw.WriteHeader(http.StatusSwitchingProtocols)
+		suite.False(hc.superfluous())
+		rw.WriteHeader(http.StatusOK)
+		suite.True(hc.superfluous())
+	})
+
+	suite.Run("FlusherHijacker", func() {
+		rw, _ := countHeaders(hijackingWriter{httptest.NewRecorder()})
+		suite.Implements((*http.Flusher)(nil), rw)
+		suite.Implements((*http.Hijacker)(nil), rw)
+		suite.NotImplements((*io.ReaderFrom)(nil), rw)
+	})
+
+	suite.Run("Plain", func() {
+		rw, hc := countHeaders(struct{ http.ResponseWriter }{httptest.NewRecorder()})
+		suite.Same(hc, rw)
+		suite.NotImplements((*http.Flusher)(nil), rw)
+	})
+}
+
+func (suite *ResponseSuite) TestResponses() {
+	var observations []Observation
+	si, err := ServerBundle{
+		Responses: &Responses{},
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(si.responseSize)
+	suite.Require().NotNil(si.superfluousWriteCount)
+
+	h := si.Then(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/noContent":
+			rw.WriteHeader(http.StatusNoContent)
+
+		case "/body":
+			_, err := rw.Write([]byte("hello, world"))
+			suite.NoError(err)
+
+		case "/twice":
+			rw.WriteHeader(http.StatusAccepted)
+			rw.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	for _, path := range []string{"/nothing", "/noContent", "/body", "/twice"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	suite.Require().Len(observations, 4)
+
+	suite.Run("Nothing", func() {
+		suite.Equal(http.StatusOK, observations[0].Code)
+		suite.Zero(observations[0].ResponseSize)
+	})
+
+	suite.Run("NoContent", func() {
+		suite.Equal(http.StatusNoContent, observations[1].Code)
+		suite.Zero(observations[1].ResponseSize)
+
+		hd, err := touchstone.HistogramValue(si.responseSize, prometheus.Labels{CodeLabel: strconv.Itoa(http.StatusNoContent)})
+		suite.Require().NoError(err)
+		suite.Equal(uint64(1), hd.Count)
+		suite.Zero(hd.Sum)
+	})
+
+	suite.Run("Body", func() {
+		suite.Equal(http.StatusOK, observations[2].Code)
+		suite.Equal(int64(12), observations[2].ResponseSize)
+
+		// the "/nothing" transaction is also a 200 with a zero size
+		hd, err := touchstone.HistogramValue(si.responseSize, prometheus.Labels{CodeLabel: strconv.Itoa(http.StatusOK)})
+		suite.Require().NoError(err)
+		suite.Equal(uint64(2), hd.Count)
+		suite.Equal(12.0, hd.Sum)
+	})
+
+	suite.Run("Twice", func() {
+		suite.Equal(http.StatusAccepted, observations[3].Code)
+
+		v, err := touchstone.Value(si.superfluousWriteCount, prometheus.Labels{CodeLabel: strconv.Itoa(http.StatusAccepted)})
+		suite.Require().NoError(err)
+		suite.Equal(1.0, v)
+
+		_, err = touchstone.Value(si.superfluousWriteCount, prometheus.Labels{CodeLabel: strconv.Itoa(http.StatusOK)})
+		suite.ErrorIs(err, touchstone.ErrNoSuchSeries)
+	})
+
+	suite.Run("Summary", func() {
+		si, err := ServerBundle{
+			Responses: &Responses{Size: prometheus.SummaryOpts{Name: "custom_response_size"}},
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.Require().NoError(err)
+		suite.Require().NotNil(si.responseSize)
+	})
+
+	suite.Run("InvalidSize", func() {
+		_, err := ServerBundle{
+			Responses: &Responses{Size: prometheus.GaugeOpts{}},
+		}.NewInstrumenter()(suite.newFactory())
+
+		suite.Error(err)
+	})
+}
+
+func (suite *ResponseSuite) TestPanicWithoutResponse() {
+	var observations []Observation
+	si, err := ServerBundle{
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+	h := si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("expected")
+	}))
+
+	suite.Panics(func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	suite.Require().Len(observations, 1)
+	suite.Zero(observations[0].Code)
+}
+
+func TestResponse(t *testing.T) {
+	suite.Run(t, new(ResponseSuite))
+}
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/httpaux/retry"
+)
+
+const (
+	// DefaultClientRetryCount is the default name of the optional counter that tracks
+	// the number of retries made by an httpaux retry.Client.
+	DefaultClientRetryCount = "client_retry_count"
+)
+
+var defaultClientRetryCount = prometheus.CounterOpts{
+	Name: DefaultClientRetryCount,
+	Help: "the total number of retried requests sent since startup",
+}
+
+// attempt returns the 0-based retry attempt of a client transaction, as reported
+// by an enclosing httpaux retry.Client.  If there is no such client, this function
+// returns zero.
+func attempt(ctx context.Context) int {
+	if s := retry.GetState(ctx); s != nil {
+		return s.Attempt()
+	}
+
+	return 0
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/httpaux/retry"
+	"github.com/xmidt-org/touchstone"
+)
+
+type RetrySuite struct {
+	BundleSuite
+}
+
+// immediateTimer is a retry.Timer that never waits.
+func immediateTimer(time.Duration) (<-chan time.Time, func() bool) {
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch, func() bool { return true }
+}
+
+func (suite *RetrySuite) TestRetryCount() {
+	var observations []Observation
+	ci, err := ClientBundle{
+		RetryCount: &prometheus.CounterOpts{},
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+	}.NewInstrumenter(ClientLabel, "test")(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(ci.retryCount)
+
+	// fail twice, then succeed
+	var calls int
+	transport := &http.Client{
+		Transport: clientTransport(func(*http.Request) (*http.Response, error) {
+			calls++
+			if calls < 3 {
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+			}
+
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+	}
+
+	c := retry.New(
+		retry.Config{
+			Retries: 3,
+			Timer:   immediateTimer,
+			Check: func(r *http.Response, err error) bool {
+				return err != nil || r.StatusCode >= 500
+			},
+		},
+		ci.Then(transport),
+	)
+
+	request, err := http.NewRequest(http.MethodPost, "http://localhost/", nil)
+	suite.Require().NoError(err)
+	response, err := c.Do(request)
+	suite.Require().NoError(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+
+	v, err := touchstone.Value(ci.retryCount, prometheus.Labels{ClientLabel: "test", MethodLabel: http.MethodPost})
+	suite.Require().NoError(err)
+	suite.Equal(2.0, v)
+
+	// each attempt is its own transaction
+	v, err = touchstone.Value(ci.count, prometheus.Labels{CodeLabel: strconv.Itoa(http.StatusServiceUnavailable)})
+	suite.Require().NoError(err)
+	suite.Equal(2.0, v)
+
+	suite.Require().Len(observations, 3)
+	for i, o := range observations {
+		suite.Equal(i, o.Attempt)
+	}
+
+	suite.Run("WithoutRetries", func() {
+		observations = nil
+		request, err := http.NewRequest(http.MethodGet, "http://localhost/", nil)
+		suite.Require().NoError(err)
+		response, err := ci.Then(transport).Do(request)
+		suite.Require().NoError(err)
+		response.Body.Close()
+
+		suite.Require().Len(observations, 1)
+		suite.Zero(observations[0].Attempt)
+
+		_, err = touchstone.Value(ci.retryCount, prometheus.Labels{MethodLabel: http.MethodGet})
+		suite.ErrorIs(err, touchstone.ErrNoSuchSeries)
+	})
+
+	suite.Run("Disabled", func() {
+		ci, err := ClientBundle{}.NewInstrumenter()(suite.newFactory())
+		suite.Require().NoError(err)
+		suite.Nil(ci.retryCount)
+	})
+}
+
+func TestRetry(t *testing.T) {
+	suite.Run(t, new(RetrySuite))
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+)
+
+const (
+	// DefaultServerSampledDuration is the default name of the optional observer that
+	// records full resolution durations for a sample of server requests.
+	DefaultServerSampledDuration = "server_request_duration_sampled_ms"
+
+	// DefaultClientSampledDuration is the default name of the optional observer that
+	// records full resolution durations for a sample of client requests.
+	DefaultClientSampledDuration = "client_request_duration_sampled_ms"
+
+	// DefaultSampleRate is the fraction of transactions sampled when no rate is set.
+	DefaultSampleRate = 0.01
+
+	// DefaultNativeHistogramBucketFactor is the bucket growth factor of the native
+	// histogram used for sampled durations when no other options are supplied.
+	DefaultNativeHistogramBucketFactor = 1.1
+)
+
+var (
+	// ErrInvalidSampleRate indicates that a Sampling had a rate that was not
+	// a fraction between 0 and 1.
+	ErrInvalidSampleRate = errors.New("A sample rate must be greater than 0 and no more than 1")
+
+	defaultServerSampledDuration = prometheus.HistogramOpts{
+		Name:                        DefaultServerSampledDuration,
+		Help:                        "the full resolution request duration in milliseconds, for a sample of requests",
+		NativeHistogramBucketFactor: DefaultNativeHistogramBucketFactor,
+	}
+
+	defaultClientSampledDuration = prometheus.HistogramOpts{
+		Name:                        DefaultClientSampledDuration,
+		Help:                        "the full resolution time, in milliseconds, between sending a request and receiving a response, for a sample of requests",
+		NativeHistogramBucketFactor: DefaultNativeHistogramBucketFactor,
+	}
+
+	// defaultSampledObjectives are used for sampled duration summaries, which would
+	// otherwise track no quantiles at all.
+	defaultSampledObjectives = map[float64]float64{
+		0.5:   0.05,
+		0.9:   0.01,
+		0.99:  0.001,
+		0.999: 0.0001,
+	}
+)
+
+// Sampling describes the optional recording of full resolution durations for a
+// sample of transactions.  The regular duration metric keeps its coarse buckets and
+// records every transaction, while the sampled metric provides visibility into the
+// tail latencies of very high volume servers and clients at a fraction of the cost.
+type Sampling struct {
+	// Duration describes the options for the sampled duration observer.  If set, it must
+	// be either a prometheus.HistogramOpts or a prometheus.SummaryOpts.  If unset, a
+	// native histogram is used.
+	//
+	// Histograms default to a NativeHistogramBucketFactor of DefaultNativeHistogramBucketFactor.
+	// Summaries with no Objectives default to tracking the 50th, 90th, 99th, and 99.9th
+	// percentiles.
+	Duration interface{}
+
+	// Rate is the fraction of transactions that are sampled.  If unset, DefaultSampleRate
+	// is used.  A rate of 1 records every transaction.
+	Rate float64
+
+	// Sample is an optional strategy that decides whether a transaction is sampled.
+	// If set, Rate is ignored.  This strategy must be safe for concurrent use.
+	Sample func() bool
+}
+
+// sampler returns the strategy for deciding whether a given transaction is sampled.
+func (s Sampling) sampler() (func() bool, error) {
+	if s.Sample != nil {
+		return s.Sample, nil
+	}
+
+	rate := s.Rate
+	switch {
+	case rate == 0.0:
+		rate = DefaultSampleRate
+
+	case rate < 0.0 || rate > 1.0:
+		return nil, ErrInvalidSampleRate
+	}
+
+	return func() bool {
+		return rand.Float64() < rate //nolint:gosec
+	}, nil
+}
+
+// newObserverVec creates the sampled duration observer along with the sampling strategy.
+func (s Sampling) newObserverVec(f touchstone.MetricFactory, defaults prometheus.HistogramOpts, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, func() bool, error) {
+	sample, err := s.sampler()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var opts interface{}
+	switch t := s.Duration.(type) {
+	case nil:
+		clone := defaults
+		opts = clone
+
+	case prometheus.HistogramOpts:
+		touchstone.ApplyDefaults(&t, defaults)
+		opts = t
+
+	case prometheus.SummaryOpts:
+		touchstone.ApplyDefaults(&t, defaults)
+		if len(t.Objectives) == 0 {
+			t.Objectives = defaultSampledObjectives
+		}
+
+		opts = t
+
+	default:
+		return nil, nil, errors.New("Sampling.Duration must be nil, a prometheus.HistogramOpts, or a prometheus.SummaryOpts")
+	}
+
+	ov, err := newObserverVec(f, opts, labelNames, curry)
+	return ov, sample, err
+}
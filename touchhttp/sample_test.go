@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/httpaux/client"
+)
+
+type SamplingSuite struct {
+	BundleSuite
+}
+
+// everyOther is a Sample strategy that samples every other transaction, starting with the first.
+func (suite *SamplingSuite) everyOther() func() bool {
+	var n int
+	return func() bool {
+		n++
+		return n%2 == 1
+	}
+}
+
+// write extracts the single child of an observer vector.
+func (suite *SamplingSuite) write(ov prometheus.ObserverVec, code, method string) *dto.Metric {
+	o, err := ov.GetMetricWith(prometheus.Labels{CodeLabel: code, MethodLabel: method})
+	suite.Require().NoError(err)
+
+	var m dto.Metric
+	suite.Require().NoError(o.(prometheus.Metric).Write(&m))
+	return &m
+}
+
+func (suite *SamplingSuite) TestSampler() {
+	suite.Run("Default", func() {
+		sample, err := Sampling{}.sampler()
+		suite.NoError(err)
+		suite.NotNil(sample)
+	})
+
+	suite.Run("All", func() {
+		sample, err := Sampling{Rate: 1.0}.sampler()
+		suite.Require().NoError(err)
+		for i := 0; i < 100; i++ {
+			suite.True(sample())
+		}
+	})
+
+	suite.Run("Custom", func() {
+		sample, err := Sampling{Rate: -1.0, Sample: func() bool { return false }}.sampler()
+		suite.Require().NoError(err)
+		suite.False(sample())
+	})
+
+	for _, rate := range []float64{-0.5, 1.5} {
+		_, err := Sampling{Rate: rate}.sampler()
+		suite.ErrorIs(err, ErrInvalidSampleRate)
+	}
+}
+
+func (suite *SamplingSuite) TestServer() {
+	si, err := ServerBundle{
+		Sampling: &Sampling{
+			Sample: suite.everyOther(),
+		},
+		Clock: suite.clock(1500 * time.Microsecond),
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+	suite.Require().NotNil(si.sampledDuration)
+
+	h := si.Then(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {}))
+	for i := 0; i < 4; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	m := suite.write(si.sampledDuration, "200", http.MethodGet)
+	suite.Equal(uint64(2), m.GetHistogram().GetSampleCount())
+	suite.Equal(3.0, m.GetHistogram().GetSampleSum()) // full resolution
+	suite.NotZero(m.GetHistogram().GetSchema())       // a native histogram
+
+	// the coarse histogram still records every transaction, truncated to milliseconds
+	m = suite.write(si.duration, "200", http.MethodGet)
+	suite.Equal(uint64(4), m.GetHistogram().GetSampleCount())
+	suite.Equal(4.0, m.GetHistogram().GetSampleSum())
+}
+
+func (suite *SamplingSuite) TestClientSummary() {
+	ci, err := ClientBundle{
+		Sampling: &Sampling{
+			Duration: prometheus.SummaryOpts{},
+			Rate:     1.0,
+		},
+		Clock: suite.clock(time.Millisecond),
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Require().NoError(err)
+	c := ci.Then(client.Func(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	_, err = c.Do(httptest.NewRequest(http.MethodPost, "/", nil))
+	suite.Require().NoError(err)
+
+	m := suite.write(ci.sampledDuration, "200", http.MethodPost)
+	suite.Equal(uint64(1), m.GetSummary().GetSampleCount())
+	suite.Len(m.GetSummary().GetQuantile(), len(defaultSampledObjectives))
+}
+
+func (suite *SamplingSuite) TestInvalid() {
+	_, err := ServerBundle{
+		Sampling: &Sampling{Duration: prometheus.GaugeOpts{}},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.Error(err)
+
+	_, err = ClientBundle{
+		Sampling: &Sampling{Rate: 2.0},
+	}.NewInstrumenter()(suite.newFactory())
+
+	suite.ErrorIs(err, ErrInvalidSampleRate)
+}
+
+func TestSampling(t *testing.T) {
+	suite.Run(t, new(SamplingSuite))
+}
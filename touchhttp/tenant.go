@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+)
+
+const (
+	// TenantLabel is the metric label containing the tenant of a transaction.  This label
+	// is only used when a bundle has a Tenancy.
+	TenantLabel = "tenant"
+
+	// TenantOther is the TenantLabel value used for any tenant that is neither allowed
+	// nor admitted under the cap, as well as for transactions with no tenant.
+	TenantOther = "other"
+
+	// DefaultServerTenants is the default name of the gauge that tracks the number of
+	// distinct tenants with their own series in server metrics.
+	DefaultServerTenants = "server_tenants"
+
+	// DefaultClientTenants is the default name of the gauge that tracks the number of
+	// distinct tenants with their own series in client metrics.
+	DefaultClientTenants = "client_tenants"
+)
+
+var (
+	// ErrUnboundedTenants indicates that a TenantConfig had neither an allow-list
+	// nor a cap, which would allow unbounded metric cardinality.
+	ErrUnboundedTenants = errors.New("Tenant partitioning requires an allow-list, a cap, or both")
+
+	// ErrNoTenantExtractor indicates that a Tenancy had no Extractor.
+	ErrNoTenantExtractor = errors.New("A tenant extractor is required")
+
+	defaultServerTenants = prometheus.GaugeOpts{
+		Name: DefaultServerTenants,
+		Help: "the number of distinct tenants that have their own series",
+	}
+
+	defaultClientTenants = prometheus.GaugeOpts{
+		Name: DefaultClientTenants,
+		Help: "the number of distinct tenants that have their own series",
+	}
+)
+
+// TenantConfig bounds the set of tenants that receive their own series.  At least
+// one of Allow or Max must be set.
+type TenantConfig struct {
+	// Allow is the set of tenants that always receive their own series.
+	Allow []string `json:"allow" yaml:"allow"`
+
+	// Max is the maximum number of distinct tenants that receive their own series,
+	// including allowed tenants.  A slot is reserved for each allowed tenant, and
+	// tenants that are not allowed are admitted on a first come, first served basis
+	// into the remaining slots.  If unset, or not greater than the number of allowed
+	// tenants, only allowed tenants receive their own series.
+	Max int `json:"max" yaml:"max"`
+}
+
+// Tenants maps tenant names onto metric label values, bounding cardinality.
+// A Tenants is safe for concurrent use.
+type Tenants struct {
+	allow map[string]bool
+
+	// others is the number of slots available to tenants that are not allowed
+	others int
+
+	lock     sync.RWMutex
+	admitted map[string]bool
+	onAdmit  func(int)
+}
+
+// NewTenants creates a Tenants from configuration.
+func NewTenants(cfg TenantConfig) (*Tenants, error) {
+	if len(cfg.Allow) == 0 && cfg.Max <= 0 {
+		return nil, ErrUnboundedTenants
+	}
+
+	t := &Tenants{
+		allow:    make(map[string]bool, len(cfg.Allow)),
+		admitted: make(map[string]bool),
+	}
+
+	for _, tenant := range cfg.Allow {
+		t.allow[tenant] = true
+	}
+
+	if cfg.Max > len(t.allow) {
+		t.others = cfg.Max - len(t.allow)
+	}
+
+	return t, nil
+}
+
+// Partition returns the label value for the given tenant.  Allowed tenants, and
+// tenants admitted under the cap, are returned as is.  All other tenants map to TenantOther.
+func (t *Tenants) Partition(tenant string) string {
+	if len(tenant) == 0 {
+		return TenantOther
+	}
+
+	t.lock.RLock()
+	admitted := t.admitted[tenant]
+	t.lock.RUnlock()
+	if admitted {
+		return tenant
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.admitted[tenant] {
+		// another goroutine admitted this tenant
+		return tenant
+	}
+
+	switch {
+	case t.allow[tenant]:
+		// allowed tenants use their reserved slots
+
+	case t.others > 0:
+		t.others--
+
+	default:
+		return TenantOther
+	}
+
+	t.admitted[tenant] = true
+	if t.onAdmit != nil {
+		t.onAdmit(len(t.admitted))
+	}
+
+	return tenant
+}
+
+// Len returns the number of distinct tenants that have been given their own label value.
+func (t *Tenants) Len() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return len(t.admitted)
+}
+
+// TenantExtractor extracts the tenant from an HTTP request.  An empty
+// tenant maps to TenantOther.
+type TenantExtractor func(*http.Request) string
+
+// TenantFromHeader returns a TenantExtractor that uses the value of an HTTP header.
+func TenantFromHeader(name string) TenantExtractor {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// Tenancy partitions a bundle's metrics by tenant.  When used, the TenantLabel is
+// added to every metric that has the CodeLabel and MethodLabel.
+type Tenancy struct {
+	// Config bounds the tenants that receive their own series.
+	Config TenantConfig
+
+	// Extractor obtains the tenant from each request.  This field is required.
+	Extractor TenantExtractor
+
+	// Tenants describes the options for the gauge that tracks the number of distinct
+	// tenants with their own series.
+	Tenants prometheus.GaugeOpts
+}
+
+// tenancy is the runtime state of a Tenancy within an instrumenter.
+type tenancy struct {
+	extractor TenantExtractor
+	tenants   *Tenants
+}
+
+func (t tenancy) tenant(r *http.Request) string {
+	return t.tenants.Partition(t.extractor(r))
+}
+
+func (ty Tenancy) new(f touchstone.MetricFactory, defaults prometheus.GaugeOpts, labelNames []string, curry prometheus.Labels) (*tenancy, error) {
+	if ty.Extractor == nil {
+		return nil, ErrNoTenantExtractor
+	}
+
+	if _, reserved := curry[TenantLabel]; reserved {
+		return nil, fmt.Errorf("%w: %s", ErrReservedLabelName, TenantLabel)
+	}
+
+	tenants, err := NewTenants(ty.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	o := ty.Tenants
+	touchstone.ApplyDefaults(&o, defaults)
+	g, err := newGauge(f, o, labelNames, curry)
+	if err != nil {
+		return nil, err
+	}
+
+	tenants.onAdmit = func(n int) { g.Set(float64(n)) }
+	return &tenancy{
+		extractor: ty.Extractor,
+		tenants:   tenants,
+	}, nil
+}
+
+// preinitializeLabels produces the label sets to preinitialize, including the TenantLabel.
+// Each set of labels is expanded for every allowed tenant and TenantOther.
+func (ty Tenancy) preinitializeLabels(ls []Labels) []prometheus.Labels {
+	tenants := append(append([]string{}, ty.Config.Allow...), TenantOther)
+	labelSets := make([]prometheus.Labels, 0, len(ls)*len(tenants))
+	for _, l := range ls {
+		for _, tenant := range tenants {
+			labelSet := make(prometheus.Labels, len(l)+1)
+			for k, v := range l {
+				labelSet[k] = v
+			}
+
+			labelSet[TenantLabel] = tenant
+			labelSets = append(labelSets, labelSet)
+		}
+	}
+
+	return labelSets
+}
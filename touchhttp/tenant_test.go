@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/httpaux/client"
+)
+
+const tenantHeader = "X-Tenant"
+
+type TenantsSuite struct {
+	BundleSuite
+}
+
+func (suite *TenantsSuite) TestNewTenants() {
+	t, err := NewTenants(TenantConfig{})
+	suite.ErrorIs(err, ErrUnboundedTenants)
+	suite.Nil(t)
+}
+
+func (suite *TenantsSuite) TestAllowOnly() {
+	t, err := NewTenants(TenantConfig{Allow: []string{"a", "b"}})
+	suite.Require().NoError(err)
+
+	suite.Equal("a", t.Partition("a"))
+	suite.Equal("a", t.Partition("a"))
+	suite.Equal(TenantOther, t.Partition("c"))
+	suite.Equal(TenantOther, t.Partition(""))
+	suite.Equal(1, t.Len())
+
+	suite.Equal("b", t.Partition("b"))
+	suite.Equal(2, t.Len())
+}
+
+func (suite *TenantsSuite) TestCap() {
+	t, err := NewTenants(TenantConfig{Allow: []string{"a", "b"}, Max: 3})
+	suite.Require().NoError(err)
+
+	// one slot is left over after reserving the allowed tenants
+	suite.Equal("x", t.Partition("x"))
+	suite.Equal(TenantOther, t.Partition("y"))
+	suite.Equal("x", t.Partition("x"))
+
+	// allowed tenants always get their reserved slots
+	suite.Equal("a", t.Partition("a"))
+	suite.Equal("b", t.Partition("b"))
+	suite.Equal(TenantOther, t.Partition("z"))
+	suite.Equal(3, t.Len())
+
+	suite.Run("MaxNotAboveAllow", func() {
+		t, err := NewTenants(TenantConfig{Allow: []string{"a", "b"}, Max: 1})
+		suite.Require().NoError(err)
+		suite.Equal(TenantOther, t.Partition("x"))
+		suite.Equal("a", t.Partition("a"))
+		suite.Equal("b", t.Partition("b"))
+		suite.Equal(2, t.Len())
+	})
+}
+
+func (suite *TenantsSuite) TestTenantFromHeader() {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(tenantHeader, "test")
+	suite.Equal("test", TenantFromHeader(tenantHeader)(r))
+}
+
+func (suite *TenantsSuite) TestServer() {
+	var observations []Observation
+	si, err := ServerBundle{
+		Tenancy: &Tenancy{
+			Config:    TenantConfig{Allow: []string{"a"}, Max: 2},
+			Extractor: TenantFromHeader(tenantHeader),
+		},
+		Hooks: []Hook{
+			func(o Observation) { observations = append(observations, o) },
+		},
+	}.NewInstrumenter(ServerLabel, "main")(suite.newFactory())
+
+	suite.Require().NoError(err)
+	h := si.Then(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	for _, tenant := range []string{"a", "b", "c", "a", ""} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(tenantHeader, tenant)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	count := func(tenant string) float64 {
+		return testutil.ToFloat64(si.count.With(prometheus.Labels{
+			CodeLabel:   "200",
+			MethodLabel: http.MethodGet,
+			TenantLabel: tenant,
+		}))
+	}
+
+	suite.Equal(2.0, count("a"))
+	suite.Equal(1.0, count("b"))
+	suite.Equal(2.0, count(TenantOther))
+	suite.Equal(3, testutil.CollectAndCount(si.duration))
+	suite.Equal(2, si.tenancy.tenants.Len())
+
+	suite.Require().Len(observations, 5)
+	suite.Equal(TenantOther, observations[2].Tenant)
+}
+
+func (suite *TenantsSuite) TestClient() {
+	f := suite.newFactory()
+	ci, err := ClientBundle{
+		Tenancy: &Tenancy{
+			Config:    TenantConfig{Max: 1},
+			Extractor: TenantFromHeader(tenantHeader),
+		},
+		Preinitialize: LabelCombinations([]int{http.StatusOK}, []string{http.MethodGet}),
+	}.NewInstrumenter(ClientLabel, "test")(f)
+
+	suite.Require().NoError(err)
+	suite.Equal(1, testutil.CollectAndCount(ci.count)) // preinitialized for TenantOther
+
+	c := ci.Then(client.Func(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(tenantHeader, "a")
+	_, err = c.Do(r)
+	suite.Require().NoError(err)
+	suite.Equal(2, testutil.CollectAndCount(ci.count))
+}
+
+func (suite *TenantsSuite) TestInvalid() {
+	testCases := []struct {
+		description string
+		tenancy     Tenancy
+		labels      []string
+		expected    error
+	}{
+		{
+			description: "NoExtractor",
+			tenancy:     Tenancy{Config: TenantConfig{Max: 1}},
+			expected:    ErrNoTenantExtractor,
+		},
+		{
+			description: "Unbounded",
+			tenancy:     Tenancy{Extractor: TenantFromHeader(tenantHeader)},
+			expected:    ErrUnboundedTenants,
+		},
+		{
+			description: "Reserved",
+			tenancy:     Tenancy{Config: TenantConfig{Max: 1}, Extractor: TenantFromHeader(tenantHeader)},
+			labels:      []string{TenantLabel, "value"},
+			expected:    ErrReservedLabelName,
+		},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.description, func() {
+			tenancy := testCase.tenancy
+			_, err := ServerBundle{Tenancy: &tenancy}.NewInstrumenter(testCase.labels...)(suite.newFactory())
+			suite.ErrorIs(err, testCase.expected)
+		})
+	}
+}
+
+func TestTenants(t *testing.T) {
+	suite.Run(t, new(TenantsSuite))
+}
@@ -0,0 +1,12 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package touchinspect provides utilities for examining the contents of a prometheus
+registry or exposition.  A Snapshot captures gathered metric families, and can report
+series counts, the largest cardinality offenders, lint problems, and differences
+from another Snapshot.
+
+This package backs the touchstone command as well as some touchtest assertions.
+*/
+package touchinspect
@@ -0,0 +1,234 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchinspect
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil/promlint"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// FamilyCount describes the number of series exposed by a single metric family.
+type FamilyCount struct {
+	// Name is the metric family name.
+	Name string
+
+	// Type is the metric family type.
+	Type dto.MetricType
+
+	// Series is the number of distinct label sets in the family.
+	Series int
+
+	// Samples is the number of exposed samples in the family.  For counters,
+	// gauges, and untyped metrics this is the same as Series.  For histograms and
+	// summaries, this includes each bucket or quantile along with the sum and count.
+	Samples int
+}
+
+// Snapshot is a point-in-time view of a set of metric families.  Families
+// are always sorted by name.
+type Snapshot struct {
+	// Families are the gathered metric families.
+	Families []*dto.MetricFamily
+}
+
+func newSnapshot(families []*dto.MetricFamily) Snapshot {
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].GetName() < families[j].GetName()
+	})
+
+	return Snapshot{Families: families}
+}
+
+// Gather takes a Snapshot of the given Gatherer.
+func Gather(g prometheus.Gatherer) (Snapshot, error) {
+	families, err := g.Gather()
+	return newSnapshot(families), err
+}
+
+// Decode reads a Snapshot from an exposition in the given format.  Use
+// expfmt.ResponseFormat to determine the format of an HTTP response.
+func Decode(r io.Reader, format expfmt.Format) (Snapshot, error) {
+	var (
+		families []*dto.MetricFamily
+		dec      = expfmt.NewDecoder(r, format)
+	)
+
+	for {
+		mf := new(dto.MetricFamily)
+		err := dec.Decode(mf)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return Snapshot{}, err
+		}
+
+		families = append(families, mf)
+	}
+
+	return newSnapshot(families), nil
+}
+
+// DecodeText reads a Snapshot from the prometheus text exposition format.
+func DecodeText(r io.Reader) (Snapshot, error) {
+	return Decode(r, expfmt.NewFormat(expfmt.TypeTextPlain))
+}
+
+// countSamples computes the number of samples exposed by a metric.
+func countSamples(t dto.MetricType, m *dto.Metric) int {
+	switch t {
+	case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+		// buckets, plus the implicit +Inf bucket, plus _sum and _count
+		buckets := m.GetHistogram().GetBucket()
+		if len(buckets) > 0 && math.IsInf(buckets[len(buckets)-1].GetUpperBound(), +1) {
+			return len(buckets) + 2
+		}
+
+		return len(buckets) + 3
+
+	case dto.MetricType_SUMMARY:
+		// quantiles, plus _sum and _count
+		return len(m.GetSummary().GetQuantile()) + 2
+
+	default:
+		return 1
+	}
+}
+
+// Count computes the FamilyCount for a single metric family.
+func Count(mf *dto.MetricFamily) FamilyCount {
+	fc := FamilyCount{
+		Name:   mf.GetName(),
+		Type:   mf.GetType(),
+		Series: len(mf.GetMetric()),
+	}
+
+	for _, m := range mf.GetMetric() {
+		fc.Samples += countSamples(fc.Type, m)
+	}
+
+	return fc
+}
+
+// SeriesCounts returns the FamilyCount for each family in this Snapshot, sorted by name.
+func (s Snapshot) SeriesCounts() []FamilyCount {
+	counts := make([]FamilyCount, 0, len(s.Families))
+	for _, mf := range s.Families {
+		counts = append(counts, Count(mf))
+	}
+
+	return counts
+}
+
+// TotalSeries returns the total number of series across all families.
+func (s Snapshot) TotalSeries() (total int) {
+	for _, mf := range s.Families {
+		total += len(mf.GetMetric())
+	}
+
+	return
+}
+
+// TopCardinality returns up to n families with the largest number of samples,
+// ordered from largest to smallest.  Ties are broken by name.  If n is nonpositive,
+// all families are returned.
+func (s Snapshot) TopCardinality(n int) []FamilyCount {
+	counts := s.SeriesCounts()
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].Samples > counts[j].Samples
+	})
+
+	if n > 0 && n < len(counts) {
+		counts = counts[:n]
+	}
+
+	return counts
+}
+
+// Lint runs the standard prometheus lint checks against this Snapshot.
+//
+// See: https://pkg.go.dev/github.com/prometheus/client_golang/prometheus/testutil/promlint
+func (s Snapshot) Lint() ([]promlint.Problem, error) {
+	return promlint.NewWithMetricFamilies(s.Families).Lint()
+}
+
+// Family returns the metric family with the given name, or nil if no such family exists.
+func (s Snapshot) Family(name string) *dto.MetricFamily {
+	i := sort.Search(len(s.Families), func(i int) bool {
+		return s.Families[i].GetName() >= name
+	})
+
+	if i < len(s.Families) && s.Families[i].GetName() == name {
+		return s.Families[i]
+	}
+
+	return nil
+}
+
+// Change describes how a single metric family differs between two Snapshots.
+type Change struct {
+	// Name is the metric family name.
+	Name string
+
+	// Before is the count from the original Snapshot.  This will be the zero
+	// value if the family was added.
+	Before FamilyCount
+
+	// After is the count from the other Snapshot.  This will be the zero
+	// value if the family was removed.
+	After FamilyCount
+}
+
+// Added tests if this Change represents a family that was not present originally.
+func (c Change) Added() bool {
+	return len(c.Before.Name) == 0
+}
+
+// Removed tests if this Change represents a family that is no longer present.
+func (c Change) Removed() bool {
+	return len(c.After.Name) == 0
+}
+
+// Diff computes the families that were added, removed, or changed series counts
+// between this Snapshot and another.  Changes are sorted by family name.
+func (s Snapshot) Diff(other Snapshot) (changes []Change) {
+	i, j := 0, 0
+	for i < len(s.Families) || j < len(other.Families) {
+		var c Change
+		switch {
+		case j >= len(other.Families) || (i < len(s.Families) && s.Families[i].GetName() < other.Families[j].GetName()):
+			c.Before = Count(s.Families[i])
+			i++
+
+		case i >= len(s.Families) || s.Families[i].GetName() > other.Families[j].GetName():
+			c.After = Count(other.Families[j])
+			j++
+
+		default:
+			c.Before = Count(s.Families[i])
+			c.After = Count(other.Families[j])
+			i++
+			j++
+
+			if c.Before == c.After {
+				continue
+			}
+		}
+
+		c.Name = c.Before.Name
+		if len(c.Name) == 0 {
+			c.Name = c.After.Name
+		}
+
+		changes = append(changes, c)
+	}
+
+	return
+}
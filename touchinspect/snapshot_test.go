@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchinspect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+)
+
+const testExposition = `
+# HELP requests_total the number of requests
+# TYPE requests_total counter
+requests_total{code="200"} 10
+requests_total{code="404"} 2
+requests_total{code="500"} 1
+# HELP latency_seconds request latency
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 4.5
+latency_seconds_count 3
+# HELP up whether the thing is up
+# TYPE up gauge
+up 1
+`
+
+type SnapshotSuite struct {
+	suite.Suite
+}
+
+func (suite *SnapshotSuite) decode(text string) Snapshot {
+	s, err := DecodeText(strings.NewReader(text))
+	suite.Require().NoError(err)
+	return s
+}
+
+func (suite *SnapshotSuite) TestDecodeText() {
+	s := suite.decode(testExposition)
+	suite.Require().Len(s.Families, 3)
+	suite.Equal("latency_seconds", s.Families[0].GetName())
+	suite.Equal("requests_total", s.Families[1].GetName())
+	suite.Equal("up", s.Families[2].GetName())
+
+	suite.NotNil(s.Family("up"))
+	suite.Nil(s.Family("nosuch"))
+	suite.Equal(5, s.TotalSeries())
+
+	_, err := DecodeText(strings.NewReader("this is not valid"))
+	suite.Error(err)
+}
+
+func (suite *SnapshotSuite) TestGather() {
+	r := prometheus.NewPedanticRegistry()
+	cv := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total", Help: "test"}, []string{"label"})
+	suite.Require().NoError(r.Register(cv))
+	cv.WithLabelValues("a").Inc()
+	cv.WithLabelValues("b").Inc()
+
+	s, err := Gather(r)
+	suite.Require().NoError(err)
+	suite.Require().Len(s.Families, 1)
+	suite.Equal(2, Count(s.Families[0]).Series)
+}
+
+func (suite *SnapshotSuite) TestSeriesCounts() {
+	counts := suite.decode(testExposition).SeriesCounts()
+	suite.Require().Len(counts, 3)
+
+	suite.Equal("latency_seconds", counts[0].Name)
+	suite.Equal(1, counts[0].Series)
+	suite.Equal(5, counts[0].Samples)
+
+	suite.Equal("requests_total", counts[1].Name)
+	suite.Equal(3, counts[1].Series)
+	suite.Equal(3, counts[1].Samples)
+
+	suite.Equal("up", counts[2].Name)
+	suite.Equal(1, counts[2].Samples)
+}
+
+func (suite *SnapshotSuite) TestTopCardinality() {
+	s := suite.decode(testExposition)
+
+	top := s.TopCardinality(2)
+	suite.Require().Len(top, 2)
+	suite.Equal("latency_seconds", top[0].Name)
+	suite.Equal("requests_total", top[1].Name)
+
+	suite.Len(s.TopCardinality(0), 3)
+	suite.Len(s.TopCardinality(100), 3)
+}
+
+func (suite *SnapshotSuite) TestLint() {
+	problems, err := suite.decode(testExposition).Lint()
+	suite.NoError(err)
+	suite.Empty(problems)
+
+	problems, err = suite.decode(`
+# TYPE badCounter counter
+badCounter 1
+`).Lint()
+
+	suite.NoError(err)
+	suite.NotEmpty(problems)
+}
+
+func (suite *SnapshotSuite) TestDiff() {
+	before := suite.decode(testExposition)
+	after := suite.decode(`
+# HELP requests_total the number of requests
+# TYPE requests_total counter
+requests_total{code="200"} 10
+# HELP up whether the thing is up
+# TYPE up gauge
+up 0
+# HELP zzz a new metric
+# TYPE zzz gauge
+zzz 1
+`)
+
+	suite.Empty(before.Diff(before))
+
+	changes := before.Diff(after)
+	suite.Require().Len(changes, 3)
+
+	suite.Equal("latency_seconds", changes[0].Name)
+	suite.True(changes[0].Removed())
+	suite.False(changes[0].Added())
+
+	suite.Equal("requests_total", changes[1].Name)
+	suite.Equal(3, changes[1].Before.Series)
+	suite.Equal(1, changes[1].After.Series)
+
+	suite.Equal("zzz", changes[2].Name)
+	suite.True(changes[2].Added())
+}
+
+func TestSnapshot(t *testing.T) {
+	suite.Run(t, new(SnapshotSuite))
+}
@@ -12,13 +12,13 @@ import (
 )
 
 // Counter uses an injected touchstone Factory to create a go-kit metrics.Counter backed
-// by a prometheus CounterVec.  The *touchstone.Factory from the enclosing fx.App
+// by a prometheus CounterVec.  The touchstone.MetricFactory from the enclosing fx.App
 // is used to create and register the prometheus metric.  The name of the returned
 // component will be the same as the metric name.
 func Counter(o prometheus.CounterOpts, labelNames ...string) fx.Option {
 	return fx.Provide(fx.Annotated{
 		Name: o.Name,
-		Target: func(f *touchstone.Factory) (m metrics.Counter, err error) {
+		Target: func(f touchstone.MetricFactory) (m metrics.Counter, err error) {
 			var pm *prometheus.CounterVec
 			pm, err = f.NewCounterVec(o, labelNames...)
 			if err == nil {
@@ -31,13 +31,13 @@ func Counter(o prometheus.CounterOpts, labelNames ...string) fx.Option {
 }
 
 // Gauge uses an injected touchstone Factory to create a go-kit metrics.Gauge backed
-// by a prometheus GaugeVec.  The *touchstone.Factory from the enclosing fx.App
+// by a prometheus GaugeVec.  The touchstone.MetricFactory from the enclosing fx.App
 // is used to create and register the prometheus metric.  The name of the returned
 // component will be the same as the metric name.
 func Gauge(o prometheus.GaugeOpts, labelNames ...string) fx.Option {
 	return fx.Provide(fx.Annotated{
 		Name: o.Name,
-		Target: func(f *touchstone.Factory) (m metrics.Gauge, err error) {
+		Target: func(f touchstone.MetricFactory) (m metrics.Gauge, err error) {
 			var pm *prometheus.GaugeVec
 			pm, err = f.NewGaugeVec(o, labelNames...)
 			if err == nil {
@@ -50,13 +50,13 @@ func Gauge(o prometheus.GaugeOpts, labelNames ...string) fx.Option {
 }
 
 // Histogram uses an injected touchstone Factory to create a go-kit metrics.Histogram backed
-// by a prometheus HistogramVec.  The *touchstone.Factory from the enclosing fx.App
+// by a prometheus HistogramVec.  The touchstone.MetricFactory from the enclosing fx.App
 // is used to create and register the prometheus metric.  The name of the returned
 // component will be the same as the metric name.
 func Histogram(o prometheus.HistogramOpts, labelNames ...string) fx.Option {
 	return fx.Provide(fx.Annotated{
 		Name: o.Name,
-		Target: func(f *touchstone.Factory) (m metrics.Histogram, err error) {
+		Target: func(f touchstone.MetricFactory) (m metrics.Histogram, err error) {
 			var pm prometheus.ObserverVec
 			pm, err = f.NewHistogramVec(o, labelNames...)
 			if err == nil {
@@ -69,13 +69,13 @@ func Histogram(o prometheus.HistogramOpts, labelNames ...string) fx.Option {
 }
 
 // Summary uses an injected touchstone Factory to create a go-kit metrics.Histogram backed
-// by a prometheus SummaryVec.  The *touchstone.Factory from the enclosing fx.App
+// by a prometheus SummaryVec.  The touchstone.MetricFactory from the enclosing fx.App
 // is used to create and register the prometheus metric.  The name of the returned
 // component will be the same as the metric name.
 func Summary(o prometheus.SummaryOpts, labelNames ...string) fx.Option {
 	return fx.Provide(fx.Annotated{
 		Name: o.Name,
-		Target: func(f *touchstone.Factory) (m metrics.Histogram, err error) {
+		Target: func(f touchstone.MetricFactory) (m metrics.Histogram, err error) {
 			var pm prometheus.ObserverVec
 			pm, err = f.NewSummaryVec(o, labelNames...)
 			if err == nil {
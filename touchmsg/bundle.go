@@ -0,0 +1,282 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchmsg
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/multierr"
+)
+
+const (
+	// DefaultInCount is the default name of the counter that tracks the
+	// total number of messages consumed.
+	DefaultInCount = "messages_in_count"
+
+	// DefaultOutCount is the default name of the counter that tracks the
+	// total number of messages produced.
+	DefaultOutCount = "messages_out_count"
+
+	// DefaultDuration is the default name of the observer that tracks
+	// the time taken to process each consumed message.
+	DefaultDuration = "message_processing_duration_ms"
+
+	// DefaultErrorCount is the default name of the counter that tracks
+	// errors, labeled by reason.
+	DefaultErrorCount = "message_error_count"
+
+	// DefaultLag is the default name of the gauge that reports consumer lag.
+	DefaultLag = "consumer_lag"
+
+	// TopicLabel is the canonical metric label name containing the topic.
+	// This label is not automatically supplied.
+	TopicLabel = "topic"
+
+	// ClientLabel is the canonical metric label name containing the name of the
+	// consumer or producer client.  This label is not automatically supplied.
+	ClientLabel = "client"
+
+	// ReasonLabel is the metric label containing the reason for an error.
+	ReasonLabel = "reason"
+
+	// ReasonProcessing is the reason recorded when message processing fails.
+	// This reason is always allowed.
+	ReasonProcessing = "processing"
+
+	// ReasonOther is the reason recorded when an error's reason is not one of
+	// the reasons configured on the Bundle.
+	ReasonOther = "other"
+)
+
+var (
+	// ErrReservedLabelName indicates that labels supplied to build an instrumenter
+	// had one or more reserved label names.
+	ErrReservedLabelName = fmt.Errorf(
+		"%s is a reserved label name and is supplied automatically",
+		ReasonLabel,
+	)
+
+	// ErrInvalidLabelCount indicates that an odd number of name/value pairs were
+	// passed when creating metrics.
+	ErrInvalidLabelCount = errors.New("The number of label names and values must be even")
+
+	defaultInCount = prometheus.CounterOpts{
+		Name: DefaultInCount,
+		Help: "the total number of messages consumed since startup",
+	}
+
+	defaultOutCount = prometheus.CounterOpts{
+		Name: DefaultOutCount,
+		Help: "the total number of messages produced since startup",
+	}
+
+	defaultDuration = prometheus.HistogramOpts{
+		Name:    DefaultDuration,
+		Help:    "the time taken to process a consumed message in milliseconds",
+		Buckets: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 5000, 10000},
+	}
+
+	defaultErrorCount = prometheus.CounterOpts{
+		Name: DefaultErrorCount,
+		Help: "the total number of errors since startup",
+	}
+
+	defaultLag = prometheus.GaugeOpts{
+		Name: DefaultLag,
+		Help: "the number of messages the consumer is behind the head of the topic",
+	}
+)
+
+// labelNames takes a sequence of name/value pairs and converts that into
+// a slice of names and a prometheus.Labels which should be used to curry
+// the associated metric.
+func labelNames(lvs []string) (names []string, curry prometheus.Labels, err error) {
+	if len(lvs)%2 != 0 {
+		return nil, nil, ErrInvalidLabelCount
+	}
+
+	names = make([]string, 0, len(lvs)/2)
+	curry = make(prometheus.Labels, len(lvs)/2)
+	for i := 0; i < len(lvs); i += 2 {
+		if lvs[i] == ReasonLabel {
+			return nil, nil, ErrReservedLabelName
+		}
+
+		names = append(names, lvs[i])
+		curry[lvs[i]] = lvs[i+1]
+	}
+
+	return
+}
+
+func newCounterVec(f touchstone.MetricFactory, o prometheus.CounterOpts, labelNames []string, curry prometheus.Labels) (*prometheus.CounterVec, error) {
+	cv, err := f.NewCounterVec(o, labelNames...)
+	err = touchstone.ExistingCollector(&cv, err)
+	if err == nil {
+		cv, err = cv.CurryWith(curry)
+	}
+
+	return cv, err
+}
+
+// Bundle describes the message metrics.  The zero value of this type
+// uses the default metrics and produces no lag gauge.
+type Bundle struct {
+	// InCount describes the options used for the consumed message counter.
+	InCount prometheus.CounterOpts
+
+	// OutCount describes the options used for the produced message counter.
+	OutCount prometheus.CounterOpts
+
+	// Duration describes the options for the processing duration observer.  If this field is
+	// set, it must be either a prometheus.HistogramOpts or a prometheus.SummaryOpts.
+	Duration interface{}
+
+	// ErrorCount describes the options used for the error counter.
+	ErrorCount prometheus.CounterOpts
+
+	// Reasons is the bounded set of error reasons.  Any reason passed to
+	// Instrumenter.Error that is not in this set is recorded as ReasonOther.
+	// ReasonProcessing is always allowed.
+	Reasons []string
+
+	// Lag describes the options used for the consumer lag gauge.
+	Lag prometheus.GaugeOpts
+
+	// LagFunc is the callback that computes the current consumer lag.  If unset,
+	// no lag gauge is created.  The curried labels passed to NewInstrumenter are
+	// applied to the lag gauge as constant labels.
+	LagFunc func() float64
+
+	// Clock is the source of the current time.  If unset, the Clock of the
+	// MetricFactory is used.
+	Clock touchstone.Clock
+}
+
+func (b Bundle) newDuration(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (prometheus.ObserverVec, error) {
+	var opts interface{}
+	switch t := b.Duration.(type) {
+	case nil:
+		clone := defaultDuration
+		opts = clone
+
+	case prometheus.HistogramOpts:
+		touchstone.ApplyDefaults(&t, defaultDuration)
+		opts = t
+
+	case prometheus.SummaryOpts:
+		touchstone.ApplyDefaults(&t, defaultDuration)
+		opts = t
+
+	default:
+		return nil, errors.New("Bundle.Duration must be nil, a prometheus.HistogramOpts, or a prometheus.SummaryOpts")
+	}
+
+	ov, err := f.NewObserverVec(opts, labelNames...)
+	err = touchstone.ExistingCollector(&ov, err)
+	if err == nil {
+		ov, err = ov.CurryWith(curry)
+	}
+
+	return ov, err
+}
+
+func (b Bundle) newLag(f touchstone.MetricFactory, curry prometheus.Labels) (prometheus.GaugeFunc, error) {
+	o := b.Lag
+	touchstone.ApplyDefaults(&o, defaultLag)
+	constLabels := make(prometheus.Labels, len(o.ConstLabels)+len(curry))
+	for k, v := range o.ConstLabels {
+		constLabels[k] = v
+	}
+
+	for k, v := range curry {
+		constLabels[k] = v
+	}
+
+	o.ConstLabels = constLabels
+	return f.NewGaugeFunc(o, b.LagFunc)
+}
+
+func (b Bundle) newReasons() map[string]bool {
+	reasons := make(map[string]bool, len(b.Reasons)+1)
+	reasons[ReasonProcessing] = true
+	for _, r := range b.Reasons {
+		reasons[r] = true
+	}
+
+	return reasons
+}
+
+// NewInstrumenter creates a constructor that can be passed to fx.Provide or annotated
+// as needed.
+//
+// The namesAndValues are any extra, curried labels to apply to all the created
+// metrics, typically TopicLabel and/or ClientLabel.  If multiple calls to this method
+// are made, the extra label names must match though the values may differ.
+//
+// Typical usage:
+//
+//	app := fx.New(
+//	  touchstone.Provide(),
+//	  fx.Provide(
+//	    fx.Annotated{
+//	      Name: "consumers.events",
+//	      Target: touchmsg.Bundle{
+//	        Reasons: []string{"decode", "commit"},
+//	        LagFunc: consumer.Lag,
+//	      }.NewInstrumenter(
+//	        touchmsg.TopicLabel, "events",
+//	      ),
+//	    },
+//	  ),
+//	)
+func (b Bundle) NewInstrumenter(namesAndValues ...string) func(touchstone.MetricFactory) (Instrumenter, error) {
+	return func(f touchstone.MetricFactory) (i Instrumenter, err error) {
+		var (
+			extraNames []string
+			curry      prometheus.Labels
+		)
+
+		extraNames, curry, err = labelNames(namesAndValues)
+		if err != nil {
+			return
+		}
+
+		clock := b.Clock
+		if clock == nil {
+			clock = f.Clock()
+		}
+
+		i.now = clock.Now
+
+		i.reasons = b.newReasons()
+
+		var metricErr error
+		touchstone.ApplyDefaults(&b.InCount, defaultInCount)
+		i.in, metricErr = newCounterVec(f, b.InCount, extraNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		touchstone.ApplyDefaults(&b.OutCount, defaultOutCount)
+		i.out, metricErr = newCounterVec(f, b.OutCount, extraNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		i.duration, metricErr = b.newDuration(f, extraNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		errorNames := append(append([]string{}, extraNames...), ReasonLabel)
+		touchstone.ApplyDefaults(&b.ErrorCount, defaultErrorCount)
+		i.errorCount, metricErr = newCounterVec(f, b.ErrorCount, errorNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		if b.LagFunc != nil {
+			_, metricErr = b.newLag(f, curry)
+			multierr.AppendInto(&err, metricErr)
+		}
+
+		return
+	}
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchmsg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type BundleSuite struct {
+	suite.Suite
+}
+
+func (suite *BundleSuite) newInstrumenter(b Bundle, namesAndValues ...string) (Instrumenter, prometheus.Gatherer) {
+	var (
+		i Instrumenter
+		g prometheus.Gatherer
+
+		app = fxtest.New(
+			suite.T(),
+			fx.Supply(touchstone.Config{
+				DisableGoCollector:        true,
+				DisableProcessCollector:   true,
+				DisableBuildInfoCollector: true,
+			}),
+			touchstone.Provide(),
+			fx.Provide(
+				b.NewInstrumenter(namesAndValues...),
+			),
+			fx.Populate(&i, &g),
+		)
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	return i, g
+}
+
+func (suite *BundleSuite) TestInvalidLabels() {
+	f := func(namesAndValues ...string) error {
+		_, r, err := touchstone.New(touchstone.Config{})
+		suite.Require().NoError(err)
+		_, err = Bundle{}.NewInstrumenter(namesAndValues...)(
+			touchstone.NewFactory(touchstone.Config{}, nil, r),
+		)
+
+		return err
+	}
+
+	suite.ErrorIs(f("odd"), ErrInvalidLabelCount)
+	suite.ErrorIs(f(ReasonLabel, "value"), ErrReservedLabelName)
+}
+
+func (suite *BundleSuite) TestInvalidDuration() {
+	_, r, err := touchstone.New(touchstone.Config{})
+	suite.Require().NoError(err)
+	_, err = Bundle{Duration: 123}.NewInstrumenter()(
+		touchstone.NewFactory(touchstone.Config{}, nil, r),
+	)
+
+	suite.Error(err)
+}
+
+func (suite *BundleSuite) TestCounts() {
+	i, _ := suite.newInstrumenter(
+		Bundle{
+			Reasons: []string{"decode"},
+		},
+		TopicLabel, "events",
+	)
+
+	i.In(3)
+	i.Out(2)
+	suite.Equal(3.0, testutil.ToFloat64(i.in))
+	suite.Equal(2.0, testutil.ToFloat64(i.out))
+
+	i.Error("decode")
+	i.Error("unexpected")
+	i.Error("another unexpected")
+	suite.Equal(1.0, testutil.ToFloat64(i.errorCount.With(prometheus.Labels{ReasonLabel: "decode"})))
+	suite.Equal(2.0, testutil.ToFloat64(i.errorCount.With(prometheus.Labels{ReasonLabel: ReasonOther})))
+}
+
+func (suite *BundleSuite) TestProcess() {
+	var (
+		now   = time.Now()
+		calls int
+	)
+
+	i, _ := suite.newInstrumenter(
+		Bundle{
+			Duration: prometheus.SummaryOpts{},
+			Clock: touchstone.ClockFunc(func() time.Time {
+				calls++
+				return now.Add(time.Duration(calls) * time.Second)
+			}),
+		},
+	)
+
+	expectedErr := errors.New("expected")
+	suite.NoError(i.Process(func() error { return nil }))
+	suite.ErrorIs(i.Process(func() error { return expectedErr }), expectedErr)
+
+	suite.Equal(2.0, testutil.ToFloat64(i.in))
+	suite.Equal(1.0, testutil.ToFloat64(i.errorCount.With(prometheus.Labels{ReasonLabel: ReasonProcessing})))
+	suite.Equal(1, testutil.CollectAndCount(i.duration.(prometheus.Collector)))
+}
+
+func (suite *BundleSuite) TestLag() {
+	_, g := suite.newInstrumenter(
+		Bundle{
+			LagFunc: func() float64 { return 42.0 },
+		},
+		TopicLabel, "events",
+	)
+
+	suite.NoError(
+		testutil.GatherAndCompare(
+			g,
+			strings.NewReader(`
+# HELP consumer_lag the number of messages the consumer is behind the head of the topic
+# TYPE consumer_lag gauge
+consumer_lag{topic="events"} 42
+`),
+			DefaultLag,
+		),
+	)
+}
+
+func TestBundle(t *testing.T) {
+	suite.Run(t, new(BundleSuite))
+}
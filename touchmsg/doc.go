@@ -0,0 +1,12 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package touchmsg defines opinionated metrics for message consumers and producers,
+such as Kafka clients or other event buses.
+
+A Bundle describes the metrics and produces an Instrumenter, which is typically
+curried by topic and/or client labels.  Error counts are labeled with a bounded
+set of reasons, so that unexpected errors cannot cause a label explosion.
+*/
+package touchmsg
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchmsg
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// noLabels is used to obtain the single child of a fully curried vector.
+var noLabels = prometheus.Labels{}
+
+// Instrumenter records metrics for a single consumer or producer.  Instances
+// are created via Bundle.NewInstrumenter.
+type Instrumenter struct {
+	in         *prometheus.CounterVec
+	out        *prometheus.CounterVec
+	duration   prometheus.ObserverVec
+	errorCount *prometheus.CounterVec
+	reasons    map[string]bool
+
+	now func() time.Time
+}
+
+// In records that n messages were consumed.
+func (i Instrumenter) In(n int) {
+	i.in.With(noLabels).Add(float64(n))
+}
+
+// Out records that n messages were produced.
+func (i Instrumenter) Out(n int) {
+	i.out.With(noLabels).Add(float64(n))
+}
+
+// ObserveDuration records the time taken to process a single message.
+func (i Instrumenter) ObserveDuration(d time.Duration) {
+	i.duration.With(noLabels).Observe(
+		float64(d) / float64(time.Millisecond),
+	)
+}
+
+// Error records an error with the given reason.  If the reason was not configured
+// on the Bundle, ReasonOther is recorded instead.
+func (i Instrumenter) Error(reason string) {
+	if !i.reasons[reason] {
+		reason = ReasonOther
+	}
+
+	i.errorCount.With(prometheus.Labels{ReasonLabel: reason}).Inc()
+}
+
+// Process consumes a single message using the given function.  The message is counted,
+// its processing duration is observed, and any error returned by fn is counted
+// with ReasonProcessing.  The error from fn is returned as is.
+func (i Instrumenter) Process(fn func() error) error {
+	i.In(1)
+	start := i.now()
+	err := fn()
+	i.ObserveDuration(i.now().Sub(start))
+	if err != nil {
+		i.Error(ReasonProcessing)
+	}
+
+	return err
+}
@@ -0,0 +1,18 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package touchmulti provides a file-backed aggregation mode for applications
+// that fork helper processes, similar to the multiprocess mode of the python
+// prometheus client.
+//
+// Each child process periodically writes a snapshot of its own metrics into a
+// shared directory using a Writer.  The parent process exposes a Gatherer that
+// reads every snapshot in that directory and merges them:  counters, histograms,
+// and untyped metrics are summed, while gauges are combined according to a GaugeMode.
+// Summary quantiles cannot be merged, so only a summary's count and sum survive
+// aggregation.
+//
+// Snapshots are cumulative rather than deltas, and are left in place when a child
+// exits.  This means counters from short-lived helpers are not lost.  Remove
+// stale snapshots, e.g. at parent startup, with Clean.
+package touchmulti
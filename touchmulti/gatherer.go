@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchmulti
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// Gatherer is a prometheus.Gatherer that merges the snapshots written to a
+// directory by Writers in other processes.
+type Gatherer struct {
+	dir  string
+	mode GaugeMode
+}
+
+var _ prometheus.Gatherer = (*Gatherer)(nil)
+
+// NewGatherer creates a Gatherer that reads snapshots from the given directory.
+// If mode is empty, GaugeSum is used.
+func NewGatherer(dir string, mode GaugeMode) *Gatherer {
+	if len(mode) == 0 {
+		mode = GaugeSum
+	}
+
+	return &Gatherer{
+		dir:  dir,
+		mode: mode,
+	}
+}
+
+// snapshots returns the paths of all snapshot files, in a stable order.
+func snapshots(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+Extension))
+	sort.Strings(paths)
+	return paths, err
+}
+
+func read(path string) (families []*dto.MetricFamily, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+
+	defer f.Close()
+	dec := expfmt.NewDecoder(f, format)
+	for {
+		mf := new(dto.MetricFamily)
+		if err = dec.Decode(mf); err != nil {
+			break
+		}
+
+		families = append(families, mf)
+	}
+
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+
+	return
+}
+
+// labelProcess adds the ProcessLabel to each gauge, keeping label pairs sorted.
+func labelProcess(id string, families []*dto.MetricFamily) {
+	for _, mf := range families {
+		if mf.GetType() != dto.MetricType_GAUGE {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			m.Label = append(m.Label, &dto.LabelPair{
+				Name:  proto.String(ProcessLabel),
+				Value: proto.String(id),
+			})
+
+			sort.Slice(m.Label, func(i, j int) bool {
+				return m.Label[i].GetName() < m.Label[j].GetName()
+			})
+		}
+	}
+}
+
+// Gather reads and merges every snapshot in this Gatherer's directory.  Snapshots
+// that cannot be read are skipped, and the errors are reported in the returned
+// prometheus.MultiError along with the remaining merged metrics.
+func (g *Gatherer) Gather() ([]*dto.MetricFamily, error) {
+	paths, err := snapshots(g.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		errs prometheus.MultiError
+		sets = make([][]*dto.MetricFamily, 0, len(paths))
+	)
+
+	for _, path := range paths {
+		families, err := read(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("snapshot %s: %w", path, err))
+			continue
+		}
+
+		if g.mode == GaugeAll {
+			labelProcess(strings.TrimSuffix(filepath.Base(path), Extension), families)
+		}
+
+		sets = append(sets, families)
+	}
+
+	merged, err := Merge(g.mode, sets...)
+	var mergeErrs prometheus.MultiError
+	if errors.As(err, &mergeErrs) {
+		errs = append(errs, mergeErrs...)
+	} else {
+		errs.Append(err)
+	}
+
+	return merged, errs.MaybeUnwrap()
+}
+
+// parentGatherer merges a parent process's own metrics with its children's snapshots.
+type parentGatherer struct {
+	parent   prometheus.Gatherer
+	children *Gatherer
+}
+
+// NewParentGatherer creates a prometheus.Gatherer that merges the metrics of a parent
+// process, gathered from the given Gatherer, with the snapshots in a directory.  A family
+// reported by both the parent and its children, such as the go and process collectors'
+// families, is merged rather than exposed twice.  If mode is empty, GaugeSum is used.
+func NewParentGatherer(parent prometheus.Gatherer, dir string, mode GaugeMode) prometheus.Gatherer {
+	return parentGatherer{
+		parent:   parent,
+		children: NewGatherer(dir, mode),
+	}
+}
+
+// Gather merges the parent's metrics with its children's.  As with Gatherer.Gather,
+// problems are reported in the returned prometheus.MultiError along with the
+// remaining merged metrics.
+func (pg parentGatherer) Gather() ([]*dto.MetricFamily, error) {
+	var errs prometheus.MultiError
+	appendErr := func(err error) {
+		var multi prometheus.MultiError
+		if errors.As(err, &multi) {
+			errs = append(errs, multi...)
+		} else {
+			errs.Append(err)
+		}
+	}
+
+	parent, err := pg.parent.Gather()
+	appendErr(err)
+
+	children, err := pg.children.Gather()
+	appendErr(err)
+
+	merged, err := Merge(pg.children.mode, parent, children)
+	appendErr(err)
+
+	return merged, errs.MaybeUnwrap()
+}
+
+// Clean removes every snapshot from a directory.  Parent processes typically
+// invoke this function at startup to discard the metrics of a previous run.
+func Clean(dir string) error {
+	paths, err := snapshots(dir)
+	for _, path := range paths {
+		if removeErr := os.Remove(path); removeErr != nil && !os.IsNotExist(removeErr) && err == nil {
+			err = removeErr
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchmulti
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+type GathererSuite struct {
+	suite.Suite
+	dir string
+}
+
+func (suite *GathererSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+}
+
+// child simulates a child process with a counter and a gauge.
+func (suite *GathererSuite) child(id string, counter, gauge float64) *Writer {
+	r := prometheus.NewPedanticRegistry()
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "events", Help: "events"})
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "workers", Help: "workers"})
+	r.MustRegister(c, g)
+	c.Add(counter)
+	g.Set(gauge)
+
+	w := NewWriter(suite.dir, id, r)
+	suite.Require().NoError(w.Flush())
+	return w
+}
+
+func (suite *GathererSuite) TestWriter() {
+	w := suite.child("", 1, 1)
+	suite.Equal(strconv.Itoa(os.Getpid()), w.ID())
+	suite.Equal(filepath.Join(suite.dir, w.ID()+Extension), w.Path())
+	suite.FileExists(w.Path())
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(suite.dir)
+	suite.Require().NoError(err)
+	suite.Len(entries, 1)
+
+	suite.NoError(w.Remove())
+	suite.NoFileExists(w.Path())
+	suite.NoError(w.Remove())
+
+	w = NewWriter(filepath.Join(suite.dir, "nosuch"), "test", prometheus.NewRegistry())
+	suite.Error(w.Flush())
+}
+
+func (suite *GathererSuite) TestGather() {
+	suite.child("1", 2, 3)
+	suite.child("2", 5, 4)
+
+	suite.NoError(
+		testutil.GatherAndCompare(
+			NewGatherer(suite.dir, ""),
+			strings.NewReader(`
+# HELP events events
+# TYPE events counter
+events 7
+# HELP workers workers
+# TYPE workers gauge
+workers 7
+`),
+		),
+	)
+
+	suite.NoError(
+		testutil.GatherAndCompare(
+			NewGatherer(suite.dir, GaugeAll),
+			strings.NewReader(`
+# HELP events events
+# TYPE events counter
+events 7
+# HELP workers workers
+# TYPE workers gauge
+workers{process="1"} 3
+workers{process="2"} 4
+`),
+		),
+	)
+}
+
+func (suite *GathererSuite) TestCorruptSnapshot() {
+	suite.child("1", 2, 3)
+	suite.Require().NoError(os.WriteFile(filepath.Join(suite.dir, "bad"+Extension), []byte("garbage"), 0o600))
+
+	families, err := NewGatherer(suite.dir, GaugeSum).Gather()
+	suite.Error(err)
+	suite.Len(families, 2)
+}
+
+func (suite *GathererSuite) TestClean() {
+	suite.child("1", 2, 3)
+	suite.child("2", 5, 4)
+	suite.Require().NoError(os.WriteFile(filepath.Join(suite.dir, "other.txt"), nil, 0o600))
+
+	suite.NoError(Clean(suite.dir))
+	families, err := NewGatherer(suite.dir, GaugeSum).Gather()
+	suite.NoError(err)
+	suite.Empty(families)
+	suite.FileExists(filepath.Join(suite.dir, "other.txt"))
+}
+
+func TestGatherer(t *testing.T) {
+	suite.Run(t, new(GathererSuite))
+}
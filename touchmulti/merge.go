@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchmulti
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// GaugeMode describes how the values of the same gauge reported by several
+// processes are combined.
+type GaugeMode string
+
+const (
+	// GaugeSum sums the values of a gauge across processes.  This is the default.
+	GaugeSum GaugeMode = "sum"
+
+	// GaugeMax uses the maximum value of a gauge across processes.
+	GaugeMax GaugeMode = "max"
+
+	// GaugeMin uses the minimum value of a gauge across processes.
+	GaugeMin GaugeMode = "min"
+
+	// GaugeAll keeps each process's gauge as a distinct series, distinguished
+	// by the ProcessLabel.
+	GaugeAll GaugeMode = "all"
+)
+
+// ProcessLabel is the label added to gauges in GaugeAll mode that identifies
+// the process that reported the value.
+const ProcessLabel = "process"
+
+// seriesKey produces a key that uniquely identifies a metric's label values.
+func seriesKey(m *dto.Metric) string {
+	pairs := make([]string, 0, len(m.GetLabel()))
+	for _, lp := range m.GetLabel() {
+		pairs = append(pairs, lp.GetName()+"\xff"+lp.GetValue())
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\xfe")
+}
+
+func combineGauge(mode GaugeMode, current, next float64) float64 {
+	switch mode {
+	case GaugeMax:
+		if next > current {
+			return next
+		}
+
+		return current
+
+	case GaugeMin:
+		if next < current {
+			return next
+		}
+
+		return current
+
+	default:
+		return current + next
+	}
+}
+
+// mergeMetric folds next into current, which must be of the given type.
+func mergeMetric(t dto.MetricType, mode GaugeMode, current, next *dto.Metric) error {
+	switch t {
+	case dto.MetricType_COUNTER:
+		current.Counter.Value = proto.Float64(current.GetCounter().GetValue() + next.GetCounter().GetValue())
+
+	case dto.MetricType_GAUGE:
+		current.Gauge.Value = proto.Float64(combineGauge(mode, current.GetGauge().GetValue(), next.GetGauge().GetValue()))
+
+	case dto.MetricType_UNTYPED:
+		current.Untyped.Value = proto.Float64(current.GetUntyped().GetValue() + next.GetUntyped().GetValue())
+
+	case dto.MetricType_SUMMARY:
+		current.Summary.SampleCount = proto.Uint64(current.GetSummary().GetSampleCount() + next.GetSummary().GetSampleCount())
+		current.Summary.SampleSum = proto.Float64(current.GetSummary().GetSampleSum() + next.GetSummary().GetSampleSum())
+
+	case dto.MetricType_HISTOGRAM:
+		cb, nb := current.GetHistogram().GetBucket(), next.GetHistogram().GetBucket()
+		if len(cb) != len(nb) {
+			return fmt.Errorf("histogram buckets differ")
+		}
+
+		for i := range cb {
+			if cb[i].GetUpperBound() != nb[i].GetUpperBound() {
+				return fmt.Errorf("histogram buckets differ")
+			}
+
+			cb[i].CumulativeCount = proto.Uint64(cb[i].GetCumulativeCount() + nb[i].GetCumulativeCount())
+		}
+
+		current.Histogram.SampleCount = proto.Uint64(current.GetHistogram().GetSampleCount() + next.GetHistogram().GetSampleCount())
+		current.Histogram.SampleSum = proto.Float64(current.GetHistogram().GetSampleSum() + next.GetHistogram().GetSampleSum())
+
+	default:
+		return fmt.Errorf("unsupported metric type %s", t)
+	}
+
+	return nil
+}
+
+// clone produces a deep copy of a metric suitable for accumulating values.
+// Summary quantiles and timestamps are dropped, as they cannot be merged.
+func clone(m *dto.Metric) *dto.Metric {
+	c := proto.Clone(m).(*dto.Metric)
+	c.TimestampMs = nil
+	if c.Summary != nil {
+		c.Summary.Quantile = nil
+	}
+
+	return c
+}
+
+// Merge combines several sets of metric families, e.g. as gathered from several
+// processes, into a single set.  Series with the same name and label values are
+// combined:  counters, histograms, and untyped metrics are summed, summaries have
+// their count and sum added and their quantiles dropped, and gauges are combined
+// according to the mode.  GaugeAll is treated as GaugeSum by this function, since
+// it has no notion of which process reported a gauge.
+//
+// A family that conflicts with an earlier family of the same name, e.g. by having
+// a different type, is skipped.  All such problems are reported in the returned
+// prometheus.MultiError, while the remaining families are still merged.
+//
+// The returned families are sorted by name, and their metrics are sorted by label values.
+// The input families are never modified.
+func Merge(mode GaugeMode, sets ...[]*dto.MetricFamily) ([]*dto.MetricFamily, error) {
+	var (
+		errs     prometheus.MultiError
+		families = make(map[string]*dto.MetricFamily)
+		series   = make(map[string]map[string]*dto.Metric)
+	)
+
+	for _, set := range sets {
+		for _, mf := range set {
+			name := mf.GetName()
+			merged, ok := families[name]
+			if !ok {
+				merged = &dto.MetricFamily{
+					Name: mf.Name,
+					Help: mf.Help,
+					Type: mf.Type,
+					Unit: mf.Unit,
+				}
+
+				families[name] = merged
+				series[name] = make(map[string]*dto.Metric)
+			} else if merged.GetType() != mf.GetType() {
+				errs = append(errs, fmt.Errorf("metric %s has conflicting types %s and %s", name, merged.GetType(), mf.GetType()))
+				continue
+			}
+
+			for _, m := range mf.GetMetric() {
+				key := seriesKey(m)
+				current, ok := series[name][key]
+				if !ok {
+					current = clone(m)
+					series[name][key] = current
+					merged.Metric = append(merged.Metric, current)
+					continue
+				}
+
+				if err := mergeMetric(merged.GetType(), mode, current, m); err != nil {
+					errs = append(errs, fmt.Errorf("metric %s: %w", name, err))
+				}
+			}
+		}
+	}
+
+	result := make([]*dto.MetricFamily, 0, len(families))
+	for _, mf := range families {
+		sort.Slice(mf.Metric, func(i, j int) bool {
+			return seriesKey(mf.Metric[i]) < seriesKey(mf.Metric[j])
+		})
+
+		result = append(result, mf)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetName() < result[j].GetName()
+	})
+
+	return result, errs.MaybeUnwrap()
+}
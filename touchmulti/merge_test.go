@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchmulti
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+)
+
+type MergeSuite struct {
+	suite.Suite
+}
+
+// process simulates the metrics of a single process.
+func (suite *MergeSuite) process(counter, gauge float64, observations ...float64) []*dto.MetricFamily {
+	r := prometheus.NewPedanticRegistry()
+	cv := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests", Help: "requests"}, []string{"code"})
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "queue", Help: "queue"})
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency", Help: "latency", Buckets: []float64{1, 10}})
+	s := prometheus.NewSummary(prometheus.SummaryOpts{Name: "size", Help: "size", Objectives: map[float64]float64{0.5: 0.05}})
+	u := prometheus.NewUntypedFunc(prometheus.UntypedOpts{Name: "things", Help: "things"}, func() float64 { return 1 })
+	r.MustRegister(cv, g, h, s, u)
+
+	cv.WithLabelValues("200").Add(counter)
+	g.Set(gauge)
+	for _, o := range observations {
+		h.Observe(o)
+		s.Observe(o)
+	}
+
+	families, err := r.Gather()
+	suite.Require().NoError(err)
+	return families
+}
+
+func (suite *MergeSuite) family(families []*dto.MetricFamily, name string) *dto.MetricFamily {
+	for _, mf := range families {
+		if mf.GetName() == name {
+			return mf
+		}
+	}
+
+	suite.Failf("missing family", "no such family: %s", name)
+	return nil
+}
+
+func (suite *MergeSuite) TestEmpty() {
+	merged, err := Merge(GaugeSum)
+	suite.NoError(err)
+	suite.Empty(merged)
+}
+
+func (suite *MergeSuite) TestSum() {
+	p1 := suite.process(2, 5, 0.5, 20)
+	p2 := suite.process(3, 7, 5)
+	merged, err := Merge(GaugeSum, p1, p2)
+	suite.Require().NoError(err)
+	suite.Require().Len(merged, 5)
+
+	suite.Equal(5.0, suite.family(merged, "requests").Metric[0].GetCounter().GetValue())
+	suite.Equal(12.0, suite.family(merged, "queue").Metric[0].GetGauge().GetValue())
+	suite.Equal(2.0, suite.family(merged, "things").Metric[0].GetUntyped().GetValue())
+
+	h := suite.family(merged, "latency").Metric[0].GetHistogram()
+	suite.Equal(uint64(3), h.GetSampleCount())
+	suite.Equal(25.5, h.GetSampleSum())
+	suite.Equal(uint64(1), h.GetBucket()[0].GetCumulativeCount())
+	suite.Equal(uint64(2), h.GetBucket()[1].GetCumulativeCount())
+
+	s := suite.family(merged, "size").Metric[0].GetSummary()
+	suite.Equal(uint64(3), s.GetSampleCount())
+	suite.Empty(s.GetQuantile())
+
+	// the inputs must not be modified
+	suite.Equal(2.0, suite.family(p1, "requests").Metric[0].GetCounter().GetValue())
+	suite.NotEmpty(suite.family(p1, "size").Metric[0].GetSummary().GetQuantile())
+}
+
+func (suite *MergeSuite) TestGaugeModes() {
+	p1, p2 := suite.process(1, 5), suite.process(1, 7)
+
+	merged, err := Merge(GaugeMax, p1, p2)
+	suite.Require().NoError(err)
+	suite.Equal(7.0, suite.family(merged, "queue").Metric[0].GetGauge().GetValue())
+
+	merged, err = Merge(GaugeMin, p1, p2)
+	suite.Require().NoError(err)
+	suite.Equal(5.0, suite.family(merged, "queue").Metric[0].GetGauge().GetValue())
+}
+
+func (suite *MergeSuite) TestDistinctSeries() {
+	r := prometheus.NewPedanticRegistry()
+	cv := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests", Help: "requests"}, []string{"code"})
+	r.MustRegister(cv)
+	cv.WithLabelValues("500").Inc()
+	other, err := r.Gather()
+	suite.Require().NoError(err)
+
+	merged, err := Merge(GaugeSum, suite.process(2, 0), other)
+	suite.Require().NoError(err)
+
+	requests := suite.family(merged, "requests")
+	suite.Require().Len(requests.Metric, 2)
+	suite.Equal("200", requests.Metric[0].Label[0].GetValue())
+	suite.Equal("500", requests.Metric[1].Label[0].GetValue())
+}
+
+func (suite *MergeSuite) TestConflicts() {
+	r := prometheus.NewPedanticRegistry()
+	r.MustRegister(
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "requests", Help: "requests"}),
+		prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency", Help: "latency", Buckets: []float64{1, 2, 3}}),
+	)
+
+	conflicting, err := r.Gather()
+	suite.Require().NoError(err)
+
+	merged, err := Merge(GaugeSum, suite.process(2, 0, 1), conflicting)
+	suite.Require().Error(err)
+	suite.Contains(err.Error(), "conflicting types")
+	suite.Contains(err.Error(), "buckets differ")
+	suite.True(strings.Contains(err.Error(), "requests"))
+
+	// the first family of a given name wins
+	suite.Equal(2.0, suite.family(merged, "requests").Metric[0].GetCounter().GetValue())
+}
+
+func TestMerge(t *testing.T) {
+	suite.Run(t, new(MergeSuite))
+}
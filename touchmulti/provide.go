@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchmulti
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Role describes how a process participates in multiprocess aggregation.
+type Role string
+
+const (
+	// RoleChild indicates a process that writes snapshots of its metrics.
+	RoleChild Role = "child"
+
+	// RoleParent indicates a process that exposes the merged metrics of its children.
+	RoleParent Role = "parent"
+)
+
+// DefaultInterval is the interval at which children write snapshots when
+// none is configured.
+const DefaultInterval = 10 * time.Second
+
+// ErrInvalidRole indicates that a Config had a Role other than RoleChild or RoleParent.
+var ErrInvalidRole = errors.New("The multiprocess role must be either child or parent")
+
+// Config describes multiprocess aggregation.  Every process that participates
+// in aggregation shares the same Dir.
+type Config struct {
+	// Dir is the directory that holds metrics snapshots.  If unset, multiprocess
+	// aggregation is disabled.
+	Dir string `json:"dir" yaml:"dir"`
+
+	// Role is the role of this process.
+	Role Role `json:"role" yaml:"role"`
+
+	// ID distinguishes this process's snapshot from the snapshots of other children.
+	// If unset, the process id is used.  Only used by children.
+	ID string `json:"id" yaml:"id"`
+
+	// Interval is how often a child writes its snapshot.  A final snapshot is always
+	// written when the child stops.  DefaultInterval is used if unset.  Only used
+	// by children.
+	Interval time.Duration `json:"interval" yaml:"interval"`
+
+	// GaugeMode is how the parent combines gauges.  If unset, GaugeSum is used.
+	// Only used by parents.
+	GaugeMode GaugeMode `json:"gaugeMode" yaml:"gaugeMode"`
+
+	// Clean indicates that a parent removes any existing snapshots at startup.
+	// Only used by parents.
+	Clean bool `json:"clean" yaml:"clean"`
+}
+
+// In holds the components used to set up multiprocess aggregation.
+type In struct {
+	fx.In
+
+	// Config is the optional multiprocess configuration.  If not supplied,
+	// multiprocess aggregation is disabled.
+	Config Config `optional:"true"`
+
+	// Gatherer is the gatherer for this process's own metrics.
+	Gatherer prometheus.Gatherer
+
+	// Logger is the optional logger used to report problems writing snapshots.
+	Logger *zap.Logger `optional:"true"`
+
+	// Lifecycle is used to write snapshots in the background.
+	Lifecycle fx.Lifecycle
+}
+
+// run writes snapshots every interval until the done channel is closed, then
+// writes a final snapshot.
+func run(w *Writer, interval time.Duration, l *zap.Logger, done <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.Flush(); err != nil {
+				l.Error("unable to write metrics snapshot", zap.String("path", w.Path()), zap.Error(err))
+			}
+
+		case <-done:
+			return
+		}
+	}
+}
+
+func startChild(in In) {
+	var (
+		w        = NewWriter(in.Config.Dir, in.Config.ID, in.Gatherer)
+		interval = in.Config.Interval
+		l        = in.Logger
+		done     = make(chan struct{})
+		stopped  = make(chan struct{})
+	)
+
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	if l == nil {
+		l = zap.NewNop()
+	}
+
+	in.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			if err := os.MkdirAll(in.Config.Dir, 0o755); err != nil {
+				return err
+			}
+
+			go run(w, interval, l, done, stopped)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(done)
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			return w.Flush()
+		},
+	})
+}
+
+// Provide sets up multiprocess aggregation according to an optional Config
+// component in the enclosing fx.App.  When no Config is supplied or its Dir is unset,
+// this option does nothing.
+//
+// Children write snapshots of the touchstone prometheus.Gatherer in the background and
+// when the application stops.  For parents, the prometheus.Gatherer is decorated with
+// NewParentGatherer so that it also exposes the merged metrics of all children.
+//
+//	app := fx.New(
+//	  touchstone.Provide(),
+//	  touchmulti.Provide(),
+//	  fx.Supply(touchmulti.Config{
+//	    Dir:  "/var/run/myagent/metrics",
+//	    Role: touchmulti.RoleChild,
+//	  }),
+//	)
+func Provide() fx.Option {
+	return fx.Options(
+		fx.Invoke(func(in In) error {
+			switch {
+			case len(in.Config.Dir) == 0 || in.Config.Role == RoleParent:
+				return nil
+
+			case in.Config.Role == RoleChild:
+				startChild(in)
+				return nil
+
+			default:
+				return fmt.Errorf("%w: %q", ErrInvalidRole, in.Config.Role)
+			}
+		}),
+		fx.Decorate(func(in In) (prometheus.Gatherer, error) {
+			if len(in.Config.Dir) == 0 || in.Config.Role != RoleParent {
+				return in.Gatherer, nil
+			}
+
+			if in.Config.Clean {
+				if err := Clean(in.Config.Dir); err != nil {
+					return nil, err
+				}
+			}
+
+			return NewParentGatherer(in.Gatherer, in.Config.Dir, in.Config.GaugeMode), nil
+		}),
+	)
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchmulti
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type ProvideSuite struct {
+	suite.Suite
+	dir string
+}
+
+func (suite *ProvideSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+}
+
+func (suite *ProvideSuite) config() touchstone.Config {
+	return touchstone.Config{
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+}
+
+func (suite *ProvideSuite) TestDisabled() {
+	var g prometheus.Gatherer
+	app := fxtest.New(
+		suite.T(),
+		touchstone.Provide(),
+		Provide(),
+		fx.Supply(suite.config()),
+		fx.Populate(&g),
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	_, ok := g.(parentGatherer)
+	suite.False(ok)
+}
+
+func (suite *ProvideSuite) TestInvalidRole() {
+	app := fx.New(
+		fx.NopLogger,
+		touchstone.Provide(),
+		Provide(),
+		fx.Supply(suite.config(), Config{Dir: suite.dir, Role: "nosuch"}),
+	)
+
+	suite.ErrorIs(app.Err(), ErrInvalidRole)
+}
+
+func (suite *ProvideSuite) TestChildAndParent() {
+	child := fxtest.New(
+		suite.T(),
+		touchstone.Provide(),
+		Provide(),
+		fx.Supply(suite.config(), Config{Dir: suite.dir, Role: RoleChild, ID: "child", Interval: time.Hour}),
+		touchstone.Counter(prometheus.CounterOpts{Name: "events", Help: "events"}),
+		fx.Invoke(
+			fx.Annotate(
+				func(c prometheus.Counter) { c.Add(3) },
+				fx.ParamTags(`name:"events"`),
+			),
+		),
+	)
+
+	child.RequireStart()
+	child.RequireStop() // writes a final snapshot
+
+	var g prometheus.Gatherer
+	parent := fxtest.New(
+		suite.T(),
+		touchstone.Provide(),
+		Provide(),
+		fx.Supply(suite.config(), Config{Dir: suite.dir, Role: RoleParent}),
+		touchstone.Gauge(prometheus.GaugeOpts{Name: "parent", Help: "parent"}),
+		fx.Invoke(
+			fx.Annotate(
+				func(prometheus.Gauge) {},
+				fx.ParamTags(`name:"parent"`),
+			),
+		),
+		fx.Populate(&g),
+	)
+
+	parent.RequireStart()
+	count, err := testutil.GatherAndCount(g)
+	suite.NoError(err)
+	suite.Equal(2, count)
+	parent.RequireStop()
+
+	// a parent that cleans discards the child's snapshot
+	parent = fxtest.New(
+		suite.T(),
+		touchstone.Provide(),
+		Provide(),
+		fx.Supply(suite.config(), Config{Dir: suite.dir, Role: RoleParent, Clean: true}),
+		fx.Populate(&g),
+	)
+
+	parent.RequireStart()
+	count, err = testutil.GatherAndCount(g)
+	suite.NoError(err)
+	suite.Zero(count)
+	parent.RequireStop()
+}
+
+func (suite *ProvideSuite) TestDefaultCollectors() {
+	child := fxtest.New(
+		suite.T(),
+		touchstone.Provide(),
+		Provide(),
+		fx.Supply(touchstone.Config{}, Config{Dir: suite.dir, Role: RoleChild, ID: "child", Interval: time.Hour}),
+	)
+
+	child.RequireStart()
+	child.RequireStop()
+
+	var g prometheus.Gatherer
+	parent := fxtest.New(
+		suite.T(),
+		touchstone.Provide(),
+		Provide(),
+		fx.Supply(touchstone.Config{}, Config{Dir: suite.dir, Role: RoleParent}),
+		fx.Populate(&g),
+	)
+
+	parent.RequireStart()
+	defer parent.RequireStop()
+
+	// the go, process, and build info families of the parent and child are merged
+	families, err := g.Gather()
+	suite.Require().NoError(err)
+	var goInfo *dto.MetricFamily
+	for _, mf := range families {
+		if mf.GetName() == "go_info" {
+			goInfo = mf
+		}
+	}
+
+	suite.Require().NotNil(goInfo)
+	suite.Require().Len(goInfo.GetMetric(), 1)
+	suite.Equal(2.0, goInfo.GetMetric()[0].GetGauge().GetValue())
+}
+
+func TestProvide(t *testing.T) {
+	suite.Run(t, new(ProvideSuite))
+}
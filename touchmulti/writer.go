@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchmulti
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Extension is the file extension of metrics snapshots written to a directory.
+const Extension = ".pb"
+
+// format is the exposition format used for snapshots.
+var format = expfmt.NewFormat(expfmt.TypeProtoDelim)
+
+// Writer writes snapshots of a single process's metrics into a shared directory.
+type Writer struct {
+	dir      string
+	id       string
+	gatherer prometheus.Gatherer
+}
+
+// NewWriter creates a Writer for the current process.  The id distinguishes this
+// process's snapshot from those of other processes.  If id is empty, the process id is used.
+func NewWriter(dir, id string, g prometheus.Gatherer) *Writer {
+	if len(id) == 0 {
+		id = strconv.Itoa(os.Getpid())
+	}
+
+	return &Writer{
+		dir:      dir,
+		id:       id,
+		gatherer: g,
+	}
+}
+
+// ID returns the identifier of the process whose metrics this Writer snapshots.
+func (w *Writer) ID() string {
+	return w.id
+}
+
+// Path returns the path of the snapshot file written by this Writer.
+func (w *Writer) Path() string {
+	return filepath.Join(w.dir, w.id+Extension)
+}
+
+// Flush gathers metrics and replaces this process's snapshot.  The snapshot is
+// written to a temporary file and renamed, so readers never see a partial snapshot.
+func (w *Writer) Flush() error {
+	families, err := w.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(w.dir, "."+w.id+"-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name()) // a no-op once renamed
+	enc := expfmt.NewEncoder(f, format)
+	for _, mf := range families {
+		if err = enc.Encode(mf); err != nil {
+			break
+		}
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), w.Path())
+	}
+
+	return err
+}
+
+// Remove deletes this process's snapshot, if it exists.  Only use this method
+// for processes whose counters should not outlive them.
+func (w *Writer) Remove() error {
+	err := os.Remove(w.Path())
+	if os.IsNotExist(err) {
+		err = nil
+	}
+
+	return err
+}
@@ -0,0 +1,15 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package touchotel adds integration with the OpenTelemetry metrics API with prometheus
+as the backend.  This package's primary use case is to allow code written against
+go.opentelemetry.io/otel/metric to share the touchstone prometheus registry, so that
+an application with both kinds of instrumentation exposes a single /metrics endpoint.
+
+OpenTelemetry instruments are exported through an OpenTelemetry SDK MeterProvider
+whose reader is a collector registered with the touchstone prometheus.Registerer.
+The usual OpenTelemetry to prometheus conversions apply, e.g. counters have a _total
+suffix and units are appended to metric names.  See Config.
+*/
+package touchotel
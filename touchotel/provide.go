@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchotel
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.uber.org/fx"
+)
+
+// Config describes how OpenTelemetry metrics are converted into prometheus metrics.
+type Config struct {
+	// Namespace is prepended to the name of each OpenTelemetry metric.  If unset,
+	// the default namespace of the touchstone Factory is used, if any.
+	Namespace string `json:"namespace" yaml:"namespace"`
+
+	// WithoutUnits disables appending units to metric names, e.g. _seconds.
+	WithoutUnits bool `json:"withoutUnits" yaml:"withoutUnits"`
+
+	// WithoutCounterSuffixes disables appending _total to the names of counters.
+	WithoutCounterSuffixes bool `json:"withoutCounterSuffixes" yaml:"withoutCounterSuffixes"`
+
+	// WithoutScopeInfo disables the otel_scope_info metric and the otel_scope labels
+	// that identify the Meter that created each metric.
+	WithoutScopeInfo bool `json:"withoutScopeInfo" yaml:"withoutScopeInfo"`
+
+	// WithoutTargetInfo disables the target_info metric, which describes the
+	// OpenTelemetry resource.
+	WithoutTargetInfo bool `json:"withoutTargetInfo" yaml:"withoutTargetInfo"`
+}
+
+// exporterOptions returns the options for the prometheus exporter described by this Config.
+func (cfg Config) exporterOptions(r prometheus.Registerer) []otelprom.Option {
+	opts := []otelprom.Option{otelprom.WithRegisterer(r)}
+	if len(cfg.Namespace) > 0 {
+		opts = append(opts, otelprom.WithNamespace(cfg.Namespace))
+	}
+
+	if cfg.WithoutUnits {
+		opts = append(opts, otelprom.WithoutUnits())
+	}
+
+	if cfg.WithoutCounterSuffixes {
+		opts = append(opts, otelprom.WithoutCounterSuffixes())
+	}
+
+	if cfg.WithoutScopeInfo {
+		opts = append(opts, otelprom.WithoutScopeInfo())
+	}
+
+	if cfg.WithoutTargetInfo {
+		opts = append(opts, otelprom.WithoutTargetInfo())
+	}
+
+	return opts
+}
+
+// NewMeterProvider creates an OpenTelemetry SDK MeterProvider whose metrics are
+// collected by the given prometheus.Registerer.  Any sdkmetric options, e.g. a
+// resource or views, are applied to the MeterProvider.
+//
+// The MeterProvider should be shut down when it is no longer used.
+func NewMeterProvider(r prometheus.Registerer, cfg Config, opts ...sdkmetric.Option) (*sdkmetric.MeterProvider, error) {
+	exporter, err := otelprom.New(cfg.exporterOptions(r)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdkmetric.NewMeterProvider(
+		append([]sdkmetric.Option{sdkmetric.WithReader(exporter)}, opts...)...,
+	), nil
+}
+
+// In holds the components used to create the OpenTelemetry MeterProvider.
+type In struct {
+	fx.In
+
+	// Config is the optional configuration for OpenTelemetry metrics.
+	Config Config `optional:"true"`
+
+	// Options are the optional sdkmetric options applied to the MeterProvider.
+	Options []sdkmetric.Option `group:"touchotel.options"`
+
+	// Registerer is the touchstone registerer that collects OpenTelemetry metrics.
+	Registerer prometheus.Registerer
+
+	// Factory is the touchstone Factory, whose default namespace is used when
+	// Config.Namespace is unset.
+	Factory *touchstone.Factory
+
+	// Lifecycle is used to shut down the MeterProvider.
+	Lifecycle fx.Lifecycle
+}
+
+// Provide bootstraps OpenTelemetry metrics backed by the touchstone prometheus
+// environment, which must be provided separately, e.g. by touchstone.Provide.  The
+// following component types are provided by this function:
+//
+//   - *sdkmetric.MeterProvider
+//   - metric.MeterProvider
+//     NOTE: This is the same object as the *sdkmetric.MeterProvider.
+//
+// The MeterProvider is shut down when the enclosing fx.App stops.  Options for
+// the MeterProvider may be supplied in the touchotel.options value group.
+//
+//	app := fx.New(
+//	  touchstone.Provide(),
+//	  touchotel.Provide(),
+//	  touchotel.Meter("github.com/acme/agent"),
+//	  fx.Invoke(
+//	    fx.Annotate(
+//	      func(m metric.Meter) error {
+//	        _, err := m.Int64Counter("requests")
+//	        return err
+//	      },
+//	      fx.ParamTags(`name:"github.com/acme/agent"`),
+//	    ),
+//	  ),
+//	)
+func Provide() fx.Option {
+	return fx.Provide(
+		func(in In) (*sdkmetric.MeterProvider, error) {
+			cfg := in.Config
+			if len(cfg.Namespace) == 0 {
+				cfg.Namespace = in.Factory.DefaultNamespace()
+			}
+
+			mp, err := NewMeterProvider(in.Registerer, cfg, in.Options...)
+			if err == nil {
+				in.Lifecycle.Append(fx.Hook{
+					OnStop: mp.Shutdown,
+				})
+			}
+
+			return mp, err
+		},
+		func(mp *sdkmetric.MeterProvider) metric.MeterProvider {
+			return mp
+		},
+	)
+}
+
+// Meter uses the injected metric.MeterProvider to create a metric.Meter with the
+// given instrumentation scope name.  The name of the returned component will be
+// the same as the scope name.
+func Meter(name string, opts ...metric.MeterOption) fx.Option {
+	return fx.Provide(fx.Annotated{
+		Name: name,
+		Target: func(mp metric.MeterProvider) metric.Meter {
+			return mp.Meter(name, opts...)
+		},
+	})
+}
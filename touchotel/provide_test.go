@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+const testScope = "github.com/xmidt-org/touchstone/touchotel"
+
+type ProvideSuite struct {
+	suite.Suite
+}
+
+func (suite *ProvideSuite) touchstoneConfig(namespace string) touchstone.Config {
+	return touchstone.Config{
+		DefaultNamespace:          namespace,
+		Pedantic:                  true,
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+}
+
+// gather returns the gathered metric families by name.
+func (suite *ProvideSuite) gather(g prometheus.Gatherer) map[string]*dto.MetricFamily {
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+
+	byName := make(map[string]*dto.MetricFamily, len(mfs))
+	for _, mf := range mfs {
+		byName[mf.GetName()] = mf
+	}
+
+	return byName
+}
+
+// record creates and updates instruments, returning any error.
+func (suite *ProvideSuite) record(m metric.Meter) error {
+	counter, err := m.Int64Counter("requests", metric.WithDescription("the number of requests"))
+	if err != nil {
+		return err
+	}
+
+	histogram, err := m.Float64Histogram("latency", metric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+
+	counter.Add(context.Background(), 3, metric.WithAttributes(attribute.String("method", "GET")))
+	histogram.Record(context.Background(), 0.25)
+	return nil
+}
+
+func (suite *ProvideSuite) TestDefaults() {
+	var (
+		g   prometheus.Gatherer
+		mp  *sdkmetric.MeterProvider
+		app = fxtest.New(
+			suite.T(),
+			touchstone.Provide(),
+			Provide(),
+			Meter(testScope),
+			fx.Supply(suite.touchstoneConfig("")),
+			fx.Invoke(
+				fx.Annotate(
+					suite.record,
+					fx.ParamTags(`name:"`+testScope+`"`),
+				),
+			),
+			fx.Populate(&g, &mp),
+		)
+	)
+
+	app.RequireStart()
+	families := suite.gather(g)
+	suite.Contains(families, "target_info")
+	suite.Contains(families, "otel_scope_info")
+	suite.Contains(families, "latency_seconds")
+
+	suite.Require().Contains(families, "requests_total")
+	requests := families["requests_total"]
+	suite.Equal("the number of requests", requests.GetHelp())
+	suite.Equal(dto.MetricType_COUNTER, requests.GetType())
+	suite.Require().Len(requests.GetMetric(), 1)
+	suite.Equal(3.0, requests.GetMetric()[0].GetCounter().GetValue())
+
+	labels := make(map[string]string)
+	for _, lp := range requests.GetMetric()[0].GetLabel() {
+		labels[lp.GetName()] = lp.GetValue()
+	}
+
+	suite.Equal("GET", labels["method"])
+	suite.Equal(testScope, labels["otel_scope_name"])
+
+	app.RequireStop()
+	suite.ErrorIs(mp.Shutdown(context.Background()), sdkmetric.ErrReaderShutdown)
+}
+
+func (suite *ProvideSuite) TestConfig() {
+	var (
+		g   prometheus.Gatherer
+		app = fxtest.New(
+			suite.T(),
+			touchstone.Provide(),
+			Provide(),
+			fx.Supply(
+				suite.touchstoneConfig("ignored"),
+				Config{
+					Namespace:              "test",
+					WithoutUnits:           true,
+					WithoutCounterSuffixes: true,
+					WithoutScopeInfo:       true,
+					WithoutTargetInfo:      true,
+				},
+			),
+			fx.Invoke(func(mp metric.MeterProvider) error {
+				return suite.record(mp.Meter(testScope))
+			}),
+			fx.Populate(&g),
+		)
+	)
+
+	app.RequireStart()
+	defer app.RequireStop()
+
+	families := suite.gather(g)
+	suite.Len(families, 2)
+	suite.Contains(families, "test_requests")
+	suite.Contains(families, "test_latency")
+}
+
+func (suite *ProvideSuite) TestDefaultNamespace() {
+	var (
+		g   prometheus.Gatherer
+		app = fxtest.New(
+			suite.T(),
+			touchstone.Provide(),
+			Provide(),
+			fx.Supply(suite.touchstoneConfig("ns")),
+			fx.Invoke(func(mp metric.MeterProvider) error {
+				return suite.record(mp.Meter(testScope))
+			}),
+			fx.Populate(&g),
+		)
+	)
+
+	app.RequireStart()
+	defer app.RequireStop()
+
+	families := suite.gather(g)
+	suite.Contains(families, "ns_requests_total")
+	suite.Contains(families, "ns_latency_seconds")
+}
+
+func (suite *ProvideSuite) TestOptions() {
+	var (
+		g   prometheus.Gatherer
+		app = fxtest.New(
+			suite.T(),
+			touchstone.Provide(),
+			Provide(),
+			fx.Supply(suite.touchstoneConfig("")),
+			fx.Provide(
+				fx.Annotate(
+					func() sdkmetric.Option {
+						return sdkmetric.WithView(
+							sdkmetric.NewView(
+								sdkmetric.Instrument{Name: "requests"},
+								sdkmetric.Stream{Name: "renamed"},
+							),
+						)
+					},
+					fx.ResultTags(`group:"touchotel.options"`),
+				),
+			),
+			fx.Invoke(func(mp metric.MeterProvider) error {
+				return suite.record(mp.Meter(testScope))
+			}),
+			fx.Populate(&g),
+		)
+	)
+
+	app.RequireStart()
+	defer app.RequireStop()
+
+	families := suite.gather(g)
+	suite.Contains(families, "renamed_total")
+	suite.NotContains(families, "requests_total")
+}
+
+func (suite *ProvideSuite) TestNewMeterProvider() {
+	r := prometheus.NewPedanticRegistry()
+	mp, err := NewMeterProvider(r, Config{WithoutTargetInfo: true, WithoutScopeInfo: true})
+	suite.Require().NoError(err)
+	defer mp.Shutdown(context.Background())
+
+	suite.Require().NoError(suite.record(mp.Meter(testScope)))
+	families := suite.gather(r)
+	suite.Len(families, 2)
+	suite.Contains(families, "requests_total")
+}
+
+func TestProvide(t *testing.T) {
+	suite.Run(t, new(ProvideSuite))
+}
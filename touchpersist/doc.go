@@ -0,0 +1,14 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package touchpersist preserves counter values across process restarts.
+//
+// A Persister periodically snapshots counters to a file.  On startup, the last
+// snapshot is restored and its values are added to the live counters whenever
+// metrics are gathered.  This hides restart resets from environments where
+// scrape intervals are long enough that rate() cannot account for them.
+//
+// Because restored counters no longer start at zero, a gauge with the timestamp
+// of the restored snapshot is also exposed.  Dashboards and alerts can use it
+// to tell a restored counter from a continuously running one.
+package touchpersist
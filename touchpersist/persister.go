@@ -0,0 +1,257 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchpersist
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultRestoreTimestamp is the name of the gauge holding the unix time, in seconds,
+// at which the restored snapshot was written.  This gauge is only exposed when
+// a snapshot was actually restored.
+const DefaultRestoreTimestamp = "counter_restore_timestamp_seconds"
+
+// format is the exposition format used for snapshots.
+var format = expfmt.NewFormat(expfmt.TypeProtoDelim)
+
+// seriesKey produces a key that uniquely identifies a metric's label values.
+func seriesKey(m *dto.Metric) string {
+	pairs := make([]string, 0, len(m.GetLabel()))
+	for _, lp := range m.GetLabel() {
+		pairs = append(pairs, lp.GetName()+"\xff"+lp.GetValue())
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\xfe")
+}
+
+// labelNames produces a key that identifies the label names of a metric.
+func labelNames(m *dto.Metric) string {
+	names := make([]string, 0, len(m.GetLabel()))
+	for _, lp := range m.GetLabel() {
+		names = append(names, lp.GetName())
+	}
+
+	sort.Strings(names)
+	return strings.Join(names, "\xff")
+}
+
+// Persister is a prometheus.Gatherer that adds restored counter values to
+// those gathered from another Gatherer, and that can snapshot the result.
+type Persister struct {
+	path     string
+	gatherer prometheus.Gatherer
+	names    map[string]bool
+
+	lock     sync.RWMutex
+	restored time.Time
+	offsets  map[string]*dto.MetricFamily
+}
+
+var _ prometheus.Gatherer = (*Persister)(nil)
+
+// New creates a Persister that snapshots counters gathered from g to the given path.
+// If any names are supplied, only counters with those fully qualified names are
+// persisted.  Otherwise, all counters are persisted.
+func New(path string, g prometheus.Gatherer, names ...string) *Persister {
+	p := &Persister{
+		path:     path,
+		gatherer: g,
+	}
+
+	if len(names) > 0 {
+		p.names = make(map[string]bool, len(names))
+		for _, n := range names {
+			p.names[n] = true
+		}
+	}
+
+	return p
+}
+
+// Path returns the snapshot file path.
+func (p *Persister) Path() string {
+	return p.path
+}
+
+// Restored returns the time at which the restored snapshot was written.  This
+// method returns the zero time if no snapshot was restored.
+func (p *Persister) Restored() time.Time {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.restored
+}
+
+func (p *Persister) persisted(mf *dto.MetricFamily) bool {
+	return mf.GetType() == dto.MetricType_COUNTER &&
+		mf.GetName() != DefaultRestoreTimestamp &&
+		(p.names == nil || p.names[mf.GetName()])
+}
+
+// Restore reads the snapshot file, if it exists.  Restored values are added to
+// subsequently gathered counters.  A missing snapshot file is not an error.
+func (p *Persister) Restore() error {
+	f, err := os.Open(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	offsets := make(map[string]*dto.MetricFamily)
+	dec := expfmt.NewDecoder(f, format)
+	for {
+		mf := new(dto.MetricFamily)
+		if err = dec.Decode(mf); err != nil {
+			break
+		}
+
+		if p.persisted(mf) {
+			offsets[mf.GetName()] = mf
+		}
+	}
+
+	if !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	p.lock.Lock()
+	p.offsets = offsets
+	p.restored = info.ModTime()
+	p.lock.Unlock()
+	return nil
+}
+
+// apply adds restored offsets to a live counter family.  Restored series that
+// haven't been touched yet in this process are added, provided they have the
+// same label names as the live series.  A restored series keeps its original
+// created timestamp, since its value accumulates from that point in time.
+func apply(mf *dto.MetricFamily, offsets []*dto.Metric) {
+	live := make(map[string]*dto.Metric, len(mf.GetMetric()))
+	for _, m := range mf.GetMetric() {
+		live[seriesKey(m)] = m
+	}
+
+	var names string
+	if len(mf.GetMetric()) > 0 {
+		names = labelNames(mf.Metric[0])
+	}
+
+	for _, offset := range offsets {
+		if m, ok := live[seriesKey(offset)]; ok {
+			m.Counter.Value = proto.Float64(m.GetCounter().GetValue() + offset.GetCounter().GetValue())
+			if created := offset.GetCounter().GetCreatedTimestamp(); created != nil {
+				m.Counter.CreatedTimestamp = created
+			}
+		} else if len(mf.GetMetric()) == 0 || labelNames(offset) == names {
+			mf.Metric = append(mf.Metric, &dto.Metric{
+				Label: offset.Label,
+				Counter: &dto.Counter{
+					Value:            proto.Float64(offset.GetCounter().GetValue()),
+					CreatedTimestamp: offset.GetCounter().GetCreatedTimestamp(),
+				},
+			})
+		}
+	}
+
+	sort.Slice(mf.Metric, func(i, j int) bool {
+		return seriesKey(mf.Metric[i]) < seriesKey(mf.Metric[j])
+	})
+}
+
+// Gather gathers metrics from the decorated Gatherer and adds any restored counter
+// values.  Restored counters are exposed even if they haven't yet been touched in
+// this process, e.g. a counter vector with no children.
+func (p *Persister) Gather() ([]*dto.MetricFamily, error) {
+	families, err := p.gatherer.Gather()
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	gathered := make(map[string]bool, len(families))
+	for _, mf := range families {
+		gathered[mf.GetName()] = true
+		if offsets, ok := p.offsets[mf.GetName()]; ok && mf.GetType() == dto.MetricType_COUNTER {
+			apply(mf, offsets.GetMetric())
+		}
+	}
+
+	for name, offsets := range p.offsets {
+		if !gathered[name] {
+			families = append(families, proto.Clone(offsets).(*dto.MetricFamily))
+		}
+	}
+
+	if !p.restored.IsZero() {
+		families = append(families, &dto.MetricFamily{
+			Name: proto.String(DefaultRestoreTimestamp),
+			Help: proto.String("the unix time, in seconds, at which restored counter values were saved"),
+			Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{
+				{Gauge: &dto.Gauge{Value: proto.Float64(float64(p.restored.UnixNano()) / 1e9)}},
+			},
+		})
+
+	}
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].GetName() < families[j].GetName()
+	})
+
+	return families, err
+}
+
+// Save writes a snapshot of the persisted counters, including any restored values.
+// The snapshot is written to a temporary file and renamed, so a crash while saving
+// never corrupts the previous snapshot.
+func (p *Persister) Save() error {
+	families, err := p.Gather()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(p.path), "."+filepath.Base(p.path)+"-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name()) // a no-op once renamed
+	enc := expfmt.NewEncoder(f, format)
+	for _, mf := range families {
+		if !p.persisted(mf) {
+			continue
+		}
+
+		if err = enc.Encode(mf); err != nil {
+			break
+		}
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), p.path)
+	}
+
+	return err
+}
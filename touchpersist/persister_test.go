@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchpersist
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type PersisterSuite struct {
+	suite.Suite
+	path string
+}
+
+func (suite *PersisterSuite) SetupTest() {
+	suite.path = filepath.Join(suite.T().TempDir(), "counters.pb")
+}
+
+// process simulates a single run of a process.
+type process struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	other    prometheus.Counter
+	gauge    prometheus.Gauge
+}
+
+func (suite *PersisterSuite) newProcess() process {
+	p := process{
+		registry: prometheus.NewPedanticRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests", Help: "requests"}, []string{"code"}),
+		other:    prometheus.NewCounter(prometheus.CounterOpts{Name: "other", Help: "other"}),
+		gauge:    prometheus.NewGauge(prometheus.GaugeOpts{Name: "gauge", Help: "gauge"}),
+	}
+
+	p.registry.MustRegister(p.requests, p.other, p.gauge)
+	return p
+}
+
+func (suite *PersisterSuite) TestNoSnapshot() {
+	p := New(suite.path, suite.newProcess().registry)
+	suite.Equal(suite.path, p.Path())
+	suite.NoError(p.Restore())
+	suite.True(p.Restored().IsZero())
+
+	families, err := p.Gather()
+	suite.NoError(err)
+	suite.Len(families, 2)
+}
+
+func (suite *PersisterSuite) TestRestore() {
+	first := suite.newProcess()
+	first.requests.WithLabelValues("200").Add(5)
+	first.requests.WithLabelValues("500").Add(1)
+	first.other.Add(3)
+	first.gauge.Set(10)
+
+	p := New(suite.path, first.registry)
+	suite.Require().NoError(p.Restore())
+	suite.Require().NoError(p.Save())
+
+	second := suite.newProcess()
+	second.requests.WithLabelValues("200").Add(2)
+	p = New(suite.path, second.registry)
+	suite.Require().NoError(p.Restore())
+	suite.False(p.Restored().IsZero())
+
+	suite.NoError(
+		testutil.GatherAndCompare(
+			p,
+			strings.NewReader(`
+# HELP gauge gauge
+# TYPE gauge gauge
+gauge 0
+# HELP other other
+# TYPE other counter
+other 3
+# HELP requests requests
+# TYPE requests counter
+requests{code="200"} 7
+requests{code="500"} 1
+`),
+			"gauge", "other", "requests",
+		),
+	)
+
+	count, err := testutil.GatherAndCount(p, DefaultRestoreTimestamp)
+	suite.NoError(err)
+	suite.Equal(1, count)
+
+	// restored values accumulate across restarts
+	suite.Require().NoError(p.Save())
+	third := suite.newProcess()
+	p = New(suite.path, third.registry, "requests")
+	suite.Require().NoError(p.Restore())
+
+	suite.NoError(
+		testutil.GatherAndCompare(
+			p,
+			strings.NewReader(`
+# HELP other other
+# TYPE other counter
+other 0
+# HELP requests requests
+# TYPE requests counter
+requests{code="200"} 7
+requests{code="500"} 1
+`),
+			"other", "requests",
+		),
+	)
+}
+
+func (suite *PersisterSuite) TestUntouched() {
+	first := suite.newProcess()
+	first.requests.WithLabelValues("404").Add(3)
+	suite.Require().NoError(New(suite.path, first.registry).Save())
+
+	// the restored vector has no children in this process
+	p := New(suite.path, suite.newProcess().registry)
+	suite.Require().NoError(p.Restore())
+
+	suite.NoError(
+		testutil.GatherAndCompare(
+			p,
+			strings.NewReader(`
+# HELP requests requests
+# TYPE requests counter
+requests{code="404"} 3
+`),
+			"requests",
+		),
+	)
+}
+
+func (suite *PersisterSuite) TestCreatedTimestamp() {
+	created := timestamppb.New(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	snapshot := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return []*dto.MetricFamily{
+			{
+				Name: proto.String("requests"),
+				Help: proto.String("requests"),
+				Type: dto.MetricType_COUNTER.Enum(),
+				Metric: []*dto.Metric{
+					{
+						Label:   []*dto.LabelPair{{Name: proto.String("code"), Value: proto.String("200")}},
+						Counter: &dto.Counter{Value: proto.Float64(5), CreatedTimestamp: created},
+					},
+					{
+						Label:   []*dto.LabelPair{{Name: proto.String("code"), Value: proto.String("500")}},
+						Counter: &dto.Counter{Value: proto.Float64(1), CreatedTimestamp: created},
+					},
+				},
+			},
+		}, nil
+	})
+
+	suite.Require().NoError(New(suite.path, snapshot).Save())
+
+	second := suite.newProcess()
+	second.requests.WithLabelValues("200").Add(2)
+	p := New(suite.path, second.registry)
+	suite.Require().NoError(p.Restore())
+
+	families, err := p.Gather()
+	suite.Require().NoError(err)
+
+	var checked int
+	for _, mf := range families {
+		if mf.GetName() != "requests" {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			suite.True(proto.Equal(created, m.GetCounter().GetCreatedTimestamp()))
+			checked++
+		}
+	}
+
+	suite.Equal(2, checked)
+}
+
+func (suite *PersisterSuite) TestErrors() {
+	suite.Require().NoError(os.WriteFile(suite.path, []byte("garbage"), 0o600))
+	suite.Error(New(suite.path, prometheus.NewRegistry()).Restore())
+
+	suite.Error(New(suite.path+"/nosuch", prometheus.NewRegistry()).Restore())
+	suite.Error(New(filepath.Join(suite.path, "nosuch", "counters.pb"), prometheus.NewRegistry()).Save())
+}
+
+func TestPersister(t *testing.T) {
+	suite.Run(t, new(PersisterSuite))
+}
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchpersist
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// DefaultInterval is the interval at which snapshots are saved when none is configured.
+const DefaultInterval = time.Minute
+
+// Config describes counter persistence.
+type Config struct {
+	// Path is the snapshot file.  If unset, persistence is disabled.
+	Path string `json:"path" yaml:"path"`
+
+	// Interval is how often a snapshot is saved.  A final snapshot is always saved
+	// when the application stops.  DefaultInterval is used if unset.
+	Interval time.Duration `json:"interval" yaml:"interval"`
+
+	// Counters are the fully qualified names of the counters to persist.  If unset,
+	// all counters are persisted.
+	Counters []string `json:"counters" yaml:"counters"`
+}
+
+// In holds the components used to set up counter persistence.
+type In struct {
+	fx.In
+
+	// Config is the optional persistence configuration.  If not supplied,
+	// persistence is disabled.
+	Config Config `optional:"true"`
+
+	// Gatherer is the gatherer for this process's metrics.
+	Gatherer prometheus.Gatherer
+
+	// Logger is the optional logger used to report problems saving snapshots.
+	Logger *zap.Logger `optional:"true"`
+
+	// Lifecycle is used to save snapshots in the background.
+	Lifecycle fx.Lifecycle
+}
+
+func run(p *Persister, interval time.Duration, l *zap.Logger, done <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.Save(); err != nil {
+				l.Error("unable to save counter snapshot", zap.String("path", p.Path()), zap.Error(err))
+			}
+
+		case <-done:
+			return
+		}
+	}
+}
+
+// Provide sets up counter persistence according to an optional Config component
+// in the enclosing fx.App.  When no Config is supplied or its Path is unset, this
+// option does nothing.
+//
+// Otherwise, the last snapshot is restored at startup and the prometheus.Gatherer
+// is decorated so that it exposes restored counter values.  Snapshots are saved in
+// the background and when the application stops.
+//
+//	app := fx.New(
+//	  touchstone.Provide(),
+//	  touchpersist.Provide(),
+//	  fx.Supply(touchpersist.Config{
+//	    Path: "/var/lib/myapp/counters.pb",
+//	  }),
+//	)
+func Provide() fx.Option {
+	return fx.Options(
+		fx.Decorate(decorate),
+
+		// ensure the decorator runs even if nothing else uses the Gatherer
+		fx.Invoke(func(prometheus.Gatherer) {}),
+	)
+}
+
+func decorate(in In) (prometheus.Gatherer, error) {
+	if len(in.Config.Path) == 0 {
+		return in.Gatherer, nil
+	}
+
+	p := New(in.Config.Path, in.Gatherer, in.Config.Counters...)
+	if err := p.Restore(); err != nil {
+		return nil, err
+	}
+
+	var (
+		interval = in.Config.Interval
+		l        = in.Logger
+		done     = make(chan struct{})
+		stopped  = make(chan struct{})
+	)
+
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	if l == nil {
+		l = zap.NewNop()
+	}
+
+	in.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go run(p, interval, l, done, stopped)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(done)
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			return p.Save()
+		},
+	})
+
+	return p, nil
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchpersist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type ProvideSuite struct {
+	suite.Suite
+	path string
+}
+
+func (suite *ProvideSuite) SetupTest() {
+	suite.path = filepath.Join(suite.T().TempDir(), "counters.pb")
+}
+
+func (suite *ProvideSuite) newApp(cfg Config, add float64, g *prometheus.Gatherer) *fxtest.App {
+	return fxtest.New(
+		suite.T(),
+		touchstone.Provide(),
+		Provide(),
+		fx.Supply(
+			touchstone.Config{
+				DisableGoCollector:        true,
+				DisableProcessCollector:   true,
+				DisableBuildInfoCollector: true,
+			},
+			cfg,
+		),
+		touchstone.Counter(prometheus.CounterOpts{Name: "events", Help: "events"}),
+		fx.Invoke(
+			fx.Annotate(
+				func(c prometheus.Counter) { c.Add(add) },
+				fx.ParamTags(`name:"events"`),
+			),
+		),
+		fx.Populate(g),
+	)
+}
+
+func (suite *ProvideSuite) TestDisabled() {
+	var g prometheus.Gatherer
+	app := suite.newApp(Config{}, 1, &g)
+	app.RequireStart()
+	app.RequireStop()
+
+	_, ok := g.(*Persister)
+	suite.False(ok)
+}
+
+func (suite *ProvideSuite) TestRestart() {
+	var g prometheus.Gatherer
+	app := suite.newApp(Config{Path: suite.path, Interval: time.Hour}, 2, &g)
+	app.RequireStart()
+	app.RequireStop() // saves a final snapshot
+	suite.FileExists(suite.path)
+
+	app = suite.newApp(Config{Path: suite.path}, 3, &g)
+	app.RequireStart()
+	families, err := g.Gather()
+	suite.Require().NoError(err)
+	suite.Require().Len(families, 2)
+	suite.Equal("events", families[1].GetName())
+	suite.Equal(5.0, families[1].Metric[0].GetCounter().GetValue())
+	app.RequireStop()
+}
+
+func (suite *ProvideSuite) TestRestoreError() {
+	suite.Require().NoError(os.WriteFile(suite.path, []byte("garbage"), 0o600))
+
+	var g prometheus.Gatherer
+	app := fx.New(
+		fx.NopLogger,
+		touchstone.Provide(),
+		Provide(),
+		fx.Supply(Config{Path: suite.path}),
+		fx.Populate(&g),
+	)
+
+	suite.Error(app.Err())
+}
+
+func TestProvide(t *testing.T) {
+	suite.Run(t, new(ProvideSuite))
+}
@@ -0,0 +1,11 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package touchpush pushes the metrics of an uber/fx application to a Prometheus
+// Pushgateway.  This is useful for batch jobs and other processes that may not live
+// long enough, or may not be reachable, to be scraped.
+//
+// The touchstone prometheus.Gatherer is pushed periodically while the application
+// runs, and once more when the application stops so that the final values of its
+// metrics are not lost.
+package touchpush
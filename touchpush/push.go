@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchpush
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// DefaultInterval is the interval at which metrics are pushed when
+// none is configured.
+const DefaultInterval = 15 * time.Second
+
+// ErrNoJob indicates that a Config had a URL but no Job.
+var ErrNoJob = errors.New("A job name is required to push metrics")
+
+// Config describes how metrics are pushed to a Pushgateway.
+type Config struct {
+	// URL is the base URL of the Pushgateway, e.g. http://pushgateway:9091.  If unset,
+	// metrics are not pushed.
+	URL string `json:"url" yaml:"url"`
+
+	// Job is the job name under which metrics are pushed.  This field is required
+	// if URL is set.
+	Job string `json:"job" yaml:"job"`
+
+	// Grouping are the optional grouping labels, in addition to the job, that identify
+	// the group of metrics pushed by this process, e.g. an instance label.
+	Grouping map[string]string `json:"grouping" yaml:"grouping"`
+
+	// Interval is how often metrics are pushed.  A final push is always made when
+	// the application stops.  DefaultInterval is used if unset.
+	Interval time.Duration `json:"interval" yaml:"interval"`
+
+	// Add indicates that pushes only replace metrics with the same names as the pushed
+	// metrics, using HTTP POST.  By default, each push replaces all the metrics in the
+	// group, using HTTP PUT.
+	Add bool `json:"add" yaml:"add"`
+}
+
+// In holds the components used to push metrics.
+type In struct {
+	fx.In
+
+	// Config is the optional push configuration.  If not supplied,
+	// metrics are not pushed.
+	Config Config `optional:"true"`
+
+	// Gatherer is the source of the pushed metrics.
+	Gatherer prometheus.Gatherer
+
+	// Client is the optional HTTP client used to push metrics.  If not supplied,
+	// http.DefaultClient is used.
+	Client push.HTTPDoer `optional:"true"`
+
+	// Logger is the optional logger used to report problems pushing metrics.
+	Logger *zap.Logger `optional:"true"`
+
+	// Lifecycle is used to push metrics in the background.
+	Lifecycle fx.Lifecycle
+}
+
+// NewPusher creates a push.Pusher for the given configuration that pushes
+// metrics from the given Gatherer.
+func NewPusher(cfg Config, g prometheus.Gatherer) (*push.Pusher, error) {
+	if len(cfg.Job) == 0 {
+		return nil, ErrNoJob
+	}
+
+	p := push.New(cfg.URL, cfg.Job).Gatherer(g)
+	for name, value := range cfg.Grouping {
+		p = p.Grouping(name, value)
+	}
+
+	return p, nil
+}
+
+// pushFunc returns the function that pushes metrics, as selected by Config.Add.
+func (cfg Config) pushFunc(p *push.Pusher) func(context.Context) error {
+	if cfg.Add {
+		return p.AddContext
+	}
+
+	return p.PushContext
+}
+
+// run pushes metrics every interval until the done channel is closed.
+func run(pushFn func(context.Context) error, interval time.Duration, l *zap.Logger, done <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		// abandon any push in progress when stopping, since a final push follows
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := pushFn(ctx); err != nil && ctx.Err() == nil {
+				l.Error("unable to push metrics", zap.Error(err))
+			}
+
+		case <-done:
+			return
+		}
+	}
+}
+
+func start(in In) error {
+	p, err := NewPusher(in.Config, in.Gatherer)
+	if err != nil {
+		return err
+	}
+
+	if in.Client != nil {
+		p = p.Client(in.Client)
+	}
+
+	var (
+		pushFn   = in.Config.pushFunc(p)
+		interval = in.Config.Interval
+		l        = in.Logger
+		done     = make(chan struct{})
+		stopped  = make(chan struct{})
+	)
+
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	if l == nil {
+		l = zap.NewNop()
+	}
+
+	in.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go run(pushFn, interval, l, done, stopped)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(done)
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			return pushFn(ctx)
+		},
+	})
+
+	return nil
+}
+
+// Provide pushes metrics to a Pushgateway according to an optional Config
+// component in the enclosing fx.App.  When no Config is supplied or its URL is unset,
+// this option does nothing.
+//
+// The touchstone prometheus.Gatherer is pushed every Config.Interval while the
+// application runs, and once more when the application stops.  A failure of the
+// final push is returned from the OnStop hook.
+//
+//	app := fx.New(
+//	  touchstone.Provide(),
+//	  touchpush.Provide(),
+//	  fx.Supply(touchpush.Config{
+//	    URL: "http://pushgateway:9091",
+//	    Job: "nightly-report",
+//	    Grouping: map[string]string{
+//	      "instance": "worker-1",
+//	    },
+//	  }),
+//	)
+func Provide() fx.Option {
+	return fx.Invoke(func(in In) error {
+		if len(in.Config.URL) == 0 {
+			return nil
+		}
+
+		return start(in)
+	})
+}
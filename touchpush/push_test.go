@@ -0,0 +1,214 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchpush
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+// pushed is a request received by the test Pushgateway.
+type pushed struct {
+	method string
+	path   string
+	body   string
+}
+
+type PushSuite struct {
+	suite.Suite
+
+	server *httptest.Server
+	status int
+
+	lock   sync.Mutex
+	pushes []pushed
+}
+
+func (suite *PushSuite) SetupTest() {
+	suite.status = http.StatusOK
+	suite.pushes = nil
+	suite.server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		suite.lock.Lock()
+		suite.pushes = append(suite.pushes, pushed{method: r.Method, path: r.URL.Path, body: string(body)})
+		suite.lock.Unlock()
+		rw.WriteHeader(suite.status)
+	}))
+}
+
+func (suite *PushSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *PushSuite) pushCount() int {
+	suite.lock.Lock()
+	defer suite.lock.Unlock()
+	return len(suite.pushes)
+}
+
+func (suite *PushSuite) lastPush() pushed {
+	suite.lock.Lock()
+	defer suite.lock.Unlock()
+	suite.Require().NotEmpty(suite.pushes)
+	return suite.pushes[len(suite.pushes)-1]
+}
+
+func (suite *PushSuite) options(cfg Config) fx.Option {
+	return fx.Options(
+		touchstone.Provide(),
+		Provide(),
+		fx.Supply(
+			touchstone.Config{
+				DisableGoCollector:        true,
+				DisableProcessCollector:   true,
+				DisableBuildInfoCollector: true,
+			},
+			cfg,
+		),
+		touchstone.Counter(prometheus.CounterOpts{Name: "events", Help: "events"}),
+		fx.Invoke(
+			fx.Annotate(
+				func(c prometheus.Counter) { c.Add(3) },
+				fx.ParamTags(`name:"events"`),
+			),
+		),
+	)
+}
+
+func (suite *PushSuite) TestNewPusher() {
+	suite.Run("NoJob", func() {
+		p, err := NewPusher(Config{URL: suite.server.URL}, prometheus.NewRegistry())
+		suite.ErrorIs(err, ErrNoJob)
+		suite.Nil(p)
+	})
+
+	suite.Run("Grouping", func() {
+		p, err := NewPusher(
+			Config{
+				URL:      suite.server.URL,
+				Job:      "test",
+				Grouping: map[string]string{"zone": "east", "instance": "worker-1"},
+			},
+			prometheus.NewRegistry(),
+		)
+
+		suite.Require().NoError(err)
+		suite.Require().NoError(p.Push())
+
+		// the order of grouping labels in the path is unspecified
+		last := suite.lastPush()
+		suite.Equal(http.MethodPut, last.method)
+		suite.Contains(
+			[]string{
+				"/metrics/job/test/instance/worker-1/zone/east",
+				"/metrics/job/test/zone/east/instance/worker-1",
+			},
+			last.path,
+		)
+	})
+}
+
+func (suite *PushSuite) TestDisabled() {
+	app := fxtest.New(
+		suite.T(),
+		suite.options(Config{}),
+	)
+
+	app.RequireStart()
+	app.RequireStop()
+	suite.Zero(suite.pushCount())
+}
+
+func (suite *PushSuite) TestNoJob() {
+	app := fx.New(
+		fx.NopLogger,
+		suite.options(Config{URL: suite.server.URL}),
+	)
+
+	suite.ErrorIs(app.Err(), ErrNoJob)
+}
+
+func (suite *PushSuite) testPush(add bool, expectedMethod string) {
+	app := fxtest.New(
+		suite.T(),
+		suite.options(Config{
+			URL:      suite.server.URL,
+			Job:      "test",
+			Grouping: map[string]string{"instance": "worker-1"},
+			Interval: 10 * time.Millisecond,
+			Add:      add,
+		}),
+	)
+
+	app.RequireStart()
+	suite.Eventually(
+		func() bool { return suite.pushCount() > 0 },
+		5*time.Second,
+		10*time.Millisecond,
+	)
+
+	app.RequireStop()
+	count := suite.pushCount()
+	last := suite.lastPush()
+	suite.Equal(expectedMethod, last.method)
+	suite.Equal("/metrics/job/test/instance/worker-1", last.path)
+	suite.NotEmpty(last.body)
+
+	// nothing is pushed after the final push
+	time.Sleep(50 * time.Millisecond)
+	suite.Equal(count, suite.pushCount())
+}
+
+func (suite *PushSuite) TestPush() {
+	suite.Run("Push", func() { suite.testPush(false, http.MethodPut) })
+	suite.Run("Add", func() { suite.testPush(true, http.MethodPost) })
+}
+
+func (suite *PushSuite) TestFinalPush() {
+	app := fxtest.New(
+		suite.T(),
+		suite.options(Config{
+			URL:      suite.server.URL,
+			Job:      "test",
+			Interval: time.Hour,
+		}),
+	)
+
+	app.RequireStart()
+	suite.Zero(suite.pushCount())
+	app.RequireStop()
+	suite.Equal(1, suite.pushCount())
+	suite.Contains(suite.lastPush().body, "events")
+}
+
+func (suite *PushSuite) TestFinalPushError() {
+	suite.status = http.StatusInternalServerError
+	app := fx.New(
+		fx.NopLogger,
+		suite.options(Config{
+			URL:      suite.server.URL,
+			Job:      "test",
+			Interval: time.Hour,
+		}),
+	)
+
+	suite.Require().NoError(app.Start(context.Background()))
+	suite.Error(app.Stop(context.Background()))
+	suite.Equal(1, suite.pushCount())
+}
+
+func TestPush(t *testing.T) {
+	suite.Run(t, new(PushSuite))
+}
@@ -0,0 +1,11 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package touchrules generates Prometheus alerting and recording rules from
+// the same metadata used to create metrics.  Rules are parameterized by the
+// metric names that touchhttp bundles and touchbundle structs actually produce,
+// so alerts stay in sync with code when metrics are renamed or reconfigured.
+//
+// The output of this package is a standard Prometheus rule file, suitable for
+// loading by Prometheus or checking with promtool.
+package touchrules
@@ -0,0 +1,546 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchrules
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+	"github.com/xmidt-org/touchstone/touchbundle"
+	"github.com/xmidt-org/touchstone/touchhttp"
+)
+
+const (
+	// DefaultGroup is the rule group name used when none is configured.
+	DefaultGroup = "touchstone"
+
+	// DefaultWindow is the range used in rate() expressions when none is configured.
+	DefaultWindow = "5m"
+
+	// DefaultFor is the duration an alert condition must hold before firing
+	// when an SLO does not specify one.
+	DefaultFor = "10m"
+
+	// DefaultSeverity is the severity label applied to alerts when an SLO
+	// does not specify one.
+	DefaultSeverity = "warning"
+
+	// DefaultLatencyObjective is the fraction of requests that must complete
+	// within the latency threshold when an SLO does not specify one.
+	DefaultLatencyObjective = 0.99
+
+	// DefaultBurnRate is the multiple of the latency error budget that triggers
+	// an alert when an SLO does not specify one.  This is the customary fast burn
+	// rate that consumes 2% of a 30 day budget in one hour.
+	DefaultBurnRate = 14.4
+
+	// DefaultErrorMatcher is the label matcher that selects failed requests from
+	// a request counter that has no separate error counter.
+	DefaultErrorMatcher = touchhttp.CodeLabel + `=~"5.."`
+)
+
+var (
+	// ErrConflictingRule indicates that two sources of rules generated a recording
+	// rule with the same name but different expressions.
+	ErrConflictingRule = errors.New("conflicting recording rule")
+
+	// ErrInvalidField indicates that a Bundle referred to a field that does not
+	// exist or that is not of an appropriate metric type.
+	ErrInvalidField = errors.New("invalid bundle field")
+)
+
+// SLO describes the service level objectives alerted upon for a set of requests.
+// The zero value generates recording rules but no alerts.
+type SLO struct {
+	// ErrorRatio is the maximum tolerated ratio of failed requests to all requests.
+	// If unset, no error rate alert is generated.
+	ErrorRatio float64
+
+	// LatencyThreshold is the upper bound of the histogram bucket requests must fall
+	// into, in the units of the duration metric.  This value should be one of the
+	// histogram's buckets.  If unset, no latency alert is generated.  Latency alerts
+	// are never generated for summaries.
+	LatencyThreshold float64
+
+	// LatencyObjective is the fraction of requests that must complete within
+	// LatencyThreshold.  DefaultLatencyObjective is used if this field is unset.
+	LatencyObjective float64
+
+	// BurnRate is the multiple of the latency error budget, 1 - LatencyObjective,
+	// that triggers an alert.  DefaultBurnRate is used if this field is unset.
+	BurnRate float64
+
+	// For is how long an alert condition must hold before firing.  DefaultFor
+	// is used if this field is unset.
+	For string
+
+	// Severity is the severity label of generated alerts.  DefaultSeverity is
+	// used if this field is unset.
+	Severity string
+}
+
+// Server describes the rules to generate for a single HTTP server.
+type Server struct {
+	// Bundle is the ServerBundle used to create the server's instrumenter.
+	Bundle touchhttp.ServerBundle
+
+	// Name is the value of the touchhttp.ServerLabel for this server.  If unset,
+	// alerts do not select by server label.
+	Name string
+
+	// Alert is the prefix for generated alert names.  If unset, the prefix is
+	// derived from Name, e.g. "servers.main" becomes "ServersMain".
+	Alert string
+
+	// SLO describes the alerts to generate.
+	SLO SLO
+}
+
+// Client describes the rules to generate for a single HTTP client.  A client's
+// error ratio is based on its error counter, i.e. requests that produced no response.
+type Client struct {
+	// Bundle is the ClientBundle used to create the client's instrumenter.
+	Bundle touchhttp.ClientBundle
+
+	// Name is the value of the touchhttp.ClientLabel for this client.  If unset,
+	// alerts do not select by client label.
+	Name string
+
+	// Alert is the prefix for generated alert names.  If unset, the prefix is
+	// derived from Name.
+	Alert string
+
+	// SLO describes the alerts to generate.
+	SLO SLO
+}
+
+// Bundle describes the rules to generate for a touchbundle struct.  Metrics are
+// referred to by struct field name, and their names are resolved with touchbundle.Describe.
+type Bundle struct {
+	// Prototype is the touchbundle struct, or pointer to struct, that describes the metrics.
+	Prototype interface{}
+
+	// Alert is the prefix for generated alert names.  If unset, the prefix is
+	// derived from the Total metric name.
+	Alert string
+
+	// Total is the name of the counter field that counts all requests.  This field is required.
+	Total string
+
+	// Errors is the name of the counter field that counts failed requests.  If unset,
+	// failed requests are selected from Total with ErrorMatcher.
+	Errors string
+
+	// ErrorMatcher selects failed requests from Total when Errors is unset.
+	// DefaultErrorMatcher is used if this field is unset.
+	ErrorMatcher string
+
+	// Duration is the name of the histogram or summary field that observes request
+	// durations.  If unset, no latency rules are generated.
+	Duration string
+
+	// By are the labels that recording rules aggregate by.
+	By []string
+
+	// Matchers are the label values that alerts select, e.g. to alert on a single
+	// label value when By aggregates over several.
+	Matchers map[string]string
+
+	// SLO describes the alerts to generate.
+	SLO SLO
+}
+
+// Config describes the rule file to generate.
+type Config struct {
+	// Defaults holds the Namespace and Subsystem applied by the touchstone.Factory
+	// that created the metrics, e.g. from touchstone.Config.
+	Defaults prometheus.Opts
+
+	// Naming is the NamingPolicy of the touchstone.Factory that created the metrics,
+	// if any.
+	Naming touchstone.NamingPolicy
+
+	// Group is the name of the generated rule group.  DefaultGroup is used if
+	// this field is unset.
+	Group string
+
+	// Interval is the optional evaluation interval of the generated rule group.
+	Interval string
+
+	// Window is the range used in rate() expressions.  DefaultWindow is used
+	// if this field is unset.
+	Window string
+
+	// Servers are the HTTP servers to generate rules for, in order.
+	Servers []Server
+
+	// Clients are the HTTP clients to generate rules for, in order.
+	Clients []Client
+
+	// Bundles are the touchbundle structs to generate rules for, in order.
+	Bundles []Bundle
+}
+
+// target is the normalized description of a set of requests to generate rules for.
+type target struct {
+	alert        string
+	total        string
+	errors       string
+	errorMatcher string
+	duration     string
+	histogram    bool
+	by           []string
+	matchers     []string
+	slo          SLO
+}
+
+// generator accumulates rules.  Recording rules are emitted once per name.
+type generator struct {
+	window  string
+	records map[string]string
+	rules   []Rule
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+// camelize turns an arbitrary name into an alert name, e.g. "servers.main" becomes "ServersMain".
+func camelize(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(part[1:])
+	}
+
+	return b.String()
+}
+
+func matcher(label, value string) []string {
+	if len(value) == 0 {
+		return nil
+	}
+
+	return []string{fmt.Sprintf(`%s=%q`, label, value)}
+}
+
+func braces(matchers ...string) string {
+	if len(matchers) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(matchers, ",") + "}"
+}
+
+func sumBy(by []string, expr string) string {
+	if len(by) == 0 {
+		return "sum(" + expr + ")"
+	}
+
+	return "sum by (" + strings.Join(by, ", ") + ") (" + expr + ")"
+}
+
+// recordName follows the level:metric:operations naming convention for recording rules.
+// The level is omitted when a rule aggregates over every label, since a name can't begin
+// with an empty segment.
+func recordName(by []string, metric, operation string) string {
+	if len(by) == 0 {
+		return metric + ":" + operation
+	}
+
+	return strings.Join(by, "_") + ":" + metric + ":" + operation
+}
+
+func (g *generator) record(name, expr string) error {
+	if existing, ok := g.records[name]; ok {
+		if existing != expr {
+			return fmt.Errorf("%w: %s", ErrConflictingRule, name)
+		}
+
+		return nil
+	}
+
+	g.records[name] = expr
+	g.rules = append(g.rules, Rule{Record: name, Expr: expr})
+	return nil
+}
+
+func (g *generator) alert(t target, suffix, expr, summary string) {
+	forDuration := t.slo.For
+	if len(forDuration) == 0 {
+		forDuration = DefaultFor
+	}
+
+	severity := t.slo.Severity
+	if len(severity) == 0 {
+		severity = DefaultSeverity
+	}
+
+	g.rules = append(g.rules, Rule{
+		Alert: t.alert + suffix,
+		Expr:  expr,
+		For:   forDuration,
+		Labels: map[string]string{
+			"severity": severity,
+		},
+		Annotations: map[string]string{
+			"summary":     summary,
+			"description": "The current value is {{ $value | humanizePercentage }}.",
+		},
+	})
+}
+
+func (g *generator) add(t target) error {
+	rate := func(metric string, matchers ...string) string {
+		return sumBy(t.by, fmt.Sprintf("rate(%s%s[%s])", metric, braces(matchers...), g.window))
+	}
+
+	errorRatio := recordName(t.by, t.total, "error_ratio_rate"+g.window)
+	numerator := rate(t.errors)
+	if len(t.errors) == 0 {
+		numerator = rate(t.total, t.errorMatcher)
+	}
+
+	if err := g.record(errorRatio, numerator+" / "+rate(t.total)); err != nil {
+		return err
+	}
+
+	if t.slo.ErrorRatio > 0 {
+		g.alert(
+			t,
+			"HighErrorRate",
+			fmt.Sprintf("%s%s > %s", errorRatio, braces(t.matchers...), formatFloat(t.slo.ErrorRatio)),
+			fmt.Sprintf("%s error ratio is above %s", t.alert, formatFloat(t.slo.ErrorRatio)),
+		)
+	}
+
+	if !t.histogram || t.slo.LatencyThreshold <= 0 {
+		return nil
+	}
+
+	le := strconv.FormatFloat(t.slo.LatencyThreshold, 'g', -1, 64)
+	slowRatio := recordName(t.by, t.duration, "slow_ratio_le"+strings.ReplaceAll(le, ".", "_")+"_rate"+g.window)
+	if err := g.record(
+		slowRatio,
+		fmt.Sprintf("1 - (%s / %s)", rate(t.duration+"_bucket", fmt.Sprintf(`le="%s"`, le)), rate(t.duration+"_count")),
+	); err != nil {
+		return err
+	}
+
+	objective := t.slo.LatencyObjective
+	if objective <= 0 {
+		objective = DefaultLatencyObjective
+	}
+
+	burnRate := t.slo.BurnRate
+	if burnRate <= 0 {
+		burnRate = DefaultBurnRate
+	}
+
+	g.alert(
+		t,
+		"LatencyBudgetBurn",
+		fmt.Sprintf("%s%s > %s", slowRatio, braces(t.matchers...), formatFloat(burnRate*(1-objective))),
+		fmt.Sprintf("%s is burning its latency budget %sx too fast", t.alert, formatFloat(burnRate)),
+	)
+
+	return nil
+}
+
+func serverTarget(s Server, defaults prometheus.Opts, policy touchstone.NamingPolicy) target {
+	names := s.Bundle.MetricNames(defaults, policy)
+	alert := s.Alert
+	if len(alert) == 0 {
+		alert = camelize(s.Name)
+	}
+
+	if len(alert) == 0 {
+		alert = "Server"
+	}
+
+	return target{
+		alert:        alert,
+		total:        names.Count,
+		errorMatcher: DefaultErrorMatcher,
+		duration:     names.Duration,
+		histogram:    names.DurationType == touchhttp.ObserverHistogram,
+		by:           []string{touchhttp.ServerLabel},
+		matchers:     matcher(touchhttp.ServerLabel, s.Name),
+		slo:          s.SLO,
+	}
+}
+
+func clientTarget(c Client, defaults prometheus.Opts, policy touchstone.NamingPolicy) target {
+	names := c.Bundle.MetricNames(defaults, policy)
+	alert := c.Alert
+	if len(alert) == 0 {
+		alert = camelize(c.Name)
+	}
+
+	if len(alert) == 0 {
+		alert = "Client"
+	}
+
+	return target{
+		alert:     alert,
+		total:     names.Count,
+		errors:    names.ErrorCount,
+		duration:  names.Duration,
+		histogram: names.DurationType == touchhttp.ObserverHistogram,
+		by:        []string{touchhttp.ClientLabel},
+		matchers:  matcher(touchhttp.ClientLabel, c.Name),
+		slo:       c.SLO,
+	}
+}
+
+// lookup finds the metric described for a bundle field, which must be of one of the given types.
+func lookup(metrics []touchbundle.Metric, field string, types ...string) (touchbundle.Metric, error) {
+	for _, m := range metrics {
+		if m.Field != field {
+			continue
+		}
+
+		for _, t := range types {
+			if m.Type == t {
+				return m, nil
+			}
+		}
+
+		return m, fmt.Errorf("%w: %s is a %s", ErrInvalidField, field, m.Type)
+	}
+
+	return touchbundle.Metric{}, fmt.Errorf("%w: no such metric field '%s'", ErrInvalidField, field)
+}
+
+func bundleTarget(b Bundle, defaults prometheus.Opts, policy touchstone.NamingPolicy) (t target, err error) {
+	metrics, err := touchbundle.Describe(b.Prototype, defaults, policy)
+	if err != nil {
+		return
+	}
+
+	total, err := lookup(metrics, b.Total, touchbundle.TypeCounter)
+	if err != nil {
+		return
+	}
+
+	t = target{
+		alert:        b.Alert,
+		total:        total.Name,
+		errorMatcher: b.ErrorMatcher,
+		by:           b.By,
+		slo:          b.SLO,
+	}
+
+	if len(t.alert) == 0 {
+		t.alert = camelize(total.Name)
+	}
+
+	if len(t.errorMatcher) == 0 {
+		t.errorMatcher = DefaultErrorMatcher
+	}
+
+	if len(b.Errors) > 0 {
+		var errorCount touchbundle.Metric
+		if errorCount, err = lookup(metrics, b.Errors, touchbundle.TypeCounter); err != nil {
+			return
+		}
+
+		t.errors = errorCount.Name
+	}
+
+	if len(b.Duration) > 0 {
+		var duration touchbundle.Metric
+		if duration, err = lookup(metrics, b.Duration, touchbundle.TypeHistogram, touchbundle.TypeSummary); err != nil {
+			return
+		}
+
+		t.duration = duration.Name
+		t.histogram = duration.Type == touchbundle.TypeHistogram
+	}
+
+	labels := make([]string, 0, len(b.Matchers))
+	for label := range b.Matchers {
+		labels = append(labels, label)
+	}
+
+	sort.Strings(labels)
+	for _, label := range labels {
+		t.matchers = append(t.matchers, matcher(label, b.Matchers[label])...)
+	}
+
+	return
+}
+
+// New generates a rule File from the given configuration.  All rules are placed
+// in a single group.  Recording rules shared by several servers, clients, or bundles
+// are only emitted once.
+func New(cfg Config) (File, error) {
+	g := generator{
+		window:  cfg.Window,
+		records: make(map[string]string),
+	}
+
+	if len(g.window) == 0 {
+		g.window = DefaultWindow
+	}
+
+	var targets []target
+	for _, s := range cfg.Servers {
+		targets = append(targets, serverTarget(s, cfg.Defaults, cfg.Naming))
+	}
+
+	for _, c := range cfg.Clients {
+		targets = append(targets, clientTarget(c, cfg.Defaults, cfg.Naming))
+	}
+
+	for _, b := range cfg.Bundles {
+		t, err := bundleTarget(b, cfg.Defaults, cfg.Naming)
+		if err != nil {
+			return File{}, err
+		}
+
+		targets = append(targets, t)
+	}
+
+	for _, t := range targets {
+		if err := g.add(t); err != nil {
+			return File{}, err
+		}
+	}
+
+	group := Group{
+		Name:     cfg.Group,
+		Interval: cfg.Interval,
+		Rules:    g.rules,
+	}
+
+	if len(group.Name) == 0 {
+		group.Name = DefaultGroup
+	}
+
+	if group.Rules == nil {
+		group.Rules = []Rule{}
+	}
+
+	return File{Groups: []Group{group}}, nil
+}
+
+// Write generates a rule File and writes it as YAML to the given writer.
+func Write(w io.Writer, cfg Config) error {
+	f, err := New(cfg)
+	if err != nil {
+		return err
+	}
+
+	return f.Write(w)
+}
@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchrules
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"github.com/xmidt-org/touchstone/touchhttp"
+	"gopkg.in/yaml.v3"
+)
+
+type testBundle struct {
+	Requests *prometheus.CounterVec   `labelNames:"service,code"`
+	Failures *prometheus.CounterVec   `labelNames:"service"`
+	Latency  *prometheus.HistogramVec `labelNames:"service" buckets:"0.1,0.5,1"`
+	InFlight prometheus.Gauge
+}
+
+type GenerateSuite struct {
+	suite.Suite
+}
+
+func (suite *GenerateSuite) find(f File, name string) *Rule {
+	for i, r := range f.Groups[0].Rules {
+		if r.Record == name || r.Alert == name {
+			return &f.Groups[0].Rules[i]
+		}
+	}
+
+	return nil
+}
+
+func (suite *GenerateSuite) TestEmpty() {
+	f, err := New(Config{})
+	suite.Require().NoError(err)
+	suite.Require().Len(f.Groups, 1)
+	suite.Equal(DefaultGroup, f.Groups[0].Name)
+	suite.NotNil(f.Groups[0].Rules)
+	suite.Empty(f.Groups[0].Rules)
+}
+
+func (suite *GenerateSuite) TestServersAndClients() {
+	f, err := New(Config{
+		Defaults: prometheus.Opts{Namespace: "xmidt"},
+		Group:    "test",
+		Interval: "1m",
+		Servers: []Server{
+			{
+				Name: "servers.main",
+				SLO: SLO{
+					ErrorRatio:       0.05,
+					LatencyThreshold: 500,
+				},
+			},
+			{
+				// shares recording rules with servers.main
+				Name:  "servers.health",
+				Alert: "Health",
+				SLO: SLO{
+					ErrorRatio: 0.01,
+					For:        "1m",
+					Severity:   "critical",
+				},
+			},
+		},
+		Clients: []Client{
+			{
+				Bundle: touchhttp.ClientBundle{Duration: prometheus.SummaryOpts{}},
+				SLO: SLO{
+					ErrorRatio:       0.1,
+					LatencyThreshold: 100,
+				},
+			},
+		},
+	})
+
+	suite.Require().NoError(err)
+	suite.Equal("test", f.Groups[0].Name)
+	suite.Equal("1m", f.Groups[0].Interval)
+	suite.Len(f.Groups[0].Rules, 7)
+
+	r := suite.find(f, "server:xmidt_server_request_count:error_ratio_rate5m")
+	suite.Require().NotNil(r)
+	suite.Equal(
+		`sum by (server) (rate(xmidt_server_request_count{code=~"5.."}[5m])) / sum by (server) (rate(xmidt_server_request_count[5m]))`,
+		r.Expr,
+	)
+
+	r = suite.find(f, "ServersMainHighErrorRate")
+	suite.Require().NotNil(r)
+	suite.Equal(`server:xmidt_server_request_count:error_ratio_rate5m{server="servers.main"} > 0.05`, r.Expr)
+	suite.Equal(DefaultFor, r.For)
+	suite.Equal(DefaultSeverity, r.Labels["severity"])
+
+	r = suite.find(f, "server:xmidt_server_request_duration_ms:slow_ratio_le500_rate5m")
+	suite.Require().NotNil(r)
+	suite.Equal(
+		`1 - (sum by (server) (rate(xmidt_server_request_duration_ms_bucket{le="500"}[5m])) / sum by (server) (rate(xmidt_server_request_duration_ms_count[5m])))`,
+		r.Expr,
+	)
+
+	r = suite.find(f, "ServersMainLatencyBudgetBurn")
+	suite.Require().NotNil(r)
+	suite.Equal(`server:xmidt_server_request_duration_ms:slow_ratio_le500_rate5m{server="servers.main"} > 0.144`, r.Expr)
+
+	r = suite.find(f, "HealthHighErrorRate")
+	suite.Require().NotNil(r)
+	suite.Equal("1m", r.For)
+	suite.Equal("critical", r.Labels["severity"])
+	suite.Nil(suite.find(f, "HealthLatencyBudgetBurn"))
+
+	r = suite.find(f, "client:xmidt_client_request_count:error_ratio_rate5m")
+	suite.Require().NotNil(r)
+	suite.Equal(
+		`sum by (client) (rate(xmidt_client_error_count[5m])) / sum by (client) (rate(xmidt_client_request_count[5m]))`,
+		r.Expr,
+	)
+
+	r = suite.find(f, "ClientHighErrorRate")
+	suite.Require().NotNil(r)
+	suite.Equal(`client:xmidt_client_request_count:error_ratio_rate5m > 0.1`, r.Expr)
+
+	// summaries cannot be aggregated, so no latency rules are generated
+	suite.Nil(suite.find(f, "ClientLatencyBudgetBurn"))
+}
+
+func (suite *GenerateSuite) TestBundle() {
+	f, err := New(Config{
+		Defaults: prometheus.Opts{Namespace: "xmidt", Subsystem: "app"},
+		Window:   "1m",
+		Bundles: []Bundle{
+			{
+				Prototype: testBundle{},
+				Total:     "Requests",
+				Duration:  "Latency",
+				By:        []string{"service"},
+				Matchers:  map[string]string{"service": "api"},
+				SLO: SLO{
+					ErrorRatio:       0.05,
+					LatencyThreshold: 0.5,
+					LatencyObjective: 0.9,
+					BurnRate:         2,
+				},
+			},
+			{
+				Prototype: (*testBundle)(nil),
+				Alert:     "Failures",
+				Total:     "Requests",
+				Errors:    "Failures",
+				SLO:       SLO{ErrorRatio: 0.01},
+			},
+		},
+	})
+
+	suite.Require().NoError(err)
+
+	r := suite.find(f, "XmidtAppRequestsHighErrorRate")
+	suite.Require().NotNil(r)
+	suite.Equal(`service:xmidt_app_requests:error_ratio_rate1m{service="api"} > 0.05`, r.Expr)
+
+	r = suite.find(f, "service:xmidt_app_latency:slow_ratio_le0_5_rate1m")
+	suite.Require().NotNil(r)
+	suite.Contains(r.Expr, `xmidt_app_latency_bucket{le="0.5"}[1m]`)
+
+	r = suite.find(f, "XmidtAppRequestsLatencyBudgetBurn")
+	suite.Require().NotNil(r)
+	suite.Equal(`service:xmidt_app_latency:slow_ratio_le0_5_rate1m{service="api"} > 0.2`, r.Expr)
+
+	r = suite.find(f, "xmidt_app_requests:error_ratio_rate1m")
+	suite.Require().NotNil(r)
+	suite.Equal(`sum(rate(xmidt_app_failures[1m])) / sum(rate(xmidt_app_requests[1m]))`, r.Expr)
+	suite.NotNil(suite.find(f, "FailuresHighErrorRate"))
+}
+
+func (suite *GenerateSuite) TestNamingPolicy() {
+	f, err := New(Config{
+		Defaults: prometheus.Opts{Namespace: "xmidt"},
+		Naming: touchstone.Naming{
+			Prefix: "p",
+			Rename: map[string]string{"requests": "renamed_requests"},
+		},
+		Servers: []Server{
+			{Name: "main", SLO: SLO{ErrorRatio: 0.05}},
+		},
+		Bundles: []Bundle{
+			{Prototype: testBundle{}, Total: "Requests", Errors: "Failures"},
+		},
+	})
+
+	suite.Require().NoError(err)
+
+	r := suite.find(f, "server:xmidt_p_server_request_count:error_ratio_rate5m")
+	suite.Require().NotNil(r)
+	suite.Contains(r.Expr, "xmidt_p_server_request_count{")
+
+	r = suite.find(f, "xmidt_renamed_requests:error_ratio_rate5m")
+	suite.Require().NotNil(r)
+	suite.Equal(`sum(rate(xmidt_p_failures[5m])) / sum(rate(xmidt_renamed_requests[5m]))`, r.Expr)
+}
+
+func (suite *GenerateSuite) TestBundleErrors() {
+	testCases := []Bundle{
+		{Prototype: 123, Total: "Requests"},
+		{Prototype: testBundle{}},
+		{Prototype: testBundle{}, Total: "NoSuchField"},
+		{Prototype: testBundle{}, Total: "InFlight"},
+		{Prototype: testBundle{}, Total: "Requests", Errors: "Latency"},
+		{Prototype: testBundle{}, Total: "Requests", Duration: "Failures"},
+	}
+
+	for _, b := range testCases {
+		_, err := New(Config{Bundles: []Bundle{b}})
+		suite.Error(err)
+	}
+}
+
+func (suite *GenerateSuite) TestConflict() {
+	_, err := New(Config{
+		Bundles: []Bundle{
+			{Prototype: testBundle{}, Total: "Requests"},
+			{Prototype: testBundle{}, Total: "Requests", Errors: "Failures"},
+		},
+	})
+
+	suite.ErrorIs(err, ErrConflictingRule)
+}
+
+func (suite *GenerateSuite) TestWrite() {
+	var b bytes.Buffer
+	suite.Require().NoError(
+		Write(&b, Config{
+			Servers: []Server{{SLO: SLO{ErrorRatio: 0.05}}},
+		}),
+	)
+
+	var f File
+	suite.Require().NoError(yaml.Unmarshal(b.Bytes(), &f))
+	suite.Require().Len(f.Groups, 1)
+	suite.Len(f.Groups[0].Rules, 2)
+	suite.Equal("ServerHighErrorRate", f.Groups[0].Rules[1].Alert)
+	suite.Contains(b.String(), "record: server:server_request_count:error_ratio_rate5m")
+
+	suite.Error(
+		Write(&b, Config{Bundles: []Bundle{{Prototype: 123}}}),
+	)
+}
+
+func TestGenerate(t *testing.T) {
+	suite.Run(t, new(GenerateSuite))
+}
@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchslo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone"
+	"go.uber.org/multierr"
+)
+
+const (
+	// DefaultGoodCount is the default name of the counter of events that met their objective.
+	DefaultGoodCount = "slo_good_event_count"
+
+	// DefaultBadCount is the default name of the counter of events that missed their objective.
+	DefaultBadCount = "slo_bad_event_count"
+
+	// DefaultObjective is the default name of the gauge that exposes the target
+	// fraction of good events for each objective.
+	DefaultObjective = "slo_objective"
+
+	// ObjectiveLabel is the metric label containing the name of the objective.
+	ObjectiveLabel = "slo"
+)
+
+var (
+	// ErrReservedLabelName indicates that labels supplied to build a Tracker
+	// used the reserved ObjectiveLabel.
+	ErrReservedLabelName = fmt.Errorf(
+		"%s is a reserved label name and is supplied automatically",
+		ObjectiveLabel,
+	)
+
+	// ErrInvalidLabelCount indicates that an odd number of name/value pairs were
+	// passed when creating a Tracker.
+	ErrInvalidLabelCount = errors.New("The number of label names and values must be even")
+
+	// ErrInvalidObjective indicates that an Objective had no name or that its
+	// Target was not strictly between 0 and 1.
+	ErrInvalidObjective = errors.New("An objective must have a name and a target strictly between 0 and 1")
+
+	defaultGoodCount = prometheus.CounterOpts{
+		Name: DefaultGoodCount,
+		Help: "the total number of events that met their service level objective",
+	}
+
+	defaultBadCount = prometheus.CounterOpts{
+		Name: DefaultBadCount,
+		Help: "the total number of events that missed their service level objective",
+	}
+
+	defaultObjective = prometheus.GaugeOpts{
+		Name: DefaultObjective,
+		Help: "the target fraction of good events for the service level objective",
+	}
+)
+
+// labelNames takes a sequence of name/value pairs and converts that into
+// a slice of names and a prometheus.Labels which should be used to curry
+// the associated metric.
+func labelNames(lvs []string) (names []string, curry prometheus.Labels, err error) {
+	if len(lvs)%2 != 0 {
+		return nil, nil, ErrInvalidLabelCount
+	}
+
+	names = make([]string, 0, len(lvs)/2)
+	curry = make(prometheus.Labels, len(lvs)/2)
+	for i := 0; i < len(lvs); i += 2 {
+		if lvs[i] == ObjectiveLabel {
+			return nil, nil, ErrReservedLabelName
+		}
+
+		names = append(names, lvs[i])
+		curry[lvs[i]] = lvs[i+1]
+	}
+
+	return
+}
+
+// Bundle describes the SLO metrics.  The zero value of this type uses the
+// default metric names.
+type Bundle struct {
+	// GoodCount describes the options used for the counter of good events.
+	GoodCount prometheus.CounterOpts
+
+	// BadCount describes the options used for the counter of bad events.
+	BadCount prometheus.CounterOpts
+
+	// Objective describes the options used for the objective gauge.
+	Objective prometheus.GaugeOpts
+}
+
+func (b Bundle) newCounter(f touchstone.MetricFactory, o, defaults prometheus.CounterOpts, labelNames []string, curry prometheus.Labels) (prometheus.Counter, error) {
+	touchstone.ApplyDefaults(&o, defaults)
+	cv, err := f.NewCounterVec(o, labelNames...)
+	err = touchstone.ExistingCollector(&cv, err)
+	if err == nil {
+		return cv.GetMetricWith(curry)
+	}
+
+	return nil, err
+}
+
+func (b Bundle) newObjective(f touchstone.MetricFactory, labelNames []string, curry prometheus.Labels) (prometheus.Gauge, error) {
+	o := b.Objective
+	touchstone.ApplyDefaults(&o, defaultObjective)
+	gv, err := f.NewGaugeVec(o, labelNames...)
+	err = touchstone.ExistingCollector(&gv, err)
+	if err == nil {
+		return gv.GetMetricWith(curry)
+	}
+
+	return nil, err
+}
+
+// NewTracker creates a constructor that can be passed to fx.Provide or annotated
+// as needed.  Each distinct Objective must use a distinct name.
+//
+// The namesAndValues are any extra, curried labels to apply to all the created
+// metrics.  If multiple trackers are created from the same Bundle, the extra
+// label names must match though the values may differ.  The ObjectiveLabel is reserved.
+//
+// Typical usage:
+//
+//	app := fx.New(
+//	  touchstone.Provide(),
+//	  fx.Provide(
+//	    touchslo.Bundle{}.NewTracker(
+//	      touchslo.Objective{Name: "api", Target: 0.999, Latency: 500 * time.Millisecond},
+//	    ),
+//	    func(t *touchslo.Tracker, f touchstone.MetricFactory) (touchhttp.ServerInstrumenter, error) {
+//	      return touchhttp.ServerBundle{
+//	        Hooks: []touchhttp.Hook{t.Observe},
+//	      }.NewInstrumenter()(f)
+//	    },
+//	  ),
+//	)
+func (b Bundle) NewTracker(o Objective, namesAndValues ...string) func(touchstone.MetricFactory) (*Tracker, error) {
+	return func(f touchstone.MetricFactory) (*Tracker, error) {
+		if len(o.Name) == 0 || o.Target <= 0.0 || o.Target >= 1.0 {
+			return nil, ErrInvalidObjective
+		}
+
+		extraNames, curry, err := labelNames(namesAndValues)
+		if err != nil {
+			return nil, err
+		}
+
+		fullNames := append(append([]string{}, extraNames...), ObjectiveLabel)
+		curry[ObjectiveLabel] = o.Name
+
+		t := &Tracker{
+			objective: o,
+		}
+
+		var metricErr error
+		t.good, metricErr = b.newCounter(f, b.GoodCount, defaultGoodCount, fullNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		t.bad, metricErr = b.newCounter(f, b.BadCount, defaultBadCount, fullNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		var target prometheus.Gauge
+		target, metricErr = b.newObjective(f, fullNames, curry)
+		multierr.AppendInto(&err, metricErr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		target.Set(o.Target)
+		return t, nil
+	}
+}
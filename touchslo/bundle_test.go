@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchslo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"github.com/xmidt-org/touchstone/touchhttp"
+)
+
+type BundleSuite struct {
+	suite.Suite
+
+	gatherer prometheus.Gatherer
+	factory  *touchstone.Factory
+}
+
+func (suite *BundleSuite) SetupTest() {
+	cfg := touchstone.Config{
+		Pedantic:                  true,
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := touchstone.New(cfg)
+	suite.Require().NoError(err)
+	suite.gatherer = g
+	suite.factory = touchstone.NewFactory(cfg, nil, r)
+}
+
+func (suite *BundleSuite) newTracker(b Bundle, o Objective, namesAndValues ...string) *Tracker {
+	t, err := b.NewTracker(o, namesAndValues...)(suite.factory)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(t)
+	return t
+}
+
+func (suite *BundleSuite) TestInvalid() {
+	f := func(o Objective, namesAndValues ...string) error {
+		t, err := Bundle{}.NewTracker(o, namesAndValues...)(suite.factory)
+		suite.Nil(t)
+		return err
+	}
+
+	suite.ErrorIs(f(Objective{Target: 0.99}), ErrInvalidObjective)
+	suite.ErrorIs(f(Objective{Name: "api"}), ErrInvalidObjective)
+	suite.ErrorIs(f(Objective{Name: "api", Target: 1.0}), ErrInvalidObjective)
+	suite.ErrorIs(f(Objective{Name: "api", Target: 0.99}, "odd"), ErrInvalidLabelCount)
+	suite.ErrorIs(f(Objective{Name: "api", Target: 0.99}, ObjectiveLabel, "value"), ErrReservedLabelName)
+}
+
+func (suite *BundleSuite) TestObserve() {
+	t := suite.newTracker(
+		Bundle{},
+		Objective{Name: "api", Target: 0.999, Latency: 500 * time.Millisecond},
+		touchhttp.ServerLabel, "main",
+	)
+
+	suite.Equal("api", t.Objective().Name)
+	t.Observe(touchhttp.Observation{Code: http.StatusOK, Duration: 100 * time.Millisecond})
+	t.Observe(touchhttp.Observation{Code: http.StatusNotFound, Duration: 500 * time.Millisecond})
+	t.Observe(touchhttp.Observation{Code: http.StatusOK, Duration: time.Second})
+	t.Observe(touchhttp.Observation{Code: http.StatusBadGateway})
+	t.Observe(touchhttp.Observation{Code: -1})
+
+	suite.Equal(2.0, testutil.ToFloat64(t.good))
+	suite.Equal(3.0, testutil.ToFloat64(t.bad))
+
+	// a second objective shares the same metrics
+	other := suite.newTracker(
+		Bundle{},
+		Objective{
+			Name:   "writes",
+			Target: 0.99,
+			Failed: func(o touchhttp.Observation) bool { return o.Code >= 400 },
+		},
+		touchhttp.ServerLabel, "main",
+	)
+
+	other.Observe(touchhttp.Observation{Code: http.StatusNotFound, Duration: time.Hour})
+	other.Good()
+	other.Bad()
+	suite.Equal(1.0, testutil.ToFloat64(other.good))
+	suite.Equal(2.0, testutil.ToFloat64(other.bad))
+
+	suite.NoError(
+		testutil.GatherAndCompare(
+			suite.gatherer,
+			strings.NewReader(`
+# HELP slo_objective the target fraction of good events for the service level objective
+# TYPE slo_objective gauge
+slo_objective{server="main",slo="api"} 0.999
+slo_objective{server="main",slo="writes"} 0.99
+`),
+			DefaultObjective,
+		),
+	)
+}
+
+func (suite *BundleSuite) TestHook() {
+	t := suite.newTracker(
+		Bundle{GoodCount: prometheus.CounterOpts{Name: "good"}, BadCount: prometheus.CounterOpts{Name: "bad"}},
+		Objective{Name: "api", Target: 0.9},
+	)
+
+	si, err := touchhttp.ServerBundle{
+		Hooks: []touchhttp.Hook{t.Observe},
+	}.NewInstrumenter()(suite.factory)
+
+	suite.Require().NoError(err)
+
+	code := http.StatusOK
+	h := si.Then(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(code)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	code = http.StatusInternalServerError
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	suite.Equal(1.0, testutil.ToFloat64(t.good))
+	suite.Equal(2.0, testutil.ToFloat64(t.bad))
+}
+
+func TestBundle(t *testing.T) {
+	suite.Run(t, new(BundleSuite))
+}
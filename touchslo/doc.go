@@ -0,0 +1,16 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package touchslo tracks service level objectives as simple good and bad
+// event counters.  A Tracker is fed by a touchhttp instrumenter via a Hook,
+// and classifies each transaction against an Objective, e.g. "99.9% of requests
+// succeed in under 500ms".
+//
+// Because the event counters are precomputed, error budget burn rates are
+// simple ratios of counter rates.  Multi-window burn rate alerts don't require
+// histogram_quantile or bucket arithmetic:
+//
+//	sum(rate(slo_bad_event_count{slo="api"}[1h]))
+//	  / (sum(rate(slo_good_event_count{slo="api"}[1h])) + sum(rate(slo_bad_event_count{slo="api"}[1h])))
+//	  > 14.4 * (1 - max(slo_objective{slo="api"}))
+package touchslo
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchslo
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xmidt-org/touchstone/touchhttp"
+)
+
+// Objective describes a service level objective for HTTP transactions, e.g.
+// "99.9% of requests succeed in under 500ms".
+type Objective struct {
+	// Name identifies this objective.  It is used as the value of the ObjectiveLabel.
+	// This field is required.
+	Name string
+
+	// Target is the fraction of events that must be good, e.g. 0.999.  This value
+	// must be strictly between 0 and 1.
+	Target float64
+
+	// Latency is the maximum duration of a good event.  If unset, the duration
+	// of transactions is not considered.
+	Latency time.Duration
+
+	// Failed is an optional strategy for determining whether a transaction failed
+	// regardless of its duration.  If unset, DefaultFailed is used.
+	Failed func(touchhttp.Observation) bool
+}
+
+// DefaultFailed is the default strategy for determining whether a transaction
+// failed.  Client errors, missing responses, and 5xx status codes are failures.
+func DefaultFailed(o touchhttp.Observation) bool {
+	return o.Err != nil || o.Code < 0 || o.Code >= http.StatusInternalServerError
+}
+
+// Tracker classifies events against an Objective, counting each one as good or bad.
+type Tracker struct {
+	objective Objective
+	good      prometheus.Counter
+	bad       prometheus.Counter
+}
+
+// Objective returns the objective this Tracker measures against.
+func (t *Tracker) Objective() Objective {
+	return t.objective
+}
+
+// Good records a good event.  Use this method for events that are not HTTP transactions.
+func (t *Tracker) Good() {
+	t.good.Inc()
+}
+
+// Bad records a bad event.  Use this method for events that are not HTTP transactions.
+func (t *Tracker) Bad() {
+	t.bad.Inc()
+}
+
+// Observe classifies an HTTP transaction and records it as either good or bad.
+// This method may be used as a touchhttp.Hook.
+func (t *Tracker) Observe(o touchhttp.Observation) {
+	failed := t.objective.Failed
+	if failed == nil {
+		failed = DefaultFailed
+	}
+
+	if failed(o) || (t.objective.Latency > 0 && o.Duration > t.objective.Latency) {
+		t.Bad()
+	} else {
+		t.Good()
+	}
+}
+
+var _ touchhttp.Hook = (*Tracker)(nil).Observe
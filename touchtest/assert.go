@@ -5,20 +5,26 @@ package touchtest
 
 import (
 	"bytes"
+	"errors"
+	"io"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone/touchinspect"
 )
 
 // Assertions is a set of test verifications for metrics.  Principally,
 // this involves comparisons against an expected Gatherer.
 type Assertions struct {
-	buffer bytes.Buffer
-	names  map[string]bool
+	buffer    bytes.Buffer
+	names     map[string]bool
+	tolerance *Tolerance
+	selector  prometheus.Labels
 
 	assert  *assert.Assertions
 	require *require.Assertions
@@ -45,6 +51,37 @@ func NewSuite(s suite.TestingSuite) *Assertions {
 // the enclosing test is failed.
 func (a *Assertions) Expect(g prometheus.Gatherer) *Assertions {
 	raw, err := g.Gather()
+	return a.expect(raw, err)
+}
+
+// ExpectDelimited is like Expect, but loads metrics from the prometheus protobuf
+// exposition format, where each metric family is length-delimited.  This is the
+// format written by touchstone.WriteDelimited.
+//
+// If any errors occur while decoding or encoding the metrics, the enclosing test is failed.
+func (a *Assertions) ExpectDelimited(r io.Reader) *Assertions {
+	var (
+		raw []*dto.MetricFamily
+		dec = expfmt.NewDecoder(r, expfmt.NewFormat(expfmt.TypeProtoDelim))
+		err error
+	)
+
+	for err == nil {
+		mf := new(dto.MetricFamily)
+		if err = dec.Decode(mf); err == nil {
+			raw = append(raw, mf)
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+
+	return a.expect(raw, err)
+}
+
+// expect loads raw metric families as the current expectation.
+func (a *Assertions) expect(raw []*dto.MetricFamily, err error) *Assertions {
 	if err == nil {
 		a.buffer.Reset()
 		a.names = make(map[string]bool)
@@ -71,15 +108,11 @@ func (a *Assertions) Expect(g prometheus.Gatherer) *Assertions {
 // This method returns true if the expectation was met.
 //
 // Use this method to run an assertion against an entire prometheus registry.
+// If a Tolerance has been set, histograms and summaries are compared approximately.
+// If a selector has been set with Where, only the matching series are compared.
 func (a *Assertions) GatherAndCompare(actual prometheus.Gatherer, metricNames ...string) bool {
-	err := testutil.GatherAndCompare(
-		actual,
-		bytes.NewReader(a.buffer.Bytes()),
-		metricNames...,
-	)
-
 	return a.assert.NoErrorf(
-		err,
+		a.gatherAndCompare(actual, metricNames...),
 		"Failed to match expected metrics: %s",
 		metricNames,
 	)
@@ -90,13 +123,23 @@ func (a *Assertions) GatherAndCompare(actual prometheus.Gatherer, metricNames ..
 // This method returns true if the expectation was met.
 //
 // Use this method to run an assertion against a single metric that optionally has
-// multiple submetrics.
+// multiple submetrics.  If a Tolerance has been set, histograms and summaries are
+// compared approximately.  If a selector has been set with Where, only the matching
+// series are compared.
 func (a *Assertions) CollectAndCompare(actual prometheus.Collector, metricNames ...string) bool {
-	err := testutil.CollectAndCompare(
-		actual,
-		bytes.NewReader(a.buffer.Bytes()),
-		metricNames...,
-	)
+	var err error
+	if a.tolerance == nil && len(a.selector) == 0 {
+		err = testutil.CollectAndCompare(
+			actual,
+			bytes.NewReader(a.buffer.Bytes()),
+			metricNames...,
+		)
+	} else {
+		r := prometheus.NewPedanticRegistry()
+		if err = r.Register(actual); err == nil {
+			err = a.gatherAndCompare(r, metricNames...)
+		}
+	}
 
 	return a.assert.NoErrorf(
 		err,
@@ -105,6 +148,25 @@ func (a *Assertions) CollectAndCompare(actual prometheus.Collector, metricNames
 	)
 }
 
+// gatherAndCompare applies any tolerance and selector, then compares the actual
+// Gatherer against the current expectation.
+func (a *Assertions) gatherAndCompare(actual prometheus.Gatherer, metricNames ...string) error {
+	if len(a.selector) > 0 {
+		actual = a.selectActual(actual)
+	}
+
+	if a.tolerance != nil {
+		actual = a.tolerate(actual)
+	}
+
+	expected, err := a.expected()
+	if err == nil {
+		err = testutil.GatherAndCompare(actual, expected, metricNames...)
+	}
+
+	return err
+}
+
 // Registered asserts that the given metric names are present in the current expectation
 // previously set with Expect.
 func (a *Assertions) Registered(metricNames ...string) bool {
@@ -126,3 +188,23 @@ func (a *Assertions) NotRegistered(metricNames ...string) bool {
 
 	return passed
 }
+
+// Lint asserts that the metrics in the given Gatherer pass the standard
+// prometheus lint checks.  Each problem is reported as a separate failure.
+func (a *Assertions) Lint(g prometheus.Gatherer) bool {
+	s, err := touchinspect.Gather(g)
+	if !a.assert.NoError(err, "Failed to gather metrics for linting") {
+		return false
+	}
+
+	problems, err := s.Lint()
+	if !a.assert.NoError(err, "Failed to lint metrics") {
+		return false
+	}
+
+	for _, p := range problems {
+		a.assert.Failf("Lint problem", "%s: %s", p.Metric, p.Text)
+	}
+
+	return len(problems) == 0
+}
@@ -4,9 +4,12 @@
 package touchtest
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -138,6 +141,40 @@ func (suite *AssertionsTestSuite) TestGatherAndCompare() {
 	mt.failures = 0
 }
 
+func (suite *AssertionsTestSuite) TestExpectDelimited() {
+	var (
+		expected        = prometheus.NewPedanticRegistry()
+		expectedCounter = prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "testCounter",
+			Help: "testCounter",
+		})
+
+		mt = &mockTestingT{t: suite.T()}
+		a  = New(mt)
+	)
+
+	suite.register(expected, expectedCounter)
+	expectedCounter.Add(3.0)
+
+	mfs, err := expected.Gather()
+	suite.Require().NoError(err)
+
+	var delimited bytes.Buffer
+	enc := expfmt.NewEncoder(&delimited, expfmt.NewFormat(expfmt.TypeProtoDelim))
+	for _, mf := range mfs {
+		suite.Require().NoError(enc.Encode(mf))
+	}
+
+	suite.Same(a, a.ExpectDelimited(&delimited))
+	suite.True(a.Registered("testCounter"))
+	suite.True(a.GatherAndCompare(expected))
+	suite.Zero(mt.errors)
+	suite.Zero(mt.failures)
+
+	a.ExpectDelimited(strings.NewReader("this is not a valid protobuf"))
+	suite.Equal(1, mt.failures)
+}
+
 func (suite *AssertionsTestSuite) TestCollectAndCompare() {
 	var (
 		expected        = prometheus.NewPedanticRegistry()
@@ -174,6 +211,37 @@ func (suite *AssertionsTestSuite) TestCollectAndCompare() {
 	mt.failures = 0
 }
 
+func (suite *AssertionsTestSuite) TestLint() {
+	var (
+		r  = prometheus.NewPedanticRegistry()
+		mt = &mockTestingT{t: suite.T()}
+		a  = New(mt)
+	)
+
+	suite.register(
+		r,
+		prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "good_total",
+			Help: "a well named counter",
+		}),
+	)
+
+	suite.True(a.Lint(r))
+	suite.Zero(mt.errors)
+	suite.Zero(mt.failures)
+
+	suite.register(
+		r,
+		prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "badCounter",
+		}),
+	)
+
+	suite.False(a.Lint(r))
+	suite.NotZero(mt.errors)
+	suite.Zero(mt.failures)
+}
+
 func TestAssertions(t *testing.T) {
 	suite.Run(t, new(AssertionsTestSuite))
 }
@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// DefaultGoroutines is the number of goroutines used by AssertConcurrent
+	// when Concurrency.Goroutines is unset.
+	DefaultGoroutines = 16
+
+	// DefaultIterations is the number of calls each goroutine makes in
+	// AssertConcurrent when Concurrency.Iterations is unset.
+	DefaultIterations = 100
+)
+
+// Concurrency describes how AssertConcurrent exercises instrumented code and
+// what the metrics must look like afterward.
+type Concurrency struct {
+	// Goroutines is the number of goroutines that call the operation.
+	// If unset, DefaultGoroutines is used.
+	Goroutines int
+
+	// Iterations is the number of times each goroutine calls the operation.
+	// If unset, DefaultIterations is used.
+	Iterations int
+
+	// Gatherer is gathered continuously while the operation runs, and once more
+	// after all goroutines have finished.  This field is required.
+	Gatherer prometheus.Gatherer
+
+	// Counts maps metric names onto the number of events each call is expected to
+	// record, e.g. 1 for a request counter.  Counter and untyped values and histogram
+	// and summary sample counts are summed across all series of a metric, and that
+	// sum must equal the expected amount times the total number of calls.  Each
+	// metric must have been gathered, even if its expected amount is zero.
+	Counts map[string]float64
+
+	// Settled is the set of gauge names that must sum to zero once all calls have
+	// finished, e.g. in-flight gauges.  Each gauge must have been gathered.
+	Settled []string
+}
+
+func (c Concurrency) goroutines() int {
+	if c.Goroutines > 0 {
+		return c.Goroutines
+	}
+
+	return DefaultGoroutines
+}
+
+func (c Concurrency) iterations() int {
+	if c.Iterations > 0 {
+		return c.Iterations
+	}
+
+	return DefaultIterations
+}
+
+// concurrentFailures collects the problems seen by the goroutines started by
+// AssertConcurrent, so that they can be reported from the test's goroutine.
+type concurrentFailures struct {
+	lock     sync.Mutex
+	failures []string
+}
+
+func (cf *concurrentFailures) add(format string, args ...interface{}) {
+	cf.lock.Lock()
+	cf.failures = append(cf.failures, fmt.Sprintf(format, args...))
+	cf.lock.Unlock()
+}
+
+// recover must be deferred directly by the goroutine being protected.
+func (cf *concurrentFailures) recover(who string) {
+	if r := recover(); r != nil {
+		cf.add("%s panicked: %v\n%s", who, r, debug.Stack())
+	}
+}
+
+// total sums the counts recorded by each series of a metric family.
+func total(mf *dto.MetricFamily) (sum float64) {
+	for _, m := range mf.GetMetric() {
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			sum += m.GetCounter().GetValue()
+
+		case dto.MetricType_GAUGE:
+			sum += m.GetGauge().GetValue()
+
+		case dto.MetricType_UNTYPED:
+			sum += m.GetUntyped().GetValue()
+
+		case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+			sum += float64(m.GetHistogram().GetSampleCount())
+
+		case dto.MetricType_SUMMARY:
+			sum += float64(m.GetSummary().GetSampleCount())
+		}
+	}
+
+	return
+}
+
+// AssertConcurrent calls op from several goroutines at once while the Concurrency's
+// Gatherer is gathered concurrently.  Each goroutine passes its index, from 0 to
+// Goroutines-1, so that different goroutines can use different label values.  Afterward,
+// the metrics in Counts and Settled are checked against the total number of calls.
+//
+// The enclosing test fails if op or gathering panics, if gathering returns an error, or
+// if any final count is inconsistent or any named metric was not gathered.  This catches
+// problems like curry misuse in middleware built on touchstone that only show up under
+// load.  Data races are only detected if the test binary is built with the race detector.
+// See Handler and RoundTrip for operations that exercise HTTP code.
+func AssertConcurrent(t assert.TestingT, c Concurrency, op func(goroutine int)) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	if c.Gatherer == nil {
+		return assert.Fail(t, "A Gatherer is required")
+	}
+
+	if failures := c.run(op); len(failures) > 0 {
+		return assert.Fail(t, "Concurrent use failed", strings.Join(failures, "\n"))
+	}
+
+	mfs, err := c.Gatherer.Gather()
+	if !assert.NoError(t, err, "Failed to gather final metrics") {
+		return false
+	}
+
+	return c.assertTotals(t, mfs, float64(c.goroutines()*c.iterations()))
+}
+
+// run calls op from each goroutine while gathering continuously, returning any
+// problems seen once every goroutine and the gathering have finished.
+func (c Concurrency) run(op func(goroutine int)) []string {
+	var (
+		failures   concurrentFailures
+		goroutines = c.goroutines()
+		iterations = c.iterations()
+
+		done     = make(chan struct{})
+		gathered = make(chan struct{})
+		wg       sync.WaitGroup
+	)
+
+	go func() {
+		defer close(gathered)
+		defer failures.recover("Gather")
+		for {
+			if _, err := c.Gatherer.Gather(); err != nil {
+				failures.add("Gather failed: %s", err)
+				return
+			}
+
+			select {
+			case <-done:
+				return
+			default:
+			}
+		}
+	}()
+
+	wg.Add(goroutines)
+	for g := 0; g < goroutines; g++ {
+		go func(g int) {
+			defer wg.Done()
+			defer failures.recover(fmt.Sprintf("goroutine %d", g))
+			for i := 0; i < iterations; i++ {
+				op(g)
+			}
+		}(g)
+	}
+
+	wg.Wait()
+	close(done)
+	<-gathered
+	return failures.failures
+}
+
+// assertTotals checks the final metrics against the Counts and Settled, given the
+// total number of calls that were made.
+func (c Concurrency) assertTotals(t assert.TestingT, mfs []*dto.MetricFamily, calls float64) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	totals := make(map[string]float64, len(mfs))
+	for _, mf := range mfs {
+		totals[mf.GetName()] = total(mf)
+	}
+
+	names := make([]string, 0, len(c.Counts))
+	for name := range c.Counts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	passed := true
+	for _, name := range names {
+		if actual, ok := totals[name]; !ok {
+			passed = assert.Failf(t, "Metric was not gathered", "Metric: %s", name) && passed
+		} else {
+			passed = assert.Equalf(t, c.Counts[name]*calls, actual, "Inconsistent count for metric: %s", name) && passed
+		}
+	}
+
+	for _, name := range c.Settled {
+		if actual, ok := totals[name]; !ok {
+			passed = assert.Failf(t, "Gauge was not gathered", "Gauge: %s", name) && passed
+		} else {
+			passed = assert.Zerof(t, actual, "Gauge did not settle: %s", name) && passed
+		}
+	}
+
+	return passed
+}
+
+// Handler returns an operation for AssertConcurrent that serves a request created
+// by newRequest with the given handler.  The response is recorded and discarded.
+func Handler(h http.Handler, newRequest func(goroutine int) *http.Request) func(int) {
+	return func(goroutine int) {
+		h.ServeHTTP(httptest.NewRecorder(), newRequest(goroutine))
+	}
+}
+
+// RoundTrip returns an operation for AssertConcurrent that sends a request created
+// by newRequest through the given round tripper, e.g. an instrumented client transport.
+// The response body, if any, is drained and closed.  Errors from the round tripper are
+// not failures, since instrumented clients are expected to record them.
+func RoundTrip(rt http.RoundTripper, newRequest func(goroutine int) *http.Request) func(int) {
+	return func(goroutine int) {
+		response, err := rt.RoundTrip(newRequest(goroutine))
+		if err == nil {
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+	"github.com/xmidt-org/touchstone"
+	"github.com/xmidt-org/touchstone/touchhttp"
+	"go.uber.org/zap"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (rtf roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return rtf(request)
+}
+
+type ConcurrentTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ConcurrentTestSuite) newFactory() (prometheus.Gatherer, *touchstone.Factory) {
+	cfg := touchstone.Config{
+		Pedantic:                  true,
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := touchstone.New(cfg)
+	suite.Require().NoError(err)
+	return g, touchstone.NewFactory(cfg, zap.L(), r)
+}
+
+func (suite *ConcurrentTestSuite) newRequest(goroutine int) *http.Request {
+	return httptest.NewRequest("GET", fmt.Sprintf("/test/%d", goroutine), nil)
+}
+
+func (suite *ConcurrentTestSuite) TestHandler() {
+	g, f := suite.newFactory()
+	si, err := touchhttp.ServerBundle{}.NewInstrumenter(touchhttp.ServerLabel, "test")(f)
+	suite.Require().NoError(err)
+
+	h := si.Then(http.HandlerFunc(func(rw http.ResponseWriter, request *http.Request) {
+		if strings.HasSuffix(request.URL.Path, "1") {
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	suite.True(
+		AssertConcurrent(suite.T(), Concurrency{
+			Goroutines: 4,
+			Iterations: 50,
+			Gatherer:   g,
+			Counts: map[string]float64{
+				touchhttp.DefaultServerCount:    1.0,
+				touchhttp.DefaultServerDuration: 1.0,
+			},
+			Settled: []string{touchhttp.DefaultServerInFlight},
+		}, Handler(h, suite.newRequest)),
+	)
+}
+
+func (suite *ConcurrentTestSuite) TestRoundTrip() {
+	g, f := suite.newFactory()
+	ci, err := touchhttp.ClientBundle{}.NewInstrumenter(touchhttp.ClientLabel, "test")(f)
+	suite.Require().NoError(err)
+
+	rt := ci.ThenRoundTripper(roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(request.URL.Path, "1") {
+			return nil, errors.New("expected")
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("test")),
+		}, nil
+	}))
+
+	suite.True(
+		AssertConcurrent(suite.T(), Concurrency{
+			Gatherer: g,
+			Counts: map[string]float64{
+				touchhttp.DefaultClientCount: 1.0,
+			},
+			Settled: []string{touchhttp.DefaultClientInFlight},
+		}, RoundTrip(rt, suite.newRequest)),
+	)
+}
+
+func (suite *ConcurrentTestSuite) TestNoGatherer() {
+	mockT := &mockTestingT{t: suite.T()}
+	suite.False(AssertConcurrent(mockT, Concurrency{}, func(int) {}))
+	suite.Equal(1, mockT.errors)
+}
+
+func (suite *ConcurrentTestSuite) TestPanic() {
+	g, _ := suite.newFactory()
+	mockT := &mockTestingT{t: suite.T()}
+	suite.False(
+		AssertConcurrent(mockT, Concurrency{Goroutines: 2, Gatherer: g}, func(goroutine int) {
+			if goroutine == 1 {
+				panic("expected")
+			}
+		}),
+	)
+
+	suite.Equal(1, mockT.errors)
+}
+
+func (suite *ConcurrentTestSuite) TestGatherError() {
+	g := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return nil, errors.New("expected")
+	})
+
+	mockT := &mockTestingT{t: suite.T()}
+	suite.False(AssertConcurrent(mockT, Concurrency{Gatherer: g}, func(int) {}))
+	suite.Equal(1, mockT.errors)
+}
+
+func (suite *ConcurrentTestSuite) TestInconsistent() {
+	g, f := suite.newFactory()
+	c, err := f.NewCounter(prometheus.CounterOpts{Name: "count", Help: "test"})
+	suite.Require().NoError(err)
+
+	gauge, err := f.NewGauge(prometheus.GaugeOpts{Name: "in_flight", Help: "test"})
+	suite.Require().NoError(err)
+
+	mockT := &mockTestingT{t: suite.T()}
+	suite.False(
+		AssertConcurrent(mockT, Concurrency{
+			Goroutines: 2,
+			Iterations: 10,
+			Gatherer:   g,
+			Counts:     map[string]float64{"count": 2.0},
+			Settled:    []string{"in_flight"},
+		}, func(goroutine int) {
+			c.Inc()
+			gauge.Inc()
+		}),
+	)
+
+	suite.Equal(2, mockT.errors)
+}
+
+func (suite *ConcurrentTestSuite) TestNotGathered() {
+	g, _ := suite.newFactory()
+	mockT := &mockTestingT{t: suite.T()}
+	suite.False(
+		AssertConcurrent(mockT, Concurrency{
+			Goroutines: 2,
+			Iterations: 10,
+			Gatherer:   g,
+			Counts:     map[string]float64{"nosuch_count": 0.0},
+			Settled:    []string{"nosuch_in_flight"},
+		}, func(int) {}),
+	)
+
+	suite.Equal(2, mockT.errors)
+}
+
+func TestConcurrent(t *testing.T) {
+	suite.Run(t, new(ConcurrentTestSuite))
+}
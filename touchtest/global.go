@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtest
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+// globalRecorder is the prometheus.Registerer installed as the global
+// registerer while AssertNoGlobalRegistrations runs.  It counts everything
+// registered through it, including unchecked collectors that describe nothing.
+type globalRecorder struct {
+	lock          sync.Mutex
+	registry      *prometheus.Registry
+	registrations int
+}
+
+func (gr *globalRecorder) Register(c prometheus.Collector) error {
+	gr.lock.Lock()
+	gr.registrations++
+	gr.lock.Unlock()
+	return gr.registry.Register(c)
+}
+
+func (gr *globalRecorder) MustRegister(cs ...prometheus.Collector) {
+	gr.lock.Lock()
+	gr.registrations += len(cs)
+	gr.lock.Unlock()
+	gr.registry.MustRegister(cs...)
+}
+
+func (gr *globalRecorder) Unregister(c prometheus.Collector) bool {
+	return gr.registry.Unregister(c)
+}
+
+// gathered returns the sorted names of the metric families gathered from
+// everything that is still registered.
+func (gr *globalRecorder) gathered() (names []string, err error) {
+	mfs, err := gr.registry.Gather()
+	for _, mf := range mfs {
+		names = append(names, mf.GetName())
+	}
+
+	sort.Strings(names)
+	return
+}
+
+// AssertNoGlobalRegistrations runs fn and fails the enclosing test if anything was
+// registered against prometheus.DefaultRegisterer while it ran, even if it was later
+// unregistered.  The failure lists the metrics gathered from whatever is still registered.  Touchstone code should
+// never use the global registry, and this function helps enforce that rule.
+//
+// For the duration of fn, prometheus.DefaultRegisterer is replaced with a recording
+// registerer backed by a private registry, so nothing registered by fn leaks into the
+// real global registry.  The original registerer is restored afterward.  Because this
+// swaps a package-level variable, it must not be used in parallel tests.
+func AssertNoGlobalRegistrations(t assert.TestingT, fn func()) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	original := prometheus.DefaultRegisterer
+	gr := &globalRecorder{
+		registry: prometheus.NewRegistry(),
+	}
+
+	prometheus.DefaultRegisterer = gr
+	defer func() {
+		prometheus.DefaultRegisterer = original
+	}()
+
+	fn()
+
+	names, err := gr.gathered()
+	if gr.registrations > 0 || len(names) > 0 || err != nil {
+		return assert.Fail(
+			t,
+			"Metrics were registered against prometheus.DefaultRegisterer",
+			"registrations: %d, gathered: [%s], gather error: %v",
+			gr.registrations,
+			strings.Join(names, ", "),
+			err,
+		)
+	}
+
+	return true
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtest
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/stretchr/testify/suite"
+)
+
+type GlobalTestSuite struct {
+	suite.Suite
+}
+
+func (suite *GlobalTestSuite) TestNoRegistrations() {
+	original := prometheus.DefaultRegisterer
+	mockT := &mockTestingT{t: suite.T()}
+	suite.True(
+		AssertNoGlobalRegistrations(mockT, func() {
+			r := prometheus.NewPedanticRegistry()
+			r.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "local",
+				Help: "local",
+			}))
+		}),
+	)
+
+	suite.Zero(mockT.errors)
+	suite.Same(original, prometheus.DefaultRegisterer)
+}
+
+func (suite *GlobalTestSuite) testRegistration(fn func()) {
+	original := prometheus.DefaultRegisterer
+	mockT := &mockTestingT{t: suite.T()}
+	suite.False(AssertNoGlobalRegistrations(mockT, fn))
+	suite.Equal(1, mockT.errors)
+	suite.Same(original, prometheus.DefaultRegisterer)
+
+	// nothing should have leaked into the real global registry
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	suite.Require().NoError(err)
+	for _, mf := range mfs {
+		suite.NotEqual("touchtest_global", mf.GetName())
+	}
+}
+
+func (suite *GlobalTestSuite) TestMustRegister() {
+	suite.testRegistration(func() {
+		prometheus.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "touchtest_global",
+			Help: "touchtest_global",
+		}))
+	})
+}
+
+func (suite *GlobalTestSuite) TestRegister() {
+	suite.testRegistration(func() {
+		suite.NoError(
+			prometheus.Register(prometheus.NewGauge(prometheus.GaugeOpts{
+				Name: "touchtest_global",
+				Help: "touchtest_global",
+			})),
+		)
+	})
+}
+
+func (suite *GlobalTestSuite) TestPromauto() {
+	suite.testRegistration(func() {
+		promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "touchtest_global",
+				Help: "touchtest_global",
+			},
+			[]string{"label"},
+		).WithLabelValues("value").Inc()
+	})
+}
+
+// uncheckedCollector describes nothing, so the registry does not check its metrics.
+type uncheckedCollector struct {
+	counter prometheus.Counter
+}
+
+func (uc uncheckedCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (uc uncheckedCollector) Collect(ch chan<- prometheus.Metric) {
+	uc.counter.Collect(ch)
+}
+
+func (suite *GlobalTestSuite) TestUnchecked() {
+	suite.testRegistration(func() {
+		prometheus.MustRegister(uncheckedCollector{
+			counter: prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "touchtest_global",
+				Help: "touchtest_global",
+			}),
+		})
+	})
+}
+
+func (suite *GlobalTestSuite) TestUnregistered() {
+	suite.testRegistration(func() {
+		c := prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "touchtest_global",
+			Help: "touchtest_global",
+		})
+
+		prometheus.MustRegister(c)
+		prometheus.Unregister(c)
+	})
+}
+
+func TestAssertNoGlobalRegistrations(t *testing.T) {
+	suite.Run(t, new(GlobalTestSuite))
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtest
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Where scopes subsequent calls to GatherAndCompare and CollectAndCompare to the series
+// whose labels match every name/value pair in the selector.  A label absent from a series
+// matches the empty string.  A nil or empty selector restores comparisons of all series.
+//
+// Use this method when a metric family is shared, e.g. when several tests each touch
+// a different series of the same vector in a common registry.
+func (a *Assertions) Where(selector prometheus.Labels) *Assertions {
+	if len(selector) > 0 {
+		a.selector = make(prometheus.Labels, len(selector))
+		for k, v := range selector {
+			a.selector[k] = v
+		}
+	} else {
+		a.selector = nil
+	}
+
+	return a
+}
+
+// matchesSelector tests if a metric has all the labels in the given selector.
+func matchesSelector(m *dto.Metric, selector prometheus.Labels) bool {
+	for name, value := range selector {
+		var actual string
+		for _, lp := range m.GetLabel() {
+			if lp.GetName() == name {
+				actual = lp.GetValue()
+				break
+			}
+		}
+
+		if actual != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// selectSeries removes the metrics that don't match the given selector.  Families
+// left with no metrics are removed entirely.
+func selectSeries(mfs []*dto.MetricFamily, selector prometheus.Labels) []*dto.MetricFamily {
+	selected := mfs[:0]
+	for _, mf := range mfs {
+		metrics := mf.Metric[:0]
+		for _, m := range mf.GetMetric() {
+			if matchesSelector(m, selector) {
+				metrics = append(metrics, m)
+			}
+		}
+
+		if len(metrics) > 0 {
+			mf.Metric = metrics
+			selected = append(selected, mf)
+		}
+	}
+
+	return selected
+}
+
+// selectActual decorates the actual Gatherer so that it only gathers the series
+// matching the current selector.
+func (a *Assertions) selectActual(actual prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := actual.Gather()
+		return selectSeries(mfs, a.selector), err
+	})
+}
+
+// expected returns the current expectation in the text format, limited to
+// the series that match the current selector.
+func (a *Assertions) expected() (io.Reader, error) {
+	if len(a.selector) == 0 {
+		return bytes.NewReader(a.buffer.Bytes()), nil
+	}
+
+	var parser expfmt.TextParser
+	parsed, err := parser.TextToMetricFamilies(bytes.NewReader(a.buffer.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+
+	mfs := make([]*dto.MetricFamily, 0, len(parsed))
+	for _, mf := range parsed {
+		mfs = append(mfs, mf)
+	}
+
+	var (
+		selected bytes.Buffer
+		enc      = expfmt.NewEncoder(&selected, expfmt.NewFormat(expfmt.TypeTextPlain))
+	)
+
+	for _, mf := range selectSeries(mfs, a.selector) {
+		if err = enc.Encode(mf); err != nil {
+			return nil, err
+		}
+	}
+
+	return &selected, nil
+}
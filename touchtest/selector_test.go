@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtest
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+)
+
+type SelectorTestSuite struct {
+	suite.Suite
+}
+
+func (suite *SelectorTestSuite) newCounterVec() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "requests",
+		Help: "test",
+	}, []string{"server", "code"})
+}
+
+func (suite *SelectorTestSuite) TestGatherAndCompare() {
+	var (
+		expected   = prometheus.NewPedanticRegistry()
+		expectedCV = suite.newCounterVec()
+
+		actual   = prometheus.NewPedanticRegistry()
+		actualCV = suite.newCounterVec()
+		other    = prometheus.NewGauge(prometheus.GaugeOpts{Name: "other", Help: "test"})
+	)
+
+	suite.Require().NoError(expected.Register(expectedCV))
+	expectedCV.WithLabelValues("servers.main", "200").Inc()
+
+	suite.Require().NoError(actual.Register(actualCV))
+	suite.Require().NoError(actual.Register(other))
+	actualCV.WithLabelValues("servers.main", "200").Inc()
+	actualCV.WithLabelValues("servers.alt", "500").Add(2.0)
+	other.Set(1.0)
+
+	mt := &mockTestingT{t: suite.T()}
+	a := New(mt).Expect(expected)
+	suite.False(a.GatherAndCompare(actual, "requests"), "sibling series should fail unscoped comparisons")
+	suite.Equal(1, mt.errors)
+	mt.errors = 0
+
+	suite.Same(a, a.Where(prometheus.Labels{"server": "servers.main"}))
+	suite.True(a.GatherAndCompare(actual, "requests"))
+	suite.True(a.GatherAndCompare(actual))
+	suite.Zero(mt.errors)
+
+	a.Where(prometheus.Labels{"server": "servers.alt"})
+	suite.False(a.GatherAndCompare(actual, "requests"))
+	suite.Equal(1, mt.errors)
+	mt.errors = 0
+
+	a.Where(nil)
+	suite.False(a.GatherAndCompare(actual, "requests"))
+	suite.Equal(1, mt.errors)
+}
+
+func (suite *SelectorTestSuite) TestMissingLabelMatchesEmpty() {
+	var (
+		expected = prometheus.NewPedanticRegistry()
+		actual   = prometheus.NewPedanticRegistry()
+		newGauge = func() prometheus.Gauge {
+			return prometheus.NewGauge(prometheus.GaugeOpts{Name: "value", Help: "test"})
+		}
+	)
+
+	suite.Require().NoError(expected.Register(newGauge()))
+	suite.Require().NoError(actual.Register(newGauge()))
+
+	mt := &mockTestingT{t: suite.T()}
+	a := New(mt).Expect(expected).Where(prometheus.Labels{"server": ""})
+	suite.True(a.GatherAndCompare(actual))
+	suite.Zero(mt.errors)
+}
+
+func (suite *SelectorTestSuite) TestCollectAndCompare() {
+	var (
+		expected   = prometheus.NewPedanticRegistry()
+		expectedCV = suite.newCounterVec()
+		actualCV   = suite.newCounterVec()
+	)
+
+	suite.Require().NoError(expected.Register(expectedCV))
+	expectedCV.WithLabelValues("servers.main", "200").Inc()
+	actualCV.WithLabelValues("servers.main", "200").Inc()
+	actualCV.WithLabelValues("servers.alt", "200").Inc()
+
+	mt := &mockTestingT{t: suite.T()}
+	a := New(mt).Expect(expected)
+	suite.False(a.CollectAndCompare(actualCV, "requests"))
+	suite.Equal(1, mt.errors)
+	mt.errors = 0
+
+	a.Where(prometheus.Labels{"server": "servers.main"})
+	suite.True(a.CollectAndCompare(actualCV, "requests"))
+	suite.Zero(mt.errors)
+}
+
+func TestSelector(t *testing.T) {
+	suite.Run(t, new(SelectorTestSuite))
+}
@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtest
+
+import (
+	"bytes"
+	"math"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Tolerance describes how far actual histograms and summaries may stray from the
+// expectation and still be considered equal.  Durations, in particular, can never
+// be compared exactly, so without a Tolerance their families must be skipped.
+//
+// Sample counts are always compared exactly, as are all other metric types.
+type Tolerance struct {
+	// Absolute is the largest allowed difference between an expected and actual
+	// sum or summary quantile value.
+	Absolute float64
+
+	// Relative is the largest allowed difference between an expected and actual sum
+	// or summary quantile value, as a fraction of the expected value, e.g. 0.05 for 5%.
+	// A value is within tolerance if it satisfies either Absolute or Relative.
+	Relative float64
+
+	// BucketDrift is the largest allowed difference between an expected and actual
+	// cumulative histogram bucket count.  This allows observations to fall into a
+	// neighboring bucket.
+	BucketDrift uint64
+}
+
+// Tolerate sets the Tolerance used by subsequent calls to GatherAndCompare and
+// CollectAndCompare.  A nil Tolerance restores exact comparisons.
+func (a *Assertions) Tolerate(t *Tolerance) *Assertions {
+	a.tolerance = t
+	return a
+}
+
+// within tests if an actual value is within tolerance of an expected value.
+func (t Tolerance) within(expected, actual float64) bool {
+	diff := math.Abs(expected - actual)
+	return diff <= t.Absolute || diff <= t.Relative*math.Abs(expected)
+}
+
+// drift tests if an actual bucket count is within tolerance of an expected count.
+func (t Tolerance) drift(expected, actual uint64) bool {
+	if expected > actual {
+		return expected-actual <= t.BucketDrift
+	}
+
+	return actual-expected <= t.BucketDrift
+}
+
+// finiteBuckets returns the buckets of a histogram, excluding any explicit +Inf bucket.
+// Parsed text expectations carry a +Inf bucket while gathered histograms do not, and
+// the +Inf bucket's count is always the sample count anyway.
+func finiteBuckets(h *dto.Histogram) []*dto.Bucket {
+	buckets := h.GetBucket()
+	if n := len(buckets); n > 0 && math.IsInf(buckets[n-1].GetUpperBound(), +1) {
+		buckets = buckets[:n-1]
+	}
+
+	return buckets
+}
+
+// histogram replaces the actual sum and bucket counts with the expected ones if every
+// value is within tolerance.  Buckets must have identical upper bounds.
+func (t Tolerance) histogram(expected, actual *dto.Histogram) {
+	eBuckets, aBuckets := finiteBuckets(expected), finiteBuckets(actual)
+	if expected.GetSampleCount() != actual.GetSampleCount() ||
+		len(eBuckets) != len(aBuckets) ||
+		!t.within(expected.GetSampleSum(), actual.GetSampleSum()) {
+		return
+	}
+
+	for i, eb := range eBuckets {
+		ab := aBuckets[i]
+		if eb.GetUpperBound() != ab.GetUpperBound() || !t.drift(eb.GetCumulativeCount(), ab.GetCumulativeCount()) {
+			return
+		}
+	}
+
+	actual.SampleSum = expected.SampleSum
+	for i, eb := range eBuckets {
+		aBuckets[i].CumulativeCount = eb.CumulativeCount
+	}
+}
+
+// summary replaces the actual sum and quantile values with the expected ones if every
+// value is within tolerance.  Quantiles must be identical.
+func (t Tolerance) summary(expected, actual *dto.Summary) {
+	if expected.GetSampleCount() != actual.GetSampleCount() ||
+		len(expected.GetQuantile()) != len(actual.GetQuantile()) ||
+		!t.within(expected.GetSampleSum(), actual.GetSampleSum()) {
+		return
+	}
+
+	for i, eq := range expected.GetQuantile() {
+		aq := actual.GetQuantile()[i]
+		if eq.GetQuantile() != aq.GetQuantile() || !t.within(eq.GetValue(), aq.GetValue()) {
+			return
+		}
+	}
+
+	actual.SampleSum = expected.SampleSum
+	for i, eq := range expected.GetQuantile() {
+		actual.Quantile[i].Value = eq.Value
+	}
+}
+
+// labelsKey produces a key that identifies a metric within its family.
+func labelsKey(m *dto.Metric) string {
+	var b strings.Builder
+	for _, lp := range m.GetLabel() {
+		b.WriteString(lp.GetName())
+		b.WriteByte('=')
+		b.WriteString(lp.GetValue())
+		b.WriteByte(0xff)
+	}
+
+	return b.String()
+}
+
+// tolerate decorates the actual Gatherer so that histograms and summaries within
+// tolerance of the current expectation are gathered with exactly the expected values.
+// The exact comparison that follows then only reports values out of tolerance.
+func (a *Assertions) tolerate(actual prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := actual.Gather()
+		if err != nil {
+			return mfs, err
+		}
+
+		var parser expfmt.TextParser
+		expected, err := parser.TextToMetricFamilies(bytes.NewReader(a.buffer.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, amf := range mfs {
+			emf, ok := expected[amf.GetName()]
+			if !ok || emf.GetType() != amf.GetType() {
+				continue
+			}
+
+			metrics := make(map[string]*dto.Metric, len(emf.GetMetric()))
+			for _, m := range emf.GetMetric() {
+				metrics[labelsKey(m)] = m
+			}
+
+			for _, am := range amf.GetMetric() {
+				em, ok := metrics[labelsKey(am)]
+				switch {
+				case !ok:
+					continue
+
+				case am.Histogram != nil && em.Histogram != nil:
+					a.tolerance.histogram(em.Histogram, am.Histogram)
+
+				case am.Summary != nil && em.Summary != nil:
+					a.tolerance.summary(em.Summary, am.Summary)
+				}
+			}
+		}
+
+		return mfs, nil
+	})
+}
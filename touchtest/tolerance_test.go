@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtest
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+)
+
+type ToleranceTestSuite struct {
+	suite.Suite
+}
+
+// registries creates an expected and an actual registry, each with a histogram vector,
+// a summary, and a counter.
+func (suite *ToleranceTestSuite) registries(observe func(hv *prometheus.HistogramVec, s prometheus.Summary, c prometheus.Counter)) (expected, actual *prometheus.Registry) {
+	newRegistry := func() *prometheus.Registry {
+		var (
+			r  = prometheus.NewPedanticRegistry()
+			hv = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Name:    "duration",
+				Help:    "test",
+				Buckets: []float64{1.0, 2.0, 5.0},
+			}, []string{"code"})
+			s = prometheus.NewSummary(prometheus.SummaryOpts{
+				Name:       "latency",
+				Help:       "test",
+				Objectives: map[float64]float64{0.5: 0.05},
+			})
+			c = prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "count",
+				Help: "test",
+			})
+		)
+
+		suite.Require().NoError(r.Register(hv))
+		suite.Require().NoError(r.Register(s))
+		suite.Require().NoError(r.Register(c))
+		observe(hv, s, c)
+		return r
+	}
+
+	return newRegistry(), newRegistry()
+}
+
+func (suite *ToleranceTestSuite) TestWithinTolerance() {
+	var calls int
+	expected, actual := suite.registries(func(hv *prometheus.HistogramVec, s prometheus.Summary, c prometheus.Counter) {
+		calls++
+		if calls == 1 {
+			hv.WithLabelValues("200").Observe(0.9)
+			hv.WithLabelValues("200").Observe(1.5)
+			s.Observe(10.0)
+		} else {
+			// 0.9 drifts into the next bucket, and the sums differ slightly
+			hv.WithLabelValues("200").Observe(1.1)
+			hv.WithLabelValues("200").Observe(1.5)
+			s.Observe(10.4)
+		}
+
+		c.Inc()
+	})
+
+	mt := &mockTestingT{t: suite.T()}
+	a := New(mt).Expect(expected)
+	suite.False(a.GatherAndCompare(actual), "exact comparisons should fail")
+	suite.NotZero(mt.errors)
+	mt.errors = 0
+
+	suite.Same(a, a.Tolerate(&Tolerance{Absolute: 0.5, BucketDrift: 1}))
+	suite.True(a.GatherAndCompare(actual))
+	suite.True(a.GatherAndCompare(actual, "duration", "latency"))
+	suite.Zero(mt.errors)
+
+	a.Tolerate(&Tolerance{Relative: 0.1, BucketDrift: 1})
+	suite.True(a.GatherAndCompare(actual))
+	suite.Zero(mt.errors)
+
+	a.Tolerate(nil)
+	suite.False(a.GatherAndCompare(actual))
+	suite.NotZero(mt.errors)
+}
+
+func (suite *ToleranceTestSuite) TestOutOfTolerance() {
+	testCases := []struct {
+		name      string
+		tolerance Tolerance
+	}{
+		{name: "Sum", tolerance: Tolerance{Absolute: 0.1, BucketDrift: 1}},
+		{name: "BucketDrift", tolerance: Tolerance{Absolute: 1.0}},
+	}
+
+	for _, testCase := range testCases {
+		suite.Run(testCase.name, func() {
+			var calls int
+			expected, actual := suite.registries(func(hv *prometheus.HistogramVec, _ prometheus.Summary, _ prometheus.Counter) {
+				calls++
+				if calls == 1 {
+					hv.WithLabelValues("200").Observe(0.9)
+				} else {
+					hv.WithLabelValues("200").Observe(1.1)
+				}
+			})
+
+			mt := &mockTestingT{t: suite.T()}
+			a := New(mt).Expect(expected).Tolerate(&testCase.tolerance)
+			suite.False(a.GatherAndCompare(actual, "duration"))
+			suite.Equal(1, mt.errors)
+		})
+	}
+}
+
+func (suite *ToleranceTestSuite) TestCountsAreExact() {
+	var calls int
+	expected, actual := suite.registries(func(hv *prometheus.HistogramVec, s prometheus.Summary, c prometheus.Counter) {
+		calls++
+		hv.WithLabelValues("200").Observe(1.0)
+		s.Observe(1.0)
+		c.Add(float64(calls))
+		if calls == 2 {
+			hv.WithLabelValues("200").Observe(1.0)
+			s.Observe(1.0)
+		}
+	})
+
+	mt := &mockTestingT{t: suite.T()}
+	a := New(mt).Expect(expected).Tolerate(&Tolerance{Absolute: 100.0, BucketDrift: 100})
+	suite.False(a.GatherAndCompare(actual, "duration"))
+	suite.False(a.GatherAndCompare(actual, "latency"))
+	suite.False(a.GatherAndCompare(actual, "count"))
+	suite.Equal(3, mt.errors)
+}
+
+func (suite *ToleranceTestSuite) TestCollectAndCompare() {
+	var (
+		expected = prometheus.NewHistogram(prometheus.HistogramOpts{Name: "duration", Help: "test", Buckets: []float64{1.0}})
+		actual   = prometheus.NewHistogram(prometheus.HistogramOpts{Name: "duration", Help: "test", Buckets: []float64{1.0}})
+		r        = prometheus.NewPedanticRegistry()
+		mt       = &mockTestingT{t: suite.T()}
+	)
+
+	suite.Require().NoError(r.Register(expected))
+	expected.Observe(0.5)
+	actual.Observe(0.6)
+
+	a := New(mt).Expect(r)
+	suite.False(a.CollectAndCompare(actual, "duration"))
+	suite.Equal(1, mt.errors)
+	mt.errors = 0
+
+	a.Tolerate(&Tolerance{Absolute: 0.2})
+	suite.True(a.CollectAndCompare(actual, "duration"))
+	suite.Zero(mt.errors)
+
+	// a collector that cannot be registered is a failure
+	suite.False(a.CollectAndCompare(prometheus.NewCounter(prometheus.CounterOpts{}), "duration"))
+	suite.Equal(1, mt.errors)
+}
+
+func TestTolerance(t *testing.T) {
+	suite.Run(t, new(ToleranceTestSuite))
+}
@@ -0,0 +1,15 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package touchtoggle allows metrics to be switched on and off at runtime.
+//
+// A Toggle is a named flag.  Metrics wrapped with a Toggle record normally while
+// it is enabled and discard everything while it is disabled.  The underlying
+// metric is swapped atomically for a no-op implementation, so a disabled metric
+// costs almost nothing on the hot path.  This is useful for expensive debug
+// metrics that should only be switched on during an incident.
+//
+// Toggles are grouped into a Toggles set, which implements the Switch interface.
+// A Switch is what an application exposes to an admin endpoint, a feature flag
+// system, or any other runtime control.
+package touchtoggle
@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtoggle
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// discard is the no-op metric swapped in while a toggle is disabled.  It satisfies
+// prometheus.Counter, prometheus.Gauge, and prometheus.Observer, and never
+// produces anything when collected.
+type discard struct{}
+
+func (discard) Desc() *prometheus.Desc           { return nil }
+func (discard) Write(*dto.Metric) error          { return nil }
+func (discard) Describe(chan<- *prometheus.Desc) {}
+func (discard) Collect(chan<- prometheus.Metric) {}
+func (discard) Inc()                             {}
+func (discard) Add(float64)                      {}
+func (discard) Set(float64)                      {}
+func (discard) Dec()                             {}
+func (discard) Sub(float64)                      {}
+func (discard) SetToCurrentTime()                {}
+func (discard) Observe(float64)                  {}
+
+// the holder types give atomic.Value a consistent concrete type to store
+type counterHolder struct{ prometheus.Counter }
+type gaugeHolder struct{ prometheus.Gauge }
+type observerHolder struct{ prometheus.Observer }
+
+type counter struct {
+	prometheus.Counter
+	current atomic.Value
+}
+
+func (c *counter) Inc()          { c.current.Load().(counterHolder).Inc() }
+func (c *counter) Add(v float64) { c.current.Load().(counterHolder).Add(v) }
+
+// Counter returns a prometheus.Counter that discards all updates while the
+// given Toggle is disabled.  The returned counter is still collected normally,
+// and retains the value it had when the toggle was disabled.
+func Counter(t *Toggle, c prometheus.Counter) prometheus.Counter {
+	tc := &counter{Counter: c}
+	t.OnChange(func(enabled bool) {
+		if enabled {
+			tc.current.Store(counterHolder{c})
+		} else {
+			tc.current.Store(counterHolder{discard{}})
+		}
+	})
+
+	return tc
+}
+
+type gauge struct {
+	prometheus.Gauge
+	current atomic.Value
+}
+
+func (g *gauge) Set(v float64)     { g.current.Load().(gaugeHolder).Set(v) }
+func (g *gauge) Inc()              { g.current.Load().(gaugeHolder).Inc() }
+func (g *gauge) Dec()              { g.current.Load().(gaugeHolder).Dec() }
+func (g *gauge) Add(v float64)     { g.current.Load().(gaugeHolder).Add(v) }
+func (g *gauge) Sub(v float64)     { g.current.Load().(gaugeHolder).Sub(v) }
+func (g *gauge) SetToCurrentTime() { g.current.Load().(gaugeHolder).SetToCurrentTime() }
+
+// Gauge returns a prometheus.Gauge that discards all updates while the given
+// Toggle is disabled.
+func Gauge(t *Toggle, g prometheus.Gauge) prometheus.Gauge {
+	tg := &gauge{Gauge: g}
+	t.OnChange(func(enabled bool) {
+		if enabled {
+			tg.current.Store(gaugeHolder{g})
+		} else {
+			tg.current.Store(gaugeHolder{discard{}})
+		}
+	})
+
+	return tg
+}
+
+type observer struct {
+	current atomic.Value
+}
+
+func (o *observer) Observe(v float64) { o.current.Load().(observerHolder).Observe(v) }
+
+// Observer returns a prometheus.Observer that discards all observations while
+// the given Toggle is disabled.
+func Observer(t *Toggle, o prometheus.Observer) prometheus.Observer {
+	to := new(observer)
+	t.OnChange(func(enabled bool) {
+		if enabled {
+			to.current.Store(observerHolder{o})
+		} else {
+			to.current.Store(observerHolder{discard{}})
+		}
+	})
+
+	return to
+}
+
+// resetOnDisable clears all the children of a vector whenever the toggle is
+// disabled, so that a disabled vector is not exposed when gathered.
+func resetOnDisable(t *Toggle, vec interface{}) {
+	if r, ok := vec.(interface{ Reset() }); ok {
+		t.OnChange(func(enabled bool) {
+			if !enabled {
+				r.Reset()
+			}
+		})
+	}
+}
+
+// CounterVec wraps a prometheus.CounterVec so that no series are created or
+// updated while a Toggle is disabled.  Disabling the toggle also resets the vector,
+// so none of its series are exposed until the toggle is enabled again.
+type CounterVec struct {
+	toggle *Toggle
+	vec    *prometheus.CounterVec
+}
+
+// NewCounterVec creates a CounterVec controlled by the given Toggle.
+func NewCounterVec(t *Toggle, vec *prometheus.CounterVec) CounterVec {
+	resetOnDisable(t, vec)
+	return CounterVec{toggle: t, vec: vec}
+}
+
+// Unwrap returns the underlying prometheus vector.
+func (cv CounterVec) Unwrap() *prometheus.CounterVec {
+	return cv.vec
+}
+
+// With returns the counter for the given labels.  If the toggle is disabled,
+// the returned counter discards all updates.
+func (cv CounterVec) With(l prometheus.Labels) prometheus.Counter {
+	if cv.toggle.Enabled() {
+		return cv.vec.With(l)
+	}
+
+	return discard{}
+}
+
+// WithLabelValues returns the counter for the given label values.  If the toggle
+// is disabled, the returned counter discards all updates.
+func (cv CounterVec) WithLabelValues(lvs ...string) prometheus.Counter {
+	if cv.toggle.Enabled() {
+		return cv.vec.WithLabelValues(lvs...)
+	}
+
+	return discard{}
+}
+
+// GaugeVec wraps a prometheus.GaugeVec so that no series are created or
+// updated while a Toggle is disabled.  Disabling the toggle also resets the vector.
+type GaugeVec struct {
+	toggle *Toggle
+	vec    *prometheus.GaugeVec
+}
+
+// NewGaugeVec creates a GaugeVec controlled by the given Toggle.
+func NewGaugeVec(t *Toggle, vec *prometheus.GaugeVec) GaugeVec {
+	resetOnDisable(t, vec)
+	return GaugeVec{toggle: t, vec: vec}
+}
+
+// Unwrap returns the underlying prometheus vector.
+func (gv GaugeVec) Unwrap() *prometheus.GaugeVec {
+	return gv.vec
+}
+
+// With returns the gauge for the given labels.  If the toggle is disabled,
+// the returned gauge discards all updates.
+func (gv GaugeVec) With(l prometheus.Labels) prometheus.Gauge {
+	if gv.toggle.Enabled() {
+		return gv.vec.With(l)
+	}
+
+	return discard{}
+}
+
+// WithLabelValues returns the gauge for the given label values.  If the toggle
+// is disabled, the returned gauge discards all updates.
+func (gv GaugeVec) WithLabelValues(lvs ...string) prometheus.Gauge {
+	if gv.toggle.Enabled() {
+		return gv.vec.WithLabelValues(lvs...)
+	}
+
+	return discard{}
+}
+
+// ObserverVec wraps a prometheus.ObserverVec, i.e. a histogram or summary vector,
+// so that no series are created or updated while a Toggle is disabled.  Disabling
+// the toggle also resets the vector if it supports resetting.
+type ObserverVec struct {
+	toggle *Toggle
+	vec    prometheus.ObserverVec
+}
+
+// NewObserverVec creates an ObserverVec controlled by the given Toggle.
+func NewObserverVec(t *Toggle, vec prometheus.ObserverVec) ObserverVec {
+	resetOnDisable(t, vec)
+	return ObserverVec{toggle: t, vec: vec}
+}
+
+// Unwrap returns the underlying prometheus vector.
+func (ov ObserverVec) Unwrap() prometheus.ObserverVec {
+	return ov.vec
+}
+
+// With returns the observer for the given labels.  If the toggle is disabled,
+// the returned observer discards all observations.
+func (ov ObserverVec) With(l prometheus.Labels) prometheus.Observer {
+	if ov.toggle.Enabled() {
+		return ov.vec.With(l)
+	}
+
+	return discard{}
+}
+
+// WithLabelValues returns the observer for the given label values.  If the toggle
+// is disabled, the returned observer discards all observations.
+func (ov ObserverVec) WithLabelValues(lvs ...string) prometheus.Observer {
+	if ov.toggle.Enabled() {
+		return ov.vec.WithLabelValues(lvs...)
+	}
+
+	return discard{}
+}
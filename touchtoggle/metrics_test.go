@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtoggle
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsSuite struct {
+	suite.Suite
+	toggle *Toggle
+}
+
+func (suite *MetricsSuite) SetupTest() {
+	suite.toggle = newToggle("test", true)
+}
+
+func (suite *MetricsSuite) TestCounter() {
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "test"})
+	tc := Counter(suite.toggle, c)
+
+	tc.Inc()
+	tc.Add(2.0)
+	suite.Equal(3.0, testutil.ToFloat64(tc))
+
+	suite.toggle.Disable()
+	tc.Inc()
+	tc.Add(2.0)
+	suite.Equal(3.0, testutil.ToFloat64(c))
+
+	suite.toggle.Enable()
+	tc.Inc()
+	suite.Equal(4.0, testutil.ToFloat64(c))
+}
+
+func (suite *MetricsSuite) TestGauge() {
+	g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test"})
+	tg := Gauge(suite.toggle, g)
+
+	tg.Set(10.0)
+	tg.Inc()
+	tg.Dec()
+	tg.Add(5.0)
+	tg.Sub(1.0)
+	suite.Equal(14.0, testutil.ToFloat64(tg))
+
+	suite.toggle.Disable()
+	tg.Set(100.0)
+	tg.Inc()
+	tg.Dec()
+	tg.Add(5.0)
+	tg.Sub(1.0)
+	tg.SetToCurrentTime()
+	suite.Equal(14.0, testutil.ToFloat64(g))
+
+	suite.toggle.Enable()
+	tg.SetToCurrentTime()
+	suite.Greater(testutil.ToFloat64(g), 14.0)
+}
+
+func (suite *MetricsSuite) TestObserver() {
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test"})
+	to := Observer(suite.toggle, h)
+
+	to.Observe(1.0)
+	suite.toggle.Disable()
+	to.Observe(1.0)
+	suite.toggle.Enable()
+	to.Observe(1.0)
+
+	var m dto.Metric
+	suite.Require().NoError(h.Write(&m))
+	suite.Equal(uint64(2), m.GetHistogram().GetSampleCount())
+}
+
+func (suite *MetricsSuite) TestCounterVec() {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test"}, []string{"label"})
+	cv := NewCounterVec(suite.toggle, vec)
+	suite.Same(vec, cv.Unwrap())
+
+	cv.WithLabelValues("a").Inc()
+	cv.With(prometheus.Labels{"label": "b"}).Inc()
+	suite.Equal(2, testutil.CollectAndCount(vec))
+
+	suite.toggle.Disable()
+	suite.Zero(testutil.CollectAndCount(vec))
+	cv.WithLabelValues("a").Inc()
+	cv.With(prometheus.Labels{"label": "b"}).Inc()
+	suite.Zero(testutil.CollectAndCount(vec))
+
+	suite.toggle.Enable()
+	cv.WithLabelValues("a").Inc()
+	suite.Equal(1.0, testutil.ToFloat64(vec.WithLabelValues("a")))
+}
+
+func (suite *MetricsSuite) TestGaugeVec() {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test"}, []string{"label"})
+	gv := NewGaugeVec(suite.toggle, vec)
+	suite.Same(vec, gv.Unwrap())
+
+	gv.WithLabelValues("a").Set(1.0)
+	gv.With(prometheus.Labels{"label": "b"}).Set(2.0)
+	suite.Equal(2, testutil.CollectAndCount(vec))
+
+	suite.toggle.Disable()
+	gv.WithLabelValues("a").Set(1.0)
+	gv.With(prometheus.Labels{"label": "b"}).Set(2.0)
+	suite.Zero(testutil.CollectAndCount(vec))
+}
+
+func (suite *MetricsSuite) TestObserverVec() {
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test"}, []string{"label"})
+	ov := NewObserverVec(suite.toggle, vec)
+	suite.Equal(vec, ov.Unwrap())
+
+	ov.WithLabelValues("a").Observe(1.0)
+	ov.With(prometheus.Labels{"label": "b"}).Observe(1.0)
+	suite.Equal(2, testutil.CollectAndCount(vec))
+
+	suite.toggle.Disable()
+	ov.WithLabelValues("a").Observe(1.0)
+	ov.With(prometheus.Labels{"label": "b"}).Observe(1.0)
+	suite.Zero(testutil.CollectAndCount(vec))
+}
+
+func TestMetrics(t *testing.T) {
+	suite.Run(t, new(MetricsSuite))
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtoggle
+
+import "go.uber.org/fx"
+
+// Config describes the initial state of toggles.
+type Config struct {
+	// Initial maps toggle names onto their initial states.  Any toggle not in
+	// this map uses the default state supplied by the code that creates it.
+	Initial map[string]bool `json:"initial" yaml:"initial"`
+}
+
+// In holds the components used to create a Toggles set.
+type In struct {
+	fx.In
+
+	// Config is the optional toggle configuration.
+	Config Config `optional:"true"`
+}
+
+// Provide supplies both a *Toggles and its Switch interface to the enclosing
+// fx.App.  An optional Config component sets the initial state of toggles.
+//
+//	app := fx.New(
+//	  touchstone.Provide(),
+//	  touchtoggle.Provide(),
+//	  fx.Provide(
+//	    func(f touchstone.MetricFactory, ts *touchtoggle.Toggles) (touchtoggle.ObserverVec, error) {
+//	      vec, err := f.NewHistogramVec(prometheus.HistogramOpts{Name: "debug_payload_size"}, "device")
+//	      if err != nil {
+//	        return touchtoggle.ObserverVec{}, err
+//	      }
+//
+//	      return touchtoggle.NewObserverVec(ts.Toggle("debug", false), vec), nil
+//	    },
+//	  ),
+//	  fx.Invoke(
+//	    func(s touchtoggle.Switch) {
+//	      // expose s via an admin endpoint or a feature flag listener
+//	    },
+//	  ),
+//	)
+func Provide() fx.Option {
+	return fx.Provide(
+		func(in In) *Toggles {
+			return NewToggles(in.Config)
+		},
+		func(ts *Toggles) Switch {
+			return ts
+		},
+	)
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtoggle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type ProvideSuite struct {
+	suite.Suite
+}
+
+func (suite *ProvideSuite) TestDefault() {
+	var (
+		ts *Toggles
+		s  Switch
+	)
+
+	app := fxtest.New(
+		suite.T(),
+		Provide(),
+		fx.Populate(&ts, &s),
+	)
+
+	app.RequireStart()
+	suite.Require().NotNil(ts)
+	suite.Same(ts, s)
+	suite.False(ts.Toggle("test", false).Enabled())
+	app.RequireStop()
+}
+
+func (suite *ProvideSuite) TestConfig() {
+	var s Switch
+	app := fxtest.New(
+		suite.T(),
+		Provide(),
+		fx.Supply(Config{
+			Initial: map[string]bool{"debug": true},
+		}),
+		fx.Invoke(func(ts *Toggles) {
+			ts.Toggle("debug", false)
+		}),
+		fx.Populate(&s),
+	)
+
+	app.RequireStart()
+	enabled, err := s.Enabled("debug")
+	suite.NoError(err)
+	suite.True(enabled)
+	app.RequireStop()
+}
+
+func TestProvide(t *testing.T) {
+	suite.Run(t, new(ProvideSuite))
+}
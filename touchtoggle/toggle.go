@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtoggle
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrNoSuchToggle indicates that a Switch was asked about a toggle that does not exist.
+var ErrNoSuchToggle = errors.New("No such toggle")
+
+// Toggle is a named flag that enables or disables a group of metrics.
+// A Toggle is safe for concurrent use.
+type Toggle struct {
+	name    string
+	enabled atomic.Bool
+
+	lock      sync.Mutex
+	listeners []func(bool)
+}
+
+func newToggle(name string, enabled bool) *Toggle {
+	t := &Toggle{
+		name: name,
+	}
+
+	t.enabled.Store(enabled)
+	return t
+}
+
+// Name returns the unique name of this toggle.
+func (t *Toggle) Name() string {
+	return t.name
+}
+
+// Enabled returns the current state of this toggle.
+func (t *Toggle) Enabled() bool {
+	return t.enabled.Load()
+}
+
+// Set changes the state of this toggle.  Listeners are only notified when the
+// state actually changes.
+func (t *Toggle) Set(enabled bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.enabled.Swap(enabled) != enabled {
+		for _, l := range t.listeners {
+			l(enabled)
+		}
+	}
+}
+
+// Enable is a shorthand for Set(true).
+func (t *Toggle) Enable() {
+	t.Set(true)
+}
+
+// Disable is a shorthand for Set(false).
+func (t *Toggle) Disable() {
+	t.Set(false)
+}
+
+// OnChange registers a listener that is invoked with the new state each time
+// this toggle changes.  The listener is also invoked immediately with the current
+// state, which allows callers to swap in the appropriate implementation of a whole
+// bundle of metrics, e.g. a touchhttp instrumenter.
+//
+// Listeners are invoked while this toggle's lock is held, so they must not
+// change the state of this toggle.
+func (t *Toggle) OnChange(l func(bool)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.listeners = append(t.listeners, l)
+	l(t.enabled.Load())
+}
+
+// Switch is the runtime control over a set of toggles.  Components that enable
+// or disable metrics, such as admin endpoints, should depend on this interface.
+type Switch interface {
+	// Names returns the sorted names of all toggles.
+	Names() []string
+
+	// Enabled returns the current state of the given toggle.  If no such toggle
+	// exists, this method returns ErrNoSuchToggle.
+	Enabled(name string) (bool, error)
+
+	// Set changes the state of the given toggle.  If no such toggle exists,
+	// this method returns ErrNoSuchToggle.
+	Set(name string, enabled bool) error
+}
+
+// Toggles is a set of uniquely named toggles.  The zero value is not usable.
+// Use NewToggles to create a Toggles.
+type Toggles struct {
+	lock    sync.Mutex
+	initial map[string]bool
+	toggles map[string]*Toggle
+}
+
+var _ Switch = (*Toggles)(nil)
+
+// NewToggles creates an empty set of toggles using the given configuration.
+func NewToggles(cfg Config) *Toggles {
+	ts := &Toggles{
+		initial: make(map[string]bool, len(cfg.Initial)),
+		toggles: make(map[string]*Toggle),
+	}
+
+	for name, enabled := range cfg.Initial {
+		ts.initial[name] = enabled
+	}
+
+	return ts
+}
+
+// Toggle returns the toggle with the given name, creating it if necessary.
+// When created, a toggle's state comes from the configuration.  If not configured,
+// enabled is used as the initial state.
+func (ts *Toggles) Toggle(name string, enabled bool) *Toggle {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	t, ok := ts.toggles[name]
+	if !ok {
+		if initial, ok := ts.initial[name]; ok {
+			enabled = initial
+		}
+
+		t = newToggle(name, enabled)
+		ts.toggles[name] = t
+	}
+
+	return t
+}
+
+func (ts *Toggles) get(name string) (*Toggle, error) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	if t, ok := ts.toggles[name]; ok {
+		return t, nil
+	}
+
+	return nil, ErrNoSuchToggle
+}
+
+// Names returns the sorted names of all toggles in this set.
+func (ts *Toggles) Names() []string {
+	ts.lock.Lock()
+	names := make([]string, 0, len(ts.toggles))
+	for name := range ts.toggles {
+		names = append(names, name)
+	}
+
+	ts.lock.Unlock()
+	sort.Strings(names)
+	return names
+}
+
+// Enabled returns the state of the named toggle.
+func (ts *Toggles) Enabled(name string) (bool, error) {
+	t, err := ts.get(name)
+	if err != nil {
+		return false, err
+	}
+
+	return t.Enabled(), nil
+}
+
+// Set changes the state of the named toggle.
+func (ts *Toggles) Set(name string, enabled bool) error {
+	t, err := ts.get(name)
+	if err == nil {
+		t.Set(enabled)
+	}
+
+	return err
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchtoggle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ToggleSuite struct {
+	suite.Suite
+}
+
+func (suite *ToggleSuite) TestOnChange() {
+	var (
+		t     = newToggle("test", false)
+		calls []bool
+	)
+
+	suite.Equal("test", t.Name())
+	suite.False(t.Enabled())
+
+	t.OnChange(func(enabled bool) {
+		calls = append(calls, enabled)
+	})
+
+	suite.Equal([]bool{false}, calls)
+
+	t.Enable()
+	suite.True(t.Enabled())
+	t.Enable() // no change, so no notification
+	t.Disable()
+	suite.False(t.Enabled())
+
+	suite.Equal([]bool{false, true, false}, calls)
+}
+
+func (suite *ToggleSuite) TestToggles() {
+	ts := NewToggles(Config{
+		Initial: map[string]bool{"configured": true},
+	})
+
+	suite.Empty(ts.Names())
+
+	configured := ts.Toggle("configured", false)
+	suite.True(configured.Enabled())
+	suite.Same(configured, ts.Toggle("configured", false))
+
+	other := ts.Toggle("other", false)
+	suite.False(other.Enabled())
+	suite.Equal([]string{"configured", "other"}, ts.Names())
+
+	suite.NoError(ts.Set("other", true))
+	enabled, err := ts.Enabled("other")
+	suite.NoError(err)
+	suite.True(enabled)
+	suite.True(other.Enabled())
+
+	_, err = ts.Enabled("nosuch")
+	suite.ErrorIs(err, ErrNoSuchToggle)
+	suite.ErrorIs(ts.Set("nosuch", true), ErrNoSuchToggle)
+}
+
+func TestToggle(t *testing.T) {
+	suite.Run(t, new(ToggleSuite))
+}
@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+)
+
+// registeredCollector is a collector that a Factory successfully registered.
+type registeredCollector struct {
+	// name is the fully qualified name of the metric, which is empty
+	// for arbitrary collectors
+	name string
+
+	// collector is the collector as registered, including any decoration
+	collector prometheus.Collector
+
+	// source is the collector as it was passed to the Factory, before any decoration
+	source prometheus.Collector
+
+	// registerer is the Registerer the collector was registered with
+	registerer prometheus.Registerer
+
+	// owner is the Factory that registered the collector
+	owner *Factory
+}
+
+// ownedBy tests whether the given Factory, or a Factory derived from it, registered
+// this collector.
+func (rc registeredCollector) ownedBy(f *Factory) bool {
+	for d := rc.owner; d != nil; d = d.parent {
+		if d == f {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unregister removes this collector from the Registerer it was registered with.
+func (rc registeredCollector) unregister() bool {
+	return rc.registerer.Unregister(rc.collector)
+}
+
+// registeredCollectors tracks the collectors registered by a Factory, and any Factory
+// derived from it, so that they can be unregistered later.
+type registeredCollectors struct {
+	lock    sync.Mutex
+	entries []registeredCollector
+}
+
+func (rcs *registeredCollectors) add(rc registeredCollector) {
+	if rcs != nil {
+		rcs.lock.Lock()
+		rcs.entries = append(rcs.entries, rc)
+		rcs.lock.Unlock()
+	}
+}
+
+// remove forgets and returns the collectors owned by the given Factory that match a predicate.
+func (rcs *registeredCollectors) remove(owner *Factory, match func(registeredCollector) bool) (removed []registeredCollector) {
+	if rcs == nil {
+		return
+	}
+
+	rcs.lock.Lock()
+	defer rcs.lock.Unlock()
+
+	kept := rcs.entries[:0]
+	for _, rc := range rcs.entries {
+		if rc.ownedBy(owner) && match(rc) {
+			removed = append(removed, rc)
+		} else {
+			kept = append(kept, rc)
+		}
+	}
+
+	// clear the tail so that removed collectors can be garbage collected
+	for i := len(kept); i < len(rcs.entries); i++ {
+		rcs.entries[i] = registeredCollector{}
+	}
+
+	rcs.entries = kept
+	return
+}
+
+// unregisterAll unregisters each collector, most recently registered first.
+func (f *Factory) unregisterAll(removed []registeredCollector) (unregistered bool) {
+	for i := len(removed) - 1; i >= 0; i-- {
+		rc := removed[i]
+		if rc.unregister() {
+			unregistered = true
+		}
+
+		if len(rc.name) > 0 {
+			f.summaries.remove(rc.name)
+		}
+	}
+
+	return
+}
+
+// Unregister removes the metric with the given fully qualified name, e.g. as reported by
+// RegistrationEvent.Name, from the registry.  Only metrics created by this Factory, or by
+// a Factory derived from it, are removed.  This allows dynamically created metrics, such as
+// those for a single tenant or connection, to be cleaned up once they are no longer needed.
+// Note that a prometheus registry only permits the name to be registered again with the
+// same label names.
+//
+// This method returns true if a metric was unregistered.
+func (f *Factory) Unregister(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	return f.unregisterAll(
+		f.registered.remove(f, func(rc registeredCollector) bool {
+			return rc.name == name
+		}),
+	)
+}
+
+// unregisterCollector removes a collector that this Factory, or a Factory derived from
+// it, registered.  The collector must be the one originally passed to register, and it
+// must be comparable, e.g. a pointer.
+func (f *Factory) unregisterCollector(c prometheus.Collector) bool {
+	return f.unregisterAll(
+		f.registered.remove(f, func(rc registeredCollector) bool {
+			return rc.source == c
+		}),
+	)
+}
+
+// Close unregisters every collector created by this Factory, or by a Factory derived
+// from it, including arbitrary collectors.  The Factory remains usable afterward.
+//
+// This method always returns nil.  It returns an error so that a Factory is an io.Closer.
+func (f *Factory) Close() error {
+	f.unregisterAll(
+		f.registered.remove(f, func(registeredCollector) bool {
+			return true
+		}),
+	)
+
+	return nil
+}
+
+// UnregisterOnStop decorates the *Factory and MetricFactory components within an fx scope
+// so that every metric created within that scope is unregistered when the application
+// stops.  Place this option within an fx.Module:
+//
+//	fx.New(
+//	  touchstone.Provide(),
+//	  fx.Module(
+//	    "sessions",
+//	    touchstone.UnregisterOnStop(),
+//	    sessions.Provide(),
+//	  ),
+//	)
+//
+// Like Subsystem, the decorated MetricFactory is the decorated *Factory, so any
+// decoration of the MetricFactory from outside the scope is not applied.
+func UnregisterOnStop() fx.Option {
+	return fx.Decorate(
+		func(f *Factory, lc fx.Lifecycle) *Factory {
+			scoped := f.derive()
+			lc.Append(fx.Hook{
+				OnStop: func(context.Context) error {
+					return scoped.Close()
+				},
+			})
+
+			return scoped
+		},
+		func(MetricFactory, f *Factory) MetricFactory {
+			return f
+		},
+	)
+}
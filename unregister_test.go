@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/fx"
+)
+
+type UnregisterTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *UnregisterTestSuite) newFactory() (prometheus.Gatherer, *Factory) {
+	cfg := Config{
+		DefaultNamespace:          "test",
+		DisableGoCollector:        true,
+		DisableProcessCollector:   true,
+		DisableBuildInfoCollector: true,
+	}
+
+	g, r, err := New(cfg)
+	suite.Require().NoError(err)
+	return g, NewFactory(cfg, suite.logger, r)
+}
+
+// names gathers the names of all registered metrics.
+func (suite *UnregisterTestSuite) names(g prometheus.Gatherer) []string {
+	mfs, err := g.Gather()
+	suite.Require().NoError(err)
+
+	names := []string{}
+	for _, mf := range mfs {
+		names = append(names, mf.GetName())
+	}
+
+	return names
+}
+
+func (suite *UnregisterTestSuite) TestUnregister() {
+	g, f := suite.newFactory()
+	c, err := f.NewCounter(prometheus.CounterOpts{Name: "counter"})
+	suite.Require().NoError(err)
+	c.Inc()
+
+	gv, err := f.NewGaugeVec(prometheus.GaugeOpts{Name: "gauge"}, "tenant")
+	suite.Require().NoError(err)
+	gv.WithLabelValues("acme").Set(1.0)
+
+	suite.Equal([]string{"test_counter", "test_gauge"}, suite.names(g))
+
+	suite.False(f.Unregister(""))
+	suite.False(f.Unregister("nosuch"))
+	suite.True(f.Unregister("test_gauge"))
+	suite.False(f.Unregister("test_gauge"))
+	suite.Equal([]string{"test_counter"}, suite.names(g))
+
+	// the name can be reused, though prometheus requires the same label names
+	_, err = f.NewGaugeVec(prometheus.GaugeOpts{Name: "gauge"}, "tenant")
+	suite.NoError(err)
+}
+
+func (suite *UnregisterTestSuite) TestUnregisterSummary() {
+	_, f := suite.newFactory()
+	s, err := f.NewSummary(prometheus.SummaryOpts{
+		Name:       "summary",
+		Objectives: map[float64]float64{0.5: 0.05},
+	})
+
+	suite.Require().NoError(err)
+	s.Observe(1.0)
+
+	_, err = f.SummaryQuantiles("test_summary", nil)
+	suite.NoError(err)
+
+	suite.True(f.Unregister("test_summary"))
+	_, err = f.SummaryQuantiles("test_summary", nil)
+	suite.ErrorIs(err, ErrNoSuchSummary)
+}
+
+func (suite *UnregisterTestSuite) TestClose() {
+	g, f := suite.newFactory()
+	_, err := f.NewCounter(prometheus.CounterOpts{Name: "counter"})
+	suite.Require().NoError(err)
+
+	_, err = f.RegisterWithTimeout(
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "collected", Help: "collected"}),
+		time.Second,
+		prometheus.CounterOpts{Name: "timeouts"},
+	)
+
+	suite.Require().NoError(err)
+
+	child := f.withSubsystem("child")
+	_, err = child.NewGauge(prometheus.GaugeOpts{Name: "gauge"})
+	suite.Require().NoError(err)
+
+	_, err = child.NewCounter(prometheus.CounterOpts{Name: "counter"})
+	suite.Require().NoError(err)
+
+	suite.Equal(
+		[]string{"collected", "test_child_counter", "test_child_gauge", "test_counter", "test_timeouts"},
+		suite.names(g),
+	)
+
+	// a derived Factory cannot unregister what its parent created
+	suite.False(child.Unregister("test_counter"))
+
+	// but a parent can unregister what a derived Factory created
+	suite.True(f.Unregister("test_child_counter"))
+
+	suite.NoError(child.Close())
+	suite.Equal([]string{"collected", "test_counter", "test_timeouts"}, suite.names(g))
+
+	suite.NoError(f.Close())
+	suite.Empty(suite.names(g))
+	suite.NoError(f.Close())
+
+	// the Factory is still usable
+	_, err = f.NewCounter(prometheus.CounterOpts{Name: "counter"})
+	suite.NoError(err)
+	suite.Equal([]string{"test_counter"}, suite.names(g))
+}
+
+func (suite *UnregisterTestSuite) TestUnregisterOnStop() {
+	var (
+		g      prometheus.Gatherer
+		module *Factory
+		mf     MetricFactory
+	)
+
+	app := suite.newTestApp(
+		Provide(),
+		fx.Replace(Config{
+			DisableGoCollector:        true,
+			DisableProcessCollector:   true,
+			DisableBuildInfoCollector: true,
+		}),
+		fx.Invoke(func(f *Factory) error {
+			_, err := f.NewCounter(prometheus.CounterOpts{Name: "app_counter"})
+			return err
+		}),
+		fx.Populate(&g),
+		fx.Module(
+			"sessions",
+			UnregisterOnStop(),
+			fx.Invoke(func(f *Factory) error {
+				_, err := f.NewGauge(prometheus.GaugeOpts{Name: "sessions"})
+				return err
+			}),
+			fx.Populate(&module, &mf),
+		),
+	)
+
+	app.RequireStart()
+	suite.Same(module, mf)
+
+	_, err := module.NewCounter(prometheus.CounterOpts{Name: "session_events"})
+	suite.Require().NoError(err)
+	suite.Equal([]string{"app_counter", "session_events", "sessions"}, suite.names(g))
+
+	app.RequireStop()
+	suite.Equal([]string{"app_counter"}, suite.names(g))
+}
+
+func TestUnregister(t *testing.T) {
+	suite.Run(t, new(UnregisterTestSuite))
+}
@@ -4,7 +4,10 @@
 package touchstone
 
 import (
+	"expvar"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -17,10 +20,49 @@ import (
 // In particular, this function is useful when f has the signature func() int.  This
 // is the common case for things like queue depth, length of a data structure, etc.
 //
+// A few other common sources of values are also supported:
+//
+//   - func() time.Duration, which is reported in seconds
+//   - func() bool, which is reported as 1 for true and 0 for false
+//   - *expvar.Int and *expvar.Float
+//   - *atomic.Int32, *atomic.Int64, *atomic.Uint32, *atomic.Uint64, and *atomic.Bool
+//     from the sync/atomic package
+//
 // If f is not a function or is a function with an unsupported signature,
 // an error is returned.
 func NewUntypedFunc(opts prometheus.UntypedOpts, f interface{}) (uf prometheus.UntypedFunc, err error) {
-	var untyped func() float64
+	untyped, err := untypedValue(f)
+	if err == nil {
+		uf = prometheus.NewUntypedFunc(opts, untyped)
+	}
+
+	return
+}
+
+// untypedValue converts any of the sources of values supported by NewUntypedFunc
+// into a func() float64.
+func untypedValue(f interface{}) (func() float64, error) {
+	if untyped := integerFunc(f); untyped != nil {
+		return untyped, nil
+	}
+
+	if untyped := scalarFunc(f); untyped != nil {
+		return untyped, nil
+	}
+
+	if untyped := valueFunc(f); untyped != nil {
+		return untyped, nil
+	}
+
+	return nil, fmt.Errorf(
+		"%T is not a function with the signature func() N, where N is a numeric type, nor a supported expvar or atomic value",
+		f,
+	)
+}
+
+// integerFunc adapts a function that returns any integer type.  If f is not
+// such a function, this function returns nil.
+func integerFunc(f interface{}) (untyped func() float64) {
 	switch fn := f.(type) {
 	case func() uint8: // handles byte
 		untyped = func() float64 { return float64(fn()) }
@@ -51,23 +93,64 @@ func NewUntypedFunc(opts prometheus.UntypedOpts, f interface{}) (uf prometheus.U
 
 	case func() int:
 		untyped = func() float64 { return float64(fn()) }
+	}
 
+	return
+}
+
+// scalarFunc adapts a function that returns a float, a time.Duration, or a bool.
+// If f is not such a function, this function returns nil.
+func scalarFunc(f interface{}) (untyped func() float64) {
+	switch fn := f.(type) {
 	case func() float32:
 		untyped = func() float64 { return float64(fn()) }
 
 	case func() float64:
 		untyped = fn
 
-	default:
-		err = fmt.Errorf(
-			"%T is not a function with the signature func() N, where N is a numeric type",
-			f,
-		)
+	case func() time.Duration:
+		untyped = func() float64 { return fn().Seconds() }
+
+	case func() bool:
+		untyped = func() float64 { return boolToFloat(fn()) }
 	}
 
-	if err == nil {
-		uf = prometheus.NewUntypedFunc(opts, untyped)
+	return
+}
+
+// valueFunc adapts the supported expvar and sync/atomic values.  If f is not one
+// of these values, this function returns nil.
+func valueFunc(f interface{}) (untyped func() float64) {
+	switch fn := f.(type) {
+	case *expvar.Int:
+		untyped = func() float64 { return float64(fn.Value()) }
+
+	case *expvar.Float:
+		untyped = fn.Value
+
+	case *atomic.Int32:
+		untyped = func() float64 { return float64(fn.Load()) }
+
+	case *atomic.Int64:
+		untyped = func() float64 { return float64(fn.Load()) }
+
+	case *atomic.Uint32:
+		untyped = func() float64 { return float64(fn.Load()) }
+
+	case *atomic.Uint64:
+		untyped = func() float64 { return float64(fn.Load()) }
+
+	case *atomic.Bool:
+		untyped = func() float64 { return boolToFloat(fn.Load()) }
 	}
 
 	return
 }
+
+func boolToFloat(v bool) float64 {
+	if v {
+		return 1.0
+	}
+
+	return 0.0
+}
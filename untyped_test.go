@@ -5,8 +5,11 @@ package touchstone
 
 import (
 	"bytes"
+	"expvar"
 	"io"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -129,6 +132,84 @@ func (suite *NewUntypedFuncSuite) TestSupportedType() {
 			f:           func() float64 { return 1234.0 },
 			expected:    1234.0,
 		},
+		{
+			description: "time.Duration",
+			f:           func() time.Duration { return 1500 * time.Millisecond },
+			expected:    1.5,
+		},
+		{
+			description: "bool true",
+			f:           func() bool { return true },
+			expected:    1.0,
+		},
+		{
+			description: "bool false",
+			f:           func() bool { return false },
+			expected:    0.0,
+		},
+		{
+			description: "*expvar.Int",
+			f: func() interface{} {
+				v := new(expvar.Int)
+				v.Set(123)
+				return v
+			}(),
+			expected: 123.0,
+		},
+		{
+			description: "*expvar.Float",
+			f: func() interface{} {
+				v := new(expvar.Float)
+				v.Set(-45.5)
+				return v
+			}(),
+			expected: -45.5,
+		},
+		{
+			description: "*atomic.Int32",
+			f: func() interface{} {
+				v := new(atomic.Int32)
+				v.Store(-17)
+				return v
+			}(),
+			expected: -17.0,
+		},
+		{
+			description: "*atomic.Int64",
+			f: func() interface{} {
+				v := new(atomic.Int64)
+				v.Store(8723)
+				return v
+			}(),
+			expected: 8723.0,
+		},
+		{
+			description: "*atomic.Uint32",
+			f: func() interface{} {
+				v := new(atomic.Uint32)
+				v.Store(92)
+				return v
+			}(),
+			expected: 92.0,
+		},
+		{
+			description: "*atomic.Uint64",
+			f: func() interface{} {
+				v := new(atomic.Uint64)
+				v.Store(1928)
+				return v
+			}(),
+			expected: 1928.0,
+		},
+		{
+			description: "*atomic.Bool",
+			f: func() interface{} {
+				v := new(atomic.Bool)
+				v.Store(true)
+				return v
+			}(),
+			expected: 1.0,
+		},
 	}
 
 	for _, testCase := range testCases {
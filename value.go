@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	// ErrNoSuchSeries indicates that a collector emitted no series matching a set of labels.
+	ErrNoSuchSeries = errors.New("No series matches the labels")
+
+	// ErrAmbiguousSeries indicates that a collector emitted more than one series
+	// matching a set of labels.
+	ErrAmbiguousSeries = errors.New("More than one series matches the labels")
+
+	// ErrWrongMetricType indicates that a series was not of the type required to read it,
+	// e.g. reading a histogram as a single value.
+	ErrWrongMetricType = errors.New("The series is not of the required type")
+)
+
+// HistogramData is the current state of a single histogram series.
+type HistogramData struct {
+	// Count is the total number of observations.
+	Count uint64
+
+	// Sum is the sum of all observations.
+	Sum float64
+
+	// Buckets maps each upper bound onto the cumulative count of observations
+	// less than or equal to that bound.  The implicit +Inf bucket is not included,
+	// as it is always equal to Count.
+	Buckets map[float64]uint64
+}
+
+// SummaryData is the current state of a single summary series.
+type SummaryData struct {
+	// Count is the total number of observations.
+	Count uint64
+
+	// Sum is the sum of all observations.
+	Sum float64
+
+	// Quantiles maps each objective onto its current estimate.
+	Quantiles map[float64]float64
+}
+
+// matches tests if a metric has every one of the given labels.  Labels of
+// the metric that are not in l are ignored.
+func matches(m *dto.Metric, l prometheus.Labels) bool {
+	found := 0
+	for _, lp := range m.GetLabel() {
+		if v, ok := l[lp.GetName()]; ok {
+			if v != lp.GetValue() {
+				return false
+			}
+
+			found++
+		}
+	}
+
+	return found == len(l)
+}
+
+// series collects the single series of c that matches the given labels.
+func series(c prometheus.Collector, l prometheus.Labels) (*dto.Metric, error) {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	var (
+		match *dto.Metric
+		err   error
+	)
+
+	// always drain the channel, so that the collecting goroutine finishes
+	for m := range ch {
+		if err != nil {
+			continue
+		}
+
+		var written dto.Metric
+		if writeErr := m.Write(&written); writeErr != nil {
+			err = writeErr
+		} else if matches(&written, l) {
+			if match != nil {
+				err = fmt.Errorf("%w: %v", ErrAmbiguousSeries, l)
+			}
+
+			match = &written
+		}
+	}
+
+	if err == nil && match == nil {
+		err = fmt.Errorf("%w: %v", ErrNoSuchSeries, l)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return match, nil
+}
+
+// Value reads the current value of a single counter, gauge, or untyped series.
+// The series is the one emitted by c whose labels include all the given labels,
+// which may be nil for a collector with only one series, such as a prometheus.Counter.
+// Any labels of the series that are not given, such as constant labels, are ignored.
+//
+// Unlike the prometheus testutil package, this function is intended for production
+// code, e.g. a health check that compares counters.  It never panics.  It returns
+// ErrNoSuchSeries or ErrAmbiguousSeries if exactly one series does not match, and
+// ErrWrongMetricType if the series is a histogram or summary.
+//
+// This function collects every series of c, so it should not be used in hot paths
+// with large vectors.
+func Value(c prometheus.Collector, labels prometheus.Labels) (float64, error) {
+	m, err := series(c, labels)
+	switch {
+	case err != nil:
+		return 0.0, err
+
+	case m.Counter != nil:
+		return m.GetCounter().GetValue(), nil
+
+	case m.Gauge != nil:
+		return m.GetGauge().GetValue(), nil
+
+	case m.Untyped != nil:
+		return m.GetUntyped().GetValue(), nil
+
+	default:
+		return 0.0, ErrWrongMetricType
+	}
+}
+
+// HistogramValue reads the current state of a single histogram series.  The series
+// is selected exactly as with Value, and ErrWrongMetricType is returned if that
+// series is not a histogram.
+func HistogramValue(c prometheus.Collector, labels prometheus.Labels) (HistogramData, error) {
+	m, err := series(c, labels)
+	if err == nil && m.Histogram == nil {
+		err = ErrWrongMetricType
+	}
+
+	if err != nil {
+		return HistogramData{}, err
+	}
+
+	h := m.GetHistogram()
+	hd := HistogramData{
+		Count:   h.GetSampleCount(),
+		Sum:     h.GetSampleSum(),
+		Buckets: make(map[float64]uint64, len(h.GetBucket())),
+	}
+
+	for _, b := range h.GetBucket() {
+		hd.Buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+	}
+
+	return hd, nil
+}
+
+// SummaryValue reads the current state of a single summary series.  The series
+// is selected exactly as with Value, and ErrWrongMetricType is returned if that
+// series is not a summary.
+func SummaryValue(c prometheus.Collector, labels prometheus.Labels) (SummaryData, error) {
+	m, err := series(c, labels)
+	if err == nil && m.Summary == nil {
+		err = ErrWrongMetricType
+	}
+
+	if err != nil {
+		return SummaryData{}, err
+	}
+
+	s := m.GetSummary()
+	sd := SummaryData{
+		Count:     s.GetSampleCount(),
+		Sum:       s.GetSampleSum(),
+		Quantiles: make(map[float64]float64, len(s.GetQuantile())),
+	}
+
+	for _, q := range s.GetQuantile() {
+		sd.Quantiles[q.GetQuantile()] = q.GetValue()
+	}
+
+	return sd, nil
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/suite"
+)
+
+type ValueTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ValueTestSuite) TestValue() {
+	suite.Run("Counter", func() {
+		c := prometheus.NewCounter(prometheus.CounterOpts{Name: "requests"})
+		c.Add(3.0)
+		v, err := Value(c, nil)
+		suite.NoError(err)
+		suite.Equal(3.0, v)
+	})
+
+	suite.Run("GaugeVec", func() {
+		gv := prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "connections", ConstLabels: prometheus.Labels{"app": "test"}},
+			[]string{"server", "pool"},
+		)
+
+		gv.WithLabelValues("a", "main").Set(5.0)
+		gv.WithLabelValues("b", "main").Set(7.0)
+
+		v, err := Value(gv, prometheus.Labels{"server": "b"})
+		suite.NoError(err)
+		suite.Equal(7.0, v)
+
+		v, err = Value(gv, prometheus.Labels{"server": "a", "pool": "main", "app": "test"})
+		suite.NoError(err)
+		suite.Equal(5.0, v)
+
+		_, err = Value(gv, prometheus.Labels{"pool": "main"})
+		suite.ErrorIs(err, ErrAmbiguousSeries)
+
+		_, err = Value(gv, nil)
+		suite.ErrorIs(err, ErrAmbiguousSeries)
+
+		_, err = Value(gv, prometheus.Labels{"server": "c"})
+		suite.ErrorIs(err, ErrNoSuchSeries)
+
+		_, err = Value(gv, prometheus.Labels{"nosuch": "a"})
+		suite.ErrorIs(err, ErrNoSuchSeries)
+	})
+
+	suite.Run("Untyped", func() {
+		u := prometheus.NewUntypedFunc(prometheus.UntypedOpts{Name: "value"}, func() float64 { return 12.5 })
+		v, err := Value(u, nil)
+		suite.NoError(err)
+		suite.Equal(12.5, v)
+	})
+
+	suite.Run("WrongType", func() {
+		h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "duration"})
+		_, err := Value(h, nil)
+		suite.ErrorIs(err, ErrWrongMetricType)
+	})
+}
+
+func (suite *ValueTestSuite) TestHistogramValue() {
+	hv := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "duration", Buckets: []float64{1.0, 5.0}},
+		[]string{"method"},
+	)
+
+	hv.WithLabelValues("GET").Observe(0.5)
+	hv.WithLabelValues("GET").Observe(3.0)
+	hv.WithLabelValues("GET").Observe(10.0)
+
+	hd, err := HistogramValue(hv, prometheus.Labels{"method": "GET"})
+	suite.Require().NoError(err)
+	suite.Equal(
+		HistogramData{
+			Count:   3,
+			Sum:     13.5,
+			Buckets: map[float64]uint64{1.0: 1, 5.0: 2},
+		},
+		hd,
+	)
+
+	_, err = HistogramValue(hv, prometheus.Labels{"method": "POST"})
+	suite.ErrorIs(err, ErrNoSuchSeries)
+
+	_, err = HistogramValue(prometheus.NewCounter(prometheus.CounterOpts{Name: "requests"}), nil)
+	suite.ErrorIs(err, ErrWrongMetricType)
+}
+
+func (suite *ValueTestSuite) TestSummaryValue() {
+	s := prometheus.NewSummary(prometheus.SummaryOpts{
+		Name:       "size",
+		Objectives: map[float64]float64{0.5: 0.05},
+	})
+
+	s.Observe(2.0)
+	s.Observe(4.0)
+
+	sd, err := SummaryValue(s, nil)
+	suite.Require().NoError(err)
+	suite.Equal(uint64(2), sd.Count)
+	suite.Equal(6.0, sd.Sum)
+	suite.Contains(sd.Quantiles, 0.5)
+
+	_, err = SummaryValue(prometheus.NewGauge(prometheus.GaugeOpts{Name: "g"}), nil)
+	suite.ErrorIs(err, ErrWrongMetricType)
+}
+
+func TestValue(t *testing.T) {
+	suite.Run(t, new(ValueTestSuite))
+}
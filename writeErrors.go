@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+const (
+	// SelfNamespace is the namespace of the metrics that touchstone uses to
+	// report on itself.  This namespace is not affected by Config.DefaultNamespace.
+	SelfNamespace = "touchstone"
+
+	// WriteErrorCountName is the name, within SelfNamespace, of the counter of
+	// failed metric writes.
+	WriteErrorCountName = "metric_write_error_count"
+
+	// MetricLabel is the label of WriteErrorCountName that holds the fully
+	// qualified name of the metric that could not be written.
+	MetricLabel = "metric"
+)
+
+// WriteErrorHook is a callback for failed metric writes.  The metric is the fully
+// qualified name of the metric vector, and err is the error returned by the vector.
+type WriteErrorHook func(metric string, err error)
+
+// LogWriteErrors produces a WriteErrorHook that logs each failed write as an error.
+func LogWriteErrors(l *zap.Logger) WriteErrorHook {
+	return func(metric string, err error) {
+		l.Error("Unable to write metric", zap.String("metric", metric), zap.Error(err))
+	}
+}
+
+// discard is a metric that accepts and discards all writes.  It is returned
+// in place of a child metric that could not be obtained from a vector.
+type discard struct{}
+
+func (discard) Desc() *prometheus.Desc           { return nil }
+func (discard) Write(*dto.Metric) error          { return nil }
+func (discard) Describe(chan<- *prometheus.Desc) {}
+func (discard) Collect(chan<- prometheus.Metric) {}
+func (discard) Inc()                             {}
+func (discard) Dec()                             {}
+func (discard) Add(float64)                      {}
+func (discard) Sub(float64)                      {}
+func (discard) Set(float64)                      {}
+func (discard) SetToCurrentTime()                {}
+func (discard) Observe(float64)                  {}
+
+// WriteErrors records failures to obtain child metrics from vectors at runtime,
+// e.g. because of inconsistent label names.  Code in hot paths such as middleware
+// uses WriteErrors instead of the With methods of vectors, which panic, or ad hoc
+// error handling, which tends to silently drop the failure.
+//
+// Each failure increments the WriteErrorCountName counter and invokes any hooks.
+// A nil *WriteErrors is valid, and discards failures.
+type WriteErrors struct {
+	count *prometheus.CounterVec
+	hooks []WriteErrorHook
+}
+
+// NewWriteErrors creates a WriteErrors whose counter is registered with the given factory.
+// The counter is shared:  each WriteErrors created against the same registry increments
+// the same counter.
+//
+// When f is a *Factory, the counter is registered directly with its Registerer, so its
+// name is always WriteErrorCountName within SelfNamespace regardless of the Factory's
+// subsystem or naming policy.  Any other MetricFactory creates the counter as usual.
+func NewWriteErrors(f MetricFactory, hooks ...WriteErrorHook) (*WriteErrors, error) {
+	o := prometheus.CounterOpts{
+		Namespace: SelfNamespace,
+		Name:      WriteErrorCountName,
+		Help:      "the total number of metric writes that failed at runtime",
+	}
+
+	var (
+		count *prometheus.CounterVec
+		err   error
+	)
+
+	if tf, ok := f.(*Factory); ok {
+		count = prometheus.NewCounterVec(o, []string{MetricLabel})
+		err = tf.registerer.Register(count)
+	} else {
+		count, err = f.NewCounterVec(o, MetricLabel)
+	}
+
+	if err = ExistingCollector(&count, err); err != nil {
+		return nil, err
+	}
+
+	return &WriteErrors{
+		count: count,
+		hooks: append([]WriteErrorHook{}, hooks...),
+	}, nil
+}
+
+// vecName determines the fully qualified name of a metric vector.
+func vecName(c prometheus.Collector) string {
+	if d := firstDesc(c); d != nil {
+		return descName(d)
+	}
+
+	return ""
+}
+
+// Record records a failed write to the given metric vector.
+func (we *WriteErrors) Record(vec prometheus.Collector, err error) {
+	if we == nil {
+		return
+	}
+
+	metric := vecName(vec)
+	we.count.WithLabelValues(metric).Inc()
+	for _, h := range we.hooks {
+		h(metric, err)
+	}
+}
+
+// Counter returns the child of a counter vector with the given labels.  If the
+// child cannot be obtained, the failure is recorded and a counter that discards
+// writes is returned.
+func (we *WriteErrors) Counter(vec *prometheus.CounterVec, l prometheus.Labels) prometheus.Counter {
+	c, err := vec.GetMetricWith(l)
+	if err != nil {
+		we.Record(vec, err)
+		return discard{}
+	}
+
+	return c
+}
+
+// Gauge returns the child of a gauge vector with the given labels.  If the
+// child cannot be obtained, the failure is recorded and a gauge that discards
+// writes is returned.
+func (we *WriteErrors) Gauge(vec *prometheus.GaugeVec, l prometheus.Labels) prometheus.Gauge {
+	g, err := vec.GetMetricWith(l)
+	if err != nil {
+		we.Record(vec, err)
+		return discard{}
+	}
+
+	return g
+}
+
+// Observer returns the child of a histogram or summary vector with the given labels.
+// If the child cannot be obtained, the failure is recorded and an observer that
+// discards observations is returned.
+func (we *WriteErrors) Observer(vec prometheus.ObserverVec, l prometheus.Labels) prometheus.Observer {
+	o, err := vec.GetMetricWith(l)
+	if err != nil {
+		we.Record(vec, err)
+		return discard{}
+	}
+
+	return o
+}
+
+// CounterWithLabelValues is like Counter, but accepts label values in the order of
+// the vector's label names.
+func (we *WriteErrors) CounterWithLabelValues(vec *prometheus.CounterVec, lvs ...string) prometheus.Counter {
+	c, err := vec.GetMetricWithLabelValues(lvs...)
+	if err != nil {
+		we.Record(vec, err)
+		return discard{}
+	}
+
+	return c
+}
+
+// ObserverWithLabelValues is like Observer, but accepts label values in the order of
+// the vector's label names.
+func (we *WriteErrors) ObserverWithLabelValues(vec prometheus.ObserverVec, lvs ...string) prometheus.Observer {
+	o, err := vec.GetMetricWithLabelValues(lvs...)
+	if err != nil {
+		we.Record(vec, err)
+		return discard{}
+	}
+
+	return o
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2022 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package touchstone
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type WriteErrorsTestSuite struct {
+	FxTestSuite
+}
+
+func (suite *WriteErrorsTestSuite) newFactory() *Factory {
+	_, r, err := New(Config{})
+	suite.Require().NoError(err)
+	return NewFactory(Config{DefaultNamespace: "test"}, suite.logger, r)
+}
+
+func (suite *WriteErrorsTestSuite) newWriteErrors(f *Factory, hooks ...WriteErrorHook) *WriteErrors {
+	we, err := NewWriteErrors(f, hooks...)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(we)
+	return we
+}
+
+func (suite *WriteErrorsTestSuite) writeErrors(we *WriteErrors, metric string) float64 {
+	return testutil.ToFloat64(we.count.WithLabelValues(metric))
+}
+
+func (suite *WriteErrorsTestSuite) TestNewWriteErrors() {
+	f := suite.newFactory()
+	first := suite.newWriteErrors(f)
+	second := suite.newWriteErrors(f)
+	suite.Same(first.count, second.count)
+}
+
+func (suite *WriteErrorsTestSuite) TestFixedName() {
+	g, r, err := New(Config{})
+	suite.Require().NoError(err)
+
+	f := NewFactory(
+		Config{DefaultNamespace: "test"},
+		suite.logger,
+		r,
+		WithNamingPolicy(Naming{Prefix: "org"}),
+	)
+
+	first := suite.newWriteErrors(f)
+	second := suite.newWriteErrors(f.withSubsystem("app"))
+	suite.Same(first.count, second.count)
+
+	first.Record(first.count, nil)
+	count, err := testutil.GatherAndCount(g, "touchstone_metric_write_error_count")
+	suite.NoError(err)
+	suite.Equal(1, count)
+}
+
+func (suite *WriteErrorsTestSuite) TestCounter() {
+	var (
+		f       = suite.newFactory()
+		metrics []string
+		we      = suite.newWriteErrors(f, func(metric string, err error) {
+			suite.Error(err)
+			metrics = append(metrics, metric)
+		})
+	)
+
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, "code")
+	suite.Require().NoError(err)
+
+	we.Counter(cv, prometheus.Labels{"code": "200"}).Inc()
+	suite.Equal(1.0, testutil.ToFloat64(cv.WithLabelValues("200")))
+	suite.Zero(suite.writeErrors(we, "test_requests_total"))
+
+	we.Counter(cv, prometheus.Labels{"nosuch": "200"}).Inc()
+	we.CounterWithLabelValues(cv, "200", "extra").Inc()
+	suite.Equal(2.0, suite.writeErrors(we, "test_requests_total"))
+	suite.Equal([]string{"test_requests_total", "test_requests_total"}, metrics)
+	suite.Equal(1, testutil.CollectAndCount(cv))
+
+	we.CounterWithLabelValues(cv, "500").Inc()
+	suite.Equal(1.0, testutil.ToFloat64(cv.WithLabelValues("500")))
+}
+
+func (suite *WriteErrorsTestSuite) TestGauge() {
+	f := suite.newFactory()
+	we := suite.newWriteErrors(f)
+	gv, err := f.NewGaugeVec(prometheus.GaugeOpts{Name: "connections"}, "server")
+	suite.Require().NoError(err)
+
+	we.Gauge(gv, prometheus.Labels{"server": "a"}).Set(5.0)
+	suite.Equal(5.0, testutil.ToFloat64(gv.WithLabelValues("a")))
+
+	we.Gauge(gv, prometheus.Labels{}).Set(5.0)
+	suite.Equal(1.0, suite.writeErrors(we, "test_connections"))
+}
+
+func (suite *WriteErrorsTestSuite) TestObserver() {
+	f := suite.newFactory()
+	we := suite.newWriteErrors(f)
+	ov, err := f.NewHistogramVec(prometheus.HistogramOpts{Name: "duration_ms"}, "method")
+	suite.Require().NoError(err)
+
+	we.Observer(ov, prometheus.Labels{"method": "GET"}).Observe(1.0)
+	we.ObserverWithLabelValues(ov, "GET").Observe(1.0)
+	suite.Zero(suite.writeErrors(we, "test_duration_ms"))
+
+	we.Observer(ov, prometheus.Labels{"method": "GET", "code": "200"}).Observe(1.0)
+	we.ObserverWithLabelValues(ov).Observe(1.0)
+	suite.Equal(2.0, suite.writeErrors(we, "test_duration_ms"))
+}
+
+func (suite *WriteErrorsTestSuite) TestNil() {
+	var we *WriteErrors
+	cv := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"code"})
+	suite.NotPanics(func() {
+		we.Counter(cv, prometheus.Labels{"nosuch": "value"}).Inc()
+	})
+}
+
+func (suite *WriteErrorsTestSuite) TestLogWriteErrors() {
+	core, logs := observer.New(zap.ErrorLevel)
+	f := suite.newFactory()
+	we := suite.newWriteErrors(f, LogWriteErrors(zap.New(core)))
+	cv, err := f.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, "code")
+	suite.Require().NoError(err)
+
+	we.CounterWithLabelValues(cv).Inc()
+	suite.Require().Equal(1, logs.Len())
+	suite.Equal("test_requests_total", logs.All()[0].ContextMap()["metric"])
+}
+
+func TestWriteErrors(t *testing.T) {
+	suite.Run(t, new(WriteErrorsTestSuite))
+}